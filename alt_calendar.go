@@ -0,0 +1,528 @@
+package cali
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// CalendarSystem is the system of years, months, and days used to interpret a date.
+// Yearly repeating events can follow a non-Gregorian system so that an event like
+// Passover or Eid lands on the right day every year.
+type CalendarSystem int64
+
+const (
+	// CalendarSystemGregorian is the default civil calendar
+	CalendarSystemGregorian CalendarSystem = 0
+	// CalendarSystemHebrew is the arithmetic Hebrew calendar where months are numbered
+	// from Nisan (1) and Adar II is month 13 in leap years
+	CalendarSystemHebrew CalendarSystem = 1
+	// CalendarSystemIslamic is the tabular (arithmetic) Islamic calendar which can differ
+	// from the observed calendar by a day or two
+	CalendarSystemIslamic CalendarSystem = 2
+	// CalendarSystemChinese is the astronomical Chinese lunisolar calendar calculated for
+	// Beijing where the year is the Gregorian year that the Chinese new year falls in
+	CalendarSystemChinese CalendarSystem = 3
+)
+
+// ValidCalendarSystem returns true if the calendar system is one of the pre-defined systems from this library
+func ValidCalendarSystem(s CalendarSystem) bool {
+	switch s {
+	case CalendarSystemGregorian, CalendarSystemHebrew, CalendarSystemIslamic, CalendarSystemChinese:
+		return true
+	default:
+		return false
+	}
+}
+
+// AltDate is a day in a calendar system other than the Gregorian calendar
+type AltDate struct {
+	System CalendarSystem `json:"system"`
+	Year   int            `json:"year"`
+	Month  int            `json:"month"`
+	Day    int            `json:"day"`
+	// IsLeapMonth is only used by the Chinese calendar for the intercalary month
+	// that repeats the month number before it
+	IsLeapMonth bool `json:"isLeapMonth"`
+}
+
+var (
+	hebrewMonthNames  = []string{"", "Nisan", "Iyyar", "Sivan", "Tammuz", "Av", "Elul", "Tishrei", "Marheshvan", "Kislev", "Tevet", "Shevat", "Adar", "Adar II"}
+	islamicMonthNames = []string{"", "Muharram", "Safar", "Rabi I", "Rabi II", "Jumada I", "Jumada II", "Rajab", "Sha'ban", "Ramadan", "Shawwal", "Dhu al-Qi'dah", "Dhu al-Hijjah"}
+)
+
+// MonthName is the display name of the month in its calendar system
+func (a AltDate) MonthName() string {
+	switch a.System {
+	case CalendarSystemHebrew:
+		if a.Month == 12 && hebrewLeapYear(a.Year) {
+			return "Adar I"
+		}
+		if a.Month > 0 && a.Month < len(hebrewMonthNames) {
+			return hebrewMonthNames[a.Month]
+		}
+	case CalendarSystemIslamic:
+		if a.Month > 0 && a.Month < len(islamicMonthNames) {
+			return islamicMonthNames[a.Month]
+		}
+	case CalendarSystemChinese:
+		if a.IsLeapMonth {
+			return fmt.Sprintf("Leap Month %d", a.Month)
+		}
+		return fmt.Sprintf("Month %d", a.Month)
+	}
+	return time.Month(a.Month).String()
+}
+
+// String displays the date like "15 Nisan 5784" or "1 Shawwal 1445"
+func (a AltDate) String() string {
+	return fmt.Sprintf("%d %s %d", a.Day, a.MonthName(), a.Year)
+}
+
+// ToAltDate converts the day of the time value into a day in the calendar system
+func ToAltDate(system CalendarSystem, t time.Time) (AltDate, error) {
+	date := fixedFromTime(t)
+	switch system {
+	case CalendarSystemGregorian:
+		return AltDate{System: system, Year: t.Year(), Month: int(t.Month()), Day: t.Day()}, nil
+	case CalendarSystemHebrew:
+		return hebrewFromFixed(date), nil
+	case CalendarSystemIslamic:
+		return islamicFromFixed(date), nil
+	case CalendarSystemChinese:
+		return chineseFromFixed(date), nil
+	}
+	return AltDate{}, ErrorInvalidCalendarSystem
+}
+
+// FromAltDate converts the day in a calendar system into a UTC time.Time at midnight
+func FromAltDate(a AltDate) (time.Time, error) {
+	if a.Month < 1 || a.Day < 1 || a.Day > 30 {
+		return time.Time{}, ErrorInvalidAltDate
+	}
+	switch a.System {
+	case CalendarSystemGregorian:
+		t := time.Date(a.Year, time.Month(a.Month), a.Day, 0, 0, 0, 0, time.UTC)
+		if t.Day() != a.Day {
+			return time.Time{}, ErrorInvalidAltDate
+		}
+		return t, nil
+	case CalendarSystemHebrew:
+		if a.Month > hebrewLastMonthOfYear(a.Year) || a.Day > hebrewLastDayOfMonth(a.Month, a.Year) {
+			return time.Time{}, ErrorInvalidAltDate
+		}
+		return timeFromFixed(fixedFromHebrew(a.Year, a.Month, a.Day)), nil
+	case CalendarSystemIslamic:
+		if a.Month > 12 || a.Day > islamicLastDayOfMonth(a.Month, a.Year) {
+			return time.Time{}, ErrorInvalidAltDate
+		}
+		return timeFromFixed(fixedFromIslamic(a.Year, a.Month, a.Day)), nil
+	case CalendarSystemChinese:
+		if a.Month > 12 {
+			return time.Time{}, ErrorInvalidAltDate
+		}
+		date, ok := fixedFromChinese(a.Year, a.Month, a.IsLeapMonth, a.Day)
+		if !ok {
+			return time.Time{}, ErrorInvalidAltDate
+		}
+		return timeFromFixed(date), nil
+	}
+	return time.Time{}, ErrorInvalidCalendarSystem
+}
+
+// AltStartDay converts the StartDay of the event into a day in the calendar system for display
+func (e Event) AltStartDay(system CalendarSystem) (AltDate, error) {
+	startDay, err := time.Parse(time.DateOnly, e.StartDay)
+	if err != nil {
+		return AltDate{}, ErrorInvalidStartDay
+	}
+	return ToAltDate(system, startDay)
+}
+
+// addAltYears moves the day forward a number of years in the calendar system and keeps
+// the same month and day. If that day does not exist in the new year (like a leap month
+// or the 30th of a short month) then the closest earlier day is used instead.
+func addAltYears(system CalendarSystem, t time.Time, years int) (time.Time, error) {
+	a, err := ToAltDate(system, t)
+	if err != nil {
+		return t, err
+	}
+	a.Year += years
+	if system == CalendarSystemHebrew && a.Month == 13 && !hebrewLeapYear(a.Year) {
+		a.Month = 12
+	}
+	a.IsLeapMonth = false
+	for ; a.Day > 0; a.Day-- {
+		next, err := FromAltDate(a)
+		if err == nil {
+			return next, nil
+		}
+	}
+	return t, ErrorInvalidAltDate
+}
+
+// ///////////////////////
+// Fixed day numbers
+// ///////////////////////
+
+// fixedEpochUnix is the fixed day number (where 0001-01-01 is day 1) of 1970-01-01
+const fixedEpochUnix = 719163
+
+func fixedFromTime(t time.Time) int64 {
+	d := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return floorDiv(d.Unix(), 86400) + fixedEpochUnix
+}
+
+func timeFromFixed(date int64) time.Time {
+	return time.Unix((date-fixedEpochUnix)*86400, 0).UTC()
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func mod(a, b int64) int64 {
+	return a - b*floorDiv(a, b)
+}
+
+// ///////////////////////
+// Hebrew
+// ///////////////////////
+
+const hebrewEpoch = -1373427
+
+func hebrewLeapYear(y int) bool {
+	return mod(int64(7*y+1), 19) < 7
+}
+
+func hebrewLastMonthOfYear(y int) int {
+	if hebrewLeapYear(y) {
+		return 13
+	}
+	return 12
+}
+
+func hebrewElapsedDays(y int) int64 {
+	monthsElapsed := floorDiv(235*int64(y)-234, 19)
+	partsElapsed := 12084 + 13753*monthsElapsed
+	days := 29*monthsElapsed + floorDiv(partsElapsed, 25920)
+	if mod(3*(days+1), 7) < 3 {
+		return days + 1
+	}
+	return days
+}
+
+func hebrewYearLengthCorrection(y int) int64 {
+	ny0 := hebrewElapsedDays(y - 1)
+	ny1 := hebrewElapsedDays(y)
+	ny2 := hebrewElapsedDays(y + 1)
+	if ny2-ny1 == 356 {
+		return 2
+	}
+	if ny1-ny0 == 382 {
+		return 1
+	}
+	return 0
+}
+
+func hebrewNewYear(y int) int64 {
+	return hebrewEpoch + hebrewElapsedDays(y) + hebrewYearLengthCorrection(y)
+}
+
+func hebrewDaysInYear(y int) int64 {
+	return hebrewNewYear(y+1) - hebrewNewYear(y)
+}
+
+func hebrewLastDayOfMonth(m, y int) int {
+	switch {
+	case m == 2 || m == 4 || m == 6 || m == 10 || m == 13:
+		return 29
+	case m == 12 && !hebrewLeapYear(y):
+		return 29
+	case m == 8 && hebrewDaysInYear(y)%10 != 5:
+		// Marheshvan is only long in years with 355 or 385 days
+		return 29
+	case m == 9 && hebrewDaysInYear(y)%10 == 3:
+		// Kislev is short in years with 353 or 383 days
+		return 29
+	}
+	return 30
+}
+
+func fixedFromHebrew(y, m, d int) int64 {
+	date := hebrewNewYear(y) + int64(d) - 1
+	if m < 7 {
+		for i := 7; i <= hebrewLastMonthOfYear(y); i++ {
+			date += int64(hebrewLastDayOfMonth(i, y))
+		}
+		for i := 1; i < m; i++ {
+			date += int64(hebrewLastDayOfMonth(i, y))
+		}
+	} else {
+		for i := 7; i < m; i++ {
+			date += int64(hebrewLastDayOfMonth(i, y))
+		}
+	}
+	return date
+}
+
+func hebrewFromFixed(date int64) AltDate {
+	approx := int(math.Floor(float64(date-hebrewEpoch)/(35975351.0/98496.0))) + 1
+	year := approx - 1
+	for hebrewNewYear(year+1) <= date {
+		year++
+	}
+	month := 1
+	if date < fixedFromHebrew(year, 1, 1) {
+		month = 7
+	}
+	for date > fixedFromHebrew(year, month, hebrewLastDayOfMonth(month, year)) {
+		month++
+	}
+	day := int(date-fixedFromHebrew(year, month, 1)) + 1
+	return AltDate{System: CalendarSystemHebrew, Year: year, Month: month, Day: day}
+}
+
+// ///////////////////////
+// Islamic
+// ///////////////////////
+
+const islamicEpoch = 227015
+
+func islamicLeapYear(y int) bool {
+	return mod(int64(14+11*y), 30) < 11
+}
+
+func islamicLastDayOfMonth(m, y int) int {
+	if m%2 == 1 || (m == 12 && islamicLeapYear(y)) {
+		return 30
+	}
+	return 29
+}
+
+func fixedFromIslamic(y, m, d int) int64 {
+	return int64(d) + 29*int64(m-1) + floorDiv(int64(6*m-1), 11) + int64(y-1)*354 + floorDiv(int64(3+11*y), 30) + islamicEpoch - 1
+}
+
+func islamicFromFixed(date int64) AltDate {
+	year := int(floorDiv(30*(date-islamicEpoch)+10646, 10631))
+	priorDays := date - fixedFromIslamic(year, 1, 1)
+	month := int(floorDiv(11*priorDays+330, 325))
+	day := int(date-fixedFromIslamic(year, month, 1)) + 1
+	return AltDate{System: CalendarSystemIslamic, Year: year, Month: month, Day: day}
+}
+
+// ///////////////////////
+// Chinese
+// ///////////////////////
+
+const (
+	meanSynodicMonth = 29.530588861
+	meanTropicalYear = 365.242189
+	// chinaOffset is the fraction of a day that Beijing is ahead of UTC
+	chinaOffset = 8.0 / 24.0
+)
+
+// moments are fractional fixed days in universal time
+func julianFromMoment(t float64) float64 {
+	return t + 1721424.5
+}
+
+func momentFromJulian(jd float64) float64 {
+	return jd - 1721424.5
+}
+
+func sinDeg(d float64) float64 {
+	return math.Sin(d * math.Pi / 180)
+}
+
+func modDeg(d float64) float64 {
+	d = math.Mod(d, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
+
+// solarLongitude is the apparent longitude of the sun in degrees at the moment
+func solarLongitude(t float64) float64 {
+	T := (julianFromMoment(t) - 2451545.0) / 36525
+	L0 := 280.46646 + 36000.76983*T + 0.0003032*T*T
+	M := 357.52911 + 35999.05029*T - 0.0001537*T*T
+	C := (1.914602-0.004817*T-0.000014*T*T)*sinDeg(M) + (0.019993-0.000101*T)*sinDeg(2*M) + 0.000289*sinDeg(3*M)
+	omega := 125.04 - 1934.136*T
+	return modDeg(L0 + C - 0.00569 - 0.00478*sinDeg(omega))
+}
+
+// nthNewMoon is the moment of the kth new moon after the new moon of January 2000
+func nthNewMoon(k float64) float64 {
+	T := k / 1236.85
+	jde := 2451550.09766 + meanSynodicMonth*k + 0.00015437*T*T - 0.000000150*T*T*T + 0.00000000073*T*T*T*T
+	E := 1 - 0.002516*T - 0.0000074*T*T
+	M := 2.5534 + 29.10535670*k - 0.0000014*T*T - 0.00000011*T*T*T
+	Mp := 201.5643 + 385.81693528*k + 0.0107582*T*T + 0.00001238*T*T*T - 0.000000058*T*T*T*T
+	F := 160.7108 + 390.67050284*k - 0.0016118*T*T - 0.00000227*T*T*T + 0.000000011*T*T*T*T
+	omega := 124.7746 - 1.56375588*k + 0.0020672*T*T + 0.00000215*T*T*T
+	correction := -0.40720*sinDeg(Mp) +
+		0.17241*E*sinDeg(M) +
+		0.01608*sinDeg(2*Mp) +
+		0.01039*sinDeg(2*F) +
+		0.00739*E*sinDeg(Mp-M) -
+		0.00514*E*sinDeg(Mp+M) +
+		0.00208*E*E*sinDeg(2*M) -
+		0.00111*sinDeg(Mp-2*F) -
+		0.00057*sinDeg(Mp+2*F) +
+		0.00056*E*sinDeg(2*Mp+M) -
+		0.00042*sinDeg(3*Mp) +
+		0.00042*E*sinDeg(M+2*F) +
+		0.00038*E*sinDeg(M-2*F) -
+		0.00024*E*sinDeg(2*Mp-M) -
+		0.00017*sinDeg(omega) -
+		0.00007*sinDeg(Mp+2*M) +
+		0.00004*sinDeg(2*Mp-2*F) +
+		0.00004*sinDeg(3*M) +
+		0.00003*sinDeg(Mp+M-2*F) +
+		0.00003*sinDeg(2*Mp+2*F) -
+		0.00003*sinDeg(Mp+M+2*F) +
+		0.00003*sinDeg(Mp-M+2*F) -
+		0.00002*sinDeg(Mp-M-2*F) -
+		0.00002*sinDeg(3*Mp+M) +
+		0.00002*sinDeg(4*Mp)
+	return momentFromJulian(jde + correction)
+}
+
+// newMoonAtOrAfter is the moment of the first new moon at or after the moment
+func newMoonAtOrAfter(t float64) float64 {
+	k := math.Round((julianFromMoment(t)-2451550.09766)/meanSynodicMonth) - 1
+	for nthNewMoon(k) < t {
+		k++
+	}
+	return nthNewMoon(k)
+}
+
+// newMoonBefore is the moment of the last new moon before the moment
+func newMoonBefore(t float64) float64 {
+	k := math.Round((julianFromMoment(t)-2451550.09766)/meanSynodicMonth) + 1
+	for nthNewMoon(k) >= t {
+		k--
+	}
+	return nthNewMoon(k)
+}
+
+// midnightInChina is the universal moment of the start of the day in Beijing
+func midnightInChina(date int64) float64 {
+	return float64(date) - chinaOffset
+}
+
+func chineseNewMoonOnOrAfter(date int64) int64 {
+	return int64(math.Floor(newMoonAtOrAfter(midnightInChina(date)) + chinaOffset))
+}
+
+func chineseNewMoonBefore(date int64) int64 {
+	return int64(math.Floor(newMoonBefore(midnightInChina(date)) + chinaOffset))
+}
+
+// chineseMajorSolarTerm is the index (1-12) of the last major solar term at the start of the day
+func chineseMajorSolarTerm(date int64) int64 {
+	s := solarLongitude(midnightInChina(date))
+	return mod(2+int64(math.Floor(s/30))-1, 12) + 1
+}
+
+func chineseNoMajorSolarTerm(date int64) bool {
+	return chineseMajorSolarTerm(date) == chineseMajorSolarTerm(chineseNewMoonOnOrAfter(date+1))
+}
+
+func chinesePriorLeapMonth(mPrime, m int64) bool {
+	for m >= mPrime {
+		if chineseNoMajorSolarTerm(m) {
+			return true
+		}
+		m = chineseNewMoonBefore(m)
+	}
+	return false
+}
+
+// estimatePriorSolarLongitude estimates the last moment before t that the sun was at the longitude
+func estimatePriorSolarLongitude(lambda, t float64) float64 {
+	rate := meanTropicalYear / 360
+	tau := t - rate*modDeg(solarLongitude(t)-lambda)
+	delta := modDeg(solarLongitude(tau)-lambda+180) - 180
+	return math.Min(t, tau-rate*delta)
+}
+
+func chineseWinterSolsticeOnOrBefore(date int64) int64 {
+	approx := estimatePriorSolarLongitude(270, midnightInChina(date+1))
+	d := int64(math.Floor(approx)) - 1
+	for solarLongitude(midnightInChina(d+1)) <= 270 {
+		d++
+	}
+	return d
+}
+
+func chineseNewYearInSui(date int64) int64 {
+	s1 := chineseWinterSolsticeOnOrBefore(date)
+	s2 := chineseWinterSolsticeOnOrBefore(s1 + 370)
+	m12 := chineseNewMoonOnOrAfter(s1 + 1)
+	m13 := chineseNewMoonOnOrAfter(m12 + 1)
+	nextM11 := chineseNewMoonBefore(s2 + 1)
+	if math.Round(float64(nextM11-m12)/meanSynodicMonth) == 12 && (chineseNoMajorSolarTerm(m12) || chineseNoMajorSolarTerm(m13)) {
+		return chineseNewMoonOnOrAfter(m13 + 1)
+	}
+	return m13
+}
+
+func chineseNewYearOnOrBefore(date int64) int64 {
+	newYear := chineseNewYearInSui(date)
+	if date >= newYear {
+		return newYear
+	}
+	return chineseNewYearInSui(date - 180)
+}
+
+func chineseFromFixed(date int64) AltDate {
+	s1 := chineseWinterSolsticeOnOrBefore(date)
+	s2 := chineseWinterSolsticeOnOrBefore(s1 + 370)
+	m12 := chineseNewMoonOnOrAfter(s1 + 1)
+	nextM11 := chineseNewMoonBefore(s2 + 1)
+	m := chineseNewMoonBefore(date + 1)
+	leapYear := math.Round(float64(nextM11-m12)/meanSynodicMonth) == 12
+
+	month := int64(math.Round(float64(m-m12) / meanSynodicMonth))
+	if leapYear && chinesePriorLeapMonth(m12, m) {
+		month--
+	}
+	month = mod(month-1, 12) + 1
+	leapMonth := leapYear && chineseNoMajorSolarTerm(m) && !chinesePriorLeapMonth(m12, chineseNewMoonBefore(m))
+
+	return AltDate{
+		System:      CalendarSystemChinese,
+		Year:        timeFromFixed(chineseNewYearOnOrBefore(date)).Year(),
+		Month:       int(month),
+		Day:         int(date-m) + 1,
+		IsLeapMonth: leapMonth,
+	}
+}
+
+func fixedFromChinese(year, month int, leap bool, day int) (int64, bool) {
+	midYear := fixedFromTime(time.Date(year, time.July, 1, 0, 0, 0, 0, time.UTC))
+	newYear := chineseNewYearOnOrBefore(midYear)
+	p := chineseNewMoonOnOrAfter(newYear + int64(month-1)*29)
+	d := chineseFromFixed(p)
+	priorNewMoon := p
+	if d.Month != month || d.IsLeapMonth != leap {
+		priorNewMoon = chineseNewMoonOnOrAfter(p + 1)
+	}
+	check := chineseFromFixed(priorNewMoon)
+	if check.Month != month || check.IsLeapMonth != leap {
+		return 0, false
+	}
+	date := priorNewMoon + int64(day) - 1
+	if chineseNewMoonBefore(date+1) != priorNewMoon {
+		return 0, false
+	}
+	return date, true
+}
@@ -0,0 +1,134 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAltDate(t *testing.T) {
+	testCases := []struct {
+		name string
+		day  string
+		out  AltDate
+	}{
+		{
+			name: "passover",
+			day:  "2024-04-23",
+			out:  AltDate{System: CalendarSystemHebrew, Year: 5784, Month: 1, Day: 15},
+		},
+		{
+			name: "rosh hashanah",
+			day:  "2024-10-03",
+			out:  AltDate{System: CalendarSystemHebrew, Year: 5785, Month: 7, Day: 1},
+		},
+		{
+			name: "eid al-fitr",
+			day:  "2024-04-10",
+			out:  AltDate{System: CalendarSystemIslamic, Year: 1445, Month: 10, Day: 1},
+		},
+		{
+			name: "islamic epoch",
+			day:  "0622-07-19",
+			out:  AltDate{System: CalendarSystemIslamic, Year: 1, Month: 1, Day: 1},
+		},
+		{
+			name: "chinese new year",
+			day:  "2024-02-10",
+			out:  AltDate{System: CalendarSystemChinese, Year: 2024, Month: 1, Day: 1},
+		},
+		{
+			name: "mid-autumn festival",
+			day:  "2024-09-17",
+			out:  AltDate{System: CalendarSystemChinese, Year: 2024, Month: 8, Day: 15},
+		},
+		{
+			name: "chinese leap month",
+			day:  "2020-05-23",
+			out:  AltDate{System: CalendarSystemChinese, Year: 2020, Month: 4, Day: 1, IsLeapMonth: true},
+		},
+		{
+			name: "chinese leap eleventh month",
+			day:  "2033-12-22",
+			out:  AltDate{System: CalendarSystemChinese, Year: 2033, Month: 11, Day: 1, IsLeapMonth: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			day, err := time.Parse(time.DateOnly, tc.day)
+			require.NoError(t, err)
+
+			out, err := ToAltDate(tc.out.System, day)
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, out)
+
+			back, err := FromAltDate(out)
+			require.NoError(t, err)
+			assert.Equal(t, tc.day, back.Format(time.DateOnly))
+		})
+	}
+}
+
+func TestAltDateString(t *testing.T) {
+	assert.Equal(t, "15 Nisan 5784", AltDate{System: CalendarSystemHebrew, Year: 5784, Month: 1, Day: 15}.String())
+	assert.Equal(t, "14 Adar I 5784", AltDate{System: CalendarSystemHebrew, Year: 5784, Month: 12, Day: 14}.String())
+	assert.Equal(t, "1 Shawwal 1445", AltDate{System: CalendarSystemIslamic, Year: 1445, Month: 10, Day: 1}.String())
+
+	_, err := FromAltDate(AltDate{System: CalendarSystemHebrew, Year: 5785, Month: 13, Day: 1})
+	assert.Equal(t, ErrorInvalidAltDate, err)
+}
+
+func TestGenerateRepeatEventsInOtherCalendarSystems(t *testing.T) {
+	testCases := []struct {
+		name   string
+		system CalendarSystem
+		day    string
+		out    []string
+	}{
+		{
+			name:   "passover",
+			system: CalendarSystemHebrew,
+			day:    "2024-04-23",
+			out:    []string{"2024-04-23", "2025-04-13", "2026-04-02"},
+		},
+		{
+			name:   "eid al-fitr",
+			system: CalendarSystemIslamic,
+			day:    "2024-04-10",
+			out:    []string{"2024-04-10", "2025-03-31", "2026-03-20"},
+		},
+		{
+			name:   "chinese new year",
+			system: CalendarSystemChinese,
+			day:    "2024-02-10",
+			out:    []string{"2024-02-10", "2025-01-29", "2026-02-17"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			events, err := GenerateRepeatEvents(Event{
+				IsRepeating: true,
+				IsAllDay:    true,
+				StartDay:    tc.day,
+				EndDay:      tc.day,
+				Repeat:      &Repeat{RepeatType: RepeatTypeYearly, RepeatOccurrences: 3, CalendarSystem: tc.system},
+			})
+			require.NoError(t, err)
+			var out []string
+			for _, e := range events {
+				out = append(out, e.StartDay)
+			}
+			assert.Equal(t, tc.out, out)
+		})
+	}
+}
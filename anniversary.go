@@ -0,0 +1,44 @@
+package cali
+
+import (
+	"time"
+)
+
+// LeapDayPolicy decides which day a yearly repeat that starts on February 29 lands on in
+// the years that aren't leap years
+type LeapDayPolicy int64
+
+const (
+	// LeapDayMarch1 moves the occurrence to March 1, the same as time.AddDate
+	LeapDayMarch1 LeapDayPolicy = 0
+	// LeapDayFebruary28 moves the occurrence to February 28, which keeps it in the same month
+	LeapDayFebruary28 LeapDayPolicy = 1
+)
+
+// ValidLeapDayPolicy returns true if the policy is one of the pre-defined policies from this library
+func ValidLeapDayPolicy(p LeapDayPolicy) bool {
+	return p == LeapDayMarch1 || p == LeapDayFebruary28
+}
+
+// addYears adds the years to the Gregorian day, moving February 29 with the policy when the
+// year after isn't a leap year
+func addYears(t time.Time, years int, policy LeapDayPolicy) time.Time {
+	next := t.AddDate(years, 0, 0)
+	if policy == LeapDayFebruary28 && t.Month() == time.February && t.Day() == 29 && next.Month() == time.March {
+		return next.AddDate(0, 0, -1)
+	}
+	return next
+}
+
+// anniversaryYears gets the years since the first occurrence of an anniversary (see
+// Repeat.Anniversary) for the occurrence that is the index after the event
+func (e Event) anniversaryYears(index int) *int64 {
+	if e.Repeat == nil || !e.Repeat.Anniversary {
+		return nil
+	}
+	years := int64(index)
+	if e.AnniversaryYears != nil {
+		years += *e.AnniversaryYears
+	}
+	return &years
+}
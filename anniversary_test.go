@@ -0,0 +1,73 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnniversaryLeapDay(t *testing.T) {
+	tests := []struct {
+		name    string
+		leapDay LeapDayPolicy
+		days    []string
+	}{
+		{name: "march 1", leapDay: LeapDayMarch1, days: []string{"2008-02-29", "2009-03-01", "2010-03-01", "2011-03-01", "2012-02-29"}},
+		{name: "february 28", leapDay: LeapDayFebruary28, days: []string{"2008-02-29", "2009-02-28", "2010-02-28", "2011-02-28", "2012-02-29"}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			e := Event{Title: "Birthday", Zone: "UTC", StartDay: "2008-02-29", EndDay: "2008-02-29", IsAllDay: true, IsRepeating: true,
+				Repeat: &Repeat{RepeatType: RepeatTypeYearly, RepeatOccurrences: 5, Anniversary: true, LeapDay: tc.leapDay}}
+			events, err := GenerateRepeatEvents(e)
+			require.NoError(t, err)
+			require.Len(t, events, len(tc.days))
+			for i, event := range events {
+				assert.Equal(t, tc.days[i], event.StartDay)
+				assert.Equal(t, tc.days[i], event.EndDay)
+				require.NotNil(t, event.AnniversaryYears)
+				assert.Equal(t, int64(i), *event.AnniversaryYears)
+			}
+		})
+	}
+}
+
+func TestAnniversary(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, count, err := c.Create(Event{Title: "Wedding", Zone: "UTC", StartDay: "2008-06-14", EndDay: "2008-06-14", IsAllDay: true, IsRepeating: true,
+		Repeat: &Repeat{RepeatType: RepeatTypeYearly, RepeatOccurrences: 3, Anniversary: true}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for i, event := range events {
+		require.NotNil(t, event.AnniversaryYears)
+		assert.Equal(t, int64(i), *event.AnniversaryYears, event.StartDay)
+	}
+
+	added, err := c.ExtendSeries(e.Id, Repeat{RepeatType: RepeatTypeYearly, RepeatOccurrences: 5, Anniversary: true})
+	require.NoError(t, err)
+	require.Len(t, added, 2)
+	assert.Equal(t, "2012-06-14", added[1].StartDay)
+	assert.Equal(t, int64(4), *added[1].AnniversaryYears)
+
+	_, _, err = c.Create(Event{Title: "Weekly", Zone: "UTC", StartDay: "2008-06-14", EndDay: "2008-06-14", IsAllDay: true, IsRepeating: true,
+		Repeat: &Repeat{RepeatType: RepeatTypeMonthly, RepeatOccurrences: 3, Anniversary: true}})
+	assert.Equal(t, ErrorInvalidAnniversary, err)
+	_, _, err = c.Create(Event{Title: "Timed", Zone: "UTC", StartDay: "2008-06-14", StartTime: "09:00", EndDay: "2008-06-14", EndTime: "10:00", IsRepeating: true,
+		Repeat: &Repeat{RepeatType: RepeatTypeYearly, RepeatOccurrences: 3, Anniversary: true}})
+	assert.Equal(t, ErrorInvalidAnniversary, err)
+	_, _, err = c.Create(Event{Title: "Leap", Zone: "UTC", StartDay: "2008-02-29", EndDay: "2008-02-29", IsAllDay: true, IsRepeating: true,
+		Repeat: &Repeat{RepeatType: RepeatTypeYearly, RepeatOccurrences: 3, LeapDay: 7}})
+	assert.Equal(t, ErrorInvalidLeapDayPolicy, err)
+
+	plain, _, err := c.Create(Event{Title: "Review", Zone: "UTC", StartDay: "2008-06-14", EndDay: "2008-06-14", IsAllDay: true, IsRepeating: true,
+		Repeat: &Repeat{RepeatType: RepeatTypeYearly, RepeatOccurrences: 2}})
+	require.NoError(t, err)
+	assert.Nil(t, plain.AnniversaryYears)
+}
@@ -0,0 +1,81 @@
+package cali
+
+// WithApproval makes new events of any of the event types start out with StatusPendingApproval,
+// so they don't show up on the calendar until a user with PermissionApprove approves them.
+// This is for shared calendars (like company events) where new events are moderated.
+func WithApproval(eventTypes ...EventType) CalendarOption {
+	return func(c *Calendar) {
+		if c.approvalTypes == nil {
+			c.approvalTypes = map[EventType]bool{}
+		}
+		for _, t := range eventTypes {
+			c.approvalTypes[t] = true
+		}
+	}
+}
+
+// pendingApproval sets the status of an active event to StatusPendingApproval if its event type needs approval
+func (c *Calendar) pendingApproval(e Event) Event {
+	if c.approvalTypes[e.EventType] && e.Status == StatusActive {
+		e.Status = StatusPendingApproval
+	}
+	return e
+}
+
+// Approve sets the status of events that are pending approval to StatusActive. The user must have
+// an invite (or series invite) to the event with PermissionApprove, unless an admin is acting on
+// behalf of the user (see AsAdminOnBehalfOf).
+func (c *Calendar) Approve(eventId int64, userId int64, editType RepeatEditType) error {
+	return c.moderate(eventId, userId, StatusActive, editType)
+}
+
+// Reject sets the status of events that are pending approval to StatusRemoved. The user must have
+// an invite (or series invite) to the event with PermissionApprove.
+func (c *Calendar) Reject(eventId int64, userId int64, editType RepeatEditType) error {
+	return c.moderate(eventId, userId, StatusRemoved, editType)
+}
+
+// moderate checks that the user can approve the event and changes the status of the events
+// that are still pending approval, the other events of a repeating series are left alone
+func (c *Calendar) moderate(eventId int64, userId int64, status Status, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		e, err := c.dataStore.Get(eventId)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrorEventNotFound
+		}
+		if e.Status != StatusPendingApproval {
+			return ErrorNotPendingApproval
+		}
+		if err := c.authorizeEvent(OperationApprove, e, &userId); err != nil {
+			return err
+		}
+		invite, err := c.GetInvitation(eventId, userId)
+		if err != nil {
+			return err
+		}
+		if (invite == nil || invite.Status == InviteStatusRevoked || !invite.Permission.HasFlag(PermissionApprove)) && !c.isAdminFor(userId) {
+			return ErrorApprovalNotAllowed
+		}
+
+		editedId := eventId
+		return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
+			e, err := c.dataStore.Get(eventId)
+			if err != nil {
+				return err
+			}
+			if e == nil || e.Status != StatusPendingApproval {
+				return nil
+			}
+			if skip, err := c.skipTransition(OperationApprove, editedId, e, status); skip || err != nil {
+				return err
+			}
+			if err := c.dataStore.SetStatus(eventId, status); err != nil {
+				return err
+			}
+			return c.publish(ChangeTypeUpdated, eventId, nil)
+		})
+	})
+}
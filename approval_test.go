@@ -0,0 +1,48 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproval(t *testing.T) {
+	const companyEvent EventType = 7
+	c := NewCalendar(&InMemoryDataStore{}, WithApproval(companyEvent))
+
+	personal, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusActive, personal.Status)
+
+	e, count, err := c.Create(Event{OwnerId: 1, EventType: companyEvent, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, StatusPendingApproval, e.Status)
+
+	events, err := c.Query(Query{Statuses: []Status{StatusActive, StatusCanceled}})
+	require.NoError(t, err)
+	require.Len(t, events, 1, "events pending approval are not shown on the calendar")
+	assert.Equal(t, personal.Id, events[0].Id)
+
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionRead|PermissionApprove, RepeatEditTypeAll))
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionEditor, RepeatEditTypeAll))
+	assert.Equal(t, ErrorApprovalNotAllowed, c.Approve(e.Id, 1, RepeatEditTypeAll), "the owner can't approve their own event")
+	assert.Equal(t, ErrorApprovalNotAllowed, c.Approve(e.Id, 3, RepeatEditTypeAll))
+	assert.Equal(t, ErrorNotPendingApproval, c.Approve(personal.Id, 2, RepeatEditTypeThis))
+
+	events, err = c.Query(Query{ParentIds: []int64{e.Id}, Statuses: []Status{StatusPendingApproval}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.NoError(t, c.Reject(events[2].Id, 2, RepeatEditTypeThis))
+	require.NoError(t, c.Approve(e.Id, 2, RepeatEditTypeAll))
+
+	events, err = c.Query(Query{ParentIds: []int64{e.Id}, Statuses: []Status{StatusActive, StatusRemoved, StatusPendingApproval}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, StatusActive, events[0].Status)
+	assert.Equal(t, StatusActive, events[1].Status)
+	assert.Equal(t, StatusRemoved, events[2].Status, "rejected events are not approved with the rest of the series")
+	assert.Equal(t, ErrorNotPendingApproval, c.Reject(e.Id, 2, RepeatEditTypeThis))
+}
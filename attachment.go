@@ -0,0 +1,258 @@
+package cali
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Kenoshen/cali/ical"
+)
+
+// AttachmentStatus is how far an attachment is through validation
+type AttachmentStatus int64
+
+const (
+	// AttachmentStatusQuarantined is for an attachment that is waiting for the scan of the
+	// calendar's AttachmentScanner and shouldn't be served yet
+	AttachmentStatusQuarantined AttachmentStatus = 0
+	// AttachmentStatusClean is for an attachment that passed validation and can be served
+	AttachmentStatusClean AttachmentStatus = 1
+	// AttachmentStatusRejected is for an attachment that failed the scan
+	AttachmentStatusRejected AttachmentStatus = -1
+)
+
+// Attachment is a file on an event. The calendar only keeps the metadata of the file, and
+// the content is kept by the application at the Url. SeriesICal and ExportICal link to the
+// clean attachments of each event with an ATTACH line.
+type Attachment struct {
+	// Id is the unique id for this attachment
+	Id int64 `json:"id"`
+	// EventId is the event that the file is attached to
+	EventId int64 `json:"eventId"`
+	// Name is the file name, like "agenda.pdf"
+	Name string `json:"name"`
+	// MimeType is the type of the content, like "application/pdf"
+	MimeType string `json:"mimeType"`
+	// Size is the length of the content in bytes
+	Size int64 `json:"size"`
+	// Url is where the application keeps the content
+	Url string `json:"url"`
+	// Status is how far the attachment is through validation
+	Status AttachmentStatus `json:"status"`
+	// RejectReason is why the scan rejected the attachment
+	RejectReason *string `json:"rejectReason"`
+	// Created is a UTC timestamp for when the attachment was added
+	Created time.Time `json:"created"`
+	// Updated is a UTC timestamp for when the status of the attachment changed last
+	Updated time.Time `json:"updated"`
+}
+
+// AttachmentStore is an optional interface for a data store that can save attachments
+type AttachmentStore interface {
+	// AddAttachment saves the attachment with a new Id and handles setting the Created and Updated fields
+	AddAttachment(a Attachment) (*Attachment, error)
+	// GetAttachment retrieves the attachment, or nil if there is no attachment with the id
+	GetAttachment(id int64) (*Attachment, error)
+	// GetAttachments retrieves all of the attachments of the event
+	GetAttachments(eventId int64) ([]*Attachment, error)
+	// SetAttachmentStatus updates the status and reject reason of the attachment and the Updated field
+	SetAttachmentStatus(id int64, status AttachmentStatus, reason *string) error
+}
+
+// AttachmentValidator checks an attachment before it is added, and returns an error if it
+// can't be added
+type AttachmentValidator func(a Attachment) error
+
+// MaxAttachmentSize rejects attachments that are bigger than the size in bytes
+func MaxAttachmentSize(size int64) AttachmentValidator {
+	return func(a Attachment) error {
+		if a.Size > size {
+			return ErrorAttachmentTooLarge
+		}
+		return nil
+	}
+}
+
+// AllowedMimeTypes rejects attachments with other mime types, where a type like "image/*"
+// allows every subtype. The parameters of the mime type (like "; charset=utf-8") are ignored.
+func AllowedMimeTypes(mimeTypes ...string) AttachmentValidator {
+	return func(a Attachment) error {
+		mimeType, _, _ := strings.Cut(a.MimeType, ";")
+		mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+		for _, allowed := range mimeTypes {
+			allowed = strings.ToLower(allowed)
+			if allowed == mimeType || (strings.HasSuffix(allowed, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(allowed, "*"))) {
+				return nil
+			}
+		}
+		return ErrorAttachmentTypeNotAllowed
+	}
+}
+
+// AttachmentScanner starts a scan (like a virus scan) of a new attachment, which stays
+// quarantined until the scanner calls CompleteAttachmentScan with the result. Scan should
+// return right away, and an error from it rejects the attachment.
+type AttachmentScanner interface {
+	Scan(a Attachment) error
+}
+
+// WithAttachmentValidators runs the validators in order when an attachment is added
+func WithAttachmentValidators(validators ...AttachmentValidator) CalendarOption {
+	return func(c *Calendar) {
+		c.attachmentValidators = append(c.attachmentValidators, validators...)
+	}
+}
+
+// WithAttachmentScanner quarantines new attachments until the scanner finishes its scan.
+// Without a scanner, attachments that pass the validators are clean right away.
+func WithAttachmentScanner(scanner AttachmentScanner) CalendarOption {
+	return func(c *Calendar) {
+		c.attachmentScanner = scanner
+	}
+}
+
+// AddAttachment runs the validators of the calendar on the attachment, saves it, and starts
+// the scan of the AttachmentScanner if the calendar has one. The attachment is returned with
+// its status, which is AttachmentStatusQuarantined until the scan is done.
+func (c *Calendar) AddAttachment(eventId int64, a Attachment) (*Attachment, error) {
+	var added *Attachment
+	err := c.outboxTx(func(c *Calendar) error {
+		var err error
+		added, err = c.addAttachment(eventId, a)
+		return err
+	})
+	return added, err
+}
+
+// addAttachment is AddAttachment in the outbox transaction (see outboxTx)
+func (c *Calendar) addAttachment(eventId int64, a Attachment) (*Attachment, error) {
+	store, ok := capability[AttachmentStore](c.dataStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	if err := c.authorize(OperationUpdate, eventId, nil); err != nil {
+		return nil, err
+	}
+	a.EventId = eventId
+	a.Status = AttachmentStatusQuarantined
+	a.RejectReason = nil
+	for _, validate := range c.attachmentValidators {
+		if err := validate(a); err != nil {
+			return nil, err
+		}
+	}
+	if c.attachmentScanner == nil {
+		a.Status = AttachmentStatusClean
+	}
+	added, err := store.AddAttachment(a)
+	if err != nil {
+		return nil, err
+	}
+	if c.attachmentScanner != nil {
+		if err := c.attachmentScanner.Scan(*added); err != nil {
+			reason := err.Error()
+			if err := store.SetAttachmentStatus(added.Id, AttachmentStatusRejected, &reason); err != nil {
+				return nil, err
+			}
+			return store.GetAttachment(added.Id)
+		}
+		return added, nil
+	}
+	return added, c.publish(ChangeTypeUpdated, eventId, nil)
+}
+
+// CompleteAttachmentScan is called by the AttachmentScanner with the result of its scan,
+// where a nil error makes the attachment clean and an error rejects it with the error as the
+// reason. Only quarantined attachments can be completed.
+func (c *Calendar) CompleteAttachmentScan(attachmentId int64, scanErr error) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[AttachmentStore](c.dataStore)
+		if !ok {
+			return ErrorAttachmentsNotSupported
+		}
+		a, err := store.GetAttachment(attachmentId)
+		if err != nil {
+			return err
+		}
+		if a == nil {
+			return ErrorAttachmentNotFound
+		}
+		if a.Status != AttachmentStatusQuarantined {
+			return ErrorAttachmentNotQuarantined
+		}
+		if scanErr != nil {
+			reason := scanErr.Error()
+			return store.SetAttachmentStatus(attachmentId, AttachmentStatusRejected, &reason)
+		}
+		if err := store.SetAttachmentStatus(attachmentId, AttachmentStatusClean, nil); err != nil {
+			return err
+		}
+		return c.publish(ChangeTypeUpdated, a.EventId, nil)
+	})
+}
+
+// GetAttachment gets an attachment in any status, so that its status can be checked
+func (c *Calendar) GetAttachment(attachmentId int64) (*Attachment, error) {
+	store, ok := capability[AttachmentStore](c.dataStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	a, err := store.GetAttachment(attachmentId)
+	if err != nil || a == nil {
+		return a, err
+	}
+	if err := c.authorize(OperationRead, a.EventId, nil); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetAttachments gets the clean attachments of the event, which leaves out the quarantined
+// and rejected attachments
+func (c *Calendar) GetAttachments(eventId int64) ([]*Attachment, error) {
+	store, ok := capability[AttachmentStore](c.dataStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	if err := c.authorize(OperationRead, eventId, nil); err != nil {
+		return nil, err
+	}
+	attachments, err := store.GetAttachments(eventId)
+	if err != nil {
+		return nil, err
+	}
+	var clean []*Attachment
+	for _, a := range attachments {
+		if a.Status == AttachmentStatusClean {
+			clean = append(clean, a)
+		}
+	}
+	return clean, nil
+}
+
+// iCalAttachments gets the ATTACH lines of each event by id, which link to the Url of each
+// clean attachment with its MimeType as the FMTTYPE, or nil if the data store doesn't have
+// attachments
+func (c *Calendar) iCalAttachments(events []*Event) (map[int64][]string, error) {
+	store, ok := capability[AttachmentStore](c.dataStore)
+	if !ok {
+		return nil, nil
+	}
+	attachments := map[int64][]string{}
+	for _, e := range events {
+		eventAttachments, err := store.GetAttachments(e.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range eventAttachments {
+			if a.Status != AttachmentStatusClean || a.Url == "" {
+				continue
+			}
+			var params []ical.Param
+			if a.MimeType != "" {
+				params = append(params, ical.Param{Name: "FMTTYPE", Value: a.MimeType})
+			}
+			attachments[e.Id] = append(attachments[e.Id], ical.Property("ATTACH", a.Url, params...))
+		}
+	}
+	return attachments, nil
+}
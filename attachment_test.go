@@ -0,0 +1,155 @@
+package cali
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Kenoshen/cali/ical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testScanner keeps the attachments that it was asked to scan
+type testScanner struct {
+	scanned []Attachment
+	err     error
+}
+
+func (s *testScanner) Scan(a Attachment) error {
+	s.scanned = append(s.scanned, a)
+	return s.err
+}
+
+func TestAttachmentValidators(t *testing.T) {
+	tests := []struct {
+		name      string
+		validator AttachmentValidator
+		a         Attachment
+		expected  error
+	}{
+		{name: "small enough", validator: MaxAttachmentSize(10), a: Attachment{Size: 10}},
+		{name: "too large", validator: MaxAttachmentSize(10), a: Attachment{Size: 11}, expected: ErrorAttachmentTooLarge},
+		{name: "allowed type", validator: AllowedMimeTypes("application/pdf"), a: Attachment{MimeType: "Application/PDF"}},
+		{name: "parameters", validator: AllowedMimeTypes("text/plain"), a: Attachment{MimeType: "text/plain; charset=utf-8"}},
+		{name: "wildcard", validator: AllowedMimeTypes("image/*"), a: Attachment{MimeType: "image/png"}},
+		{name: "other type", validator: AllowedMimeTypes("image/*"), a: Attachment{MimeType: "application/x-msdownload"}, expected: ErrorAttachmentTypeNotAllowed},
+		{name: "prefix only", validator: AllowedMimeTypes("image/*"), a: Attachment{MimeType: "imagex/png"}, expected: ErrorAttachmentTypeNotAllowed},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			assert.Equal(t, tc.expected, tc.validator(tc.a))
+		})
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			scanner := &testScanner{}
+			c := NewCalendar(tc.store, WithAttachmentScanner(scanner),
+				WithAttachmentValidators(MaxAttachmentSize(1<<20), AllowedMimeTypes("application/pdf", "image/*")))
+			e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+			require.NoError(t, err)
+
+			_, err = c.AddAttachment(e.Id, Attachment{Name: "setup.exe", MimeType: "application/x-msdownload", Size: 100})
+			assert.Equal(t, ErrorAttachmentTypeNotAllowed, err)
+			assert.Empty(t, scanner.scanned, "invalid attachments aren't saved or scanned")
+
+			agenda, err := c.AddAttachment(e.Id, Attachment{Name: "agenda.pdf", MimeType: "application/pdf", Size: 100, Url: "https://files/agenda.pdf"})
+			require.NoError(t, err)
+			assert.Equal(t, AttachmentStatusQuarantined, agenda.Status)
+			photo, err := c.AddAttachment(e.Id, Attachment{Name: "photo.png", MimeType: "image/png", Size: 200})
+			require.NoError(t, err)
+			require.Len(t, scanner.scanned, 2)
+			assert.Equal(t, agenda.Id, scanner.scanned[0].Id)
+
+			attachments, err := c.GetAttachments(e.Id)
+			require.NoError(t, err)
+			assert.Empty(t, attachments, "quarantined attachments are left out")
+
+			require.NoError(t, c.CompleteAttachmentScan(agenda.Id, nil))
+			require.NoError(t, c.CompleteAttachmentScan(photo.Id, errors.New("EICAR test signature")))
+			assert.Equal(t, ErrorAttachmentNotQuarantined, c.CompleteAttachmentScan(agenda.Id, nil))
+
+			attachments, err = c.GetAttachments(e.Id)
+			require.NoError(t, err)
+			require.Len(t, attachments, 1)
+			assert.Equal(t, "agenda.pdf", attachments[0].Name)
+			rejected, err := c.GetAttachment(photo.Id)
+			require.NoError(t, err)
+			assert.Equal(t, AttachmentStatusRejected, rejected.Status)
+			assert.Equal(t, "EICAR test signature", *rejected.RejectReason)
+		})
+	}
+}
+
+func TestAttachmentsWithoutScanner(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	a, err := c.AddAttachment(e.Id, Attachment{Name: "notes.txt", MimeType: "text/plain", Size: 5})
+	require.NoError(t, err)
+	assert.Equal(t, AttachmentStatusClean, a.Status)
+
+	scanner := &testScanner{err: errors.New("scanner is down")}
+	c = NewCalendar(&InMemoryDataStore{}, WithAttachmentScanner(scanner))
+	e, _, err = c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	a, err = c.AddAttachment(e.Id, Attachment{Name: "notes.txt", MimeType: "text/plain", Size: 5})
+	require.NoError(t, err)
+	assert.Equal(t, AttachmentStatusRejected, a.Status)
+
+	_, err = NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}).AddAttachment(e.Id, Attachment{})
+	assert.Equal(t, ErrorAttachmentsNotSupported, err)
+}
+
+func TestICalAttachments(t *testing.T) {
+	scanner := &testScanner{}
+	c := NewCalendar(&InMemoryDataStore{}, WithAttachmentScanner(scanner))
+	e, _, err := c.Create(Event{OwnerId: 1, Title: "Review", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00"})
+	require.NoError(t, err)
+	agenda, err := c.AddAttachment(e.Id, Attachment{Name: "agenda.pdf", MimeType: "application/pdf", Size: 5, Url: "https://files.example.com/agenda.pdf"})
+	require.NoError(t, err)
+	require.NoError(t, c.CompleteAttachmentScan(agenda.Id, nil))
+	_, err = c.AddAttachment(e.Id, Attachment{Name: "virus.exe", MimeType: "application/octet-stream", Size: 5, Url: "https://files.example.com/virus.exe"})
+	require.NoError(t, err)
+
+	data, err := c.ExportICal(Query{EventIds: []int64{e.Id}})
+	require.NoError(t, err)
+	lines := ical.Lines(data)
+	assert.Contains(t, lines, "ATTACH;FMTTYPE=application/pdf:https://files.example.com/agenda.pdf")
+	assert.Len(t, filterPrefix(lines, "ATTACH"), 1, "quarantined attachments aren't exported")
+
+	series, _, err := c.Create(Event{OwnerId: 1, Title: "Standup", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+	require.NoError(t, err)
+	notes, err := c.AddAttachment(series.Id, Attachment{Name: "notes.txt", Size: 5, Url: "https://files.example.com/notes.txt"})
+	require.NoError(t, err)
+	require.NoError(t, c.CompleteAttachmentScan(notes.Id, nil))
+	data, err = c.SeriesICal(series.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ATTACH:https://files.example.com/notes.txt"}, filterPrefix(ical.Lines(data), "ATTACH"))
+
+	// the exports don't have attachments if the data store can't keep them
+	data, err = NewCalendar(struct{ DataStore }{c.dataStore}).ExportICal(Query{EventIds: []int64{e.Id}})
+	require.NoError(t, err)
+	assert.NotContains(t, data, "ATTACH")
+}
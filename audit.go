@@ -0,0 +1,92 @@
+package cali
+
+import (
+	"time"
+)
+
+// Actor is who made a change through the calendar
+type Actor struct {
+	// UserId is the user that the change was made as
+	UserId int64 `json:"userId"`
+	// AdminId is the admin that made the change on behalf of the user, or nil if the user made it themselves
+	AdminId *int64 `json:"adminId"`
+}
+
+// AuditEntry is a record of a single change (see Change) and who made it
+type AuditEntry struct {
+	// Id is unique for every entry
+	Id int64 `json:"id"`
+	// Type is the kind of change
+	Type ChangeType `json:"type"`
+	// EventId is the event that was changed
+	EventId int64 `json:"eventId"`
+	// UserId is the invited user for ChangeTypeInvite changes
+	UserId *int64 `json:"userId"`
+	// Actor is who made the change, or nil if the calendar wasn't scoped to an actor
+	Actor *Actor `json:"actor"`
+	// Time is when the change was made
+	Time time.Time `json:"time"`
+	// Event is a copy of the event after the change was made, which is the version of the
+	// event that RestoreSnapshot can roll back to
+	Event *Event `json:"event"`
+}
+
+// AuditStore is an optional interface for a data store that keeps an audit log of changes
+type AuditStore interface {
+	// AddAuditEntry saves a new entry and handles setting the Id field
+	AddAuditEntry(entry AuditEntry) (*AuditEntry, error)
+	// GetAuditEntries retrieves the entries of the event, oldest first
+	GetAuditEntries(eventId int64) ([]*AuditEntry, error)
+}
+
+// WithAuditLog writes an entry to the audit log of the data store for every change made
+// through the calendar (see Watch). The data store must implement AuditStore.
+func WithAuditLog() CalendarOption {
+	return func(c *Calendar) {
+		c.auditLog = true
+	}
+}
+
+// AuditLog gets the audit entries of the event, oldest first
+func (c *Calendar) AuditLog(eventId int64) ([]*AuditEntry, error) {
+	store, ok := capability[AuditStore](c.dataStore)
+	if !ok {
+		return nil, ErrorAuditLogNotSupported
+	}
+	return store.GetAuditEntries(eventId)
+}
+
+// AsAdminOnBehalfOf creates a copy of the calendar for support tooling where the admin acts
+// on behalf of the user. Every change made through the copy records both of them as the
+// actor of the change (in the audit log and the change feed), and the admin can make
+// changes that need the user's own permission, like approving an event or dismissing the
+// user's reminders, even if the user doesn't have it.
+//
+//	err := c.AsAdminOnBehalfOf(adminId, userId).Approve(eventId, userId, RepeatEditTypeThis)
+func (c *Calendar) AsAdminOnBehalfOf(adminId int64, userId int64) *Calendar {
+	scoped := *c
+	scoped.actor = &Actor{UserId: userId, AdminId: &adminId}
+	return &scoped
+}
+
+// isAdminFor returns true if the calendar is scoped to an admin acting on behalf of the user
+func (c *Calendar) isAdminFor(userId int64) bool {
+	return c.actor != nil && c.actor.AdminId != nil && c.actor.UserId == userId
+}
+
+// addAuditEntry writes the change to the audit log
+func (c *Calendar) addAuditEntry(change Change) error {
+	store, ok := capability[AuditStore](c.dataStore)
+	if !ok {
+		return ErrorAuditLogNotSupported
+	}
+	_, err := store.AddAuditEntry(AuditEntry{
+		Type:    change.Type,
+		EventId: change.EventId,
+		UserId:  change.UserId,
+		Actor:   change.Actor,
+		Time:    change.Time,
+		Event:   change.Event,
+	})
+	return err
+}
@@ -0,0 +1,62 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsAdminOnBehalfOf(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithAuditLog(), WithApproval(7), WithNotificationSender(&testSender{}))
+	changes, stop := c.Watch(10)
+	defer stop()
+
+	e, _, err := c.Create(Event{OwnerId: 1, EventType: 7, Title: "Town hall", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+
+	// user 2 can't approve the event, but an admin acting for them can
+	assert.Equal(t, ErrorApprovalNotAllowed, c.Approve(e.Id, 2, RepeatEditTypeThis))
+	admin := c.AsAdminOnBehalfOf(100, 2)
+	assert.Equal(t, ErrorApprovalNotAllowed, admin.Approve(e.Id, 3, RepeatEditTypeThis), "only for the user they act for")
+	require.NoError(t, admin.Approve(e.Id, 2, RepeatEditTypeThis))
+	require.NoError(t, admin.UpdateTitle(e.Id, "All hands", RepeatEditTypeThis))
+
+	log, err := c.AuditLog(e.Id)
+	require.NoError(t, err)
+	require.Len(t, log, 4)
+	assert.Equal(t, ChangeTypeCreated, log[0].Type)
+	assert.Nil(t, log[0].Actor)
+	assert.Equal(t, ChangeTypeInvite, log[1].Type)
+	assert.Equal(t, int64(2), *log[1].UserId)
+	for _, entry := range log[2:] {
+		assert.Equal(t, ChangeTypeUpdated, entry.Type)
+		require.NotNil(t, entry.Actor)
+		assert.Equal(t, int64(2), entry.Actor.UserId)
+		assert.Equal(t, int64(100), *entry.Actor.AdminId)
+	}
+
+	// the change feed has the actor too
+	var last Change
+	for i := 0; i < 4; i++ {
+		last = <-changes
+	}
+	require.NotNil(t, last.Actor)
+	assert.Equal(t, int64(100), *last.Actor.AdminId)
+
+	// an admin can dismiss the reminders of the user they act for
+	r, err := c.AddReminder(e.Id, 2, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, ErrorReminderNotFound, c.AsAdminOnBehalfOf(100, 3).DismissReminder(r.Id, 3))
+	require.NoError(t, admin.DismissReminder(r.Id, 100))
+}
+
+func TestAuditLogNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}, WithAuditLog())
+	_, _, err := c.Create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true})
+	assert.Equal(t, ErrorAuditLogNotSupported, err)
+	_, err = c.AuditLog(1)
+	assert.Equal(t, ErrorAuditLogNotSupported, err)
+}
@@ -0,0 +1,174 @@
+package cali
+
+import (
+	"errors"
+)
+
+// Operation is the kind of calendar operation that is authorized (see Authorizer)
+type Operation string
+
+const (
+	// OperationRead is getting or querying an event
+	OperationRead Operation = "read"
+	// OperationCreate is creating an event, where the event of the request doesn't have an Id yet
+	OperationCreate Operation = "create"
+	// OperationUpdate is changing the fields of an event
+	OperationUpdate Operation = "update"
+	// OperationCancel is canceling an event
+	OperationCancel Operation = "cancel"
+	// OperationRemove is removing an event
+	OperationRemove Operation = "remove"
+	// OperationInvite is inviting a user to an event or changing the permission of their invitation
+	OperationInvite Operation = "invite"
+	// OperationRespond is a change to a user's own invitation, like accepting it or setting a private note
+	OperationRespond Operation = "respond"
+	// OperationApprove is approving or rejecting an event that is pending approval
+	OperationApprove Operation = "approve"
+	// OperationRestore is setting a removed, canceled, or abandoned event back to active
+	OperationRestore Operation = "restore"
+)
+
+// AuthorizationRequest is everything an Authorizer knows about an operation
+type AuthorizationRequest struct {
+	// Actor is who is doing the operation, or nil if the calendar wasn't scoped to an actor (see AsUser)
+	Actor *Actor
+	// Operation is what the actor is doing
+	Operation Operation
+	// Event is the event of the operation, for edits to repeating events this is the event whose id was passed in
+	Event *Event
+	// Invite is the actor's invitation (or series invitation) to the event, or nil if they don't have one
+	Invite *Invite
+	// UserId is the user whose invitation is changed for OperationInvite, OperationRespond, and
+	// OperationApprove, and nil for the other operations
+	UserId *int64
+}
+
+// Authorizer decides if an operation is allowed, so that an organization can plug in its own
+// policy system (like OPA or RBAC) instead of relying solely on the invite permissions.
+// Authorize returns nil to allow the operation, or an error that wraps ErrorNotAuthorized to
+// deny it. Any other error fails the operation.
+type Authorizer interface {
+	Authorize(r AuthorizationRequest) error
+}
+
+// AuthorizerFunc lets a function be used as an Authorizer
+type AuthorizerFunc func(r AuthorizationRequest) error
+
+// Authorize calls the function
+func (f AuthorizerFunc) Authorize(r AuthorizationRequest) error {
+	return f(r)
+}
+
+// WithAuthorizer consults the authorizer before every operation of the calendar. Edits are
+// denied with the error of the authorizer, and events that are denied for OperationRead are
+// left out of query results. Use AsUser (or AsAdminOnBehalfOf) to set the actor of the operations.
+func WithAuthorizer(authorizer Authorizer) CalendarOption {
+	return func(c *Calendar) {
+		c.authorizer = authorizer
+	}
+}
+
+// AsUser creates a copy of the calendar where every operation is done as the user
+//
+//	events, err := c.AsUser(userId).Query(q)
+func (c *Calendar) AsUser(userId int64) *Calendar {
+	scoped := *c
+	scoped.actor = &Actor{UserId: userId}
+	return &scoped
+}
+
+// PermissionAuthorizer is an Authorizer that uses the invite permissions: the owner of the
+// event and admins can do everything, users can respond to their own invitations, and other
+// operations need the matching permission on an invitation that isn't revoked. Public events
+// can be read by anyone and calendars without an actor are not restricted.
+type PermissionAuthorizer struct{}
+
+var operationPermissions = map[Operation]Permission{
+	OperationRead:    PermissionRead,
+	OperationUpdate:  PermissionModify,
+	OperationCancel:  PermissionCancel,
+	OperationRemove:  PermissionDelete,
+	OperationRestore: PermissionDelete,
+	OperationInvite:  PermissionInvite,
+	OperationApprove: PermissionApprove,
+}
+
+// Authorize checks the invite permissions of the actor
+func (PermissionAuthorizer) Authorize(r AuthorizationRequest) error {
+	if r.Actor == nil || r.Actor.AdminId != nil {
+		return nil
+	}
+	if r.Operation != OperationApprove && r.Event.OwnerId == r.Actor.UserId {
+		return nil
+	}
+	switch r.Operation {
+	case OperationCreate:
+		return ErrorNotAuthorized
+	case OperationRespond:
+		if r.UserId != nil && *r.UserId == r.Actor.UserId {
+			return nil
+		}
+		return ErrorNotAuthorized
+	case OperationRead:
+		if r.Event.Visibility == VisibilityPublic {
+			return nil
+		}
+	}
+	if r.Invite == nil || r.Invite.Status == InviteStatusRevoked || !r.Invite.Permission.HasFlag(operationPermissions[r.Operation]) {
+		return ErrorNotAuthorized
+	}
+	return nil
+}
+
+// authorize gets the event and checks that the operation is allowed
+func (c *Calendar) authorize(op Operation, eventId int64, userId *int64) error {
+	if c.authorizer == nil {
+		return nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return ErrorEventNotFound
+	}
+	return c.authorizeEvent(op, e, userId)
+}
+
+// authorizeEvent asks the authorizer if the actor can do the operation on the event
+func (c *Calendar) authorizeEvent(op Operation, e *Event, userId *int64) error {
+	if c.authorizer == nil {
+		return nil
+	}
+	r := AuthorizationRequest{Actor: c.actor, Operation: op, Event: e, UserId: userId}
+	if c.actor != nil && e.Id > 0 {
+		invite, err := c.GetInvitation(e.Id, c.actor.UserId)
+		if err != nil {
+			return err
+		}
+		r.Invite = invite
+	}
+	return c.authorizer.Authorize(r)
+}
+
+// authorizedEvents leaves out the events that the actor isn't authorized to read, holidays
+// are shown to every user so they are never left out
+func (c *Calendar) authorizedEvents(events []*Event) ([]*Event, error) {
+	if c.authorizer == nil {
+		return events, nil
+	}
+	result := make([]*Event, 0, len(events))
+	for _, e := range events {
+		if e.Id >= 0 {
+			err := c.authorizeEvent(OperationRead, e, nil)
+			if errors.Is(err, ErrorNotAuthorized) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package cali
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionAuthorizer(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithAuthorizer(PermissionAuthorizer{}))
+	owner := c.AsUser(1)
+	e, _, err := owner.Create(Event{OwnerId: 1, Title: "Planning", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00"})
+	require.NoError(t, err)
+	_, _, err = owner.Create(Event{OwnerId: 2, Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true})
+	assert.Equal(t, ErrorNotAuthorized, err, "can't create events for other users")
+	require.NoError(t, owner.InviteUser(e.Id, 2, PermissionViewer, RepeatEditTypeThis))
+
+	viewer := c.AsUser(2)
+	got, err := viewer.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Planning", got.Title)
+	assert.Equal(t, ErrorNotAuthorized, viewer.UpdateTitle(e.Id, "Review", RepeatEditTypeThis))
+	assert.Equal(t, ErrorNotAuthorized, viewer.InviteUser(e.Id, 3, PermissionViewer, RepeatEditTypeThis))
+	assert.Equal(t, ErrorNotAuthorized, viewer.Cancel(e.Id, RepeatEditTypeThis))
+	assert.Equal(t, ErrorNotAuthorized, viewer.AcceptInvitation(e.Id, 1, RepeatEditTypeThis), "can only respond for themselves")
+	require.NoError(t, viewer.AcceptInvitation(e.Id, 2, RepeatEditTypeThis))
+
+	stranger := c.AsUser(3)
+	_, err = stranger.Get(e.Id)
+	assert.Equal(t, ErrorNotAuthorized, err)
+	events, err := stranger.Query(Query{})
+	require.NoError(t, err)
+	assert.Empty(t, events, "events that can't be read are left out")
+	events, err = viewer.Query(Query{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+
+	require.NoError(t, owner.UpdateInvitationPermission(e.Id, 2, PermissionEditor, RepeatEditTypeThis))
+	require.NoError(t, viewer.UpdateTitle(e.Id, "Review", RepeatEditTypeThis))
+	require.NoError(t, c.AsAdminOnBehalfOf(100, 3).Remove(e.Id, RepeatEditTypeThis))
+
+	// calendars without an actor aren't restricted
+	_, err = c.Get(e.Id)
+	require.NoError(t, err)
+}
+
+func TestAuthorizer(t *testing.T) {
+	var requests []AuthorizationRequest
+	failure := fmt.Errorf("policy service is down")
+	c := NewCalendar(&InMemoryDataStore{}, WithAuthorizer(AuthorizerFunc(func(r AuthorizationRequest) error {
+		requests = append(requests, r)
+		if r.Event.Title == "Down" {
+			return failure
+		}
+		if r.Operation == OperationCancel {
+			return fmt.Errorf("%w: events are never canceled", ErrorNotAuthorized)
+		}
+		return nil
+	})))
+	e, _, err := c.AsUser(1).Create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true})
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, OperationCreate, requests[0].Operation)
+	assert.Equal(t, int64(1), requests[0].Actor.UserId)
+	assert.Nil(t, requests[0].Invite)
+
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	require.Len(t, requests, 2)
+	assert.Equal(t, OperationInvite, requests[1].Operation)
+	assert.Nil(t, requests[1].Actor)
+	assert.Equal(t, int64(2), *requests[1].UserId)
+
+	require.NoError(t, c.AsUser(2).DeclineInvitation(e.Id, 2, RepeatEditTypeThis))
+	require.Len(t, requests, 3)
+	assert.Equal(t, OperationRespond, requests[2].Operation)
+	require.NotNil(t, requests[2].Invite)
+	assert.Equal(t, int64(2), requests[2].Invite.UserId)
+
+	err = c.Cancel(e.Id, RepeatEditTypeThis)
+	assert.ErrorIs(t, err, ErrorNotAuthorized)
+	got, err := c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusActive, got.Status)
+
+	// errors that don't deny the operation fail queries
+	require.NoError(t, c.UpdateTitle(e.Id, "Down", RepeatEditTypeThis))
+	_, err = c.Query(Query{})
+	assert.Equal(t, failure, err)
+}
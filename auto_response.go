@@ -0,0 +1,180 @@
+package cali
+
+import (
+	"time"
+)
+
+// AutoResponsePolicy is a set of rules for a user (or a resource like a room)
+// that is used to respond to invitations automatically as soon as the user
+// is invited to an event
+type AutoResponsePolicy struct {
+	// UserId is the user (or resource) that this policy responds for
+	UserId int64 `json:"userId"`
+	// AcceptWhenFree confirms the invite if the user has no other active
+	// events that overlap with the event
+	AcceptWhenFree bool `json:"acceptWhenFree"`
+	// DeclineOverlaps declines the invite if the user has any other active
+	// events that overlap with the event
+	DeclineOverlaps bool `json:"declineOverlaps"`
+	// DeclineOutsideWorkingHours declines the invite if the event is not
+	// completely within the WorkingHours (all day events are never declined)
+	DeclineOutsideWorkingHours bool `json:"declineOutsideWorkingHours"`
+	// WorkingHours is required if DeclineOutsideWorkingHours is true
+	WorkingHours *WorkingHours `json:"workingHours"`
+}
+
+// WorkingHours is the window of time during the week that a user is available
+type WorkingHours struct {
+	// Zone must be a valid time.Location name like "UTC" or "America/New_York"
+	Zone string `json:"zone"`
+	// DayOfWeek is a bitmask of the working days of the week (SMTWTFS)
+	DayOfWeek DayOfWeek `json:"dayOfWeek"`
+	// StartTime is the HH:MM value when the working day starts
+	StartTime string `json:"startTime"`
+	// EndTime is the HH:MM value when the working day ends
+	EndTime string `json:"endTime"`
+}
+
+// Contains returns true if the event starts and ends on the same working day
+// and is between the start and end time of the working hours
+func (w WorkingHours) Contains(e Event) bool {
+	if e.IsAllDay {
+		return true
+	}
+	loc, err := time.LoadLocation(w.Zone)
+	if err != nil {
+		return false
+	}
+	start, end, err := e.zonedSpan()
+	if err != nil {
+		return false
+	}
+	start = start.In(loc)
+	end = end.In(loc)
+	if start.Format(time.DateOnly) != end.Format(time.DateOnly) {
+		return false
+	}
+	if !w.DayOfWeek.HasFlag(dayOfWeekFromWeekday(start.Weekday())) {
+		return false
+	}
+	return start.Format(TimeFormat) >= w.StartTime && end.Format(TimeFormat) <= w.EndTime
+}
+
+// zonedSpan gets the start and end of the event in the event's zone
+func (e Event) zonedSpan() (time.Time, time.Time, error) {
+	loc, err := time.LoadLocation(e.Zone)
+	if err != nil {
+		return time.Time{}, time.Time{}, ErrorInvalidZone
+	}
+	start, end, err := e.span()
+	if err != nil {
+		return start, end, err
+	}
+	start = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, loc)
+	end = time.Date(end.Year(), end.Month(), end.Day(), end.Hour(), end.Minute(), end.Second(), 0, loc)
+	return start, end, nil
+}
+
+// SetAutoResponsePolicy saves the auto response policy for the user on the policy
+func (c *Calendar) SetAutoResponsePolicy(p AutoResponsePolicy) error {
+	store, ok := capability[AutoResponseStore](c.dataStore)
+	if !ok {
+		return ErrorAutoResponseNotSupported
+	}
+	if err := ValidateAutoResponsePolicy(p); err != nil {
+		return err
+	}
+	return store.SetAutoResponsePolicy(p)
+}
+
+// GetAutoResponsePolicy grabs the auto response policy for the user or nil if there is none
+func (c *Calendar) GetAutoResponsePolicy(userId int64) (*AutoResponsePolicy, error) {
+	store, ok := capability[AutoResponseStore](c.dataStore)
+	if !ok {
+		return nil, ErrorAutoResponseNotSupported
+	}
+	return store.GetAutoResponsePolicy(userId)
+}
+
+// applyAutoResponse evaluates the user's auto response policy (if there is one)
+// against the event and updates the user's invite status to match
+func (c *Calendar) applyAutoResponse(eventId int64, userId int64) error {
+	store, ok := capability[AutoResponseStore](c.dataStore)
+	if !ok {
+		return nil
+	}
+	p, err := store.GetAutoResponsePolicy(userId)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return nil
+	}
+	e, err := c.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return ErrorEventNotFound
+	}
+
+	status, err := c.evaluateAutoResponse(*p, *e)
+	if err != nil {
+		return err
+	}
+	if status == InviteStatusPending {
+		return nil
+	}
+	if err := c.overrideSeriesInvite(eventId, userId); err != nil {
+		return err
+	}
+	return c.dataStore.SetInviteStatus(eventId, userId, status)
+}
+
+// evaluateAutoResponse decides what the invite status should be for the
+// event given the policy. Declines take priority over accepts.
+func (c *Calendar) evaluateAutoResponse(p AutoResponsePolicy, e Event) (InviteStatus, error) {
+	if p.DeclineOutsideWorkingHours && p.WorkingHours != nil && !p.WorkingHours.Contains(e) {
+		return InviteStatusDeclined, nil
+	}
+	if !p.DeclineOverlaps && !p.AcceptWhenFree {
+		return InviteStatusPending, nil
+	}
+
+	conflicts, err := c.conflictingEvents(p.UserId, e)
+	if err != nil {
+		return InviteStatusPending, err
+	}
+	if p.DeclineOverlaps && len(conflicts) > 0 {
+		return InviteStatusDeclined, nil
+	}
+	if p.AcceptWhenFree && len(conflicts) == 0 {
+		return InviteStatusConfirmed, nil
+	}
+	return InviteStatusPending, nil
+}
+
+// conflictingEvents finds the other active events for the user that overlap with the event
+func (c *Calendar) conflictingEvents(userId int64, e Event) ([]*Event, error) {
+	start, end, err := e.span()
+	if err != nil {
+		return nil, err
+	}
+	events, err := c.dataStore.Query(Query{
+		Start:    &start,
+		End:      &end,
+		UserIds:  []int64{userId},
+		Statuses: []Status{StatusActive},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []*Event
+	for _, other := range events {
+		if other.Id == e.Id || !e.Overlaps(*other) {
+			continue
+		}
+		result = append(result, other)
+	}
+	return result, nil
+}
@@ -0,0 +1,116 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoResponse(t *testing.T) {
+	workingHours := &WorkingHours{
+		Zone:      den,
+		DayOfWeek: DayOfWeekMonday | DayOfWeekTuesday | DayOfWeekWednesday | DayOfWeekThursday | DayOfWeekFriday,
+		StartTime: "09:00",
+		EndTime:   "17:00",
+	}
+	testCases := []struct {
+		name      string
+		policy    *AutoResponsePolicy
+		startTime string
+		endTime   string
+		zone      string
+		out       InviteStatus
+	}{
+		{
+			name:      "no policy",
+			startTime: "09:30",
+			endTime:   "10:30",
+			out:       InviteStatusPending,
+		},
+		{
+			name:      "accept when free",
+			policy:    &AutoResponsePolicy{AcceptWhenFree: true},
+			startTime: "10:00",
+			endTime:   "11:00",
+			out:       InviteStatusConfirmed,
+		},
+		{
+			name:      "accept when free but busy",
+			policy:    &AutoResponsePolicy{AcceptWhenFree: true},
+			startTime: "09:30",
+			endTime:   "10:30",
+			out:       InviteStatusPending,
+		},
+		{
+			name:      "decline overlaps",
+			policy:    &AutoResponsePolicy{AcceptWhenFree: true, DeclineOverlaps: true},
+			startTime: "09:30",
+			endTime:   "10:30",
+			out:       InviteStatusDeclined,
+		},
+		{
+			name:      "decline outside working hours",
+			policy:    &AutoResponsePolicy{AcceptWhenFree: true, DeclineOutsideWorkingHours: true, WorkingHours: workingHours},
+			startTime: "16:30",
+			endTime:   "17:30",
+			out:       InviteStatusDeclined,
+		},
+		{
+			name:      "inside working hours in a different zone",
+			policy:    &AutoResponsePolicy{AcceptWhenFree: true, DeclineOutsideWorkingHours: true, WorkingHours: workingHours},
+			startTime: "18:00",
+			endTime:   "19:00",
+			zone:      "America/New_York",
+			out:       InviteStatusConfirmed,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Log(tc.name)
+			t.Parallel()
+
+			d := &InMemoryDataStore{}
+			c := NewCalendar(d)
+
+			// user 5 is busy on Jan 2nd from 9 to 10
+			_, _, err := c.Create(Event{OwnerId: 5, StartDay: "2008-01-02", StartTime: "09:00", EndDay: "2008-01-02", EndTime: "10:00", Zone: den})
+			require.NoError(t, err)
+
+			if tc.policy != nil {
+				tc.policy.UserId = 5
+				require.NoError(t, c.SetAutoResponsePolicy(*tc.policy))
+			}
+
+			zone := den
+			if tc.zone != "" {
+				zone = tc.zone
+			}
+			a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-02", StartTime: tc.startTime, EndDay: "2008-01-02", EndTime: tc.endTime, Zone: zone})
+			require.NoError(t, err)
+
+			err = c.InviteUser(a.Id, 5, PermissionInvitee, RepeatEditTypeThis)
+			require.NoError(t, err)
+
+			invite, err := c.GetInvitation(a.Id, 5)
+			require.NoError(t, err)
+			require.NotNil(t, invite)
+			assert.Equal(t, tc.out, invite.Status)
+		})
+	}
+}
+
+func TestSetAutoResponsePolicyValidation(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	err := c.SetAutoResponsePolicy(AutoResponsePolicy{UserId: 1, DeclineOutsideWorkingHours: true})
+	require.Equal(t, ErrorInvalidWorkingHours, err)
+
+	err = c.SetAutoResponsePolicy(AutoResponsePolicy{UserId: 1, WorkingHours: &WorkingHours{DayOfWeek: DayOfWeekMonday, StartTime: "17:00", EndTime: "09:00"}})
+	require.Equal(t, ErrorStartTimeIsAfterEndTime, err)
+
+	p, err := c.GetAutoResponsePolicy(1)
+	require.NoError(t, err)
+	assert.Nil(t, p)
+}
@@ -0,0 +1,119 @@
+package cali
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BatchCreateStore is an optional interface for a data store that can save many events at
+// once (in a single transaction or bulk insert). Either all of the events are saved or none are.
+type BatchCreateStore interface {
+	// CreateBatch saves the events the same way as Create and returns them in the same order
+	CreateBatch(events []Event) ([]*Event, error)
+}
+
+// BatchError has the errors of the events in a batch by their index in the batch
+type BatchError struct {
+	Errors map[int]error
+}
+
+func (e *BatchError) Error() string {
+	indexes := make([]int, 0, len(e.Errors))
+	for i := range e.Errors {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	var messages []string
+	for _, i := range indexes {
+		messages = append(messages, fmt.Sprintf("event %d: %v", i, e.Errors[i]))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// CreateBatch creates all of the events. Every event is validated first, and if any are invalid,
+// then none of them are created. Non-repeating events are saved with a single bulk insert if the
+// data store supports it, otherwise each event is created on its own. The results are in the same
+// order as the events (with the first event of repeating events), and if any events fail, then the
+// error is a *BatchError and the results have nil for the events that failed.
+func (c *Calendar) CreateBatch(events []Event) ([]*Event, error) {
+	var created []*Event
+	err := c.outboxTx(func(c *Calendar) error {
+		var err error
+		created, err = c.createBatch(events)
+		return err
+	})
+	return created, err
+}
+
+// createBatch is CreateBatch in the outbox transaction (see outboxTx)
+func (c *Calendar) createBatch(events []Event) ([]*Event, error) {
+	batchErr := &BatchError{Errors: map[int]error{}}
+	events = append([]Event(nil), events...)
+	for i := range events {
+		if err := applyDuration(&events[i]); err != nil {
+			batchErr.Errors[i] = err
+			continue
+		}
+		if err := c.snapEvent(&events[i]); err != nil {
+			batchErr.Errors[i] = err
+			continue
+		}
+		syncDuration(&events[i])
+		e := events[i]
+		if err := Validate(e); err != nil {
+			batchErr.Errors[i] = err
+		} else if err := ValidateLinks(e.Links); err != nil {
+			batchErr.Errors[i] = err
+		} else if err := c.validateCustomFields(e); err != nil {
+			batchErr.Errors[i] = err
+		} else if err := c.authorizeEvent(OperationCreate, &e, nil); err != nil {
+			batchErr.Errors[i] = err
+		} else if e.AddConference && e.Conference == nil && c.conferenceProvider == nil {
+			batchErr.Errors[i] = ErrorConferenceNotConfigured
+		}
+	}
+	if len(batchErr.Errors) > 0 {
+		return nil, batchErr
+	}
+
+	results := make([]*Event, len(events))
+	var bulk []Event
+	var bulkIndexes []int
+	for i, e := range events {
+		if store, ok := capability[BatchCreateStore](c.dataStore); ok && store != nil && !e.IsRepeating {
+			e = c.pendingApproval(e)
+			e.Display = nil
+			bulk = append(bulk, e)
+			bulkIndexes = append(bulkIndexes, i)
+			continue
+		}
+		newEvent, _, err := c.Create(e)
+		if err != nil {
+			batchErr.Errors[i] = err
+			continue
+		}
+		results[i] = newEvent
+	}
+
+	if len(bulk) > 0 {
+		created, err := c.dataStore.(BatchCreateStore).CreateBatch(bulk)
+		for j, i := range bulkIndexes {
+			if err != nil {
+				batchErr.Errors[i] = err
+				continue
+			}
+			results[i] = created[j]
+			if err := c.publish(ChangeTypeCreated, created[j].Id, nil); err != nil {
+				batchErr.Errors[i] = err
+			} else if err := c.addConference([]*Event{created[j]}); err != nil {
+				batchErr.Errors[i] = err
+			}
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return results, batchErr
+	}
+	return results, nil
+}
@@ -0,0 +1,44 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateBatch(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	events, err := c.CreateBatch([]Event{
+		{Title: "one", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"},
+		{Title: "weekly", StartDay: "2008-01-02", EndDay: "2008-01-02", IsAllDay: true, Zone: "UTC", IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeWeekly, DayOfWeek: DayOfWeekWednesday, RepeatOccurrences: 3}},
+		{Title: "two", StartDay: "2008-01-03", EndDay: "2008-01-03", IsAllDay: true, Zone: "UTC"},
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "one", events[0].Title)
+	assert.Equal(t, "weekly", events[1].Title)
+	assert.Equal(t, "two", events[2].Title)
+	assert.NotEqual(t, events[0].Id, events[2].Id)
+
+	all, err := c.Query(Query{})
+	require.NoError(t, err)
+	assert.Len(t, all, 5)
+}
+
+func TestCreateBatchValidatesFirst(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	events, err := c.CreateBatch([]Event{
+		{Title: "ok", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"},
+		{Title: "bad", StartDay: "2008-01-02", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"},
+	})
+	assert.Nil(t, events)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.Error(t, batchErr.Errors[1])
+
+	all, err := c.Query(Query{})
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
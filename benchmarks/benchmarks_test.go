@@ -0,0 +1,151 @@
+package benchmarks
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kenoshen/cali"
+)
+
+var workloads = []struct {
+	name     string
+	workload Workload
+}{
+	{"small", Small},
+	{"large", Large},
+}
+
+func build(b *testing.B, w Workload) *cali.Calendar {
+	b.Helper()
+	c, err := w.Build()
+	if err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
+
+func BenchmarkQuery(b *testing.B) {
+	weekStart := Start.AddDate(0, 3, 0)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	yearEnd := Start.AddDate(1, 0, 0)
+	queries := []struct {
+		name  string
+		query cali.Query
+	}{
+		{"week", cali.Query{Start: &weekStart, End: &weekEnd}},
+		{"year", cali.Query{Start: &Start, End: &yearEnd}},
+		{"user", cali.Query{UserIds: []int64{7}}},
+		{"user week", cali.Query{UserIds: []int64{7}, Start: &weekStart, End: &weekEnd}},
+		{"calendar", cali.Query{CalendarIds: []int64{1}}},
+		{"text", cali.Query{Text: []string{"missing"}}},
+	}
+	for _, wc := range workloads {
+		c := build(b, wc.workload)
+		for _, qc := range queries {
+			b.Run(wc.name+"/"+qc.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					if _, err := c.Query(qc.query); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkSort(b *testing.B) {
+	for _, wc := range workloads {
+		c := build(b, wc.workload)
+		events, err := c.Query(cali.Query{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(wc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			unsorted := make([]*cali.Event, len(events))
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				// reverse the events so that every run has the same work to do
+				for j, e := range events {
+					unsorted[len(events)-1-j] = e
+				}
+				b.StartTimer()
+				cali.Sort(unsorted)
+			}
+		})
+	}
+}
+
+func BenchmarkGenerateRepeatEvents(b *testing.B) {
+	repeats := []struct {
+		name   string
+		repeat cali.Repeat
+	}{
+		{"daily", cali.Repeat{RepeatType: cali.RepeatTypeDaily, RepeatOccurrences: cali.MaxRepeatOccurrence}},
+		{"weekly", cali.Repeat{RepeatType: cali.RepeatTypeWeekly, DayOfWeek: cali.DayOfWeekMonday | cali.DayOfWeekWednesday | cali.DayOfWeekFriday, RepeatOccurrences: cali.MaxRepeatOccurrence}},
+		{"monthly", cali.Repeat{RepeatType: cali.RepeatTypeMonthly, RepeatOccurrences: cali.MaxRepeatOccurrence}},
+		{"yearly hebrew", cali.Repeat{RepeatType: cali.RepeatTypeYearly, RepeatOccurrences: cali.MaxRepeatOccurrence, CalendarSystem: cali.CalendarSystemHebrew}},
+	}
+	for _, rc := range repeats {
+		repeat := rc.repeat
+		e := cali.Event{
+			StartDay:    "2008-01-07",
+			EndDay:      "2008-01-07",
+			IsAllDay:    true,
+			Zone:        "UTC",
+			IsRepeating: true,
+			Repeat:      &repeat,
+		}
+		b.Run(rc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := cali.GenerateRepeatEvents(e); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(rc.name+" occurrences", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				err := cali.RepeatOccurrences(e, func(startDay, endDay time.Time) bool {
+					return true
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkConcurrent has readers querying a week for a user while one in ten operations
+// edits an event. The in memory data store is not safe for concurrent use, so every
+// operation holds a lock like an application sharing a single calendar would.
+func BenchmarkConcurrent(b *testing.B) {
+	c := build(b, Large)
+	var mu sync.Mutex
+	weekStart := Start.AddDate(0, 3, 0)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			mu.Lock()
+			var err error
+			if i%10 == 0 {
+				err = c.UpdateTitle(int64(1+i%Large.Events), time.Now().String(), cali.RepeatEditTypeThis)
+			} else {
+				_, err = c.Query(cali.Query{UserIds: []int64{int64(1 + i%Large.Users)}, Start: &weekStart, End: &weekEnd})
+			}
+			mu.Unlock()
+			if err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,41 @@
+// Package benchmarks has benchmarks of realistic calendar workloads that are used to catch
+// performance regressions in Query, Sort, and GenerateRepeatEvents. Run them with
+//
+//	go test -run xxx -bench . ./benchmarks
+//
+// and add -cpuprofile cpu.out or -memprofile mem.out to profile a single benchmark with
+// go tool pprof.
+//
+// The Small workload is 1,000 events and 20 weekly series over 2 calendars, and the Large
+// workload is 20,000 events and 500 weekly series over 50 calendars. Each series has
+// MaxRepeatOccurrence events.
+//
+// Baselines on a 4 core Intel Xeon (go 1.22, -benchtime 300ms):
+//
+//	BenchmarkQuery/small/week                71751 ns/op     7232 B/op     119 allocs/op
+//	BenchmarkQuery/small/year              3116249 ns/op   261632 B/op    3257 allocs/op
+//	BenchmarkQuery/small/user               132253 ns/op    27136 B/op      38 allocs/op
+//	BenchmarkQuery/small/user_week           69007 ns/op     8592 B/op     115 allocs/op
+//	BenchmarkQuery/small/calendar           799934 ns/op    54848 B/op      25 allocs/op
+//	BenchmarkQuery/small/text               330277 ns/op        0 B/op       0 allocs/op
+//	BenchmarkQuery/large/week              1351787 ns/op   110752 B/op    1376 allocs/op
+//	BenchmarkQuery/large/year            112960913 ns/op  7317856 B/op   64599 allocs/op
+//	BenchmarkQuery/large/user               111483 ns/op    17088 B/op      34 allocs/op
+//	BenchmarkQuery/large/user_week          130044 ns/op    13536 B/op     208 allocs/op
+//	BenchmarkQuery/large/calendar         17189961 ns/op    54848 B/op      25 allocs/op
+//	BenchmarkQuery/large/text             17133719 ns/op        0 B/op       0 allocs/op
+//	BenchmarkSort/small                     404871 ns/op       70 B/op       2 allocs/op
+//	BenchmarkSort/large                   19100973 ns/op    17976 B/op       2 allocs/op
+//	BenchmarkGenerateRepeatEvents/daily      22852 ns/op    11032 B/op      94 allocs/op
+//	BenchmarkGenerateRepeatEvents/weekly     30822 ns/op    11384 B/op      97 allocs/op
+//	BenchmarkGenerateRepeatEvents/monthly    25332 ns/op    11032 B/op      94 allocs/op
+//	BenchmarkGenerateRepeatEvents/yearly_hebrew  69045 ns/op  11032 B/op  94 allocs/op
+//	BenchmarkGenerateRepeatEvents/daily_occurrences           5175 ns/op  0 B/op  0 allocs/op
+//	BenchmarkGenerateRepeatEvents/weekly_occurrences         10626 ns/op  0 B/op  0 allocs/op
+//	BenchmarkGenerateRepeatEvents/monthly_occurrences         4945 ns/op  0 B/op  0 allocs/op
+//	BenchmarkGenerateRepeatEvents/yearly_hebrew_occurrences  59198 ns/op  0 B/op  0 allocs/op
+//	BenchmarkConcurrent                     250386 ns/op    23945 B/op     247 allocs/op
+//
+// Queries by calendar and text can't use the indexes of the in memory data store, so they
+// check every event.
+package benchmarks
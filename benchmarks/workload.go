@@ -0,0 +1,86 @@
+package benchmarks
+
+import (
+	"time"
+
+	"github.com/Kenoshen/cali"
+)
+
+// Workload describes the size of a calendar that is filled with generated events
+type Workload struct {
+	// Events is the number of single events that are spread over a year
+	Events int
+	// Series is the number of weekly repeating series with the max number of occurrences
+	Series int
+	// Users is the number of users that are invited to the events (one invite per event)
+	Users int
+	// Calendars is the number of calendars that the events are spread over
+	Calendars int
+}
+
+// Small is a calendar for a single team
+var Small = Workload{Events: 1000, Series: 20, Users: 20, Calendars: 2}
+
+// Large is a calendar for a whole organization
+var Large = Workload{Events: 20000, Series: 500, Users: 500, Calendars: 50}
+
+// Start is the first day of the generated events
+var Start = time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Build creates a calendar with an in memory data store that has the events of the workload
+func (w Workload) Build() (*cali.Calendar, error) {
+	c := cali.NewCalendar(&cali.InMemoryDataStore{})
+	for i := 0; i < w.Events; i++ {
+		e, _, err := c.Create(w.event(i))
+		if err != nil {
+			return nil, err
+		}
+		if err := c.InviteUser(e.Id, w.user(i+1), cali.PermissionViewer, cali.RepeatEditTypeThis); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < w.Series; i++ {
+		e := w.event(i)
+		e.IsRepeating = true
+		e.Repeat = &cali.Repeat{
+			RepeatType:        cali.RepeatTypeWeekly,
+			DayOfWeek:         weekday(e.StartDay),
+			RepeatOccurrences: cali.MaxRepeatOccurrence,
+		}
+		if _, _, err := c.Create(e); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// event makes the i-th single event of the workload, where every fourth event is all day
+func (w Workload) event(i int) cali.Event {
+	day := Start.AddDate(0, 0, i%365).Format(time.DateOnly)
+	e := cali.Event{
+		CalendarId: int64(i % max(w.Calendars, 1)),
+		OwnerId:    w.user(i),
+		Title:      "event",
+		Zone:       "UTC",
+		StartDay:   day,
+		EndDay:     day,
+	}
+	if i%4 == 0 {
+		e.IsAllDay = true
+	} else {
+		e.StartTime = time.Date(0, 1, 1, 8+i%9, 0, 0, 0, time.UTC).Format(cali.TimeFormat)
+		e.EndTime = time.Date(0, 1, 1, 9+i%9, 0, 0, 0, time.UTC).Format(cali.TimeFormat)
+	}
+	return e
+}
+
+// user picks one of the users of the workload for the i-th event
+func (w Workload) user(i int) int64 {
+	return int64(1 + i%max(w.Users, 1))
+}
+
+// weekday gets the repeat day of week of the day
+func weekday(day string) cali.DayOfWeek {
+	t, _ := time.Parse(time.DateOnly, day)
+	return cali.DayOfWeek(1 << t.Weekday())
+}
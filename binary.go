@@ -0,0 +1,602 @@
+package cali
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// The binary form of events and invites uses the protobuf wire format, so it is compact
+// and other services can read it with the messages below. Fields that have their zero
+// value are left out, pointers are only written when they aren't nil, and unknown fields
+// are skipped when reading so new fields can be added without breaking old readers. The
+// UserData maps are JSON (so numbers are read back as float64 like the JSON data stores).
+// Repeat.Next and Event.Display are never written.
+//
+//	message Event {
+//	  int64 id = 1;                  int64 calendar_id = 2;       optional int64 source_id = 3;
+//	  string external_key = 4;       optional int64 parent_id = 5; int64 owner_id = 6;
+//	  int64 event_type = 7;          string title = 8;            optional string description = 9;
+//	  optional string url = 10;      optional string location = 11; GeoPoint geo = 12;
+//	  int64 status = 13;             int64 priority = 14;         int64 visibility = 15;
+//	  bool disallow_forwarding = 16; bool add_conference = 17;    Conference conference = 18;
+//	  bool is_all_day = 19;          bool is_repeating = 20;      Repeat repeat = 21;
+//	  string zone = 22;              string start_day = 23;       string start_time = 24;
+//	  string end_day = 25;           string end_time = 26;        optional int64 duration_minutes = 27;
+//	  repeated string overrides = 28; string recurrence_id = 29;  Timestamp created = 30;
+//	  Timestamp updated = 31;        int64 version = 32;          bytes user_data = 33;
+//	  optional string cancel_reason = 34; repeated Link links = 35;
+//	  optional int64 anniversary_years = 36;
+//	}
+//	message GeoPoint { double latitude = 1; double longitude = 2; }
+//	message Link { string label = 1; string url = 2; string type = 3; }
+//	message Conference { string id = 1; string provider = 2; string join_url = 3; }
+//	message Repeat {
+//	  int64 repeat_type = 1; uint32 day_of_week = 2; bool business_days_only = 3;
+//	  int64 repeat_occurrences = 4; Timestamp repeat_stop_date = 5; int64 calendar_system = 6;
+//	  bool anniversary = 7; int64 leap_day = 8;
+//	}
+//	message Invite {
+//	  int64 event_id = 1; int64 user_id = 2; int64 status = 3; uint32 permission = 4;
+//	  optional string private_note = 5; bytes user_data = 6; bool is_series = 7;
+//	  TimeProposal proposal = 8; optional int64 forwarded_by = 9;
+//	  Timestamp created = 10; Timestamp updated = 11; bool muted = 12;
+//	}
+//	message TimeProposal {
+//	  string start_day = 1; string start_time = 2; string end_day = 3; string end_time = 4;
+//	  optional string comment = 5; Timestamp created = 6;
+//	}
+//	message Timestamp { int64 seconds = 1; int32 nanos = 2; }
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// MarshalBinary writes the event in its binary form
+func (e Event) MarshalBinary() ([]byte, error) {
+	var w protoWriter
+	w.int(1, e.Id)
+	w.int(2, e.CalendarId)
+	w.intPtr(3, e.SourceId)
+	w.string(4, e.ExternalKey)
+	w.intPtr(5, e.ParentId)
+	w.int(6, e.OwnerId)
+	w.int(7, e.EventType)
+	w.string(8, e.Title)
+	w.stringPtr(9, e.Description)
+	w.stringPtr(10, e.Url)
+	w.stringPtr(11, e.Location)
+	if e.Geo != nil {
+		var g protoWriter
+		g.double(1, e.Geo.Latitude)
+		g.double(2, e.Geo.Longitude)
+		w.message(12, g)
+	}
+	w.int(13, int64(e.Status))
+	w.int(14, int64(e.Priority))
+	w.int(15, int64(e.Visibility))
+	w.bool(16, e.DisallowForwarding)
+	w.bool(17, e.AddConference)
+	if e.Conference != nil {
+		var c protoWriter
+		c.string(1, e.Conference.Id)
+		c.string(2, e.Conference.Provider)
+		c.string(3, e.Conference.JoinUrl)
+		w.message(18, c)
+	}
+	w.bool(19, e.IsAllDay)
+	w.bool(20, e.IsRepeating)
+	if e.Repeat != nil {
+		var r protoWriter
+		r.int(1, int64(e.Repeat.RepeatType))
+		r.int(2, int64(e.Repeat.DayOfWeek))
+		r.bool(3, e.Repeat.BusinessDaysOnly)
+		r.int(4, e.Repeat.RepeatOccurrences)
+		if e.Repeat.RepeatStopDate != nil {
+			r.time(5, *e.Repeat.RepeatStopDate)
+		}
+		r.int(6, int64(e.Repeat.CalendarSystem))
+		r.bool(7, e.Repeat.Anniversary)
+		r.int(8, int64(e.Repeat.LeapDay))
+		w.message(21, r)
+	}
+	w.string(22, e.Zone)
+	w.string(23, e.StartDay)
+	w.string(24, e.StartTime)
+	w.string(25, e.EndDay)
+	w.string(26, e.EndTime)
+	w.intPtr(27, e.DurationMinutes)
+	for _, o := range e.Overrides {
+		w.bytes(28, []byte(o))
+	}
+	w.string(29, e.RecurrenceId)
+	w.time(30, e.Created)
+	w.time(31, e.Updated)
+	w.int(32, e.Version)
+	if err := w.json(33, e.UserData); err != nil {
+		return nil, err
+	}
+	w.stringPtr(34, e.CancelReason)
+	for _, link := range e.Links {
+		var l protoWriter
+		l.string(1, link.Label)
+		l.string(2, link.Url)
+		l.string(3, string(link.Type))
+		w.message(35, l)
+	}
+	w.intPtr(36, e.AnniversaryYears)
+	return w.b, nil
+}
+
+// UnmarshalBinary reads the event from its binary form (see MarshalBinary)
+func (e *Event) UnmarshalBinary(data []byte) error {
+	*e = Event{}
+	return readProto(data, func(field int, r *protoReader) error {
+		switch field {
+		case 1:
+			e.Id = r.int()
+		case 2:
+			e.CalendarId = r.int()
+		case 3:
+			e.SourceId = r.intPtr()
+		case 4:
+			e.ExternalKey = r.string()
+		case 5:
+			e.ParentId = r.intPtr()
+		case 6:
+			e.OwnerId = r.int()
+		case 7:
+			e.EventType = r.int()
+		case 8:
+			e.Title = r.string()
+		case 9:
+			e.Description = r.stringPtr()
+		case 10:
+			e.Url = r.stringPtr()
+		case 11:
+			e.Location = r.stringPtr()
+		case 12:
+			e.Geo = &GeoPoint{}
+			r.message(func(field int, r *protoReader) error {
+				switch field {
+				case 1:
+					e.Geo.Latitude = r.double()
+				case 2:
+					e.Geo.Longitude = r.double()
+				default:
+					r.skip()
+				}
+				return r.err
+			})
+		case 13:
+			e.Status = Status(r.int())
+		case 14:
+			e.Priority = Priority(r.int())
+		case 15:
+			e.Visibility = Visibility(r.int())
+		case 16:
+			e.DisallowForwarding = r.bool()
+		case 17:
+			e.AddConference = r.bool()
+		case 18:
+			e.Conference = &Conference{}
+			r.message(func(field int, r *protoReader) error {
+				switch field {
+				case 1:
+					e.Conference.Id = r.string()
+				case 2:
+					e.Conference.Provider = r.string()
+				case 3:
+					e.Conference.JoinUrl = r.string()
+				default:
+					r.skip()
+				}
+				return r.err
+			})
+		case 19:
+			e.IsAllDay = r.bool()
+		case 20:
+			e.IsRepeating = r.bool()
+		case 21:
+			e.Repeat = &Repeat{}
+			r.message(func(field int, r *protoReader) error {
+				switch field {
+				case 1:
+					e.Repeat.RepeatType = RepeatType(r.int())
+				case 2:
+					e.Repeat.DayOfWeek = DayOfWeek(r.int())
+				case 3:
+					e.Repeat.BusinessDaysOnly = r.bool()
+				case 4:
+					e.Repeat.RepeatOccurrences = r.int()
+				case 5:
+					t := r.time()
+					e.Repeat.RepeatStopDate = &t
+				case 6:
+					e.Repeat.CalendarSystem = CalendarSystem(r.int())
+				case 7:
+					e.Repeat.Anniversary = r.bool()
+				case 8:
+					e.Repeat.LeapDay = LeapDayPolicy(r.int())
+				default:
+					r.skip()
+				}
+				return r.err
+			})
+		case 22:
+			e.Zone = r.string()
+		case 23:
+			e.StartDay = r.string()
+		case 24:
+			e.StartTime = r.string()
+		case 25:
+			e.EndDay = r.string()
+		case 26:
+			e.EndTime = r.string()
+		case 27:
+			e.DurationMinutes = r.intPtr()
+		case 28:
+			e.Overrides = append(e.Overrides, r.string())
+		case 29:
+			e.RecurrenceId = r.string()
+		case 30:
+			e.Created = r.time()
+		case 31:
+			e.Updated = r.time()
+		case 32:
+			e.Version = r.int()
+		case 33:
+			r.json(&e.UserData)
+		case 34:
+			e.CancelReason = r.stringPtr()
+		case 35:
+			var link Link
+			r.message(func(field int, r *protoReader) error {
+				switch field {
+				case 1:
+					link.Label = r.string()
+				case 2:
+					link.Url = r.string()
+				case 3:
+					link.Type = LinkType(r.string())
+				default:
+					r.skip()
+				}
+				return r.err
+			})
+			e.Links = append(e.Links, link)
+		case 36:
+			e.AnniversaryYears = r.intPtr()
+		default:
+			r.skip()
+		}
+		return r.err
+	})
+}
+
+// MarshalBinary writes the invite in its binary form (see Event.MarshalBinary)
+func (i Invite) MarshalBinary() ([]byte, error) {
+	var w protoWriter
+	w.int(1, i.EventId)
+	w.int(2, i.UserId)
+	w.int(3, int64(i.Status))
+	w.int(4, int64(i.Permission))
+	w.stringPtr(5, i.PrivateNote)
+	if err := w.json(6, i.UserData); err != nil {
+		return nil, err
+	}
+	w.bool(7, i.IsSeries)
+	if i.Proposal != nil {
+		var p protoWriter
+		p.string(1, i.Proposal.StartDay)
+		p.string(2, i.Proposal.StartTime)
+		p.string(3, i.Proposal.EndDay)
+		p.string(4, i.Proposal.EndTime)
+		p.stringPtr(5, i.Proposal.Comment)
+		p.time(6, i.Proposal.Created)
+		w.message(8, p)
+	}
+	w.intPtr(9, i.ForwardedBy)
+	w.time(10, i.Created)
+	w.time(11, i.Updated)
+	w.bool(12, i.Muted)
+	return w.b, nil
+}
+
+// UnmarshalBinary reads the invite from its binary form (see MarshalBinary)
+func (i *Invite) UnmarshalBinary(data []byte) error {
+	*i = Invite{}
+	return readProto(data, func(field int, r *protoReader) error {
+		switch field {
+		case 1:
+			i.EventId = r.int()
+		case 2:
+			i.UserId = r.int()
+		case 3:
+			i.Status = InviteStatus(r.int())
+		case 4:
+			i.Permission = Permission(r.int())
+		case 5:
+			i.PrivateNote = r.stringPtr()
+		case 6:
+			r.json(&i.UserData)
+		case 7:
+			i.IsSeries = r.bool()
+		case 8:
+			i.Proposal = &TimeProposal{}
+			r.message(func(field int, r *protoReader) error {
+				switch field {
+				case 1:
+					i.Proposal.StartDay = r.string()
+				case 2:
+					i.Proposal.StartTime = r.string()
+				case 3:
+					i.Proposal.EndDay = r.string()
+				case 4:
+					i.Proposal.EndTime = r.string()
+				case 5:
+					i.Proposal.Comment = r.stringPtr()
+				case 6:
+					i.Proposal.Created = r.time()
+				default:
+					r.skip()
+				}
+				return r.err
+			})
+		case 9:
+			i.ForwardedBy = r.intPtr()
+		case 10:
+			i.Created = r.time()
+		case 11:
+			i.Updated = r.time()
+		case 12:
+			i.Muted = r.bool()
+		default:
+			r.skip()
+		}
+		return r.err
+	})
+}
+
+// protoWriter appends protobuf fields to a buffer
+type protoWriter struct {
+	b []byte
+}
+
+func (w *protoWriter) tag(field, wireType int) {
+	w.b = binary.AppendUvarint(w.b, uint64(field<<3|wireType))
+}
+
+// int writes the value if it isn't 0
+func (w *protoWriter) int(field int, v int64) {
+	if v != 0 {
+		w.tag(field, wireVarint)
+		w.b = binary.AppendUvarint(w.b, uint64(v))
+	}
+}
+
+// intPtr writes the value (even if it is 0) if it isn't nil
+func (w *protoWriter) intPtr(field int, v *int64) {
+	if v != nil {
+		w.tag(field, wireVarint)
+		w.b = binary.AppendUvarint(w.b, uint64(*v))
+	}
+}
+
+func (w *protoWriter) bool(field int, v bool) {
+	if v {
+		w.int(field, 1)
+	}
+}
+
+func (w *protoWriter) double(field int, v float64) {
+	if v != 0 {
+		w.tag(field, wireFixed64)
+		w.b = binary.LittleEndian.AppendUint64(w.b, math.Float64bits(v))
+	}
+}
+
+func (w *protoWriter) bytes(field int, v []byte) {
+	w.tag(field, wireBytes)
+	w.b = binary.AppendUvarint(w.b, uint64(len(v)))
+	w.b = append(w.b, v...)
+}
+
+// string writes the value if it isn't empty
+func (w *protoWriter) string(field int, v string) {
+	if v != "" {
+		w.bytes(field, []byte(v))
+	}
+}
+
+// stringPtr writes the value (even if it is empty) if it isn't nil
+func (w *protoWriter) stringPtr(field int, v *string) {
+	if v != nil {
+		w.bytes(field, []byte(*v))
+	}
+}
+
+func (w *protoWriter) message(field int, m protoWriter) {
+	w.bytes(field, m.b)
+}
+
+// time writes the value as a Timestamp message if it isn't the zero time
+func (w *protoWriter) time(field int, v time.Time) {
+	if v.IsZero() {
+		return
+	}
+	var t protoWriter
+	t.int(1, v.Unix())
+	t.int(2, int64(v.Nanosecond()))
+	w.message(field, t)
+}
+
+// json writes the value as JSON if it isn't empty
+func (w *protoWriter) json(field int, v map[string]interface{}) error {
+	if len(v) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.bytes(field, b)
+	return nil
+}
+
+// protoReader reads the value of a protobuf field where the first problem is kept in err
+type protoReader struct {
+	b        []byte
+	wireType int
+	err      error
+}
+
+// readProto calls f with each field of the message, which has to read or skip the value
+func readProto(data []byte, f func(field int, r *protoReader) error) error {
+	r := &protoReader{b: data}
+	for len(r.b) > 0 {
+		tag := r.uvarint()
+		if r.err != nil {
+			return r.err
+		}
+		r.wireType = int(tag & 7)
+		if err := f(int(tag>>3), r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *protoReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b)
+	if n <= 0 {
+		r.b = nil
+		r.err = ErrorInvalidBinary
+		return 0
+	}
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *protoReader) int() int64 {
+	if r.wireType != wireVarint {
+		r.skip()
+		r.err = ErrorInvalidBinary
+		return 0
+	}
+	return int64(r.uvarint())
+}
+
+func (r *protoReader) intPtr() *int64 {
+	v := r.int()
+	return &v
+}
+
+func (r *protoReader) bool() bool {
+	return r.int() != 0
+}
+
+func (r *protoReader) double() float64 {
+	if r.wireType != wireFixed64 || len(r.b) < 8 {
+		r.skip()
+		r.err = ErrorInvalidBinary
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(r.b))
+	r.b = r.b[8:]
+	return v
+}
+
+func (r *protoReader) bytes() []byte {
+	if r.wireType != wireBytes {
+		r.skip()
+		r.err = ErrorInvalidBinary
+		return nil
+	}
+	n := r.uvarint()
+	if r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.b)) {
+		r.b = nil
+		r.err = ErrorInvalidBinary
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *protoReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *protoReader) stringPtr() *string {
+	v := r.string()
+	return &v
+}
+
+// message reads the fields of an embedded message with f
+func (r *protoReader) message(f func(field int, r *protoReader) error) {
+	b := r.bytes()
+	if r.err != nil {
+		return
+	}
+	r.err = readProto(b, f)
+}
+
+// time reads a Timestamp message as a UTC time
+func (r *protoReader) time() time.Time {
+	var seconds, nanos int64
+	r.message(func(field int, r *protoReader) error {
+		switch field {
+		case 1:
+			seconds = r.int()
+		case 2:
+			nanos = r.int()
+		default:
+			r.skip()
+		}
+		return r.err
+	})
+	return time.Unix(seconds, nanos).UTC()
+}
+
+func (r *protoReader) json(v *map[string]interface{}) {
+	b := r.bytes()
+	if r.err != nil {
+		return
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		r.err = ErrorInvalidBinary
+	}
+}
+
+// skip moves past the value of a field that isn't read
+func (r *protoReader) skip() {
+	switch r.wireType {
+	case wireVarint:
+		r.uvarint()
+	case wireFixed64, wireFixed32:
+		size := 8
+		if r.wireType == wireFixed32 {
+			size = 4
+		}
+		if len(r.b) < size {
+			r.b = nil
+			r.err = ErrorInvalidBinary
+			return
+		}
+		r.b = r.b[size:]
+	case wireBytes:
+		r.bytes()
+	default:
+		r.b = nil
+		r.err = ErrorInvalidBinary
+	}
+}
@@ -0,0 +1,103 @@
+package cali
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventBinary(t *testing.T) {
+	empty := ""
+	zero := int64(0)
+	parentId := int64(-7)
+	url, location := "https://example.com", "Cafe"
+	stop := time.Date(2008, 6, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		name  string
+		event Event
+	}{
+		{
+			name:  "empty",
+			event: Event{},
+		},
+		{
+			name: "every field",
+			event: Event{
+				Id: 12, CalendarId: 3, SourceId: &zero, ExternalKey: "ext-1", ParentId: &parentId, OwnerId: 1, EventType: 4,
+				Title: "Lunch ☕", Description: &empty, Url: &url, Location: &location, CancelReason: &empty,
+				Links: []Link{{Label: "Agenda", Url: "https://example.com/agenda", Type: LinkTypeAgenda}, {Url: "tel:+15555550100"}},
+				Geo:   &GeoPoint{Latitude: 39.7392, Longitude: -104.9903}, Status: StatusCanceled, Priority: 2,
+				Visibility: VisibilityPublic, DisallowForwarding: true, AddConference: true,
+				Conference:  &Conference{Id: "m1", Provider: "test", JoinUrl: "https://example.com/m1"},
+				IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeWeekly, DayOfWeek: DayOfWeekMonday | DayOfWeekFriday, BusinessDaysOnly: true, RepeatStopDate: &stop, CalendarSystem: 1, Anniversary: true, LeapDay: LeapDayFebruary28}, AnniversaryYears: &zero,
+				Zone: den, StartDay: "2008-01-01", StartTime: "12:00:30", EndDay: "2008-01-01", EndTime: "13:00", DurationMinutes: &zero,
+				Overrides: []string{OverrideTitle, OverrideTime}, RecurrenceId: "2008-01-01 11:00",
+				Created: time.Date(2007, 12, 1, 8, 30, 0, 123, time.UTC), Updated: time.Date(2007, 12, 2, 8, 30, 0, 0, time.UTC),
+				Version: 9, UserData: map[string]interface{}{"room": "4B", "seats": float64(6)},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			b, err := tc.event.MarshalBinary()
+			require.NoError(t, err)
+			var e Event
+			require.NoError(t, e.UnmarshalBinary(b))
+			assert.Equal(t, tc.event, e)
+
+			j, err := json.Marshal(tc.event)
+			require.NoError(t, err)
+			assert.Less(t, len(b), len(j))
+		})
+	}
+}
+
+func TestInviteBinary(t *testing.T) {
+	forwardedBy := int64(3)
+	note, comment := "bring slides", ""
+	invite := Invite{
+		EventId: 12, UserId: 2, Status: InviteStatusDeclined, Permission: PermissionRead | PermissionModify,
+		PrivateNote: &note, UserData: map[string]interface{}{"color": "red"}, IsSeries: true,
+		Proposal:    &TimeProposal{StartDay: "2008-01-02", StartTime: "12:00", EndDay: "2008-01-02", EndTime: "13:00", Comment: &comment, Created: time.Date(2007, 12, 3, 0, 0, 0, 0, time.UTC)},
+		ForwardedBy: &forwardedBy, Muted: true, Created: time.Date(2007, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+	b, err := invite.MarshalBinary()
+	require.NoError(t, err)
+	var i Invite
+	require.NoError(t, i.UnmarshalBinary(b))
+	assert.Equal(t, invite, i)
+
+	b, err = Invite{}.MarshalBinary()
+	require.NoError(t, err)
+	assert.Empty(t, b)
+	require.NoError(t, i.UnmarshalBinary(b))
+	assert.Equal(t, Invite{}, i)
+}
+
+func TestUnmarshalBinary(t *testing.T) {
+	b, err := Event{Id: 1, Title: "Lunch"}.MarshalBinary()
+	require.NoError(t, err)
+
+	// unknown fields of every wire type are skipped
+	w := protoWriter{b: append([]byte{}, b...)}
+	w.int(50, 1)
+	w.double(51, 1)
+	w.string(52, "x")
+	w.tag(53, wireFixed32)
+	unknown := append(w.b, 0, 0, 0, 0)
+	var e Event
+	require.NoError(t, e.UnmarshalBinary(unknown))
+	assert.Equal(t, Event{Id: 1, Title: "Lunch"}, e)
+
+	// truncated data and wrong wire types are errors
+	assert.ErrorIs(t, e.UnmarshalBinary(b[:len(b)-1]), ErrorInvalidBinary)
+	assert.ErrorIs(t, e.UnmarshalBinary([]byte{8<<3 | wireVarint, 1}), ErrorInvalidBinary)
+	assert.ErrorIs(t, e.UnmarshalBinary([]byte{12<<3 | wireBytes, 2, 1<<3 | wireFixed64, 0}), ErrorInvalidBinary)
+	assert.ErrorIs(t, e.UnmarshalBinary([]byte{0x80}), ErrorInvalidBinary)
+}
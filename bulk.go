@@ -0,0 +1,142 @@
+package cali
+
+// BulkStatusStore is an optional interface for a data store that can change the status of
+// every event that matches a query in a single operation
+type BulkStatusStore interface {
+	// SetStatusWhere applies the status to every event that matches the query and doesn't
+	// already have the status, and returns the ids of the events that were changed
+	SetStatusWhere(q Query, status Status) ([]int64, error)
+}
+
+// BatchStatusStore is an optional interface for a data store that can change the status of
+// many events by id in a single operation, which is used for edits of a whole series
+type BatchStatusStore interface {
+	// SetStatusBatch applies the status to every event the same way as SetStatus
+	SetStatusBatch(eventIds []int64, status Status) error
+}
+
+// CancelMany sets the status of every active event that matches the query to StatusCanceled
+// (for example, every event in a room on a snow day) and returns the number of events canceled.
+// If the query has Statuses, then those are used instead of only active events.
+func (c *Calendar) CancelMany(q Query) (int64, error) {
+	return c.setStatusMany(q, StatusCanceled)
+}
+
+// RemoveMany sets the status of every active event that matches the query to StatusRemoved
+// and returns the number of events removed. If the query has Statuses, then those are used
+// instead of only active events.
+func (c *Calendar) RemoveMany(q Query) (int64, error) {
+	return c.setStatusMany(q, StatusRemoved)
+}
+
+// setStatusMany sets the status of the matching events with a single store operation if the
+// data store supports it and the calendar has no Authorizer, otherwise it sets the status of
+// each event that is authorized
+func (c *Calendar) setStatusMany(q Query, status Status) (int64, error) {
+	var count int64
+	err := c.outboxTx(func(c *Calendar) error {
+		var err error
+		count, err = c.applyStatusMany(q, status)
+		return err
+	})
+	return count, err
+}
+
+// applyStatusMany is setStatusMany in the outbox transaction (see outboxTx)
+func (c *Calendar) applyStatusMany(q Query, status Status) (int64, error) {
+	if len(q.Statuses) == 0 {
+		q.Statuses = []Status{StatusActive}
+	}
+	op := OperationCancel
+	if status == StatusRemoved {
+		op = OperationRemove
+	}
+	var ids []int64
+	if store, ok := capability[BulkStatusStore](c.dataStore); ok && c.authorizer == nil {
+		q.Statuses = c.transitionableStatuses(op, q.Statuses, status)
+		if len(q.Statuses) == 0 {
+			return 0, nil
+		}
+		changed, err := store.SetStatusWhere(q, status)
+		if err != nil {
+			return 0, err
+		}
+		ids = changed
+	} else {
+		events, err := c.dataStore.Query(q)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range events {
+			if e.Status == status || c.checkTransition(op, e, status) != nil {
+				continue
+			}
+			if err := c.authorizeEvent(op, e, nil); err != nil {
+				return int64(len(ids)), err
+			}
+			if err := c.dataStore.SetStatus(e.Id, status); err != nil {
+				return int64(len(ids)), err
+			}
+			ids = append(ids, e.Id)
+		}
+	}
+	for _, id := range ids {
+		if err := c.publish(ChangeTypeUpdated, id, nil); err != nil {
+			return int64(len(ids)), err
+		}
+		if err := c.releaseConference(id); err != nil {
+			return int64(len(ids)), err
+		}
+	}
+	return int64(len(ids)), nil
+}
+
+// setStatus sets the status of the events of the edit and then calls after for each event.
+// Edits of many events use a single SetStatusBatch if the data store supports it.
+func (c *Calendar) setStatus(op Operation, editType RepeatEditType, eventId int64, status Status, after func(eventId int64) error) error {
+	editedId := eventId
+	store, ok := capability[BatchStatusStore](c.dataStore)
+	if !ok || editType == RepeatEditTypeThis {
+		return c.editEvents(op, editType, eventId, func(eventId int64) error {
+			if skip, err := c.skipStatus(op, editedId, eventId, status); skip || err != nil {
+				return err
+			}
+			if err := c.dataStore.SetStatus(eventId, status); err != nil {
+				return err
+			}
+			return after(eventId)
+		})
+	}
+	if err := c.authorize(op, eventId, nil); err != nil {
+		return err
+	}
+	if err := c.checkLocks(editType, eventId); err != nil {
+		return err
+	}
+	var ids []int64
+	err := c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
+		if skip, err := c.skipStatus(op, editedId, eventId, status); skip || err != nil {
+			return err
+		}
+		ids = append(ids, eventId)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := store.SetStatusBatch(ids, status); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := after(id); err != nil {
+			return err
+		}
+		if err := c.publish(ChangeTypeUpdated, id, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
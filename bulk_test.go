@@ -0,0 +1,123 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelAndRemoveMany(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	create := func(calendarId int64, day string) *Event {
+		e, _, err := c.Create(Event{CalendarId: calendarId, StartDay: day, EndDay: day, IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	roomA1 := create(1, "2008-01-01")
+	roomA2 := create(1, "2008-01-01")
+	roomA3 := create(1, "2008-01-02")
+	roomB := create(2, "2008-01-01")
+	require.NoError(t, c.Remove(roomA2.Id, RepeatEditTypeThis))
+
+	changes, stop := c.Watch(10)
+	defer stop()
+
+	day := _t(time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC))
+	dayEnd := _t(time.Date(2008, time.January, 1, 23, 59, 0, 0, time.UTC))
+	count, err := c.CancelMany(Query{Start: day, End: dayEnd, CalendarIds: []int64{1}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	change := <-changes
+	assert.Equal(t, roomA1.Id, change.EventId)
+
+	status := func(id int64) Status {
+		e, err := c.Get(id)
+		require.NoError(t, err)
+		return e.Status
+	}
+	assert.Equal(t, StatusCanceled, status(roomA1.Id))
+	// removed events stay removed
+	assert.Equal(t, StatusRemoved, status(roomA2.Id))
+	assert.Equal(t, StatusActive, status(roomA3.Id))
+	assert.Equal(t, StatusActive, status(roomB.Id))
+
+	count, err = c.RemoveMany(Query{CalendarIds: []int64{1}, Statuses: []Status{StatusActive, StatusCanceled}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, StatusRemoved, status(roomA1.Id))
+	assert.Equal(t, StatusRemoved, status(roomA3.Id))
+	assert.Equal(t, StatusActive, status(roomB.Id))
+}
+
+// roundTripStore counts the calls that write events, and it only has the batch interfaces
+// of the in memory data store
+type roundTripStore struct {
+	DataStore
+	memory *InMemoryDataStore
+	calls  []string
+}
+
+func newRoundTripStore() *roundTripStore {
+	memory := &InMemoryDataStore{}
+	return &roundTripStore{DataStore: memory, memory: memory}
+}
+
+func (d *roundTripStore) Create(event Event) (*Event, error) {
+	d.calls = append(d.calls, "Create")
+	return d.memory.Create(event)
+}
+
+func (d *roundTripStore) CreateBatch(events []Event) ([]*Event, error) {
+	d.calls = append(d.calls, "CreateBatch")
+	return d.memory.CreateBatch(events)
+}
+
+func (d *roundTripStore) SetStatus(eventId int64, status Status) error {
+	d.calls = append(d.calls, "SetStatus")
+	return d.memory.SetStatus(eventId, status)
+}
+
+func (d *roundTripStore) SetStatusBatch(eventIds []int64, status Status) error {
+	d.calls = append(d.calls, "SetStatusBatch")
+	return d.memory.SetStatusBatch(eventIds, status)
+}
+
+func TestSeriesBatchWrites(t *testing.T) {
+	store := newRoundTripStore()
+	c := NewCalendar(store)
+	changes, stop := c.Watch(20)
+	defer stop()
+
+	e, count, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 5}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+	// the first event is the parent of the others, so it is created first
+	assert.Equal(t, []string{"Create", "CreateBatch"}, store.calls)
+	events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	assert.Len(t, events, 5)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, ChangeTypeCreated, (<-changes).Type)
+	}
+
+	store.calls = nil
+	require.NoError(t, c.Cancel(events[2].Id, RepeatEditTypeThisAndAfter))
+	assert.Equal(t, []string{"SetStatusBatch"}, store.calls)
+	for _, event := range events {
+		got, err := c.Get(event.Id)
+		require.NoError(t, err)
+		if event.Id < events[2].Id {
+			assert.Equal(t, StatusActive, got.Status)
+		} else {
+			assert.Equal(t, StatusCanceled, got.Status)
+			assert.Equal(t, event.Id, (<-changes).EventId)
+		}
+	}
+
+	store.calls = nil
+	require.NoError(t, c.Remove(e.Id, RepeatEditTypeThis))
+	assert.Equal(t, []string{"SetStatus"}, store.calls)
+}
@@ -0,0 +1,202 @@
+package cali
+
+import (
+	"container/list"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+)
+
+// WithQueryCache keeps the results of up to size queries for the ttl (zero is until they are
+// invalidated) so that hot views, like a team's calendar for this week, don't hit the data
+// store for every user. Queries are the same if they only differ by the order of their lists
+// or by the location of their times. Every change published by the calendar removes the cached
+// results that could include the event, and changes made by other calendars (like other
+// servers) can be passed to InvalidateQueryCache from their outbox.
+func WithQueryCache(size int, ttl time.Duration) CalendarOption {
+	return func(c *Calendar) {
+		c.queryCache = &queryCache{
+			size:    size,
+			ttl:     ttl,
+			entries: map[string]*list.Element{},
+			order:   list.New(),
+		}
+	}
+}
+
+// InvalidateQueryCache removes the cached query results that could include the events of the
+// changes, or all of the cached results if there are no changes
+func (c *Calendar) InvalidateQueryCache(changes ...Change) {
+	if c.queryCache == nil {
+		return
+	}
+	if len(changes) == 0 {
+		c.queryCache.clear()
+		return
+	}
+	for _, change := range changes {
+		c.queryCache.invalidate(change)
+	}
+}
+
+// queryCache is a least recently used cache of query results
+type queryCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	// order has the *cacheEntry values with the most recently used at the front
+	order *list.List
+	// generation is changed by every invalidation so that results that were queried
+	// before an invalidation aren't cached after it
+	generation int64
+}
+
+// cacheEntry is the cached results of a query
+type cacheEntry struct {
+	key     string
+	q       Query
+	events  []*Event
+	ids     map[int64]bool
+	expires time.Time
+}
+
+// cacheKey is the same for queries that match the same events, where the lists are sorted and
+// the times are the wall clock times that events are compared with
+func (q Query) cacheKey() string {
+	type key struct {
+		Start, End   string
+		StartBound   RangeBound
+		EndBound     RangeBound
+		EventIds     []int64
+		CalendarIds  []int64
+		ParentIds    []int64
+		UserIds      []int64
+		SourceIds    []int64
+		EventTypes   []int64
+		Statuses     []int64
+		Priorities   []int64
+		Visibilities []int64
+		Near         *GeoRadius
+		Text         []string
+		CustomFields []CustomFieldQuery
+	}
+	ints := func(values []int64) []int64 {
+		sorted := append([]int64(nil), values...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		return sorted
+	}
+	k := key{
+		EventIds:     ints(q.EventIds),
+		CalendarIds:  ints(q.CalendarIds),
+		ParentIds:    ints(q.ParentIds),
+		UserIds:      ints(q.UserIds),
+		SourceIds:    ints(q.SourceIds),
+		EventTypes:   ints(q.EventTypes),
+		Near:         q.Near,
+		Text:         append([]string(nil), q.Text...),
+		CustomFields: q.CustomFields,
+	}
+	if q.Start != nil {
+		k.Start, k.StartBound = FloatingBound(*q.Start), q.StartBound
+	}
+	if q.End != nil {
+		k.End, k.EndBound = FloatingBound(*q.End), q.EndBound
+	}
+	for _, s := range q.Statuses {
+		k.Statuses = append(k.Statuses, int64(s))
+	}
+	for _, p := range q.Priorities {
+		k.Priorities = append(k.Priorities, int64(p))
+	}
+	for _, v := range q.Visibilities {
+		k.Visibilities = append(k.Visibilities, int64(v))
+	}
+	k.Statuses, k.Priorities, k.Visibilities = ints(k.Statuses), ints(k.Priorities), ints(k.Visibilities)
+	sort.Strings(k.Text)
+	b, _ := json.Marshal(k)
+	return string(b)
+}
+
+// get finds the cached results of the query and returns the generation to put new results with
+func (qc *queryCache) get(key string) ([]*Event, int64, bool) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	element, ok := qc.entries[key]
+	if !ok {
+		return nil, qc.generation, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if qc.ttl > 0 && time.Now().After(entry.expires) {
+		qc.remove(element)
+		return nil, qc.generation, false
+	}
+	qc.order.MoveToFront(element)
+	return copyEvents(entry.events), qc.generation, true
+}
+
+// put caches the results of the query unless the cache was invalidated since the generation
+func (qc *queryCache) put(key string, q Query, events []*Event, generation int64) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	if generation != qc.generation || qc.size <= 0 {
+		return
+	}
+	if element, ok := qc.entries[key]; ok {
+		qc.remove(element)
+	}
+	entry := &cacheEntry{key: key, q: q, events: copyEvents(events), ids: map[int64]bool{}, expires: time.Now().Add(qc.ttl)}
+	for _, e := range events {
+		entry.ids[e.Id] = true
+	}
+	qc.entries[key] = qc.order.PushFront(entry)
+	for qc.order.Len() > qc.size {
+		qc.remove(qc.order.Back())
+	}
+}
+
+// invalidate removes the cached results that have the event of the change or that the changed
+// event could now be a part of. The users of a query aren't checked, so a change to any invite
+// of an event removes every cached query that the event matches.
+func (qc *queryCache) invalidate(change Change) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	qc.generation++
+	for element := qc.order.Front(); element != nil; {
+		next := element.Next()
+		entry := element.Value.(*cacheEntry)
+		if change.Event == nil || entry.ids[change.EventId] || entry.q.Matches(change.Event) {
+			qc.remove(element)
+		}
+		element = next
+	}
+}
+
+// clear removes all of the cached results
+func (qc *queryCache) clear() {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	qc.generation++
+	qc.entries = map[string]*list.Element{}
+	qc.order.Init()
+}
+
+// remove takes the entry out of the cache, the lock must be held
+func (qc *queryCache) remove(element *list.Element) {
+	delete(qc.entries, element.Value.(*cacheEntry).key)
+	qc.order.Remove(element)
+}
+
+// copyEvents makes copies of the events so that changes to them don't change the cache
+func copyEvents(events []*Event) []*Event {
+	if events == nil {
+		return nil
+	}
+	result := make([]*Event, len(events))
+	for i, e := range events {
+		copied := *e
+		result[i] = &copied
+	}
+	return result
+}
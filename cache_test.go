@@ -0,0 +1,99 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingDataStore struct {
+	*InMemoryDataStore
+	queries int
+}
+
+func (d *countingDataStore) Query(q Query) ([]*Event, error) {
+	d.queries++
+	return d.InMemoryDataStore.Query(q)
+}
+
+func TestQueryCache(t *testing.T) {
+	store := &countingDataStore{InMemoryDataStore: &InMemoryDataStore{}}
+	c := NewCalendar(store, WithQueryCache(10, 0))
+	create := func(calendarId int64, day string) *Event {
+		e, _, err := c.Create(Event{CalendarId: calendarId, Title: day, StartDay: day, EndDay: day, IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	first := create(1, "2008-01-01")
+	create(2, "2008-01-02")
+
+	week := Query{Start: tt("2008-01-01 00:00"), End: tt("2008-01-07 00:00"), CalendarIds: []int64{1, 2}}
+	events, err := c.Query(week)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	events[0].Title = "changed by the caller"
+	assert.Equal(t, 1, store.queries)
+
+	// the same query with the lists in another order and the times in another location
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	start := time.Date(2008, 1, 1, 0, 0, 0, 0, loc)
+	events, err = c.Query(Query{Start: &start, End: tt("2008-01-07 00:00"), CalendarIds: []int64{2, 1}})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "2008-01-01", events[0].Title, "the cached events can't be changed by callers")
+	assert.Equal(t, 1, store.queries)
+
+	// a change to an event that isn't in the query keeps the cached results
+	create(3, "2008-01-03")
+	_, err = c.Query(week)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.queries)
+
+	// a new event that the query matches removes the cached results
+	create(1, "2008-01-04")
+	events, err = c.Query(week)
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, 2, store.queries)
+
+	// an event that moves out of the query removes the cached results
+	require.NoError(t, c.UpdateDayTime(first.Id, "2008-02-01", "", "2008-02-01", "", "UTC", true))
+	events, err = c.Query(week)
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, 3, store.queries)
+
+	// changes from other calendars can be passed in
+	c.InvalidateQueryCache(Change{Type: ChangeTypeUpdated, EventId: first.Id})
+	_, err = c.Query(week)
+	require.NoError(t, err)
+	assert.Equal(t, 4, store.queries)
+	c.InvalidateQueryCache()
+	_, err = c.Query(week)
+	require.NoError(t, err)
+	assert.Equal(t, 5, store.queries)
+}
+
+func TestQueryCacheLimits(t *testing.T) {
+	store := &countingDataStore{InMemoryDataStore: &InMemoryDataStore{}}
+	c := NewCalendar(store, WithQueryCache(1, time.Hour))
+	a := Query{CalendarIds: []int64{1}}
+	b := Query{CalendarIds: []int64{2}}
+	for _, q := range []Query{a, a, b, a} {
+		_, err := c.Query(q)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, store.queries, "only the most recent query is kept")
+
+	store = &countingDataStore{InMemoryDataStore: &InMemoryDataStore{}}
+	c = NewCalendar(store, WithQueryCache(10, time.Nanosecond))
+	for _, q := range []Query{a, a} {
+		_, err := c.Query(q)
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, 2, store.queries, "the results expired")
+}
@@ -14,34 +14,255 @@ type Calendar struct {
 	// dataStore is the implementation of the data store that the
 	// event and invitation data will be stored in
 	dataStore DataStore
+
+	// holidayCalendarId is the calendar id given to the holiday overlay events
+	holidayCalendarId int64
+	// holidayProvider is used to overlay holidays onto query results
+	holidayProvider HolidayProvider
+
+	// ifMatch is the expected ETag of the event for update operations
+	ifMatch string
+	// actor is who makes the changes through the calendar (see AsAdminOnBehalfOf)
+	actor *Actor
+	// authorizer is consulted before every operation when it is set
+	authorizer Authorizer
+	// hideAbandoned leaves the abandoned events out of queries like the removed events
+	hideAbandoned bool
+	// statusTransitions decides which status changes are allowed, nil is DefaultStatusTransitions
+	statusTransitions StatusTransitionPolicy
+	// rsvpResetPolicy decides which edits reset the confirmed invitations, nil never resets them
+	rsvpResetPolicy *RSVPResetPolicy
+	// reactionCounts sets the ReactionCounts of the events that are read
+	reactionCounts bool
+	// attachmentValidators check new attachments before they are saved
+	attachmentValidators []AttachmentValidator
+	// attachmentScanner quarantines new attachments until their scan is done
+	attachmentScanner AttachmentScanner
+
+	// feed sends the changes made through the calendar to the watchers
+	feed *changeFeed
+
+	// syncRetention is how long a sync token is good for, zero is forever
+	syncRetention time.Duration
+
+	// inviteCarryOver decides how invites are copied when a series is extended
+	inviteCarryOver InviteCarryOver
+
+	// rsvpKey signs the RSVP tokens
+	rsvpKey []byte
+	// rsvpTTL is how long an RSVP token is good for
+	rsvpTTL time.Duration
+
+	// notificationSender delivers notifications to invitees
+	notificationSender NotificationSender
+
+	// conferenceProvider creates meetings for events that want a conference link
+	conferenceProvider ConferenceProvider
+
+	// outbox is true if changes and notifications are written to the outbox of the data store
+	outbox bool
+	// outboxChanges are the changes of the current outbox transaction, which are sent to the
+	// watchers once it is committed (see outboxTx)
+	outboxChanges *[]Change
+
+	// invitePolicies are checked before a user is invited
+	invitePolicies []InvitePolicy
+
+	// approvalTypes are the event types that are created pending approval
+	approvalTypes map[EventType]bool
+
+	// reportBackend totals up the organization reports, the calendar reads the events itself if it is nil
+	reportBackend ReportBackend
+
+	// displayRules derive the Display of events when they are read
+	displayRules []DisplayRule
+
+	// queryCache keeps the results of queries until they are invalidated by a change
+	queryCache *queryCache
+	// seriesRecords is true if new repeating events save their shared fields in a Series
+	seriesRecords bool
+	// granularity is the minutes that the times of events snap to, or 0 for any minute
+	granularity       int64
+	strictGranularity bool
+	// iCalProperties are the UserData keys that are exported to ical as X- properties
+	iCalProperties ICalProperties
+	// userInfoResolver finds the names and emails of users for the attendees of ical exports
+	userInfoResolver UserInfoResolver
+	// uidGenerator makes the UIDs of the ical exports, nil is DefaultUID
+	uidGenerator UIDGenerator
+	// startBound and endBound are the bounds of the queries that use RangeBoundDefault
+	startBound RangeBound
+	endBound   RangeBound
+	// customFields are the typed fields of the UserData of each event type
+	customFields map[EventType][]CustomField
+	// notificationRenderer renders the subject and body of notifications in the locale of each user
+	notificationRenderer NotificationRenderer
+	userLocale           func(userId int64) string
+	// auditLog is true if every change is written to the audit log of the data store
+	auditLog bool
+	// weekStart is the first day of the week of the week views
+	weekStart time.Weekday
 }
 
+// CalendarOption is used to configure optional behavior of a calendar
+// when it is created with NewCalendar
+type CalendarOption func(c *Calendar)
+
 // NewCalendar creates a new calendar with the given data store
-func NewCalendar(dataStore DataStore) *Calendar {
+func NewCalendar(dataStore DataStore, opts ...CalendarOption) *Calendar {
 	c := &Calendar{
 		dataStore: dataStore,
+		feed:      &changeFeed{},
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
 	return c
 }
 
 // Get grabs a single event by id
 func (c *Calendar) Get(eventId int64) (*Event, error) {
-	return c.dataStore.Get(eventId)
+	e, err := c.dataStore.Get(eventId)
+	if err != nil || e == nil {
+		return e, err
+	}
+	if err := c.authorizeEvent(OperationRead, e, nil); err != nil {
+		return nil, err
+	}
+	events, err := c.withSeries([]*Event{e})
+	if err != nil {
+		return nil, err
+	}
+	if events, err = c.withReactions(events); err != nil {
+		return nil, err
+	}
+	return c.withDisplay(events)[0], nil
 }
 
-// Query collects a list of events using the provided query parameters
+// Query collects a list of events using the provided query parameters. Removed events are
+// left out unless the query has Statuses or IncludeRemoved.
 func (c *Calendar) Query(q Query) ([]*Event, error) {
-	results, err := c.dataStore.Query(q)
+	q = c.withRangeBounds(c.withStatusDefaults(q))
+	var key string
+	var generation int64
+	if c.queryCache != nil {
+		key = q.cacheKey()
+		cached, gen, ok := c.queryCache.get(key)
+		if ok {
+			authorized, err := c.authorizedEvents(cached)
+			if err != nil {
+				return nil, err
+			}
+			if authorized, err = c.withReactions(authorized); err != nil {
+				return nil, err
+			}
+			return c.withDisplay(authorized), nil
+		}
+		generation = gen
+	}
+	results, err := c.queryCustomFields(q)
+	if err != nil {
+		return nil, err
+	}
+	subscribed, err := c.subscribedEvents(q)
+	if err != nil {
+		return nil, err
+	}
+	results = mergeEvents(results, subscribed)
+	holidays, err := c.holidayEvents(q)
 	if err != nil {
 		return nil, err
 	}
+	results = append(results, holidays...)
+	if results, err = c.withSeries(results); err != nil {
+		return nil, err
+	}
 	Sort(results)
-	return results, err
+	if c.queryCache != nil {
+		c.queryCache.put(key, q, results, generation)
+	}
+	if results, err = c.authorizedEvents(results); err != nil {
+		return nil, err
+	}
+	if results, err = c.withReactions(results); err != nil {
+		return nil, err
+	}
+	return c.withDisplay(results), nil
+}
+
+// PublicEvents collects the events that match the query and have VisibilityPublic no matter
+// who is invited to them, so the UserIds and Visibilities fields of the query are ignored.
+// Only active and canceled events are returned unless the query has Statuses.
+func (c *Calendar) PublicEvents(q Query) ([]*Event, error) {
+	q = c.withRangeBounds(q)
+	q.UserIds = nil
+	q.Visibilities = []Visibility{VisibilityPublic}
+	if len(q.Statuses) == 0 {
+		q.Statuses = []Status{StatusActive, StatusCanceled}
+	}
+	results, err := c.queryCustomFields(q)
+	if err != nil {
+		return nil, err
+	}
+	if results, err = c.withSeries(results); err != nil {
+		return nil, err
+	}
+	Sort(results)
+	if results, err = c.authorizedEvents(results); err != nil {
+		return nil, err
+	}
+	if results, err = c.withReactions(results); err != nil {
+		return nil, err
+	}
+	return c.withDisplay(results), nil
+}
+
+// prepareCreate applies the settings of the calendar to a new event (like its granularity)
+// and checks that the event can be created, without saving it
+func (c *Calendar) prepareCreate(e Event) (Event, error) {
+	e = c.pendingApproval(e)
+	e.Display = nil
+	if err := applyDuration(&e); err != nil {
+		return e, err
+	}
+	if err := c.snapEvent(&e); err != nil {
+		return e, err
+	}
+	syncDuration(&e)
+	if err := Validate(e); err != nil {
+		return e, err
+	}
+	if err := ValidateLinks(e.Links); err != nil {
+		return e, err
+	}
+	if err := c.validateCustomFields(e); err != nil {
+		return e, err
+	}
+	if err := c.authorizeEvent(OperationCreate, &e, nil); err != nil {
+		return e, err
+	}
+	if e.AddConference && e.Conference == nil && c.conferenceProvider == nil {
+		return e, ErrorConferenceNotConfigured
+	}
+	return e, nil
 }
 
 // Create an event with the given values. Created and Updated fields will be set automatically. Repeating events will also be created automatically.
 func (c *Calendar) Create(e Event) (*Event, int64, error) {
-	if err := Validate(e); err != nil {
+	var created *Event
+	var count int64
+	err := c.outboxTx(func(c *Calendar) error {
+		var err error
+		created, count, err = c.create(e)
+		return err
+	})
+	return created, count, err
+}
+
+// create is Create in the outbox transaction (see outboxTx)
+func (c *Calendar) create(e Event) (*Event, int64, error) {
+	e, err := c.prepareCreate(e)
+	if err != nil {
 		return nil, 0, err
 	}
 
@@ -50,154 +271,556 @@ func (c *Calendar) Create(e Event) (*Event, int64, error) {
 		var count int64 = 0
 		if newEvent != nil {
 			count++
+			if publishErr := c.publish(ChangeTypeCreated, newEvent.Id, nil); publishErr != nil && err == nil {
+				err = publishErr
+			}
+			if err == nil {
+				err = c.addConference([]*Event{newEvent})
+			}
 		}
 		return newEvent, count, err
 	}
 
-	events, err := GenerateRepeatEvents(e)
-	if err != nil {
-		return nil, 0, err
-	}
-
-	if events == nil || len(events) == 0 {
-		return nil, 0, ErrorEmptyRepeatingEvents
+	var series *Series
+	if c.seriesRecords {
+		if _, ok := capability[SeriesStore](c.dataStore); !ok {
+			return nil, 0, ErrorSeriesRecordsNotSupported
+		}
+		s := newSeries(&e)
+		series = &s
 	}
 
 	var results []*Event
-	var count int64 = 0
-	var parentId *int64
-	for _, event := range events {
-		if parentId != nil {
-			event.ParentId = parentId
+	err = c.inTx(func(tx *Calendar) error {
+		var err error
+		if results, err = createRepeating(tx.dataStore, e); err != nil {
+			return err
 		}
-		newEvent, err := c.dataStore.Create(*event)
-		if err != nil {
-			return nil, 0, err
+		if series == nil {
+			return nil
 		}
+		store, ok := capability[SeriesStore](tx.dataStore)
+		if !ok {
+			return ErrorSeriesRecordsNotSupported
+		}
+		series.Id = results[0].Id
+		_, err = store.CreateSeries(*series)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	var count int64 = 0
+	for _, newEvent := range results {
 		if newEvent != nil {
 			count++
-			if parentId == nil {
-				parentId = &newEvent.Id
+			if err := c.publish(ChangeTypeCreated, newEvent.Id, nil); err != nil {
+				return nil, 0, err
 			}
 		}
-		results = append(results, newEvent)
+	}
+	if series != nil {
+		if results, err = c.withSeries(results); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := c.addConference(results); err != nil {
+		return results[0], count, err
 	}
 
 	return results[0], count, nil
 }
 
-// UpdateTime changes the time values of the event and repeated events
-func (c *Calendar) UpdateTime(eventId int64, startTime string, endTime string, editType RepeatEditType) error {
-	if err := ValidateTimeValues(startTime, endTime); err != nil {
-		return err
+// CreateTruncated creates the event like Create, but a repeat that has more than
+// MaxRepeatOccurrence occurrences is cut short (see TruncateRepeat) instead of failing.
+// It returns the number of events created and the number of occurrences that were dropped.
+func (c *Calendar) CreateTruncated(e Event) (*Event, int64, int64, error) {
+	e, dropped, err := TruncateRepeat(e)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	newEvent, count, err := c.Create(e)
+	if err != nil {
+		return newEvent, count, 0, err
 	}
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetTime(eventId, startTime, endTime)
+	return newEvent, count, dropped, nil
+}
+
+// UpdateTime changes the time values of the event and repeated events. The event keeps its start
+// day, and an end time before the start time is an ErrorStartTimeIsAfterEndTime (see
+// UpdateTimeOvernight). The other events of the series are moved by the same amount as the event
+// (see ShiftEvent), so their days roll over when the new times cross midnight.
+func (c *Calendar) UpdateTime(eventId int64, startTime string, endTime string, editType RepeatEditType) error {
+	return c.updateTime(eventId, startTime, endTime, editType, false)
+}
+
+// UpdateTimeOvernight changes the time values like UpdateTime, except that an end time before the
+// start time ends the event on the next day, like 22:00 to 02:00 for a night shift
+func (c *Calendar) UpdateTimeOvernight(eventId int64, startTime string, endTime string, editType RepeatEditType) error {
+	return c.updateTime(eventId, startTime, endTime, editType, true)
+}
+
+// updateTime changes the time values of the events, where an end time before the start time is
+// on the next day if overnight is set
+func (c *Calendar) updateTime(eventId int64, startTime string, endTime string, editType RepeatEditType, overnight bool) error {
+	return c.outboxTx(func(c *Calendar) error {
+		var err error
+		if startTime, err = c.snapTime("startTime", startTime); err != nil {
+			return err
+		}
+		if endTime, err = c.snapTime("endTime", endTime); err != nil {
+			return err
+		}
+		if _, err := parseTime(startTime); err != nil {
+			return ErrorInvalidStartTime
+		}
+		if _, err := parseTime(endTime); err != nil {
+			return ErrorInvalidEndTime
+		}
+		if !overnight && startTime > endTime {
+			return ErrorStartTimeIsAfterEndTime
+		}
+		e, err := c.dataStore.Get(eventId)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrorEventNotFound
+		}
+		if e.IsAllDay {
+			return ErrorAllDayCantHaveTimes
+		}
+		delta, duration, err := timeChange(*e, startTime, endTime)
+		if err != nil {
+			return err
+		}
+		return c.editField(OverrideTime, editType, eventId, func(eventId int64) error {
+			return c.shiftEvent(eventId, func(e Event) (Event, error) {
+				if e.IsAllDay {
+					return e, ErrorAllDayCantHaveTimes
+				}
+				return ShiftEvent(e, delta, duration)
+			})
+		})
 	})
 }
 
 // UpdateDayTime changes the day and time values of a single event
 func (c *Calendar) UpdateDayTime(eventId int64, startDay, startTime, endDay, endTime string, zone string, isAllDay bool) error {
-	if err := ValidateDayTimeValues(startDay, startTime, endDay, endTime, zone, isAllDay); err != nil {
-		return err
-	}
-	return c.dataStore.SetDayTime(eventId, startDay, startTime, endDay, endTime, zone, isAllDay)
+	return c.outboxTx(func(c *Calendar) error {
+		if !isAllDay {
+			snapped := Event{StartDay: startDay, StartTime: startTime, EndDay: endDay, EndTime: endTime}
+			if err := c.snapEvent(&snapped); err != nil {
+				return err
+			}
+			startDay, startTime, endDay, endTime = snapped.StartDay, snapped.StartTime, snapped.EndDay, snapped.EndTime
+		}
+		if err := ValidateDayTimeValues(startDay, startTime, endDay, endTime, zone, isAllDay); err != nil {
+			return err
+		}
+		if err := c.authorize(OperationUpdate, eventId, nil); err != nil {
+			return err
+		}
+		if err := c.checkLocks(RepeatEditTypeThis, eventId); err != nil {
+			return err
+		}
+		return c.ifMatched(eventId, func() error {
+			e, err := c.dataStore.Get(eventId)
+			if err != nil {
+				return err
+			}
+			if e == nil {
+				return ErrorEventNotFound
+			}
+			event := *e
+			err = c.notifyChanges(eventId, func() error {
+				return c.dataStore.SetDayTime(eventId, startDay, startTime, endDay, endTime, zone, isAllDay)
+			})
+			if err != nil {
+				return err
+			}
+			if store, ok := capability[OverrideStore](c.dataStore); ok {
+				if err := addOverride(store, event, OverrideTime); err != nil {
+					return err
+				}
+			}
+			return c.publish(ChangeTypeUpdated, eventId, nil)
+		})
+	})
 }
 
 // Cancel sets the status of the event to StatusCanceled
 func (c *Calendar) Cancel(eventId int64, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetStatus(eventId, StatusCanceled)
-	})
+	return c.CancelWithReason(eventId, nil, editType)
 }
 
 // Remove sets the status of the event to StatusRemoved (we never delete things here)
 func (c *Calendar) Remove(eventId int64, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetStatus(eventId, StatusRemoved)
+	return c.outboxTx(func(c *Calendar) error {
+		return c.setStatus(OperationRemove, editType, eventId, StatusRemoved, c.releaseConference)
 	})
 }
 
 // UpdateTitle sets the title of the event
 func (c *Calendar) UpdateTitle(eventId int64, title string, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetTitle(eventId, title)
+	return c.outboxTx(func(c *Calendar) error {
+		return c.editField(OverrideTitle, editType, eventId, func(eventId int64) error {
+			return c.dataStore.SetTitle(eventId, title)
+		})
 	})
 }
 
 // UpdateDescription sets the description of the event
 func (c *Calendar) UpdateDescription(eventId int64, description *string, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetDescription(eventId, description)
+	return c.outboxTx(func(c *Calendar) error {
+		return c.editField(OverrideDescription, editType, eventId, func(eventId int64) error {
+			return c.dataStore.SetDescription(eventId, description)
+		})
 	})
 }
 
 // UpdateUrl sets the url link of the event
 func (c *Calendar) UpdateUrl(eventId int64, url *string, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetUrl(eventId, url)
+	return c.outboxTx(func(c *Calendar) error {
+		return c.editField(OverrideUrl, editType, eventId, func(eventId int64) error {
+			return c.dataStore.SetUrl(eventId, url)
+		})
+	})
+}
+
+// UpdateLocation sets the location of the event, which needs a data store that implements LocationStore
+func (c *Calendar) UpdateLocation(eventId int64, location *string, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[LocationStore](c.dataStore)
+		if !ok {
+			return ErrorLocationNotSupported
+		}
+		return c.editField(OverrideLocation, editType, eventId, func(eventId int64) error {
+			return c.notifyChanges(eventId, func() error {
+				return store.SetLocation(eventId, location)
+			})
+		})
+	})
+}
+
+// UpdateGeo sets the latitude and longitude of the event's location, which needs a data store
+// that implements GeoPointStore
+func (c *Calendar) UpdateGeo(eventId int64, geo *GeoPoint, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		if geo != nil && !geo.Valid() {
+			return ErrorInvalidGeo
+		}
+		store, ok := capability[GeoPointStore](c.dataStore)
+		if !ok {
+			return ErrorGeoNotSupported
+		}
+		return c.editField(OverrideGeo, editType, eventId, func(eventId int64) error {
+			return store.SetGeo(eventId, geo)
+		})
+	})
+}
+
+// UpdateVisibility sets who can see the event, which needs a data store that implements VisibilityStore
+func (c *Calendar) UpdateVisibility(eventId int64, visibility Visibility, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		if !ValidVisibility(visibility) {
+			return ErrorInvalidVisibility
+		}
+		store, ok := capability[VisibilityStore](c.dataStore)
+		if !ok {
+			return ErrorVisibilityNotSupported
+		}
+		return c.editField(OverrideVisibility, editType, eventId, func(eventId int64) error {
+			return store.SetVisibility(eventId, visibility)
+		})
+	})
+}
+
+// UpdateDisallowForwarding sets whether invitees can forward their invitation to other users,
+// which needs a data store that implements ForwardingStore
+func (c *Calendar) UpdateDisallowForwarding(eventId int64, disallow bool, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[ForwardingStore](c.dataStore)
+		if !ok {
+			return ErrorForwardingNotSupported
+		}
+		return c.editField(OverrideDisallowForwarding, editType, eventId, func(eventId int64) error {
+			return store.SetDisallowForwarding(eventId, disallow)
+		})
+	})
+}
+
+// UpdatePriority sets the priority of the event, which needs a data store that implements PriorityStore
+func (c *Calendar) UpdatePriority(eventId int64, priority Priority, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		if !ValidPriority(priority) {
+			return ErrorInvalidPriority
+		}
+		store, ok := capability[PriorityStore](c.dataStore)
+		if !ok {
+			return ErrorPriorityNotSupported
+		}
+		return c.editField(OverridePriority, editType, eventId, func(eventId int64) error {
+			return store.SetPriority(eventId, priority)
+		})
 	})
 }
 
 // UpdateUserData sets the user data for the event
 func (c *Calendar) UpdateUserData(eventId int64, userData map[string]interface{}, editType RepeatEditType) error {
-	return c.dataStore.SetUserData(eventId, userData)
+	return c.outboxTx(func(c *Calendar) error {
+		if err := c.authorize(OperationUpdate, eventId, nil); err != nil {
+			return err
+		}
+		if err := c.checkLocks(RepeatEditTypeThis, eventId); err != nil {
+			return err
+		}
+		return c.ifMatched(eventId, func() error {
+			if len(c.customFields) > 0 {
+				e, err := c.dataStore.Get(eventId)
+				if err != nil {
+					return err
+				}
+				if e == nil {
+					return ErrorEventNotFound
+				}
+				if err := ValidateCustomFields(c.customFields[e.EventType], userData); err != nil {
+					return err
+				}
+			}
+			if err := c.dataStore.SetUserData(eventId, userData); err != nil {
+				return err
+			}
+			return c.publish(ChangeTypeUpdated, eventId, nil)
+		})
+	})
 }
 
 // ///////////////////////
 // Invites
 // ///////////////////////
 
-// GetInvitation grabs a single matching invite from the data store or nil if it does not exist
+// GetInvitation grabs a single matching invite from the data store or nil if it does not exist.
+// If the user doesn't have an invite to the event, then their series invite is returned.
 func (c *Calendar) GetInvitation(eventId int64, userId int64) (*Invite, error) {
-	return c.dataStore.GetInvite(eventId, userId)
+	invite, err := c.dataStore.GetInvite(eventId, userId)
+	if err != nil || invite != nil {
+		return invite, err
+	}
+	return c.getSeriesInvite(eventId, userId)
 }
 
 // AcceptInvitation changes the status of an invitation to InviteStatusConfirmed
 func (c *Calendar) AcceptInvitation(eventId int64, userId int64, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetInviteStatus(eventId, userId, InviteStatusConfirmed)
+	return c.setInviteStatus(eventId, userId, InviteStatusConfirmed, editType)
+}
+
+// AcceptInvitationWithConflicts accepts the invitation like AcceptInvitation and returns the
+// user's other active events that overlap with the accepted events, so that the user can be
+// warned about them. The events that are accepted together (like the events of a series) are
+// not conflicts of each other.
+func (c *Calendar) AcceptInvitationWithConflicts(eventId int64, userId int64, editType RepeatEditType) ([]*Event, error) {
+	if err := c.AcceptInvitation(eventId, userId, editType); err != nil {
+		return nil, err
+	}
+	seen := map[int64]bool{}
+	var ids []int64
+	err := c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
+		seen[eventId] = true
+		ids = append(ids, eventId)
+		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []*Event
+	for _, id := range ids {
+		e, err := c.dataStore.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		if e == nil || e.Status != StatusActive {
+			continue
+		}
+		others, err := c.conflictingEvents(userId, *e)
+		if err != nil {
+			return nil, err
+		}
+		for _, other := range others {
+			if !seen[other.Id] {
+				seen[other.Id] = true
+				conflicts = append(conflicts, other)
+			}
+		}
+	}
+	return Sort(conflicts), nil
+}
+
+// TentativelyAcceptInvitation changes the status of an invitation to InviteStatusTentative
+func (c *Calendar) TentativelyAcceptInvitation(eventId int64, userId int64, editType RepeatEditType) error {
+	return c.setInviteStatus(eventId, userId, InviteStatusTentative, editType)
+}
+
+// WaitlistInvitation changes the status of an invitation to InviteStatusWaitlisted
+func (c *Calendar) WaitlistInvitation(eventId int64, userId int64, editType RepeatEditType) error {
+	return c.setInviteStatus(eventId, userId, InviteStatusWaitlisted, editType)
 }
 
 // DeclineInvitation changes the status of an invitation to InviteStatusDeclined
 func (c *Calendar) DeclineInvitation(eventId int64, userId int64, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetInviteStatus(eventId, userId, InviteStatusDeclined)
-	})
+	return c.setInviteStatus(eventId, userId, InviteStatusDeclined, editType)
 }
 
 // RevokeInvitation changes the status of an invitation to InviteStatusRevoked (we never delete things)
 func (c *Calendar) RevokeInvitation(eventId int64, userId int64, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetInviteStatus(eventId, userId, InviteStatusRevoked)
-	})
+	return c.setInviteStatus(eventId, userId, InviteStatusRevoked, editType)
 }
 
-// InviteUser creates a pending invitation for a user on an event
+// InviteUser creates a pending invitation for a user on an event. Inviting a user to all of the
+// events of a repeating series creates a series invite if the data store supports it (see InviteUserToSeries).
 func (c *Calendar) InviteUser(eventId int64, userId int64, permission Permission, editType RepeatEditType) error {
-	now := time.Now()
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
+	return c.outboxTx(func(c *Calendar) error {
+		if _, ok := capability[SeriesInviteStore](c.dataStore); ok && editType == RepeatEditTypeAll {
+			e, err := c.Get(eventId)
+			if err != nil {
+				return err
+			}
+			if e != nil && e.IsRepeating && e.ParentId != nil {
+				return c.InviteUserToSeries(eventId, userId, permission)
+			}
+		}
+		if err := c.checkInvitePolicies(eventId, Invite{EventId: eventId, UserId: userId, Status: InviteStatusPending, Permission: permission}); err != nil {
+			return err
+		}
+		now := time.Now()
+		err := c.editInvites(OperationInvite, editType, eventId, userId, func(eventId int64) error {
+			i := Invite{
+				EventId:    eventId,
+				UserId:     userId,
+				Status:     InviteStatusPending,
+				Permission: permission,
+				Created:    now,
+			}
+			i.Updated = i.Created
+			if err := ValidateInvite(i); err != nil {
+				return err
+			}
+			if _, err := c.dataStore.AddInvite(i); err != nil {
+				return err
+			}
+			return c.applyAutoResponse(eventId, userId)
+		})
+		if err != nil {
+			return err
+		}
+		return c.notifyInvited(eventId, userId)
+	})
+}
+
+// ForwardInvitation invites the toUserId to the event as an invitee on behalf of the fromUserId,
+// who must have PermissionInvite, and records who forwarded it on the new invite. The owner can
+// stop invitees from forwarding with UpdateDisallowForwarding.
+func (c *Calendar) ForwardInvitation(eventId int64, fromUserId int64, toUserId int64) error {
+	return c.outboxTx(func(c *Calendar) error {
+		e, err := c.dataStore.Get(eventId)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrorEventNotFound
+		}
+		if e.DisallowForwarding {
+			return ErrorForwardingNotAllowed
+		}
+		from, err := c.GetInvitation(eventId, fromUserId)
+		if err != nil {
+			return err
+		}
+		if from == nil || from.Status == InviteStatusRevoked {
+			return ErrorInviteNotFound
+		}
+		if !from.Permission.HasFlag(PermissionInvite) {
+			return ErrorForwardingNotAllowed
+		}
+		to, err := c.GetInvitation(eventId, toUserId)
+		if err != nil {
+			return err
+		}
+		if e.OwnerId == toUserId || (to != nil && to.Status != InviteStatusRevoked) {
+			return ErrorAlreadyInvited
+		}
+
 		i := Invite{
-			EventId:    eventId,
-			UserId:     userId,
-			Status:     InviteStatusPending,
-			Permission: permission,
-			Created:    now,
-		}
-		i.Updated = i.Created
-		if err := ValidateInvite(i); err != nil {
+			EventId:     eventId,
+			UserId:      toUserId,
+			Status:      InviteStatusPending,
+			Permission:  PermissionInvitee,
+			ForwardedBy: &fromUserId,
+		}
+		if err := c.checkInvitePolicies(eventId, i); err != nil {
 			return err
 		}
-		_, err := c.dataStore.AddInvite(i)
-		return err
+		err = c.editInvites(OperationInvite, RepeatEditTypeThis, eventId, toUserId, func(eventId int64) error {
+			if _, err := c.dataStore.AddInvite(i); err != nil {
+				return err
+			}
+			return c.applyAutoResponse(eventId, toUserId)
+		})
+		if err != nil {
+			return err
+		}
+		return c.notifyInvited(eventId, toUserId)
 	})
 }
 
 // UpdateInvitationPermission sets the permission of a user on an event
 func (c *Calendar) UpdateInvitationPermission(eventId int64, userId int64, permission Permission, editType RepeatEditType) error {
-	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
-		return c.dataStore.SetInvitePermissions(eventId, userId, permission)
+	return c.outboxTx(func(c *Calendar) error {
+		return c.editInviteOrSeries(OperationInvite, editType, eventId, userId, func(store SeriesInviteStore, parentId int64) error {
+			return store.SetSeriesInvitePermissions(parentId, userId, permission)
+		}, func(eventId int64) error {
+			return c.dataStore.SetInvitePermissions(eventId, userId, permission)
+		})
+	})
+}
+
+// GetPrivateNote gets the note that only the user can see on the event, or nil if there is no note
+func (c *Calendar) GetPrivateNote(eventId int64, userId int64) (*string, error) {
+	invite, err := c.GetInvitation(eventId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrorInviteNotFound
+	}
+	return invite.PrivateNote, nil
+}
+
+// UpdatePrivateNote sets the note that only the user can see on their invitation to the event,
+// which needs a data store that implements PrivateInviteStore
+func (c *Calendar) UpdatePrivateNote(eventId int64, userId int64, note *string, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[PrivateInviteStore](c.dataStore)
+		if !ok {
+			return ErrorPrivateInviteNotSupported
+		}
+		return c.editInviteOrSeries(OperationRespond, editType, eventId, userId, nil, func(eventId int64) error {
+			return store.SetInvitePrivateNote(eventId, userId, note)
+		})
+	})
+}
+
+// UpdateInvitationUserData sets the user data that only the user can see on their invitation to
+// the event, which needs a data store that implements PrivateInviteStore
+func (c *Calendar) UpdateInvitationUserData(eventId int64, userId int64, userData map[string]interface{}, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[PrivateInviteStore](c.dataStore)
+		if !ok {
+			return ErrorPrivateInviteNotSupported
+		}
+		return c.editInviteOrSeries(OperationRespond, editType, eventId, userId, nil, func(eventId int64) error {
+			return store.SetInviteUserData(eventId, userId, userData)
+		})
 	})
 }
 
@@ -240,42 +863,44 @@ func (c *Calendar) getAllRepeatingEventsThisAndAfter(e Event) ([]*Event, error)
 // passed in event, or to the other repeat events based on what edit
 // type is passed in
 func (c *Calendar) applyEditBasedOnRepeatEditType(editType RepeatEditType, eventId int64, f func(eventId int64) error) error {
-	switch editType {
-	case RepeatEditTypeThis:
-		return f(eventId)
-	case RepeatEditTypeAll:
-		e, err := c.Get(eventId)
-		if err != nil {
-			return err
-		}
-		if e == nil {
-			return ErrorEventNotFound
-		}
-		events, err := c.getAllRepeatingEvents(*e)
-		for _, event := range events {
-			err = f(event.Id)
+	return c.ifMatched(eventId, func() error {
+		switch editType {
+		case RepeatEditTypeThis:
+			return f(eventId)
+		case RepeatEditTypeAll:
+			e, err := c.Get(eventId)
 			if err != nil {
 				return err
 			}
-		}
-		return nil
+			if e == nil {
+				return ErrorEventNotFound
+			}
+			events, err := c.getAllRepeatingEvents(*e)
+			for _, event := range events {
+				err = f(event.Id)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
 
-	case RepeatEditTypeThisAndAfter:
-		e, err := c.Get(eventId)
-		if err != nil {
-			return err
-		}
-		if e == nil {
-			return ErrorEventNotFound
-		}
-		events, err := c.getAllRepeatingEventsThisAndAfter(*e)
-		for _, event := range events {
-			err = f(event.Id)
+		case RepeatEditTypeThisAndAfter:
+			e, err := c.Get(eventId)
 			if err != nil {
 				return err
 			}
+			if e == nil {
+				return ErrorEventNotFound
+			}
+			events, err := c.getAllRepeatingEventsThisAndAfter(*e)
+			for _, event := range events {
+				err = f(event.Id)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
 		}
-		return nil
-	}
-	return ErrorInvalidRepeatEditType
+		return ErrorInvalidRepeatEditType
+	})
 }
@@ -76,6 +76,48 @@ func TestCalendar(t *testing.T) {
 	assert.Equal(t, InviteStatusDeclined, invite.Status)
 }
 
+func TestPrivateNote(t *testing.T) {
+	d := &InMemoryDataStore{}
+	c := NewCalendar(d)
+
+	a, _, err := c.Create(Event{
+		OwnerId:  1,
+		StartDay: "2008-01-01",
+		EndDay:   "2008-01-01",
+		IsAllDay: true,
+		Zone:     "UTC",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeThis))
+
+	note, err := c.GetPrivateNote(a.Id, 7)
+	require.NoError(t, err)
+	assert.Nil(t, note)
+
+	text := "bring the report"
+	require.NoError(t, c.UpdatePrivateNote(a.Id, 7, &text, RepeatEditTypeThis))
+	note, err = c.GetPrivateNote(a.Id, 7)
+	require.NoError(t, err)
+	require.NotNil(t, note)
+	assert.Equal(t, text, *note)
+
+	// the owner's invite and the shared event don't have the note
+	note, err = c.GetPrivateNote(a.Id, 1)
+	require.NoError(t, err)
+	assert.Nil(t, note)
+	assert.Nil(t, a.Description)
+
+	require.NoError(t, c.UpdateInvitationUserData(a.Id, 7, map[string]interface{}{"color": "red"}, RepeatEditTypeThis))
+	invite, err := c.GetInvitation(a.Id, 7)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"color": "red"}, invite.UserData)
+	assert.Nil(t, a.UserData)
+
+	_, err = c.GetPrivateNote(a.Id, 8)
+	assert.Equal(t, ErrorInviteNotFound, err)
+	assert.Equal(t, ErrorInviteNotFound, c.UpdatePrivateNote(a.Id, 8, &text, RepeatEditTypeThis))
+}
+
 func TestCalendarQueries(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -551,3 +593,138 @@ func foreach(events []*Event, f func(e Event)) {
 		}
 	}
 }
+
+func TestPublicEvents(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	create := func(title string, day string, visibility Visibility) *Event {
+		e, _, err := c.Create(Event{OwnerId: 1, Title: title, Visibility: visibility, StartDay: day, EndDay: day, IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	create("private", "2008-01-01", VisibilityPrivate)
+	create("concert", "2008-01-01", VisibilityPublic)
+	removed := create("removed", "2008-01-01", VisibilityPublic)
+	require.NoError(t, c.Remove(removed.Id, RepeatEditTypeThis))
+	later := create("later", "2008-01-02", VisibilityPrivate)
+	require.NoError(t, c.UpdateVisibility(later.Id, VisibilityPublic, RepeatEditTypeThis))
+	assert.Contains(t, later.MarshallToICal(), "CLASS:PUBLIC")
+
+	// the user ids are ignored so that anyone can see public events
+	events, err := c.PublicEvents(Query{UserIds: []int64{99}, Visibilities: []Visibility{VisibilityPrivate}})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "concert", events[0].Title)
+	assert.Equal(t, "later", events[1].Title)
+
+	assert.Equal(t, ErrorInvalidVisibility, c.UpdateVisibility(later.Id, 5, RepeatEditTypeThis))
+}
+
+func TestQueryUserIdsUsesInvites(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionViewer, RepeatEditTypeThis))
+
+	count := func(userId int64) int {
+		events, err := c.Query(Query{UserIds: []int64{userId}})
+		require.NoError(t, err)
+		return len(events)
+	}
+	assert.Equal(t, 1, count(1))
+	assert.Equal(t, 1, count(2))
+	assert.Equal(t, 0, count(3))
+
+	require.NoError(t, c.DeclineInvitation(e.Id, 2, RepeatEditTypeThis))
+	assert.Equal(t, 0, count(2))
+}
+
+func TestCreateTruncated(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e := Event{
+		StartDay:    "2008-01-01",
+		EndDay:      "2008-01-01",
+		Zone:        "UTC",
+		IsAllDay:    true,
+		IsRepeating: true,
+		Repeat:      &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: MaxRepeatOccurrence + 5},
+	}
+	_, _, err := c.Create(e)
+	require.Equal(t, ErrorRepeatOccurrenceTooLarge, err)
+
+	a, count, dropped, err := c.CreateTruncated(e)
+	require.NoError(t, err)
+	require.NotNil(t, a)
+	assert.Equal(t, MaxRepeatOccurrence, count)
+	assert.Equal(t, int64(5), dropped)
+	assert.Equal(t, MaxRepeatOccurrence, a.Repeat.RepeatOccurrences)
+}
+
+func TestAcceptInvitationWithConflicts(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	create := func(ownerId int64, day, startTime, endTime string) *Event {
+		e, _, err := c.Create(Event{OwnerId: ownerId, StartDay: day, StartTime: startTime, EndDay: day, EndTime: endTime, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	oneOnOne := create(1, "2008-01-01", "09:30", "10:00")
+	create(1, "2008-01-01", "10:00", "11:00")
+	canceled := create(1, "2008-01-01", "09:00", "09:30")
+	require.NoError(t, c.Cancel(canceled.Id, RepeatEditTypeThis))
+	declined := create(2, "2008-01-01", "09:00", "10:00")
+	require.NoError(t, c.InviteUser(declined.Id, 1, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.DeclineInvitation(declined.Id, 1, RepeatEditTypeThis))
+
+	e := create(2, "2008-01-01", "09:00", "10:00")
+	require.NoError(t, c.InviteUser(e.Id, 1, PermissionInvitee, RepeatEditTypeThis))
+	conflicts, err := c.AcceptInvitationWithConflicts(e.Id, 1, RepeatEditTypeThis)
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, oneOnOne.Id, conflicts[0].Id)
+
+	invite, err := c.GetInvitation(e.Id, 1)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusConfirmed, invite.Status)
+
+	series, _, err := c.Create(Event{OwnerId: 2, StartDay: "2008-01-01", StartTime: "09:45", EndDay: "2008-01-01", EndTime: "10:15", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(series.Id, 1, PermissionInvitee, RepeatEditTypeAll))
+	conflicts, err = c.AcceptInvitationWithConflicts(series.Id, 1, RepeatEditTypeAll)
+	require.NoError(t, err)
+	assert.Len(t, conflicts, 3, "the 1:1, the next meeting, and the accepted event")
+
+	_, err = c.AcceptInvitationWithConflicts(oneOnOne.Id, 3, RepeatEditTypeThis)
+	assert.Error(t, err)
+}
+
+func TestForwardInvitation(t *testing.T) {
+	sender := &testSender{}
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender))
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionRead|PermissionInvite, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionInvitee, RepeatEditTypeThis))
+	sender.sent = nil
+
+	assert.Equal(t, ErrorForwardingNotAllowed, c.ForwardInvitation(e.Id, 3, 4))
+	assert.Equal(t, ErrorInviteNotFound, c.ForwardInvitation(e.Id, 5, 4))
+	assert.Equal(t, ErrorAlreadyInvited, c.ForwardInvitation(e.Id, 2, 3))
+	assert.Equal(t, ErrorAlreadyInvited, c.ForwardInvitation(e.Id, 2, 1))
+
+	require.NoError(t, c.ForwardInvitation(e.Id, 2, 4))
+	invite, err := c.GetInvitation(e.Id, 4)
+	require.NoError(t, err)
+	require.NotNil(t, invite)
+	assert.Equal(t, InviteStatusPending, invite.Status)
+	assert.Equal(t, Permission(PermissionInvitee), invite.Permission)
+	require.NotNil(t, invite.ForwardedBy)
+	assert.Equal(t, int64(2), *invite.ForwardedBy)
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, int64(4), sender.sent[0].UserId)
+
+	// the owner can stop invitees from forwarding
+	require.NoError(t, c.UpdateDisallowForwarding(e.Id, true, RepeatEditTypeThis))
+	assert.Equal(t, ErrorForwardingNotAllowed, c.ForwardInvitation(e.Id, 2, 5))
+	require.NoError(t, c.UpdateDisallowForwarding(e.Id, false, RepeatEditTypeThis))
+	require.NoError(t, c.ForwardInvitation(e.Id, 2, 5))
+}
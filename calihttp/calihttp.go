@@ -0,0 +1,14 @@
+// Package calihttp has HTTP handlers that expose a cali.Calendar to web clients
+package calihttp
+
+import (
+	"errors"
+	"net/http"
+)
+
+// ErrorUnauthorized can be returned by an Authenticator when the request has no valid user
+var ErrorUnauthorized = errors.New("unauthorized")
+
+// Authenticator finds the id of the user that made the request. Any error is
+// returned to the client as a 401 Unauthorized.
+type Authenticator func(r *http.Request) (int64, error)
@@ -0,0 +1,72 @@
+package calihttp
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/Kenoshen/cali"
+)
+
+// FeedHandler serves the ical feed of a feed token (see cali.Calendar.IssueFeedToken), so
+// calendar apps can subscribe to the events of a user with a webcal URL. The token is read
+// from the "token" query parameter, or else from the last part of the path with an optional
+// ".ics" extension:
+//
+//	http.Handle("/feeds/", calihttp.NewFeedHandler(c))
+//
+// A missing, unknown, or revoked token is a 401 Unauthorized.
+type FeedHandler struct {
+	// Calendar is the calendar that has the feed tokens and events
+	Calendar *cali.Calendar
+	// Query is the query of the events in the feed, which is narrowed by the scope of the token
+	Query func(r *http.Request) cali.Query
+}
+
+// NewFeedHandler creates a handler that serves every event of the feeds
+func NewFeedHandler(c *cali.Calendar) *FeedHandler {
+	return &FeedHandler{Calendar: c}
+}
+
+// FeedToken gets the token of the feed from the request
+func FeedToken(r *http.Request) string {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token
+	}
+	token := strings.TrimSuffix(path.Base(r.URL.Path), ".ics")
+	if token == "/" || token == "." {
+		return ""
+	}
+	return token
+}
+
+func (h *FeedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	token := FeedToken(r)
+	if token == "" {
+		http.Error(w, ErrorUnauthorized.Error(), http.StatusUnauthorized)
+		return
+	}
+	var q cali.Query
+	if h.Query != nil {
+		q = h.Query(r)
+	}
+	ical, err := h.Calendar.FeedICal(token, q)
+	if errors.Is(err, cali.ErrorInvalidFeedToken) {
+		http.Error(w, ErrorUnauthorized.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if r.Method == http.MethodGet {
+		_, _ = w.Write([]byte(ical))
+	}
+}
@@ -0,0 +1,40 @@
+package calihttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedHandler(t *testing.T) {
+	c := cali.NewCalendar(&cali.InMemoryDataStore{})
+	_, _, err := c.Create(cali.Event{OwnerId: 1, Title: "Standup", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	token, issued, err := c.IssueFeedToken(1, cali.FeedScope{})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/feeds/", NewFeedHandler(c))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	for _, url := range []string{"/feeds/" + token + ".ics", "/feeds/?token=" + token} {
+		resp, err := http.Get(server.URL + url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode, url)
+		assert.Equal(t, "text/calendar; charset=utf-8", resp.Header.Get("Content-Type"))
+	}
+
+	require.NoError(t, c.RevokeFeedToken(issued.Id))
+	for _, url := range []string{"/feeds/" + token + ".ics", "/feeds/", "/feeds/unknown"} {
+		resp, err := http.Get(server.URL + url)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, url)
+	}
+}
@@ -0,0 +1,138 @@
+package calihttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Kenoshen/cali"
+)
+
+// DefaultWatchBuffer is the number of changes that can be waiting to be written to a client
+const DefaultWatchBuffer = 64
+
+// DefaultKeepAlive is how often a comment is written to idle clients so that proxies
+// don't close the connection
+const DefaultKeepAlive = 30 * time.Second
+
+// WatchHandler streams the change feed of the calendar to the client as Server-Sent
+// Events. Each change is written with the change type as the event name and the JSON
+// of the change as the data, and the client only receives changes to events that
+// they can see. A user whose invitation was declined or revoked gets the change to the
+// invitation without its event, as a notice to remove the event.
+//
+//	http.Handle("/watch", calihttp.NewWatchHandler(c, auth))
+//
+// In the browser this can be read with an EventSource:
+//
+//	new EventSource("/watch").addEventListener("updated", e => JSON.parse(e.data))
+type WatchHandler struct {
+	// Calendar is the calendar that is watched
+	Calendar *cali.Calendar
+	// Authenticate finds the user that is watching
+	Authenticate Authenticator
+	// Visible decides if the user can see the change, the default is VisibleToUser
+	Visible func(c *cali.Calendar, userId int64, change cali.Change) bool
+	// Buffer is the size of the watch channel, the default is DefaultWatchBuffer
+	Buffer int
+	// KeepAlive is how often to write a comment to an idle client, the default is DefaultKeepAlive
+	KeepAlive time.Duration
+}
+
+// NewWatchHandler creates a handler with the default settings
+func NewWatchHandler(c *cali.Calendar, authenticate Authenticator) *WatchHandler {
+	return &WatchHandler{
+		Calendar:     c,
+		Authenticate: authenticate,
+	}
+}
+
+// VisibleToUser returns true if the user can see the event (see canSee), or if the change is
+// to the user's own invitation, so that the client finds out that an event should be hidden
+// when the invitation is declined or revoked
+func VisibleToUser(c *cali.Calendar, userId int64, change cali.Change) bool {
+	if change.UserId != nil && *change.UserId == userId {
+		return true
+	}
+	return canSee(c, userId, change)
+}
+
+// canSee returns true if the user owns the event of the change or has an invitation to it
+// that hasn't been declined or revoked. The Event of a change is only written to the users
+// that can see it, and the rest only get the change without its event.
+func canSee(c *cali.Calendar, userId int64, change cali.Change) bool {
+	if change.Event != nil && change.Event.OwnerId == userId {
+		return true
+	}
+	invite, err := c.GetInvitation(change.EventId, userId)
+	return err == nil && invite != nil && invite.Status != cali.InviteStatusDeclined && invite.Status != cali.InviteStatusRevoked
+}
+
+func (h *WatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	userId, err := h.Authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	visible := h.Visible
+	if visible == nil {
+		visible = VisibleToUser
+	}
+	buffer := h.Buffer
+	if buffer <= 0 {
+		buffer = DefaultWatchBuffer
+	}
+	keepAlive := h.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = DefaultKeepAlive
+	}
+
+	changes, stop := h.Calendar.Watch(buffer)
+	defer stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	var id int64
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case change, ok := <-changes:
+			if !ok {
+				return
+			}
+			if !visible(h.Calendar, userId, change) {
+				continue
+			}
+			if !canSee(h.Calendar, userId, change) {
+				change.Event = nil
+			}
+			data, err := json.Marshal(change)
+			if err != nil {
+				continue
+			}
+			id++
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, change.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
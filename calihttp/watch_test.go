@@ -0,0 +1,207 @@
+package calihttp
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userFromHeader(r *http.Request) (int64, error) {
+	userId, err := strconv.ParseInt(r.Header.Get("X-User"), 10, 64)
+	if err != nil {
+		return 0, ErrorUnauthorized
+	}
+	return userId, nil
+}
+
+// lockedStore makes an InMemoryDataStore safe for the handler to read while the test writes
+type lockedStore struct {
+	mu sync.Mutex
+	d  cali.InMemoryDataStore
+}
+
+func (s *lockedStore) Create(event cali.Event) (*cali.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.Create(event)
+}
+
+func (s *lockedStore) SetTime(eventId int64, startTime, endTime string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetTime(eventId, startTime, endTime)
+}
+
+func (s *lockedStore) SetDayTime(eventId int64, startDay, startTime, endDay, endTime, zone string, isAllDay bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetDayTime(eventId, startDay, startTime, endDay, endTime, zone, isAllDay)
+}
+
+func (s *lockedStore) SetStatus(eventId int64, status cali.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetStatus(eventId, status)
+}
+
+func (s *lockedStore) SetTitle(eventId int64, title string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetTitle(eventId, title)
+}
+
+func (s *lockedStore) SetDescription(eventId int64, description *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetDescription(eventId, description)
+}
+
+func (s *lockedStore) SetUrl(eventId int64, url *string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetUrl(eventId, url)
+}
+
+func (s *lockedStore) SetUserData(eventId int64, userData map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetUserData(eventId, userData)
+}
+
+func (s *lockedStore) Get(eventId int64) (*cali.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.Get(eventId)
+}
+
+func (s *lockedStore) Query(q cali.Query) ([]*cali.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.Query(q)
+}
+
+func (s *lockedStore) AddInvite(invite cali.Invite) (*cali.Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.AddInvite(invite)
+}
+
+func (s *lockedStore) SetInviteStatus(eventId, userId int64, status cali.InviteStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetInviteStatus(eventId, userId, status)
+}
+
+func (s *lockedStore) SetInvitePermissions(eventId, userId int64, permissions cali.Permission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.SetInvitePermissions(eventId, userId, permissions)
+}
+
+func (s *lockedStore) GetInvite(eventId, userId int64) (*cali.Invite, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.d.GetInvite(eventId, userId)
+}
+
+func TestWatchHandler(t *testing.T) {
+	c := cali.NewCalendar(&lockedStore{})
+	server := httptest.NewServer(NewWatchHandler(c, userFromHeader))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("X-User", "2")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	newEvent := func(ownerId int64) *cali.Event {
+		e, _, err := c.Create(cali.Event{OwnerId: ownerId, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	reader := bufio.NewReader(res.Body)
+	next := func() (string, cali.Change) {
+		var name string
+		var change cali.Change
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				return name, change
+			}
+			if v, ok := strings.CutPrefix(line, "event: "); ok {
+				name = v
+			}
+			if v, ok := strings.CutPrefix(line, "data: "); ok {
+				require.NoError(t, json.Unmarshal([]byte(v), &change))
+			}
+		}
+	}
+
+	// user 2 can't see an event owned by user 1 until they are invited
+	hidden := newEvent(1)
+	require.NoError(t, c.UpdateTitle(hidden.Id, "hidden", cali.RepeatEditTypeThis))
+	own := newEvent(2)
+
+	name, change := next()
+	assert.Equal(t, "created", name)
+	assert.Equal(t, own.Id, change.EventId)
+
+	require.NoError(t, c.InviteUser(hidden.Id, 2, cali.PermissionInvitee, cali.RepeatEditTypeThis))
+	name, change = next()
+	assert.Equal(t, "invite", name)
+	assert.Equal(t, hidden.Id, change.EventId)
+
+	require.NoError(t, c.UpdateTitle(hidden.Id, "shared", cali.RepeatEditTypeThis))
+	name, change = next()
+	assert.Equal(t, "updated", name)
+	assert.Equal(t, "shared", change.Event.Title)
+
+	// after the invitation is revoked, user 2 only gets a notice without the event
+	require.NoError(t, c.RevokeInvitation(hidden.Id, 2, cali.RepeatEditTypeThis))
+	name, change = next()
+	assert.Equal(t, "invite", name)
+	assert.Equal(t, hidden.Id, change.EventId)
+	assert.Nil(t, change.Event)
+
+	require.NoError(t, c.UpdateTitle(hidden.Id, "secret", cali.RepeatEditTypeThis))
+	require.NoError(t, c.UpdateTitle(own.Id, "mine", cali.RepeatEditTypeThis))
+	name, change = next()
+	assert.Equal(t, "updated", name)
+	assert.Equal(t, own.Id, change.EventId, "the changes to the revoked event are skipped")
+
+	// and the same after declining
+	declined := newEvent(1)
+	require.NoError(t, c.UpdateTitle(own.Id, "mine again", cali.RepeatEditTypeThis))
+	_, change = next()
+	assert.Equal(t, own.Id, change.EventId, "the handler is past the new event before the invitation")
+	require.NoError(t, c.InviteUser(declined.Id, 2, cali.PermissionInvitee, cali.RepeatEditTypeThis))
+	_, change = next()
+	assert.Equal(t, declined.Id, change.EventId)
+	require.NoError(t, c.DeclineInvitation(declined.Id, 2, cali.RepeatEditTypeThis))
+	name, change = next()
+	assert.Equal(t, "invite", name)
+	assert.Nil(t, change.Event)
+	require.NoError(t, c.UpdateTitle(declined.Id, "secret", cali.RepeatEditTypeThis))
+	require.NoError(t, c.UpdateTitle(own.Id, "still mine", cali.RepeatEditTypeThis))
+	_, change = next()
+	assert.Equal(t, own.Id, change.EventId)
+}
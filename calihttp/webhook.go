@@ -0,0 +1,54 @@
+package calihttp
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Kenoshen/cali"
+)
+
+// Webhook posts outbox records to a URL and can be used as the Deliver function of a
+// cali.OutboxDrainer:
+//
+//	drainer := &cali.OutboxDrainer{Store: store, Deliver: calihttp.NewWebhook(url).Deliver}
+//
+// The body is the JSON payload of the record, the Cali-Delivery header has the record id
+// (which is the same every time a record is retried), and the Cali-Kind header has the kind.
+type Webhook struct {
+	// URL is where the records are posted
+	URL string
+	// Client sends the requests, the default is http.DefaultClient
+	Client *http.Client
+}
+
+// NewWebhook posts outbox records to the url
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url}
+}
+
+// Deliver posts the record and returns an error unless the response is a 2xx status
+func (w *Webhook) Deliver(record cali.OutboxRecord) error {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(record.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cali-Delivery", strconv.FormatInt(record.Id, 10))
+	req.Header.Set("Cali-Kind", string(record.Kind))
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package calihttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhook(t *testing.T) {
+	var received []string
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		received = append(received, r.Header.Get("Cali-Delivery")+" "+r.Header.Get("Cali-Kind")+" "+string(b))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL)
+	record := cali.OutboxRecord{Id: 7, Kind: cali.OutboxKindChange, Payload: []byte(`{"type":0}`)}
+	require.NoError(t, webhook.Deliver(record))
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, webhook.Deliver(record))
+	assert.Equal(t, []string{`7 change {"type":0}`, `7 change {"type":0}`}, received)
+}
@@ -0,0 +1,71 @@
+// Package calimq connects a cali.Calendar to a message queue like Kafka or NATS. The
+// package doesn't depend on a client library, so a small adapter is needed for the client
+// that is used, like this one for NATS:
+//
+//	producer := calimq.ProducerFunc(func(m calimq.Message) error {
+//		msg := nats.NewMsg(m.Subject)
+//		msg.Data = m.Payload
+//		for k, v := range m.Headers {
+//			msg.Header.Set(k, v)
+//		}
+//		return conn.PublishMsg(msg)
+//	})
+//
+// or this one for Kafka, where the Key keeps the messages of an event in one partition:
+//
+//	producer := calimq.ProducerFunc(func(m calimq.Message) error {
+//		return writer.WriteMessages(ctx, kafka.Message{Topic: m.Subject, Key: []byte(m.Key), Value: m.Payload})
+//	})
+package calimq
+
+import (
+	"errors"
+)
+
+// SchemaVersion is the version of the Envelope that is published. It is only incremented
+// when a change to the payload would break existing consumers.
+const SchemaVersion = 1
+
+// DefaultPrefix is the start of every subject, like "cali.change.updated"
+const DefaultPrefix = "cali"
+
+// Headers of every message
+const (
+	HeaderSchemaVersion = "Cali-Schema-Version"
+	HeaderDelivery      = "Cali-Delivery"
+	HeaderKind          = "Cali-Kind"
+)
+
+var (
+	// ErrorUnsupportedSchemaVersion is returned when a message has a newer schema version than this package
+	ErrorUnsupportedSchemaVersion = errors.New("unsupported schema version")
+	// ErrorUnknownCommand is returned for a command with an unknown type
+	ErrorUnknownCommand = errors.New("unknown command type")
+	// ErrorInvalidCommand is returned for a command that is missing a field its type needs
+	ErrorInvalidCommand = errors.New("command is missing a required field")
+)
+
+// Message is a single message for a Kafka topic or NATS subject
+type Message struct {
+	// Subject is the Kafka topic or NATS subject
+	Subject string
+	// Key is the partition key, which is the event id so that the messages of an event stay in order
+	Key string
+	// Headers are the message headers (see HeaderSchemaVersion)
+	Headers map[string]string
+	// Payload is the JSON of the Envelope
+	Payload []byte
+}
+
+// Producer sends messages to the queue
+type Producer interface {
+	Produce(m Message) error
+}
+
+// ProducerFunc is a function that can be used as a Producer
+type ProducerFunc func(m Message) error
+
+// Produce calls the function
+func (f ProducerFunc) Produce(m Message) error {
+	return f(m)
+}
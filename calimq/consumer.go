@@ -0,0 +1,274 @@
+package calimq
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strconv"
+	"sync"
+
+	"github.com/Kenoshen/cali"
+)
+
+// CommandType is the calendar operation of a command
+type CommandType string
+
+const (
+	// CommandTypeCreate creates the Event of the command
+	CommandTypeCreate CommandType = "create"
+	// CommandTypeUpdate applies the Update of the command to the event
+	CommandTypeUpdate CommandType = "update"
+	// CommandTypeRSVP accepts or declines the invitation of the user to the event
+	CommandTypeRSVP CommandType = "rsvp"
+)
+
+// Command is the payload of an inbound message
+type Command struct {
+	// SchemaVersion is the SchemaVersion the command was written with
+	SchemaVersion int `json:"schemaVersion"`
+	// Id is unique for every command, and a command with an id that was already applied is skipped
+	Id string `json:"id"`
+	// Type is the operation to apply
+	Type CommandType `json:"type"`
+	// EventId is the event of update and rsvp commands
+	EventId int64 `json:"eventId"`
+	// EditType is which events of a series an update or rsvp command applies to
+	EditType cali.RepeatEditType `json:"editType"`
+	// Event is the new event of a create command
+	Event *cali.Event `json:"event,omitempty"`
+	// Update is the change of an update command
+	Update *Update `json:"update,omitempty"`
+	// UserId is the invited user of an rsvp command
+	UserId int64 `json:"userId"`
+	// Status is the response of an rsvp command, which is either confirmed or declined
+	Status cali.InviteStatus `json:"status"`
+}
+
+// Update has the fields that an update command changes, where nil fields aren't changed
+type Update struct {
+	Title       *string          `json:"title,omitempty"`
+	Description *string          `json:"description,omitempty"`
+	Url         *string          `json:"url,omitempty"`
+	Location    *string          `json:"location,omitempty"`
+	Priority    *cali.Priority   `json:"priority,omitempty"`
+	Visibility  *cali.Visibility `json:"visibility,omitempty"`
+	// StartTime and EndTime are changed together
+	StartTime *string `json:"startTime,omitempty"`
+	EndTime   *string `json:"endTime,omitempty"`
+}
+
+// Message makes the message of the command for the subject, which is keyed by the event id
+// (or the command id of a create command) so that the commands of an event stay in order
+func (cmd Command) Message(subject string) (Message, error) {
+	if cmd.SchemaVersion == 0 {
+		cmd.SchemaVersion = SchemaVersion
+	}
+	b, err := json.Marshal(cmd)
+	if err != nil {
+		return Message{}, err
+	}
+	key := cmd.Id
+	if cmd.Type != CommandTypeCreate {
+		key = strconv.FormatInt(cmd.EventId, 10)
+	}
+	return Message{
+		Subject: subject,
+		Key:     key,
+		Headers: map[string]string{HeaderSchemaVersion: strconv.Itoa(cmd.SchemaVersion)},
+		Payload: b,
+	}, nil
+}
+
+// ProcessedStore remembers the ids of the commands that were applied. It should be shared
+// by every consumer of the queue, so it is usually kept in a database.
+type ProcessedStore interface {
+	// IsProcessed returns true if the command id was marked as processed
+	IsProcessed(id string) (bool, error)
+	// MarkProcessed saves the command id
+	MarkProcessed(id string) error
+}
+
+// InMemoryProcessedStore is a ProcessedStore for a single process
+type InMemoryProcessedStore struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+func (s *InMemoryProcessedStore) IsProcessed(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ids[id], nil
+}
+
+func (s *InMemoryProcessedStore) MarkProcessed(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ids == nil {
+		s.ids = map[string]bool{}
+	}
+	s.ids[id] = true
+	return nil
+}
+
+// DefaultWorkers is the number of commands a Consumer applies at the same time
+const DefaultWorkers = 4
+
+// Consumer applies the commands of inbound messages to a calendar. A command id is only
+// marked as processed after the command is applied, so a command that is delivered again
+// (or after a failure) is applied at most once.
+type Consumer struct {
+	// Calendar is where the commands are applied
+	Calendar *cali.Calendar
+	// Processed remembers the applied command ids
+	Processed ProcessedStore
+	// Workers is the number of commands applied at the same time by Run, the default is DefaultWorkers
+	Workers int
+	// OnError is called by Run with every message that couldn't be applied, and can be nil
+	OnError func(m Message, err error)
+}
+
+// NewConsumer applies commands to the calendar with the default settings
+func NewConsumer(c *cali.Calendar, processed ProcessedStore) *Consumer {
+	return &Consumer{Calendar: c, Processed: processed}
+}
+
+// Handle applies the command of a single message. Brokers that acknowledge messages should
+// only acknowledge the message if there is no error.
+func (c *Consumer) Handle(m Message) error {
+	var cmd Command
+	if err := json.Unmarshal(m.Payload, &cmd); err != nil {
+		return err
+	}
+	if cmd.SchemaVersion > SchemaVersion {
+		return ErrorUnsupportedSchemaVersion
+	}
+	if cmd.Id == "" {
+		return ErrorInvalidCommand
+	}
+	processed, err := c.Processed.IsProcessed(cmd.Id)
+	if err != nil || processed {
+		return err
+	}
+	if err := c.apply(cmd); err != nil {
+		return err
+	}
+	return c.Processed.MarkProcessed(cmd.Id)
+}
+
+// Run applies the messages until the channel is closed or the context is done. The messages
+// are split between the workers by their key, so the commands of an event are applied in
+// the order they were received while the commands of other events are applied in parallel.
+func (c *Consumer) Run(ctx context.Context, messages <-chan Message) error {
+	workers := c.Workers
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	queues := make([]chan Message, workers)
+	var wg sync.WaitGroup
+	for i := range queues {
+		queues[i] = make(chan Message)
+		wg.Add(1)
+		go func(queue <-chan Message) {
+			defer wg.Done()
+			for m := range queue {
+				if err := c.Handle(m); err != nil && c.OnError != nil {
+					c.OnError(m, err)
+				}
+			}
+		}(queues[i])
+	}
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case m, ok := <-messages:
+			if !ok {
+				return nil
+			}
+			h := fnv.New32a()
+			h.Write([]byte(m.Key))
+			queue := queues[h.Sum32()%uint32(workers)]
+			select {
+			case queue <- m:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (c *Consumer) apply(cmd Command) error {
+	switch cmd.Type {
+	case CommandTypeCreate:
+		if cmd.Event == nil {
+			return ErrorInvalidCommand
+		}
+		_, _, err := c.Calendar.Create(*cmd.Event)
+		return err
+	case CommandTypeUpdate:
+		if cmd.Update == nil {
+			return ErrorInvalidCommand
+		}
+		return c.update(cmd.EventId, *cmd.Update, cmd.EditType)
+	case CommandTypeRSVP:
+		switch cmd.Status {
+		case cali.InviteStatusConfirmed:
+			return c.Calendar.AcceptInvitation(cmd.EventId, cmd.UserId, cmd.EditType)
+		case cali.InviteStatusTentative:
+			return c.Calendar.TentativelyAcceptInvitation(cmd.EventId, cmd.UserId, cmd.EditType)
+		case cali.InviteStatusWaitlisted:
+			return c.Calendar.WaitlistInvitation(cmd.EventId, cmd.UserId, cmd.EditType)
+		case cali.InviteStatusDeclined:
+			return c.Calendar.DeclineInvitation(cmd.EventId, cmd.UserId, cmd.EditType)
+		}
+		return cali.ErrorInvalidInviteStatus
+	}
+	return ErrorUnknownCommand
+}
+
+func (c *Consumer) update(eventId int64, u Update, editType cali.RepeatEditType) error {
+	if (u.StartTime == nil) != (u.EndTime == nil) {
+		return ErrorInvalidCommand
+	}
+	if u.Title != nil {
+		if err := c.Calendar.UpdateTitle(eventId, *u.Title, editType); err != nil {
+			return err
+		}
+	}
+	if u.Description != nil {
+		if err := c.Calendar.UpdateDescription(eventId, u.Description, editType); err != nil {
+			return err
+		}
+	}
+	if u.Url != nil {
+		if err := c.Calendar.UpdateUrl(eventId, u.Url, editType); err != nil {
+			return err
+		}
+	}
+	if u.Location != nil {
+		if err := c.Calendar.UpdateLocation(eventId, u.Location, editType); err != nil {
+			return err
+		}
+	}
+	if u.Priority != nil {
+		if err := c.Calendar.UpdatePriority(eventId, *u.Priority, editType); err != nil {
+			return err
+		}
+	}
+	if u.Visibility != nil {
+		if err := c.Calendar.UpdateVisibility(eventId, *u.Visibility, editType); err != nil {
+			return err
+		}
+	}
+	if u.StartTime != nil {
+		return c.Calendar.UpdateTime(eventId, *u.StartTime, *u.EndTime, editType)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package calimq
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsumerHandle(t *testing.T) {
+	c := cali.NewCalendar(&cali.InMemoryDataStore{})
+	consumer := NewConsumer(c, &InMemoryProcessedStore{})
+	handle := func(cmd Command) error {
+		m, err := cmd.Message("cali.commands")
+		require.NoError(t, err)
+		return consumer.Handle(m)
+	}
+
+	create := Command{Id: "1", Type: CommandTypeCreate, Event: &cali.Event{OwnerId: 1, Title: "Lunch", StartDay: "2008-01-01", EndDay: "2008-01-01", StartTime: "12:00", EndTime: "13:00", Zone: "UTC"}}
+	require.NoError(t, handle(create))
+	require.NoError(t, handle(create), "a repeated command is skipped")
+	events, err := c.Query(cali.Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	eventId := events[0].Id
+
+	title, location, start, end := "Team lunch", "Cafe", "12:30", "13:30"
+	require.NoError(t, handle(Command{Id: "2", Type: CommandTypeUpdate, EventId: eventId, Update: &Update{Title: &title, Location: &location, StartTime: &start, EndTime: &end}}))
+	require.NoError(t, c.InviteUser(eventId, 2, cali.PermissionViewer, cali.RepeatEditTypeThis))
+	require.NoError(t, handle(Command{Id: "3", Type: CommandTypeRSVP, EventId: eventId, UserId: 2, Status: cali.InviteStatusConfirmed}))
+
+	e, err := c.Get(eventId)
+	require.NoError(t, err)
+	assert.Equal(t, "Team lunch", e.Title)
+	assert.Equal(t, "Cafe", *e.Location)
+	assert.Equal(t, "12:30", e.StartTime)
+	assert.Equal(t, "13:30", e.EndTime)
+	invite, err := c.GetInvitation(eventId, 2)
+	require.NoError(t, err)
+	assert.Equal(t, cali.InviteStatusConfirmed, invite.Status)
+
+	// a failed command isn't marked as processed, so it can be applied again
+	assert.Equal(t, cali.ErrorInvalidInviteStatus, handle(Command{Id: "4", Type: CommandTypeRSVP, EventId: eventId, UserId: 2, Status: cali.InviteStatusRevoked}))
+	require.NoError(t, handle(Command{Id: "4", Type: CommandTypeRSVP, EventId: eventId, UserId: 2, Status: cali.InviteStatusDeclined}))
+	invite, err = c.GetInvitation(eventId, 2)
+	require.NoError(t, err)
+	assert.Equal(t, cali.InviteStatusDeclined, invite.Status)
+
+	assert.Equal(t, ErrorUnknownCommand, handle(Command{Id: "5", Type: "delete"}))
+	assert.Equal(t, ErrorInvalidCommand, handle(Command{Type: CommandTypeCreate}))
+	assert.Equal(t, ErrorInvalidCommand, handle(Command{Id: "6", Type: CommandTypeUpdate, EventId: eventId, Update: &Update{StartTime: &start}}))
+	assert.Equal(t, ErrorUnsupportedSchemaVersion, handle(Command{Id: "7", SchemaVersion: SchemaVersion + 1}))
+}
+
+func TestConsumerRun(t *testing.T) {
+	c := cali.NewCalendar(&cali.InMemoryDataStore{})
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		e, _, err := c.Create(cali.Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		ids = append(ids, e.Id)
+	}
+
+	var mu sync.Mutex
+	var failed []error
+	consumer := NewConsumer(c, &InMemoryProcessedStore{})
+	consumer.OnError = func(m Message, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, err)
+	}
+	messages := make(chan Message)
+	done := make(chan error)
+	go func() { done <- consumer.Run(context.Background(), messages) }()
+
+	// the titles of each event are applied in order, so the last one wins
+	titles := []string{"a", "b", "c", "d", "e"}
+	for _, title := range titles {
+		for _, id := range ids {
+			title := title
+			m, err := Command{Id: fmt.Sprintf("%v-%v", title, id), Type: CommandTypeUpdate, EventId: id, Update: &Update{Title: &title}}.Message("cali.commands")
+			require.NoError(t, err)
+			messages <- m
+		}
+	}
+	messages <- Message{Payload: []byte("{")}
+	close(messages)
+	require.NoError(t, <-done)
+
+	for _, id := range ids {
+		e, err := c.Get(id)
+		require.NoError(t, err)
+		assert.Equal(t, "e", e.Title)
+	}
+	assert.Len(t, failed, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, consumer.Run(ctx, make(chan Message)))
+}
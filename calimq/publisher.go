@@ -0,0 +1,131 @@
+package calimq
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/Kenoshen/cali"
+)
+
+// Envelope is the payload of every published message
+type Envelope struct {
+	// SchemaVersion is the SchemaVersion the message was published with
+	SchemaVersion int `json:"schemaVersion"`
+	// Kind is the kind of the message (a cali.OutboxKind)
+	Kind cali.OutboxKind `json:"kind"`
+	// Id is the outbox record id of the message, which is the same every time the record is
+	// retried, or 0 if the message wasn't published from an outbox
+	Id int64 `json:"id,omitempty"`
+	// Change is set for cali.OutboxKindChange messages
+	Change *cali.Change `json:"change,omitempty"`
+	// Notification is set for cali.OutboxKindNotification messages
+	Notification *cali.Notification `json:"notification,omitempty"`
+}
+
+// Publisher publishes the changes and notifications of a calendar. Changes are published
+// to "<prefix>.change.<type>" (like "cali.change.updated") and notifications to
+// "<prefix>.notification". It can be used as the Deliver function of a cali.OutboxDrainer
+// so that every change is published at least once:
+//
+//	drainer := &cali.OutboxDrainer{Store: store, Deliver: calimq.NewPublisher(producer).Deliver}
+//
+// or with Watch to publish changes right away (which drops changes if the process stops).
+type Publisher struct {
+	// Producer sends the messages
+	Producer Producer
+	// Prefix is the start of every subject, the default is DefaultPrefix
+	Prefix string
+}
+
+// NewPublisher publishes with the producer and the default settings
+func NewPublisher(producer Producer) *Publisher {
+	return &Publisher{Producer: producer}
+}
+
+// Deliver publishes the outbox record
+func (p *Publisher) Deliver(record cali.OutboxRecord) error {
+	envelope := Envelope{SchemaVersion: SchemaVersion, Kind: record.Kind, Id: record.Id}
+	switch record.Kind {
+	case cali.OutboxKindChange:
+		change, err := record.Change()
+		if err != nil {
+			return err
+		}
+		envelope.Change = &change
+	case cali.OutboxKindNotification:
+		n, err := record.Notification()
+		if err != nil {
+			return err
+		}
+		envelope.Notification = &n
+	default:
+		return nil
+	}
+	return p.publish(envelope)
+}
+
+// Publish publishes a single change
+func (p *Publisher) Publish(change cali.Change) error {
+	return p.publish(Envelope{SchemaVersion: SchemaVersion, Kind: cali.OutboxKindChange, Change: &change})
+}
+
+// Watch publishes every change of the calendar (see cali.Calendar.Watch) until the returned
+// function is called. Changes that can't be published are passed to onError, which can be nil.
+func (p *Publisher) Watch(c *cali.Calendar, buffer int, onError func(change cali.Change, err error)) func() {
+	changes, stop := c.Watch(buffer)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for change := range changes {
+			if err := p.Publish(change); err != nil && onError != nil {
+				onError(change, err)
+			}
+		}
+	}()
+	return func() {
+		stop()
+		<-done
+	}
+}
+
+func (p *Publisher) publish(envelope Envelope) error {
+	b, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	prefix := p.Prefix
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	m := Message{
+		Headers: map[string]string{
+			HeaderSchemaVersion: strconv.Itoa(envelope.SchemaVersion),
+			HeaderKind:          string(envelope.Kind),
+		},
+		Payload: b,
+	}
+	if envelope.Id != 0 {
+		m.Headers[HeaderDelivery] = strconv.FormatInt(envelope.Id, 10)
+	}
+	if envelope.Change != nil {
+		m.Subject = prefix + ".change." + envelope.Change.Type.String()
+		m.Key = strconv.FormatInt(envelope.Change.EventId, 10)
+	} else {
+		m.Subject = prefix + ".notification"
+		m.Key = strconv.FormatInt(envelope.Notification.Event.Id, 10)
+	}
+	return p.Producer.Produce(m)
+}
+
+// DecodeEnvelope reads the payload of a published message and returns
+// ErrorUnsupportedSchemaVersion if it is newer than SchemaVersion
+func DecodeEnvelope(payload []byte) (Envelope, error) {
+	var envelope Envelope
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return envelope, err
+	}
+	if envelope.SchemaVersion > SchemaVersion {
+		return envelope, ErrorUnsupportedSchemaVersion
+	}
+	return envelope, nil
+}
@@ -0,0 +1,97 @@
+package calimq
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProducer keeps every message it produces
+type testProducer struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func (p *testProducer) Produce(m Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, m)
+	return nil
+}
+
+type noopSender struct{}
+
+func (noopSender) Send(n cali.Notification) error { return nil }
+
+func TestPublisherDeliver(t *testing.T) {
+	d := &cali.InMemoryDataStore{}
+	c := cali.NewCalendar(d, cali.WithOutbox(), cali.WithNotificationSender(noopSender{}))
+	e, _, err := c.Create(cali.Event{OwnerId: 1, Title: "Lunch", StartDay: "2008-01-01", EndDay: "2008-01-01", StartTime: "12:00", EndTime: "13:00", Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, cali.PermissionViewer, cali.RepeatEditTypeThis))
+
+	producer := &testProducer{err: errors.New("broker is down")}
+	publisher := NewPublisher(producer)
+	publisher.Prefix = "test"
+	drainer := &cali.OutboxDrainer{Store: d, Deliver: publisher.Deliver}
+	delivered, err := drainer.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+
+	producer.err = nil
+	delivered, err = drainer.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	require.Len(t, producer.messages, 3)
+
+	key := producer.messages[0].Key
+	assert.Equal(t, []string{"test.change.created", "test.change.invite", "test.notification"}, []string{producer.messages[0].Subject, producer.messages[1].Subject, producer.messages[2].Subject})
+	for _, m := range producer.messages {
+		assert.Equal(t, key, m.Key, "every message of the event should have the same key")
+		assert.Equal(t, "1", m.Headers[HeaderSchemaVersion])
+		assert.NotEmpty(t, m.Headers[HeaderDelivery])
+	}
+
+	envelope, err := DecodeEnvelope(producer.messages[0].Payload)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, envelope.SchemaVersion)
+	assert.Equal(t, cali.OutboxKindChange, envelope.Kind)
+	require.NotNil(t, envelope.Change)
+	assert.Equal(t, e.Id, envelope.Change.EventId)
+	assert.Equal(t, "Lunch", envelope.Change.Event.Title)
+	assert.Nil(t, envelope.Notification)
+
+	envelope, err = DecodeEnvelope(producer.messages[2].Payload)
+	require.NoError(t, err)
+	require.NotNil(t, envelope.Notification)
+	assert.Equal(t, int64(2), envelope.Notification.UserId)
+
+	_, err = DecodeEnvelope([]byte(`{"schemaVersion":2}`))
+	assert.Equal(t, ErrorUnsupportedSchemaVersion, err)
+}
+
+func TestPublisherWatch(t *testing.T) {
+	c := cali.NewCalendar(&cali.InMemoryDataStore{})
+	producer := &testProducer{}
+	stop := NewPublisher(producer).Watch(c, 10, nil)
+	e, _, err := c.Create(cali.Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateTitle(e.Id, "Holiday", cali.RepeatEditTypeThis))
+	stop()
+
+	require.Len(t, producer.messages, 2)
+	assert.Equal(t, "cali.change.created", producer.messages[0].Subject)
+	assert.Equal(t, "cali.change.updated", producer.messages[1].Subject)
+	assert.Empty(t, producer.messages[1].Headers[HeaderDelivery])
+	envelope, err := DecodeEnvelope(producer.messages[1].Payload)
+	require.NoError(t, err)
+	assert.Equal(t, "Holiday", envelope.Change.Event.Title)
+}
@@ -0,0 +1,64 @@
+// Package calisql generates the SQL for cali data stores that are backed by a relational
+// database. The package only uses database/sql, so the application registers the driver:
+//
+//	db, err := calisql.Open("pgx", dsn, calisql.Postgres, calisql.WithMaxOpenConns(50), calisql.WithDebugLog(logger, 100*time.Millisecond))
+//	err = db.QueryEvents(ctx, q, func(rows *sql.Rows) error { ... })
+//
+// Open has defaults for the connection pool and the statement timeouts, while New uses a
+// handle that is already configured and only changes what the options set.
+//
+// The generated SQL expects these tables and columns (other columns are left alone):
+//
+//	events:  id, calendar_id, parent_id, source_id, event_type, status, priority, visibility,
+//	         title, description, floating_start, floating_end
+//	invites: event_id, user_id, status, is_series
+//
+// where floating_start and floating_end are the text of cali.Event.FloatingSpan, so that
+// ranges are compared the same way as cali.Event.InRange, and series invites are in the
+// invites table with is_series set and the parent id of the series as the event_id.
+//
+// Instead of writing a data store, PostgresDataStore can be used with the tables of
+// PostgresMigrations, which add an external_key, a version, and a JSON data column to them:
+//
+//	store := calisql.NewPostgresDataStore(db)
+//	err = store.Migrate(ctx)
+//	c := cali.NewCalendar(store)
+//
+// SQLiteDataStore and SQLiteMigrations are the same for SQLite.
+package calisql
+
+import (
+	"strconv"
+)
+
+// Dialect is the part of the SQL that is different between databases
+type Dialect struct {
+	// Name of the database, like "postgres"
+	Name string
+	// Placeholder gets the bind parameter for the nth (starting at 1) argument
+	Placeholder func(n int) string
+	// Array converts the values of an IN list into a single argument for InListArray,
+	// or is nil if the database can't bind arrays
+	Array func(values []int64) interface{}
+}
+
+// Postgres numbers the parameters ($1) and binds IN lists as arrays. The Array function
+// passes the slice through, which works with pgx. Other drivers may need a wrapper, like
+// pq.Array for lib/pq.
+var Postgres = Dialect{
+	Name:        "postgres",
+	Placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+	Array:       func(values []int64) interface{} { return values },
+}
+
+// SQLite uses "?" parameters and can't bind arrays
+var SQLite = Dialect{
+	Name:        "sqlite",
+	Placeholder: func(int) string { return "?" },
+}
+
+// MySQL uses "?" parameters and can't bind arrays
+var MySQL = Dialect{
+	Name:        "mysql",
+	Placeholder: func(int) string { return "?" },
+}
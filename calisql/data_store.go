@@ -0,0 +1,738 @@
+package calisql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Kenoshen/cali"
+)
+
+// runner runs statements on the database or in a transaction
+type runner interface {
+	Query(ctx context.Context, query string, scan func(rows *sql.Rows) error, args ...interface{}) error
+	Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// dataStore is the cali.DataStore of the SQL data stores. Every event is kept as JSON in the
+// data column of the events table, along with the columns that the Generator queries, so the
+// Set methods read the event, change it, and write the whole row in a transaction. Invites are
+// kept the same way in the invites table.
+type dataStore struct {
+	db        *DB
+	run       runner
+	inTx      bool
+	generator Generator
+	// forUpdate is the clause that locks the row of an event that is read to be changed
+	forUpdate string
+	// check is the version that an event must have to be changed in IfVersion
+	check *versionCheck
+}
+
+// versionCheck is the version that the event must have to be changed (see IfVersion)
+type versionCheck struct {
+	eventId int64
+	version int64
+}
+
+// newDataStore makes the data store for the database
+func newDataStore(db *DB, forUpdate string) *dataStore {
+	generator := db.Generator()
+	generator.Columns = []string{"e.id", "e.parent_id", "e.data"}
+	return &dataStore{db: db, run: db, generator: generator, forUpdate: forUpdate}
+}
+
+// InTx calls f with a data store that makes its changes in one transaction, which is committed
+// if f returns nil and rolled back otherwise. Calls in a transaction join the transaction.
+func (d *dataStore) InTx(f func(tx cali.DataStore) error) error {
+	return d.atomic(func(tx *dataStore) error {
+		return f(tx)
+	})
+}
+
+// IfVersion calls f with a data store whose changes to the event fail with
+// cali.ErrorPreconditionFailed unless the event has the version (see cali.VersionStore)
+func (d *dataStore) IfVersion(eventId int64, version int64, f func(store cali.DataStore) error) error {
+	scoped := *d
+	scoped.check = &versionCheck{eventId: eventId, version: version}
+	return f(&scoped)
+}
+
+// atomic calls f with the data store in a transaction, starting one if there isn't one yet
+func (d *dataStore) atomic(f func(tx *dataStore) error) error {
+	if d.inTx {
+		return f(d)
+	}
+	return d.db.InTx(context.Background(), func(tx *Tx) error {
+		scoped := *d
+		scoped.run, scoped.inTx = tx, true
+		return f(&scoped)
+	})
+}
+
+// bind replaces the "?" parameters of the SQL with the placeholders of the dialect
+func (d *dataStore) bind(query string) string {
+	if d.generator.Dialect.Placeholder == nil {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.generator.Dialect.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d *dataStore) query(query string, scan func(rows *sql.Rows) error, args ...interface{}) error {
+	return d.run.Query(context.Background(), d.bind(query), scan, args...)
+}
+
+func (d *dataStore) exec(query string, args ...interface{}) error {
+	_, err := d.run.Exec(context.Background(), d.bind(query), args...)
+	return err
+}
+
+// ///////////////////////
+// Events
+// ///////////////////////
+
+const eventColumns = "calendar_id, parent_id, source_id, external_key, event_type, status, priority, visibility, title, description, floating_start, floating_end, version, data"
+
+// eventValues gets the values of the eventColumns for the event
+func eventValues(e *cali.Event) ([]interface{}, error) {
+	stored := *e
+	stored.Display, stored.ReactionCounts = nil, nil
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, err
+	}
+	var externalKey interface{}
+	if e.ExternalKey != "" {
+		externalKey = e.ExternalKey
+	}
+	start, end := e.FloatingSpan()
+	return []interface{}{e.CalendarId, nullable(e.ParentId), nullable(e.SourceId), externalKey, e.EventType,
+		int64(e.Status), int64(e.Priority), int64(e.Visibility), e.Title, nullable(e.Description), start, end, e.Version, string(data)}, nil
+}
+
+// nullable gets the value of the pointer, or nil for a NULL
+func nullable[T any](v *T) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// scanEvent reads an event from the id, parent_id, and data columns
+func scanEvent(rows *sql.Rows) (*cali.Event, error) {
+	var id int64
+	var parentId sql.NullInt64
+	var data []byte
+	if err := rows.Scan(&id, &parentId, &data); err != nil {
+		return nil, err
+	}
+	var e cali.Event
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	e.Id, e.ParentId = id, nil
+	if parentId.Valid {
+		e.ParentId = &parentId.Int64
+	}
+	return &e, nil
+}
+
+// events finds the events of the SQL, which selects the id, parent_id, and data columns
+func (d *dataStore) events(query string, args ...interface{}) ([]*cali.Event, error) {
+	var result []*cali.Event
+	err := d.query(query, func(rows *sql.Rows) error {
+		e, err := scanEvent(rows)
+		if err == nil {
+			result = append(result, e)
+		}
+		return err
+	}, args...)
+	return result, err
+}
+
+// event finds the event with the id, or nil if there isn't one, where lock locks its row
+// until the end of the transaction
+func (d *dataStore) event(eventId int64, lock bool) (*cali.Event, error) {
+	query := "SELECT id, parent_id, data FROM events WHERE id = ?"
+	if lock {
+		query += d.forUpdate
+	}
+	events, err := d.events(query, eventId)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+func (d *dataStore) Create(event cali.Event) (*cali.Event, error) {
+	var created *cali.Event
+	err := d.atomic(func(tx *dataStore) error {
+		var err error
+		created, err = tx.create(event)
+		return err
+	})
+	return created, err
+}
+
+// create saves the event and the invite of its owner
+func (d *dataStore) create(event cali.Event) (*cali.Event, error) {
+	if err := cali.Validate(event); err != nil {
+		return nil, err
+	}
+	if event.ExternalKey != "" {
+		existing, err := d.GetByExternalKey(event.ExternalKey)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return nil, cali.ErrorDuplicateExternalKey
+		}
+	}
+	event.Created = time.Now()
+	event.Updated = event.Created
+	event.Version = 1
+	values, err := eventValues(&event)
+	if err != nil {
+		return nil, err
+	}
+	err = d.query("INSERT INTO events ("+eventColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id", func(rows *sql.Rows) error {
+		return rows.Scan(&event.Id)
+	}, values...)
+	if err != nil {
+		return nil, err
+	}
+
+	// if the event is a repeating event, but doesn't have the ParentId
+	// field set, then this must be the first event of the repeat and
+	// should also have its own Id as the ParentId
+	if event.IsRepeating && event.ParentId == nil {
+		event.ParentId = &event.Id
+		if err := d.exec("UPDATE events SET parent_id = ? WHERE id = ?", event.Id, event.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = d.AddInvite(cali.Invite{
+		EventId:    event.Id,
+		UserId:     event.OwnerId,
+		Status:     cali.InviteStatusConfirmed,
+		Permission: cali.PermissionOwner,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (d *dataStore) CreateBatch(events []cali.Event) ([]*cali.Event, error) {
+	result := make([]*cali.Event, 0, len(events))
+	err := d.atomic(func(tx *dataStore) error {
+		for _, event := range events {
+			created, err := tx.create(event)
+			if err != nil {
+				return err
+			}
+			result = append(result, created)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CreateRepeating saves every occurrence of the repeating event in one transaction, so a
+// failure part of the way through doesn't leave half of a series behind
+func (d *dataStore) CreateRepeating(e cali.Event) ([]*cali.Event, error) {
+	events, err := cali.GenerateRepeatEvents(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, cali.ErrorEmptyRepeatingEvents
+	}
+	results := make([]*cali.Event, 0, len(events))
+	err = d.atomic(func(tx *dataStore) error {
+		first, err := tx.create(*events[0])
+		if err != nil {
+			return err
+		}
+		results = append(results, first)
+		for _, event := range events[1:] {
+			event.ParentId = &first.Id
+			// the external key is unique, so only the first event of the series has it
+			event.ExternalKey = ""
+			created, err := tx.create(*event)
+			if err != nil {
+				return err
+			}
+			results = append(results, created)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// update changes the event with f and saves it with a new Updated and Version. The row is only
+// written if it still has the version that was read (or the version of IfVersion), so a change
+// that is made at the same time fails with cali.ErrorPreconditionFailed instead of being lost.
+func (d *dataStore) update(eventId int64, f func(e *cali.Event) error) error {
+	return d.atomic(func(tx *dataStore) error {
+		e, err := tx.event(eventId, true)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return cali.ErrorEventNotFound
+		}
+		version := e.Version
+		check := tx.check
+		if check == nil || check.eventId != eventId {
+			check = nil
+		} else if check.version != version {
+			return cali.ErrorPreconditionFailed
+		}
+		if err := f(e); err != nil {
+			return err
+		}
+		e.Updated = time.Now()
+		e.Version++
+		values, err := eventValues(e)
+		if err != nil {
+			return err
+		}
+		columns := strings.Split(eventColumns, ", ")
+		result, err := tx.run.Exec(context.Background(), tx.bind("UPDATE events SET "+strings.Join(columns, " = ?, ")+" = ? WHERE id = ? AND version = ?"), append(values, eventId, version)...)
+		if err != nil {
+			return err
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return err
+		} else if updated == 0 {
+			return cali.ErrorPreconditionFailed
+		}
+		if check != nil {
+			check.version = e.Version
+		}
+		return nil
+	})
+}
+
+func (d *dataStore) SetTime(eventId int64, startTime, endTime string) error {
+	if err := cali.ValidateTimeValues(startTime, endTime); err != nil {
+		return err
+	}
+	return d.update(eventId, func(e *cali.Event) error {
+		e.StartTime, e.EndTime = startTime, endTime
+		cali.SyncDuration(e)
+		return nil
+	})
+}
+
+func (d *dataStore) SetDayTime(eventId int64, startDay, startTime, endDay, endTime, zone string, isAllDay bool) error {
+	if err := cali.ValidateDayTimeValues(startDay, startTime, endDay, endTime, zone, isAllDay); err != nil {
+		return err
+	}
+	return d.update(eventId, func(e *cali.Event) error {
+		e.StartDay, e.StartTime, e.EndDay, e.EndTime = startDay, startTime, endDay, endTime
+		e.Zone, e.IsAllDay = zone, isAllDay
+		cali.SyncDuration(e)
+		return nil
+	})
+}
+
+func (d *dataStore) SetStatus(eventId int64, status cali.Status) error {
+	if !cali.ValidStatus(status) {
+		return cali.ErrorInvalidStatus
+	}
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Status = status
+		return nil
+	})
+}
+
+func (d *dataStore) SetTitle(eventId int64, title string) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Title = title
+		return nil
+	})
+}
+
+func (d *dataStore) SetDescription(eventId int64, description *string) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Description = description
+		return nil
+	})
+}
+
+func (d *dataStore) SetUrl(eventId int64, url *string) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Url = url
+		return nil
+	})
+}
+
+func (d *dataStore) SetLinks(eventId int64, links []cali.Link) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Links = links
+		return nil
+	})
+}
+
+func (d *dataStore) SetLocation(eventId int64, location *string) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Location = location
+		return nil
+	})
+}
+
+func (d *dataStore) SetGeo(eventId int64, geo *cali.GeoPoint) error {
+	if geo != nil && !geo.Valid() {
+		return cali.ErrorInvalidGeo
+	}
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Geo = geo
+		return nil
+	})
+}
+
+func (d *dataStore) SetVisibility(eventId int64, visibility cali.Visibility) error {
+	if !cali.ValidVisibility(visibility) {
+		return cali.ErrorInvalidVisibility
+	}
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Visibility = visibility
+		return nil
+	})
+}
+
+func (d *dataStore) SetDisallowForwarding(eventId int64, disallow bool) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.DisallowForwarding = disallow
+		return nil
+	})
+}
+
+func (d *dataStore) SetPriority(eventId int64, priority cali.Priority) error {
+	if !cali.ValidPriority(priority) {
+		return cali.ErrorInvalidPriority
+	}
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Priority = priority
+		return nil
+	})
+}
+
+func (d *dataStore) SetConference(eventId int64, conference *cali.Conference) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Conference = conference
+		return nil
+	})
+}
+
+func (d *dataStore) SetUserData(eventId int64, userData map[string]interface{}) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.UserData = userData
+		return nil
+	})
+}
+
+func (d *dataStore) SetCancelReason(eventId int64, reason *string) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.CancelReason = reason
+		return nil
+	})
+}
+
+func (d *dataStore) SetOverrides(eventId int64, overrides []string) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.Overrides = overrides
+		return nil
+	})
+}
+
+func (d *dataStore) SetRecurrenceId(eventId int64, recurrenceId string) error {
+	return d.update(eventId, func(e *cali.Event) error {
+		e.RecurrenceId = recurrenceId
+		return nil
+	})
+}
+
+func (d *dataStore) GetByExternalKey(key string) (*cali.Event, error) {
+	if key == "" {
+		return nil, nil
+	}
+	events, err := d.events("SELECT id, parent_id, data FROM events WHERE external_key = ?", key)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+func (d *dataStore) SetExternalKey(eventId int64, key string) error {
+	return d.atomic(func(tx *dataStore) error {
+		existing, err := tx.GetByExternalKey(key)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Id == eventId {
+			return nil
+		}
+		if existing != nil {
+			return cali.ErrorDuplicateExternalKey
+		}
+		return tx.update(eventId, func(e *cali.Event) error {
+			e.ExternalKey = key
+			return nil
+		})
+	})
+}
+
+func (d *dataStore) Get(eventId int64) (*cali.Event, error) {
+	return d.event(eventId, false)
+}
+
+func (d *dataStore) GetMany(eventIds []int64) ([]*cali.Event, error) {
+	result := make([]*cali.Event, len(eventIds))
+	if len(eventIds) == 0 {
+		return result, nil
+	}
+	events, err := d.Query(cali.Query{EventIds: eventIds})
+	if err != nil {
+		return nil, err
+	}
+	byId := map[int64]*cali.Event{}
+	for _, e := range events {
+		byId[e.Id] = e
+	}
+	for i, id := range eventIds {
+		result[i] = byId[id]
+	}
+	return result, nil
+}
+
+// Query finds the events with the SQL of the Generator, which leaves out the Near and
+// CustomFields of the query for the calendar to check
+func (d *dataStore) Query(q cali.Query) ([]*cali.Event, error) {
+	query, args := d.generator.SelectEvents(q)
+	var result []*cali.Event
+	err := d.run.Query(context.Background(), query, func(rows *sql.Rows) error {
+		e, err := scanEvent(rows)
+		if err == nil {
+			result = append(result, e)
+		}
+		return err
+	}, args...)
+	return result, err
+}
+
+// ///////////////////////
+// Invites
+// ///////////////////////
+
+// invites finds the invites of the SQL, which selects the data column
+func (d *dataStore) invites(query string, args ...interface{}) ([]*cali.Invite, error) {
+	var result []*cali.Invite
+	err := d.query(query, func(rows *sql.Rows) error {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+		var invite cali.Invite
+		if err := json.Unmarshal(data, &invite); err != nil {
+			return err
+		}
+		result = append(result, &invite)
+		return nil
+	}, args...)
+	return result, err
+}
+
+// invite finds the invite of the user, or nil if there isn't one
+func (d *dataStore) invite(eventId, userId int64, isSeries bool, lock bool) (*cali.Invite, error) {
+	query := "SELECT data FROM invites WHERE event_id = ? AND user_id = ? AND is_series = ?"
+	if lock {
+		query += d.forUpdate
+	}
+	invites, err := d.invites(query, eventId, userId, isSeries)
+	if err != nil || len(invites) == 0 {
+		return nil, err
+	}
+	return invites[0], nil
+}
+
+// putInvite saves the invite, replacing the invite of the user if there is one
+func (d *dataStore) putInvite(invite *cali.Invite) error {
+	data, err := json.Marshal(invite)
+	if err != nil {
+		return err
+	}
+	return d.exec("INSERT INTO invites (event_id, user_id, is_series, status, data) VALUES (?, ?, ?, ?, ?)"+
+		" ON CONFLICT (event_id, user_id, is_series) DO UPDATE SET status = excluded.status, data = excluded.data",
+		invite.EventId, invite.UserId, invite.IsSeries, int64(invite.Status), string(data))
+}
+
+// updateInvite changes the invite with f and saves it with a new Updated
+func (d *dataStore) updateInvite(eventId, userId int64, isSeries bool, f func(i *cali.Invite)) error {
+	return d.atomic(func(tx *dataStore) error {
+		invite, err := tx.invite(eventId, userId, isSeries, true)
+		if err != nil {
+			return err
+		}
+		if invite == nil {
+			return cali.ErrorInviteNotFound
+		}
+		f(invite)
+		invite.Updated = time.Now()
+		return tx.putInvite(invite)
+	})
+}
+
+func (d *dataStore) AddInvite(a cali.Invite) (*cali.Invite, error) {
+	a.Created = time.Now()
+	a.Updated = a.Created
+	if err := cali.ValidateInvite(a); err != nil {
+		return nil, err
+	}
+	if err := d.putInvite(&a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (d *dataStore) SetInviteStatus(eventId, userId int64, status cali.InviteStatus) error {
+	return d.updateInvite(eventId, userId, false, func(i *cali.Invite) { i.Status = status })
+}
+
+func (d *dataStore) SetInvitePermissions(eventId, userId int64, permissions cali.Permission) error {
+	return d.updateInvite(eventId, userId, false, func(i *cali.Invite) { i.Permission = permissions })
+}
+
+func (d *dataStore) SetInvitePrivateNote(eventId, userId int64, note *string) error {
+	return d.updateInvite(eventId, userId, false, func(i *cali.Invite) { i.PrivateNote = note })
+}
+
+func (d *dataStore) SetInviteUserData(eventId, userId int64, userData map[string]interface{}) error {
+	return d.updateInvite(eventId, userId, false, func(i *cali.Invite) { i.UserData = userData })
+}
+
+func (d *dataStore) GetInvite(eventId, userId int64) (*cali.Invite, error) {
+	return d.invite(eventId, userId, false, false)
+}
+
+func (d *dataStore) GetInvites(eventId int64) ([]*cali.Invite, error) {
+	return d.invites("SELECT data FROM invites WHERE event_id = ? AND is_series = FALSE ORDER BY user_id", eventId)
+}
+
+func (d *dataStore) AddSeriesInvite(a cali.Invite) (*cali.Invite, error) {
+	a.IsSeries = true
+	return d.AddInvite(a)
+}
+
+func (d *dataStore) SetSeriesInviteStatus(parentId, userId int64, status cali.InviteStatus) error {
+	return d.updateInvite(parentId, userId, true, func(i *cali.Invite) { i.Status = status })
+}
+
+func (d *dataStore) SetSeriesInvitePermissions(parentId, userId int64, permissions cali.Permission) error {
+	return d.updateInvite(parentId, userId, true, func(i *cali.Invite) { i.Permission = permissions })
+}
+
+func (d *dataStore) GetSeriesInvite(parentId, userId int64) (*cali.Invite, error) {
+	return d.invite(parentId, userId, true, false)
+}
+
+func (d *dataStore) GetSeriesInvites(parentId int64) ([]*cali.Invite, error) {
+	return d.invites("SELECT data FROM invites WHERE event_id = ? AND is_series = TRUE ORDER BY user_id", parentId)
+}
+
+// ///////////////////////
+// Outbox
+// ///////////////////////
+
+// outboxRecords finds the outbox records of the SQL, which selects the id and data columns
+func (d *dataStore) outboxRecords(query string, args ...interface{}) ([]*cali.OutboxRecord, error) {
+	var result []*cali.OutboxRecord
+	err := d.query(query, func(rows *sql.Rows) error {
+		var id int64
+		var data []byte
+		if err := rows.Scan(&id, &data); err != nil {
+			return err
+		}
+		var record cali.OutboxRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+		record.Id = id
+		result = append(result, &record)
+		return nil
+	}, args...)
+	return result, err
+}
+
+// updateOutboxRecord changes the record with f and saves it
+func (d *dataStore) updateOutboxRecord(id int64, f func(r *cali.OutboxRecord)) error {
+	return d.atomic(func(tx *dataStore) error {
+		records, err := tx.outboxRecords("SELECT id, data FROM outbox WHERE id = ?"+tx.forUpdate, id)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return cali.ErrorOutboxRecordNotFound
+		}
+		f(records[0])
+		data, err := json.Marshal(records[0])
+		if err != nil {
+			return err
+		}
+		return tx.exec("UPDATE outbox SET delivered = ?, data = ? WHERE id = ?", records[0].Delivered != nil, string(data), id)
+	})
+}
+
+func (d *dataStore) AddOutboxRecord(record cali.OutboxRecord) (*cali.OutboxRecord, error) {
+	record.Created = time.Now()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	err = d.query("INSERT INTO outbox (delivered, data) VALUES (?, ?) RETURNING id", func(rows *sql.Rows) error {
+		return rows.Scan(&record.Id)
+	}, record.Delivered != nil, string(data))
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (d *dataStore) PendingOutboxRecords(limit int) ([]*cali.OutboxRecord, error) {
+	return d.outboxRecords("SELECT id, data FROM outbox WHERE delivered = ? ORDER BY id LIMIT ?", false, limit)
+}
+
+func (d *dataStore) MarkOutboxDelivered(id int64) error {
+	return d.updateOutboxRecord(id, func(r *cali.OutboxRecord) {
+		now := time.Now()
+		r.Delivered = &now
+	})
+}
+
+func (d *dataStore) MarkOutboxFailed(id int64, reason string) error {
+	return d.updateOutboxRecord(id, func(r *cali.OutboxRecord) {
+		r.Attempts++
+		r.LastError = reason
+	})
+}
@@ -0,0 +1,375 @@
+package calisql
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/Kenoshen/cali"
+)
+
+// QueryLog is a statement that was run, for finding slow queries (see WithDebugLog)
+type QueryLog struct {
+	// SQL is the generated statement
+	SQL string
+	// Args are the arguments of the statement
+	Args []interface{}
+	// Elapsed is how long the statement took to run
+	Elapsed time.Duration
+	// Err is the error of the statement, or nil if it worked
+	Err error
+}
+
+// Logger receives the statements of WithDebugLog
+type Logger func(log QueryLog)
+
+// Option configures a DB
+type Option func(*DB)
+
+// WithInListStrategy sets how IN lists are bound, the default is InListBucketed
+func WithInListStrategy(strategy InListStrategy) Option {
+	return func(db *DB) {
+		db.generator.InList = strategy
+	}
+}
+
+// WithColumns sets the columns that SelectEvents selects from the events table
+func WithColumns(columns ...string) Option {
+	return func(db *DB) {
+		db.generator.Columns = columns
+	}
+}
+
+// WithDebugLog logs the generated SQL and timing of every statement that takes at least the
+// threshold (or every statement for zero), which is off by default
+func WithDebugLog(logger Logger, threshold time.Duration) Option {
+	return func(db *DB) {
+		db.logger = logger
+		db.logThreshold = threshold
+	}
+}
+
+// WithSlowQueryThreshold changes how long a statement takes before it is logged by the
+// logger of WithDebugLog, the default for Open is DefaultSlowQueryThreshold
+func WithSlowQueryThreshold(threshold time.Duration) Option {
+	return func(db *DB) {
+		db.logThreshold = threshold
+	}
+}
+
+// WithMaxOpenConns sets the most connections that are open at once (see sql.DB.SetMaxOpenConns)
+func WithMaxOpenConns(n int) Option {
+	return func(db *DB) {
+		db.pool.maxOpenConns = &n
+	}
+}
+
+// WithMaxIdleConns sets the most connections that are kept when idle (see sql.DB.SetMaxIdleConns)
+func WithMaxIdleConns(n int) Option {
+	return func(db *DB) {
+		db.pool.maxIdleConns = &n
+	}
+}
+
+// WithConnMaxLifetime sets how long a connection is reused (see sql.DB.SetConnMaxLifetime)
+func WithConnMaxLifetime(d time.Duration) Option {
+	return func(db *DB) {
+		db.pool.connMaxLifetime = &d
+	}
+}
+
+// WithConnMaxIdleTime sets how long a connection is kept when idle (see sql.DB.SetConnMaxIdleTime)
+func WithConnMaxIdleTime(d time.Duration) Option {
+	return func(db *DB) {
+		db.pool.connMaxIdleTime = &d
+	}
+}
+
+// WithReadTimeout sets how long a statement that returns rows can run (zero has no limit)
+func WithReadTimeout(d time.Duration) Option {
+	return func(db *DB) {
+		db.readTimeout = d
+	}
+}
+
+// WithWriteTimeout sets how long a statement that doesn't return rows can run (zero has no limit)
+func WithWriteTimeout(d time.Duration) Option {
+	return func(db *DB) {
+		db.writeTimeout = d
+	}
+}
+
+// Defaults of Open
+const (
+	DefaultMaxOpenConns       = 20
+	DefaultMaxIdleConns       = 5
+	DefaultConnMaxLifetime    = 30 * time.Minute
+	DefaultConnMaxIdleTime    = 5 * time.Minute
+	DefaultReadTimeout        = 5 * time.Second
+	DefaultWriteTimeout       = 10 * time.Second
+	DefaultSlowQueryThreshold = 500 * time.Millisecond
+)
+
+// pool has the connection pool settings that were set with options, where nil is unchanged
+type pool struct {
+	maxOpenConns    *int
+	maxIdleConns    *int
+	connMaxLifetime *time.Duration
+	connMaxIdleTime *time.Duration
+}
+
+// apply sets the pool settings on the database handle
+func (p pool) apply(db *sql.DB) {
+	if p.maxOpenConns != nil {
+		db.SetMaxOpenConns(*p.maxOpenConns)
+	}
+	if p.maxIdleConns != nil {
+		db.SetMaxIdleConns(*p.maxIdleConns)
+	}
+	if p.connMaxLifetime != nil {
+		db.SetConnMaxLifetime(*p.connMaxLifetime)
+	}
+	if p.connMaxIdleTime != nil {
+		db.SetConnMaxIdleTime(*p.connMaxIdleTime)
+	}
+}
+
+// DB runs the generated SQL with prepared statements that are cached by their SQL text
+type DB struct {
+	db        *sql.DB
+	generator Generator
+
+	logger       Logger
+	logThreshold time.Duration
+
+	pool         pool
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	// owned is true if the handle was opened by Open and is closed by Close
+	owned bool
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt
+}
+
+// New uses the database handle with the dialect of its driver. Only the pool settings of
+// the options are changed on the handle, and statements don't have a timeout unless one is set.
+func New(db *sql.DB, dialect Dialect, opts ...Option) *DB {
+	d := &DB{db: db, generator: Generator{Dialect: dialect}, stmts: map[string]*sql.Stmt{}}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.pool.apply(db)
+	return d
+}
+
+// Open opens the database with the registered driver and uses the defaults (like
+// DefaultMaxOpenConns and DefaultReadTimeout) for the settings that the options don't change
+func Open(driverName string, dataSourceName string, dialect Dialect, opts ...Option) (*DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	defaults := []Option{
+		WithMaxOpenConns(DefaultMaxOpenConns),
+		WithMaxIdleConns(DefaultMaxIdleConns),
+		WithConnMaxLifetime(DefaultConnMaxLifetime),
+		WithConnMaxIdleTime(DefaultConnMaxIdleTime),
+		WithReadTimeout(DefaultReadTimeout),
+		WithWriteTimeout(DefaultWriteTimeout),
+		WithSlowQueryThreshold(DefaultSlowQueryThreshold),
+	}
+	d := New(db, dialect, append(defaults, opts...)...)
+	d.owned = true
+	return d, nil
+}
+
+// SQL gets the database handle
+func (d *DB) SQL() *sql.DB {
+	return d.db
+}
+
+// Generator gets the SQL generator of the database
+func (d *DB) Generator() Generator {
+	return d.generator
+}
+
+// QueryEvents runs the SQL of SelectEvents for the query and calls scan for every row
+func (d *DB) QueryEvents(ctx context.Context, q cali.Query, scan func(rows *sql.Rows) error) error {
+	query, args := d.generator.SelectEvents(q)
+	return d.Query(ctx, query, scan, args...)
+}
+
+// Query runs a prepared statement and calls scan for every row. The read timeout covers
+// the statement and the scanning of its rows.
+func (d *DB) Query(ctx context.Context, query string, scan func(rows *sql.Rows) error, args ...interface{}) error {
+	ctx, cancel := withTimeout(ctx, d.readTimeout)
+	defer cancel()
+	start := time.Now()
+	err := d.query(ctx, nil, query, scan, args)
+	d.log(query, args, start, err)
+	return err
+}
+
+// query runs the statement (in the transaction if it isn't nil) and scans its rows
+func (d *DB) query(ctx context.Context, tx *sql.Tx, query string, scan func(rows *sql.Rows) error, args []interface{}) error {
+	stmt, err := d.stmt(ctx, tx, query)
+	if err != nil {
+		return err
+	}
+	if tx != nil {
+		defer stmt.Close()
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Exec runs a prepared statement that doesn't return rows
+func (d *DB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, d.writeTimeout)
+	defer cancel()
+	start := time.Now()
+	result, err := d.exec(ctx, nil, query, args)
+	d.log(query, args, start, err)
+	return result, err
+}
+
+// exec runs the statement in the transaction if it isn't nil
+func (d *DB) exec(ctx context.Context, tx *sql.Tx, query string, args []interface{}) (sql.Result, error) {
+	stmt, err := d.stmt(ctx, tx, query)
+	if err != nil {
+		return nil, err
+	}
+	if tx != nil {
+		defer stmt.Close()
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// InTx begins a transaction and calls f with it, then commits the transaction if f returns nil
+// or rolls it back otherwise. The statements of the transaction are the cached statements of
+// the DB, with the same timeouts and logging.
+func (d *DB) InTx(ctx context.Context, f func(tx *Tx) error) error {
+	sqlTx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := f(&Tx{db: d, tx: sqlTx}); err != nil {
+		_ = sqlTx.Rollback()
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+// Tx is a transaction of a DB (see InTx)
+type Tx struct {
+	db *DB
+	tx *sql.Tx
+}
+
+// Query runs a statement in the transaction like DB.Query
+func (t *Tx) Query(ctx context.Context, query string, scan func(rows *sql.Rows) error, args ...interface{}) error {
+	ctx, cancel := withTimeout(ctx, t.db.readTimeout)
+	defer cancel()
+	start := time.Now()
+	err := t.db.query(ctx, t.tx, query, scan, args)
+	t.db.log(query, args, start, err)
+	return err
+}
+
+// Exec runs a statement that doesn't return rows in the transaction like DB.Exec
+func (t *Tx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, t.db.writeTimeout)
+	defer cancel()
+	start := time.Now()
+	result, err := t.db.exec(ctx, t.tx, query, args)
+	t.db.log(query, args, start, err)
+	return result, err
+}
+
+// Prepared gets the number of cached statements
+func (d *DB) Prepared() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.stmts)
+}
+
+// Close closes the cached statements, and the database handle if it was opened by Open
+func (d *DB) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var firstErr error
+	for query, stmt := range d.stmts {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(d.stmts, query)
+	}
+	if d.owned {
+		if err := d.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// prepare gets the cached statement for the SQL, or prepares it
+func (d *DB) prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if stmt, ok := d.stmts[query]; ok {
+		return stmt, nil
+	}
+	stmt, err := d.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	d.stmts[query] = stmt
+	return stmt, nil
+}
+
+// stmt gets the cached statement for the SQL, or prepares it. In a transaction, the cached
+// statement is bound to the transaction, and a statement that isn't cached yet is prepared on
+// the connection of the transaction so that it doesn't wait for another connection of the pool.
+func (d *DB) stmt(ctx context.Context, tx *sql.Tx, query string) (*sql.Stmt, error) {
+	if tx == nil {
+		return d.prepare(ctx, query)
+	}
+	d.mu.Lock()
+	cached, ok := d.stmts[query]
+	d.mu.Unlock()
+	if ok {
+		return tx.StmtContext(ctx, cached), nil
+	}
+	return tx.PrepareContext(ctx, query)
+}
+
+// log sends the statement to the logger if it is slow enough
+func (d *DB) log(query string, args []interface{}, start time.Time, err error) {
+	if d.logger == nil {
+		return
+	}
+	elapsed := time.Since(start)
+	if elapsed < d.logThreshold {
+		return
+	}
+	d.logger(QueryLog{SQL: query, Args: args, Elapsed: elapsed, Err: err})
+}
+
+// withTimeout adds the timeout to the context, where zero has no timeout
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
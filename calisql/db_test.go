@@ -0,0 +1,152 @@
+package calisql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDriver prepares every statement (other than "BROKEN") and returns no rows, where
+// "SLOW" statements wait until their context is done
+type testDriver struct{}
+
+func (d *testDriver) Open(string) (driver.Conn, error) {
+	return &testConn{}, nil
+}
+
+type testConn struct{}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	if query == "BROKEN" {
+		return nil, errors.New("syntax error")
+	}
+	return testStmt{slow: query == "SLOW"}, nil
+}
+
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type testStmt struct {
+	slow bool
+}
+
+func (testStmt) Close() error                                    { return nil }
+func (testStmt) NumInput() int                                   { return -1 }
+func (testStmt) Exec(args []driver.Value) (driver.Result, error) { return driver.RowsAffected(1), nil }
+func (testStmt) Query(args []driver.Value) (driver.Rows, error)  { return testRows{}, nil }
+
+func (s testStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if s.slow {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s testStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if s.slow {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return testRows{}, nil
+}
+
+type testRows struct{}
+
+func (testRows) Columns() []string              { return []string{"id"} }
+func (testRows) Close() error                   { return nil }
+func (testRows) Next(dest []driver.Value) error { return io.EOF }
+
+var registerTestDriver sync.Once
+
+func openTestDB(t *testing.T) *sql.DB {
+	registerTestDriver.Do(func() {
+		sql.Register("calisqltest", &testDriver{})
+	})
+	db, err := sql.Open("calisqltest", "")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestDBStatementCache(t *testing.T) {
+	sqlDB := openTestDB(t)
+	var logs []QueryLog
+	db := New(sqlDB, SQLite, WithDebugLog(func(log QueryLog) { logs = append(logs, log) }, 0))
+	defer db.Close()
+	ctx := context.Background()
+
+	for _, ids := range [][]int64{{1, 2, 3}, {4, 5, 6, 7}, {8}} {
+		err := db.QueryEvents(ctx, cali.Query{EventIds: ids}, func(rows *sql.Rows) error {
+			return errors.New("there are no rows to scan")
+		})
+		require.NoError(t, err)
+	}
+	// three and four ids share the same bucket
+	assert.Equal(t, 2, db.Prepared())
+	_, err := db.Exec(ctx, "UPDATE events SET title = ? WHERE id = ?", "Standup", 1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, db.Prepared())
+
+	require.Len(t, logs, 4)
+	assert.Equal(t, "SELECT e.* FROM events e WHERE e.id IN (?, ?, ?, ?) ORDER BY e.id", logs[0].SQL)
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3), int64(3)}, logs[0].Args)
+	assert.Equal(t, "UPDATE events SET title = ? WHERE id = ?", logs[3].SQL)
+
+	_, err = db.Exec(ctx, "BROKEN")
+	assert.Error(t, err)
+	require.Len(t, logs, 5)
+	assert.Equal(t, err, logs[4].Err)
+	assert.Equal(t, 3, db.Prepared())
+
+	require.NoError(t, db.Close())
+	assert.Equal(t, 0, db.Prepared())
+}
+
+func TestDBDebugLogThreshold(t *testing.T) {
+	sqlDB := openTestDB(t)
+	var logs []QueryLog
+	db := New(sqlDB, Postgres, WithDebugLog(func(log QueryLog) { logs = append(logs, log) }, time.Hour))
+	defer db.Close()
+	_, err := db.Exec(context.Background(), "SELECT 1")
+	require.NoError(t, err)
+	assert.Empty(t, logs, "only slow statements are logged")
+}
+
+func TestDBPoolAndTimeouts(t *testing.T) {
+	sqlDB := openTestDB(t)
+	db := New(sqlDB, SQLite, WithMaxOpenConns(3), WithReadTimeout(10*time.Millisecond), WithWriteTimeout(10*time.Millisecond))
+	defer db.Close()
+	assert.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+
+	ctx := context.Background()
+	err := db.Query(ctx, "SLOW", func(*sql.Rows) error { return nil })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = db.Exec(ctx, "SLOW")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestOpen(t *testing.T) {
+	openTestDB(t)
+	db, err := Open("calisqltest", "", Postgres, WithMaxOpenConns(2))
+	require.NoError(t, err)
+	assert.Equal(t, 2, db.SQL().Stats().MaxOpenConnections)
+	assert.Equal(t, DefaultReadTimeout, db.readTimeout)
+	assert.Equal(t, DefaultWriteTimeout, db.writeTimeout)
+	assert.Equal(t, DefaultSlowQueryThreshold, db.logThreshold)
+	require.NoError(t, db.Close())
+	assert.Error(t, db.SQL().Ping(), "the handle opened by Open is closed")
+
+	_, err = Open("missing", "", Postgres)
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package calisql
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+)
+
+// Migration is a change to the schema of the database, which is applied once (see Migrate)
+type Migration struct {
+	// Version orders the migrations and is saved in the schema_migrations table once applied
+	Version int64
+	// Statements are the DDL of the migration, which are run in order
+	Statements []string
+}
+
+// Migrate applies the migrations that haven't been applied to the database yet in the order of
+// their versions, each one in its own transaction, and saves their versions in the
+// schema_migrations table. It should be run by one process at a time, like when deploying.
+func Migrate(ctx context.Context, db *DB, migrations []Migration) error {
+	if _, err := db.Exec(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY)"); err != nil {
+		return err
+	}
+	applied := map[int64]bool{}
+	err := db.Query(ctx, "SELECT version FROM schema_migrations", func(rows *sql.Rows) error {
+		var version int64
+		err := rows.Scan(&version)
+		applied[version] = true
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	sorted := append([]Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	insert := "INSERT INTO schema_migrations (version) VALUES (" + db.generator.Dialect.Placeholder(1) + ")"
+	for _, m := range sorted {
+		if applied[m.Version] {
+			continue
+		}
+		err := db.InTx(ctx, func(tx *Tx) error {
+			for _, statement := range m.Statements {
+				if _, err := tx.Exec(ctx, statement); err != nil {
+					return err
+				}
+			}
+			_, err := tx.Exec(ctx, insert, m.Version)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package calisql
+
+import (
+	"context"
+)
+
+// PostgresMigrations create the tables of the PostgresDataStore. The events and invites tables
+// have the columns that the Generator queries (see the package docs), and the rest of the
+// fields of each event and invite are in the JSON of its data column. The version column of
+// the events is the Version of the event, which makes the changes to it a compare-and-set. The
+// outbox table has the records of cali.OutboxStore, with the rest of their fields in the data
+// column as well. Migration 4 adds the seconds to the floating times of the events that were
+// saved before cali.Event.FloatingSpan had them.
+var PostgresMigrations = []Migration{
+	{Version: 1, Statements: []string{
+		`CREATE TABLE IF NOT EXISTS events (
+			id BIGSERIAL PRIMARY KEY,
+			calendar_id BIGINT NOT NULL,
+			parent_id BIGINT,
+			source_id BIGINT,
+			external_key TEXT UNIQUE,
+			event_type BIGINT NOT NULL,
+			status BIGINT NOT NULL,
+			priority BIGINT NOT NULL,
+			visibility BIGINT NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT,
+			floating_start TEXT NOT NULL,
+			floating_end TEXT NOT NULL,
+			data JSONB NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS events_calendar_range ON events (calendar_id, floating_start, floating_end)",
+		"CREATE INDEX IF NOT EXISTS events_range ON events (floating_start, floating_end)",
+		"CREATE INDEX IF NOT EXISTS events_parent ON events (parent_id)",
+		"CREATE INDEX IF NOT EXISTS events_source ON events (source_id)",
+		`CREATE TABLE IF NOT EXISTS invites (
+			event_id BIGINT NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+			user_id BIGINT NOT NULL,
+			is_series BOOLEAN NOT NULL,
+			status BIGINT NOT NULL,
+			data JSONB NOT NULL,
+			PRIMARY KEY (event_id, user_id, is_series)
+		)`,
+		"CREATE INDEX IF NOT EXISTS invites_user ON invites (user_id, status)",
+	}},
+	{Version: 2, Statements: []string{
+		"ALTER TABLE events ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0",
+		"UPDATE events SET version = (data->>'version')::BIGINT",
+	}},
+	{Version: 3, Statements: []string{
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id BIGSERIAL PRIMARY KEY,
+			delivered BOOLEAN NOT NULL,
+			data JSONB NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (delivered, id)",
+	}},
+	{Version: 4, Statements: []string{
+		"UPDATE events SET floating_start = floating_start || ':00' WHERE length(floating_start) = 16",
+		"UPDATE events SET floating_end = floating_end || ':00' WHERE length(floating_end) = 16",
+	}},
+}
+
+// PostgresDataStore is a cali.DataStore for PostgreSQL. Besides DataStore, it implements the
+// TxStore, BatchCreateStore, BatchGetStore, RepeatExpansionStore, InviteListStore,
+// SeriesInviteStore, ExternalKeyStore, CancelReasonStore, LinkStore, OverrideStore,
+// VersionStore, OutboxStore, and the setters of the optional fields (LocationStore, GeoPointStore,
+// VisibilityStore, ForwardingStore, PriorityStore, ConferenceStore, and PrivateInviteStore)
+// interfaces of cali. Every change to an event is made in a transaction that locks its row and
+// only writes it if its version column hasn't changed, and the occurrences of a repeating event
+// are created in a single transaction. With cali.WithOutbox, the outbox records are added in
+// the transaction of the changes that they record.
+//
+// The Next of a RepeatTypeCustom repeat isn't saved (like every JSON data store), and numbers
+// in the UserData of events and invites are read back as float64.
+type PostgresDataStore struct {
+	*dataStore
+}
+
+// NewPostgresDataStore keeps the events and invites in the database, which should use the
+// Postgres dialect and have the tables of PostgresMigrations (see Migrate)
+func NewPostgresDataStore(db *DB) *PostgresDataStore {
+	return &PostgresDataStore{newDataStore(db, " FOR UPDATE")}
+}
+
+// Migrate creates or updates the tables of the data store with PostgresMigrations
+func (d *PostgresDataStore) Migrate(ctx context.Context) error {
+	return Migrate(ctx, d.db, PostgresMigrations)
+}
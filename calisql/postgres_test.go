@@ -0,0 +1,352 @@
+package calisql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptDriver logs every statement (and BEGIN, COMMIT, and ROLLBACK) of its connections and
+// answers the queries with the respond function of the script for the DSN
+type scriptDriver struct{}
+
+type script struct {
+	mu      sync.Mutex
+	log     []string
+	args    [][]driver.Value
+	respond func(query string, args []driver.Value) ([][]driver.Value, error)
+}
+
+func (s *script) record(entry string, args []driver.Value) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.log = append(s.log, entry)
+	s.args = append(s.args, args)
+}
+
+// statements gets the log where each statement is cut off after its first few words
+func (s *script) statements() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]string, len(s.log))
+	for i, entry := range s.log {
+		words := strings.Fields(entry)
+		result[i] = strings.Join(words[:min(len(words), 3)], " ")
+	}
+	return result
+}
+
+var (
+	scripts          = map[string]*script{}
+	scriptsMu        sync.Mutex
+	registerScripted sync.Once
+)
+
+func (scriptDriver) Open(dsn string) (driver.Conn, error) {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+	return &scriptConn{s: scripts[dsn]}, nil
+}
+
+type scriptConn struct {
+	s *script
+}
+
+func (c *scriptConn) Prepare(query string) (driver.Stmt, error) {
+	return &scriptStmt{s: c.s, query: query}, nil
+}
+
+func (c *scriptConn) Close() error { return nil }
+
+func (c *scriptConn) Begin() (driver.Tx, error) {
+	c.s.record("BEGIN", nil)
+	return scriptTx{s: c.s}, nil
+}
+
+type scriptTx struct {
+	s *script
+}
+
+func (t scriptTx) Commit() error {
+	t.s.record("COMMIT", nil)
+	return nil
+}
+
+func (t scriptTx) Rollback() error {
+	t.s.record("ROLLBACK", nil)
+	return nil
+}
+
+type scriptStmt struct {
+	s     *script
+	query string
+}
+
+func (st *scriptStmt) Close() error  { return nil }
+func (st *scriptStmt) NumInput() int { return -1 }
+
+func (st *scriptStmt) Exec(args []driver.Value) (driver.Result, error) {
+	st.s.record(st.query, args)
+	rows, err := st.s.respond(st.query, args)
+	if err != nil {
+		return nil, err
+	}
+	// a statement that is answered with a count affects that many rows instead of one
+	if len(rows) == 1 && len(rows[0]) == 1 {
+		if affected, ok := rows[0][0].(int64); ok {
+			return driver.RowsAffected(affected), nil
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (st *scriptStmt) Query(args []driver.Value) (driver.Rows, error) {
+	st.s.record(st.query, args)
+	rows, err := st.s.respond(st.query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptRows{rows: rows}, nil
+}
+
+type scriptRows struct {
+	rows [][]driver.Value
+}
+
+func (r *scriptRows) Columns() []string {
+	if len(r.rows) == 0 {
+		return []string{"id"}
+	}
+	columns := make([]string, len(r.rows[0]))
+	for i := range columns {
+		columns[i] = fmt.Sprint("c", i)
+	}
+	return columns
+}
+
+func (r *scriptRows) Close() error { return nil }
+
+func (r *scriptRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func openScript(t *testing.T, dialect Dialect, respond func(query string, args []driver.Value) ([][]driver.Value, error)) (*DB, *script) {
+	registerScripted.Do(func() {
+		sql.Register("calisqlscript", scriptDriver{})
+	})
+	s := &script{respond: respond}
+	scriptsMu.Lock()
+	scripts[t.Name()] = s
+	scriptsMu.Unlock()
+	db, err := sql.Open("calisqlscript", t.Name())
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return New(db, dialect), s
+}
+
+// inserts answers the INSERT INTO events statements with ids counting up from 1, and fails
+// the insert after the fail id if it isn't zero
+func inserts(fail int64) func(query string, args []driver.Value) ([][]driver.Value, error) {
+	var id int64
+	return func(query string, args []driver.Value) ([][]driver.Value, error) {
+		if !strings.HasPrefix(query, "INSERT INTO events") {
+			return nil, nil
+		}
+		id++
+		if fail > 0 && id > fail {
+			return nil, errors.New("disk full")
+		}
+		return [][]driver.Value{{id}}, nil
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	db, s := openScript(t, Postgres, func(query string, args []driver.Value) ([][]driver.Value, error) {
+		return nil, nil
+	})
+	require.NoError(t, NewPostgresDataStore(db).Migrate(context.Background()))
+	expected := []string{"CREATE TABLE IF", "SELECT version FROM"}
+	for _, m := range PostgresMigrations {
+		expected = append(expected, "BEGIN")
+		for _, statement := range m.Statements {
+			words := strings.Fields(statement)
+			expected = append(expected, strings.Join(words[:3], " "))
+		}
+		expected = append(expected, "INSERT INTO schema_migrations", "COMMIT")
+	}
+	assert.Equal(t, expected, s.statements())
+	assert.Equal(t, []driver.Value{int64(4)}, s.args[len(s.args)-2])
+
+	applied, s := openScript(t, Postgres, func(query string, args []driver.Value) ([][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT version") {
+			return [][]driver.Value{{int64(1)}, {int64(2)}, {int64(3)}, {int64(4)}}, nil
+		}
+		return nil, nil
+	})
+	require.NoError(t, Migrate(context.Background(), applied, PostgresMigrations))
+	assert.Equal(t, []string{"CREATE TABLE IF", "SELECT version FROM"}, s.statements(), "applied migrations are skipped")
+}
+
+func TestPostgresCreateRepeating(t *testing.T) {
+	db, s := openScript(t, Postgres, inserts(0))
+	c := cali.NewCalendar(NewPostgresDataStore(db))
+	e, count, err := c.Create(cali.Event{Title: "Standup", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15",
+		IsRepeating: true, Repeat: &cali.Repeat{RepeatType: cali.RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, int64(1), e.Id)
+	assert.Equal(t, int64(1), *e.ParentId)
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT INTO events", "UPDATE events SET", "INSERT INTO invites",
+		"INSERT INTO events", "INSERT INTO invites",
+		"INSERT INTO events", "INSERT INTO invites",
+		"COMMIT",
+	}, s.statements(), "the occurrences are created in one transaction")
+	assert.Equal(t, "INSERT INTO events (calendar_id, parent_id, source_id, external_key, event_type, status, priority, visibility, title, description, floating_start, floating_end, version, data) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id", s.log[1])
+	assert.Equal(t, []driver.Value{int64(1), int64(1)}, s.args[2])
+	assert.Equal(t, int64(1), s.args[4][1], "the other occurrences have the first as their parent")
+	assert.Equal(t, "2008-01-03 09:00:00", s.args[6][10])
+
+	failing, s := openScript(t, Postgres, inserts(2))
+	_, _, err = cali.NewCalendar(NewPostgresDataStore(failing)).Create(cali.Event{Title: "Standup", Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true,
+		IsRepeating: true, Repeat: &cali.Repeat{RepeatType: cali.RepeatTypeDaily, RepeatOccurrences: 3}})
+	assert.EqualError(t, err, "disk full")
+	statements := s.statements()
+	assert.Equal(t, "ROLLBACK", statements[len(statements)-1])
+	assert.NotContains(t, statements, "COMMIT")
+}
+
+func TestPostgresUpdate(t *testing.T) {
+	stored := cali.Event{Title: "Lunch", Zone: "UTC", StartDay: "2008-01-01", StartTime: "12:00", EndDay: "2008-01-01", EndTime: "13:00", Version: 4,
+		UserData: map[string]interface{}{"room": "4B"}}
+	data, err := json.Marshal(stored)
+	require.NoError(t, err)
+	var changed bool
+	db, s := openScript(t, Postgres, func(query string, args []driver.Value) ([][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT id, parent_id, data FROM events WHERE id = $1") && args[0] == int64(7) {
+			return [][]driver.Value{{int64(7), nil, data}}, nil
+		}
+		if strings.HasPrefix(query, "UPDATE events SET") && changed {
+			// the row was changed after it was read
+			return [][]driver.Value{{int64(0)}}, nil
+		}
+		return nil, nil
+	})
+	store := NewPostgresDataStore(db)
+
+	e, err := store.Get(7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), e.Id)
+	assert.Nil(t, e.ParentId)
+	assert.Equal(t, "4B", e.UserData["room"])
+
+	require.NoError(t, store.SetTitle(7, "Team Lunch"))
+	assert.Equal(t, []string{"SELECT id, parent_id,", "BEGIN", "SELECT id, parent_id,", "UPDATE events SET", "COMMIT"}, s.statements())
+	assert.True(t, strings.HasSuffix(s.log[2], " FOR UPDATE"), "the row is locked until the change is committed")
+	assert.True(t, strings.HasSuffix(s.log[3], " WHERE id = $15 AND version = $16"), "the row is only written if it has the version that was read")
+	update := s.args[3]
+	assert.Equal(t, "Team Lunch", update[8])
+	assert.Equal(t, int64(5), update[12])
+	assert.Equal(t, []driver.Value{int64(7), int64(4)}, update[len(update)-2:])
+	var saved cali.Event
+	require.NoError(t, json.Unmarshal([]byte(update[13].(string)), &saved))
+	assert.Equal(t, "Team Lunch", saved.Title)
+	assert.Equal(t, int64(5), saved.Version)
+
+	changed = true
+	assert.Equal(t, cali.ErrorPreconditionFailed, store.SetTitle(7, "Team Lunch"))
+	assert.Equal(t, "ROLLBACK", s.statements()[len(s.log)-1])
+	changed = false
+
+	before := len(s.log)
+	err = store.IfVersion(7, 3, func(tx cali.DataStore) error {
+		return tx.SetTitle(7, "Team Lunch")
+	})
+	assert.Equal(t, cali.ErrorPreconditionFailed, err, "the event doesn't have the version")
+	assert.NotContains(t, s.statements()[before:], "UPDATE events SET")
+
+	assert.Equal(t, cali.ErrorEventNotFound, store.SetTitle(8, "Missing"))
+	assert.Equal(t, cali.ErrorInvalidPriority, store.SetPriority(7, 12))
+	invite, err := store.GetInvite(7, 1)
+	require.NoError(t, err)
+	assert.Nil(t, invite)
+	assert.Equal(t, cali.ErrorInviteNotFound, store.SetInviteStatus(7, 1, cali.InviteStatusDeclined))
+}
+
+func TestPostgresOutbox(t *testing.T) {
+	data, err := json.Marshal(cali.Event{Title: "Lunch", Zone: "UTC", StartDay: "2008-01-01", StartTime: "12:00", EndDay: "2008-01-01", EndTime: "13:00", Version: 4})
+	require.NoError(t, err)
+	record, err := json.Marshal(cali.OutboxRecord{Kind: cali.OutboxKindChange, Payload: []byte(`{"type":1}`)})
+	require.NoError(t, err)
+	var failOutbox bool
+	db, s := openScript(t, Postgres, func(query string, args []driver.Value) ([][]driver.Value, error) {
+		switch {
+		case strings.HasPrefix(query, "SELECT id, parent_id, data FROM events WHERE id = $1") && args[0] == int64(7):
+			return [][]driver.Value{{int64(7), nil, data}}, nil
+		case strings.HasPrefix(query, "INSERT INTO outbox") && failOutbox:
+			return nil, errors.New("disk full")
+		case strings.HasPrefix(query, "INSERT INTO outbox"):
+			return [][]driver.Value{{int64(3)}}, nil
+		case strings.HasPrefix(query, "SELECT id, data FROM outbox"):
+			return [][]driver.Value{{int64(3), record}}, nil
+		}
+		return nil, nil
+	})
+	store := NewPostgresDataStore(db)
+	c := cali.NewCalendar(store, cali.WithOutbox())
+
+	require.NoError(t, c.UpdateTitle(7, "Team Lunch", cali.RepeatEditTypeThis))
+	statements := s.statements()
+	assert.Equal(t, "BEGIN", statements[0])
+	assert.Equal(t, "COMMIT", statements[len(statements)-1])
+	assert.Contains(t, statements, "UPDATE events SET")
+	assert.Contains(t, statements, "INSERT INTO outbox")
+	assert.NotContains(t, statements[1:len(statements)-1], "COMMIT", "the change and its outbox record are committed together")
+
+	failOutbox = true
+	before := len(s.log)
+	assert.EqualError(t, c.UpdateTitle(7, "Team Lunch", cali.RepeatEditTypeThis), "disk full")
+	statements = s.statements()[before:]
+	assert.Contains(t, statements, "UPDATE events SET")
+	assert.Equal(t, "ROLLBACK", statements[len(statements)-1], "the change is rolled back without its outbox record")
+	assert.NotContains(t, statements, "COMMIT")
+
+	pending, err := store.PendingOutboxRecords(10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, int64(3), pending[0].Id)
+	assert.Equal(t, cali.OutboxKindChange, pending[0].Kind)
+	assert.Equal(t, []driver.Value{false, int64(10)}, s.args[len(s.args)-1])
+
+	before = len(s.log)
+	require.NoError(t, store.MarkOutboxFailed(3, "timeout"))
+	assert.Equal(t, []string{"BEGIN", "SELECT id, data", "UPDATE outbox SET", "COMMIT"}, s.statements()[before:])
+	assert.True(t, strings.HasSuffix(s.log[before+1], " FOR UPDATE"))
+	var failed cali.OutboxRecord
+	require.NoError(t, json.Unmarshal([]byte(s.args[before+2][1].(string)), &failed))
+	assert.Equal(t, int64(1), failed.Attempts)
+	assert.Equal(t, "timeout", failed.LastError)
+
+	before = len(s.log)
+	require.NoError(t, store.MarkOutboxDelivered(3))
+	assert.Equal(t, true, s.args[before+2][0], "delivered records aren't pending")
+	assert.Equal(t, int64(3), s.args[before+2][2])
+}
@@ -0,0 +1,157 @@
+package calisql
+
+import (
+	"strings"
+
+	"github.com/Kenoshen/cali"
+)
+
+// InListStrategy is how the values of an IN list are bound
+type InListStrategy int
+
+const (
+	// InListBucketed binds a parameter for every value, but pads the list to the next power
+	// of two by repeating the last value, so that the number of distinct statements (and
+	// query plans) stays small
+	InListBucketed InListStrategy = iota
+	// InListExpand binds a parameter for every value, which makes a new statement for every
+	// length of the list
+	InListExpand
+	// InListArray binds the whole list as one array parameter ("= ANY($1)"), which makes a
+	// single statement no matter how long the list is. Dialects without an Array function
+	// use InListBucketed instead.
+	InListArray
+)
+
+// Generator builds the SQL for queries. The same shape of query (the same fields set, and
+// lists of the same size bucket) always makes the same SQL text, so the statements can be
+// prepared once and reused.
+type Generator struct {
+	Dialect Dialect
+	InList  InListStrategy
+	// Columns are the selected columns of the events table (aliased as "e"), or "e.*" if empty
+	Columns []string
+}
+
+// SelectEvents builds the SQL and arguments that find the events of the query, ordered by id.
+// The Near and CustomFields fields aren't a part of the SQL, so the calendar filters the
+// results by distance and custom field values.
+func (g Generator) SelectEvents(q cali.Query) (string, []interface{}) {
+	b := &builder{g: g}
+	if q.Start != nil {
+		start := cali.FloatingBound(*q.Start)
+		if q.StartBound == cali.RangeBoundExclusive {
+			// events without any length are still in a range that starts at their time
+			b.where("(e.floating_end > " + b.arg(start) + " OR (e.floating_end = " + b.arg(start) + " AND e.floating_start = e.floating_end))")
+		} else {
+			b.where("e.floating_end >= " + b.arg(start))
+		}
+	}
+	if q.End != nil {
+		if q.EndBound == cali.RangeBoundExclusive {
+			b.where("e.floating_start < " + b.arg(cali.FloatingBound(*q.End)))
+		} else {
+			b.where("e.floating_start <= " + b.arg(cali.FloatingBound(*q.End)))
+		}
+	}
+	b.in("e.id", q.EventIds)
+	b.in("e.calendar_id", q.CalendarIds)
+	b.in("e.parent_id", q.ParentIds)
+	if len(q.UserIds) > 0 {
+		// the invite to the event itself is used instead of the user's series invite
+		b.where("EXISTS (SELECT 1 FROM invites i WHERE " + b.inClause("i.user_id", q.UserIds) +
+			" AND i.status >= 0 AND ((i.is_series = FALSE AND i.event_id = e.id) OR" +
+			" (i.is_series = TRUE AND i.event_id = e.parent_id AND NOT EXISTS" +
+			" (SELECT 1 FROM invites o WHERE o.event_id = e.id AND o.user_id = i.user_id AND o.is_series = FALSE))))")
+	}
+	b.in("e.event_type", ints(q.EventTypes))
+	b.in("e.source_id", q.SourceIds)
+	b.in("e.status", ints(q.Statuses))
+	b.in("e.priority", ints(q.Priorities))
+	b.in("e.visibility", ints(q.Visibilities))
+	if len(q.Text) > 0 {
+		var or []string
+		for _, text := range q.Text {
+			pattern := "%" + escapeLike(text) + "%"
+			or = append(or, "e.title LIKE "+b.arg(pattern)+" ESCAPE '!'", "e.description LIKE "+b.arg(pattern)+" ESCAPE '!'")
+		}
+		b.where("(" + strings.Join(or, " OR ") + ")")
+	}
+
+	columns := "e.*"
+	if len(g.Columns) > 0 {
+		columns = strings.Join(g.Columns, ", ")
+	}
+	sql := "SELECT " + columns + " FROM events e"
+	if len(b.clauses) > 0 {
+		sql += " WHERE " + strings.Join(b.clauses, " AND ")
+	}
+	return sql + " ORDER BY e.id", b.args
+}
+
+// builder collects the clauses and arguments of a statement
+type builder struct {
+	g       Generator
+	clauses []string
+	args    []interface{}
+}
+
+func (b *builder) where(clause string) {
+	b.clauses = append(b.clauses, clause)
+}
+
+// arg adds the argument and gets its placeholder
+func (b *builder) arg(v interface{}) string {
+	b.args = append(b.args, v)
+	return b.g.Dialect.Placeholder(len(b.args))
+}
+
+// in adds an IN clause if there are values
+func (b *builder) in(column string, values []int64) {
+	if len(values) > 0 {
+		b.where(b.inClause(column, values))
+	}
+}
+
+// inClause binds the values with the InListStrategy of the generator
+func (b *builder) inClause(column string, values []int64) string {
+	if b.g.InList == InListArray && b.g.Dialect.Array != nil {
+		return column + " = ANY(" + b.arg(b.g.Dialect.Array(values)) + ")"
+	}
+	n := len(values)
+	if b.g.InList != InListExpand {
+		n = bucket(n)
+	}
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = b.arg(values[min(i, len(values)-1)])
+	}
+	return column + " IN (" + strings.Join(placeholders, ", ") + ")"
+}
+
+// bucket rounds the length up to the next power of two
+func bucket(n int) int {
+	size := 1
+	for size < n {
+		size *= 2
+	}
+	return size
+}
+
+// ints converts the values of an enumeration to int64
+func ints[T ~int64](values []T) []int64 {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]int64, len(values))
+	for i, v := range values {
+		result[i] = int64(v)
+	}
+	return result
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern with "!", which (unlike a backslash) is
+// written the same in the string literals of every dialect, including MySQL
+func escapeLike(s string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(s)
+}
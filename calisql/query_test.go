@@ -0,0 +1,132 @@
+package calisql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectEvents(t *testing.T) {
+	start := time.Date(2008, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2008, 1, 31, 17, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		generator Generator
+		query     cali.Query
+		sql       string
+		args      []interface{}
+	}{
+		{
+			name:      "everything",
+			generator: Generator{Dialect: Postgres},
+			query:     cali.Query{},
+			sql:       "SELECT e.* FROM events e ORDER BY e.id",
+		},
+		{
+			name:      "range",
+			generator: Generator{Dialect: Postgres, Columns: []string{"e.id", "e.title"}},
+			query:     cali.Query{Start: &start, End: &end},
+			sql:       "SELECT e.id, e.title FROM events e WHERE e.floating_end >= $1 AND e.floating_start <= $2 ORDER BY e.id",
+			args:      []interface{}{"2008-01-01 09:00:00", "2008-01-31 17:00:00"},
+		},
+		{
+			name:      "half-open range",
+			generator: Generator{Dialect: SQLite},
+			query:     cali.Query{Start: &start, End: &end, StartBound: cali.RangeBoundExclusive, EndBound: cali.RangeBoundExclusive},
+			sql:       "SELECT e.* FROM events e WHERE (e.floating_end > ? OR (e.floating_end = ? AND e.floating_start = e.floating_end)) AND e.floating_start < ? ORDER BY e.id",
+			args:      []interface{}{"2008-01-01 09:00:00", "2008-01-01 09:00:00", "2008-01-31 17:00:00"},
+		},
+		{
+			name:      "bucketed",
+			generator: Generator{Dialect: SQLite},
+			query:     cali.Query{CalendarIds: []int64{1, 2, 3}, Statuses: []cali.Status{cali.StatusActive}},
+			sql:       "SELECT e.* FROM events e WHERE e.calendar_id IN (?, ?, ?, ?) AND e.status IN (?) ORDER BY e.id",
+			args:      []interface{}{int64(1), int64(2), int64(3), int64(3), int64(0)},
+		},
+		{
+			name:      "expanded",
+			generator: Generator{Dialect: Postgres, InList: InListExpand},
+			query:     cali.Query{EventIds: []int64{4, 5, 6}},
+			sql:       "SELECT e.* FROM events e WHERE e.id IN ($1, $2, $3) ORDER BY e.id",
+			args:      []interface{}{int64(4), int64(5), int64(6)},
+		},
+		{
+			name:      "array",
+			generator: Generator{Dialect: Postgres, InList: InListArray},
+			query:     cali.Query{EventIds: []int64{4, 5, 6}, Priorities: []cali.Priority{cali.PriorityHigh}},
+			sql:       "SELECT e.* FROM events e WHERE e.id = ANY($1) AND e.priority = ANY($2) ORDER BY e.id",
+			args:      []interface{}{[]int64{4, 5, 6}, []int64{int64(cali.PriorityHigh)}},
+		},
+		{
+			name:      "array without dialect support",
+			generator: Generator{Dialect: MySQL, InList: InListArray},
+			query:     cali.Query{SourceIds: []int64{7, 8}},
+			sql:       "SELECT e.* FROM events e WHERE e.source_id IN (?, ?) ORDER BY e.id",
+			args:      []interface{}{int64(7), int64(8)},
+		},
+		{
+			name:      "users",
+			generator: Generator{Dialect: Postgres},
+			query:     cali.Query{UserIds: []int64{9}, Visibilities: []cali.Visibility{cali.VisibilityPublic}},
+			sql: "SELECT e.* FROM events e WHERE EXISTS (SELECT 1 FROM invites i WHERE i.user_id IN ($1) AND i.status >= 0 AND" +
+				" ((i.is_series = FALSE AND i.event_id = e.id) OR (i.is_series = TRUE AND i.event_id = e.parent_id AND NOT EXISTS" +
+				" (SELECT 1 FROM invites o WHERE o.event_id = e.id AND o.user_id = i.user_id AND o.is_series = FALSE))))" +
+				" AND e.visibility IN ($2) ORDER BY e.id",
+			args: []interface{}{int64(9), int64(cali.VisibilityPublic)},
+		},
+		{
+			name:      "text",
+			generator: Generator{Dialect: SQLite},
+			query:     cali.Query{Text: []string{"50%_off"}},
+			sql:       "SELECT e.* FROM events e WHERE (e.title LIKE ? ESCAPE '!' OR e.description LIKE ? ESCAPE '!') ORDER BY e.id",
+			args:      []interface{}{"%50!%!_off%", "%50!%!_off%"},
+		},
+		{
+			name:      "text in mysql",
+			generator: Generator{Dialect: MySQL},
+			query:     cali.Query{Text: []string{`wow!\`}},
+			sql:       "SELECT e.* FROM events e WHERE (e.title LIKE ? ESCAPE '!' OR e.description LIKE ? ESCAPE '!') ORDER BY e.id",
+			args:      []interface{}{`%wow!!\%`, `%wow!!\%`},
+		},
+		{
+			name:      "text in postgres",
+			generator: Generator{Dialect: Postgres},
+			query:     cali.Query{Text: []string{"50%"}},
+			sql:       "SELECT e.* FROM events e WHERE (e.title LIKE $1 ESCAPE '!' OR e.description LIKE $2 ESCAPE '!') ORDER BY e.id",
+			args:      []interface{}{"%50!%%", "%50!%%"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			sql, args := tc.generator.SelectEvents(tc.query)
+			assert.Equal(t, tc.sql, sql)
+			assert.Equal(t, tc.args, args)
+		})
+	}
+}
+
+func TestSelectEventsStableShapes(t *testing.T) {
+	g := Generator{Dialect: Postgres}
+	shapes := map[string]bool{}
+	for n := 1; n <= 64; n++ {
+		ids := make([]int64, n)
+		for i := range ids {
+			ids[i] = int64(i + 1)
+		}
+		sql, args := g.SelectEvents(cali.Query{EventIds: ids})
+		assert.Len(t, args, bucket(n))
+		shapes[sql] = true
+	}
+	// 1, 2, 4, 8, 16, 32, and 64 values
+	assert.Len(t, shapes, 7)
+
+	g.InList = InListArray
+	a, _ := g.SelectEvents(cali.Query{EventIds: []int64{1}})
+	b, _ := g.SelectEvents(cali.Query{EventIds: []int64{1, 2, 3, 4, 5}})
+	assert.Equal(t, a, b)
+}
@@ -0,0 +1,80 @@
+package calisql
+
+import (
+	"context"
+)
+
+// SQLiteMigrations create the tables of the SQLiteDataStore, which are the same as the ones of
+// PostgresMigrations but with the types of SQLite. RETURNING needs SQLite 3.35 or later.
+var SQLiteMigrations = []Migration{
+	{Version: 1, Statements: []string{
+		`CREATE TABLE IF NOT EXISTS events (
+			id INTEGER PRIMARY KEY,
+			calendar_id INTEGER NOT NULL,
+			parent_id INTEGER,
+			source_id INTEGER,
+			external_key TEXT UNIQUE,
+			event_type INTEGER NOT NULL,
+			status INTEGER NOT NULL,
+			priority INTEGER NOT NULL,
+			visibility INTEGER NOT NULL,
+			title TEXT NOT NULL,
+			description TEXT,
+			floating_start TEXT NOT NULL,
+			floating_end TEXT NOT NULL,
+			data TEXT NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS events_calendar_range ON events (calendar_id, floating_start, floating_end)",
+		"CREATE INDEX IF NOT EXISTS events_range ON events (floating_start, floating_end)",
+		"CREATE INDEX IF NOT EXISTS events_parent ON events (parent_id)",
+		"CREATE INDEX IF NOT EXISTS events_source ON events (source_id)",
+		`CREATE TABLE IF NOT EXISTS invites (
+			event_id INTEGER NOT NULL REFERENCES events (id) ON DELETE CASCADE,
+			user_id INTEGER NOT NULL,
+			is_series BOOLEAN NOT NULL,
+			status INTEGER NOT NULL,
+			data TEXT NOT NULL,
+			PRIMARY KEY (event_id, user_id, is_series)
+		)`,
+		"CREATE INDEX IF NOT EXISTS invites_user ON invites (user_id, status)",
+	}},
+	{Version: 2, Statements: []string{
+		"ALTER TABLE events ADD COLUMN version INTEGER NOT NULL DEFAULT 0",
+		"UPDATE events SET version = json_extract(data, '$.version')",
+	}},
+	{Version: 3, Statements: []string{
+		`CREATE TABLE IF NOT EXISTS outbox (
+			id INTEGER PRIMARY KEY,
+			delivered BOOLEAN NOT NULL,
+			data TEXT NOT NULL
+		)`,
+		"CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (delivered, id)",
+	}},
+	{Version: 4, Statements: []string{
+		"UPDATE events SET floating_start = floating_start || ':00' WHERE length(floating_start) = 16",
+		"UPDATE events SET floating_end = floating_end || ':00' WHERE length(floating_end) = 16",
+	}},
+}
+
+// SQLiteDataStore is a cali.DataStore for SQLite, which keeps the events of an embedded or
+// single binary deployment in a file. It works like the PostgresDataStore and implements the
+// same interfaces of cali. It is spelled SQLiteDataStore (not SqliteDataStore) to match the
+// SQLite dialect and SQLiteMigrations.
+//
+// SQLite doesn't lock rows, so the database should be opened with WithMaxOpenConns(1) for
+// the changes to an event to not be interleaved. That is also needed for an in-memory
+// database (":memory:"), since every connection to one gets its own database.
+type SQLiteDataStore struct {
+	*dataStore
+}
+
+// NewSQLiteDataStore keeps the events and invites in the database, which should use the
+// SQLite dialect and have the tables of SQLiteMigrations (see Migrate)
+func NewSQLiteDataStore(db *DB) *SQLiteDataStore {
+	return &SQLiteDataStore{newDataStore(db, "")}
+}
+
+// Migrate creates or updates the tables of the data store with SQLiteMigrations
+func (d *SQLiteDataStore) Migrate(ctx context.Context) error {
+	return Migrate(ctx, d.db, SQLiteMigrations)
+}
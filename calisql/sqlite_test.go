@@ -0,0 +1,51 @@
+package calisql
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteDataStore(t *testing.T) {
+	var stored []byte
+	respond := inserts(0)
+	db, s := openScript(t, SQLite, func(query string, args []driver.Value) ([][]driver.Value, error) {
+		if strings.HasPrefix(query, "SELECT id, parent_id, data FROM events WHERE id = ?") && stored != nil {
+			return [][]driver.Value{{args[0], args[0], stored}}, nil
+		}
+		return respond(query, args)
+	})
+	store := NewSQLiteDataStore(db)
+	require.NoError(t, store.Migrate(context.Background()))
+	assert.Contains(t, s.log, SQLiteMigrations[0].Statements[0])
+
+	c := cali.NewCalendar(store)
+	e, count, err := c.Create(cali.Event{Title: "Standup", Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true,
+		IsRepeating: true, Repeat: &cali.Repeat{RepeatType: cali.RepeatTypeDaily, RepeatOccurrences: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, int64(1), *e.ParentId)
+	statements := s.statements()
+	assert.Equal(t, []string{
+		"BEGIN",
+		"INSERT INTO events", "UPDATE events SET", "INSERT INTO invites",
+		"INSERT INTO events", "INSERT INTO invites",
+		"COMMIT",
+	}, statements[len(statements)-7:], "the occurrences are created in one transaction")
+	assert.Contains(t, s.log, "INSERT INTO events (calendar_id, parent_id, source_id, external_key, event_type, status, priority, visibility, title, description, floating_start, floating_end, version, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) RETURNING id")
+	assert.Contains(t, s.log, "INSERT INTO invites (event_id, user_id, is_series, status, data) VALUES (?, ?, ?, ?, ?) ON CONFLICT (event_id, user_id, is_series) DO UPDATE SET status = excluded.status, data = excluded.data")
+
+	stored, err = json.Marshal(e)
+	require.NoError(t, err)
+	before := len(s.log)
+	require.NoError(t, store.SetTitle(1, "Team Standup"))
+	assert.Equal(t, []string{"BEGIN", "SELECT id, parent_id,", "UPDATE events SET", "COMMIT"}, s.statements()[before:])
+	assert.Equal(t, "SELECT id, parent_id, data FROM events WHERE id = ?", s.log[before+1], "SQLite doesn't lock rows")
+	assert.Equal(t, "Team Standup", s.args[before+2][8])
+}
@@ -0,0 +1,6 @@
+// Package sqlitetest runs the calisql.SQLiteDataStore against a real in-memory SQLite
+// database. It is its own module, so that the SQLite driver is only a dependency of these
+// tests and not of cali. Run them from this directory with
+//
+//	go test ./...
+package sqlitetest
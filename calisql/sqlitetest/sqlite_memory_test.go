@@ -0,0 +1,192 @@
+package sqlitetest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/Kenoshen/cali"
+	"github.com/Kenoshen/cali/calisql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// TestSQLiteMemory runs the SQLiteDataStore against a real in-memory SQLite database
+func TestSQLiteMemory(t *testing.T) {
+	db, err := calisql.Open("sqlite", ":memory:", calisql.SQLite, calisql.WithMaxOpenConns(1))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+	store := calisql.NewSQLiteDataStore(db)
+	require.NoError(t, store.Migrate(context.Background()))
+	require.NoError(t, store.Migrate(context.Background()), "the migrations that ran are skipped")
+
+	c := cali.NewCalendar(store)
+	e, count, err := c.Create(cali.Event{OwnerId: 1, Title: "Standup", Zone: "America/Denver", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15",
+		IsRepeating: true, Repeat: &cali.Repeat{RepeatType: cali.RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	_, _, err = c.Create(cali.Event{OwnerId: 1, Title: "Lunch", Zone: "UTC", StartDay: "2008-01-02", StartTime: "12:00", EndDay: "2008-01-02", EndTime: "13:00"})
+	require.NoError(t, err)
+
+	events, err := c.Query(cali.Query{ParentIds: []int64{*e.ParentId}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for i, day := range []string{"2008-01-01", "2008-01-02", "2008-01-03"} {
+		assert.Equal(t, day, events[i].StartDay)
+		assert.Equal(t, "Standup", events[i].Title)
+	}
+	found, err := c.Query(cali.Query{Text: []string{"lunch"}})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "Lunch", found[0].Title)
+
+	require.NoError(t, c.InviteUser(e.Id, 2, cali.PermissionInvitee, cali.RepeatEditTypeThis))
+	require.NoError(t, c.AcceptInvitation(e.Id, 2, cali.RepeatEditTypeThis))
+	invite, err := store.GetInvite(e.Id, 2)
+	require.NoError(t, err)
+	require.NotNil(t, invite)
+	assert.Equal(t, cali.InviteStatusConfirmed, invite.Status)
+
+	require.NoError(t, c.UpdateTitle(e.Id, "Team Standup", cali.RepeatEditTypeAll))
+	require.NoError(t, c.UpdateTime(events[1].Id, "10:00", "10:30", cali.RepeatEditTypeThis))
+	updated, err := store.Get(events[1].Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Team Standup", updated.Title)
+	assert.Equal(t, "10:00", updated.StartTime)
+	assert.Equal(t, "10:30", updated.EndTime)
+	assert.Greater(t, updated.Version, events[1].Version)
+
+	removedId := events[2].Id
+	require.NoError(t, c.Remove(removedId, cali.RepeatEditTypeThis))
+	events, err = c.Query(cali.Query{ParentIds: []int64{*e.ParentId}})
+	require.NoError(t, err)
+	require.Len(t, events, 2, "removed events aren't queried")
+	removed, err := store.Get(removedId)
+	require.NoError(t, err)
+	require.NotNil(t, removed, "we never delete things")
+	assert.Equal(t, cali.StatusRemoved, removed.Status)
+}
+
+func TestSQLiteMemoryIfMatchRace(t *testing.T) {
+	db, err := calisql.Open("sqlite", ":memory:", calisql.SQLite, calisql.WithMaxOpenConns(1))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+	store := calisql.NewSQLiteDataStore(db)
+	require.NoError(t, store.Migrate(context.Background()))
+	c := cali.NewCalendar(store)
+	e, _, err := c.Create(cali.Event{OwnerId: 1, Title: "Lunch", Zone: "UTC", StartDay: "2008-01-02", StartTime: "12:00", EndDay: "2008-01-02", EndTime: "13:00"})
+	require.NoError(t, err)
+	etag := e.ETag()
+
+	// every writer has the same etag, so only one of them can edit the event
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.IfMatch(etag).UpdateTitle(e.Id, fmt.Sprint("Lunch ", i), cali.RepeatEditTypeThis)
+		}(i)
+	}
+	wg.Wait()
+	var winner string
+	for i, err := range errs {
+		if err == nil {
+			assert.Empty(t, winner, "only one edit is made")
+			winner = fmt.Sprint("Lunch ", i)
+			continue
+		}
+		assert.Equal(t, cali.ErrorPreconditionFailed, err)
+	}
+	saved, err := store.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, winner, saved.Title)
+	assert.Equal(t, e.Version+1, saved.Version)
+
+	// a writer that read the event before the edit can't overwrite it
+	err = store.IfVersion(e.Id, e.Version, func(tx cali.DataStore) error {
+		return tx.SetTitle(e.Id, "Stale")
+	})
+	assert.Equal(t, cali.ErrorPreconditionFailed, err)
+	require.NoError(t, store.IfVersion(e.Id, saved.Version, func(tx cali.DataStore) error {
+		if err := tx.SetTitle(e.Id, "Team Lunch"); err != nil {
+			return err
+		}
+		return tx.SetUserData(e.Id, map[string]interface{}{"room": "4B"})
+	}), "the version moves along with the changes in IfVersion")
+	saved, err = store.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "Team Lunch", saved.Title)
+	assert.Equal(t, e.Version+3, saved.Version)
+}
+
+func TestSQLiteMemoryOutbox(t *testing.T) {
+	db, err := calisql.Open("sqlite", ":memory:", calisql.SQLite, calisql.WithMaxOpenConns(1))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+	store := calisql.NewSQLiteDataStore(db)
+	require.NoError(t, store.Migrate(context.Background()))
+	c := cali.NewCalendar(store, cali.WithOutbox())
+	e, _, err := c.Create(cali.Event{OwnerId: 1, Title: "Lunch", Zone: "UTC", StartDay: "2008-01-02", StartTime: "12:00", EndDay: "2008-01-02", EndTime: "13:00"})
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateTitle(e.Id, "Team Lunch", cali.RepeatEditTypeThis))
+
+	var titles []string
+	failing := true
+	drainer := &cali.OutboxDrainer{Store: store, Deliver: func(record cali.OutboxRecord) error {
+		if failing {
+			return fmt.Errorf("webhook is down")
+		}
+		change, err := record.Change()
+		require.NoError(t, err)
+		titles = append(titles, change.Event.Title)
+		return nil
+	}}
+	delivered, err := drainer.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	pending, err := store.PendingOutboxRecords(10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, int64(1), pending[0].Attempts)
+	assert.Equal(t, "webhook is down", pending[0].LastError)
+
+	failing = false
+	delivered, err = drainer.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+	assert.Equal(t, []string{"Lunch", "Team Lunch"}, titles)
+	pending, err = store.PendingOutboxRecords(10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+	assert.Equal(t, cali.ErrorOutboxRecordNotFound, store.MarkOutboxDelivered(99))
+}
+
+func TestSQLiteMemoryTextWildcards(t *testing.T) {
+	db, err := calisql.Open("sqlite", ":memory:", calisql.SQLite, calisql.WithMaxOpenConns(1))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, db.Close())
+	})
+	store := calisql.NewSQLiteDataStore(db)
+	require.NoError(t, store.Migrate(context.Background()))
+	c := cali.NewCalendar(store)
+	for _, title := range []string{"50% off", "500 off", "Wow! Sale", "Wow Sale"} {
+		_, _, err := c.Create(cali.Event{OwnerId: 1, Title: title, Zone: "UTC", StartDay: "2008-01-02", EndDay: "2008-01-02", IsAllDay: true})
+		require.NoError(t, err)
+	}
+	for text, title := range map[string]string{"50%": "50% off", "wow!": "Wow! Sale"} {
+		found, err := c.Query(cali.Query{Text: []string{text}})
+		require.NoError(t, err)
+		require.Len(t, found, 1, text)
+		assert.Equal(t, title, found[0].Title)
+	}
+}
@@ -0,0 +1,45 @@
+package cali
+
+// CancelReasonStore is an optional interface for a data store that can save why an event
+// was canceled (see CancelWithReason)
+type CancelReasonStore interface {
+	// SetCancelReason updates the event with the reason it was canceled, or nil to clear it
+	SetCancelReason(eventId int64, reason *string) error
+}
+
+// CancelWithReason sets the status of the event to StatusCanceled like Cancel and keeps the
+// reason on the event, so it is in the canceled notifications, the changes of Watch, and the
+// COMMENT of the ical export. A nil reason is the same as Cancel, and a reason needs a data
+// store that implements CancelReasonStore.
+func (c *Calendar) CancelWithReason(eventId int64, reason *string, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[CancelReasonStore](c.dataStore)
+		if reason != nil && !ok {
+			return ErrorCancelReasonNotSupported
+		}
+		return c.setStatus(OperationCancel, editType, eventId, StatusCanceled, func(eventId int64) error {
+			if reason != nil {
+				if err := store.SetCancelReason(eventId, reason); err != nil {
+					return err
+				}
+			}
+			if err := c.releaseConference(eventId); err != nil {
+				return err
+			}
+			return c.notifyCanceled(eventId)
+		})
+	})
+}
+
+// clearCancelReason removes the reason of an event that isn't canceled anymore
+func (c *Calendar) clearCancelReason(eventId int64) error {
+	store, ok := capability[CancelReasonStore](c.dataStore)
+	if !ok {
+		return nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil || e == nil || e.CancelReason == nil {
+		return err
+	}
+	return store.SetCancelReason(eventId, nil)
+}
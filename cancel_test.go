@@ -0,0 +1,65 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelWithReason(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			sender := &testSender{}
+			c := NewCalendar(tc.store, WithNotificationSender(sender))
+			e, _, err := c.Create(Event{OwnerId: 1, Title: "Offsite", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+			require.NoError(t, err)
+			require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+			changes, stop := c.Watch(10)
+			defer stop()
+			sender.sent = nil
+
+			reason := "the venue flooded"
+			require.NoError(t, c.CancelWithReason(e.Id, &reason, RepeatEditTypeThis))
+			require.Len(t, sender.sent, 1)
+			assert.Equal(t, NotificationTypeEventCanceled, sender.sent[0].Type)
+			assert.Equal(t, &reason, sender.sent[0].Event.CancelReason)
+			change := <-changes
+			assert.Equal(t, &reason, change.Event.CancelReason)
+			got, err := c.Get(e.Id)
+			require.NoError(t, err)
+			assert.Equal(t, StatusCanceled, got.Status)
+			assert.Contains(t, got.MarshallToICal(), "STATUS:CANCELLED\r\nCOMMENT:the venue flooded\r\n")
+
+			require.NoError(t, c.Restore(e.Id, RepeatEditTypeThis))
+			got, err = c.Get(e.Id)
+			require.NoError(t, err)
+			assert.Equal(t, StatusActive, got.Status)
+			assert.Nil(t, got.CancelReason)
+		})
+	}
+}
+
+func TestCancelWithReasonNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	reason := "no reason"
+	assert.Equal(t, ErrorCancelReasonNotSupported, c.CancelWithReason(e.Id, &reason, RepeatEditTypeThis))
+	require.NoError(t, c.CancelWithReason(e.Id, nil, RepeatEditTypeThis))
+}
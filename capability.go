@@ -0,0 +1,111 @@
+package cali
+
+import (
+	"strings"
+)
+
+// The optional capabilities of a data store. The calendar checks for each one (see
+// capability) and falls back to the generic behavior with the DataStore methods when the data
+// store doesn't have it, so a simple data store only needs to implement DataStore.
+
+// WrapperStore is a data store that wraps other data stores, like the EncryptedDataStore. A
+// wrapper has the methods of the optional interfaces so that it can pass them on, but it only
+// has a capability when all of the data stores that it wraps have it.
+type WrapperStore interface {
+	// Unwrap gets the data stores that are wrapped
+	Unwrap() []DataStore
+}
+
+// capability gets the data store as the optional interface T. A WrapperStore only has T when
+// the data stores that it wraps have it, so wrapping a data store doesn't change which
+// features of the calendar work.
+func capability[T any](store DataStore) (T, bool) {
+	result, ok := store.(T)
+	if !ok {
+		return result, false
+	}
+	if wrapper, ok := store.(WrapperStore); ok {
+		for _, inner := range wrapper.Unwrap() {
+			if _, ok := capability[T](inner); !ok {
+				var none T
+				return none, false
+			}
+		}
+	}
+	return result, true
+}
+
+// TxStore is an optional interface for a data store that can make many changes in a single
+// transaction. The calendar uses it to create the events (and series record) of a repeating
+// event together, and publishes the changes after the transaction is committed.
+type TxStore interface {
+	// InTx calls f with a data store that makes its changes in one transaction, which is
+	// committed if f returns nil and rolled back otherwise
+	InTx(f func(tx DataStore) error) error
+}
+
+// VersionStore is an optional interface for a data store that can make the changes to an event
+// conditional on the version of the event (see Event.Version), which the calendar uses to
+// compare and set the event for IfMatch
+type VersionStore interface {
+	// IfVersion calls f with a data store whose changes to the event fail with
+	// ErrorPreconditionFailed unless the event has the version. The version is compared as
+	// the event is changed, and each change of f moves the expected version along with it.
+	IfVersion(eventId int64, version int64, f func(store DataStore) error) error
+}
+
+// BatchStore is a data store that has all of the batch operations. The calendar checks
+// for each of them on its own, so a data store can implement only some of them.
+type BatchStore interface {
+	BatchCreateStore
+	BatchGetStore
+	BatchStatusStore
+}
+
+// SearchStore is an optional interface for a data store that has a full text index
+type SearchStore interface {
+	// Search finds the events that match the text and the query, with the most relevant first
+	Search(text string, q Query) ([]*Event, error)
+}
+
+// GeoStore is a data store with a geo index, which handles the Near field of queries
+type GeoStore = GeoIndexStore
+
+// inTx calls f with a copy of the calendar that uses a transaction of the data store, or with
+// the calendar itself if the data store doesn't implement TxStore
+func (c *Calendar) inTx(f func(tx *Calendar) error) error {
+	store, ok := capability[TxStore](c.dataStore)
+	if !ok {
+		return f(c)
+	}
+	return store.InTx(func(tx DataStore) error {
+		scoped := *c
+		scoped.dataStore = tx
+		return f(&scoped)
+	})
+}
+
+// Search finds the events that match the text and the query. If the data store implements
+// SearchStore, then its ranking is used, otherwise the words of the text are matched like
+// the Text field of Query and the events are in time order.
+func (c *Calendar) Search(text string, q Query) ([]*Event, error) {
+	store, ok := capability[SearchStore](c.dataStore)
+	if !ok {
+		q.Text = append(q.Text, strings.Fields(text)...)
+		return c.Query(q)
+	}
+	results, err := store.Search(text, c.withRangeBounds(c.withStatusDefaults(q)))
+	if err != nil {
+		return nil, err
+	}
+	if results, err = c.authorizedEvents(results); err != nil {
+		return nil, err
+	}
+	if results, err = c.withSeries(results); err != nil {
+		return nil, err
+	}
+	if results, err = c.withReactions(results); err != nil {
+		return nil, err
+	}
+	return c.withDisplay(results), nil
+}
@@ -0,0 +1,183 @@
+package cali
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// plainStore only has the DataStore methods, like a simple third party data store
+type plainStore struct {
+	DataStore
+}
+
+// assertPlainWrapper checks that a wrapper of a plainStore doesn't have the capabilities that
+// the plainStore doesn't have, so the calendar falls back to the DataStore methods
+func assertPlainWrapper(t *testing.T, store DataStore) {
+	_, ok := capability[SeriesInviteStore](store)
+	assert.False(t, ok)
+	_, ok = capability[RSVPSummaryStore](store)
+	assert.False(t, ok)
+
+	c := NewCalendar(store)
+	e, count, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	require.NoError(t, c.InviteUser(e.Id, 7, PermissionInvitee, RepeatEditTypeAll))
+	events, err := c.Query(Query{ParentIds: []int64{*e.ParentId}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	for _, occurrence := range events {
+		invite, err := store.GetInvite(occurrence.Id, 7)
+		require.NoError(t, err)
+		require.NotNil(t, invite, "every occurrence has its own invite")
+		assert.Equal(t, InviteStatusPending, invite.Status)
+	}
+}
+
+func TestOptionalSetters(t *testing.T) {
+	location := "Room 4"
+	tests := []struct {
+		name   string
+		update func(c *Calendar, eventId int64) error
+		err    error
+	}{
+		{name: "location", err: ErrorLocationNotSupported, update: func(c *Calendar, eventId int64) error {
+			return c.UpdateLocation(eventId, &location, RepeatEditTypeThis)
+		}},
+		{name: "geo", err: ErrorGeoNotSupported, update: func(c *Calendar, eventId int64) error {
+			return c.UpdateGeo(eventId, &GeoPoint{Latitude: 40.7, Longitude: -74}, RepeatEditTypeThis)
+		}},
+		{name: "visibility", err: ErrorVisibilityNotSupported, update: func(c *Calendar, eventId int64) error {
+			return c.UpdateVisibility(eventId, VisibilityPrivate, RepeatEditTypeThis)
+		}},
+		{name: "disallow forwarding", err: ErrorForwardingNotSupported, update: func(c *Calendar, eventId int64) error {
+			return c.UpdateDisallowForwarding(eventId, true, RepeatEditTypeThis)
+		}},
+		{name: "priority", err: ErrorPriorityNotSupported, update: func(c *Calendar, eventId int64) error {
+			return c.UpdatePriority(eventId, PriorityHigh, RepeatEditTypeThis)
+		}},
+		{name: "private note", err: ErrorPrivateInviteNotSupported, update: func(c *Calendar, eventId int64) error {
+			return c.UpdatePrivateNote(eventId, 0, &location, RepeatEditTypeThis)
+		}},
+		{name: "invite user data", err: ErrorPrivateInviteNotSupported, update: func(c *Calendar, eventId int64) error {
+			return c.UpdateInvitationUserData(eventId, 0, map[string]interface{}{"color": "red"}, RepeatEditTypeThis)
+		}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			for _, store := range []DataStore{&InMemoryDataStore{}, plainStore{&InMemoryDataStore{}}} {
+				c := NewCalendar(store)
+				e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+				require.NoError(t, err)
+				err = tc.update(c, e.Id)
+				if _, plain := store.(plainStore); plain {
+					assert.Equal(t, tc.err, err, "a data store with only the DataStore methods doesn't support it")
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+		})
+	}
+}
+
+// txStore commits the writes of a transaction by replaying them on the data store, so
+// nothing is saved if the transaction fails
+type txStore struct {
+	*InMemoryDataStore
+	commits int
+	fail    bool
+}
+
+func (d *txStore) InTx(f func(tx DataStore) error) error {
+	staged := &InMemoryDataStore{curId: d.curId}
+	if err := f(staged); err != nil {
+		return err
+	}
+	if d.fail {
+		return errors.New("could not commit")
+	}
+	for _, e := range staged.events {
+		if _, err := d.InMemoryDataStore.Create(*e); err != nil {
+			return err
+		}
+	}
+	d.commits++
+	return nil
+}
+
+func TestTxStore(t *testing.T) {
+	store := &txStore{InMemoryDataStore: &InMemoryDataStore{}}
+	c := NewCalendar(store)
+	changes, stop := c.Watch(10)
+	defer stop()
+	repeating := Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}}
+
+	e, count, err := c.Create(repeating)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, 1, store.commits)
+	events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, ChangeTypeCreated, (<-changes).Type)
+	}
+
+	// nothing is saved or published when the transaction fails
+	store.fail = true
+	_, _, err = c.Create(repeating)
+	assert.EqualError(t, err, "could not commit")
+	events, err = c.Query(Query{})
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Empty(t, changes)
+}
+
+// searchStore ranks the events with the longest title first
+type searchStore struct {
+	*InMemoryDataStore
+}
+
+func (d searchStore) Search(text string, q Query) ([]*Event, error) {
+	q.Text = []string{text}
+	events, err := d.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(events); i++ {
+		for j := i + 1; j < len(events); j++ {
+			if len(events[j].Title) > len(events[i].Title) {
+				events[i], events[j] = events[j], events[i]
+			}
+		}
+	}
+	return events, nil
+}
+
+func TestSearch(t *testing.T) {
+	for _, store := range []DataStore{&InMemoryDataStore{}, searchStore{&InMemoryDataStore{}}} {
+		c := NewCalendar(store)
+		for i, title := range []string{"Team sync", "Team offsite planning", "Lunch"} {
+			day := fmt.Sprintf("2008-01-0%d", i+1)
+			_, _, err := c.Create(Event{Title: title, StartDay: day, EndDay: day, IsAllDay: true, Zone: "UTC"})
+			require.NoError(t, err)
+		}
+		events, err := c.Search("Team", Query{})
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		if _, ok := store.(SearchStore); ok {
+			assert.Equal(t, "Team offsite planning", events[0].Title, "ranked by the data store")
+		} else {
+			assert.Equal(t, "Team sync", events[0].Title, "in time order")
+		}
+	}
+}
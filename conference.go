@@ -0,0 +1,104 @@
+package cali
+
+// Conference is an online meeting (like Zoom, Google Meet, or Microsoft Teams) for an event
+type Conference struct {
+	// Id is the provider's id for the meeting
+	Id string `json:"id"`
+	// Provider is the name of the provider that created the meeting
+	Provider string `json:"provider"`
+	// JoinUrl is the link that attendees use to join the meeting
+	JoinUrl string `json:"joinUrl"`
+}
+
+// ConferenceProvider creates and deletes online meetings for events
+type ConferenceProvider interface {
+	// CreateMeeting creates a meeting for the event. For a repeating series, only one
+	// meeting is created (with the first event) and it is shared by the whole series.
+	CreateMeeting(e Event) (*Conference, error)
+	// DeleteMeeting deletes the meeting once no active events are using it
+	DeleteMeeting(conference Conference) error
+}
+
+// ConferenceStore is an optional interface for a data store that can save the online meetings
+// of events (see WithConferenceProvider)
+type ConferenceStore interface {
+	// SetConference updates the event with the online meeting
+	SetConference(eventId int64, conference *Conference) error
+}
+
+// WithConferenceProvider creates an online meeting for every event that is created
+// with AddConference, and deletes the meeting when the events are canceled or removed.
+// The meetings are saved on the events, which needs a data store that implements ConferenceStore.
+func WithConferenceProvider(provider ConferenceProvider) CalendarOption {
+	return func(c *Calendar) {
+		c.conferenceProvider = provider
+	}
+}
+
+// addConference creates a single meeting for the events if they were created with AddConference
+func (c *Calendar) addConference(events []*Event) error {
+	if len(events) == 0 || events[0] == nil || !events[0].AddConference || events[0].Conference != nil {
+		return nil
+	}
+	if c.conferenceProvider == nil {
+		return ErrorConferenceNotConfigured
+	}
+	store, ok := capability[ConferenceStore](c.dataStore)
+	if !ok {
+		return ErrorConferenceNotSupported
+	}
+	conference, err := c.conferenceProvider.CreateMeeting(*events[0])
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := store.SetConference(e.Id, conference); err != nil {
+			return err
+		}
+		e.Conference = conference
+		if err := c.publish(ChangeTypeUpdated, e.Id, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseConference deletes the meeting of a canceled or removed event if no other active
+// events in its series are using the same meeting, and then takes the meeting off of the events.
+// A data store without ConferenceStore never has meetings to release.
+func (c *Calendar) releaseConference(eventId int64) error {
+	store, ok := capability[ConferenceStore](c.dataStore)
+	if c.conferenceProvider == nil || !ok {
+		return nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil || e == nil || e.Conference == nil {
+		return err
+	}
+	sharing := []*Event{e}
+	if e.ParentId != nil {
+		others, err := c.dataStore.Query(Query{ParentIds: []int64{*e.ParentId}})
+		if err != nil {
+			return err
+		}
+		sharing = nil
+		for _, other := range others {
+			if other.Conference == nil || other.Conference.Id != e.Conference.Id {
+				continue
+			}
+			if other.Status == StatusActive {
+				return nil
+			}
+			sharing = append(sharing, other)
+		}
+	}
+	if err := c.conferenceProvider.DeleteMeeting(*e.Conference); err != nil {
+		return err
+	}
+	for _, other := range sharing {
+		if err := store.SetConference(other.Id, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
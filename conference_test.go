@@ -0,0 +1,86 @@
+package cali
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConferenceProvider struct {
+	created []int64
+	deleted []string
+}
+
+func (p *testConferenceProvider) CreateMeeting(e Event) (*Conference, error) {
+	p.created = append(p.created, e.Id)
+	id := fmt.Sprintf("m%d", e.Id)
+	return &Conference{Id: id, Provider: "test", JoinUrl: "https://meet.example.com/" + id}, nil
+}
+
+func (p *testConferenceProvider) DeleteMeeting(conference Conference) error {
+	p.deleted = append(p.deleted, conference.Id)
+	return nil
+}
+
+func TestConference(t *testing.T) {
+	provider := &testConferenceProvider{}
+	c := NewCalendar(&InMemoryDataStore{}, WithConferenceProvider(provider))
+
+	a, _, err := c.Create(Event{AddConference: true, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NotNil(t, a.Conference)
+	assert.Equal(t, fmt.Sprintf("https://meet.example.com/m%d", a.Id), a.Conference.JoinUrl)
+
+	b, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	assert.Nil(t, b.Conference)
+	assert.Equal(t, []int64{a.Id}, provider.created)
+
+	require.NoError(t, c.Cancel(a.Id, RepeatEditTypeThis))
+	require.NoError(t, c.Remove(a.Id, RepeatEditTypeThis))
+	assert.Equal(t, []string{fmt.Sprintf("m%d", a.Id)}, provider.deleted)
+	assert.Nil(t, a.Conference)
+
+	_, _, err = NewCalendar(&InMemoryDataStore{}).Create(Event{AddConference: true, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	assert.Equal(t, ErrorConferenceNotConfigured, err)
+
+	plain := &testConferenceProvider{}
+	_, _, err = NewCalendar(plainStore{&InMemoryDataStore{}}, WithConferenceProvider(plain)).Create(Event{AddConference: true, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	assert.Equal(t, ErrorConferenceNotSupported, err)
+	assert.Empty(t, plain.created, "no meeting is created when the data store can't save it")
+}
+
+func TestConferenceSeries(t *testing.T) {
+	provider := &testConferenceProvider{}
+	c := NewCalendar(&InMemoryDataStore{}, WithConferenceProvider(provider))
+
+	a, count, err := c.Create(Event{
+		AddConference: true,
+		StartDay:      "2008-01-01",
+		EndDay:        "2008-01-01",
+		IsAllDay:      true,
+		Zone:          "UTC",
+		IsRepeating:   true,
+		Repeat:        &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+	assert.Len(t, provider.created, 1)
+
+	require.NotNil(t, a.Conference)
+	meetingId := a.Conference.Id
+	events, err := c.Query(Query{ParentIds: []int64{a.Id}})
+	require.NoError(t, err)
+	for _, e := range events {
+		require.NotNil(t, e.Conference)
+		assert.Equal(t, meetingId, e.Conference.Id)
+	}
+
+	// the meeting is only deleted once every event is canceled
+	require.NoError(t, c.Cancel(events[1].Id, RepeatEditTypeThis))
+	assert.Empty(t, provider.deleted)
+	require.NoError(t, c.Cancel(a.Id, RepeatEditTypeAll))
+	assert.Equal(t, []string{meetingId}, provider.deleted)
+}
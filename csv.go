@@ -0,0 +1,330 @@
+package cali
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ColumnMap is the set of CSV header names used for each event field when
+// importing or exporting events. Empty column names are skipped.
+type ColumnMap struct {
+	Title       string
+	StartDay    string
+	StartTime   string
+	EndDay      string
+	EndTime     string
+	AllDay      string
+	Description string
+	Location    string
+	Url         string
+	TimeZone    string
+
+	// DayFormat is the time package layout for the day columns
+	DayFormat string
+	// TimeFormat is the time package layout for the time columns
+	TimeFormat string
+	// Zone is given to every imported event that doesn't have a TimeZone value, it defaults to "UTC"
+	Zone string
+}
+
+// GoogleColumnMap matches the CSV columns that Google Calendar imports
+var GoogleColumnMap = ColumnMap{
+	Title:       "Subject",
+	StartDay:    "Start Date",
+	StartTime:   "Start Time",
+	EndDay:      "End Date",
+	EndTime:     "End Time",
+	AllDay:      "All Day Event",
+	Description: "Description",
+	Location:    "Location",
+	TimeZone:    "Time Zone",
+	DayFormat:   "01/02/2006",
+	TimeFormat:  "3:04 PM",
+}
+
+// OutlookColumnMap matches the CSV columns that Outlook imports and exports
+var OutlookColumnMap = ColumnMap{
+	Title:       "Subject",
+	StartDay:    "Start Date",
+	StartTime:   "Start Time",
+	EndDay:      "End Date",
+	EndTime:     "End Time",
+	AllDay:      "All day event",
+	Description: "Description",
+	Location:    "Location",
+	TimeZone:    "Time Zone",
+	DayFormat:   "1/2/2006",
+	TimeFormat:  "3:04:05 PM",
+}
+
+// csvDayFormats are tried in order when a day doesn't match the column map's DayFormat
+var csvDayFormats = []string{time.DateOnly, "01/02/2006", "1/2/2006", "2006/01/02"}
+
+// csvTimeFormats are tried in order when a time doesn't match the column map's TimeFormat
+var csvTimeFormats = []string{TimeFormat, "15:04:05", "3:04 PM", "3:04:05 PM", "3:04PM", "3PM", "3 PM"}
+
+// ExportCSV writes all of the events that match the query to w using the GoogleColumnMap
+func (c *Calendar) ExportCSV(w io.Writer, q Query) error {
+	return c.ExportCSVWithColumns(w, q, GoogleColumnMap)
+}
+
+// ExportCSVWithColumns writes all of the events that match the query to w using the column map
+func (c *Calendar) ExportCSVWithColumns(w io.Writer, q Query, columns ColumnMap) error {
+	events, err := c.Query(q)
+	if err != nil {
+		return err
+	}
+	columns = columns.withDefaults()
+
+	fields := columns.fields()
+	writer := csv.NewWriter(w)
+	header := make([]string, 0, len(fields))
+	for _, f := range fields {
+		header = append(header, f.column)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, e := range events {
+		row := make([]string, 0, len(fields))
+		for _, f := range fields {
+			row = append(row, f.get(columns, *e))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportCSV reads every row of the CSV and creates an event for it. The first row
+// must be a header with the column names from the mapping. If any row fails, then
+// the error says which row and the events before it are still created.
+func (c *Calendar) ImportCSV(r io.Reader, mapping ColumnMap) ([]*Event, error) {
+	events, err := ParseCSV(r, mapping)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Event
+	for i, e := range events {
+		newEvent, _, err := c.Create(e)
+		if err != nil {
+			return result, fmt.Errorf("row %d: %w", i+2, err)
+		}
+		result = append(result, newEvent)
+	}
+	return result, nil
+}
+
+// ParseCSV converts every row of the CSV into an event without saving them
+func ParseCSV(r io.Reader, mapping ColumnMap) ([]Event, error) {
+	records, err := readCSV(r, mapping)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, 0, len(records))
+	for _, record := range records {
+		if record.err != nil {
+			return nil, fmt.Errorf("row %d: %w", record.row, record.err)
+		}
+		events = append(events, record.event)
+	}
+	return events, nil
+}
+
+// readCSV converts every row of the CSV into an import record, where a row that can't be
+// converted has the error instead of stopping the rest of the rows
+func readCSV(r io.Reader, mapping ColumnMap) ([]importRecord, error) {
+	mapping = mapping.withDefaults()
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		index[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := index[strings.ToLower(mapping.StartDay)]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrorMissingCSVColumn, mapping.StartDay)
+	}
+
+	var records []importRecord
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		value := func(column string) string {
+			if column == "" {
+				return ""
+			}
+			i, ok := index[strings.ToLower(column)]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		e, err := mapping.event(value)
+		records = append(records, importRecord{row: row, event: e, err: err})
+	}
+	return records, nil
+}
+
+// event builds a single event out of the values of a row
+func (m ColumnMap) event(value func(column string) string) (Event, error) {
+	e := Event{
+		Title:    value(m.Title),
+		Status:   StatusActive,
+		Zone:     m.Zone,
+		IsAllDay: parseCSVBool(value(m.AllDay)),
+	}
+	startDay, err := parseCSVDay(value(m.StartDay), m.DayFormat)
+	if err != nil {
+		return e, ErrorInvalidStartDay
+	}
+	e.StartDay = startDay
+	e.EndDay = startDay
+	if v := value(m.EndDay); v != "" {
+		if e.EndDay, err = parseCSVDay(v, m.DayFormat); err != nil {
+			return e, ErrorInvalidEndDay
+		}
+	}
+
+	startTime := value(m.StartTime)
+	endTime := value(m.EndTime)
+	if startTime == "" && endTime == "" {
+		e.IsAllDay = true
+	}
+	if !e.IsAllDay {
+		if e.StartTime, err = parseCSVTime(startTime, m.TimeFormat); err != nil {
+			return e, ErrorInvalidStartTime
+		}
+		if e.EndTime, err = parseCSVTime(endTime, m.TimeFormat); err != nil {
+			return e, ErrorInvalidEndTime
+		}
+	}
+
+	if v := value(m.Description); v != "" {
+		e.Description = &v
+	}
+	if v := value(m.Location); v != "" {
+		e.Location = &v
+	}
+	if v := value(m.Url); v != "" {
+		e.Url = &v
+	}
+	if v := value(m.TimeZone); v != "" {
+		e.Zone = v
+	}
+	return e, nil
+}
+
+type csvField struct {
+	column string
+	get    func(m ColumnMap, e Event) string
+}
+
+// fields are the columns that have a name in the order they are exported
+func (m ColumnMap) fields() []csvField {
+	str := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	all := []csvField{
+		{m.Title, func(m ColumnMap, e Event) string { return e.Title }},
+		{m.StartDay, func(m ColumnMap, e Event) string { return formatCSVDay(e.StartDay, m.DayFormat) }},
+		{m.StartTime, func(m ColumnMap, e Event) string { return formatCSVTime(e.StartTime, m.TimeFormat) }},
+		{m.EndDay, func(m ColumnMap, e Event) string { return formatCSVDay(e.EndDay, m.DayFormat) }},
+		{m.EndTime, func(m ColumnMap, e Event) string { return formatCSVTime(e.EndTime, m.TimeFormat) }},
+		{m.AllDay, func(m ColumnMap, e Event) string {
+			if e.IsAllDay {
+				return "True"
+			}
+			return "False"
+		}},
+		{m.Description, func(m ColumnMap, e Event) string { return str(e.Description) }},
+		{m.Location, func(m ColumnMap, e Event) string { return str(e.Location) }},
+		{m.Url, func(m ColumnMap, e Event) string { return str(e.Url) }},
+		{m.TimeZone, func(m ColumnMap, e Event) string { return e.Zone }},
+	}
+	var result []csvField
+	for _, f := range all {
+		if f.column != "" {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func (m ColumnMap) withDefaults() ColumnMap {
+	if m.DayFormat == "" {
+		m.DayFormat = time.DateOnly
+	}
+	if m.TimeFormat == "" {
+		m.TimeFormat = TimeFormat
+	}
+	if m.Zone == "" {
+		m.Zone = "UTC"
+	}
+	return m
+}
+
+func parseCSVDay(s string, format string) (string, error) {
+	var err error
+	for _, f := range append([]string{format}, csvDayFormats...) {
+		var t time.Time
+		if t, err = time.Parse(f, s); err == nil {
+			return t.Format(time.DateOnly), nil
+		}
+	}
+	return "", err
+}
+
+func parseCSVTime(s string, format string) (string, error) {
+	var err error
+	for _, f := range append([]string{format}, csvTimeFormats...) {
+		var t time.Time
+		if t, err = time.Parse(f, strings.ToUpper(s)); err == nil {
+			return t.Format(TimeFormat), nil
+		}
+	}
+	return "", err
+}
+
+func parseCSVBool(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "yes", "y", "1":
+		return true
+	}
+	return false
+}
+
+func formatCSVDay(day string, format string) string {
+	t, err := time.Parse(time.DateOnly, day)
+	if err != nil {
+		return day
+	}
+	return t.Format(format)
+}
+
+func formatCSVTime(hourMin string, format string) string {
+	if hourMin == "" {
+		return ""
+	}
+	t, err := time.Parse(TimeFormat, hourMin)
+	if err != nil {
+		return hourMin
+	}
+	return t.Format(format)
+}
@@ -0,0 +1,97 @@
+package cali
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCSV(t *testing.T) {
+	in := `Subject,Start Date,Start Time,End Date,End Time,All Day Event,Description,Location
+Standup,01/02/2008,9:00 AM,01/02/2008,9:15 AM,False,Daily sync,Room 1
+Offsite,01/03/2008,,01/04/2008,,True,,
+`
+	c := NewCalendar(&InMemoryDataStore{})
+	events, err := c.ImportCSV(strings.NewReader(in), GoogleColumnMap)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	assert.Equal(t, "Standup", events[0].Title)
+	assert.Equal(t, "2008-01-02", events[0].StartDay)
+	assert.Equal(t, "09:00", events[0].StartTime)
+	assert.Equal(t, "09:15", events[0].EndTime)
+	require.NotNil(t, events[0].Description)
+	assert.Equal(t, "Daily sync", *events[0].Description)
+	require.NotNil(t, events[0].Location)
+	assert.Equal(t, "Room 1", *events[0].Location)
+
+	assert.Equal(t, "Offsite", events[1].Title)
+	assert.True(t, events[1].IsAllDay)
+	assert.Equal(t, "2008-01-04", events[1].EndDay)
+	assert.Nil(t, events[1].Description)
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+
+	_, err := c.ImportCSV(strings.NewReader("Title,When\nA,B\n"), GoogleColumnMap)
+	require.ErrorIs(t, err, ErrorMissingCSVColumn)
+
+	_, err = c.ImportCSV(strings.NewReader("Subject,Start Date,Start Time,End Time\nA,01/02/2008,9:00 AM,nope\n"), GoogleColumnMap)
+	require.ErrorIs(t, err, ErrorInvalidEndTime)
+	assert.Contains(t, err.Error(), "row 2")
+}
+
+func TestExportCSV(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	location := "Room, 1"
+	_, _, err := c.Create(Event{Title: "Standup", StartDay: "2008-01-02", StartTime: "13:00", EndDay: "2008-01-02", EndTime: "13:15", Location: &location})
+	require.NoError(t, err)
+	_, _, err = c.Create(Event{Title: "Offsite", StartDay: "2008-01-03", EndDay: "2008-01-04", IsAllDay: true})
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, c.ExportCSV(&out, Query{}))
+	assert.Equal(t, `Subject,Start Date,Start Time,End Date,End Time,All Day Event,Description,Location,Time Zone
+Standup,01/02/2008,1:00 PM,01/02/2008,1:15 PM,False,,"Room, 1",
+Offsite,01/03/2008,,01/04/2008,,True,,,
+`, out.String())
+
+	// exported events can be imported again
+	events, err := NewCalendar(&InMemoryDataStore{}).ImportCSV(&out, GoogleColumnMap)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "13:00", events[0].StartTime)
+	assert.Equal(t, "Room, 1", *events[0].Location)
+}
+
+func TestCSVZoneRoundTrip(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	a, _, err := c.Create(Event{Title: "Standup", Zone: "America/Denver", StartDay: "2008-01-02", StartTime: "09:00", EndDay: "2008-01-02", EndTime: "09:15"})
+	require.NoError(t, err)
+
+	for _, columns := range []ColumnMap{GoogleColumnMap, OutlookColumnMap} {
+		var out bytes.Buffer
+		require.NoError(t, c.ExportCSVWithColumns(&out, Query{}, columns))
+		assert.Contains(t, out.String(), ",America/Denver\n")
+
+		events, err := NewCalendar(&InMemoryDataStore{}).ImportCSV(&out, columns)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "America/Denver", events[0].Zone)
+		assert.Equal(t, "09:00", events[0].StartTime)
+		start, err := events[0].Start()
+		require.NoError(t, err)
+		expected, err := a.Start()
+		require.NoError(t, err)
+		assert.True(t, expected.Equal(start), "the event is at the same instant after the round trip")
+	}
+
+	// rows without a zone get the Zone of the column map
+	events, err := ParseCSV(strings.NewReader("Start Date,Time Zone\n2008-01-02,\n"), ColumnMap{StartDay: "Start Date", TimeZone: "Time Zone", Zone: "Europe/Paris"})
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Paris", events[0].Zone)
+}
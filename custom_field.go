@@ -0,0 +1,246 @@
+package cali
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CustomFieldType is the type of the value of a custom field
+type CustomFieldType string
+
+const (
+	// CustomFieldTypeString is for any string value
+	CustomFieldTypeString CustomFieldType = "string"
+	// CustomFieldTypeNumber is for a number value, which is a float64 once read from JSON
+	CustomFieldTypeNumber CustomFieldType = "number"
+	// CustomFieldTypeEnum is for a string value that is one of the Options of the field
+	CustomFieldTypeEnum CustomFieldType = "enum"
+	// CustomFieldTypeDate is for a YYYY-MM-DD string value
+	CustomFieldTypeDate CustomFieldType = "date"
+)
+
+// CustomField is a typed field of the events of an event type (see WithCustomFields). The value
+// of the field is saved in the UserData of the event under its Key, so the data stores don't
+// need anything new to save it.
+type CustomField struct {
+	// Key is the UserData key of the value
+	Key string `json:"key"`
+	// Label is what the field is shown as, like "Room Capacity"
+	Label string `json:"label"`
+	// Type is the type of the value
+	Type CustomFieldType `json:"type"`
+	// Required is true if every event of the event type must have a value
+	Required bool `json:"required"`
+	// Options are the allowed values of a CustomFieldTypeEnum field
+	Options []string `json:"options"`
+}
+
+// CustomFieldError is returned when the UserData of an event doesn't fit one of its custom
+// fields, and it matches ErrorInvalidCustomField with errors.Is
+type CustomFieldError struct {
+	// Key is the key of the custom field
+	Key string
+	// Reason is what is wrong with the value, like "is required"
+	Reason string
+}
+
+func (e *CustomFieldError) Error() string {
+	return fmt.Sprintf("custom field %s %s", e.Key, e.Reason)
+}
+
+func (e *CustomFieldError) Is(target error) bool {
+	return target == ErrorInvalidCustomField
+}
+
+// CustomFieldQuery is a search on the value of a custom field (or any other UserData key) for
+// the CustomFields of a Query. Numbers are compared as numbers, and strings (like dates) are
+// compared lexically, so an event without a value, or with a value of another type, doesn't match.
+type CustomFieldQuery struct {
+	// Key is the UserData key of the value
+	Key string `json:"key"`
+	// Equals is an OR search for specific values
+	Equals []interface{} `json:"equals"`
+	// Min is an inclusive lower bound of the value
+	Min interface{} `json:"min"`
+	// Max is an inclusive upper bound of the value
+	Max interface{} `json:"max"`
+}
+
+// WithCustomFields defines the custom fields of the events of the event type, which are checked
+// when the events are created or their UserData is updated. UserData keys that aren't a custom
+// field are left alone.
+func WithCustomFields(eventType EventType, fields ...CustomField) CalendarOption {
+	return func(c *Calendar) {
+		if c.customFields == nil {
+			c.customFields = map[EventType][]CustomField{}
+		}
+		c.customFields[eventType] = append(c.customFields[eventType], fields...)
+	}
+}
+
+// CustomFields gets the custom fields of the event type, like for building a form
+func (c *Calendar) CustomFields(eventType EventType) []CustomField {
+	return append([]CustomField(nil), c.customFields[eventType]...)
+}
+
+// ValidateCustomFields checks that the required fields have a value and that every value has
+// the type of its field
+func ValidateCustomFields(fields []CustomField, userData map[string]interface{}) error {
+	for _, field := range fields {
+		value, ok := userData[field.Key]
+		if !ok || value == nil {
+			if field.Required {
+				return &CustomFieldError{Key: field.Key, Reason: "is required"}
+			}
+			continue
+		}
+		switch field.Type {
+		case CustomFieldTypeString:
+			if _, ok := value.(string); !ok {
+				return &CustomFieldError{Key: field.Key, Reason: "must be a string"}
+			}
+		case CustomFieldTypeNumber:
+			if _, ok := customFieldNumber(value); !ok {
+				return &CustomFieldError{Key: field.Key, Reason: "must be a number"}
+			}
+		case CustomFieldTypeEnum:
+			if !field.allows(value) {
+				return &CustomFieldError{Key: field.Key, Reason: "must be one of " + strings.Join(field.Options, ", ")}
+			}
+		case CustomFieldTypeDate:
+			s, _ := value.(string)
+			if _, err := time.Parse(time.DateOnly, s); err != nil {
+				return &CustomFieldError{Key: field.Key, Reason: "must be a YYYY-MM-DD date"}
+			}
+		default:
+			return &CustomFieldError{Key: field.Key, Reason: fmt.Sprintf("has unknown type %q", field.Type)}
+		}
+	}
+	return nil
+}
+
+// allows returns true if the value is one of the options of the field
+func (field CustomField) allows(value interface{}) bool {
+	for _, option := range field.Options {
+		if value == option {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCustomFields checks the UserData of the event against the custom fields of its event type
+func (c *Calendar) validateCustomFields(e Event) error {
+	return ValidateCustomFields(c.customFields[e.EventType], e.UserData)
+}
+
+// Matches returns true if the user data has a value for the key that fits the search
+func (q CustomFieldQuery) Matches(userData map[string]interface{}) bool {
+	value, ok := userData[q.Key]
+	if !ok || value == nil {
+		return false
+	}
+	if len(q.Equals) > 0 {
+		found := false
+		for _, v := range q.Equals {
+			if cmp, ok := compareCustomValues(value, v); ok && cmp == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Min != nil {
+		if cmp, ok := compareCustomValues(value, q.Min); !ok || cmp < 0 {
+			return false
+		}
+	}
+	if q.Max != nil {
+		if cmp, ok := compareCustomValues(value, q.Max); !ok || cmp > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// compareCustomValues compares two numbers or two strings, and returns false for anything else
+func compareCustomValues(a, b interface{}) (int, bool) {
+	if x, ok := customFieldNumber(a); ok {
+		y, ok := customFieldNumber(b)
+		switch {
+		case !ok:
+			return 0, false
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+	x, ok := a.(string)
+	if !ok {
+		return 0, false
+	}
+	y, ok := b.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(x, y), true
+}
+
+// customFieldNumber converts any of the number types to a float64
+func customFieldNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// queryCustomFields queries the data store without the CustomFields of the query and filters
+// the results itself, so the search works with any data store (even an encrypted one)
+func (c *Calendar) queryCustomFields(q Query) ([]*Event, error) {
+	if len(q.CustomFields) == 0 {
+		return c.queryNear(q)
+	}
+	fields := q.CustomFields
+	q.CustomFields = nil
+	events, err := c.queryNear(q)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Event
+	for _, e := range events {
+		if (Query{CustomFields: fields}).Matches(e) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,97 @@
+package cali
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var roomFields = []CustomField{
+	{Key: "room", Label: "Room", Type: CustomFieldTypeString, Required: true},
+	{Key: "capacity", Label: "Capacity", Type: CustomFieldTypeNumber},
+	{Key: "format", Label: "Format", Type: CustomFieldTypeEnum, Options: []string{"in-person", "remote"}},
+	{Key: "deadline", Label: "Deadline", Type: CustomFieldTypeDate},
+}
+
+func TestValidateCustomFields(t *testing.T) {
+	assert.NoError(t, ValidateCustomFields(roomFields, map[string]interface{}{"room": "A", "capacity": 12, "format": "remote", "deadline": "2008-01-31", "other": true}))
+	assert.NoError(t, ValidateCustomFields(roomFields, map[string]interface{}{"room": "A", "capacity": 12.5}))
+	tests := []struct {
+		userData map[string]interface{}
+		key      string
+	}{
+		{userData: nil, key: "room"},
+		{userData: map[string]interface{}{"room": 1}, key: "room"},
+		{userData: map[string]interface{}{"room": "A", "capacity": "12"}, key: "capacity"},
+		{userData: map[string]interface{}{"room": "A", "format": "hybrid"}, key: "format"},
+		{userData: map[string]interface{}{"room": "A", "deadline": "01/31/2008"}, key: "deadline"},
+	}
+	for _, tc := range tests {
+		err := ValidateCustomFields(roomFields, tc.userData)
+		var fieldErr *CustomFieldError
+		require.True(t, errors.As(err, &fieldErr), tc.key)
+		assert.Equal(t, tc.key, fieldErr.Key)
+		assert.True(t, errors.Is(err, ErrorInvalidCustomField))
+	}
+}
+
+func TestCustomFields(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store, WithCustomFields(2, roomFields...))
+			assert.Equal(t, roomFields, c.CustomFields(2))
+			assert.Empty(t, c.CustomFields(1))
+
+			meeting := func(title string, userData map[string]interface{}) Event {
+				return Event{EventType: 2, Title: title, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", UserData: userData}
+			}
+			small, _, err := c.Create(meeting("Small", map[string]interface{}{"room": "A", "capacity": 4, "format": "in-person", "deadline": "2008-01-10"}))
+			require.NoError(t, err)
+			_, _, err = c.Create(meeting("Large", map[string]interface{}{"room": "B", "capacity": 40, "format": "remote", "deadline": "2008-02-10"}))
+			require.NoError(t, err)
+			_, _, err = c.Create(meeting("No Room", nil))
+			assert.True(t, errors.Is(err, ErrorInvalidCustomField))
+			_, _, err = c.Create(Event{EventType: 1, Title: "Other Type", Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true})
+			assert.NoError(t, err, "other event types have no custom fields")
+			_, err = c.CreateBatch([]Event{meeting("Bad Format", map[string]interface{}{"room": "C", "format": "hybrid"})})
+			assert.True(t, errors.Is(err.(*BatchError).Errors[0], ErrorInvalidCustomField))
+
+			titles := func(q Query) []string {
+				events, err := c.Query(q)
+				require.NoError(t, err)
+				var result []string
+				for _, e := range events {
+					result = append(result, e.Title)
+				}
+				return result
+			}
+			assert.Equal(t, []string{"Large"}, titles(Query{CustomFields: []CustomFieldQuery{{Key: "capacity", Min: 10}}}))
+			assert.Equal(t, []string{"Small"}, titles(Query{CustomFields: []CustomFieldQuery{{Key: "format", Equals: []interface{}{"in-person", "hybrid"}}}}))
+			assert.Equal(t, []string{"Small"}, titles(Query{CustomFields: []CustomFieldQuery{{Key: "deadline", Max: "2008-01-31"}}}))
+			assert.Empty(t, titles(Query{CustomFields: []CustomFieldQuery{{Key: "capacity", Min: 10}, {Key: "format", Equals: []interface{}{"in-person"}}}}))
+			assert.Empty(t, titles(Query{CustomFields: []CustomFieldQuery{{Key: "capacity", Min: "10"}}}), "a string doesn't compare with a number")
+
+			err = c.UpdateUserData(small.Id, map[string]interface{}{"capacity": 4}, RepeatEditTypeThis)
+			assert.True(t, errors.Is(err, ErrorInvalidCustomField))
+			require.NoError(t, c.UpdateUserData(small.Id, map[string]interface{}{"room": "A", "capacity": 20}, RepeatEditTypeThis))
+			assert.ElementsMatch(t, []string{"Large", "Small"}, titles(Query{CustomFields: []CustomFieldQuery{{Key: "capacity", Min: 10.0, Max: 40}}}))
+		})
+	}
+}
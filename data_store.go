@@ -6,10 +6,11 @@ import (
 
 type DataStore interface {
 	// Create should save an event in the data store and handle setting the Created and Updated and Id fields
+	// and setting the Version to 1. Every Set method should also update the Updated field and increment the Version.
 	Create(event Event) (*Event, error)
-	// SetTime updates the time values for a specific event
+	// SetTime updates the time values for a specific event, and the DurationMinutes if the event has one
 	SetTime(eventId int64, startTime, endTime string) error
-	// SetDayTime updates the day and time values for a specific event
+	// SetDayTime updates the day and time values for a specific event, and the DurationMinutes if the event has one
 	SetDayTime(eventId int64, startDay, startTime, endDay, endTime, zone string, isAllDay bool) error
 	// SetStatus applies the given status to the event. If the event already has the status it returns nil
 	SetStatus(eventId int64, status Status) error
@@ -38,11 +39,90 @@ type DataStore interface {
 	GetInvite(eventId, userId int64) (*Invite, error)
 }
 
+// LocationStore is an optional interface for a data store that can change the location of
+// events (see UpdateLocation)
+type LocationStore interface {
+	// SetLocation updates the event with the location value
+	SetLocation(eventId int64, location *string) error
+}
+
+// VisibilityStore is an optional interface for a data store that can change the visibility of
+// events (see UpdateVisibility)
+type VisibilityStore interface {
+	// SetVisibility updates the event with the visibility value
+	SetVisibility(eventId int64, visibility Visibility) error
+}
+
+// ForwardingStore is an optional interface for a data store that can change whether invitees
+// can forward their invitations (see UpdateDisallowForwarding)
+type ForwardingStore interface {
+	// SetDisallowForwarding updates whether invitees can forward their invitation to other users
+	SetDisallowForwarding(eventId int64, disallow bool) error
+}
+
+// PrivateInviteStore is an optional interface for a data store that can save the private notes
+// and user data that only the invitee can see (see UpdatePrivateNote and UpdateInvitationUserData)
+type PrivateInviteStore interface {
+	// SetInvitePrivateNote uses the EventId and UserId to update the private note of the invite and updates the Updated date too
+	SetInvitePrivateNote(eventId, userId int64, note *string) error
+	// SetInviteUserData uses the EventId and UserId to update the user data of the invite and updates the Updated date too
+	SetInviteUserData(eventId, userId int64, userData map[string]interface{}) error
+}
+
+// AutoResponseStore is an optional interface for a data store that can save
+// auto response policies for users
+type AutoResponseStore interface {
+	// SetAutoResponsePolicy creates or replaces the policy for the UserId on the policy
+	SetAutoResponsePolicy(policy AutoResponsePolicy) error
+	// GetAutoResponsePolicy retrieves the policy for the user. If none is found, it returns nil, nil
+	GetAutoResponsePolicy(userId int64) (*AutoResponsePolicy, error)
+}
+
+// SeriesInviteStore is an optional interface for a data store that can save a single
+// invite for all of the events of a repeating series. The series invites are kept apart
+// from the invites of single events, so GetInvite never returns a series invite.
+type SeriesInviteStore interface {
+	// AddSeriesInvite adds a new series invite where the EventId is the ParentId of the
+	// series and handles setting the IsSeries, Created, and Updated fields
+	AddSeriesInvite(invite Invite) (*Invite, error)
+	// SetSeriesInviteStatus updates the status of the series invite and updates the Updated date too
+	SetSeriesInviteStatus(parentId, userId int64, status InviteStatus) error
+	// SetSeriesInvitePermissions updates the permissions of the series invite and updates the Updated date too
+	SetSeriesInvitePermissions(parentId, userId int64, permissions Permission) error
+	// GetSeriesInvite retrieves the series invite for the user. If none is found, it returns nil, nil
+	GetSeriesInvite(parentId, userId int64) (*Invite, error)
+	// GetSeriesInvites retrieves all of the series invites for the series
+	GetSeriesInvites(parentId int64) ([]*Invite, error)
+}
+
+// GeoIndexStore is an optional interface for a data store that can filter events by
+// the Near field of a query. The calendar filters the results of other stores itself.
+type GeoIndexStore interface {
+	// HasGeoIndex returns true if the Query method handles the Near field
+	HasGeoIndex() bool
+}
+
 // InMemoryDataStore implements the DataStore interface and is useful for a mock data source
 type InMemoryDataStore struct {
-	events  []*Event
-	invites []*Invite
-	curId   int64
+	events        []*Event
+	invites       []*Invite
+	seriesInvites []*Invite
+	autoResponses map[int64]*AutoResponsePolicy
+	subscriptions []*Subscription
+	outbox        []*OutboxRecord
+	reminders     []*Reminder
+	audit         []*AuditEntry
+	locks         map[int64]*EventLock
+	followers     []*Follower
+	reactions     []*Reaction
+	attachments   []*Attachment
+	feedTokens    []*FeedToken
+	availability  []*Availability
+	bookings      []*Booking
+	series        []*Series
+	curId         int64
+	idx           *memoryIndex
+	check         *versionCheck
 }
 
 func (d *InMemoryDataStore) Create(event Event) (*Event, error) {
@@ -50,9 +130,13 @@ func (d *InMemoryDataStore) Create(event Event) (*Event, error) {
 	if err != nil {
 		return nil, err
 	}
+	if event.ExternalKey != "" && d.index().externalKeys[event.ExternalKey] != nil {
+		return nil, ErrorDuplicateExternalKey
+	}
 	event.Id = d.id()
 	event.Created = time.Now()
 	event.Updated = event.Created
+	event.Version = 1
 
 	// if the event is a repeating event, but doesn't have the ParentId
 	// field set, then this must be the first event of the repeat and
@@ -75,19 +159,63 @@ func (d *InMemoryDataStore) Create(event Event) (*Event, error) {
 	return &event, nil
 }
 
+func (d *InMemoryDataStore) CreateBatch(events []Event) ([]*Event, error) {
+	keys := map[string]bool{}
+	for _, event := range events {
+		if err := Validate(event); err != nil {
+			return nil, err
+		}
+		if event.ExternalKey == "" {
+			continue
+		}
+		if keys[event.ExternalKey] || d.index().externalKeys[event.ExternalKey] != nil {
+			return nil, ErrorDuplicateExternalKey
+		}
+		keys[event.ExternalKey] = true
+	}
+	result := make([]*Event, 0, len(events))
+	for _, event := range events {
+		newEvent, err := d.Create(event)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, newEvent)
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) CreateRepeating(e Event) ([]*Event, error) {
+	return generateRepeating(d, e)
+}
+
+func (d *InMemoryDataStore) SetStatusBatch(eventIds []int64, status Status) error {
+	for _, id := range eventIds {
+		if d.event(id) == nil {
+			return ErrorEventNotFound
+		}
+	}
+	for _, id := range eventIds {
+		if err := d.SetStatus(id, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (d *InMemoryDataStore) SetTime(eventId int64, startTime, endTime string) error {
 	if err := ValidateTimeValues(startTime, endTime); err != nil {
 		return err
 	}
 
-	for _, other := range d.events {
-		if other.Id == eventId {
-			other.StartTime = startTime
-			other.EndTime = endTime
-			return nil
-		}
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
 	}
-	return ErrorEventNotFound
+	other.StartTime = startTime
+	other.EndTime = endTime
+	syncDuration(other)
+	d.touch(other)
+	return nil
 }
 
 func (d *InMemoryDataStore) SetDayTime(eventId int64, startDay, startTime, endDay, endTime, zone string, isAllDay bool) error {
@@ -95,18 +223,20 @@ func (d *InMemoryDataStore) SetDayTime(eventId int64, startDay, startTime, endDa
 		return err
 	}
 
-	for _, other := range d.events {
-		if other.Id == eventId {
-			other.StartDay = startDay
-			other.StartTime = startTime
-			other.EndDay = endDay
-			other.EndTime = endTime
-			other.IsAllDay = isAllDay
-			other.Zone = zone
-			return nil
-		}
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
 	}
-	return ErrorEventNotFound
+	other.StartDay = startDay
+	other.StartTime = startTime
+	other.EndDay = endDay
+	other.EndTime = endTime
+	other.IsAllDay = isAllDay
+	other.Zone = zone
+	syncDuration(other)
+	d.touch(other)
+	d.index().daysDirty = true
+	return nil
 }
 
 func (d *InMemoryDataStore) SetStatus(eventId int64, status Status) error {
@@ -114,83 +244,213 @@ func (d *InMemoryDataStore) SetStatus(eventId int64, status Status) error {
 		return ErrorInvalidStatus
 	}
 
-	for _, other := range d.events {
-		if other.Id == eventId {
-			other.Status = status
-			return nil
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Status = status
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetStatusWhere(q Query, status Status) ([]int64, error) {
+	if !ValidStatus(status) {
+		return nil, ErrorInvalidStatus
+	}
+
+	events, err := d.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	var result []int64
+	for _, other := range events {
+		if other.Status == status {
+			continue
 		}
+		other.Status = status
+		d.touch(other)
+		result = append(result, other.Id)
 	}
-	return ErrorEventNotFound
+	return result, nil
 }
 
 func (d *InMemoryDataStore) SetTitle(eventId int64, title string) error {
-	for _, other := range d.events {
-		if other.Id == eventId {
-			other.Title = title
-			return nil
-		}
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
 	}
-	return ErrorEventNotFound
+	other.Title = title
+	d.touch(other)
+	return nil
 }
 
 func (d *InMemoryDataStore) SetDescription(eventId int64, description *string) error {
-	for _, other := range d.events {
-		if other.Id == eventId {
-			other.Description = description
-			return nil
-		}
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
 	}
-	return ErrorEventNotFound
+	other.Description = description
+	d.touch(other)
+	return nil
 }
 
 func (d *InMemoryDataStore) SetUrl(eventId int64, url *string) error {
-	for _, other := range d.events {
-		if other.Id == eventId {
-			other.Url = url
-			return nil
-		}
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Url = url
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetLinks(eventId int64, links []Link) error {
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Links = copyLinks(links)
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetLocation(eventId int64, location *string) error {
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
 	}
-	return ErrorEventNotFound
+	other.Location = location
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetGeo(eventId int64, geo *GeoPoint) error {
+	if geo != nil && !geo.Valid() {
+		return ErrorInvalidGeo
+	}
+
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Geo = geo
+	d.touch(other)
+	return nil
+}
+
+// HasGeoIndex is true since the Near field of queries is checked by Query.Matches
+func (d *InMemoryDataStore) HasGeoIndex() bool {
+	return true
+}
+
+func (d *InMemoryDataStore) SetVisibility(eventId int64, visibility Visibility) error {
+	if !ValidVisibility(visibility) {
+		return ErrorInvalidVisibility
+	}
+
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Visibility = visibility
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetDisallowForwarding(eventId int64, disallow bool) error {
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.DisallowForwarding = disallow
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetOverrides(eventId int64, overrides []string) error {
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Overrides = overrides
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetRecurrenceId(eventId int64, recurrenceId string) error {
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.RecurrenceId = recurrenceId
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetCancelReason(eventId int64, reason *string) error {
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.CancelReason = reason
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetPriority(eventId int64, priority Priority) error {
+	if !ValidPriority(priority) {
+		return ErrorInvalidPriority
+	}
+
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Priority = priority
+	d.touch(other)
+	return nil
+}
+
+func (d *InMemoryDataStore) SetConference(eventId int64, conference *Conference) error {
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	other.Conference = conference
+	d.touch(other)
+	return nil
 }
 
 func (d *InMemoryDataStore) SetUserData(eventId int64, userData map[string]interface{}) error {
-	for _, other := range d.events {
-		if other.Id == eventId {
-			other.UserData = userData
-			return nil
-		}
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
 	}
-	return ErrorEventNotFound
+	other.UserData = userData
+	d.touch(other)
+	return nil
 }
 
 func (d *InMemoryDataStore) Get(eventId int64) (*Event, error) {
-	for _, event := range d.events {
-		if event.Id == eventId {
-			return event, nil
-		}
+	return d.event(eventId), nil
+}
+
+func (d *InMemoryDataStore) GetMany(eventIds []int64) ([]*Event, error) {
+	result := make([]*Event, len(eventIds))
+	for i, id := range eventIds {
+		result[i] = d.event(id)
 	}
-	return nil, nil
+	return result, nil
 }
 
 func (d *InMemoryDataStore) Query(q Query) ([]*Event, error) {
+	events, ok := d.candidates(q)
+	if !ok {
+		events = d.events
+	}
 	var result []*Event
-	for _, event := range d.events {
-		if !q.Matches(event) {
-			continue
-		}
-		found := false
-		for _, userId := range q.UserIds {
-			for _, inv := range d.invites {
-				if event.Id == inv.EventId && inv.UserId == userId && inv.Status >= 0 {
-					found = true
-					break
-				}
-			}
-			if found {
-				break
-			}
-		}
-		if found || len(q.UserIds) == 0 {
+	for _, event := range events {
+		if q.MatchesInvites(event, d.eventAndSeriesInvites(event)) {
 			result = append(result, event)
 		}
 	}
@@ -210,36 +470,707 @@ func (d *InMemoryDataStore) AddInvite(a Invite) (*Invite, error) {
 }
 
 func (d *InMemoryDataStore) SetInviteStatus(eventId, userId int64, status InviteStatus) error {
-	for _, invite := range d.invites {
-		if invite.EventId == eventId && invite.UserId == userId {
-			invite.Status = status
-			invite.Updated = time.Now()
+	invite := d.index().invites[inviteKey{eventId, userId}]
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.Status = status
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) SetInvitePermissions(eventId, userId int64, permissions Permission) error {
+	invite := d.index().invites[inviteKey{eventId, userId}]
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.Permission = permissions
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) SetInvitePrivateNote(eventId, userId int64, note *string) error {
+	invite := d.index().invites[inviteKey{eventId, userId}]
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.PrivateNote = note
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) SetInviteProposal(eventId, userId int64, proposal *TimeProposal) error {
+	invite := d.index().invites[inviteKey{eventId, userId}]
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.Proposal = proposal
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) SetInviteMuted(eventId, userId int64, muted bool) error {
+	invite := d.index().invites[inviteKey{eventId, userId}]
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.Muted = muted
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) SetInviteUserData(eventId, userId int64, userData map[string]interface{}) error {
+	invite := d.index().invites[inviteKey{eventId, userId}]
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.UserData = userData
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) GetInvite(eventId int64, userId int64) (*Invite, error) {
+	return d.index().invites[inviteKey{eventId, userId}], nil
+}
+
+func (d *InMemoryDataStore) GetInvites(eventId int64) ([]*Invite, error) {
+	return append([]*Invite(nil), d.index().eventInvites[eventId]...), nil
+}
+
+func (d *InMemoryDataStore) SetAutoResponsePolicy(policy AutoResponsePolicy) error {
+	if d.autoResponses == nil {
+		d.autoResponses = map[int64]*AutoResponsePolicy{}
+	}
+	d.autoResponses[policy.UserId] = &policy
+	return nil
+}
+
+func (d *InMemoryDataStore) GetAutoResponsePolicy(userId int64) (*AutoResponsePolicy, error) {
+	return d.autoResponses[userId], nil
+}
+
+func (d *InMemoryDataStore) ChangedEvents(since time.Time) ([]*Event, error) {
+	var result []*Event
+	for _, event := range d.events {
+		if !event.Updated.Before(since) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) ChangedInvites(since time.Time) ([]*Invite, error) {
+	var result []*Invite
+	for _, invites := range [][]*Invite{d.invites, d.seriesInvites} {
+		for _, invite := range invites {
+			if !invite.Updated.Before(since) {
+				result = append(result, invite)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) AddSeriesInvite(a Invite) (*Invite, error) {
+	a.IsSeries = true
+	a.Created = time.Now()
+	a.Updated = a.Created
+	err := ValidateInvite(a)
+	if err != nil {
+		return nil, err
+	}
+	d.seriesInvites = append(d.seriesInvites, &a)
+	return &a, nil
+}
+
+func (d *InMemoryDataStore) SetSeriesInviteStatus(parentId, userId int64, status InviteStatus) error {
+	invite, _ := d.GetSeriesInvite(parentId, userId)
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.Status = status
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) SetSeriesInvitePermissions(parentId, userId int64, permissions Permission) error {
+	invite, _ := d.GetSeriesInvite(parentId, userId)
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	invite.Permission = permissions
+	invite.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) GetSeriesInvite(parentId, userId int64) (*Invite, error) {
+	return d.index().seriesInvites[inviteKey{parentId, userId}], nil
+}
+
+func (d *InMemoryDataStore) GetSeriesInvites(parentId int64) ([]*Invite, error) {
+	return append([]*Invite(nil), d.index().parentSeries[parentId]...), nil
+}
+
+func (d *InMemoryDataStore) SetSubscription(s Subscription) (*Subscription, error) {
+	s.Updated = time.Now()
+	for i, other := range d.subscriptions {
+		if other.UserId == s.UserId && other.CalendarId == s.CalendarId {
+			s.Created = other.Created
+			d.subscriptions[i] = &s
+			return &s, nil
+		}
+	}
+	s.Created = s.Updated
+	d.subscriptions = append(d.subscriptions, &s)
+	return &s, nil
+}
+
+func (d *InMemoryDataStore) RemoveSubscription(userId, calendarId int64) error {
+	for i, other := range d.subscriptions {
+		if other.UserId == userId && other.CalendarId == calendarId {
+			d.subscriptions = append(d.subscriptions[:i], d.subscriptions[i+1:]...)
 			return nil
 		}
 	}
-	return ErrorInviteNotFound
+	return nil
 }
 
-func (d *InMemoryDataStore) SetInvitePermissions(eventId, userId int64, permissions Permission) error {
-	for _, invite := range d.invites {
-		if invite.EventId == eventId && invite.UserId == userId {
-			invite.Permission = permissions
-			invite.Updated = time.Now()
+func (d *InMemoryDataStore) GetSubscriptions(userId int64) ([]*Subscription, error) {
+	var result []*Subscription
+	for _, s := range d.subscriptions {
+		if s.UserId == userId {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) AddFollower(f Follower) (*Follower, error) {
+	if d.event(f.EventId) == nil {
+		return nil, ErrorEventNotFound
+	}
+	for _, other := range d.followers {
+		if other.EventId == f.EventId && other.UserId == f.UserId {
+			return other, nil
+		}
+	}
+	f.Created = time.Now()
+	d.followers = append(d.followers, &f)
+	return &f, nil
+}
+
+func (d *InMemoryDataStore) RemoveFollower(eventId, userId int64) error {
+	for i, other := range d.followers {
+		if other.EventId == eventId && other.UserId == userId {
+			d.followers = append(d.followers[:i], d.followers[i+1:]...)
 			return nil
 		}
 	}
-	return ErrorInviteNotFound
+	return nil
 }
 
-func (d *InMemoryDataStore) GetInvite(eventId int64, userId int64) (*Invite, error) {
-	for _, invite := range d.invites {
-		if invite.EventId == eventId && invite.UserId == userId {
-			return invite, nil
+func (d *InMemoryDataStore) GetFollowers(eventId int64) ([]*Follower, error) {
+	var result []*Follower
+	for _, f := range d.followers {
+		if f.EventId == eventId {
+			result = append(result, f)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) GetFollowedEventIds(userId int64) ([]int64, error) {
+	var result []int64
+	for _, f := range d.followers {
+		if f.UserId == userId {
+			result = append(result, f.EventId)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) AddReaction(r Reaction) (*Reaction, error) {
+	if d.event(r.EventId) == nil {
+		return nil, ErrorEventNotFound
+	}
+	for _, other := range d.reactions {
+		if other.EventId == r.EventId && other.UserId == r.UserId && other.Emoji == r.Emoji {
+			return other, nil
+		}
+	}
+	r.Created = time.Now()
+	d.reactions = append(d.reactions, &r)
+	return &r, nil
+}
+
+func (d *InMemoryDataStore) RemoveReaction(eventId, userId int64, emoji string) error {
+	for i, other := range d.reactions {
+		if other.EventId == eventId && other.UserId == userId && other.Emoji == emoji {
+			d.reactions = append(d.reactions[:i], d.reactions[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (d *InMemoryDataStore) GetReactions(eventId int64) ([]*Reaction, error) {
+	var result []*Reaction
+	for _, r := range d.reactions {
+		if r.EventId == eventId {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) CountReactions(eventIds []int64) (map[int64]map[string]int64, error) {
+	ids := map[int64]bool{}
+	for _, id := range eventIds {
+		ids[id] = true
+	}
+	var reactions []*Reaction
+	for _, r := range d.reactions {
+		if ids[r.EventId] {
+			reactions = append(reactions, r)
+		}
+	}
+	return countReactions(reactions), nil
+}
+
+func (d *InMemoryDataStore) AddAttachment(a Attachment) (*Attachment, error) {
+	if d.event(a.EventId) == nil {
+		return nil, ErrorEventNotFound
+	}
+	a.Id = int64(len(d.attachments) + 1)
+	a.Created = time.Now().UTC()
+	a.Updated = a.Created
+	d.attachments = append(d.attachments, &a)
+	return &a, nil
+}
+
+func (d *InMemoryDataStore) GetAttachment(id int64) (*Attachment, error) {
+	if id < 1 || id > int64(len(d.attachments)) {
+		return nil, nil
+	}
+	return d.attachments[id-1], nil
+}
+
+func (d *InMemoryDataStore) GetAttachments(eventId int64) ([]*Attachment, error) {
+	var result []*Attachment
+	for _, a := range d.attachments {
+		if a.EventId == eventId {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) SetAttachmentStatus(id int64, status AttachmentStatus, reason *string) error {
+	a, _ := d.GetAttachment(id)
+	if a == nil {
+		return ErrorAttachmentNotFound
+	}
+	a.Status = status
+	a.RejectReason = reason
+	a.Updated = time.Now().UTC()
+	return nil
+}
+
+func (d *InMemoryDataStore) AddFeedToken(t FeedToken) (*FeedToken, error) {
+	t.Id = int64(len(d.feedTokens) + 1)
+	t.Created = time.Now().UTC()
+	d.feedTokens = append(d.feedTokens, &t)
+	return &t, nil
+}
+
+func (d *InMemoryDataStore) GetFeedToken(id int64) (*FeedToken, error) {
+	if id < 1 || id > int64(len(d.feedTokens)) {
+		return nil, nil
+	}
+	return d.feedTokens[id-1], nil
+}
+
+func (d *InMemoryDataStore) GetFeedTokenByHash(hash string) (*FeedToken, error) {
+	for _, t := range d.feedTokens {
+		if t.Hash == hash {
+			return t, nil
 		}
 	}
 	return nil, nil
 }
 
+func (d *InMemoryDataStore) GetFeedTokens(userId int64) ([]*FeedToken, error) {
+	var result []*FeedToken
+	for _, t := range d.feedTokens {
+		if t.UserId == userId {
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) RevokeFeedToken(id int64) error {
+	t, _ := d.GetFeedToken(id)
+	if t == nil {
+		return ErrorInvalidFeedToken
+	}
+	t.Revoked = true
+	return nil
+}
+
+func (d *InMemoryDataStore) AddOutboxRecord(record OutboxRecord) (*OutboxRecord, error) {
+	record.Id = int64(len(d.outbox) + 1)
+	record.Created = time.Now()
+	d.outbox = append(d.outbox, &record)
+	return &record, nil
+}
+
+func (d *InMemoryDataStore) PendingOutboxRecords(limit int) ([]*OutboxRecord, error) {
+	var result []*OutboxRecord
+	for _, record := range d.outbox {
+		if len(result) >= limit {
+			break
+		}
+		if record.Delivered == nil {
+			copied := *record
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) MarkOutboxDelivered(id int64) error {
+	if id < 1 || id > int64(len(d.outbox)) {
+		return ErrorOutboxRecordNotFound
+	}
+	now := time.Now()
+	d.outbox[id-1].Delivered = &now
+	return nil
+}
+
+func (d *InMemoryDataStore) MarkOutboxFailed(id int64, reason string) error {
+	if id < 1 || id > int64(len(d.outbox)) {
+		return ErrorOutboxRecordNotFound
+	}
+	d.outbox[id-1].Attempts++
+	d.outbox[id-1].LastError = reason
+	return nil
+}
+
+func (d *InMemoryDataStore) AddAuditEntry(entry AuditEntry) (*AuditEntry, error) {
+	entry.Id = int64(len(d.audit) + 1)
+	d.audit = append(d.audit, &entry)
+	copied := entry
+	return &copied, nil
+}
+
+func (d *InMemoryDataStore) GetAuditEntries(eventId int64) ([]*AuditEntry, error) {
+	var result []*AuditEntry
+	for _, entry := range d.audit {
+		if entry.EventId == eventId {
+			copied := *entry
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) AddReminder(r Reminder) (*Reminder, error) {
+	r.Id = int64(len(d.reminders) + 1)
+	r.Created = time.Now()
+	r.Updated = r.Created
+	d.reminders = append(d.reminders, &r)
+	copied := r
+	return &copied, nil
+}
+
+func (d *InMemoryDataStore) GetReminder(reminderId int64) (*Reminder, error) {
+	if reminderId < 1 || reminderId > int64(len(d.reminders)) {
+		return nil, nil
+	}
+	copied := *d.reminders[reminderId-1]
+	return &copied, nil
+}
+
+func (d *InMemoryDataStore) UpdateReminder(r Reminder) error {
+	if r.Id < 1 || r.Id > int64(len(d.reminders)) {
+		return ErrorReminderNotFound
+	}
+	existing := d.reminders[r.Id-1]
+	existing.SnoozedUntil = r.SnoozedUntil
+	existing.Sent = r.Sent
+	existing.Dismissed = r.Dismissed
+	existing.Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) DueReminders(before time.Time) ([]*Reminder, error) {
+	var result []*Reminder
+	for _, r := range d.reminders {
+		if r.IsDue(before) {
+			copied := *r
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) LockEvent(lock EventLock, now time.Time) error {
+	if d.locks == nil {
+		d.locks = map[int64]*EventLock{}
+	}
+	existing := d.locks[lock.EventId]
+	if existing != nil && existing.Held(now) {
+		if existing.UserId != lock.UserId {
+			return ErrorEventLocked
+		}
+		existing.Expires = lock.Expires
+		return nil
+	}
+	d.locks[lock.EventId] = &lock
+	return nil
+}
+
+func (d *InMemoryDataStore) GetEventLock(eventId int64) (*EventLock, error) {
+	lock := d.locks[eventId]
+	if lock == nil {
+		return nil, nil
+	}
+	copied := *lock
+	return &copied, nil
+}
+
+func (d *InMemoryDataStore) UnlockEvent(eventId int64, userId int64) error {
+	if lock := d.locks[eventId]; lock != nil && lock.UserId == userId {
+		delete(d.locks, eventId)
+	}
+	return nil
+}
+
+func (d *InMemoryDataStore) AddAvailability(a Availability) (*Availability, error) {
+	a.Id = int64(len(d.availability) + 1)
+	a.Created = time.Now()
+	a.Updated = a.Created
+	d.availability = append(d.availability, &a)
+	return &a, nil
+}
+
+func (d *InMemoryDataStore) GetAvailability(id int64) (*Availability, error) {
+	if id < 1 || id > int64(len(d.availability)) {
+		return nil, nil
+	}
+	return d.availability[id-1], nil
+}
+
+func (d *InMemoryDataStore) BookSlot(b Booking, e Event) (*Booking, *Event, error) {
+	now := time.Now()
+	for _, other := range d.bookings {
+		if other.AvailabilityId == b.AvailabilityId && other.Day == b.Day && other.StartTime == b.StartTime && other.Holds(now) {
+			return nil, nil, ErrorSlotAlreadyBooked
+		}
+	}
+	event, err := d.Create(e)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.Id = int64(len(d.bookings) + 1)
+	b.EventId = event.Id
+	b.Created = time.Now()
+	b.Updated = b.Created
+	d.bookings = append(d.bookings, &b)
+	return &b, event, nil
+}
+
+func (d *InMemoryDataStore) GetBooking(id int64) (*Booking, error) {
+	if id < 1 || id > int64(len(d.bookings)) {
+		return nil, nil
+	}
+	return d.bookings[id-1], nil
+}
+
+func (d *InMemoryDataStore) GetBookings(availabilityId int64, startDay, endDay string) ([]*Booking, error) {
+	var result []*Booking
+	for _, b := range d.bookings {
+		if b.AvailabilityId == availabilityId && b.Day >= startDay && b.Day <= endDay {
+			result = append(result, b)
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) SetBookingStatus(id int64, status BookingStatus) error {
+	if id < 1 || id > int64(len(d.bookings)) {
+		return ErrorBookingNotFound
+	}
+	d.bookings[id-1].Status = status
+	d.bookings[id-1].Updated = time.Now()
+	return nil
+}
+
+func (d *InMemoryDataStore) Stats(userId int64, window TimeWindow) (*Stats, error) {
+	// the events of the user include the events with declined invites
+	events, _ := d.candidates(Query{UserIds: []int64{userId}})
+	q := Query{Start: &window.Start, End: &window.End, Statuses: []Status{StatusActive}}
+	s := newStats(userId, window)
+	for _, event := range events {
+		if q.Matches(event) {
+			s.add(event, d.eventAndSeriesInvites(event))
+		}
+	}
+	s.finish()
+	return s, nil
+}
+
+func (d *InMemoryDataStore) RSVPSummary(eventId int64) (*RSVPSummary, error) {
+	event := d.event(eventId)
+	if event == nil {
+		return nil, ErrorEventNotFound
+	}
+	// the invites of the event come before the series invites so that they override them
+	return newRSVPSummary(*event, d.eventAndSeriesInvites(event)), nil
+}
+
+func (d *InMemoryDataStore) PendingInvitesBefore(before time.Time) ([]*Invite, error) {
+	idx := d.index()
+	var result []*Invite
+	add := func(invites []*Invite, lookup map[inviteKey]*Invite) {
+		for _, invite := range invites {
+			// only the first invite of a user is used, like GetInvite
+			if lookup[inviteKey{invite.EventId, invite.UserId}] != invite {
+				continue
+			}
+			if invite.Status != InviteStatusPending || !invite.Created.Before(before) {
+				continue
+			}
+			event := idx.events[invite.EventId]
+			if event == nil || event.Status != StatusActive || event.OwnerId == invite.UserId {
+				continue
+			}
+			i := *invite
+			result = append(result, &i)
+		}
+	}
+	add(d.invites, idx.invites)
+	add(d.seriesInvites, idx.seriesInvites)
+	return result, nil
+}
+
+func (d *InMemoryDataStore) CreateSeries(s Series) (*Series, error) {
+	s.Created = time.Now()
+	s.Updated = s.Created
+	d.series = append(d.series, &s)
+	result := s
+	return &result, nil
+}
+
+func (d *InMemoryDataStore) SetSeries(s Series) error {
+	for _, other := range d.series {
+		if other.Id == s.Id {
+			s.Created = other.Created
+			s.Updated = time.Now()
+			*other = s
+			return nil
+		}
+	}
+	return ErrorSeriesNotFound
+}
+
+func (d *InMemoryDataStore) GetSeries(seriesIds []int64) ([]*Series, error) {
+	var result []*Series
+	for _, id := range seriesIds {
+		for _, s := range d.series {
+			if s.Id == id {
+				found := *s
+				result = append(result, &found)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func (d *InMemoryDataStore) GetByExternalKey(key string) (*Event, error) {
+	if key == "" {
+		return nil, nil
+	}
+	return d.index().externalKeys[key], nil
+}
+
+func (d *InMemoryDataStore) SetExternalKey(eventId int64, key string) error {
+	idx := d.index()
+	other, err := d.edit(eventId)
+	if err != nil {
+		return err
+	}
+	if key == other.ExternalKey {
+		return nil
+	}
+	if key != "" && idx.externalKeys[key] != nil {
+		return ErrorDuplicateExternalKey
+	}
+	delete(idx.externalKeys, other.ExternalKey)
+	if key != "" {
+		idx.externalKeys[key] = other
+	}
+	other.ExternalKey = key
+	d.touch(other)
+	return nil
+}
+
+// eventAndSeriesInvites gets the invites of the event and the series invites of its series
+func (d *InMemoryDataStore) eventAndSeriesInvites(event *Event) []*Invite {
+	idx := d.index()
+	invites := idx.eventInvites[event.Id]
+	if event.ParentId == nil || len(idx.parentSeries[*event.ParentId]) == 0 {
+		return invites
+	}
+	return append(append([]*Invite(nil), invites...), idx.parentSeries[*event.ParentId]...)
+}
+
+// versionCheck is the version that the event must have to be changed (see VersionStore)
+type versionCheck struct {
+	eventId int64
+	version int64
+}
+
+// IfVersion calls f with the data store, where the changes to the event fail with
+// ErrorPreconditionFailed unless the event has the version
+func (d *InMemoryDataStore) IfVersion(eventId int64, version int64, f func(store DataStore) error) error {
+	previous := d.check
+	d.check = &versionCheck{eventId: eventId, version: version}
+	defer func() {
+		d.check = previous
+	}()
+	return f(d)
+}
+
+// edit gets the event to change, comparing its version if it is being changed in IfVersion
+func (d *InMemoryDataStore) edit(eventId int64) (*Event, error) {
+	other := d.event(eventId)
+	if other == nil {
+		return nil, ErrorEventNotFound
+	}
+	if d.check != nil && d.check.eventId == eventId && d.check.version != other.Version {
+		return nil, ErrorPreconditionFailed
+	}
+	return other, nil
+}
+
+// touch marks the event as modified, and sets the version expected by IfVersion to the new
+// version so that the rest of the changes in IfVersion can be made
+func (d *InMemoryDataStore) touch(other *Event) {
+	other.touch()
+	if d.check != nil && d.check.eventId == other.Id {
+		d.check.version = other.Version
+	}
+}
+
+// touch marks the event as modified by updating the Updated and Version fields
+func (e *Event) touch() {
+	e.Updated = time.Now()
+	e.Version++
+}
+
 // id generates the next id value
 func (d *InMemoryDataStore) id() int64 {
 	d.curId++
@@ -0,0 +1,200 @@
+package cali
+
+import (
+	"sort"
+	"time"
+)
+
+// inviteKey is the EventId and UserId of an invite
+type inviteKey struct {
+	eventId int64
+	userId  int64
+}
+
+// memoryIndex has lookups of the events and invites of an InMemoryDataStore so that
+// queries and edits don't have to scan every event and invite
+type memoryIndex struct {
+	events  map[int64]*Event
+	parents map[int64][]*Event
+	owners  map[int64][]*Event
+	// externalKeys has the events with an ExternalKey by the key
+	externalKeys map[string]*Event
+
+	invites       map[inviteKey]*Invite
+	eventInvites  map[int64][]*Invite
+	userInvites   map[int64][]*Invite
+	seriesInvites map[inviteKey]*Invite
+	parentSeries  map[int64][]*Invite
+	userSeries    map[int64][]*Invite
+
+	// days has every event sorted by StartDay and is sorted again before a query if an
+	// event's days changed. longest is the most days that any event spans.
+	days      []*Event
+	daysDirty bool
+	longest   int
+
+	// the number of events, invites, and series invites that have been indexed
+	eventCount, inviteCount, seriesCount int
+}
+
+// index gets the indexes of the data store after adding any events and invites that haven't been indexed
+func (d *InMemoryDataStore) index() *memoryIndex {
+	if d.idx == nil {
+		d.idx = &memoryIndex{
+			events:        map[int64]*Event{},
+			parents:       map[int64][]*Event{},
+			owners:        map[int64][]*Event{},
+			externalKeys:  map[string]*Event{},
+			invites:       map[inviteKey]*Invite{},
+			eventInvites:  map[int64][]*Invite{},
+			userInvites:   map[int64][]*Invite{},
+			seriesInvites: map[inviteKey]*Invite{},
+			parentSeries:  map[int64][]*Invite{},
+			userSeries:    map[int64][]*Invite{},
+		}
+	}
+	idx := d.idx
+	for ; idx.eventCount < len(d.events); idx.eventCount++ {
+		e := d.events[idx.eventCount]
+		idx.events[e.Id] = e
+		if e.ParentId != nil {
+			idx.parents[*e.ParentId] = append(idx.parents[*e.ParentId], e)
+		}
+		idx.owners[e.OwnerId] = append(idx.owners[e.OwnerId], e)
+		if _, ok := idx.externalKeys[e.ExternalKey]; e.ExternalKey != "" && !ok {
+			idx.externalKeys[e.ExternalKey] = e
+		}
+		idx.days = append(idx.days, e)
+		idx.daysDirty = true
+	}
+	for ; idx.inviteCount < len(d.invites); idx.inviteCount++ {
+		i := d.invites[idx.inviteCount]
+		key := inviteKey{i.EventId, i.UserId}
+		if _, ok := idx.invites[key]; !ok {
+			idx.invites[key] = i
+		}
+		idx.eventInvites[i.EventId] = append(idx.eventInvites[i.EventId], i)
+		idx.userInvites[i.UserId] = append(idx.userInvites[i.UserId], i)
+	}
+	for ; idx.seriesCount < len(d.seriesInvites); idx.seriesCount++ {
+		i := d.seriesInvites[idx.seriesCount]
+		key := inviteKey{i.EventId, i.UserId}
+		if _, ok := idx.seriesInvites[key]; !ok {
+			idx.seriesInvites[key] = i
+		}
+		idx.parentSeries[i.EventId] = append(idx.parentSeries[i.EventId], i)
+		idx.userSeries[i.UserId] = append(idx.userSeries[i.UserId], i)
+	}
+	if idx.daysDirty {
+		sort.SliceStable(idx.days, func(i, j int) bool {
+			return idx.days[i].StartDay < idx.days[j].StartDay
+		})
+		idx.longest = 0
+		for _, e := range idx.days {
+			if span := daySpan(e); span > idx.longest {
+				idx.longest = span
+			}
+		}
+		idx.daysDirty = false
+	}
+	return idx
+}
+
+// event finds the event by its id, or nil if there isn't one
+func (d *InMemoryDataStore) event(eventId int64) *Event {
+	return d.index().events[eventId]
+}
+
+// candidates picks the smallest list of events that could match the query using the indexes,
+// or returns false if the query can't use an index and every event has to be checked
+func (d *InMemoryDataStore) candidates(q Query) ([]*Event, bool) {
+	idx := d.index()
+	var best []*Event
+	found := false
+	use := func(events []*Event) {
+		if !found || len(events) < len(best) {
+			best = events
+			found = true
+		}
+	}
+
+	if len(q.EventIds) > 0 {
+		var events []*Event
+		for _, id := range q.EventIds {
+			if e := idx.events[id]; e != nil {
+				events = append(events, e)
+			}
+		}
+		use(events)
+	}
+	if len(q.ParentIds) > 0 {
+		var events []*Event
+		for _, id := range q.ParentIds {
+			events = append(events, idx.parents[id]...)
+		}
+		use(events)
+	}
+	if len(q.UserIds) > 0 {
+		var events []*Event
+		for _, userId := range q.UserIds {
+			for _, i := range idx.userInvites[userId] {
+				if e := idx.events[i.EventId]; e != nil {
+					events = append(events, e)
+				}
+			}
+			for _, i := range idx.userSeries[userId] {
+				events = append(events, idx.parents[i.EventId]...)
+			}
+			events = append(events, idx.owners[userId]...)
+		}
+		use(events)
+	}
+	if q.Start != nil || q.End != nil {
+		// the events that start on or before the end of the query, and that start no
+		// earlier than the longest event before the start of the query
+		low, high := 0, len(idx.days)
+		if q.End != nil {
+			endDay := q.End.Format(time.DateOnly)
+			high = sort.Search(len(idx.days), func(i int) bool {
+				return idx.days[i].StartDay > endDay
+			})
+		}
+		if q.Start != nil {
+			startDay := q.Start.AddDate(0, 0, -idx.longest).Format(time.DateOnly)
+			low = sort.Search(high, func(i int) bool {
+				return idx.days[i].StartDay >= startDay
+			})
+		}
+		use(idx.days[low:high])
+	}
+	if !found {
+		return nil, false
+	}
+
+	// remove duplicates and keep the order that the events were created in
+	seen := map[int64]bool{}
+	result := make([]*Event, 0, len(best))
+	for _, e := range best {
+		if !seen[e.Id] {
+			seen[e.Id] = true
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result, true
+}
+
+// daySpan is the number of days between the start and end day of the event
+func daySpan(e *Event) int {
+	start, err := time.Parse(time.DateOnly, e.StartDay)
+	if err != nil {
+		return 0
+	}
+	end, err := time.Parse(time.DateOnly, e.EndDay)
+	if err != nil {
+		return 0
+	}
+	return int(end.Sub(start).Hours() / 24)
+}
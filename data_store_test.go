@@ -2,6 +2,7 @@ package cali
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,3 +45,71 @@ func TestInMemoryDataStore(t *testing.T) {
 	res, err := d.Query(Query{Statuses: []Status{StatusActive}})
 	assert.Len(t, res, 2)
 }
+
+func TestInMemoryDataStoreIndexes(t *testing.T) {
+	d := &InMemoryDataStore{}
+	long, err := d.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-20", IsAllDay: true})
+	require.NoError(t, err)
+	short, err := d.Create(Event{OwnerId: 2, StartDay: "2008-01-10", EndDay: "2008-01-10", IsAllDay: true})
+	require.NoError(t, err)
+	_, err = d.AddInvite(Invite{EventId: short.Id, UserId: 1, Permission: PermissionViewer})
+	require.NoError(t, err)
+
+	ids := func(q Query) []int64 {
+		events, err := d.Query(q)
+		require.NoError(t, err)
+		var result []int64
+		for _, e := range events {
+			result = append(result, e.Id)
+		}
+		return result
+	}
+	start := time.Date(2008, time.January, 15, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2008, time.January, 16, 0, 0, 0, 0, time.UTC)
+	// the long event starts before the query but still overlaps it
+	assert.Equal(t, []int64{long.Id}, ids(Query{Start: &start, End: &end}))
+	assert.Equal(t, []int64{long.Id, short.Id}, ids(Query{UserIds: []int64{1}}))
+	assert.Equal(t, []int64{short.Id}, ids(Query{UserIds: []int64{2}}))
+
+	// the day index is updated when the days of an event change
+	require.NoError(t, d.SetDayTime(short.Id, "2008-01-16", "", "2008-01-16", "", "", true))
+	assert.Equal(t, []int64{long.Id, short.Id}, ids(Query{Start: &start, End: &end}))
+
+	// declined invites are kept out of the results
+	require.NoError(t, d.SetInviteStatus(short.Id, 1, InviteStatusDeclined))
+	assert.Equal(t, []int64{long.Id}, ids(Query{UserIds: []int64{1}}))
+}
+
+// BenchmarkInMemoryDataStoreQuery compares a query that has to check every event
+// with queries that use the indexes of the data store
+func BenchmarkInMemoryDataStoreQuery(b *testing.B) {
+	d := &InMemoryDataStore{}
+	day := time.Date(2008, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 20000; i++ {
+		startDay := day.AddDate(0, 0, i%365).Format(time.DateOnly)
+		e, err := d.Create(Event{OwnerId: int64(i % 100), StartDay: startDay, EndDay: startDay, IsAllDay: true})
+		require.NoError(b, err)
+		_, err = d.AddInvite(Invite{EventId: e.Id, UserId: int64(100 + i%500), Permission: PermissionViewer})
+		require.NoError(b, err)
+	}
+	start := day.AddDate(0, 0, 100)
+	end := start.AddDate(0, 0, 7)
+	queries := []struct {
+		name  string
+		query Query
+	}{
+		{"scan", Query{Statuses: []Status{StatusActive}}},
+		{"user", Query{UserIds: []int64{250}}},
+		{"days", Query{Start: &start, End: &end}},
+		{"event", Query{EventIds: []int64{1234}}},
+	}
+	for _, bc := range queries {
+		b.Run(bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := d.Query(bc.query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package cali
+
+// Display is how an event should be shown, which is derived by the display rules of the
+// calendar every time the event is read instead of being saved with the event
+type Display struct {
+	// Color is a color for the event, like "#3366ff"
+	Color string `json:"color"`
+	// Category is a label for the event, like "Focus time" or "External"
+	Category string `json:"category"`
+}
+
+// DisplayRule sets the color and category of the events that match it
+type DisplayRule struct {
+	// Match returns true if the rule applies to the event
+	Match func(e Event) bool
+	// Color is used for the events that match, unless it is "" or an earlier rule set the color
+	Color string
+	// Category is used for the events that match, unless it is "" or an earlier rule set the category
+	Category string
+}
+
+// WithDisplayRules sets the Display of the events returned by Get, Query, and PublicEvents
+// using the rules in order, where the first matching rule with a color sets the color and
+// the first matching rule with a category sets the category. Events that don't match any
+// rule don't have a Display.
+func WithDisplayRules(rules ...DisplayRule) CalendarOption {
+	return func(c *Calendar) {
+		c.displayRules = append(c.displayRules, rules...)
+	}
+}
+
+// display works out the Display of the event from the rules, or nil if no rules match
+func (c *Calendar) display(e Event) *Display {
+	var d Display
+	for _, rule := range c.displayRules {
+		if (d.Color != "" || rule.Color == "") && (d.Category != "" || rule.Category == "") {
+			continue
+		}
+		if !rule.Match(e) {
+			continue
+		}
+		if d.Color == "" {
+			d.Color = rule.Color
+		}
+		if d.Category == "" {
+			d.Category = rule.Category
+		}
+	}
+	if d == (Display{}) {
+		return nil
+	}
+	return &d
+}
+
+// withDisplay makes copies of the events with their Display set by the display rules, so the
+// events in the data store are not changed
+func (c *Calendar) withDisplay(events []*Event) []*Event {
+	if len(c.displayRules) == 0 {
+		return events
+	}
+	result := make([]*Event, 0, len(events))
+	for _, e := range events {
+		if e == nil {
+			result = append(result, e)
+			continue
+		}
+		copied := *e
+		copied.Display = c.display(copied)
+		result = append(result, &copied)
+	}
+	return result
+}
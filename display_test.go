@@ -0,0 +1,49 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisplayRules(t *testing.T) {
+	const focusTime EventType = 3
+	store := &InMemoryDataStore{}
+	c := NewCalendar(store, WithDisplayRules(
+		DisplayRule{Match: func(e Event) bool { return e.EventType == focusTime }, Color: "#00aa00", Category: "Focus time"},
+		DisplayRule{Match: func(e Event) bool { return e.Priority == 1 }, Color: "#ff0000"},
+		DisplayRule{Match: func(e Event) bool { return e.OwnerId != 1 }, Category: "External"},
+	))
+	create := func(e Event) *Event {
+		e.StartDay, e.EndDay, e.IsAllDay, e.Zone = "2008-01-01", "2008-01-01", true, "UTC"
+		created, _, err := c.Create(e)
+		require.NoError(t, err)
+		return created
+	}
+	focus := create(Event{OwnerId: 1, EventType: focusTime, Priority: 1, Display: &Display{Color: "#000000"}})
+	urgent := create(Event{OwnerId: 2, Priority: 1})
+	plain := create(Event{OwnerId: 1})
+
+	stored, err := store.Get(focus.Id)
+	require.NoError(t, err)
+	assert.Nil(t, stored.Display, "the display is never saved")
+
+	e, err := c.Get(focus.Id)
+	require.NoError(t, err)
+	assert.Equal(t, &Display{Color: "#00aa00", Category: "Focus time"}, e.Display)
+	assert.Equal(t, stored.ETag(), e.ETag(), "the display is not a part of the etag")
+
+	events, err := c.Query(Query{})
+	require.NoError(t, err)
+	displays := map[int64]*Display{}
+	for _, e := range events {
+		displays[e.Id] = e.Display
+	}
+	assert.Equal(t, map[int64]*Display{
+		focus.Id:  {Color: "#00aa00", Category: "Focus time"},
+		urgent.Id: {Color: "#ff0000", Category: "External"},
+		plain.Id:  nil,
+	}, displays)
+	assert.Nil(t, stored.Display, "the events in the data store are not changed")
+}
@@ -0,0 +1,60 @@
+package cali
+
+import (
+	"time"
+)
+
+// Duration gets the time between the start and end of the event, which for all day events is
+// the whole days that the event spans
+func (e Event) Duration() (time.Duration, error) {
+	start, end, err := e.span()
+	if err != nil {
+		return 0, err
+	}
+	return end.Sub(start), nil
+}
+
+// applyDuration fills in the EndDay and EndTime of the event from its DurationMinutes if they are
+// empty, or makes sure that the duration matches them if they aren't
+func applyDuration(e *Event) error {
+	if e.DurationMinutes == nil {
+		return nil
+	}
+	if *e.DurationMinutes < 0 || e.IsAllDay {
+		return ErrorInvalidDuration
+	}
+	start, err := e.Start()
+	if err != nil || e.StartTime == "" {
+		return ErrorInvalidStartTime
+	}
+	end := start.Add(time.Duration(*e.DurationMinutes) * time.Minute)
+	if e.EndDay == "" && e.EndTime == "" {
+		e.EndDay, e.EndTime = end.Format(time.DateOnly), formatTime(end)
+		return nil
+	}
+	if d, err := e.Duration(); err != nil || d != end.Sub(start) {
+		return ErrorDurationMismatch
+	}
+	return nil
+}
+
+// syncDuration sets the DurationMinutes of the event to the time between its start and end if the
+// event uses a duration, so that the two stay the same after the times of the event are changed
+func syncDuration(e *Event) {
+	if e.DurationMinutes == nil {
+		return
+	}
+	d, err := e.Duration()
+	if err != nil || e.IsAllDay {
+		e.DurationMinutes = nil
+		return
+	}
+	minutes := int64(d / time.Minute)
+	e.DurationMinutes = &minutes
+}
+
+// SyncDuration is for data stores outside of this package, which keep the DurationMinutes of an
+// event up to date when its times are set (see DataStore.SetTime)
+func SyncDuration(e *Event) {
+	syncDuration(e)
+}
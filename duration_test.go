@@ -0,0 +1,68 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurationEvents(t *testing.T) {
+	ninety := int64(90)
+	testCases := []struct {
+		name    string
+		event   Event
+		endDay  string
+		endTime string
+		err     error
+	}{
+		{name: "end from the duration", event: Event{StartDay: "2008-01-01", StartTime: "09:00", DurationMinutes: &ninety}, endDay: "2008-01-01", endTime: "10:30"},
+		{name: "end past midnight", event: Event{StartDay: "2008-01-01", StartTime: "23:30", DurationMinutes: &ninety}, endDay: "2008-01-02", endTime: "01:00"},
+		{name: "end with seconds", event: Event{StartDay: "2008-01-01", StartTime: "09:00:30", DurationMinutes: &ninety}, endDay: "2008-01-01", endTime: "10:30:30"},
+		{name: "matching end", event: Event{StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:30", DurationMinutes: &ninety}, endDay: "2008-01-01", endTime: "10:30"},
+		{name: "mismatched end", event: Event{StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", DurationMinutes: &ninety}, err: ErrorDurationMismatch},
+		{name: "all day", event: Event{StartDay: "2008-01-01", IsAllDay: true, DurationMinutes: &ninety}, err: ErrorInvalidDuration},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(&InMemoryDataStore{})
+			tc.event.OwnerId = 1
+			tc.event.Zone = "UTC"
+			e, _, err := c.Create(tc.event)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.endDay, e.EndDay)
+			assert.Equal(t, tc.endTime, e.EndTime)
+			require.NotNil(t, e.DurationMinutes)
+			assert.Equal(t, ninety, *e.DurationMinutes)
+		})
+	}
+
+	// the duration follows the times of the event
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", DurationMinutes: &ninety})
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateTime(e.Id, "09:00", "09:45:30", RepeatEditTypeThis))
+	e, err = c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(45), *e.DurationMinutes)
+	assert.Equal(t, int64(90), ninety)
+}
+
+func TestSecondsTimes(t *testing.T) {
+	assert.NoError(t, ValidateTimeValues("09:00:15", "09:00:45"))
+	assert.Equal(t, ErrorStartTimeIsAfterEndTime, ValidateDayTimeValues("2008-01-01", "09:00:45", "2008-01-01", "09:00:15", "UTC", false))
+
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00:15", EndDay: "2008-01-01", EndTime: "09:00:45"})
+	require.NoError(t, err)
+	d, err := e.Duration()
+	require.NoError(t, err)
+	assert.Equal(t, "30s", d.String())
+}
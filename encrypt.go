@@ -0,0 +1,1137 @@
+package cali
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Encryptor encrypts the sensitive fields of events before they are saved in the data
+// store and decrypts them after they are read. Implementations can call out to a key
+// management service so that the keys are managed by the customer.
+type Encryptor interface {
+	// Encrypt converts the plaintext into ciphertext that is safe to save as a string
+	Encrypt(plaintext string) (string, error)
+	// Decrypt converts the ciphertext from Encrypt back into the plaintext
+	Decrypt(ciphertext string) (string, error)
+}
+
+// EncryptedUserDataKey is the only key of the UserData saved in the data store for an
+// encrypted event, and its value is the encrypted JSON of the real UserData
+const EncryptedUserDataKey = "$encrypted"
+
+// WithEncryptor encrypts the Title, Description, Url, Links, Location, and UserData of every
+// event in the data store, see NewEncryptedDataStore
+func WithEncryptor(encryptor Encryptor) CalendarOption {
+	return func(c *Calendar) {
+		c.dataStore = NewEncryptedDataStore(c.dataStore, encryptor)
+	}
+}
+
+// EncryptedDataStore wraps another data store and encrypts the Title, Description, Url, Links,
+// Location, and UserData of events on the way in and decrypts them on the way out. Since
+// the data store only has ciphertext, the Text field of a Query won't match encrypted fields.
+type EncryptedDataStore struct {
+	DataStore
+	encryptor Encryptor
+}
+
+// NewEncryptedDataStore wraps the data store so the sensitive event fields are encrypted at rest
+func NewEncryptedDataStore(store DataStore, encryptor Encryptor) *EncryptedDataStore {
+	return &EncryptedDataStore{
+		DataStore: store,
+		encryptor: encryptor,
+	}
+}
+
+// Unwrap gets the data store that the events are encrypted in (see WrapperStore)
+func (d *EncryptedDataStore) Unwrap() []DataStore {
+	return []DataStore{d.DataStore}
+}
+
+func (d *EncryptedDataStore) InTx(f func(tx DataStore) error) error {
+	store, ok := capability[TxStore](d.DataStore)
+	if !ok {
+		return f(d)
+	}
+	return store.InTx(func(tx DataStore) error {
+		return f(NewEncryptedDataStore(tx, d.encryptor))
+	})
+}
+
+// IfVersion calls f with the data store in IfVersion of the wrapped data store (see VersionStore),
+// or with the data store itself if the wrapped data store doesn't implement VersionStore
+func (d *EncryptedDataStore) IfVersion(eventId int64, version int64, f func(store DataStore) error) error {
+	store, ok := capability[VersionStore](d.DataStore)
+	if !ok {
+		return f(d)
+	}
+	return store.IfVersion(eventId, version, func(scoped DataStore) error {
+		return f(NewEncryptedDataStore(scoped, d.encryptor))
+	})
+}
+
+func (d *EncryptedDataStore) Create(event Event) (*Event, error) {
+	if err := d.encryptEvent(&event); err != nil {
+		return nil, err
+	}
+	e, err := d.DataStore.Create(event)
+	if err != nil || e == nil {
+		return e, err
+	}
+	return d.decryptEvent(e)
+}
+
+func (d *EncryptedDataStore) GetMany(eventIds []int64) ([]*Event, error) {
+	events, err := getMany(d.DataStore, eventIds)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Event, len(events))
+	for i, e := range events {
+		if e == nil {
+			continue
+		}
+		if result[i], err = d.decryptEvent(e); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (d *EncryptedDataStore) CreateBatch(events []Event) ([]*Event, error) {
+	store, ok := capability[BatchCreateStore](d.DataStore)
+	if !ok {
+		var result []*Event
+		for _, event := range events {
+			e, err := d.Create(event)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, e)
+		}
+		return result, nil
+	}
+	encrypted := make([]Event, len(events))
+	for i, event := range events {
+		if err := d.encryptEvent(&event); err != nil {
+			return nil, err
+		}
+		encrypted[i] = event
+	}
+	created, err := store.CreateBatch(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptEvents(created)
+}
+
+func (d *EncryptedDataStore) CreateRepeating(e Event) ([]*Event, error) {
+	store, ok := capability[RepeatExpansionStore](d.DataStore)
+	if !ok {
+		return generateRepeating(d, e)
+	}
+	if err := d.encryptEvent(&e); err != nil {
+		return nil, err
+	}
+	created, err := store.CreateRepeating(e)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptEvents(created)
+}
+
+func (d *EncryptedDataStore) SetStatusWhere(q Query, status Status) ([]int64, error) {
+	store, ok := capability[BulkStatusStore](d.DataStore)
+	if ok {
+		return store.SetStatusWhere(q, status)
+	}
+	events, err := d.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	var result []int64
+	for _, e := range events {
+		if e.Status == status {
+			continue
+		}
+		if err := d.SetStatus(e.Id, status); err != nil {
+			return nil, err
+		}
+		result = append(result, e.Id)
+	}
+	return result, nil
+}
+
+func (d *EncryptedDataStore) SetStatusBatch(eventIds []int64, status Status) error {
+	store, ok := capability[BatchStatusStore](d.DataStore)
+	if ok {
+		return store.SetStatusBatch(eventIds, status)
+	}
+	for _, id := range eventIds {
+		if err := d.SetStatus(id, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *EncryptedDataStore) SetTitle(eventId int64, title string) error {
+	title, err := d.encryptor.Encrypt(title)
+	if err != nil {
+		return err
+	}
+	return d.DataStore.SetTitle(eventId, title)
+}
+
+func (d *EncryptedDataStore) SetDescription(eventId int64, description *string) error {
+	description, err := d.encryptOptional(description)
+	if err != nil {
+		return err
+	}
+	return d.DataStore.SetDescription(eventId, description)
+}
+
+func (d *EncryptedDataStore) SetUrl(eventId int64, url *string) error {
+	url, err := d.encryptOptional(url)
+	if err != nil {
+		return err
+	}
+	return d.DataStore.SetUrl(eventId, url)
+}
+
+func (d *EncryptedDataStore) SetLocation(eventId int64, location *string) error {
+	store, ok := capability[LocationStore](d.DataStore)
+	if !ok {
+		return ErrorLocationNotSupported
+	}
+	location, err := d.encryptOptional(location)
+	if err != nil {
+		return err
+	}
+	return store.SetLocation(eventId, location)
+}
+
+func (d *EncryptedDataStore) SetUserData(eventId int64, userData map[string]interface{}) error {
+	userData, err := d.encryptUserData(userData)
+	if err != nil {
+		return err
+	}
+	return d.DataStore.SetUserData(eventId, userData)
+}
+
+func (d *EncryptedDataStore) Get(eventId int64) (*Event, error) {
+	e, err := d.DataStore.Get(eventId)
+	if err != nil || e == nil {
+		return e, err
+	}
+	return d.decryptEvent(e)
+}
+
+func (d *EncryptedDataStore) Query(q Query) ([]*Event, error) {
+	events, err := d.DataStore.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptEvents(events)
+}
+
+func (d *EncryptedDataStore) SetAutoResponsePolicy(policy AutoResponsePolicy) error {
+	store, ok := capability[AutoResponseStore](d.DataStore)
+	if !ok {
+		return ErrorAutoResponseNotSupported
+	}
+	return store.SetAutoResponsePolicy(policy)
+}
+
+func (d *EncryptedDataStore) GetAutoResponsePolicy(userId int64) (*AutoResponsePolicy, error) {
+	store, ok := capability[AutoResponseStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetAutoResponsePolicy(userId)
+}
+
+func (d *EncryptedDataStore) AddSeriesInvite(invite Invite) (*Invite, error) {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSeriesInviteNotSupported
+	}
+	return store.AddSeriesInvite(invite)
+}
+
+func (d *EncryptedDataStore) SetSeriesInviteStatus(parentId, userId int64, status InviteStatus) error {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return ErrorSeriesInviteNotSupported
+	}
+	return store.SetSeriesInviteStatus(parentId, userId, status)
+}
+
+func (d *EncryptedDataStore) SetSeriesInvitePermissions(parentId, userId int64, permissions Permission) error {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return ErrorSeriesInviteNotSupported
+	}
+	return store.SetSeriesInvitePermissions(parentId, userId, permissions)
+}
+
+func (d *EncryptedDataStore) GetSeriesInvite(parentId, userId int64) (*Invite, error) {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetSeriesInvite(parentId, userId)
+}
+
+func (d *EncryptedDataStore) GetSeriesInvites(parentId int64) ([]*Invite, error) {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetSeriesInvites(parentId)
+}
+
+func (d *EncryptedDataStore) SetSubscription(s Subscription) (*Subscription, error) {
+	store, ok := capability[SubscriptionStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSubscriptionNotSupported
+	}
+	return store.SetSubscription(s)
+}
+
+func (d *EncryptedDataStore) RemoveSubscription(userId, calendarId int64) error {
+	store, ok := capability[SubscriptionStore](d.DataStore)
+	if !ok {
+		return ErrorSubscriptionNotSupported
+	}
+	return store.RemoveSubscription(userId, calendarId)
+}
+
+func (d *EncryptedDataStore) GetSubscriptions(userId int64) ([]*Subscription, error) {
+	store, ok := capability[SubscriptionStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetSubscriptions(userId)
+}
+
+func (d *EncryptedDataStore) GetInvites(eventId int64) ([]*Invite, error) {
+	store, ok := capability[InviteListStore](d.DataStore)
+	if !ok {
+		return nil, ErrorInviteListNotSupported
+	}
+	return store.GetInvites(eventId)
+}
+
+func (d *EncryptedDataStore) ChangedEvents(since time.Time) ([]*Event, error) {
+	store, ok := capability[SyncStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSyncNotSupported
+	}
+	events, err := store.ChangedEvents(since)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptEvents(events)
+}
+
+func (d *EncryptedDataStore) ChangedInvites(since time.Time) ([]*Invite, error) {
+	store, ok := capability[SyncStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSyncNotSupported
+	}
+	return store.ChangedInvites(since)
+}
+
+func (d *EncryptedDataStore) AddOutboxRecord(record OutboxRecord) (*OutboxRecord, error) {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return nil, ErrorOutboxNotSupported
+	}
+	// the payload has the plaintext event, so the whole payload is saved as an encrypted JSON string
+	ciphertext, err := d.encryptor.Encrypt(string(record.Payload))
+	if err != nil {
+		return nil, err
+	}
+	plaintext := record.Payload
+	if record.Payload, err = json.Marshal(ciphertext); err != nil {
+		return nil, err
+	}
+	r, err := store.AddOutboxRecord(record)
+	if err != nil || r == nil {
+		return r, err
+	}
+	copied := *r
+	copied.Payload = plaintext
+	return &copied, nil
+}
+
+func (d *EncryptedDataStore) PendingOutboxRecords(limit int) ([]*OutboxRecord, error) {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return nil, ErrorOutboxNotSupported
+	}
+	records, err := store.PendingOutboxRecords(limit)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*OutboxRecord, 0, len(records))
+	for _, r := range records {
+		var ciphertext string
+		if err := json.Unmarshal(r.Payload, &ciphertext); err != nil {
+			return nil, ErrorDecryptionFailed
+		}
+		plaintext, err := d.encryptor.Decrypt(ciphertext)
+		if err != nil {
+			return nil, err
+		}
+		copied := *r
+		copied.Payload = json.RawMessage(plaintext)
+		result = append(result, &copied)
+	}
+	return result, nil
+}
+
+func (d *EncryptedDataStore) MarkOutboxDelivered(id int64) error {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return ErrorOutboxNotSupported
+	}
+	return store.MarkOutboxDelivered(id)
+}
+
+func (d *EncryptedDataStore) MarkOutboxFailed(id int64, reason string) error {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return ErrorOutboxNotSupported
+	}
+	return store.MarkOutboxFailed(id, reason)
+}
+
+func (d *EncryptedDataStore) SetInviteProposal(eventId, userId int64, proposal *TimeProposal) error {
+	store, ok := capability[ProposalStore](d.DataStore)
+	if !ok {
+		return ErrorProposalsNotSupported
+	}
+	return store.SetInviteProposal(eventId, userId, proposal)
+}
+
+func (d *EncryptedDataStore) SetInviteMuted(eventId, userId int64, muted bool) error {
+	store, ok := capability[MuteStore](d.DataStore)
+	if !ok {
+		return ErrorMuteNotSupported
+	}
+	return store.SetInviteMuted(eventId, userId, muted)
+}
+
+func (d *EncryptedDataStore) AddAvailability(a Availability) (*Availability, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	var err error
+	if a.Title, err = d.encryptor.Encrypt(a.Title); err != nil {
+		return nil, err
+	}
+	stored, err := store.AddAvailability(a)
+	if err != nil || stored == nil {
+		return stored, err
+	}
+	return d.decryptAvailability(stored)
+}
+
+func (d *EncryptedDataStore) GetAvailability(id int64) (*Availability, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	stored, err := store.GetAvailability(id)
+	if err != nil || stored == nil {
+		return stored, err
+	}
+	return d.decryptAvailability(stored)
+}
+
+func (d *EncryptedDataStore) BookSlot(b Booking, e Event) (*Booking, *Event, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, nil, ErrorSlotsNotSupported
+	}
+	if err := d.encryptEvent(&e); err != nil {
+		return nil, nil, err
+	}
+	booking, event, err := store.BookSlot(b, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	if event, err = d.decryptEvent(event); err != nil {
+		return nil, nil, err
+	}
+	return booking, event, nil
+}
+
+func (d *EncryptedDataStore) GetBooking(id int64) (*Booking, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	return store.GetBooking(id)
+}
+
+func (d *EncryptedDataStore) GetBookings(availabilityId int64, startDay, endDay string) ([]*Booking, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	return store.GetBookings(availabilityId, startDay, endDay)
+}
+
+func (d *EncryptedDataStore) SetBookingStatus(id int64, status BookingStatus) error {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return ErrorSlotsNotSupported
+	}
+	return store.SetBookingStatus(id, status)
+}
+
+func (d *EncryptedDataStore) Stats(userId int64, window TimeWindow) (*Stats, error) {
+	if store, ok := capability[StatsStore](d.DataStore); ok {
+		return store.Stats(userId, window)
+	}
+	// the stats don't use any encrypted fields, so the events don't need to be decrypted
+	return computeStats(d.DataStore, userId, window)
+}
+
+func (d *EncryptedDataStore) RSVPSummary(eventId int64) (*RSVPSummary, error) {
+	if store, ok := capability[RSVPSummaryStore](d.DataStore); ok {
+		return store.RSVPSummary(eventId)
+	}
+	return computeRSVPSummary(d.DataStore, eventId)
+}
+
+func (d *EncryptedDataStore) PendingInvitesBefore(before time.Time) ([]*Invite, error) {
+	store, ok := capability[PendingInviteStore](d.DataStore)
+	if !ok {
+		return nil, ErrorPendingInvitesNotSupported
+	}
+	return store.PendingInvitesBefore(before)
+}
+
+func (d *EncryptedDataStore) CreateSeries(s Series) (*Series, error) {
+	store, ok := capability[SeriesStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSeriesRecordsNotSupported
+	}
+	if err := d.encryptSeries(&s); err != nil {
+		return nil, err
+	}
+	stored, err := store.CreateSeries(s)
+	if err != nil || stored == nil {
+		return stored, err
+	}
+	return d.decryptSeries(stored)
+}
+
+func (d *EncryptedDataStore) SetSeries(s Series) error {
+	store, ok := capability[SeriesStore](d.DataStore)
+	if !ok {
+		return ErrorSeriesRecordsNotSupported
+	}
+	if err := d.encryptSeries(&s); err != nil {
+		return err
+	}
+	return store.SetSeries(s)
+}
+
+func (d *EncryptedDataStore) GetSeries(seriesIds []int64) ([]*Series, error) {
+	store, ok := capability[SeriesStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSeriesRecordsNotSupported
+	}
+	stored, err := store.GetSeries(seriesIds)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Series, 0, len(stored))
+	for _, s := range stored {
+		decrypted, err := d.decryptSeries(s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, decrypted)
+	}
+	return result, nil
+}
+
+func (d *EncryptedDataStore) GetByExternalKey(key string) (*Event, error) {
+	store, ok := capability[ExternalKeyStore](d.DataStore)
+	if !ok {
+		return nil, ErrorExternalKeysNotSupported
+	}
+	e, err := store.GetByExternalKey(key)
+	if err != nil || e == nil {
+		return e, err
+	}
+	return d.decryptEvent(e)
+}
+
+func (d *EncryptedDataStore) SetExternalKey(eventId int64, key string) error {
+	store, ok := capability[ExternalKeyStore](d.DataStore)
+	if !ok {
+		return ErrorExternalKeysNotSupported
+	}
+	return store.SetExternalKey(eventId, key)
+}
+
+func (d *EncryptedDataStore) SetOverrides(eventId int64, overrides []string) error {
+	store, ok := capability[OverrideStore](d.DataStore)
+	if !ok {
+		return ErrorOverridesNotSupported
+	}
+	return store.SetOverrides(eventId, overrides)
+}
+
+func (d *EncryptedDataStore) SetRecurrenceId(eventId int64, recurrenceId string) error {
+	store, ok := capability[OverrideStore](d.DataStore)
+	if !ok {
+		return ErrorOverridesNotSupported
+	}
+	return store.SetRecurrenceId(eventId, recurrenceId)
+}
+
+func (d *EncryptedDataStore) SetGeo(eventId int64, geo *GeoPoint) error {
+	store, ok := capability[GeoPointStore](d.DataStore)
+	if !ok {
+		return ErrorGeoNotSupported
+	}
+	return store.SetGeo(eventId, geo)
+}
+
+func (d *EncryptedDataStore) SetVisibility(eventId int64, visibility Visibility) error {
+	store, ok := capability[VisibilityStore](d.DataStore)
+	if !ok {
+		return ErrorVisibilityNotSupported
+	}
+	return store.SetVisibility(eventId, visibility)
+}
+
+func (d *EncryptedDataStore) SetDisallowForwarding(eventId int64, disallow bool) error {
+	store, ok := capability[ForwardingStore](d.DataStore)
+	if !ok {
+		return ErrorForwardingNotSupported
+	}
+	return store.SetDisallowForwarding(eventId, disallow)
+}
+
+func (d *EncryptedDataStore) SetPriority(eventId int64, priority Priority) error {
+	store, ok := capability[PriorityStore](d.DataStore)
+	if !ok {
+		return ErrorPriorityNotSupported
+	}
+	return store.SetPriority(eventId, priority)
+}
+
+func (d *EncryptedDataStore) SetConference(eventId int64, conference *Conference) error {
+	store, ok := capability[ConferenceStore](d.DataStore)
+	if !ok {
+		return ErrorConferenceNotSupported
+	}
+	return store.SetConference(eventId, conference)
+}
+
+func (d *EncryptedDataStore) SetInvitePrivateNote(eventId, userId int64, note *string) error {
+	store, ok := capability[PrivateInviteStore](d.DataStore)
+	if !ok {
+		return ErrorPrivateInviteNotSupported
+	}
+	return store.SetInvitePrivateNote(eventId, userId, note)
+}
+
+func (d *EncryptedDataStore) SetInviteUserData(eventId, userId int64, userData map[string]interface{}) error {
+	store, ok := capability[PrivateInviteStore](d.DataStore)
+	if !ok {
+		return ErrorPrivateInviteNotSupported
+	}
+	return store.SetInviteUserData(eventId, userId, userData)
+}
+
+func (d *EncryptedDataStore) SetCancelReason(eventId int64, reason *string) error {
+	store, ok := capability[CancelReasonStore](d.DataStore)
+	if !ok {
+		return ErrorCancelReasonNotSupported
+	}
+	return store.SetCancelReason(eventId, reason)
+}
+
+func (d *EncryptedDataStore) SetLinks(eventId int64, links []Link) error {
+	store, ok := capability[LinkStore](d.DataStore)
+	if !ok {
+		return ErrorLinksNotSupported
+	}
+	links, err := d.encryptLinks(links)
+	if err != nil {
+		return err
+	}
+	return store.SetLinks(eventId, links)
+}
+
+func (d *EncryptedDataStore) AddFollower(f Follower) (*Follower, error) {
+	store, ok := capability[FollowerStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	return store.AddFollower(f)
+}
+
+func (d *EncryptedDataStore) RemoveFollower(eventId, userId int64) error {
+	store, ok := capability[FollowerStore](d.DataStore)
+	if !ok {
+		return ErrorFollowersNotSupported
+	}
+	return store.RemoveFollower(eventId, userId)
+}
+
+func (d *EncryptedDataStore) GetFollowers(eventId int64) ([]*Follower, error) {
+	store, ok := capability[FollowerStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	return store.GetFollowers(eventId)
+}
+
+func (d *EncryptedDataStore) GetFollowedEventIds(userId int64) ([]int64, error) {
+	store, ok := capability[FollowerStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	return store.GetFollowedEventIds(userId)
+}
+
+func (d *EncryptedDataStore) AddReaction(r Reaction) (*Reaction, error) {
+	store, ok := capability[ReactionStore](d.DataStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	return store.AddReaction(r)
+}
+
+func (d *EncryptedDataStore) RemoveReaction(eventId, userId int64, emoji string) error {
+	store, ok := capability[ReactionStore](d.DataStore)
+	if !ok {
+		return ErrorReactionsNotSupported
+	}
+	return store.RemoveReaction(eventId, userId, emoji)
+}
+
+func (d *EncryptedDataStore) GetReactions(eventId int64) ([]*Reaction, error) {
+	store, ok := capability[ReactionStore](d.DataStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	return store.GetReactions(eventId)
+}
+
+func (d *EncryptedDataStore) CountReactions(eventIds []int64) (map[int64]map[string]int64, error) {
+	store, ok := capability[ReactionStore](d.DataStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	return store.CountReactions(eventIds)
+}
+
+func (d *EncryptedDataStore) AddAttachment(a Attachment) (*Attachment, error) {
+	store, ok := capability[AttachmentStore](d.DataStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	return store.AddAttachment(a)
+}
+
+func (d *EncryptedDataStore) GetAttachment(id int64) (*Attachment, error) {
+	store, ok := capability[AttachmentStore](d.DataStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	return store.GetAttachment(id)
+}
+
+func (d *EncryptedDataStore) GetAttachments(eventId int64) ([]*Attachment, error) {
+	store, ok := capability[AttachmentStore](d.DataStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	return store.GetAttachments(eventId)
+}
+
+func (d *EncryptedDataStore) SetAttachmentStatus(id int64, status AttachmentStatus, reason *string) error {
+	store, ok := capability[AttachmentStore](d.DataStore)
+	if !ok {
+		return ErrorAttachmentsNotSupported
+	}
+	return store.SetAttachmentStatus(id, status, reason)
+}
+
+func (d *EncryptedDataStore) AddFeedToken(t FeedToken) (*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.AddFeedToken(t)
+}
+
+func (d *EncryptedDataStore) GetFeedToken(id int64) (*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.GetFeedToken(id)
+}
+
+func (d *EncryptedDataStore) GetFeedTokenByHash(hash string) (*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.GetFeedTokenByHash(hash)
+}
+
+func (d *EncryptedDataStore) GetFeedTokens(userId int64) ([]*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.GetFeedTokens(userId)
+}
+
+func (d *EncryptedDataStore) RevokeFeedToken(id int64) error {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return ErrorFeedTokensNotSupported
+	}
+	return store.RevokeFeedToken(id)
+}
+
+// encryptSeries encrypts the sensitive fields of the series in place
+func (d *EncryptedDataStore) encryptSeries(s *Series) error {
+	var err error
+	if s.Description, err = d.encryptOptional(s.Description); err != nil {
+		return err
+	}
+	if s.Url, err = d.encryptOptional(s.Url); err != nil {
+		return err
+	}
+	s.Location, err = d.encryptOptional(s.Location)
+	return err
+}
+
+// decryptSeries makes a decrypted copy of the series so the stored series is not changed
+func (d *EncryptedDataStore) decryptSeries(stored *Series) (*Series, error) {
+	s := *stored
+	var err error
+	if s.Description, err = d.decryptOptional(s.Description); err != nil {
+		return nil, err
+	}
+	if s.Url, err = d.decryptOptional(s.Url); err != nil {
+		return nil, err
+	}
+	if s.Location, err = d.decryptOptional(s.Location); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// encryptEvent encrypts the sensitive fields of the event in place
+func (d *EncryptedDataStore) encryptEvent(e *Event) error {
+	var err error
+	if e.Title, err = d.encryptor.Encrypt(e.Title); err != nil {
+		return err
+	}
+	if e.Description, err = d.encryptOptional(e.Description); err != nil {
+		return err
+	}
+	if e.Url, err = d.encryptOptional(e.Url); err != nil {
+		return err
+	}
+	if e.Links, err = d.encryptLinks(e.Links); err != nil {
+		return err
+	}
+	if e.Location, err = d.encryptOptional(e.Location); err != nil {
+		return err
+	}
+	e.UserData, err = d.encryptUserData(e.UserData)
+	return err
+}
+
+// decryptEvent makes a decrypted copy of the event so the stored event is not changed
+func (d *EncryptedDataStore) decryptEvent(stored *Event) (*Event, error) {
+	e := *stored
+	var err error
+	if e.Title, err = d.encryptor.Decrypt(e.Title); err != nil {
+		return nil, err
+	}
+	if e.Description, err = d.decryptOptional(e.Description); err != nil {
+		return nil, err
+	}
+	if e.Url, err = d.decryptOptional(e.Url); err != nil {
+		return nil, err
+	}
+	if e.Links, err = d.decryptLinks(e.Links); err != nil {
+		return nil, err
+	}
+	if e.Location, err = d.decryptOptional(e.Location); err != nil {
+		return nil, err
+	}
+	if e.UserData, err = d.decryptUserData(e.UserData); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// decryptAuditEntry makes a copy of the audit entry with a decrypted copy of its event
+func (d *EncryptedDataStore) decryptAuditEntry(stored *AuditEntry) (*AuditEntry, error) {
+	entry := *stored
+	if entry.Event != nil {
+		e, err := d.decryptEvent(entry.Event)
+		if err != nil {
+			return nil, err
+		}
+		entry.Event = e
+	}
+	return &entry, nil
+}
+
+// decryptAvailability makes a decrypted copy of the availability so the stored availability is not changed
+func (d *EncryptedDataStore) decryptAvailability(stored *Availability) (*Availability, error) {
+	a := *stored
+	var err error
+	if a.Title, err = d.encryptor.Decrypt(a.Title); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (d *EncryptedDataStore) decryptEvents(events []*Event) ([]*Event, error) {
+	result := make([]*Event, 0, len(events))
+	for _, stored := range events {
+		e, err := d.decryptEvent(stored)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+func (d *EncryptedDataStore) encryptOptional(s *string) (*string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	v, err := d.encryptor.Encrypt(*s)
+	return &v, err
+}
+
+func (d *EncryptedDataStore) decryptOptional(s *string) (*string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	v, err := d.encryptor.Decrypt(*s)
+	return &v, err
+}
+
+// encryptLinks makes a copy of the links with encrypted labels and urls
+func (d *EncryptedDataStore) encryptLinks(links []Link) ([]Link, error) {
+	return d.mapLinks(links, d.encryptor.Encrypt)
+}
+
+// decryptLinks makes a copy of the links with decrypted labels and urls
+func (d *EncryptedDataStore) decryptLinks(links []Link) ([]Link, error) {
+	return d.mapLinks(links, d.encryptor.Decrypt)
+}
+
+func (d *EncryptedDataStore) mapLinks(links []Link, f func(s string) (string, error)) ([]Link, error) {
+	result := copyLinks(links)
+	for i := range result {
+		var err error
+		if result[i].Label, err = f(result[i].Label); err != nil {
+			return nil, err
+		}
+		if result[i].Url, err = f(result[i].Url); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (d *EncryptedDataStore) encryptUserData(userData map[string]interface{}) (map[string]interface{}, error) {
+	if userData == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(userData)
+	if err != nil {
+		return nil, err
+	}
+	v, err := d.encryptor.Encrypt(string(b))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{EncryptedUserDataKey: v}, nil
+}
+
+func (d *EncryptedDataStore) decryptUserData(userData map[string]interface{}) (map[string]interface{}, error) {
+	v, ok := userData[EncryptedUserDataKey].(string)
+	if !ok || len(userData) != 1 {
+		return userData, nil
+	}
+	s, err := d.encryptor.Decrypt(v)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AESEncryptor is an Encryptor that uses AES-GCM with a random nonce, and
+// the ciphertext is the base64 of the nonce followed by the sealed data
+type AESEncryptor struct {
+	aead cipher.AEAD
+}
+
+// NewAESEncryptor creates an AES-GCM encryptor where the key must be 16, 24, or 32 bytes
+func NewAESEncryptor(key []byte) (*AESEncryptor, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AESEncryptor{aead: aead}, nil
+}
+
+func (a *AESEncryptor) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, a.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := a.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (a *AESEncryptor) Decrypt(ciphertext string) (string, error) {
+	b, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrorDecryptionFailed
+	}
+	size := a.aead.NonceSize()
+	if len(b) < size {
+		return "", ErrorDecryptionFailed
+	}
+	plaintext, err := a.aead.Open(nil, b[:size], b[size:], nil)
+	if err != nil {
+		return "", ErrorDecryptionFailed
+	}
+	return string(plaintext), nil
+}
+
+func (d *EncryptedDataStore) AddReminder(r Reminder) (*Reminder, error) {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.AddReminder(r)
+}
+
+func (d *EncryptedDataStore) GetReminder(reminderId int64) (*Reminder, error) {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.GetReminder(reminderId)
+}
+
+func (d *EncryptedDataStore) UpdateReminder(r Reminder) error {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return ErrorRemindersNotSupported
+	}
+	return store.UpdateReminder(r)
+}
+
+func (d *EncryptedDataStore) DueReminders(before time.Time) ([]*Reminder, error) {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.DueReminders(before)
+}
+
+func (d *EncryptedDataStore) AddAuditEntry(entry AuditEntry) (*AuditEntry, error) {
+	store, ok := capability[AuditStore](d.DataStore)
+	if !ok {
+		return nil, ErrorAuditLogNotSupported
+	}
+	if entry.Event != nil {
+		e := *entry.Event
+		if err := d.encryptEvent(&e); err != nil {
+			return nil, err
+		}
+		entry.Event = &e
+	}
+	added, err := store.AddAuditEntry(entry)
+	if err != nil {
+		return nil, err
+	}
+	return d.decryptAuditEntry(added)
+}
+
+func (d *EncryptedDataStore) GetAuditEntries(eventId int64) ([]*AuditEntry, error) {
+	store, ok := capability[AuditStore](d.DataStore)
+	if !ok {
+		return nil, ErrorAuditLogNotSupported
+	}
+	entries, err := store.GetAuditEntries(eventId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		decrypted, err := d.decryptAuditEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, decrypted)
+	}
+	return result, nil
+}
+
+func (d *EncryptedDataStore) LockEvent(lock EventLock, now time.Time) error {
+	store, ok := capability[LockStore](d.DataStore)
+	if !ok {
+		return ErrorLocksNotSupported
+	}
+	return store.LockEvent(lock, now)
+}
+
+func (d *EncryptedDataStore) GetEventLock(eventId int64) (*EventLock, error) {
+	store, ok := capability[LockStore](d.DataStore)
+	if !ok {
+		return nil, ErrorLocksNotSupported
+	}
+	return store.GetEventLock(eventId)
+}
+
+func (d *EncryptedDataStore) UnlockEvent(eventId int64, userId int64) error {
+	store, ok := capability[LockStore](d.DataStore)
+	if !ok {
+		return ErrorLocksNotSupported
+	}
+	return store.UnlockEvent(eventId, userId)
+}
@@ -0,0 +1,85 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptedDataStore(t *testing.T) {
+	enc, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	d := &InMemoryDataStore{}
+	c := NewCalendar(d, WithEncryptor(enc))
+
+	description := "blood work"
+	a, _, err := c.Create(Event{
+		Title:       "Doctor",
+		Description: &description,
+		StartDay:    "2008-01-01",
+		EndDay:      "2008-01-01",
+		IsAllDay:    true,
+		Zone:        "UTC",
+		UserData:    map[string]interface{}{"room": "4b"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Doctor", a.Title)
+	assert.Equal(t, "blood work", *a.Description)
+	assert.Equal(t, "4b", a.UserData["room"])
+
+	// the data store only has ciphertext
+	stored, err := d.Get(a.Id)
+	require.NoError(t, err)
+	assert.NotEqual(t, "Doctor", stored.Title)
+	assert.NotEqual(t, "blood work", *stored.Description)
+	assert.Contains(t, stored.UserData, EncryptedUserDataKey)
+	assert.Nil(t, stored.Url)
+
+	url := "https://example.com"
+	require.NoError(t, c.UpdateTitle(a.Id, "Dentist", RepeatEditTypeThis))
+	require.NoError(t, c.UpdateUrl(a.Id, &url, RepeatEditTypeThis))
+	assert.NotEqual(t, url, *stored.Url)
+
+	events, err := c.Query(Query{EventIds: []int64{a.Id}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "Dentist", events[0].Title)
+	assert.Equal(t, url, *events[0].Url)
+
+	// optional stores still work through the wrapper
+	result, err := c.Sync("")
+	require.NoError(t, err)
+	require.Len(t, result.Events, 1)
+	assert.Equal(t, "Dentist", result.Events[0].Title)
+}
+
+func TestEncryptedPlainDataStore(t *testing.T) {
+	enc, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	assertPlainWrapper(t, NewEncryptedDataStore(plainStore{&InMemoryDataStore{}}, enc))
+}
+
+func TestAESEncryptor(t *testing.T) {
+	_, err := NewAESEncryptor([]byte("short"))
+	assert.Error(t, err)
+
+	enc, err := NewAESEncryptor([]byte("0123456789abcdef"))
+	require.NoError(t, err)
+	a, err := enc.Encrypt("secret")
+	require.NoError(t, err)
+	b, err := enc.Encrypt("secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+
+	plaintext, err := enc.Decrypt(a)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", plaintext)
+
+	other, err := NewAESEncryptor([]byte("fedcba9876543210"))
+	require.NoError(t, err)
+	_, err = other.Decrypt(a)
+	assert.Equal(t, ErrorDecryptionFailed, err)
+	_, err = enc.Decrypt("!!")
+	assert.Equal(t, ErrorDecryptionFailed, err)
+}
@@ -0,0 +1,93 @@
+package cali
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// ETag is a stable hash of all of the fields of the event (including the Version, but not the Display
+// or the ReactionCounts) formatted as a strong HTTP entity tag. It changes every time the event is
+// modified.
+func (e Event) ETag() string {
+	// the display and the reaction counts are set when the event is read, so they aren't a part
+	// of the event
+	e.Display, e.ReactionCounts = nil, nil
+	b, err := json.Marshal(plainEvent(e))
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// IfMatch creates a copy of the calendar where every update operation first checks
+// that the event being edited still has the expected ETag, and returns
+// ErrorPreconditionFailed if it doesn't. This is the same as an HTTP If-Match header.
+// For edits to repeating events, only the event whose id is passed in is checked. If the
+// data store implements VersionStore, then the check and the edit are a compare-and-set, so
+// only one of two concurrent edits with the same ETag is made. The copy is for one edit at a
+// time.
+//
+//	err := c.IfMatch(etag).UpdateTitle(eventId, "New Title", RepeatEditTypeThis)
+func (c *Calendar) IfMatch(etag string) *Calendar {
+	scoped := *c
+	scoped.ifMatch = etag
+	return &scoped
+}
+
+// checkIfMatch returns ErrorPreconditionFailed if the calendar was created with
+// IfMatch and the ETag of the event doesn't match
+func (c *Calendar) checkIfMatch(eventId int64) error {
+	_, err := c.matchIfMatch(eventId)
+	return err
+}
+
+// matchIfMatch checks the ETag of the event like checkIfMatch and returns the event that was
+// checked, or nil if the calendar wasn't created with IfMatch
+func (c *Calendar) matchIfMatch(eventId int64) (*Event, error) {
+	if c.ifMatch == "" || c.ifMatch == "*" {
+		return nil, nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	// the ETag is of the event that Get reads, which has the fields of its series record
+	events, err := c.withSeries([]*Event{e})
+	if err != nil {
+		return nil, err
+	}
+	if e = events[0]; e.ETag() != c.ifMatch {
+		return nil, ErrorPreconditionFailed
+	}
+	return e, nil
+}
+
+// ifMatched calls f after checkIfMatch. If the data store implements VersionStore, then the
+// calendar uses a data store that only changes the event while it has the version that was
+// checked, so an edit made by someone else after the check fails the edit of f with
+// ErrorPreconditionFailed instead of being overwritten.
+func (c *Calendar) ifMatched(eventId int64, f func() error) error {
+	e, err := c.matchIfMatch(eventId)
+	if err != nil {
+		return err
+	}
+	store, ok := capability[VersionStore](c.dataStore)
+	if e == nil || !ok {
+		return f()
+	}
+	return store.IfVersion(eventId, e.Version, func(scoped DataStore) error {
+		// c is the copy of the calendar made by IfMatch, so its data store can be swapped, and
+		// the ETag isn't checked again since the data store compares the versions
+		dataStore, etag := c.dataStore, c.ifMatch
+		c.dataStore, c.ifMatch = scoped, ""
+		defer func() {
+			c.dataStore, c.ifMatch = dataStore, etag
+		}()
+		return f()
+	})
+}
@@ -0,0 +1,155 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETag(t *testing.T) {
+	a := Event{Id: 1, Title: "a", Version: 1}
+	b := a
+	assert.Equal(t, a.ETag(), b.ETag())
+	assert.Regexp(t, `^"[0-9a-f]{24}"$`, a.ETag())
+
+	b.Version++
+	assert.NotEqual(t, a.ETag(), b.ETag())
+	b = a
+	b.Title = "b"
+	assert.NotEqual(t, a.ETag(), b.ETag())
+}
+
+func TestIfMatch(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	a, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), a.Version)
+
+	etag := a.ETag()
+	require.NoError(t, c.IfMatch(etag).UpdateTitle(a.Id, "first", RepeatEditTypeThis))
+	assert.Equal(t, int64(2), a.Version)
+
+	// the etag is stale now that the event changed
+	err = c.IfMatch(etag).UpdateTitle(a.Id, "second", RepeatEditTypeThis)
+	assert.Equal(t, ErrorPreconditionFailed, err)
+	err = c.IfMatch(etag).UpdateDayTime(a.Id, "2008-01-02", "", "2008-01-02", "", "UTC", true)
+	assert.Equal(t, ErrorPreconditionFailed, err)
+	assert.Equal(t, "first", a.Title)
+
+	// the original calendar doesn't check etags
+	require.NoError(t, c.UpdateTitle(a.Id, "second", RepeatEditTypeThis))
+	require.NoError(t, c.IfMatch("*").UpdateTitle(a.Id, "third", RepeatEditTypeThis))
+
+	e, err := c.Get(a.Id)
+	require.NoError(t, err)
+	require.NoError(t, c.IfMatch(e.ETag()).UpdateTitle(a.Id, "fourth", RepeatEditTypeThis))
+	assert.Equal(t, "fourth", a.Title)
+}
+
+// interleavedStore makes the edit of another writer after the ETag of an IfMatch edit is
+// checked and before the edit is made
+type interleavedStore struct {
+	*InMemoryDataStore
+	interleave func()
+}
+
+func (d *interleavedStore) IfVersion(eventId int64, version int64, f func(store DataStore) error) error {
+	d.interleave()
+	return d.InMemoryDataStore.IfVersion(eventId, version, f)
+}
+
+func TestIfMatchRace(t *testing.T) {
+	enc, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	testCases := []struct {
+		name  string
+		store func(inner func() *interleavedStore) DataStore
+	}{
+		{name: "in memory", store: func(inner func() *interleavedStore) DataStore {
+			return inner()
+		}},
+		{name: "encrypted", store: func(inner func() *interleavedStore) DataStore {
+			return NewEncryptedDataStore(inner(), enc)
+		}},
+		{name: "sharded", store: func(inner func() *interleavedStore) DataStore {
+			store, err := NewShardedDataStore(nil, inner(), inner())
+			require.NoError(t, err)
+			return store
+		}},
+		{name: "replicated", store: func(inner func() *interleavedStore) DataStore {
+			primary := inner()
+			return NewReplicatedDataStore(primary, primary, 0)
+		}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			var other func()
+			interleave := func() {
+				if other != nil {
+					f := other
+					other = nil
+					f()
+				}
+			}
+			c := NewCalendar(tc.store(func() *interleavedStore {
+				return &interleavedStore{InMemoryDataStore: &InMemoryDataStore{}, interleave: interleave}
+			}))
+			_, ok := capability[VersionStore](c.dataStore)
+			require.True(t, ok)
+			a, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true,
+				IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+			require.NoError(t, err)
+			e, err := c.Get(a.Id)
+			require.NoError(t, err)
+
+			// both writers have the same etag, and the other one edits the event first
+			other = func() {
+				require.NoError(t, c.IfMatch(e.ETag()).UpdateTitle(a.Id, "other", RepeatEditTypeThis))
+			}
+			err = c.IfMatch(e.ETag()).UpdateTitle(a.Id, "mine", RepeatEditTypeThis)
+			assert.Equal(t, ErrorPreconditionFailed, err)
+			e, err = c.Get(a.Id)
+			require.NoError(t, err)
+			assert.Equal(t, "other", e.Title, "the edit of the other writer isn't overwritten")
+			assert.Equal(t, []string{OverrideTitle}, e.Overrides, "the title and the override were both set in the version check")
+
+			require.NoError(t, c.IfMatch(e.ETag()).UpdateTitle(a.Id, "mine", RepeatEditTypeThis))
+			e, err = c.Get(a.Id)
+			require.NoError(t, err)
+			assert.Equal(t, "mine", e.Title)
+		})
+	}
+}
+
+func TestIfMatchReactionCounts(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithReactionCounts())
+	a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	_, err = c.React(a.Id, 2, "🎉")
+	require.NoError(t, err)
+
+	e, err := c.Get(a.Id)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"🎉": 1}, e.ReactionCounts)
+	require.NoError(t, c.IfMatch(e.ETag()).UpdateTitle(a.Id, "first", RepeatEditTypeThis), "the counts aren't a part of the etag")
+	assert.Equal(t, ErrorPreconditionFailed, c.IfMatch(e.ETag()).UpdateTitle(a.Id, "second", RepeatEditTypeThis))
+}
+
+func TestIfMatchSeriesRecords(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithSeriesRecords())
+	location := "Room 1"
+	a, _, err := c.Create(Event{OwnerId: 1, Title: "Sync", Location: &location,
+		StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+	require.NoError(t, err)
+
+	e, err := c.Get(a.Id)
+	require.NoError(t, err)
+	require.NotNil(t, e.Repeat, "the event has the fields of its series record")
+	require.NoError(t, c.IfMatch(e.ETag()).UpdateTitle(a.Id, "first", RepeatEditTypeThis))
+	assert.Equal(t, ErrorPreconditionFailed, c.IfMatch(e.ETag()).UpdateTitle(a.Id, "second", RepeatEditTypeThis))
+}
@@ -0,0 +1,45 @@
+package cali
+
+// ExternalKeyStore is an optional interface for a data store that can find events by their
+// ExternalKey. The data store should return ErrorDuplicateExternalKey from Create (and
+// CreateBatch) if another event already has the external key of the new event.
+type ExternalKeyStore interface {
+	// GetByExternalKey retrieves the event with the external key. If none is found, it returns nil, nil
+	GetByExternalKey(key string) (*Event, error)
+	// SetExternalKey updates the external key of the event, where "" removes it
+	SetExternalKey(eventId int64, key string) error
+}
+
+// GetByExternalKey grabs a single event by the key that another system uses for it, or nil
+// if no event has the key
+func (c *Calendar) GetByExternalKey(key string) (*Event, error) {
+	store, ok := capability[ExternalKeyStore](c.dataStore)
+	if !ok {
+		return nil, ErrorExternalKeysNotSupported
+	}
+	e, err := store.GetByExternalKey(key)
+	if err != nil || e == nil {
+		return e, err
+	}
+	events, err := c.withSeries([]*Event{e})
+	if err != nil {
+		return nil, err
+	}
+	if events, err = c.withReactions(events); err != nil {
+		return nil, err
+	}
+	return c.withDisplay(events)[0], nil
+}
+
+// UpdateExternalKey sets the key that another system uses for the event, where "" removes it
+func (c *Calendar) UpdateExternalKey(eventId int64, key string) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[ExternalKeyStore](c.dataStore)
+		if !ok {
+			return ErrorExternalKeysNotSupported
+		}
+		return c.editEvents(OperationUpdate, RepeatEditTypeThis, eventId, func(eventId int64) error {
+			return store.SetExternalKey(eventId, key)
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalKey(t *testing.T) {
+	stores := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}},
+			Key: func(e Event) int64 { return e.OwnerId }}},
+	}
+	for _, tc := range stores {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store)
+			create := func(ownerId int64, key string) (*Event, error) {
+				e, _, err := c.Create(Event{OwnerId: ownerId, ExternalKey: key, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC"})
+				return e, err
+			}
+			a, err := create(1, "crm-123")
+			require.NoError(t, err)
+			_, err = create(2, "crm-123")
+			assert.Equal(t, ErrorDuplicateExternalKey, err)
+			b, err := create(2, "")
+			require.NoError(t, err)
+			_, err = create(3, "")
+			require.NoError(t, err, "events without a key don't conflict")
+
+			found, err := c.GetByExternalKey("crm-123")
+			require.NoError(t, err)
+			require.NotNil(t, found)
+			assert.Equal(t, a.Id, found.Id)
+			found, err = c.GetByExternalKey("crm-456")
+			require.NoError(t, err)
+			assert.Nil(t, found)
+
+			assert.Equal(t, ErrorDuplicateExternalKey, c.UpdateExternalKey(b.Id, "crm-123"))
+			require.NoError(t, c.UpdateExternalKey(a.Id, "crm-456"))
+			require.NoError(t, c.UpdateExternalKey(b.Id, "crm-123"))
+			found, err = c.GetByExternalKey("crm-123")
+			require.NoError(t, err)
+			assert.Equal(t, b.Id, found.Id)
+			found, err = c.GetByExternalKey("crm-456")
+			require.NoError(t, err)
+			assert.Equal(t, a.Id, found.Id)
+		})
+	}
+}
+
+func TestExternalKeyRepeating(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	a, count, err := c.Create(Event{OwnerId: 1, ExternalKey: "crm-123", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Equal(t, "crm-123", a.ExternalKey)
+
+	// only the first event of the series has the key
+	events, err := c.Query(Query{ParentIds: []int64{*a.ParentId}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, "", events[1].ExternalKey)
+
+	_, err = c.CreateBatch([]Event{
+		{OwnerId: 1, ExternalKey: "crm-456", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC"},
+		{OwnerId: 1, ExternalKey: "crm-456", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC"},
+	})
+	assert.Error(t, err)
+	found, err := c.GetByExternalKey("crm-456")
+	require.NoError(t, err)
+	assert.Nil(t, found, "none of the batch is created")
+}
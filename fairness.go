@@ -0,0 +1,148 @@
+package cali
+
+import (
+	"sort"
+	"time"
+)
+
+// FairnessFalloff is how far a meeting can reach outside of a participant's working hours
+// before it is scored as unreasonable for them
+const FairnessFalloff = 4 * time.Hour
+
+// NightEndTime is the HH:MM value when the night ends, where a meeting that is any part of
+// the time between midnight and NightEndTime is never reasonable (no 3am meetings)
+const NightEndTime = "06:00"
+
+// MeetingParticipant is a user and the working hours in their home zone
+type MeetingParticipant struct {
+	UserId       int64        `json:"userId"`
+	WorkingHours WorkingHours `json:"workingHours"`
+}
+
+// Fairness is how reasonable a meeting time is for each of the participants, where a
+// score is from 0 (unreasonable) to 1 (completely within the working hours)
+type Fairness struct {
+	// Score is the average score of the participants
+	Score float64 `json:"score"`
+	// Worst is the lowest score of the participants
+	Worst float64 `json:"worst"`
+	// Scores is the score of each participant by user id
+	Scores map[int64]float64 `json:"scores"`
+}
+
+// Reasonableness scores a meeting time from 0 to 1 for the working hours. A meeting that is
+// completely within the working hours on a working day is 1, and the score falls to 0 as the
+// meeting reaches FairnessFalloff outside of the working hours. A meeting on a day that isn't
+// a working day or during the night is 0.
+func (w WorkingHours) Reasonableness(start, end time.Time) (float64, error) {
+	if err := ValidateWorkingHours(w); err != nil {
+		return 0, err
+	}
+	loc, _ := time.LoadLocation(w.Zone)
+	start, end = start.In(loc), end.In(loc)
+	if !w.DayOfWeek.HasFlag(dayOfWeekFromWeekday(start.Weekday())) {
+		return 0, nil
+	}
+	nightEnd, _ := parseTime(NightEndTime)
+	for day := dayStart(start); day.Before(end); day = day.AddDate(0, 0, 1) {
+		night := TimeWindow{Start: day, End: withClock(day, nightEnd)}
+		if overlapsAny(TimeWindow{Start: start, End: end}, []TimeWindow{night}) {
+			return 0, nil
+		}
+	}
+
+	workStartTime, _ := parseTime(w.StartTime)
+	workEndTime, _ := parseTime(w.EndTime)
+	workStart, workEnd := withClock(dayStart(start), workStartTime), withClock(dayStart(start), workEndTime)
+	outside := max(workStart.Sub(start), end.Sub(workEnd), 0)
+	return max(1-float64(outside)/float64(FairnessFalloff), 0), nil
+}
+
+// dayStart gets midnight of the day of the time in the time's location
+func dayStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// withClock sets the hour, minute, and second of the day to the ones of the clock
+func withClock(day time.Time, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, day.Location())
+}
+
+// MeetingFairness scores a meeting time for each of the participants (see Reasonableness)
+func MeetingFairness(start, end time.Time, participants []MeetingParticipant) (*Fairness, error) {
+	f := &Fairness{Worst: 1, Scores: map[int64]float64{}}
+	if len(participants) == 0 {
+		f.Score = 1
+		return f, nil
+	}
+	for _, p := range participants {
+		score, err := p.WorkingHours.Reasonableness(start, end)
+		if err != nil {
+			return nil, err
+		}
+		f.Scores[p.UserId] = score
+		f.Score += score
+		f.Worst = min(f.Worst, score)
+	}
+	f.Score /= float64(len(participants))
+	return f, nil
+}
+
+// Suggestion is a meeting time that every participant is free for
+type Suggestion struct {
+	TimeWindow
+	Fairness Fairness `json:"fairness"`
+}
+
+// SuggestTimes finds the meeting times of the duration that start every step in the window
+// where none of the participants are busy (see AvailabilityHeatmap). The times are ranked by
+// fairness so that no participant gets an unreasonable time: by the worst score, then by the
+// average score, and then by the start. Times that are unreasonable for any participant (a
+// worst score of 0) aren't suggested.
+func (c *Calendar) SuggestTimes(participants []MeetingParticipant, window TimeWindow, duration time.Duration, step time.Duration) ([]Suggestion, error) {
+	if duration <= 0 || step <= 0 || !window.End.After(window.Start) {
+		return nil, ErrorInvalidSuggestion
+	}
+	if window.End.Sub(window.Start)/step > MaxHeatmapSlots {
+		return nil, ErrorInvalidSuggestion
+	}
+	busy := map[int64][]TimeWindow{}
+	for _, p := range participants {
+		spans, err := c.busySpans(p.UserId, window)
+		if err != nil {
+			return nil, err
+		}
+		busy[p.UserId] = spans
+	}
+
+	var suggestions []Suggestion
+	for start := window.Start; !start.Add(duration).After(window.End); start = start.Add(step) {
+		candidate := TimeWindow{Start: start, End: start.Add(duration)}
+		free := true
+		for _, p := range participants {
+			if overlapsAny(candidate, busy[p.UserId]) {
+				free = false
+				break
+			}
+		}
+		if !free {
+			continue
+		}
+		fairness, err := MeetingFairness(candidate.Start, candidate.End, participants)
+		if err != nil {
+			return nil, err
+		}
+		if fairness.Worst == 0 {
+			continue
+		}
+		suggestions = append(suggestions, Suggestion{TimeWindow: candidate, Fairness: *fairness})
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i].Fairness, suggestions[j].Fairness
+		if a.Worst != b.Worst {
+			return a.Worst > b.Worst
+		}
+		return a.Score > b.Score
+	})
+	return suggestions, nil
+}
@@ -0,0 +1,75 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReasonableness(t *testing.T) {
+	w := WorkingHours{Zone: den, DayOfWeek: DayOfWeekWeekdays, StartTime: "09:00", EndTime: "17:00"}
+	loc, err := time.LoadLocation(den)
+	require.NoError(t, err)
+	testCases := []struct {
+		name  string
+		start time.Time
+		hours float64
+		score float64
+	}{
+		{name: "within working hours", start: time.Date(2008, 1, 2, 10, 0, 0, 0, loc), hours: 1, score: 1},
+		{name: "an hour early", start: time.Date(2008, 1, 2, 8, 0, 0, 0, loc), hours: 1, score: 0.75},
+		{name: "ends two hours late", start: time.Date(2008, 1, 2, 18, 0, 0, 0, loc), hours: 1, score: 0.5},
+		{name: "far outside", start: time.Date(2008, 1, 2, 22, 0, 0, 0, loc), hours: 1, score: 0},
+		{name: "in another zone", start: time.Date(2008, 1, 2, 17, 0, 0, 0, time.UTC), hours: 1, score: 1},
+		{name: "during the night", start: time.Date(2008, 1, 2, 5, 30, 0, 0, loc), hours: 1, score: 0},
+		{name: "into the next night", start: time.Date(2008, 1, 2, 16, 0, 0, 0, loc), hours: 9, score: 0},
+		{name: "weekend", start: time.Date(2008, 1, 5, 10, 0, 0, 0, loc), hours: 1, score: 0},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			score, err := w.Reasonableness(tc.start, tc.start.Add(time.Duration(tc.hours*float64(time.Hour))))
+			require.NoError(t, err)
+			assert.InDelta(t, tc.score, score, 0.0001)
+		})
+	}
+
+	_, err = WorkingHours{Zone: "Nowhere", DayOfWeek: DayOfWeekWeekdays, StartTime: "09:00", EndTime: "17:00"}.Reasonableness(time.Now(), time.Now())
+	assert.Equal(t, ErrorInvalidZone, err)
+}
+
+func TestSuggestTimes(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	participants := []MeetingParticipant{
+		{UserId: 1, WorkingHours: WorkingHours{Zone: den, DayOfWeek: DayOfWeekWeekdays, StartTime: "09:00", EndTime: "17:00"}},
+		{UserId: 2, WorkingHours: WorkingHours{Zone: "Europe/Berlin", DayOfWeek: DayOfWeekWeekdays, StartTime: "09:00", EndTime: "17:00"}},
+	}
+	// user 2 is busy 15:00-15:30 UTC (16:00 in Berlin)
+	_, _, err := c.Create(Event{OwnerId: 2, Zone: "Europe/Berlin", StartDay: "2008-01-02", StartTime: "16:00", EndDay: "2008-01-02", EndTime: "16:30"})
+	require.NoError(t, err)
+
+	window := TimeWindow{Start: time.Date(2008, 1, 2, 0, 0, 0, 0, time.UTC), End: time.Date(2008, 1, 3, 0, 0, 0, 0, time.UTC)}
+	suggestions, err := c.SuggestTimes(participants, window, 30*time.Minute, 30*time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, suggestions)
+
+	// 15:30 UTC is 08:30 in Denver and 16:30 in Berlin, and 16:00 UTC is 09:00 in Denver and 17:00 in Berlin
+	assert.Equal(t, time.Date(2008, 1, 2, 15, 30, 0, 0, time.UTC), suggestions[0].Start)
+	assert.InDelta(t, 0.875, suggestions[0].Fairness.Worst, 0.0001)
+	assert.InDelta(t, 1, suggestions[0].Fairness.Scores[2], 0.0001)
+	assert.Equal(t, time.Date(2008, 1, 2, 16, 0, 0, 0, time.UTC), suggestions[1].Start)
+	for i, s := range suggestions {
+		assert.NotEqual(t, time.Date(2008, 1, 2, 15, 0, 0, 0, time.UTC), s.Start, "user 2 is busy")
+		assert.Greater(t, s.Fairness.Worst, 0.0)
+		if i > 0 {
+			assert.LessOrEqual(t, s.Fairness.Worst, suggestions[i-1].Fairness.Worst)
+		}
+	}
+
+	_, err = c.SuggestTimes(participants, window, 0, time.Minute)
+	assert.Equal(t, ErrorInvalidSuggestion, err)
+}
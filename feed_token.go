@@ -0,0 +1,177 @@
+package cali
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// FeedScope limits what the ical feed of a token shows
+type FeedScope struct {
+	// CalendarIds are the calendars that are in the feed, where empty is every calendar
+	CalendarIds []int64 `json:"calendarIds"`
+	// BusyOnly hides the title, description, links, and location of the events, so the feed only
+	// shows when the user is busy
+	BusyOnly bool `json:"busyOnly"`
+}
+
+// FeedToken gives read access to the ical feed of a user without logging in, like in a webcal
+// URL. Only the hash of the token is saved, so the token itself is only known when it is issued.
+type FeedToken struct {
+	// Id is the unique id for this token, which is used to rotate or revoke it
+	Id int64 `json:"id"`
+	// UserId is the user whose events are in the feed
+	UserId int64 `json:"userId"`
+	// Hash is the SHA-256 hash of the token (see HashFeedToken)
+	Hash string `json:"-"`
+	// Scope is what the feed shows
+	Scope FeedScope `json:"scope"`
+	// Revoked is true once the token can't be used anymore
+	Revoked bool `json:"revoked"`
+	// Created is a timestamp for when the token was issued
+	Created time.Time `json:"created"`
+}
+
+// FeedTokenStore is an optional interface for a data store that can save feed tokens
+type FeedTokenStore interface {
+	// AddFeedToken saves the token with a new Id and handles setting the Created field
+	AddFeedToken(t FeedToken) (*FeedToken, error)
+	// GetFeedToken retrieves the token with the id, or nil if there isn't one
+	GetFeedToken(id int64) (*FeedToken, error)
+	// GetFeedTokenByHash retrieves the token with the hash, or nil if there isn't one
+	GetFeedTokenByHash(hash string) (*FeedToken, error)
+	// GetFeedTokens retrieves all of the tokens of the user, including the revoked ones
+	GetFeedTokens(userId int64) ([]*FeedToken, error)
+	// RevokeFeedToken sets Revoked on the token
+	RevokeFeedToken(id int64) error
+}
+
+// HashFeedToken gets the hash of the token that is saved in the data store
+func HashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueFeedToken makes a new feed token for the user with the scope. The token is returned
+// along with its record, and it can't be found again since only its hash is saved.
+func (c *Calendar) IssueFeedToken(userId int64, scope FeedScope) (string, *FeedToken, error) {
+	store, ok := capability[FeedTokenStore](c.dataStore)
+	if !ok {
+		return "", nil, ErrorFeedTokensNotSupported
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	t, err := store.AddFeedToken(FeedToken{UserId: userId, Hash: HashFeedToken(token), Scope: scope})
+	if err != nil {
+		return "", nil, err
+	}
+	return token, t, nil
+}
+
+// RotateFeedToken revokes the token and issues a new one for the same user and scope
+func (c *Calendar) RotateFeedToken(id int64) (string, *FeedToken, error) {
+	store, ok := capability[FeedTokenStore](c.dataStore)
+	if !ok {
+		return "", nil, ErrorFeedTokensNotSupported
+	}
+	old, err := store.GetFeedToken(id)
+	if err != nil {
+		return "", nil, err
+	}
+	if old == nil || old.Revoked {
+		return "", nil, ErrorInvalidFeedToken
+	}
+	if err := store.RevokeFeedToken(id); err != nil {
+		return "", nil, err
+	}
+	return c.IssueFeedToken(old.UserId, old.Scope)
+}
+
+// RevokeFeedToken makes the token unusable, like when its URL was leaked
+func (c *Calendar) RevokeFeedToken(id int64) error {
+	store, ok := capability[FeedTokenStore](c.dataStore)
+	if !ok {
+		return ErrorFeedTokensNotSupported
+	}
+	return store.RevokeFeedToken(id)
+}
+
+// GetFeedTokens gets all of the feed tokens of the user, including the revoked ones
+func (c *Calendar) GetFeedTokens(userId int64) ([]*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](c.dataStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.GetFeedTokens(userId)
+}
+
+// FeedICal exports the events of the user of the token that match the query and the scope of
+// the token to an ical calendar. The events are read as the user (see AsUser), so the
+// Authorizer of the calendar decides what is in the feed, and the UserIds and CalendarIds of
+// the query can only narrow what the token can see.
+func (c *Calendar) FeedICal(token string, q Query) (string, error) {
+	store, ok := capability[FeedTokenStore](c.dataStore)
+	if !ok {
+		return "", ErrorFeedTokensNotSupported
+	}
+	t, err := store.GetFeedTokenByHash(HashFeedToken(token))
+	if err != nil {
+		return "", err
+	}
+	if t == nil || t.Revoked {
+		return "", ErrorInvalidFeedToken
+	}
+	q.UserIds = []int64{t.UserId}
+	if len(t.Scope.CalendarIds) > 0 {
+		q.CalendarIds = intersectIds(t.Scope.CalendarIds, q.CalendarIds)
+		if len(q.CalendarIds) == 0 {
+			return marshallCalendarToICal(nil, iCalOptions{properties: c.iCalProperties, uidGenerator: c.uidGenerator})
+		}
+	}
+	scoped := c.AsUser(t.UserId)
+	events, err := scoped.Query(q)
+	if err != nil {
+		return "", err
+	}
+	if t.Scope.BusyOnly {
+		busy := make([]*Event, len(events))
+		for i, e := range events {
+			copied := *e
+			copied.Title = "Busy"
+			copied.Description, copied.Url, copied.Location, copied.Geo = nil, nil, nil, nil
+			copied.Links = nil
+			copied.UserData = nil
+			busy[i] = &copied
+		}
+		return marshallCalendarToICal(busy, iCalOptions{properties: c.iCalProperties, uidGenerator: c.uidGenerator})
+	}
+	options, err := scoped.iCalOptions(events)
+	if err != nil {
+		return "", err
+	}
+	return marshallCalendarToICal(events, options)
+}
+
+// intersectIds gets the allowed ids that are also wanted, or all of the allowed ids if
+// nothing in particular is wanted
+func intersectIds(allowed []int64, wanted []int64) []int64 {
+	if len(wanted) == 0 {
+		return allowed
+	}
+	ok := map[int64]bool{}
+	for _, id := range allowed {
+		ok[id] = true
+	}
+	var result []int64
+	for _, id := range wanted {
+		if ok[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
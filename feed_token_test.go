@@ -0,0 +1,90 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedTokens(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store)
+			_, _, err := c.Create(Event{OwnerId: 1, CalendarId: 10, Title: "Standup", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+			require.NoError(t, err)
+			_, _, err = c.Create(Event{OwnerId: 1, CalendarId: 20, Title: "Doctor", StartDay: "2008-01-02", EndDay: "2008-01-02", IsAllDay: true, Zone: "UTC"})
+			require.NoError(t, err)
+			_, _, err = c.Create(Event{OwnerId: 2, CalendarId: 10, Title: "Someone else's", StartDay: "2008-01-03", EndDay: "2008-01-03", IsAllDay: true, Zone: "UTC"})
+			require.NoError(t, err)
+
+			token, issued, err := c.IssueFeedToken(1, FeedScope{CalendarIds: []int64{10}})
+			require.NoError(t, err)
+			assert.NotEmpty(t, token)
+			assert.Equal(t, HashFeedToken(token), issued.Hash)
+
+			ical, err := c.FeedICal(token, Query{})
+			require.NoError(t, err)
+			assert.Contains(t, ical, "SUMMARY:Standup")
+			assert.NotContains(t, ical, "Doctor", "other calendars are out of scope")
+			assert.NotContains(t, ical, "Someone else's", "only the events of the user are in the feed")
+			ical, err = c.FeedICal(token, Query{CalendarIds: []int64{20}})
+			require.NoError(t, err)
+			assert.NotContains(t, ical, "Doctor", "the query can't widen the scope")
+
+			rotated, replacement, err := c.RotateFeedToken(issued.Id)
+			require.NoError(t, err)
+			assert.Equal(t, issued.Scope, replacement.Scope)
+			_, err = c.FeedICal(token, Query{})
+			assert.Equal(t, ErrorInvalidFeedToken, err)
+			_, _, err = c.RotateFeedToken(issued.Id)
+			assert.Equal(t, ErrorInvalidFeedToken, err)
+			_, err = c.FeedICal(rotated, Query{})
+			require.NoError(t, err)
+
+			require.NoError(t, c.RevokeFeedToken(replacement.Id))
+			_, err = c.FeedICal(rotated, Query{})
+			assert.Equal(t, ErrorInvalidFeedToken, err)
+			_, err = c.FeedICal("unknown", Query{})
+			assert.Equal(t, ErrorInvalidFeedToken, err)
+
+			tokens, err := c.GetFeedTokens(1)
+			require.NoError(t, err)
+			require.Len(t, tokens, 2)
+			assert.True(t, tokens[0].Revoked)
+			assert.True(t, tokens[1].Revoked)
+		})
+	}
+}
+
+func TestFeedTokenBusyOnly(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	location := "Clinic"
+	_, _, err := c.Create(Event{OwnerId: 1, Title: "Doctor", Location: &location, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	token, _, err := c.IssueFeedToken(1, FeedScope{BusyOnly: true})
+	require.NoError(t, err)
+	ical, err := c.FeedICal(token, Query{})
+	require.NoError(t, err)
+	assert.Contains(t, ical, "SUMMARY:Busy")
+	assert.NotContains(t, ical, "Doctor")
+	assert.NotContains(t, ical, "Clinic")
+
+	_, _, err = NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}).IssueFeedToken(1, FeedScope{})
+	assert.Equal(t, ErrorFeedTokensNotSupported, err)
+}
@@ -0,0 +1,136 @@
+package cali
+
+import (
+	"time"
+)
+
+// Follower is a user that gets the change notifications of an event without being invited
+// to it, like for a public or team event. Followers aren't in the invites of the event.
+type Follower struct {
+	// EventId is the event that is followed
+	EventId int64 `json:"eventId"`
+	// UserId is the user that follows the event
+	UserId int64 `json:"userId"`
+	// Created is a timestamp for when the user started following the event
+	Created time.Time `json:"created"`
+}
+
+// FollowerStore is an optional interface for a data store that can save the followers of events
+type FollowerStore interface {
+	// AddFollower saves the follower and handles setting the Created field. If the user
+	// already follows the event it returns the existing follower
+	AddFollower(f Follower) (*Follower, error)
+	// RemoveFollower deletes the follower. If the user doesn't follow the event it returns nil
+	RemoveFollower(eventId, userId int64) error
+	// GetFollowers retrieves all of the followers of the event
+	GetFollowers(eventId int64) ([]*Follower, error)
+	// GetFollowedEventIds retrieves the ids of all of the events that the user follows
+	GetFollowedEventIds(userId int64) ([]int64, error)
+}
+
+// Follow makes the user a follower of the event, so they get its change and canceled
+// notifications. The event has to be readable by the calendar (see Authorizer), and it has
+// to be public unless the user owns it or has an invitation that isn't declined or revoked,
+// otherwise it returns ErrorNotAuthorized.
+func (c *Calendar) Follow(eventId int64, userId int64) (*Follower, error) {
+	store, ok := capability[FollowerStore](c.dataStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	e, err := c.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	if e.Visibility != VisibilityPublic && e.OwnerId != userId {
+		invite, err := c.GetInvitation(eventId, userId)
+		if err != nil {
+			return nil, err
+		}
+		if invite == nil || invite.Status == InviteStatusDeclined || invite.Status == InviteStatusRevoked {
+			return nil, ErrorNotAuthorized
+		}
+	}
+	return store.AddFollower(Follower{EventId: eventId, UserId: userId})
+}
+
+// Unfollow stops the user from following the event
+func (c *Calendar) Unfollow(eventId int64, userId int64) error {
+	store, ok := capability[FollowerStore](c.dataStore)
+	if !ok {
+		return ErrorFollowersNotSupported
+	}
+	return store.RemoveFollower(eventId, userId)
+}
+
+// GetFollowers gets all of the followers of the event
+func (c *Calendar) GetFollowers(eventId int64) ([]*Follower, error) {
+	store, ok := capability[FollowerStore](c.dataStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	return store.GetFollowers(eventId)
+}
+
+// FollowedEvents gets the events that the user follows that match the rest of the query
+func (c *Calendar) FollowedEvents(userId int64, q Query) ([]*Event, error) {
+	store, ok := capability[FollowerStore](c.dataStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	ids, err := store.GetFollowedEventIds(userId)
+	if err != nil {
+		return nil, err
+	}
+	if len(q.EventIds) > 0 {
+		allowed := map[int64]bool{}
+		for _, id := range q.EventIds {
+			allowed[id] = true
+		}
+		var both []int64
+		for _, id := range ids {
+			if allowed[id] {
+				both = append(both, id)
+			}
+		}
+		ids = both
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	q.EventIds = ids
+	return c.Query(q)
+}
+
+// notifyFollowers sends a copy of the notification to every follower of the event that isn't
+// invited to it (or its owner), with Follower set and no InviteStatus. Only the followers of
+// public events are notified, so that they stop getting the notifications of an event that
+// is no longer public.
+func (c *Calendar) notifyFollowers(n Notification, invites []*Invite) error {
+	store, ok := capability[FollowerStore](c.dataStore)
+	if !ok || n.Event.Visibility != VisibilityPublic {
+		return nil
+	}
+	followers, err := store.GetFollowers(n.Event.Id)
+	if err != nil {
+		return err
+	}
+	invited := map[int64]bool{n.Event.OwnerId: true}
+	for _, invite := range invites {
+		invited[invite.UserId] = true
+	}
+	for _, f := range followers {
+		if invited[f.UserId] {
+			continue
+		}
+		copied := n
+		copied.UserId = f.UserId
+		copied.Follower = true
+		if err := c.sendNotification(copied); err != nil {
+			return err
+		}
+	}
+	return nil
+}
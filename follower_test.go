@@ -0,0 +1,121 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollowers(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			sender := &testSender{}
+			c := NewCalendar(tc.store, WithNotificationSender(sender))
+			var events []*Event
+			for _, title := range []string{"All hands", "Launch party"} {
+				e, _, err := c.Create(Event{OwnerId: 1, Title: title, Visibility: VisibilityPublic, StartDay: "2008-01-01", StartTime: "09:00",
+					EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC"})
+				require.NoError(t, err)
+				events = append(events, e)
+			}
+			require.NoError(t, c.InviteUser(events[0].Id, 2, PermissionInvitee, RepeatEditTypeThis))
+			sender.sent = nil
+
+			follower, err := c.Follow(events[0].Id, 3)
+			require.NoError(t, err)
+			assert.Equal(t, events[0].Id, follower.EventId)
+			_, err = c.Follow(events[0].Id, 2)
+			require.NoError(t, err, "an invitee can follow too, but only gets one notification")
+			_, err = c.Follow(events[1].Id, 3)
+			require.NoError(t, err)
+			followers, err := c.GetFollowers(events[0].Id)
+			require.NoError(t, err)
+			assert.Len(t, followers, 2)
+
+			// followers aren't attendees
+			invites, err := c.getInvites(events[0].Id)
+			require.NoError(t, err)
+			for _, invite := range invites {
+				assert.NotEqual(t, int64(3), invite.UserId)
+			}
+
+			followed, err := c.FollowedEvents(3, Query{})
+			require.NoError(t, err)
+			assert.Len(t, followed, 2)
+			followed, err = c.FollowedEvents(3, Query{EventIds: []int64{events[1].Id}})
+			require.NoError(t, err)
+			require.Len(t, followed, 1)
+			assert.Equal(t, "Launch party", followed[0].Title)
+
+			require.NoError(t, c.UpdateTime(events[0].Id, "11:00", "12:00", RepeatEditTypeThis))
+			require.Len(t, sender.sent, 2)
+			assert.Equal(t, int64(2), sender.sent[0].UserId)
+			assert.False(t, sender.sent[0].Follower)
+			assert.Equal(t, int64(3), sender.sent[1].UserId)
+			assert.True(t, sender.sent[1].Follower)
+			assert.Equal(t, NotificationTypeEventChanged, sender.sent[1].Type)
+			assert.NotEmpty(t, sender.sent[1].Changes)
+
+			require.NoError(t, c.Unfollow(events[0].Id, 3))
+			sender.sent = nil
+			require.NoError(t, c.Cancel(events[0].Id, RepeatEditTypeThis))
+			require.Len(t, sender.sent, 1)
+			assert.Equal(t, int64(2), sender.sent[0].UserId)
+		})
+	}
+}
+
+func TestFollowersNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	_, err := c.Follow(1, 2)
+	assert.Equal(t, ErrorFollowersNotSupported, err)
+	_, err = c.FollowedEvents(2, Query{})
+	assert.Equal(t, ErrorFollowersNotSupported, err)
+}
+
+func TestFollowPrivate(t *testing.T) {
+	sender := &testSender{}
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender))
+	e, _, err := c.Create(Event{OwnerId: 1, Title: "Review", Visibility: VisibilityPrivate, StartDay: "2008-01-01", StartTime: "09:00",
+		EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC"})
+	require.NoError(t, err)
+
+	_, err = c.Follow(e.Id, 3)
+	assert.Equal(t, ErrorNotAuthorized, err, "a private event can't be followed without an invitation")
+	_, err = c.Follow(e.Id, 1)
+	require.NoError(t, err, "the owner can follow")
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	_, err = c.Follow(e.Id, 2)
+	require.NoError(t, err)
+	require.NoError(t, c.RevokeInvitation(e.Id, 2, RepeatEditTypeThis))
+	require.NoError(t, c.Unfollow(e.Id, 2))
+	_, err = c.Follow(e.Id, 2)
+	assert.Equal(t, ErrorNotAuthorized, err, "a revoked invitation can't follow")
+
+	// followers stop getting notifications when a public event becomes private
+	public, _, err := c.Create(Event{OwnerId: 1, Title: "All hands", Visibility: VisibilityPublic, StartDay: "2008-01-01", StartTime: "09:00",
+		EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC"})
+	require.NoError(t, err)
+	_, err = c.Follow(public.Id, 3)
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateVisibility(public.Id, VisibilityPrivate, RepeatEditTypeThis))
+	sender.sent = nil
+	require.NoError(t, c.UpdateTime(public.Id, "11:00", "12:00", RepeatEditTypeThis))
+	assert.Empty(t, sender.sent)
+}
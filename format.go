@@ -0,0 +1,242 @@
+package cali
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locale has the names and patterns needed to display days and times for a
+// specific language and region
+type Locale struct {
+	// Tag is the BCP 47 language tag like "en-US" or "fr"
+	Tag string
+	// Months are the abbreviated month names starting with January
+	Months [12]string
+	// Weekdays are the abbreviated day names starting with Sunday
+	Weekdays [7]string
+	// DayPattern is used to display a day without a year where {w} is
+	// the weekday, {d} is the day of the month, and {m} is the month
+	DayPattern string
+	// DayYearPattern is the same as DayPattern but can also contain {y}
+	DayYearPattern string
+	// Hour12 is true if times use a 12 hour clock with AM and PM
+	Hour12 bool
+	// AM is the suffix for morning times on a 12 hour clock
+	AM string
+	// PM is the suffix for afternoon and evening times on a 12 hour clock
+	PM string
+}
+
+var (
+	// LocaleEnUS is US English ("Mar 3, 9–10 AM")
+	LocaleEnUS = Locale{
+		Tag:            "en-US",
+		Months:         [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Weekdays:       [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		DayPattern:     "{m} {d}",
+		DayYearPattern: "{m} {d}, {y}",
+		Hour12:         true,
+		AM:             "AM",
+		PM:             "PM",
+	}
+	// LocaleEnGB is British English ("3 Mar, 09:00–10:00")
+	LocaleEnGB = Locale{
+		Tag:            "en-GB",
+		Months:         LocaleEnUS.Months,
+		Weekdays:       LocaleEnUS.Weekdays,
+		DayPattern:     "{d} {m}",
+		DayYearPattern: "{d} {m} {y}",
+	}
+	// LocaleFr is French ("3 mars, 09:00–10:00")
+	LocaleFr = Locale{
+		Tag:            "fr",
+		Months:         [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		Weekdays:       [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		DayPattern:     "{d} {m}",
+		DayYearPattern: "{d} {m} {y}",
+	}
+	// LocaleDe is German ("3. März, 09:00–10:00")
+	LocaleDe = Locale{
+		Tag:            "de",
+		Months:         [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		Weekdays:       [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		DayPattern:     "{d}. {m}",
+		DayYearPattern: "{d}. {m} {y}",
+	}
+	// LocaleEs is Spanish ("3 mar, 09:00–10:00")
+	LocaleEs = Locale{
+		Tag:            "es",
+		Months:         [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		Weekdays:       [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		DayPattern:     "{d} {m}",
+		DayYearPattern: "{d} {m} {y}",
+	}
+)
+
+var (
+	localesMu sync.RWMutex
+	locales   = map[string]Locale{
+		"en":    LocaleEnUS,
+		"en-us": LocaleEnUS,
+		"en-gb": LocaleEnGB,
+		"fr":    LocaleFr,
+		"de":    LocaleDe,
+		"es":    LocaleEs,
+	}
+)
+
+// RegisterLocale adds (or replaces) a locale that can be found with LookupLocale
+func RegisterLocale(l Locale) {
+	localesMu.Lock()
+	defer localesMu.Unlock()
+	locales[strings.ToLower(l.Tag)] = l
+}
+
+// LookupLocale finds a registered locale by its tag. If there is no exact match
+// then the language without the region is tried ("fr-CA" falls back to "fr").
+func LookupLocale(tag string) (Locale, bool) {
+	localesMu.RLock()
+	defer localesMu.RUnlock()
+	tag = strings.ToLower(strings.ReplaceAll(tag, "_", "-"))
+	if l, ok := locales[tag]; ok {
+		return l, true
+	}
+	if i := strings.Index(tag, "-"); i > 0 {
+		l, ok := locales[tag[:i]]
+		return l, ok
+	}
+	return Locale{}, false
+}
+
+// Formatter renders event days, times, and ranges for a locale in a specific zone
+type Formatter struct {
+	// Locale is used for the names and patterns
+	Locale Locale
+	// Location is the zone that timed events are converted to before display
+	Location *time.Location
+	// ShowZone adds the zone abbreviation to the end of timed ranges ("MST")
+	ShowZone bool
+	// ShowYear adds the year to each displayed day
+	ShowYear bool
+	// ShowWeekday adds the abbreviated weekday in front of each displayed day
+	ShowWeekday bool
+	// WeekStart is the first day of the week that the days of weekly repeats are listed from
+	WeekStart time.Weekday
+}
+
+// NewFormatter creates a formatter using a registered locale tag and a zone name
+func NewFormatter(localeTag string, zone string) (*Formatter, error) {
+	l, ok := LookupLocale(localeTag)
+	if !ok {
+		return nil, ErrorUnknownLocale
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, ErrorInvalidZone
+	}
+	return &Formatter{Locale: l, Location: loc}, nil
+}
+
+// Day renders the day portion of the time ("Mar 3" or "3 mars")
+func (f Formatter) Day(t time.Time) string {
+	pattern := f.Locale.DayPattern
+	if f.ShowYear {
+		pattern = f.Locale.DayYearPattern
+	}
+	s := strings.NewReplacer(
+		"{d}", strconv.Itoa(t.Day()),
+		"{m}", f.Locale.Months[t.Month()-1],
+		"{y}", strconv.Itoa(t.Year()),
+		"{w}", f.Locale.Weekdays[t.Weekday()],
+	).Replace(pattern)
+	if f.ShowWeekday && !strings.Contains(pattern, "{w}") {
+		s = f.Locale.Weekdays[t.Weekday()] + " " + s
+	}
+	return s
+}
+
+// Time renders the time portion of the time ("9:30 AM" or "09:30")
+func (f Formatter) Time(t time.Time) string {
+	return f.clock(t, true)
+}
+
+// Range renders the whole span of the event in the formatter's zone. All day
+// events are never converted between zones since they cover whole days.
+//
+//	"Mar 3, 9–10 AM MST"
+//	"3 mars, 09:00–10:00"
+//	"Mar 3 – Mar 5"
+func (f Formatter) Range(e Event) (string, error) {
+	if e.IsAllDay {
+		start, err := time.Parse(time.DateOnly, e.StartDay)
+		if err != nil {
+			return "", ErrorInvalidStartDay
+		}
+		end, err := time.Parse(time.DateOnly, e.EndDay)
+		if err != nil {
+			return "", ErrorInvalidEndDay
+		}
+		if start.Equal(end) {
+			return f.Day(start), nil
+		}
+		return f.Day(start) + " – " + f.Day(end), nil
+	}
+
+	start, end, err := e.zonedSpan()
+	if err != nil {
+		return "", err
+	}
+	loc := f.Location
+	if loc == nil {
+		loc = start.Location()
+	}
+	start = start.In(loc)
+	end = end.In(loc)
+
+	var s string
+	if start.Format(time.DateOnly) == end.Format(time.DateOnly) {
+		s = fmt.Sprintf("%s, %s", f.Day(start), f.TimeRange(start, end))
+	} else {
+		s = fmt.Sprintf("%s, %s – %s, %s", f.Day(start), f.Time(start), f.Day(end), f.Time(end))
+	}
+	if f.ShowZone {
+		s += " " + start.Format("MST")
+	}
+	return s, nil
+}
+
+// TimeRange renders two times on the same day where a shared AM or PM is only
+// displayed once ("9–10 AM", "11 AM–1 PM", "09:00–10:00")
+func (f Formatter) TimeRange(start, end time.Time) string {
+	if f.Locale.Hour12 && (start.Hour() < 12) == (end.Hour() < 12) {
+		return f.clock(start, false) + "–" + f.clock(end, true)
+	}
+	return f.clock(start, true) + "–" + f.clock(end, true)
+}
+
+// clock renders the time with or without the AM/PM suffix. Times on the hour
+// leave off the minutes on a 12 hour clock.
+func (f Formatter) clock(t time.Time, meridiem bool) string {
+	if !f.Locale.Hour12 {
+		return t.Format(TimeFormat)
+	}
+	h := t.Hour() % 12
+	if h == 0 {
+		h = 12
+	}
+	s := strconv.Itoa(h)
+	if t.Minute() != 0 {
+		s += fmt.Sprintf(":%02d", t.Minute())
+	}
+	if meridiem {
+		if t.Hour() < 12 {
+			s += " " + f.Locale.AM
+		} else {
+			s += " " + f.Locale.PM
+		}
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatterRange(t *testing.T) {
+	testCases := []struct {
+		name     string
+		locale   string
+		zone     string
+		showZone bool
+		in       Event
+		out      string
+	}{
+		{
+			name:     "english with zone",
+			locale:   "en-US",
+			zone:     den,
+			showZone: true,
+			in:       Event{StartDay: "2008-03-03", StartTime: "09:00", EndDay: "2008-03-03", EndTime: "10:00", Zone: den},
+			out:      "Mar 3, 9–10 AM MST",
+		},
+		{
+			name:   "french",
+			locale: "fr-FR",
+			zone:   den,
+			in:     Event{StartDay: "2008-03-03", StartTime: "09:00", EndDay: "2008-03-03", EndTime: "10:00", Zone: den},
+			out:    "3 mars, 09:00–10:00",
+		},
+		{
+			name:   "converted to another zone",
+			locale: "en",
+			zone:   "America/New_York",
+			in:     Event{StartDay: "2008-03-03", StartTime: "10:30", EndDay: "2008-03-03", EndTime: "11:00", Zone: den},
+			out:    "Mar 3, 12:30–1 PM",
+		},
+		{
+			name:   "morning to afternoon",
+			locale: "en",
+			zone:   den,
+			in:     Event{StartDay: "2008-03-03", StartTime: "11:00", EndDay: "2008-03-03", EndTime: "13:15", Zone: den},
+			out:    "Mar 3, 11 AM–1:15 PM",
+		},
+		{
+			name:   "multiple days",
+			locale: "de",
+			zone:   den,
+			in:     Event{StartDay: "2008-03-03", StartTime: "22:00", EndDay: "2008-03-04", EndTime: "02:00", Zone: den},
+			out:    "3. März, 22:00 – 4. März, 02:00",
+		},
+		{
+			name:   "all day",
+			locale: "en-GB",
+			zone:   "Asia/Tokyo",
+			in:     Event{StartDay: "2008-03-03", EndDay: "2008-03-05", IsAllDay: true, Zone: den},
+			out:    "3 Mar – 5 Mar",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			f, err := NewFormatter(tc.locale, tc.zone)
+			require.NoError(t, err)
+			f.ShowZone = tc.showZone
+			out, err := f.Range(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, out)
+		})
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	_, err := NewFormatter("xx", "UTC")
+	require.Equal(t, ErrorUnknownLocale, err)
+
+	RegisterLocale(Locale{Tag: "xx", DayPattern: "{d}/{m}"})
+	l, ok := LookupLocale("XX_YY")
+	require.True(t, ok)
+	assert.Equal(t, "xx", l.Tag)
+}
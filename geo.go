@@ -0,0 +1,70 @@
+package cali
+
+import (
+	"math"
+)
+
+// earthRadius is the mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// GeoPoint is a latitude and longitude in degrees
+type GeoPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Valid returns true if the latitude is between -90 and 90 and the longitude is between -180 and 180
+func (p GeoPoint) Valid() bool {
+	return p.Latitude >= -90 && p.Latitude <= 90 && p.Longitude >= -180 && p.Longitude <= 180
+}
+
+// DistanceTo gets the great-circle distance in meters to the other point
+func (p GeoPoint) DistanceTo(other GeoPoint) float64 {
+	lat1 := p.Latitude * math.Pi / 180
+	lat2 := other.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (other.Longitude - p.Longitude) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// GeoPointStore is an optional interface for a data store that can change the geo point of
+// events (see UpdateGeo)
+type GeoPointStore interface {
+	// SetGeo updates the event with the latitude and longitude of the location
+	SetGeo(eventId int64, geo *GeoPoint) error
+}
+
+// GeoRadius is a circle around a center point
+type GeoRadius struct {
+	// Center is the middle of the circle
+	Center GeoPoint
+	// Distance is the radius of the circle in meters
+	Distance float64
+}
+
+// Contains returns true if the point is within the circle, where a nil point is never in the circle
+func (r GeoRadius) Contains(p *GeoPoint) bool {
+	return p != nil && r.Center.DistanceTo(*p) <= r.Distance
+}
+
+// queryNear queries the data store and filters the results by the Near field
+// of the query if the data store can't do it itself
+func (c *Calendar) queryNear(q Query) ([]*Event, error) {
+	if store, ok := capability[GeoIndexStore](c.dataStore); q.Near == nil || (ok && store.HasGeoIndex()) {
+		return c.dataStore.Query(q)
+	}
+	near := *q.Near
+	q.Near = nil
+	events, err := c.dataStore.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Event
+	for _, e := range events {
+		if near.Contains(e.Geo) {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noGeoIndexStore hides the geo index of the in memory data store
+type noGeoIndexStore struct {
+	*InMemoryDataStore
+}
+
+func (s noGeoIndexStore) HasGeoIndex() bool {
+	return false
+}
+
+func TestGeoPointDistance(t *testing.T) {
+	denver := GeoPoint{Latitude: 39.7392, Longitude: -104.9903}
+	boulder := GeoPoint{Latitude: 40.0150, Longitude: -105.2705}
+	assert.InDelta(t, 38900, denver.DistanceTo(boulder), 500)
+	assert.Equal(t, float64(0), denver.DistanceTo(denver))
+	assert.False(t, GeoPoint{Latitude: 91}.Valid())
+	assert.False(t, GeoPoint{Longitude: -181}.Valid())
+}
+
+func TestQueryNear(t *testing.T) {
+	for _, d := range []DataStore{&InMemoryDataStore{}, noGeoIndexStore{&InMemoryDataStore{}}} {
+		c := NewCalendar(d)
+		create := func(title string, geo *GeoPoint) *Event {
+			e, _, err := c.Create(Event{Title: title, Geo: geo, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+			require.NoError(t, err)
+			return e
+		}
+		create("denver", &GeoPoint{Latitude: 39.7392, Longitude: -104.9903})
+		create("boulder", &GeoPoint{Latitude: 40.0150, Longitude: -105.2705})
+		create("nowhere", nil)
+		e := create("moved", &GeoPoint{Latitude: 0, Longitude: 0})
+
+		events, err := c.Query(Query{Near: &GeoRadius{Center: GeoPoint{Latitude: 39.75, Longitude: -105}, Distance: 10000}})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.Equal(t, "denver", events[0].Title)
+
+		require.NoError(t, c.UpdateGeo(e.Id, &GeoPoint{Latitude: 40.0, Longitude: -105.2}, RepeatEditTypeThis))
+		events, err = c.Query(Query{Near: &GeoRadius{Center: GeoPoint{Latitude: 39.75, Longitude: -105}, Distance: 50000}})
+		require.NoError(t, err)
+		assert.Len(t, events, 3)
+
+		assert.Equal(t, ErrorInvalidGeo, c.UpdateGeo(e.Id, &GeoPoint{Latitude: 100}, RepeatEditTypeThis))
+	}
+}
@@ -0,0 +1,67 @@
+package cali
+
+// BatchGetStore is an optional interface for a data store that can get many events by id in a
+// single round trip. Data stores without it are asked with a Query of the EventIds instead.
+type BatchGetStore interface {
+	// GetMany retrieves the events in the same order as the ids, with nil for the ids that
+	// don't have an event
+	GetMany(eventIds []int64) ([]*Event, error)
+}
+
+// GetMany grabs the events by id in a single call to the data store, for resolving a page of
+// ids from search, notifications, or a sync. The results are in the same order as the ids, with
+// nil for the ids that don't have an event (or that can't be read, see WithAuthorizer).
+func (c *Calendar) GetMany(eventIds []int64) ([]*Event, error) {
+	events, err := getMany(c.dataStore, eventIds)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]*Event, 0, len(events))
+	for _, e := range events {
+		if e != nil {
+			found = append(found, e)
+		}
+	}
+	if found, err = c.authorizedEvents(found); err != nil {
+		return nil, err
+	}
+	if found, err = c.withSeries(found); err != nil {
+		return nil, err
+	}
+	if found, err = c.withReactions(found); err != nil {
+		return nil, err
+	}
+	byId := make(map[int64]*Event, len(found))
+	for _, e := range c.withDisplay(found) {
+		byId[e.Id] = e
+	}
+	result := make([]*Event, len(eventIds))
+	for i, id := range eventIds {
+		result[i] = byId[id]
+	}
+	return result, nil
+}
+
+// getMany gets the events with GetMany if the data store supports it, otherwise with a
+// Query of the ids, and puts them in the same order as the ids
+func getMany(store DataStore, eventIds []int64) ([]*Event, error) {
+	if len(eventIds) == 0 {
+		return []*Event{}, nil
+	}
+	if batch, ok := capability[BatchGetStore](store); ok {
+		return batch.GetMany(eventIds)
+	}
+	events, err := store.Query(Query{EventIds: eventIds})
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[int64]*Event, len(events))
+	for _, e := range events {
+		byId[e.Id] = e
+	}
+	result := make([]*Event, len(eventIds))
+	for i, id := range eventIds {
+		result[i] = byId[id]
+	}
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMany(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	stores := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "without GetMany", store: struct{ DataStore }{&InMemoryDataStore{}}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range stores {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store)
+			var ids []int64
+			for _, title := range []string{"One", "Two", "Three"} {
+				e, _, err := c.Create(Event{OwnerId: 1, Title: title, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+				require.NoError(t, err)
+				ids = append(ids, e.Id)
+			}
+
+			events, err := c.GetMany([]int64{ids[2], 1000, ids[0], ids[1]})
+			require.NoError(t, err)
+			require.Len(t, events, 4)
+			assert.Equal(t, "Three", events[0].Title)
+			assert.Nil(t, events[1], "ids without an event are nil")
+			assert.Equal(t, "One", events[2].Title)
+			assert.Equal(t, "Two", events[3].Title)
+
+			events, err = c.GetMany(nil)
+			require.NoError(t, err)
+			assert.Empty(t, events)
+		})
+	}
+}
+
+func TestGetManyAuthorized(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithAuthorizer(PermissionAuthorizer{}))
+	mine, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	theirs, _, err := c.Create(Event{OwnerId: 2, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+
+	events, err := c.AsUser(1).GetMany([]int64{mine.Id, theirs.Id})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, mine.Id, events[0].Id)
+	assert.Nil(t, events[1])
+}
@@ -0,0 +1,110 @@
+package cali
+
+import (
+	"fmt"
+	"time"
+)
+
+// OffGridError is returned in strict mode (see WithTimeGranularity) when a time isn't on a
+// multiple of the granularity
+type OffGridError struct {
+	// Field is the JSON name of the event field with the time, like "startTime"
+	Field string
+	// Value is the HH:MM time that is off the grid
+	Value string
+	// Minutes is the granularity of the calendar
+	Minutes int64
+}
+
+func (e *OffGridError) Error() string {
+	return fmt.Sprintf("%s %s is not on a %d minute boundary", e.Field, e.Value, e.Minutes)
+}
+
+// WithTimeGranularity makes the times of events snap to multiples of the minutes (like 5, 15,
+// or 30), which must divide an hour evenly. The times given to Create, CreateBatch, UpdateTime,
+// and UpdateDayTime are rounded to the nearest multiple, or rejected with an *OffGridError if
+// strict is true.
+func WithTimeGranularity(minutes int64, strict bool) CalendarOption {
+	return func(c *Calendar) {
+		c.granularity = minutes
+		c.strictGranularity = strict
+	}
+}
+
+// ValidGranularity returns true if the minutes divide an hour evenly
+func ValidGranularity(minutes int64) bool {
+	return minutes > 0 && minutes <= 60 && 60%minutes == 0
+}
+
+// OnGrid returns true if the HH:MM time is on a multiple of the minutes
+func OnGrid(hourMin string, minutes int64) (bool, error) {
+	if !ValidGranularity(minutes) {
+		return false, ErrorInvalidGranularity
+	}
+	t, err := parseTime(hourMin)
+	if err != nil {
+		return false, err
+	}
+	return t.Second() == 0 && int64(t.Minute())%minutes == 0, nil
+}
+
+// RoundDayTime rounds the YYYY-MM-DD day and HH:MM time to the nearest multiple of the minutes,
+// where a time halfway between is rounded up and the day rolls over at midnight
+func RoundDayTime(day, hourMin string, minutes int64) (string, string, error) {
+	if !ValidGranularity(minutes) {
+		return "", "", ErrorInvalidGranularity
+	}
+	t, err := parseDayTime(day, hourMin)
+	if err != nil {
+		return "", "", err
+	}
+	t = t.Add(time.Duration(minutes) * time.Minute / 2).Truncate(time.Duration(minutes) * time.Minute)
+	return t.Format(time.DateOnly), t.Format(TimeFormat), nil
+}
+
+// RoundTime rounds the HH:MM time to the nearest multiple of the minutes, where a time halfway
+// between is rounded up and times that round up to midnight become 00:00
+func RoundTime(hourMin string, minutes int64) (string, error) {
+	_, rounded, err := RoundDayTime("2000-01-01", hourMin, minutes)
+	return rounded, err
+}
+
+// snapDayTime rounds the day and time to the granularity of the calendar, or returns an
+// *OffGridError in strict mode. Empty and invalid values are left alone for validation to report.
+func (c *Calendar) snapDayTime(field, day, hourMin string) (string, string, error) {
+	if c.granularity == 0 || hourMin == "" {
+		return day, hourMin, nil
+	}
+	if !ValidGranularity(c.granularity) {
+		return day, hourMin, ErrorInvalidGranularity
+	}
+	if _, err := parseDayTime(day, hourMin); err != nil {
+		return day, hourMin, nil
+	}
+	if onGrid, _ := OnGrid(hourMin, c.granularity); onGrid {
+		return day, hourMin, nil
+	}
+	if c.strictGranularity {
+		return day, hourMin, &OffGridError{Field: field, Value: hourMin, Minutes: c.granularity}
+	}
+	return RoundDayTime(day, hourMin, c.granularity)
+}
+
+// snapEvent rounds the start and end of the event to the granularity of the calendar
+func (c *Calendar) snapEvent(e *Event) error {
+	if c.granularity == 0 || e.IsAllDay {
+		return nil
+	}
+	var err error
+	if e.StartDay, e.StartTime, err = c.snapDayTime("startTime", e.StartDay, e.StartTime); err != nil {
+		return err
+	}
+	e.EndDay, e.EndTime, err = c.snapDayTime("endTime", e.EndDay, e.EndTime)
+	return err
+}
+
+// snapTime rounds the time to the granularity of the calendar
+func (c *Calendar) snapTime(field, hourMin string) (string, error) {
+	_, rounded, err := c.snapDayTime(field, "2000-01-01", hourMin)
+	return rounded, err
+}
@@ -0,0 +1,84 @@
+package cali
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTime(t *testing.T) {
+	testCases := []struct {
+		name    string
+		hourMin string
+		minutes int64
+		out     string
+		err     error
+	}{
+		{name: "on the grid", hourMin: "09:15", minutes: 15, out: "09:15"},
+		{name: "round down", hourMin: "09:07", minutes: 15, out: "09:00"},
+		{name: "halfway rounds up", hourMin: "09:05", minutes: 10, out: "09:10"},
+		{name: "round up to the hour", hourMin: "09:46", minutes: 30, out: "10:00"},
+		{name: "round up to midnight", hourMin: "23:58", minutes: 5, out: "00:00"},
+		{name: "invalid granularity", hourMin: "09:00", minutes: 7, err: ErrorInvalidGranularity},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			out, err := RoundTime(tc.hourMin, tc.minutes)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.out, out)
+		})
+	}
+
+	day, hourMin, err := RoundDayTime("2008-12-31", "23:58", 15)
+	require.NoError(t, err)
+	assert.Equal(t, "2009-01-01", day)
+	assert.Equal(t, "00:00", hourMin)
+}
+
+func TestTimeGranularity(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithTimeGranularity(15, false))
+	a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:07", EndDay: "2008-01-01", EndTime: "23:53", Zone: "UTC"})
+	require.NoError(t, err)
+	assert.Equal(t, "09:00", a.StartTime)
+	assert.Equal(t, "2008-01-02", a.EndDay)
+	assert.Equal(t, "00:00", a.EndTime)
+
+	require.NoError(t, c.UpdateTime(a.Id, "10:08", "10:52", RepeatEditTypeThis))
+	e, err := c.Get(a.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "10:15", e.StartTime)
+	assert.Equal(t, "10:45", e.EndTime)
+
+	require.NoError(t, c.UpdateDayTime(a.Id, "2008-01-03", "11:01", "2008-01-03", "12:14", "UTC", false))
+	e, err = c.Get(a.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "11:00", e.StartTime)
+	assert.Equal(t, "12:15", e.EndTime)
+
+	strict := NewCalendar(&InMemoryDataStore{}, WithTimeGranularity(30, true))
+	_, _, err = strict.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:45", Zone: "UTC"})
+	var offGrid *OffGridError
+	require.True(t, errors.As(err, &offGrid))
+	assert.Equal(t, &OffGridError{Field: "endTime", Value: "09:45", Minutes: 30}, offGrid)
+
+	_, err = strict.CreateBatch([]Event{
+		{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:30", Zone: "UTC"},
+		{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:10", EndDay: "2008-01-01", EndTime: "09:30", Zone: "UTC"},
+	})
+	var batchErr *BatchError
+	require.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Errors, 1)
+	assert.IsType(t, &OffGridError{}, batchErr.Errors[1])
+
+	_, _, err = NewCalendar(&InMemoryDataStore{}, WithTimeGranularity(7, false)).Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:45", Zone: "UTC"})
+	assert.Equal(t, ErrorInvalidGranularity, err)
+}
@@ -0,0 +1,100 @@
+package cali
+
+import (
+	"time"
+)
+
+// MaxHeatmapSlots is the most slots that a heatmap can have
+const MaxHeatmapSlots = 10000
+
+// Heatmap is the number of available users in each slot of a time window, which can be
+// rendered as a "when2meet" style grid
+type Heatmap struct {
+	// Window is the time that the heatmap covers
+	Window TimeWindow `json:"window"`
+	// SlotSize is the length of each slot
+	SlotSize time.Duration `json:"slotSize"`
+	// UserIds are the users that the heatmap is for
+	UserIds []int64 `json:"userIds"`
+	// Slots are the slots of the window in order, where the last one ends at the end of the
+	// window and can be shorter than the slot size
+	Slots []HeatmapSlot `json:"slots"`
+}
+
+// HeatmapSlot is the availability of the users in a single slot
+type HeatmapSlot struct {
+	TimeWindow
+	// Available is the number of users that have no events in the slot
+	Available int `json:"available"`
+	// BusyUserIds are the users that have an event in the slot
+	BusyUserIds []int64 `json:"busyUserIds"`
+}
+
+// AvailabilityHeatmap splits the window into slots and counts the users that are available
+// in each slot. A user is busy in a slot if they have an active event (that they haven't
+// declined) that overlaps the slot, where all day events don't make a user busy. The events
+// are compared by their instants in their own zones, so users in different zones can be
+// compared with each other.
+func (c *Calendar) AvailabilityHeatmap(userIds []int64, window TimeWindow, slotSize time.Duration) (*Heatmap, error) {
+	if slotSize <= 0 || !window.End.After(window.Start) {
+		return nil, ErrorInvalidHeatmap
+	}
+	if (window.End.Sub(window.Start)+slotSize-1)/slotSize > MaxHeatmapSlots {
+		return nil, ErrorInvalidHeatmap
+	}
+	h := &Heatmap{Window: window, SlotSize: slotSize, UserIds: userIds}
+	for start := window.Start; start.Before(window.End); start = start.Add(slotSize) {
+		end := start.Add(slotSize)
+		if end.After(window.End) {
+			end = window.End
+		}
+		h.Slots = append(h.Slots, HeatmapSlot{TimeWindow: TimeWindow{Start: start, End: end}, Available: len(userIds)})
+	}
+
+	for _, userId := range userIds {
+		spans, err := c.busySpans(userId, window)
+		if err != nil {
+			return nil, err
+		}
+		for i, slot := range h.Slots {
+			if overlapsAny(slot.TimeWindow, spans) {
+				h.Slots[i].Available--
+				h.Slots[i].BusyUserIds = append(h.Slots[i].BusyUserIds, userId)
+			}
+		}
+	}
+	return h, nil
+}
+
+// busySpans gets the instants of the user's active events (other than all day events) that
+// are near the window, which are the events that the user hasn't declined
+func (c *Calendar) busySpans(userId int64, window TimeWindow) ([]TimeWindow, error) {
+	// queries compare wall clock times, so the query is a day wider to find events in other zones
+	queryStart, queryEnd := window.Start.AddDate(0, 0, -1), window.End.AddDate(0, 0, 1)
+	events, err := c.dataStore.Query(Query{Start: &queryStart, End: &queryEnd, UserIds: []int64{userId}, Statuses: []Status{StatusActive}})
+	if err != nil {
+		return nil, err
+	}
+	var spans []TimeWindow
+	for _, e := range events {
+		if e.IsAllDay {
+			continue
+		}
+		start, end, err := e.zonedSpan()
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, TimeWindow{Start: start, End: end})
+	}
+	return spans, nil
+}
+
+// overlapsAny returns true if the window overlaps any of the spans
+func overlapsAny(w TimeWindow, spans []TimeWindow) bool {
+	for _, span := range spans {
+		if span.Start.Before(w.End) && span.End.After(w.Start) {
+			return true
+		}
+	}
+	return false
+}
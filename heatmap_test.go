@@ -0,0 +1,50 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailabilityHeatmap(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	create := func(e Event) *Event {
+		created, _, err := c.Create(e)
+		require.NoError(t, err)
+		return created
+	}
+	// user 1 is busy 09:00-10:00 UTC, user 2 is busy 09:30-10:30 UTC (03:30 in Denver)
+	create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00"})
+	create(Event{OwnerId: 2, Zone: den, StartDay: "2008-01-01", StartTime: "02:30", EndDay: "2008-01-01", EndTime: "03:30"})
+	// all day events, canceled events, and declined invites don't make a user busy
+	create(Event{OwnerId: 3, Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true})
+	canceled := create(Event{OwnerId: 3, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "11:00"})
+	require.NoError(t, c.Cancel(canceled.Id, RepeatEditTypeThis))
+	declined := create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", StartTime: "10:30", EndDay: "2008-01-01", EndTime: "11:00"})
+	require.NoError(t, c.InviteUser(declined.Id, 3, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.DeclineInvitation(declined.Id, 3, RepeatEditTypeThis))
+
+	window := TimeWindow{Start: time.Date(2008, 1, 1, 9, 0, 0, 0, time.UTC), End: time.Date(2008, 1, 1, 10, 45, 0, 0, time.UTC)}
+	h, err := c.AvailabilityHeatmap([]int64{1, 2, 3}, window, 30*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, h.Slots, 4)
+	var available []int
+	var busy [][]int64
+	for _, slot := range h.Slots {
+		available = append(available, slot.Available)
+		busy = append(busy, slot.BusyUserIds)
+	}
+	assert.Equal(t, []int{2, 1, 2, 2}, available)
+	assert.Equal(t, [][]int64{{1}, {1, 2}, {2}, {1}}, busy)
+	assert.Equal(t, window.End, h.Slots[3].End, "the last slot is cut short")
+	assert.Equal(t, 15*time.Minute, h.Slots[3].End.Sub(h.Slots[3].Start))
+
+	_, err = c.AvailabilityHeatmap([]int64{1}, window, 0)
+	assert.Equal(t, ErrorInvalidHeatmap, err)
+	_, err = c.AvailabilityHeatmap([]int64{1}, TimeWindow{Start: window.End, End: window.Start}, time.Minute)
+	assert.Equal(t, ErrorInvalidHeatmap, err)
+	_, err = c.AvailabilityHeatmap([]int64{1}, TimeWindow{Start: window.Start, End: window.Start.AddDate(1, 0, 0)}, time.Minute)
+	assert.Equal(t, ErrorInvalidHeatmap, err)
+}
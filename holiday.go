@@ -0,0 +1,77 @@
+package cali
+
+import (
+	"hash/fnv"
+	"time"
+)
+
+// Holiday is a single day off (or day of observance) for a region
+type Holiday struct {
+	// Name is the display name of the holiday like "Independence Day"
+	Name string `json:"name"`
+	// Day is the YYYY-MM-DD value of the holiday
+	Day string `json:"day"`
+	// Region is a short code for where the holiday is observed like "US" or "UK"
+	Region string `json:"region"`
+}
+
+// HolidayProvider is a source of holidays, see the holidays package for built-in providers
+type HolidayProvider interface {
+	// Holidays gets all of the holidays on or between the start and end days
+	Holidays(start, end time.Time) ([]Holiday, error)
+}
+
+// holidayIdsPerDay is the number of holiday ids on each day (see Holiday.Event)
+const holidayIdsPerDay = 1_000_000_000_000
+
+// Event converts the holiday into a read-only all day event on the calendar id. The event
+// has a negative id so it can never be confused with (or updated as) a saved event. The id
+// is made from the day and a hash of the region and the name, so the same holiday always has
+// the same id no matter which query or provider it comes from.
+func (h Holiday) Event(calendarId int64) Event {
+	e := Event{
+		CalendarId: calendarId,
+		Title:      h.Name,
+		Status:     StatusActive,
+		IsAllDay:   true,
+		Zone:       "UTC",
+		StartDay:   h.Day,
+		EndDay:     h.Day,
+	}
+	if day, err := time.Parse(time.DateOnly, h.Day); err == nil {
+		hash := fnv.New64a()
+		hash.Write([]byte(h.Region + "\x00" + h.Name))
+		e.Id = -(fixedFromTime(day)*holidayIdsPerDay + int64(hash.Sum64()%holidayIdsPerDay) + 1)
+	}
+	return e
+}
+
+// WithHolidayCalendar overlays the holidays from the provider onto the results of Query
+// as read-only all day events on the calendar id. Holidays are only added to queries
+// that have both a Start and an End, and that match the rest of the query.
+func WithHolidayCalendar(calendarId int64, provider HolidayProvider) CalendarOption {
+	return func(c *Calendar) {
+		c.holidayCalendarId = calendarId
+		c.holidayProvider = provider
+	}
+}
+
+// holidayEvents gets the overlay holiday events that match the query
+func (c *Calendar) holidayEvents(q Query) ([]*Event, error) {
+	if c.holidayProvider == nil || q.Start == nil || q.End == nil {
+		return nil, nil
+	}
+	holidays, err := c.holidayProvider.Holidays(*q.Start, *q.End)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Event
+	for _, h := range holidays {
+		e := h.Event(c.holidayCalendarId)
+		// holidays are shown to every user, so UserIds are not checked
+		if q.Matches(&e) {
+			result = append(result, &e)
+		}
+	}
+	return result, nil
+}
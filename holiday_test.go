@@ -0,0 +1,87 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testHolidayProvider []Holiday
+
+func (p testHolidayProvider) Holidays(start, end time.Time) ([]Holiday, error) {
+	var result []Holiday
+	for _, h := range p {
+		if h.Day >= start.Format(time.DateOnly) && h.Day <= end.Format(time.DateOnly) {
+			result = append(result, h)
+		}
+	}
+	return result, nil
+}
+
+func TestHolidayCalendar(t *testing.T) {
+	provider := testHolidayProvider{
+		{Name: "New Year's Day", Day: "2008-01-01"},
+		{Name: "Later", Day: "2008-03-01"},
+	}
+	d := &InMemoryDataStore{}
+	c := NewCalendar(d, WithHolidayCalendar(99, provider))
+
+	_, _, err := c.Create(Event{CalendarId: 1, Title: "Work", StartDay: "2008-01-02", EndDay: "2008-01-02", IsAllDay: true})
+	require.NoError(t, err)
+
+	events, err := c.Query(Query{Start: tt("2008-01-01 00:00"), End: tt("2008-01-31 00:00")})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "New Year's Day", events[0].Title)
+	assert.Equal(t, int64(99), events[0].CalendarId)
+	assert.Less(t, events[0].Id, int64(0))
+	assert.Equal(t, "Work", events[1].Title)
+	assert.Len(t, d.events, 1, "holidays should never be saved")
+
+	// holidays are read-only
+	err = c.UpdateTitle(events[0].Id, "Changed", RepeatEditTypeThis)
+	assert.Equal(t, ErrorEventNotFound, err)
+
+	events, err = c.Query(Query{Start: tt("2008-01-01 00:00"), End: tt("2008-01-31 00:00"), CalendarIds: []int64{1}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	events, err = c.Query(Query{})
+	require.NoError(t, err)
+	require.Len(t, events, 1, "unbounded queries don't include holidays")
+}
+
+func TestHolidayIds(t *testing.T) {
+	provider := testHolidayProvider{
+		{Name: "New Year's Day", Day: "2008-01-01", Region: "US"},
+		{Name: "Bank Holiday", Day: "2008-01-01", Region: "UK"},
+		{Name: "Groundhog Day", Day: "2008-02-02", Region: "US"},
+		{Name: "Later", Day: "2008-03-01", Region: "US"},
+	}
+	c := NewCalendar(&InMemoryDataStore{}, WithHolidayCalendar(99, provider))
+	ids := func(start, end string) map[string]int64 {
+		events, err := c.Query(Query{Start: tt(start), End: tt(end)})
+		require.NoError(t, err)
+		result := map[string]int64{}
+		for _, e := range events {
+			result[e.Title] = e.Id
+		}
+		return result
+	}
+	january := ids("2008-01-01 00:00", "2008-02-15 00:00")
+	february := ids("2008-01-15 00:00", "2008-03-15 00:00")
+	require.Len(t, january, 3)
+	require.Len(t, february, 2)
+	assert.Equal(t, january["Groundhog Day"], february["Groundhog Day"], "the id doesn't depend on the range of the query")
+	assert.NotEqual(t, january["New Year's Day"], january["Bank Holiday"])
+
+	reversed := NewCalendar(&InMemoryDataStore{}, WithHolidayCalendar(99, testHolidayProvider{provider[1], provider[0]}))
+	events, err := reversed.Query(Query{Start: tt("2008-01-01 00:00"), End: tt("2008-01-02 00:00")})
+	require.NoError(t, err)
+	for _, e := range events {
+		assert.Equal(t, january[e.Title], e.Id, "the id doesn't depend on the order of the provider")
+	}
+	assert.Len(t, events, 2)
+}
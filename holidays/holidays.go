@@ -0,0 +1,170 @@
+// Package holidays has built-in cali.HolidayProvider implementations for a few
+// regions. Each provider is a list of rules that are calculated for every year
+// that is requested, so there are no tables that need to be kept up to date.
+package holidays
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Kenoshen/cali"
+)
+
+// Rule calculates the days of a single holiday for a year. Most rules return
+// a single day, but rules that include an observed day can return more.
+type Rule func(year int) []cali.Holiday
+
+// Provider is a set of holiday rules for a region and implements cali.HolidayProvider
+type Provider struct {
+	// Region is the short code set on every holiday from this provider
+	Region string
+	// Rules are the holiday rules that are calculated for every year
+	Rules []Rule
+}
+
+// Holidays gets all of the holidays on or between the start and end days sorted by day
+func (p Provider) Holidays(start, end time.Time) ([]cali.Holiday, error) {
+	startDay := start.Format(time.DateOnly)
+	endDay := end.Format(time.DateOnly)
+	var result []cali.Holiday
+	// observed days can land in the year before or after the holiday
+	for year := start.Year() - 1; year <= end.Year()+1; year++ {
+		for _, rule := range p.Rules {
+			for _, h := range rule(year) {
+				if h.Day < startDay || h.Day > endDay {
+					continue
+				}
+				h.Region = p.Region
+				result = append(result, h)
+			}
+		}
+	}
+	sort.SliceStable(result, func(a, b int) bool {
+		return result[a].Day < result[b].Day
+	})
+	return result, nil
+}
+
+// Combine merges the holidays of multiple providers into a single provider
+func Combine(providers ...cali.HolidayProvider) cali.HolidayProvider {
+	return combined(providers)
+}
+
+type combined []cali.HolidayProvider
+
+func (c combined) Holidays(start, end time.Time) ([]cali.Holiday, error) {
+	var result []cali.Holiday
+	for _, p := range c {
+		holidays, err := p.Holidays(start, end)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, holidays...)
+	}
+	sort.SliceStable(result, func(a, b int) bool {
+		return result[a].Day < result[b].Day
+	})
+	return result, nil
+}
+
+// ///////////////////////
+// Rules
+// ///////////////////////
+
+// Fixed is a holiday on the same month and day every year
+func Fixed(name string, month time.Month, day int) Rule {
+	return func(year int) []cali.Holiday {
+		return []cali.Holiday{holiday(name, time.Date(year, month, day, 0, 0, 0, 0, time.UTC))}
+	}
+}
+
+// Since only applies the rule on or after the first year
+func Since(firstYear int, rule Rule) Rule {
+	return func(year int) []cali.Holiday {
+		if year < firstYear {
+			return nil
+		}
+		return rule(year)
+	}
+}
+
+// NthWeekday is a holiday on the nth weekday of the month (ex: the 3rd Monday of January).
+// If n is negative, then it counts from the end of the month (-1 is the last).
+func NthWeekday(name string, month time.Month, weekday time.Weekday, n int) Rule {
+	return func(year int) []cali.Holiday {
+		return []cali.Holiday{holiday(name, nthWeekday(year, month, weekday, n))}
+	}
+}
+
+// EasterOffset is a holiday a number of days from (Western) Easter Sunday
+func EasterOffset(name string, days int) Rule {
+	return func(year int) []cali.Holiday {
+		return []cali.Holiday{holiday(name, Easter(year).AddDate(0, 0, days))}
+	}
+}
+
+// ObservedNearestWeekday is a fixed holiday that is also observed on the Friday before
+// when it lands on a Saturday, or on the Monday after when it lands on a Sunday
+func ObservedNearestWeekday(name string, month time.Month, day int) Rule {
+	return func(year int) []cali.Holiday {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		result := []cali.Holiday{holiday(name, d)}
+		switch d.Weekday() {
+		case time.Saturday:
+			result = append(result, holiday(name+" (Observed)", d.AddDate(0, 0, -1)))
+		case time.Sunday:
+			result = append(result, holiday(name+" (Observed)", d.AddDate(0, 0, 1)))
+		}
+		return result
+	}
+}
+
+// ObservedNextMonday is a fixed holiday that is also observed on the following Monday
+// when it lands on a weekend
+func ObservedNextMonday(name string, month time.Month, day int) Rule {
+	return func(year int) []cali.Holiday {
+		d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		result := []cali.Holiday{holiday(name, d)}
+		switch d.Weekday() {
+		case time.Saturday:
+			result = append(result, holiday(name+" (Substitute Day)", d.AddDate(0, 0, 2)))
+		case time.Sunday:
+			result = append(result, holiday(name+" (Substitute Day)", d.AddDate(0, 0, 1)))
+		}
+		return result
+	}
+}
+
+// Easter calculates Western Easter Sunday using the anonymous Gregorian algorithm
+func Easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	if n < 0 {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+		offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+		return last.AddDate(0, 0, -offset+(n+1)*7)
+	}
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+(n-1)*7)
+}
+
+func holiday(name string, day time.Time) cali.Holiday {
+	return cali.Holiday{Name: name, Day: day.Format(time.DateOnly)}
+}
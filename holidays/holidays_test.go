@@ -0,0 +1,103 @@
+package holidays
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEaster(t *testing.T) {
+	assert.Equal(t, "2008-03-23", Easter(2008).Format(time.DateOnly))
+	assert.Equal(t, "2019-04-21", Easter(2019).Format(time.DateOnly))
+	assert.Equal(t, "2024-03-31", Easter(2024).Format(time.DateOnly))
+	assert.Equal(t, "2038-04-25", Easter(2038).Format(time.DateOnly))
+}
+
+func TestProviders(t *testing.T) {
+	testCases := []struct {
+		name     string
+		provider Provider
+		start    string
+		end      string
+		out      []string
+	}{
+		{
+			name:     "us 2022",
+			provider: US,
+			start:    "2021-12-31",
+			end:      "2022-12-31",
+			out: []string{
+				"2021-12-31 New Year's Day (Observed)",
+				"2022-01-01 New Year's Day",
+				"2022-01-17 Martin Luther King Jr. Day",
+				"2022-02-21 Washington's Birthday",
+				"2022-05-30 Memorial Day",
+				"2022-06-19 Juneteenth",
+				"2022-06-20 Juneteenth (Observed)",
+				"2022-07-04 Independence Day",
+				"2022-09-05 Labor Day",
+				"2022-10-10 Columbus Day",
+				"2022-11-11 Veterans Day",
+				"2022-11-24 Thanksgiving Day",
+				"2022-12-25 Christmas Day",
+				"2022-12-26 Christmas Day (Observed)",
+			},
+		},
+		{
+			name:     "uk christmas on a sunday",
+			provider: UK,
+			start:    "2022-12-01",
+			end:      "2023-01-31",
+			out: []string{
+				"2022-12-25 Christmas Day",
+				"2022-12-26 Boxing Day",
+				"2022-12-27 Christmas Day (Substitute Day)",
+				"2023-01-01 New Year's Day",
+				"2023-01-02 New Year's Day (Substitute Day)",
+			},
+		},
+		{
+			name:     "germany easter",
+			provider: Germany,
+			start:    "2024-03-01",
+			end:      "2024-05-31",
+			out: []string{
+				"2024-03-29 Karfreitag",
+				"2024-04-01 Ostermontag",
+				"2024-05-01 Tag der Arbeit",
+				"2024-05-09 Christi Himmelfahrt",
+				"2024-05-20 Pfingstmontag",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			start, _ := time.Parse(time.DateOnly, tc.start)
+			end, _ := time.Parse(time.DateOnly, tc.end)
+			holidays, err := tc.provider.Holidays(start, end)
+			require.NoError(t, err)
+			var out []string
+			for _, h := range holidays {
+				assert.Equal(t, tc.provider.Region, h.Region)
+				out = append(out, h.Day+" "+h.Name)
+			}
+			assert.Equal(t, tc.out, out)
+		})
+	}
+}
+
+func TestCombine(t *testing.T) {
+	start := time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, time.July, 31, 0, 0, 0, 0, time.UTC)
+	holidays, err := Combine(US, France).Holidays(start, end)
+	require.NoError(t, err)
+	require.Len(t, holidays, 2)
+	assert.Equal(t, "US", holidays[0].Region)
+	assert.Equal(t, "FR", holidays[1].Region)
+}
@@ -0,0 +1,96 @@
+package holidays
+
+import (
+	"time"
+
+	"github.com/Kenoshen/cali"
+)
+
+// US is the United States federal holidays, with observed days for fixed holidays
+// that land on a weekend
+var US = Provider{
+	Region: "US",
+	Rules: []Rule{
+		ObservedNearestWeekday("New Year's Day", time.January, 1),
+		NthWeekday("Martin Luther King Jr. Day", time.January, time.Monday, 3),
+		NthWeekday("Washington's Birthday", time.February, time.Monday, 3),
+		NthWeekday("Memorial Day", time.May, time.Monday, -1),
+		Since(2021, ObservedNearestWeekday("Juneteenth", time.June, 19)),
+		ObservedNearestWeekday("Independence Day", time.July, 4),
+		NthWeekday("Labor Day", time.September, time.Monday, 1),
+		NthWeekday("Columbus Day", time.October, time.Monday, 2),
+		ObservedNearestWeekday("Veterans Day", time.November, 11),
+		NthWeekday("Thanksgiving Day", time.November, time.Thursday, 4),
+		ObservedNearestWeekday("Christmas Day", time.December, 25),
+	},
+}
+
+// UK is the bank holidays for England and Wales. One-off bank holidays (like
+// coronations and jubilees) are not included.
+var UK = Provider{
+	Region: "UK",
+	Rules: []Rule{
+		ObservedNextMonday("New Year's Day", time.January, 1),
+		EasterOffset("Good Friday", -2),
+		EasterOffset("Easter Monday", 1),
+		NthWeekday("Early May Bank Holiday", time.May, time.Monday, 1),
+		NthWeekday("Spring Bank Holiday", time.May, time.Monday, -1),
+		NthWeekday("Summer Bank Holiday", time.August, time.Monday, -1),
+		ukChristmas,
+	},
+}
+
+// France is the national public holidays of France
+var France = Provider{
+	Region: "FR",
+	Rules: []Rule{
+		Fixed("Jour de l'an", time.January, 1),
+		EasterOffset("Lundi de Pâques", 1),
+		Fixed("Fête du Travail", time.May, 1),
+		Fixed("Victoire 1945", time.May, 8),
+		EasterOffset("Ascension", 39),
+		EasterOffset("Lundi de Pentecôte", 50),
+		Fixed("Fête nationale", time.July, 14),
+		Fixed("Assomption", time.August, 15),
+		Fixed("Toussaint", time.November, 1),
+		Fixed("Armistice 1918", time.November, 11),
+		Fixed("Noël", time.December, 25),
+	},
+}
+
+// Germany is the nationwide public holidays of Germany (holidays that are only
+// observed in some states are not included)
+var Germany = Provider{
+	Region: "DE",
+	Rules: []Rule{
+		Fixed("Neujahr", time.January, 1),
+		EasterOffset("Karfreitag", -2),
+		EasterOffset("Ostermontag", 1),
+		Fixed("Tag der Arbeit", time.May, 1),
+		EasterOffset("Christi Himmelfahrt", 39),
+		EasterOffset("Pfingstmontag", 50),
+		Fixed("Tag der Deutschen Einheit", time.October, 3),
+		Fixed("1. Weihnachtstag", time.December, 25),
+		Fixed("2. Weihnachtstag", time.December, 26),
+	},
+}
+
+// ukChristmas handles Christmas and Boxing Day together since the substitute days
+// for one depend on the other
+func ukChristmas(year int) []cali.Holiday {
+	christmas := time.Date(year, time.December, 25, 0, 0, 0, 0, time.UTC)
+	boxing := christmas.AddDate(0, 0, 1)
+	result := []cali.Holiday{holiday("Christmas Day", christmas), holiday("Boxing Day", boxing)}
+	switch christmas.Weekday() {
+	case time.Friday:
+		result = append(result, holiday("Boxing Day (Substitute Day)", christmas.AddDate(0, 0, 3)))
+	case time.Saturday:
+		result = append(result,
+			holiday("Christmas Day (Substitute Day)", christmas.AddDate(0, 0, 2)),
+			holiday("Boxing Day (Substitute Day)", christmas.AddDate(0, 0, 3)),
+		)
+	case time.Sunday:
+		result = append(result, holiday("Christmas Day (Substitute Day)", christmas.AddDate(0, 0, 2)))
+	}
+	return result
+}
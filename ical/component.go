@@ -0,0 +1,80 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrorInvalidComponent is returned by Parse when the BEGIN and END lines of the components don't match
+var ErrorInvalidComponent = errors.New("invalid component")
+
+// Component is a parsed component like VCALENDAR or VEVENT
+type Component struct {
+	// Name is the upper case name of the component, like VEVENT
+	Name string
+	// Properties are the properties of the component in order
+	Properties []ContentLine
+	// Components are the components inside the component, like the VEVENTs of a VCALENDAR
+	Components []*Component
+}
+
+// ContentLine is a parsed content line
+type ContentLine struct {
+	// Name is the upper case name of the property, like DTSTART
+	Name string
+	// Params are the parameters of the property
+	Params []Param
+	// Value is the value of the property as it is in the content line, so TEXT values are still escaped
+	Value string
+}
+
+// Parse reads the first component (like a VCALENDAR) of the data and every component inside it
+func Parse(data string) (*Component, error) {
+	var stack []*Component
+	for _, line := range Lines(data) {
+		if line == "" {
+			continue
+		}
+		name, params, value, err := ParseProperty(line)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "BEGIN":
+			c := &Component{Name: strings.ToUpper(value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, c)
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(value) {
+				return nil, ErrorInvalidComponent
+			}
+			if len(stack) == 1 {
+				return stack[0], nil
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, ErrorInvalidComponent
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, ContentLine{Name: name, Params: params, Value: value})
+		}
+	}
+	return nil, ErrorInvalidComponent
+}
+
+// Lines gets the content lines of the component and every component inside it, including the
+// BEGIN and END lines, so that Join(c.Lines()) is the ical data of the component
+func (c *Component) Lines() []string {
+	lines := []string{Property("BEGIN", c.Name)}
+	for _, p := range c.Properties {
+		lines = append(lines, Property(p.Name, p.Value, p.Params...))
+	}
+	for _, child := range c.Components {
+		lines = append(lines, child.Lines()...)
+	}
+	return append(lines, Property("END", c.Name))
+}
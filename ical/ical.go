@@ -0,0 +1,247 @@
+// Package ical has helpers for writing (and reading back) the content lines of RFC 5545
+// iCalendar files, like escaping text values, encoding property parameters, and folding
+// lines that are longer than 75 octets.
+package ical
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrorInvalidContentLine is returned by ParseProperty when the line doesn't have a name and a value
+var ErrorInvalidContentLine = errors.New("invalid content line")
+
+// CRLF is the end of every content line
+const CRLF = "\r\n"
+
+// MaxLineOctets is the most octets that a content line can have before it is folded
+const MaxLineOctets = 75
+
+// Param is a property parameter, like CN=Jane in ATTENDEE;CN=Jane:mailto:jane@example.com
+type Param struct {
+	Name  string
+	Value string
+}
+
+// EscapeText escapes a TEXT value, where backslashes, semicolons, and commas are escaped with a
+// backslash and new lines become \n
+func EscapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '\\', ';', ',':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\r':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+			b.WriteString(`\n`)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// UnescapeText reverses EscapeText
+func UnescapeText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			b.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// ParamValue encodes a property parameter value. Double quotes, new lines, and carets are
+// encoded as RFC 6868 describes (^', ^n, and ^^), other control characters are removed, and
+// the value is quoted if it has a colon, semicolon, or comma.
+func ParamValue(v string) string {
+	var b strings.Builder
+	quote := false
+	for _, r := range v {
+		switch {
+		case r == '^':
+			b.WriteString("^^")
+		case r == '"':
+			b.WriteString("^'")
+		case r == '\n':
+			b.WriteString("^n")
+		case r < 0x20 && r != '\t', r == 0x7f:
+		default:
+			if r == ':' || r == ';' || r == ',' {
+				quote = true
+			}
+			b.WriteRune(r)
+		}
+	}
+	if quote {
+		return `"` + b.String() + `"`
+	}
+	return b.String()
+}
+
+// Property makes a content line from the property name, parameters, and value. The value is
+// used as is, so TEXT values should be escaped with EscapeText first.
+func Property(name string, value string, params ...Param) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(name))
+	for _, p := range params {
+		b.WriteByte(';')
+		b.WriteString(strings.ToUpper(p.Name))
+		b.WriteByte('=')
+		b.WriteString(ParamValue(p.Value))
+	}
+	b.WriteByte(':')
+	b.WriteString(value)
+	return b.String()
+}
+
+// ParseProperty splits an unfolded content line into the upper case property name, the
+// parameters (with their values decoded like ParamValue encodes them), and the value
+func ParseProperty(line string) (string, []Param, string, error) {
+	// the value starts after the first colon that isn't in a quoted parameter value
+	quoted := false
+	end := -1
+	for i := 0; i < len(line) && end < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				end = i
+			}
+		}
+	}
+	if end <= 0 {
+		return "", nil, "", ErrorInvalidContentLine
+	}
+	parts := splitUnquoted(line[:end], ';')
+	var params []Param
+	for _, part := range parts[1:] {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok || name == "" {
+			return "", nil, "", ErrorInvalidContentLine
+		}
+		params = append(params, Param{Name: strings.ToUpper(name), Value: decodeParamValue(value)})
+	}
+	return strings.ToUpper(parts[0]), params, line[end+1:], nil
+}
+
+// ParamOf gets the value of the named parameter, or "" if there isn't one
+func ParamOf(params []Param, name string) string {
+	for _, p := range params {
+		if strings.EqualFold(p.Name, name) {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// splitUnquoted splits s at each sep that isn't between double quotes
+func splitUnquoted(s string, sep byte) []string {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// decodeParamValue reverses ParamValue
+func decodeParamValue(v string) string {
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		v = v[1 : len(v)-1]
+	}
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		if v[i] != '^' || i+1 == len(v) {
+			b.WriteByte(v[i])
+			continue
+		}
+		switch v[i+1] {
+		case '^':
+			b.WriteByte('^')
+		case '\'':
+			b.WriteByte('"')
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte('^')
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// Fold splits a content line into lines of at most MaxLineOctets octets (not counting the
+// CRLF), where each line after the first starts with a space. Multi-octet UTF-8 characters
+// aren't split across lines.
+func Fold(line string) string {
+	if len(line) <= MaxLineOctets {
+		return line
+	}
+	var b strings.Builder
+	limit := MaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString(CRLF + " ")
+		line = line[cut:]
+		// the space at the start of the next line is one of its octets
+		limit = MaxLineOctets - 1
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// Unfold joins folded lines back together by removing every line break that is followed
+// by a space or a tab
+func Unfold(s string) string {
+	s = strings.ReplaceAll(s, CRLF+" ", "")
+	s = strings.ReplaceAll(s, CRLF+"\t", "")
+	s = strings.ReplaceAll(s, "\n ", "")
+	return strings.ReplaceAll(s, "\n\t", "")
+}
+
+// Join folds the content lines and joins them with CRLF
+func Join(lines []string) string {
+	folded := make([]string, len(lines))
+	for i, line := range lines {
+		folded[i] = Fold(line)
+	}
+	return strings.Join(folded, CRLF)
+}
+
+// Lines splits the unfolded content lines of s, which can end with either CRLF or LF
+func Lines(s string) []string {
+	s = strings.ReplaceAll(Unfold(s), CRLF, "\n")
+	return strings.Split(strings.TrimRight(s, "\n"), "\n")
+}
@@ -0,0 +1,86 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeText(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{name: "plain", in: "Team sync", out: "Team sync"},
+		{name: "separators", in: "a,b;c", out: `a\,b\;c`},
+		{name: "backslash", in: `C:\notes`, out: `C:\\notes`},
+		{name: "new lines", in: "one\ntwo\r\nthree", out: `one\ntwo\nthree`},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			assert.Equal(t, tc.out, EscapeText(tc.in))
+			assert.Equal(t, strings.ReplaceAll(tc.in, "\r\n", "\n"), UnescapeText(tc.out))
+		})
+	}
+}
+
+func TestParamValue(t *testing.T) {
+	assert.Equal(t, "Jane Doe", ParamValue("Jane Doe"))
+	assert.Equal(t, `"Doe, Jane"`, ParamValue("Doe, Jane"))
+	assert.Equal(t, `"mailto:jane@example.com"`, ParamValue("mailto:jane@example.com"))
+	assert.Equal(t, "Jane ^'JD^' Doe^n^^", ParamValue("Jane \"JD\" Doe\n^\x01"))
+
+	assert.Equal(t, `ATTENDEE;CN="Doe, Jane";ROLE=CHAIR:mailto:jane@example.com`,
+		Property("attendee", "mailto:jane@example.com", Param{Name: "cn", Value: "Doe, Jane"}, Param{Name: "ROLE", Value: "CHAIR"}))
+	assert.Equal(t, "X-COLOR:blue", Property("X-COLOR", "blue"))
+}
+
+func TestFold(t *testing.T) {
+	short := "SUMMARY:Team sync"
+	assert.Equal(t, short, Fold(short))
+
+	long := "DESCRIPTION:" + strings.Repeat("0123456789", 16)
+	folded := Fold(long)
+	lines := strings.Split(folded, CRLF)
+	assert.Len(t, lines, 3)
+	for i, line := range lines {
+		assert.LessOrEqual(t, len(line), MaxLineOctets)
+		if i > 0 {
+			assert.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+	assert.Equal(t, long, Unfold(folded))
+
+	// multi-octet characters stay on one line
+	wide := "SUMMARY:" + strings.Repeat("é", 40)
+	folded = Fold(wide)
+	for _, line := range strings.Split(folded, CRLF) {
+		assert.LessOrEqual(t, len(line), MaxLineOctets)
+		assert.True(t, strings.ToValidUTF8(line, "?") == line)
+	}
+	assert.Equal(t, wide, Unfold(folded))
+
+	joined := Join([]string{"BEGIN:VEVENT", long, "END:VEVENT"})
+	assert.Equal(t, []string{"BEGIN:VEVENT", long, "END:VEVENT"}, Lines(joined))
+	assert.Equal(t, []string{"BEGIN:VEVENT", "END:VEVENT"}, Lines("BEGIN:VEVENT\nEND:VEVENT\n"))
+}
+
+func TestParseProperty(t *testing.T) {
+	name, params, value, err := ParseProperty(`attendee;CN="Doe, Jane";X-NOTE=say ^'hi^'^n:mailto:jane@example.com`)
+	assert.NoError(t, err)
+	assert.Equal(t, "ATTENDEE", name)
+	assert.Equal(t, []Param{{Name: "CN", Value: "Doe, Jane"}, {Name: "X-NOTE", Value: "say \"hi\"\n"}}, params)
+	assert.Equal(t, "mailto:jane@example.com", value)
+	assert.Equal(t, "Doe, Jane", ParamOf(params, "cn"))
+	assert.Equal(t, "", ParamOf(params, "ROLE"))
+
+	_, _, _, err = ParseProperty("no value")
+	assert.Equal(t, ErrorInvalidContentLine, err)
+	_, _, _, err = ParseProperty("NAME;BROKEN:value")
+	assert.Equal(t, ErrorInvalidContentLine, err)
+}
@@ -0,0 +1,281 @@
+package ical
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// XCalNamespace is the XML namespace of xCal (RFC 6321)
+const XCalNamespace = "urn:ietf:params:xml:ns:icalendar-2.0"
+
+// valueTypes are the default value types of the properties that aren't TEXT, where any other
+// property is TEXT unless it is an X- property (which is UNKNOWN)
+var valueTypes = map[string]string{
+	"DTSTART":       "date-time",
+	"DTEND":         "date-time",
+	"DTSTAMP":       "date-time",
+	"DUE":           "date-time",
+	"RDATE":         "date-time",
+	"EXDATE":        "date-time",
+	"RECURRENCE-ID": "date-time",
+	"CREATED":       "date-time",
+	"LAST-MODIFIED": "date-time",
+	"RRULE":         "recur",
+	"EXRULE":        "recur",
+	"TZOFFSETFROM":  "utc-offset",
+	"TZOFFSETTO":    "utc-offset",
+	"PRIORITY":      "integer",
+	"SEQUENCE":      "integer",
+	"ORGANIZER":     "cal-address",
+	"ATTENDEE":      "cal-address",
+	"URL":           "uri",
+	"TZURL":         "uri",
+}
+
+// multiValued are the properties that can have a list of values separated by commas
+var multiValued = map[string]bool{"RDATE": true, "EXDATE": true}
+
+// integerRecurParts are the parts of a RECUR value that are integers
+var integerRecurParts = map[string]bool{
+	"count": true, "interval": true, "bysecond": true, "byminute": true, "byhour": true, "bymonthday": true,
+	"byyearday": true, "byweekno": true, "bymonth": true, "bysetpos": true,
+}
+
+// valueType gets the value type of the property and the parameters without a VALUE parameter
+func (p ContentLine) valueType() (string, []Param) {
+	valueType := "text"
+	if t, ok := valueTypes[p.Name]; ok {
+		valueType = t
+	} else if strings.HasPrefix(p.Name, "X-") {
+		valueType = "unknown"
+	}
+	var params []Param
+	for _, param := range p.Params {
+		if param.Name == "VALUE" {
+			valueType = strings.ToLower(param.Value)
+			continue
+		}
+		params = append(params, param)
+	}
+	return valueType, params
+}
+
+// values splits the value of the property into its values
+func (p ContentLine) values() []string {
+	if multiValued[p.Name] {
+		return strings.Split(p.Value, ",")
+	}
+	return []string{p.Value}
+}
+
+// JCal marshalls the component to jCal (RFC 7265), which is a JSON array of the name, the
+// properties, and the components
+func (c *Component) JCal() ([]byte, error) {
+	return json.Marshal(c.jCal())
+}
+
+func (c *Component) jCal() []interface{} {
+	properties := make([]interface{}, 0, len(c.Properties))
+	for _, p := range c.Properties {
+		valueType, params := p.valueType()
+		jParams := map[string]string{}
+		for _, param := range params {
+			jParams[strings.ToLower(param.Name)] = param.Value
+		}
+		property := []interface{}{strings.ToLower(p.Name), jParams, valueType}
+		for _, v := range p.values() {
+			property = append(property, jCalValue(valueType, v))
+		}
+		properties = append(properties, property)
+	}
+	components := make([]interface{}, 0, len(c.Components))
+	for _, component := range c.Components {
+		components = append(components, component.jCal())
+	}
+	return []interface{}{strings.ToLower(c.Name), properties, components}
+}
+
+// jCalValue converts the value of the type to its jCal value
+func jCalValue(valueType, v string) interface{} {
+	switch valueType {
+	case "date-time":
+		return formatDateTime(v)
+	case "date":
+		return formatDate(v)
+	case "utc-offset":
+		return formatOffset(v)
+	case "integer":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+		return v
+	case "recur":
+		recur := map[string]interface{}{}
+		for _, part := range recurParts(v) {
+			var values []interface{}
+			for _, value := range part.values {
+				values = append(values, recurValue(part.name, value))
+			}
+			if len(values) == 1 {
+				recur[part.name] = values[0]
+			} else {
+				recur[part.name] = values
+			}
+		}
+		return recur
+	case "text":
+		return UnescapeText(v)
+	}
+	return v
+}
+
+// recurPart is a part of a RECUR value like BYDAY=MO,TU
+type recurPart struct {
+	name   string
+	values []string
+}
+
+// recurParts splits the RECUR value into its parts with lower case names
+func recurParts(v string) []recurPart {
+	var parts []recurPart
+	for _, part := range strings.Split(v, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		parts = append(parts, recurPart{name: strings.ToLower(name), values: strings.Split(value, ",")})
+	}
+	return parts
+}
+
+// recurValue converts a value of a RECUR part to its jCal value
+func recurValue(name, value string) interface{} {
+	if integerRecurParts[name] {
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	}
+	if name == "until" {
+		if len(value) == len("20060102") {
+			return formatDate(value)
+		}
+		return formatDateTime(value)
+	}
+	return value
+}
+
+// formatDateTime converts a DATE-TIME like 20080101T090000Z to 2008-01-01T09:00:00Z
+func formatDateTime(v string) string {
+	if len(v) < len("20060102T150405") {
+		return v
+	}
+	return formatDate(v[:8]) + "T" + v[9:11] + ":" + v[11:13] + ":" + v[13:15] + v[15:]
+}
+
+// formatDate converts a DATE like 20080101 to 2008-01-01
+func formatDate(v string) string {
+	if len(v) != len("20060102") {
+		return v
+	}
+	return v[:4] + "-" + v[4:6] + "-" + v[6:]
+}
+
+// formatOffset converts a UTC-OFFSET like -0700 to -07:00
+func formatOffset(v string) string {
+	if len(v) != 5 && len(v) != 7 {
+		return v
+	}
+	result := v[:3] + ":" + v[3:5]
+	if len(v) == 7 {
+		result += ":" + v[5:]
+	}
+	return result
+}
+
+// XCal marshalls the component to xCal (RFC 6321), which is an icalendar XML document
+func (c *Component) XCal() ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<icalendar xmlns="` + XCalNamespace + `">`)
+	if err := c.xCal(&b); err != nil {
+		return nil, err
+	}
+	b.WriteString("</icalendar>")
+	return []byte(b.String()), nil
+}
+
+func (c *Component) xCal(b *strings.Builder) error {
+	name := strings.ToLower(c.Name)
+	b.WriteString("<" + name + ">")
+	if len(c.Properties) > 0 {
+		b.WriteString("<properties>")
+		for _, p := range c.Properties {
+			valueType, params := p.valueType()
+			pName := strings.ToLower(p.Name)
+			b.WriteString("<" + pName + ">")
+			if len(params) > 0 {
+				b.WriteString("<parameters>")
+				for _, param := range params {
+					paramName := strings.ToLower(param.Name)
+					b.WriteString("<" + paramName + "><text>")
+					if err := xml.EscapeText(b, []byte(param.Value)); err != nil {
+						return err
+					}
+					b.WriteString("</text></" + paramName + ">")
+				}
+				b.WriteString("</parameters>")
+			}
+			for _, v := range p.values() {
+				if err := xCalValue(b, valueType, v); err != nil {
+					return err
+				}
+			}
+			b.WriteString("</" + pName + ">")
+		}
+		b.WriteString("</properties>")
+	}
+	if len(c.Components) > 0 {
+		b.WriteString("<components>")
+		for _, component := range c.Components {
+			if err := component.xCal(b); err != nil {
+				return err
+			}
+		}
+		b.WriteString("</components>")
+	}
+	b.WriteString("</" + name + ">")
+	return nil
+}
+
+// xCalValue writes the value of the type as an xCal value element
+func xCalValue(b *strings.Builder, valueType, v string) error {
+	b.WriteString("<" + valueType + ">")
+	if valueType == "recur" {
+		for _, part := range recurParts(v) {
+			for _, value := range part.values {
+				b.WriteString("<" + part.name + ">")
+				if err := xml.EscapeText(b, []byte(toString(recurValue(part.name, value)))); err != nil {
+					return err
+				}
+				b.WriteString("</" + part.name + ">")
+			}
+		}
+	} else if err := xml.EscapeText(b, []byte(toString(jCalValue(valueType, v)))); err != nil {
+		return err
+	}
+	b.WriteString("</" + valueType + ">")
+	return nil
+}
+
+// toString formats a jCal value that isn't an object as a string
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VTIMEZONE\r\n" +
+	"TZID:America/Denver\r\n" +
+	"BEGIN:DAYLIGHT\r\n" +
+	"DTSTART:20070311T020000\r\n" +
+	"TZOFFSETFROM:-0700\r\n" +
+	"TZOFFSETTO:-0600\r\n" +
+	"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU\r\n" +
+	"END:DAYLIGHT\r\n" +
+	"END:VTIMEZONE\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:1\r\n" +
+	"DTSTART;TZID=America/Denver:20080101T090000\r\n" +
+	"DTEND;VALUE=DATE:20080102\r\n" +
+	"SUMMARY:Lunch\\, then <coffee>\r\n" +
+	"PRIORITY:2\r\n" +
+	"X-ROOM:4B\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParse(t *testing.T) {
+	c, err := Parse(testCalendar)
+	require.NoError(t, err)
+	assert.Equal(t, "VCALENDAR", c.Name)
+	require.Len(t, c.Components, 2)
+	assert.Equal(t, "VTIMEZONE", c.Components[0].Name)
+	require.Len(t, c.Components[0].Components, 1)
+	assert.Equal(t, "DAYLIGHT", c.Components[0].Components[0].Name)
+	assert.Equal(t, ContentLine{Name: "DTSTART", Params: []Param{{Name: "TZID", Value: "America/Denver"}}, Value: "20080101T090000"}, c.Components[1].Properties[1])
+	assert.Equal(t, testCalendar, Join(c.Lines())+CRLF, "Lines is the reverse of Parse")
+
+	_, err = Parse("BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n")
+	assert.ErrorIs(t, err, ErrorInvalidComponent)
+	_, err = Parse("BEGIN:VCALENDAR\r\n")
+	assert.ErrorIs(t, err, ErrorInvalidComponent)
+	_, err = Parse("VERSION:2.0\r\n")
+	assert.ErrorIs(t, err, ErrorInvalidComponent)
+}
+
+func TestJCal(t *testing.T) {
+	c, err := Parse(testCalendar)
+	require.NoError(t, err)
+	b, err := c.JCal()
+	require.NoError(t, err)
+	assert.JSONEq(t, `["vcalendar", [["version", {}, "text", "2.0"]], [
+		["vtimezone", [["tzid", {}, "text", "America/Denver"]], [
+			["daylight", [
+				["dtstart", {}, "date-time", "2007-03-11T02:00:00"],
+				["tzoffsetfrom", {}, "utc-offset", "-07:00"],
+				["tzoffsetto", {}, "utc-offset", "-06:00"],
+				["rrule", {}, "recur", {"freq": "YEARLY", "bymonth": 3, "byday": "2SU"}]
+			], []]
+		]],
+		["vevent", [
+			["uid", {}, "text", "1"],
+			["dtstart", {"tzid": "America/Denver"}, "date-time", "2008-01-01T09:00:00"],
+			["dtend", {}, "date", "2008-01-02"],
+			["summary", {}, "text", "Lunch, then <coffee>"],
+			["priority", {}, "integer", 2],
+			["x-room", {}, "unknown", "4B"]
+		], []]
+	]]`, string(b))
+}
+
+func TestXCal(t *testing.T) {
+	c, err := Parse(testCalendar)
+	require.NoError(t, err)
+	b, err := c.XCal()
+	require.NoError(t, err)
+	xcal := string(b)
+	assert.True(t, strings.HasPrefix(xcal, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+`<icalendar xmlns="urn:ietf:params:xml:ns:icalendar-2.0"><vcalendar><properties><version><text>2.0</text></version></properties><components>`))
+	assert.Contains(t, xcal, "<rrule><recur><freq>YEARLY</freq><bymonth>3</bymonth><byday>2SU</byday></recur></rrule>")
+	assert.Contains(t, xcal, "<tzoffsetfrom><utc-offset>-07:00</utc-offset></tzoffsetfrom>")
+	assert.Contains(t, xcal, "<dtstart><parameters><tzid><text>America/Denver</text></tzid></parameters><date-time>2008-01-01T09:00:00</date-time></dtstart>")
+	assert.Contains(t, xcal, "<dtend><date>2008-01-02</date></dtend>")
+	assert.Contains(t, xcal, "<summary><text>Lunch, then &lt;coffee&gt;</text></summary>")
+	assert.Contains(t, xcal, "<priority><integer>2</integer></priority>")
+	assert.True(t, strings.HasSuffix(xcal, "</vevent></components></vcalendar></icalendar>"))
+}
@@ -0,0 +1,228 @@
+package cali
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Kenoshen/cali/ical"
+)
+
+// SeriesICal marshalls the series of the event to an ical format (see MarshallSeriesToICal)
+func (c *Calendar) SeriesICal(eventId int64) (string, error) {
+	e, err := c.Get(eventId)
+	if err != nil {
+		return "", err
+	}
+	if e == nil {
+		return "", ErrorEventNotFound
+	}
+	if !e.IsRepeating || e.ParentId == nil {
+		return "", ErrorNotRepeatingEvent
+	}
+	events, err := c.getAllRepeatingEvents(*e)
+	if err != nil {
+		return "", err
+	}
+	if events, err = c.withSeries(events); err != nil {
+		return "", err
+	}
+	options, err := c.iCalOptions(events)
+	if err != nil {
+		return "", err
+	}
+	lines, err := seriesICalLines(events, options)
+	if err != nil {
+		return "", err
+	}
+	return ical.Join(lines), nil
+}
+
+// MarshallSeriesToICal marshalls the events of a series to an ical format. The series is a master
+// VEVENT (with the ParentId as the UID) that has an RDATE for each event in the series and an
+// EXDATE for each event that isn't active. Each active event that overrides any fields (see
+// Event.Overrides) is another VEVENT with the same UID and a RECURRENCE-ID of the start that
+// it had in the series, so that clients that import the series keep the changes to the event.
+// The UserData keys in the properties are exported as X- properties.
+func MarshallSeriesToICal(events []*Event, properties ICalProperties) (string, error) {
+	lines, err := seriesICalLines(events, iCalOptions{properties: properties})
+	if err != nil {
+		return "", err
+	}
+	return ical.Join(lines), nil
+}
+
+// seriesICalLines gets the content lines of MarshallSeriesToICal
+func seriesICalLines(events []*Event, options iCalOptions) ([]string, error) {
+	if err := ValidateICalProperties(options.properties); err != nil {
+		return nil, err
+	}
+	events = append([]*Event(nil), events...)
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Id < events[j].Id
+	})
+	if len(events) == 0 {
+		return nil, ErrorEventNotFound
+	}
+	first := events[0]
+	if !first.IsRepeating || first.ParentId == nil {
+		return nil, ErrorNotRepeatingEvent
+	}
+	uid := options.uid(*first.ParentId)
+
+	// the master has the values of the first active event that doesn't override anything
+	master := first
+	for _, e := range events {
+		if e.Status == StatusActive && len(e.Overrides) == 0 {
+			master = e
+			break
+		}
+	}
+	firstStart, err := recurrenceTime(*first)
+	if err != nil {
+		return nil, err
+	}
+	masterStart, err := master.Start()
+	if err != nil {
+		return nil, ErrorInvalidStartDay
+	}
+	duration, err := master.Duration()
+	if err != nil {
+		return nil, err
+	}
+	m, err := ShiftEvent(*master, firstStart.Sub(masterStart), duration)
+	if err != nil {
+		return nil, err
+	}
+
+	var dates, overrides []string
+	for i, e := range events {
+		start, err := recurrenceTime(*e)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			dates = append(dates, iCalDateTime("RDATE", start, m.Zone))
+		}
+		if e.Status != StatusActive {
+			dates = append(dates, iCalDateTime("EXDATE", start, m.Zone))
+		} else if len(e.Overrides) > 0 {
+			extra := append([]string{iCalDateTime("RECURRENCE-ID", start, m.Zone)}, options.lines(*e)...)
+			overrides = append(overrides, e.iCalLines(uid, extra...)...)
+		}
+	}
+	return append(m.iCalLines(uid, append(dates, options.lines(m)...)...), overrides...), nil
+}
+
+// ExportICal marshalls the events that match the query to an ical calendar (see MarshallCalendarToICal)
+func (c *Calendar) ExportICal(q Query) (string, error) {
+	events, err := c.Query(q)
+	if err != nil {
+		return "", err
+	}
+	options, err := c.iCalOptions(events)
+	if err != nil {
+		return "", err
+	}
+	return marshallCalendarToICal(events, options)
+}
+
+// MarshallCalendarToICal marshalls the events to a VCALENDAR. The events of each series are
+// marshalled together (see MarshallSeriesToICal), and the calendar has a VTIMEZONE with the
+// daylight saving time rules of every zone that the events use (other than UTC) for the
+// years of the events. The UserData keys in the properties are exported as X- properties.
+func MarshallCalendarToICal(events []*Event, properties ICalProperties) (string, error) {
+	return marshallCalendarToICal(events, iCalOptions{properties: properties})
+}
+
+// marshallCalendarToICal is MarshallCalendarToICal with the extra lines of the options
+func marshallCalendarToICal(events []*Event, options iCalOptions) (string, error) {
+	if err := ValidateICalProperties(options.properties); err != nil {
+		return "", err
+	}
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:-//Kenoshen//cali//EN"}
+
+	var vevents []string
+	var zones []string
+	firstYear, lastYear := map[string]int{}, map[string]int{}
+	series := map[int64][]*Event{}
+	var parentIds []int64
+	for _, e := range events {
+		if e.Zone != "" && e.Zone != "UTC" {
+			start, end, err := e.span()
+			if err != nil {
+				return "", err
+			}
+			if _, ok := firstYear[e.Zone]; !ok {
+				zones = append(zones, e.Zone)
+				firstYear[e.Zone], lastYear[e.Zone] = start.Year(), end.Year()
+			}
+			firstYear[e.Zone] = min(firstYear[e.Zone], start.Year())
+			lastYear[e.Zone] = max(lastYear[e.Zone], end.Year())
+		}
+		if e.IsRepeating && e.ParentId != nil {
+			if _, ok := series[*e.ParentId]; !ok {
+				parentIds = append(parentIds, *e.ParentId)
+			}
+			series[*e.ParentId] = append(series[*e.ParentId], e)
+			continue
+		}
+		vevents = append(vevents, e.iCalLines(options.uid(e.Id), options.lines(*e)...)...)
+	}
+	for _, parentId := range parentIds {
+		s, err := seriesICalLines(series[parentId], options)
+		if err != nil {
+			return "", err
+		}
+		vevents = append(vevents, s...)
+	}
+
+	sort.Strings(zones)
+	for _, zone := range zones {
+		vtimezone, err := vTimezoneLines(zone, firstYear[zone], lastYear[zone])
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, vtimezone...)
+	}
+	lines = append(lines, vevents...)
+	lines = append(lines, "END:VCALENDAR")
+	return ical.Join(lines), nil
+}
+
+// iCalOptions are the extra lines of the VEVENTs of an ical export
+type iCalOptions struct {
+	properties ICalProperties
+	// people are the ORGANIZER and ATTENDEE lines of each event by id
+	people map[int64][]string
+	// attachments are the ATTACH lines of each event by id
+	attachments map[int64][]string
+	// uidGenerator makes the UIDs of the VEVENTs, nil is DefaultUID
+	uidGenerator UIDGenerator
+}
+
+// iCalOptions gets the options of the ical exports of the calendar for the events
+func (c *Calendar) iCalOptions(events []*Event) (iCalOptions, error) {
+	people, err := c.iCalPeople(events)
+	if err != nil {
+		return iCalOptions{}, err
+	}
+	attachments, err := c.iCalAttachments(events)
+	return iCalOptions{properties: c.iCalProperties, people: people, attachments: attachments, uidGenerator: c.uidGenerator}, err
+}
+
+// lines gets the extra lines of the VEVENT of the event
+func (o iCalOptions) lines(e Event) []string {
+	lines := append(append([]string(nil), o.people[e.Id]...), o.attachments[e.Id]...)
+	return append(lines, o.properties.lines(e)...)
+}
+
+// recurrenceTime gets the start that the event had in its series before its time was overridden
+func recurrenceTime(e Event) (time.Time, error) {
+	day, hourMin, _ := strings.Cut(e.recurrenceStart(), " ")
+	t, err := parseDayTime(day, hourMin)
+	if err != nil {
+		return t, ErrorInvalidStartDay
+	}
+	return t, nil
+}
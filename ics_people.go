@@ -0,0 +1,122 @@
+package cali
+
+import (
+	"sort"
+
+	"github.com/Kenoshen/cali/ical"
+)
+
+// UserInfo is how a user is shown in an ical export
+type UserInfo struct {
+	// Name is the display name of the user, which is the CN of the user
+	Name string `json:"name"`
+	// Email is the email address of the user, which is the mailto: address of the user
+	Email string `json:"email"`
+}
+
+// UserInfoResolver looks up the names and emails of users for ical exports, since the calendar
+// only knows the ids of users. Users that aren't in the result aren't exported.
+type UserInfoResolver interface {
+	// UserInfo gets the info of each of the users by id
+	UserInfo(userIds []int64) (map[int64]UserInfo, error)
+}
+
+// WithUserInfoResolver adds the ORGANIZER and ATTENDEE lines of every event to the exports of
+// SeriesICal and ExportICal, using the resolver to find the name and email of each user
+func WithUserInfoResolver(resolver UserInfoResolver) CalendarOption {
+	return func(c *Calendar) {
+		c.userInfoResolver = resolver
+	}
+}
+
+// iCalPartStat is the PARTSTAT of each invite status
+var iCalPartStat = map[InviteStatus]string{
+	InviteStatusPending:   "NEEDS-ACTION",
+	InviteStatusConfirmed: "ACCEPTED",
+	InviteStatusTentative: "TENTATIVE",
+	// iCalendar doesn't have a waitlist, so the user still has to be let in
+	InviteStatusWaitlisted: "NEEDS-ACTION",
+	InviteStatusDeclined:   "DECLINED",
+}
+
+// iCalPeople gets the ORGANIZER and ATTENDEE lines of each event by id, or nil if the calendar
+// doesn't have a UserInfoResolver. The owner is the ORGANIZER and co-organizers (see
+// Invite.IsOrganizer) are attendees with ROLE=CHAIR. Revoked invites aren't exported.
+func (c *Calendar) iCalPeople(events []*Event) (map[int64][]string, error) {
+	if c.userInfoResolver == nil {
+		return nil, nil
+	}
+	invites := map[int64][]*Invite{}
+	userIds := map[int64]bool{}
+	for _, e := range events {
+		userIds[e.OwnerId] = true
+		eventInvites, err := c.getInvites(e.Id)
+		if err == ErrorInviteListNotSupported {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, invite := range eventInvites {
+			if invite.UserId != e.OwnerId && invite.Status != InviteStatusRevoked {
+				invites[e.Id] = append(invites[e.Id], invite)
+				userIds[invite.UserId] = true
+			}
+		}
+	}
+	ids := make([]int64, 0, len(userIds))
+	for userId := range userIds {
+		ids = append(ids, userId)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	users, err := c.userInfoResolver.UserInfo(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	people := map[int64][]string{}
+	for _, e := range events {
+		var lines []string
+		if owner, ok := users[e.OwnerId]; ok && owner.Email != "" {
+			lines = append(lines, ical.Property("ORGANIZER", "mailto:"+owner.Email, userParams(owner)...))
+		}
+		eventInvites := invites[e.Id]
+		sort.SliceStable(eventInvites, func(i, j int) bool {
+			return eventInvites[i].UserId < eventInvites[j].UserId
+		})
+		seen := map[int64]bool{}
+		for _, invite := range eventInvites {
+			user, ok := users[invite.UserId]
+			if !ok || user.Email == "" || seen[invite.UserId] {
+				continue
+			}
+			seen[invite.UserId] = true
+			role := "REQ-PARTICIPANT"
+			if invite.IsOrganizer() {
+				role = "CHAIR"
+			}
+			rsvp := "FALSE"
+			if invite.Status == InviteStatusPending {
+				rsvp = "TRUE"
+			}
+			params := append(userParams(user),
+				ical.Param{Name: "PARTSTAT", Value: iCalPartStat[invite.Status]},
+				ical.Param{Name: "ROLE", Value: role},
+				ical.Param{Name: "RSVP", Value: rsvp},
+			)
+			lines = append(lines, ical.Property("ATTENDEE", "mailto:"+user.Email, params...))
+		}
+		people[e.Id] = lines
+	}
+	return people, nil
+}
+
+// userParams is the CN of the user if they have a name
+func userParams(user UserInfo) []ical.Param {
+	if user.Name == "" {
+		return nil
+	}
+	return []ical.Param{{Name: "CN", Value: user.Name}}
+}
@@ -0,0 +1,83 @@
+package cali
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Kenoshen/cali/ical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDirectory is a UserInfoResolver with a fixed list of users
+type testDirectory map[int64]UserInfo
+
+func (d testDirectory) UserInfo(userIds []int64) (map[int64]UserInfo, error) {
+	result := map[int64]UserInfo{}
+	for _, userId := range userIds {
+		if user, ok := d[userId]; ok {
+			result[userId] = user
+		}
+	}
+	return result, nil
+}
+
+// failingDirectory is a UserInfoResolver that always fails
+type failingDirectory struct{}
+
+func (failingDirectory) UserInfo(userIds []int64) (map[int64]UserInfo, error) {
+	return nil, errors.New("directory is down")
+}
+
+func TestICalAttendees(t *testing.T) {
+	directory := testDirectory{
+		1: {Name: "Owner, Olivia", Email: "olivia@example.com"},
+		2: {Name: "Ada", Email: "ada@example.com"},
+		3: {Name: "Bo", Email: "bo@example.com"},
+		4: {Email: "cy@example.com"},
+		5: {Name: "Revoked", Email: "revoked@example.com"},
+	}
+	c := NewCalendar(&InMemoryDataStore{}, WithUserInfoResolver(directory))
+	e, _, err := c.Create(Event{OwnerId: 1, Title: "Review", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00"})
+	require.NoError(t, err)
+	for _, userId := range []int64{2, 3, 4, 5, 6} {
+		require.NoError(t, c.InviteUser(e.Id, userId, PermissionInvitee, RepeatEditTypeThis))
+	}
+	require.NoError(t, c.AcceptInvitation(e.Id, 2, RepeatEditTypeThis))
+	require.NoError(t, c.DeclineInvitation(e.Id, 3, RepeatEditTypeThis))
+	require.NoError(t, c.AddCoOrganizer(e.Id, 4, RepeatEditTypeThis))
+	require.NoError(t, c.RevokeInvitation(e.Id, 5, RepeatEditTypeThis))
+
+	data, err := c.ExportICal(Query{EventIds: []int64{e.Id}})
+	require.NoError(t, err)
+	lines := ical.Lines(data)
+	assert.Contains(t, lines, `ORGANIZER;CN="Owner, Olivia":mailto:olivia@example.com`)
+	assert.Contains(t, lines, "ATTENDEE;CN=Ada;PARTSTAT=ACCEPTED;ROLE=REQ-PARTICIPANT;RSVP=FALSE:mailto:ada@example.com")
+	assert.Contains(t, lines, "ATTENDEE;CN=Bo;PARTSTAT=DECLINED;ROLE=REQ-PARTICIPANT;RSVP=FALSE:mailto:bo@example.com")
+	assert.Contains(t, lines, "ATTENDEE;PARTSTAT=NEEDS-ACTION;ROLE=CHAIR;RSVP=TRUE:mailto:cy@example.com")
+	assert.NotContains(t, data, "revoked@example.com")
+	// the owner is only the organizer and users without info aren't exported
+	assert.NotContains(t, data, "ATTENDEE;CN=\"Owner")
+	assert.Len(t, filterPrefix(lines, "ATTENDEE"), 3)
+
+	// the exports don't have people without a resolver
+	data, err = NewCalendar(c.dataStore).ExportICal(Query{EventIds: []int64{e.Id}})
+	require.NoError(t, err)
+	assert.NotContains(t, data, "ATTENDEE")
+	assert.NotContains(t, data, "ORGANIZER")
+
+	_, err = NewCalendar(c.dataStore, WithUserInfoResolver(failingDirectory{})).ExportICal(Query{EventIds: []int64{e.Id}})
+	assert.EqualError(t, err, "directory is down")
+}
+
+// filterPrefix gets the lines that start with the prefix
+func filterPrefix(lines []string, prefix string) []string {
+	var result []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, prefix) {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,210 @@
+package cali
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Kenoshen/cali/ical"
+)
+
+// iCalJSONParam is the parameter of an X- property with a value that isn't a string, which
+// is exported as JSON so that it is the same type when it is imported
+const iCalJSONParam = "X-VALUE"
+
+// ICalProperties maps UserData keys to the names of the X- properties (like "X-APP-COLOR") that
+// they are exported to ical as and imported from. String values are exported as text, and other
+// values are exported as JSON with an X-VALUE=JSON parameter.
+type ICalProperties map[string]string
+
+// WithICalProperties exports the UserData keys as X- properties in SeriesICal and ExportICal,
+// and imports them back into UserData in UnmarshallICal
+func WithICalProperties(properties ICalProperties) CalendarOption {
+	return func(c *Calendar) {
+		c.iCalProperties = properties
+	}
+}
+
+// ValidateICalProperties makes sure that every property name starts with X- and is only used once
+func ValidateICalProperties(properties ICalProperties) error {
+	seen := map[string]bool{}
+	for _, name := range properties {
+		name = strings.ToUpper(name)
+		if !strings.HasPrefix(name, "X-") || len(name) == 2 || seen[name] {
+			return ErrorInvalidICalProperty
+		}
+		for _, r := range name {
+			if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return ErrorInvalidICalProperty
+			}
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// lines gets the X- properties of the UserData of the event sorted by the property name
+func (p ICalProperties) lines(e Event) []string {
+	var lines []string
+	for key, name := range p {
+		value, ok := e.UserData[key]
+		if !ok {
+			continue
+		}
+		if s, ok := value.(string); ok {
+			lines = append(lines, ical.Property(name, ical.EscapeText(s)))
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, ical.Property(name, ical.EscapeText(string(b)), ical.Param{Name: iCalJSONParam, Value: "JSON"}))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// key gets the UserData key of the property name, or false if the property isn't mapped
+func (p ICalProperties) key(name string) (string, bool) {
+	for key, n := range p {
+		if strings.EqualFold(n, name) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// userData sets the UserData key of the X- property on the event if the property is mapped
+func (p ICalProperties) userData(e *Event, name string, params []ical.Param, value string) error {
+	key, ok := p.key(name)
+	if !ok {
+		return nil
+	}
+	if e.UserData == nil {
+		e.UserData = map[string]interface{}{}
+	}
+	value = ical.UnescapeText(value)
+	if !strings.EqualFold(ical.ParamOf(params, iCalJSONParam), "JSON") {
+		e.UserData[key] = value
+		return nil
+	}
+	var v interface{}
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return ErrorInvalidICal
+	}
+	e.UserData[key] = v
+	return nil
+}
+
+// UnmarshallICal reads the VEVENTs of the ical data (see UnmarshallICal) with the ical properties of the calendar
+func (c *Calendar) UnmarshallICal(data string) ([]*Event, error) {
+	return UnmarshallICal(data, c.iCalProperties)
+}
+
+// UnmarshallICal reads the VEVENTs of the ical data as events, where the X- properties in the
+// properties are set in the UserData of the events. Only the times, SUMMARY, DESCRIPTION, CLASS,
+// and PRIORITY are read, so the events of a series (with RRULE, RDATE, or RECURRENCE-ID) are
+// read as separate events without a repeat. The events don't have ids or owners.
+func UnmarshallICal(data string, properties ICalProperties) ([]*Event, error) {
+	if err := ValidateICalProperties(properties); err != nil {
+		return nil, err
+	}
+	var events []*Event
+	var e *Event
+	// depth is how far into the components of a VEVENT (like a VALARM) the line is
+	depth := 0
+	for _, line := range ical.Lines(data) {
+		if line == "" {
+			continue
+		}
+		name, params, value, err := ical.ParseProperty(line)
+		if err != nil {
+			return nil, ErrorInvalidICal
+		}
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT") && e == nil:
+			e = &Event{Zone: "UTC"}
+			continue
+		case name == "BEGIN" && e != nil:
+			depth++
+			continue
+		case name == "END" && e != nil && depth > 0:
+			depth--
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT") && e != nil:
+			events = append(events, e)
+			e = nil
+			continue
+		}
+		if e == nil || depth > 0 {
+			continue
+		}
+		switch name {
+		case "DTSTART", "DTEND":
+			err = setICalTime(e, name == "DTSTART", params, value)
+		case "SUMMARY":
+			e.Title = ical.UnescapeText(value)
+		case "DESCRIPTION":
+			description := ical.UnescapeText(value)
+			e.Description = &description
+		case "CLASS":
+			if strings.EqualFold(value, "PUBLIC") {
+				e.Visibility = VisibilityPublic
+			}
+		case "PRIORITY":
+			priority, parseErr := strconv.ParseInt(value, 10, 64)
+			if parseErr != nil || !ValidPriority(Priority(priority)) {
+				return nil, ErrorInvalidICal
+			}
+			e.Priority = Priority(priority)
+		default:
+			err = properties.userData(e, name, params, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if e != nil {
+		return nil, ErrorInvalidICal
+	}
+	return events, nil
+}
+
+// setICalTime sets the start or end of the event from a DTSTART or DTEND, where a DATE value
+// makes the event an all day event (and the DTEND of an all day event is the day after it ends)
+func setICalTime(e *Event, start bool, params []ical.Param, value string) error {
+	if strings.EqualFold(ical.ParamOf(params, "VALUE"), "DATE") {
+		t, err := time.Parse("20060102", value)
+		if err != nil {
+			return ErrorInvalidICal
+		}
+		e.IsAllDay = true
+		if start {
+			e.StartDay, e.StartTime = t.Format(time.DateOnly), ""
+		} else {
+			e.EndDay, e.EndTime = t.AddDate(0, 0, -1).Format(time.DateOnly), ""
+		}
+		return nil
+	}
+	var t time.Time
+	var err error
+	if zone := ical.ParamOf(params, "TZID"); zone != "" {
+		e.Zone = zone
+		t, err = time.Parse(iCalLocalFormat, value)
+	} else if t, err = time.Parse(iCalDateTimeFormat, value); err != nil {
+		// a floating time without a zone
+		t, err = time.Parse(iCalLocalFormat, value)
+	}
+	if err != nil {
+		return ErrorInvalidICal
+	}
+	if start {
+		e.StartDay, e.StartTime = t.Format(time.DateOnly), formatTime(t)
+	} else {
+		e.EndDay, e.EndTime = t.Format(time.DateOnly), formatTime(t)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package cali
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICalProperties(t *testing.T) {
+	properties := ICalProperties{"color": "X-APP-COLOR", "rooms": "X-APP-ROOMS", "notes": "X-APP-NOTES"}
+	c := NewCalendar(&InMemoryDataStore{}, WithICalProperties(properties))
+	description := "Agenda; budget"
+	_, _, err := c.Create(Event{
+		OwnerId: 1, Title: "Planning, Q3", Description: &description, Zone: den, Priority: PriorityHigh, Visibility: VisibilityPublic,
+		StartDay: "2008-07-01", StartTime: "09:00", EndDay: "2008-07-01", EndTime: "10:30",
+		UserData: map[string]interface{}{"color": "blue", "rooms": []interface{}{"A", 2.0}, "notes": "line one\nline two", "secret": "not exported"},
+	})
+	require.NoError(t, err)
+
+	data, err := c.ExportICal(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	assert.Contains(t, data, "\r\nX-APP-COLOR:blue\r\n")
+	assert.Contains(t, data, "\r\nX-APP-NOTES:line one\\nline two\r\n")
+	assert.Contains(t, data, "\r\nX-APP-ROOMS;X-VALUE=JSON:[\"A\"\\,2]\r\n")
+	assert.NotContains(t, data, "not exported")
+
+	events, err := c.UnmarshallICal(data)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	e := events[0]
+	assert.Equal(t, "Planning, Q3", e.Title)
+	assert.Equal(t, description, *e.Description)
+	assert.Equal(t, den, e.Zone)
+	assert.Equal(t, "2008-07-01", e.StartDay)
+	assert.Equal(t, "09:00", e.StartTime)
+	assert.Equal(t, "10:30", e.EndTime)
+	assert.Equal(t, PriorityHigh, e.Priority)
+	assert.Equal(t, VisibilityPublic, e.Visibility)
+	assert.Equal(t, map[string]interface{}{"color": "blue", "rooms": []interface{}{"A", 2.0}, "notes": "line one\nline two"}, e.UserData)
+
+	// the properties aren't exported or imported without the mapping
+	events, err = UnmarshallICal(data, nil)
+	require.NoError(t, err)
+	assert.Nil(t, events[0].UserData)
+
+	_, err = MarshallCalendarToICal(nil, ICalProperties{"color": "COLOR"})
+	assert.Equal(t, ErrorInvalidICalProperty, err)
+	_, err = UnmarshallICal(data, ICalProperties{"a": "X-A", "b": "x-a"})
+	assert.Equal(t, ErrorInvalidICalProperty, err)
+}
+
+func TestUnmarshallICal(t *testing.T) {
+	data := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"DTSTART;VALUE=DATE:20080704",
+		"DTEND;VALUE=DATE:20080706",
+		"SUMMARY:Long weekend",
+		"BEGIN:VALARM",
+		"DESCRIPTION:Reminder",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20080101T090000",
+		"DTEND:20080101T093000",
+		"SUMMARY:Floating",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\n")
+	events, err := UnmarshallICal(data, nil)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.True(t, events[0].IsAllDay)
+	assert.Equal(t, "2008-07-04", events[0].StartDay)
+	assert.Equal(t, "2008-07-05", events[0].EndDay)
+	assert.Nil(t, events[0].Description)
+	assert.Equal(t, "09:00", events[1].StartTime)
+	assert.Equal(t, "UTC", events[1].Zone)
+
+	_, err = UnmarshallICal("BEGIN:VEVENT\nDTSTART:tomorrow\nEND:VEVENT", nil)
+	assert.Equal(t, ErrorInvalidICal, err)
+	_, err = UnmarshallICal("BEGIN:VEVENT\nSUMMARY:Unfinished", nil)
+	assert.Equal(t, ErrorInvalidICal, err)
+}
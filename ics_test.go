@@ -0,0 +1,78 @@
+package cali
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesICal(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	_, _, err := c.Create(Event{OwnerId: 1, Title: "Standup", Zone: "UTC", IsRepeating: true, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15", Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 4}})
+	require.NoError(t, err)
+	events, err := c.Query(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	// move the first event, rename the second, and cancel the last
+	require.NoError(t, c.UpdateTime(events[0].Id, "10:00", "10:30", RepeatEditTypeThis))
+	require.NoError(t, c.UpdateTitle(events[1].Id, "Planning", RepeatEditTypeThis))
+	require.NoError(t, c.Cancel(events[3].Id, RepeatEditTypeThis))
+	e, err := c.Get(events[0].Id)
+	require.NoError(t, err)
+	assert.Equal(t, "2008-01-01 09:00", e.RecurrenceId)
+
+	ical, err := c.SeriesICal(events[2].Id)
+	require.NoError(t, err)
+	uid := fmt.Sprintf("UID:%v", *events[0].ParentId)
+	assert.Equal(t, strings.Join([]string{
+		"BEGIN:VEVENT",
+		uid,
+		"DTSTAMP:20080101T090000Z",
+		"DTSTART:20080101T090000Z",
+		"DTEND:20080101T091500Z",
+		"RDATE:20080102T090000Z",
+		"RDATE:20080103T090000Z",
+		"RDATE:20080104T090000Z",
+		"EXDATE:20080104T090000Z",
+		"SUMMARY:Standup",
+		"CLASS:PRIVATE",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		uid,
+		"DTSTAMP:20080101T100000Z",
+		"DTSTART:20080101T100000Z",
+		"DTEND:20080101T103000Z",
+		"RECURRENCE-ID:20080101T090000Z",
+		"SUMMARY:Standup",
+		"CLASS:PRIVATE",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		uid,
+		"DTSTAMP:20080102T090000Z",
+		"DTSTART:20080102T090000Z",
+		"DTEND:20080102T091500Z",
+		"RECURRENCE-ID:20080102T090000Z",
+		"SUMMARY:Planning",
+		"CLASS:PRIVATE",
+		"END:VEVENT",
+	}, "\r\n"), ical)
+
+	single, _, err := c.Create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15"})
+	require.NoError(t, err)
+	_, err = c.SeriesICal(single.Id)
+	assert.Equal(t, ErrorNotRepeatingEvent, err)
+}
+
+func TestMarshallToICalEscaping(t *testing.T) {
+	description := "Agenda: budget; hiring, and\nplanning " + strings.Repeat("x", 80)
+	ical := Event{Id: 1, Title: "Sync, weekly", Description: &description, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00"}.MarshallToICal()
+	assert.Contains(t, ical, "\r\nSUMMARY:Sync\\, weekly\r\n")
+	assert.Contains(t, ical, "\r\nDESCRIPTION:Agenda: budget\\; hiring\\, and\\nplanning xxx")
+	for _, line := range strings.Split(ical, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+}
@@ -0,0 +1,51 @@
+package cali
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// UIDGenerator makes the UID of the VEVENTs of an ical export, where the id is the Id of a
+// single event or the ParentId of a repeating series. The UID must only depend on the id so
+// that every export of an event has the same UID, and clients that subscribe to the export
+// update their copy of the event instead of adding another one.
+type UIDGenerator func(id int64) string
+
+// DefaultUID uses the id as the UID, which is the UID when the calendar doesn't have a UIDGenerator
+func DefaultUID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// DomainUID makes UIDs like "42@example.com", which RFC 5545 recommends so that the UIDs of
+// different calendars don't collide
+func DomainUID(domain string) UIDGenerator {
+	return func(id int64) string {
+		return fmt.Sprintf("%d@%s", id, domain)
+	}
+}
+
+// HashUID makes UIDs from a hash of the id and the salt, which don't show the ids of the
+// events. The salt should be the same for every export, like the name of the application.
+func HashUID(salt string) UIDGenerator {
+	return func(id int64) string {
+		sum := sha256.Sum256([]byte(salt + ":" + strconv.FormatInt(id, 10)))
+		return hex.EncodeToString(sum[:16])
+	}
+}
+
+// WithUIDGenerator makes the UIDs of the ical exports of the calendar with the generator
+func WithUIDGenerator(generator UIDGenerator) CalendarOption {
+	return func(c *Calendar) {
+		c.uidGenerator = generator
+	}
+}
+
+// uid gets the UID of the VEVENTs of the id
+func (o iCalOptions) uid(id int64) string {
+	if o.uidGenerator == nil {
+		return DefaultUID(id)
+	}
+	return o.uidGenerator(id)
+}
@@ -0,0 +1,53 @@
+package cali
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUIDGenerators(t *testing.T) {
+	assert.Equal(t, "42", DefaultUID(42))
+	assert.Equal(t, "42@example.com", DomainUID("example.com")(42))
+	hash := HashUID("app")
+	assert.Equal(t, hash(42), HashUID("app")(42), "the same id always has the same UID")
+	assert.NotEqual(t, hash(42), hash(43))
+	assert.NotEqual(t, hash(42), HashUID("other")(42))
+	assert.Len(t, hash(42), 32)
+}
+
+func TestWithUIDGenerator(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithUIDGenerator(DomainUID("example.com")))
+	e, _, err := c.Create(Event{Title: "Lunch", Zone: "UTC", StartDay: "2008-01-01", StartTime: "12:00", EndDay: "2008-01-01", EndTime: "13:00"})
+	require.NoError(t, err)
+	series, _, err := c.Create(Event{Title: "Standup", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+
+	first, err := c.ExportICal(Query{})
+	require.NoError(t, err)
+	assert.Contains(t, first, "UID:"+DomainUID("example.com")(e.Id)+"\r\n")
+	assert.Contains(t, first, "UID:"+DomainUID("example.com")(*series.ParentId)+"\r\n")
+
+	// the DTSTAMP can change, but the UIDs of every export are the same
+	second, err := c.ExportICal(Query{})
+	require.NoError(t, err)
+	assert.Equal(t, uids(first), uids(second))
+
+	s, err := c.SeriesICal(series.Id)
+	require.NoError(t, err)
+	assert.Contains(t, s, "UID:"+DomainUID("example.com")(*series.ParentId)+"\r\n")
+}
+
+// uids gets the UID lines of the ical data
+func uids(data string) []string {
+	var result []string
+	for _, line := range strings.Split(data, "\r\n") {
+		if strings.HasPrefix(line, "UID:") {
+			result = append(result, line)
+		}
+	}
+	return result
+}
@@ -0,0 +1,329 @@
+package cali
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Kenoshen/cali/ical"
+)
+
+// ImportOutcome is what an import did with a record (a CSV row, a VEVENT, or a JSON event)
+type ImportOutcome string
+
+const (
+	// ImportOutcomeCreated is for a record that was created as a new event
+	ImportOutcomeCreated ImportOutcome = "created"
+	// ImportOutcomeSkipped is for a record that was left out because it is a duplicate
+	ImportOutcomeSkipped ImportOutcome = "skipped"
+	// ImportOutcomeFailed is for a record that couldn't be read or created
+	ImportOutcomeFailed ImportOutcome = "failed"
+)
+
+// DuplicateMatch is how a record was found to be a duplicate of another event
+type DuplicateMatch string
+
+const (
+	// DuplicateMatchSourceId is for an event with the same SourceId
+	DuplicateMatchSourceId DuplicateMatch = "sourceId"
+	// DuplicateMatchExternalKey is for an event with the same ExternalKey, which is the UID
+	// (and RECURRENCE-ID) of an ical import
+	DuplicateMatchExternalKey DuplicateMatch = "externalKey"
+	// DuplicateMatchTitleAndTime is for an event with the same title (ignoring case) at the
+	// same start and end instants
+	DuplicateMatchTitleAndTime DuplicateMatch = "titleAndTime"
+)
+
+// ImportOptions changes how the events of an import are created
+type ImportOptions struct {
+	// AllowDuplicates creates the records that look like duplicates instead of skipping them
+	AllowDuplicates bool
+	// DryRun reads, maps, and validates every record and finds the duplicates without saving
+	// anything to the data store, so the report has the events that would be created
+	DryRun bool
+}
+
+// ImportRecord is the outcome of a single record of an import
+type ImportRecord struct {
+	// Row is where the record is in the import, which is the line of a CSV row (where the
+	// header is row 1) or the position of a VEVENT or JSON event (starting at 1)
+	Row int `json:"row"`
+	// Outcome is what the import did with the record
+	Outcome ImportOutcome `json:"outcome"`
+	// Event is the created event, or the event that was read from the record if it was
+	// skipped or couldn't be created. It is nil if the record couldn't be read.
+	Event *Event `json:"event"`
+	// DuplicateOf is the id of the existing event that a skipped record duplicates
+	DuplicateOf *int64 `json:"duplicateOf"`
+	// DuplicateOfRow is the row earlier in the import that a skipped record duplicates
+	DuplicateOfRow *int `json:"duplicateOfRow"`
+	// Match is how the duplicate was found
+	Match DuplicateMatch `json:"match,omitempty"`
+	// Error is why the record failed
+	Error error `json:"-"`
+}
+
+// ImportReport has the outcome of every record of an import in the order of the import
+type ImportReport struct {
+	// DryRun is true if nothing was saved (see ImportOptions), where the records that are
+	// ImportOutcomeCreated have the events that would be created (without ids)
+	DryRun  bool           `json:"dryRun"`
+	Records []ImportRecord `json:"records"`
+}
+
+// Created gets the events that the import created, or would create in a dry run
+func (r *ImportReport) Created() []*Event {
+	var events []*Event
+	for _, record := range r.Records {
+		if record.Outcome == ImportOutcomeCreated {
+			events = append(events, record.Event)
+		}
+	}
+	return events
+}
+
+// Skipped gets the records that were left out as duplicates
+func (r *ImportReport) Skipped() []ImportRecord {
+	return r.withOutcome(ImportOutcomeSkipped)
+}
+
+// Failed gets the records that couldn't be read or created
+func (r *ImportReport) Failed() []ImportRecord {
+	return r.withOutcome(ImportOutcomeFailed)
+}
+
+func (r *ImportReport) withOutcome(outcome ImportOutcome) []ImportRecord {
+	var records []ImportRecord
+	for _, record := range r.Records {
+		if record.Outcome == outcome {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// importRecord is a record that was read from an import, or the error of reading it
+type importRecord struct {
+	row   int
+	event Event
+	err   error
+}
+
+// ImportCSVWithOptions reads every row of the CSV (like ImportCSV) and creates an event for
+// each one that isn't a duplicate. Rows that fail don't stop the import, and the report has
+// the outcome of every row. The error is only for a CSV that can't be read at all.
+func (c *Calendar) ImportCSVWithOptions(r io.Reader, mapping ColumnMap, opts ImportOptions) (*ImportReport, error) {
+	records, err := readCSV(r, mapping)
+	if err != nil {
+		return nil, err
+	}
+	return c.importRecords(records, opts)
+}
+
+// ImportICal reads the VEVENTs of the ical data (see UnmarshallICal) and creates an event for
+// each one that isn't a duplicate. The UID of each VEVENT (with its RECURRENCE-ID if it has
+// one) is the ExternalKey of its event, so importing the same data again skips every event.
+// The error is only for data that isn't an ical calendar.
+func (c *Calendar) ImportICal(data string, opts ImportOptions) (*ImportReport, error) {
+	records, err := c.readICal(data)
+	if err != nil {
+		return nil, err
+	}
+	return c.importRecords(records, opts)
+}
+
+// ImportJSON reads a JSON array of events (see InstantEvent) and creates an event for each one
+// that isn't a duplicate. The ids of the events are ignored. The error is only for data that
+// isn't a JSON array.
+func (c *Calendar) ImportJSON(r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+	records := make([]importRecord, len(raw))
+	for i, b := range raw {
+		var e InstantEvent
+		err := json.Unmarshal(b, &e)
+		e.Id, e.ParentId = 0, nil
+		records[i] = importRecord{row: i + 1, event: e.Event, err: err}
+	}
+	return c.importRecords(records, opts)
+}
+
+// readICal reads every VEVENT of the ical data as an import record
+func (c *Calendar) readICal(data string) ([]importRecord, error) {
+	calendar, err := ical.Parse(data)
+	if err != nil {
+		return nil, ErrorInvalidICal
+	}
+	components := calendar.Components
+	if calendar.Name == "VEVENT" {
+		components = []*ical.Component{calendar}
+	}
+	var records []importRecord
+	for _, component := range components {
+		if component.Name != "VEVENT" {
+			continue
+		}
+		record := importRecord{row: len(records) + 1}
+		events, err := UnmarshallICal(ical.Join(component.Lines()), c.iCalProperties)
+		if err != nil {
+			record.err = err
+		} else if len(events) == 1 {
+			record.event = *events[0]
+			record.event.ExternalKey = iCalExternalKey(component)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// iCalExternalKey gets the UID of the VEVENT, with its RECURRENCE-ID if it is an override
+func iCalExternalKey(component *ical.Component) string {
+	var uid, recurrenceId string
+	for _, p := range component.Properties {
+		switch p.Name {
+		case "UID":
+			uid = ical.UnescapeText(p.Value)
+		case "RECURRENCE-ID":
+			recurrenceId = p.Value
+		}
+	}
+	if uid == "" || recurrenceId == "" {
+		return uid
+	}
+	return uid + ";" + recurrenceId
+}
+
+// importRecords creates the events of the records that aren't duplicates of existing events or
+// of earlier records
+func (c *Calendar) importRecords(records []importRecord, opts ImportOptions) (*ImportReport, error) {
+	report := &ImportReport{DryRun: opts.DryRun}
+	seen := map[string]int{}
+	for _, record := range records {
+		result := ImportRecord{Row: record.row}
+		if record.err != nil {
+			result.Outcome, result.Error = ImportOutcomeFailed, record.err
+			report.Records = append(report.Records, result)
+			continue
+		}
+		e := record.event
+		result.Event = &e
+		keys := duplicateKeys(e)
+		if !opts.AllowDuplicates {
+			if row, match, ok := seenDuplicate(seen, keys); ok {
+				result.Outcome, result.DuplicateOfRow, result.Match = ImportOutcomeSkipped, &row, match
+				report.Records = append(report.Records, result)
+				continue
+			}
+			existing, match, err := c.findDuplicate(e)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				result.Outcome, result.DuplicateOf, result.Match = ImportOutcomeSkipped, &existing.Id, match
+				report.Records = append(report.Records, result)
+				continue
+			}
+		}
+		created, err := c.importEvent(e, opts)
+		if err != nil {
+			result.Outcome, result.Error = ImportOutcomeFailed, err
+			report.Records = append(report.Records, result)
+			continue
+		}
+		result.Outcome, result.Event = ImportOutcomeCreated, created
+		report.Records = append(report.Records, result)
+		for _, key := range keys {
+			seen[key.key] = record.row
+		}
+	}
+	return report, nil
+}
+
+// importEvent creates the event, or only checks that it can be created in a dry run
+func (c *Calendar) importEvent(e Event, opts ImportOptions) (*Event, error) {
+	if opts.DryRun {
+		prepared, err := c.prepareCreate(e)
+		if err != nil {
+			return nil, err
+		}
+		return &prepared, nil
+	}
+	created, _, err := c.Create(e)
+	return created, err
+}
+
+// duplicateKey is a key that another record with the same key duplicates
+type duplicateKey struct {
+	key   string
+	match DuplicateMatch
+}
+
+// duplicateKeys gets the keys of the event in the order that duplicates are matched
+func duplicateKeys(e Event) []duplicateKey {
+	var keys []duplicateKey
+	if e.SourceId != nil {
+		keys = append(keys, duplicateKey{fmt.Sprintf("source:%d", *e.SourceId), DuplicateMatchSourceId})
+	}
+	if e.ExternalKey != "" {
+		keys = append(keys, duplicateKey{"external:" + e.ExternalKey, DuplicateMatchExternalKey})
+	}
+	if start, end, err := e.Instants(); err == nil {
+		keys = append(keys, duplicateKey{fmt.Sprintf("time:%d:%d:%s", start.Unix(), end.Unix(), normalizeTitle(e.Title)), DuplicateMatchTitleAndTime})
+	}
+	return keys
+}
+
+// seenDuplicate finds the row of an earlier record with one of the keys
+func seenDuplicate(seen map[string]int, keys []duplicateKey) (int, DuplicateMatch, bool) {
+	for _, key := range keys {
+		if row, ok := seen[key.key]; ok {
+			return row, key.match, true
+		}
+	}
+	return 0, "", false
+}
+
+// findDuplicate finds an existing event that the event duplicates, or nil if there isn't one
+func (c *Calendar) findDuplicate(e Event) (*Event, DuplicateMatch, error) {
+	if e.SourceId != nil {
+		events, err := c.Query(Query{SourceIds: []int64{*e.SourceId}})
+		if err != nil {
+			return nil, "", err
+		}
+		if len(events) > 0 {
+			return events[0], DuplicateMatchSourceId, nil
+		}
+	}
+	if e.ExternalKey != "" {
+		existing, err := c.GetByExternalKey(e.ExternalKey)
+		if err != nil && err != ErrorExternalKeysNotSupported {
+			return nil, "", err
+		}
+		if existing != nil {
+			return existing, DuplicateMatchExternalKey, nil
+		}
+	}
+	start, end, err := e.Instants()
+	if err != nil {
+		return nil, "", nil
+	}
+	events, err := c.Query(Query{Start: &start, End: &end})
+	if err != nil {
+		return nil, "", err
+	}
+	for _, existing := range events {
+		existingStart, existingEnd, err := existing.Instants()
+		if err == nil && existingStart.Equal(start) && existingEnd.Equal(end) && normalizeTitle(existing.Title) == normalizeTitle(e.Title) {
+			return existing, DuplicateMatchTitleAndTime, nil
+		}
+	}
+	return nil, "", nil
+}
+
+// normalizeTitle makes titles that only differ by case or spaces the same
+func normalizeTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
@@ -0,0 +1,131 @@
+package cali
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCSVWithOptions(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	existing, _, err := c.Create(Event{Title: "Standup", Zone: "UTC", StartDay: "2008-01-02", StartTime: "13:00", EndDay: "2008-01-02", EndTime: "13:15"})
+	require.NoError(t, err)
+
+	csv := "Subject,Start Date,Start Time,End Date,End Time\n" +
+		"standup,01/02/2008,1:00 PM,01/02/2008,1:15 PM\n" +
+		"Lunch,01/02/2008,12:00 PM,01/02/2008,1:00 PM\n" +
+		"Broken,01/02/2008,9:00 AM,01/02/2008,nope\n" +
+		"Backwards,01/02/2008,9:00 AM,01/01/2008,9:00 AM\n" +
+		"LUNCH,01/02/2008,12:00 PM,01/02/2008,1:00 PM\n"
+	report, err := c.ImportCSVWithOptions(strings.NewReader(csv), GoogleColumnMap, ImportOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Records, 5)
+
+	assert.Equal(t, ImportOutcomeSkipped, report.Records[0].Outcome)
+	assert.Equal(t, existing.Id, *report.Records[0].DuplicateOf)
+	assert.Equal(t, DuplicateMatchTitleAndTime, report.Records[0].Match)
+	assert.Equal(t, ImportOutcomeCreated, report.Records[1].Outcome)
+	assert.Equal(t, 3, report.Records[1].Row)
+	assert.Equal(t, ImportOutcomeFailed, report.Records[2].Outcome)
+	assert.ErrorIs(t, report.Records[2].Error, ErrorInvalidEndTime)
+	assert.Nil(t, report.Records[2].Event)
+	assert.Equal(t, ImportOutcomeFailed, report.Records[3].Outcome)
+	assert.Equal(t, "Backwards", report.Records[3].Event.Title)
+	assert.Equal(t, ImportOutcomeSkipped, report.Records[4].Outcome)
+	assert.Equal(t, 3, *report.Records[4].DuplicateOfRow, "duplicates in the import are skipped too")
+
+	assert.Len(t, report.Created(), 1)
+	assert.Len(t, report.Skipped(), 2)
+	assert.Len(t, report.Failed(), 2)
+
+	report, err = c.ImportCSVWithOptions(strings.NewReader(csv), GoogleColumnMap, ImportOptions{AllowDuplicates: true})
+	require.NoError(t, err)
+	assert.Len(t, report.Created(), 3)
+
+	_, err = c.ImportCSVWithOptions(strings.NewReader("Title,When\nA,B\n"), GoogleColumnMap, ImportOptions{})
+	assert.ErrorIs(t, err, ErrorMissingCSVColumn)
+}
+
+func TestImportICal(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nUID:standup@example.com\r\nDTSTART:20080102T130000Z\r\nDTEND:20080102T131500Z\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:standup@example.com\r\nRECURRENCE-ID:20080103T130000Z\r\nDTSTART:20080103T140000Z\r\nDTEND:20080103T141500Z\r\nSUMMARY:Standup\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:bad@example.com\r\nDTSTART:nope\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	c := NewCalendar(&InMemoryDataStore{})
+	report, err := c.ImportICal(data, ImportOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Records, 3)
+	assert.Equal(t, ImportOutcomeCreated, report.Records[0].Outcome)
+	assert.Equal(t, "standup@example.com", report.Records[0].Event.ExternalKey)
+	assert.Equal(t, ImportOutcomeCreated, report.Records[1].Outcome, "an override has its own key")
+	assert.Equal(t, "standup@example.com;20080103T130000Z", report.Records[1].Event.ExternalKey)
+	assert.Equal(t, ImportOutcomeFailed, report.Records[2].Outcome)
+	assert.Equal(t, ErrorInvalidICal, report.Records[2].Error)
+
+	report, err = c.ImportICal(data, ImportOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Skipped(), 2, "importing again skips every event")
+	assert.Equal(t, DuplicateMatchExternalKey, report.Records[0].Match)
+
+	_, err = c.ImportICal("not ical", ImportOptions{})
+	assert.Equal(t, ErrorInvalidICal, err)
+}
+
+func TestImportJSON(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	json := `[
+		{"id": 7, "parentId": 3, "sourceId": 42, "title": "Standup", "zone": "UTC", "startDay": "2008-01-02", "startTime": "13:00", "endDay": "2008-01-02", "endTime": "13:15"},
+		{"title": "Moved standup", "sourceId": 42, "zone": "UTC", "startDay": "2008-01-03", "startTime": "13:00", "endDay": "2008-01-03", "endTime": "13:15"},
+		{"title": 5}
+	]`
+	report, err := c.ImportJSON(strings.NewReader(json), ImportOptions{})
+	require.NoError(t, err)
+	require.Len(t, report.Records, 3)
+	assert.Equal(t, ImportOutcomeCreated, report.Records[0].Outcome)
+	assert.Nil(t, report.Records[0].Event.ParentId, "the ids of the JSON are ignored")
+	assert.Equal(t, ImportOutcomeSkipped, report.Records[1].Outcome)
+	assert.Equal(t, DuplicateMatchSourceId, report.Records[1].Match)
+	assert.Equal(t, ImportOutcomeFailed, report.Records[2].Outcome)
+
+	_, err = c.ImportJSON(strings.NewReader(`{}`), ImportOptions{})
+	assert.Error(t, err)
+}
+
+func TestImportDryRun(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithTimeGranularity(15, true))
+	_, _, err := c.Create(Event{Title: "Standup", Zone: "UTC", StartDay: "2008-01-02", StartTime: "13:00", EndDay: "2008-01-02", EndTime: "13:15"})
+	require.NoError(t, err)
+
+	csv := "Subject,Start Date,Start Time,End Date,End Time\n" +
+		"Standup,01/02/2008,1:00 PM,01/02/2008,1:15 PM\n" +
+		"Lunch,01/02/2008,12:00 PM,01/02/2008,1:00 PM\n" +
+		"Lunch,01/02/2008,12:00 PM,01/02/2008,1:00 PM\n" +
+		"Backwards,01/02/2008,9:00 AM,01/01/2008,9:00 AM\n"
+	report, err := c.ImportCSVWithOptions(strings.NewReader(csv), GoogleColumnMap, ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Created(), 1)
+	assert.Equal(t, "Lunch", report.Created()[0].Title)
+	assert.Zero(t, report.Created()[0].Id)
+	assert.Len(t, report.Skipped(), 2)
+	assert.Len(t, report.Failed(), 1)
+
+	events, err := c.Query(Query{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "nothing is saved")
+
+	json := `[{"title": "Offsite", "zone": "UTC", "startDay": "2008-01-02", "startTime": "09:10", "endDay": "2008-01-02", "endTime": "10:00"}]`
+	report, err = c.ImportJSON(strings.NewReader(json), ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	assert.Len(t, report.Failed(), 1, "the events are validated like Create")
+
+	report, err = c.ImportICal("BEGIN:VEVENT\r\nUID:1\r\nDTSTART:20080103T090000Z\r\nDTEND:20080103T100000Z\r\nSUMMARY:Review\r\nEND:VEVENT\r\n", ImportOptions{DryRun: true})
+	require.NoError(t, err)
+	require.Len(t, report.Created(), 1)
+	found, err := c.GetByExternalKey("1")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
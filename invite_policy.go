@@ -0,0 +1,111 @@
+package cali
+
+import (
+	"strings"
+)
+
+// InvitePolicy enforces the rules of an organization on new invitations, like the most
+// users that can be invited to an event or which users can be invited at all
+type InvitePolicy interface {
+	// CheckInvite returns an error if the invite can't be added to the event, where
+	// invites are the current invites of the event (nil if the data store can't list them)
+	CheckInvite(e Event, invite Invite, invites []*Invite) error
+}
+
+// InvitePolicyFunc makes an InvitePolicy out of a function
+type InvitePolicyFunc func(e Event, invite Invite, invites []*Invite) error
+
+func (f InvitePolicyFunc) CheckInvite(e Event, invite Invite, invites []*Invite) error {
+	return f(e, invite, invites)
+}
+
+// WithInvitePolicy checks every new invitation (from InviteUser and InviteUserToSeries)
+// against all of the policies, and the first error stops the invitation
+func WithInvitePolicy(policies ...InvitePolicy) CalendarOption {
+	return func(c *Calendar) {
+		c.invitePolicies = append(c.invitePolicies, policies...)
+	}
+}
+
+// MaxInviteesPolicy limits the number of users (other than the owner) that are invited to an
+// event, where declined invitations count but revoked invitations don't. The data store
+// must implement InviteListStore.
+func MaxInviteesPolicy(max int) InvitePolicy {
+	return InvitePolicyFunc(func(e Event, invite Invite, invites []*Invite) error {
+		if invites == nil {
+			return ErrorInviteListNotSupported
+		}
+		count := 0
+		for _, i := range invites {
+			if i.UserId == e.OwnerId || i.UserId == invite.UserId || i.Status == InviteStatusRevoked {
+				continue
+			}
+			count++
+		}
+		if count >= max {
+			return ErrorTooManyInvitees
+		}
+		return nil
+	})
+}
+
+// SameTenantPolicy only allows users in the same tenant as the owner of the event to be invited
+func SameTenantPolicy(tenant func(userId int64) (string, error)) InvitePolicy {
+	return InvitePolicyFunc(func(e Event, invite Invite, invites []*Invite) error {
+		owner, err := tenant(e.OwnerId)
+		if err != nil {
+			return err
+		}
+		invitee, err := tenant(invite.UserId)
+		if err != nil {
+			return err
+		}
+		if owner != invitee {
+			return ErrorInviteNotAllowed
+		}
+		return nil
+	})
+}
+
+// BlockedDomainsPolicy doesn't allow users with an email address in any of the domains
+// (or their subdomains) to be invited
+func BlockedDomainsPolicy(email func(userId int64) (string, error), domains ...string) InvitePolicy {
+	return InvitePolicyFunc(func(e Event, invite Invite, invites []*Invite) error {
+		address, err := email(invite.UserId)
+		if err != nil {
+			return err
+		}
+		_, domain, _ := strings.Cut(strings.ToLower(address), "@")
+		for _, blocked := range domains {
+			blocked = strings.ToLower(blocked)
+			if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+				return ErrorInviteNotAllowed
+			}
+		}
+		return nil
+	})
+}
+
+// checkInvitePolicies checks the invite for the event against every invite policy
+func (c *Calendar) checkInvitePolicies(eventId int64, invite Invite) error {
+	if len(c.invitePolicies) == 0 {
+		return nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return ErrorEventNotFound
+	}
+	invites, err := c.getInvites(eventId)
+	if err != nil && err != ErrorInviteListNotSupported {
+		return err
+	}
+	for _, policy := range c.invitePolicies {
+		if err := policy.CheckInvite(*e, invite, invites); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvitePolicies(t *testing.T) {
+	emails := map[int64]string{1: "owner@example.com", 2: "a@example.com", 3: "b@example.com", 4: "c@Partner.Example.org", 5: "d@example.com"}
+	tenants := map[int64]string{1: "acme", 2: "acme", 3: "acme", 4: "acme", 5: "other"}
+	c := NewCalendar(&InMemoryDataStore{}, WithInvitePolicy(
+		MaxInviteesPolicy(2),
+		SameTenantPolicy(func(userId int64) (string, error) {
+			return tenants[userId], nil
+		}),
+		BlockedDomainsPolicy(func(userId int64) (string, error) {
+			return emails[userId], nil
+		}, "example.org"),
+	))
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+
+	assert.Equal(t, ErrorInviteNotAllowed, c.InviteUser(e.Id, 4, PermissionViewer, RepeatEditTypeThis), "blocked domain")
+	assert.Equal(t, ErrorInviteNotAllowed, c.InviteUser(e.Id, 5, PermissionViewer, RepeatEditTypeThis), "other tenant")
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionViewer, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionViewer, RepeatEditTypeThis))
+	tenants[6] = "acme"
+	assert.Equal(t, ErrorTooManyInvitees, c.InviteUser(e.Id, 6, PermissionViewer, RepeatEditTypeThis))
+
+	invite, err := c.GetInvitation(e.Id, 6)
+	require.NoError(t, err)
+	assert.Nil(t, invite, "the invite should not be added")
+
+	// revoked invitations don't count toward the max
+	require.NoError(t, c.RevokeInvitation(e.Id, 3, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 6, PermissionViewer, RepeatEditTypeThis))
+}
@@ -0,0 +1,59 @@
+package cali
+
+import (
+	"github.com/Kenoshen/cali/ical"
+)
+
+// ExportJCal marshalls the events that match the query to a jCal (RFC 7265) calendar, which
+// is the JSON form of the ical calendar of ExportICal
+func (c *Calendar) ExportJCal(q Query) ([]byte, error) {
+	calendar, err := c.exportComponent(q)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.JCal()
+}
+
+// ExportXCal marshalls the events that match the query to an xCal (RFC 6321) calendar, which
+// is the XML form of the ical calendar of ExportICal
+func (c *Calendar) ExportXCal(q Query) ([]byte, error) {
+	calendar, err := c.exportComponent(q)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.XCal()
+}
+
+// MarshallCalendarToJCal marshalls the events to a jCal (RFC 7265) calendar (see MarshallCalendarToICal)
+func MarshallCalendarToJCal(events []*Event, properties ICalProperties) ([]byte, error) {
+	calendar, err := marshallCalendarToComponent(events, properties)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.JCal()
+}
+
+// MarshallCalendarToXCal marshalls the events to an xCal (RFC 6321) calendar (see MarshallCalendarToICal)
+func MarshallCalendarToXCal(events []*Event, properties ICalProperties) ([]byte, error) {
+	calendar, err := marshallCalendarToComponent(events, properties)
+	if err != nil {
+		return nil, err
+	}
+	return calendar.XCal()
+}
+
+func (c *Calendar) exportComponent(q Query) (*ical.Component, error) {
+	data, err := c.ExportICal(q)
+	if err != nil {
+		return nil, err
+	}
+	return ical.Parse(data)
+}
+
+func marshallCalendarToComponent(events []*Event, properties ICalProperties) (*ical.Component, error) {
+	data, err := MarshallCalendarToICal(events, properties)
+	if err != nil {
+		return nil, err
+	}
+	return ical.Parse(data)
+}
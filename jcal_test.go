@@ -0,0 +1,34 @@
+package cali
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportJCal(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	_, _, err := c.Create(Event{OwnerId: 1, Title: "Lunch", Zone: den, StartDay: "2008-01-01", StartTime: "12:00", EndDay: "2008-01-01", EndTime: "13:00"})
+	require.NoError(t, err)
+
+	b, err := c.ExportJCal(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	var jcal []interface{}
+	require.NoError(t, json.Unmarshal(b, &jcal))
+	require.Len(t, jcal, 3)
+	assert.Equal(t, "vcalendar", jcal[0])
+	components := jcal[2].([]interface{})
+	require.Len(t, components, 2)
+	assert.Equal(t, "vtimezone", components[0].([]interface{})[0])
+	vevent := components[1].([]interface{})
+	assert.Equal(t, "vevent", vevent[0])
+	assert.Contains(t, vevent[1], []interface{}{"dtstart", map[string]interface{}{"tzid": den}, "date-time", "2008-01-01T12:00:00"})
+	assert.Contains(t, vevent[1], []interface{}{"summary", map[string]interface{}{}, "text", "Lunch"})
+
+	x, err := c.ExportXCal(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	assert.Contains(t, string(x), "<summary><text>Lunch</text></summary>")
+	assert.Contains(t, string(x), "<dtstart><parameters><tzid><text>America/Denver</text></tzid></parameters><date-time>2008-01-01T12:00:00</date-time></dtstart>")
+}
@@ -0,0 +1,92 @@
+package cali
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// InstantEvent wraps an event to change how it is converted to and from JSON. Along
+// with all of the normal event fields, the JSON also has "start" and "end" RFC3339
+// instants calculated from the day, time, and zone fields. When reading JSON, either
+// the day and time fields or the instants can be given.
+type InstantEvent struct {
+	Event
+}
+
+// instantEventJSON is the JSON shape of an InstantEvent
+type instantEventJSON struct {
+	plainEvent
+	Start *time.Time `json:"start"`
+	End   *time.Time `json:"end"`
+}
+
+// plainEvent has the same fields as Event without any of the JSON methods
+type plainEvent Event
+
+// Instants gets the start and end moments of the event in the event's zone. All day
+// events start at midnight of the start day and end at midnight after the end day.
+func (e Event) Instants() (time.Time, time.Time, error) {
+	return e.zonedSpan()
+}
+
+// MarshalJSON writes the event with the extra "start" and "end" instants
+func (e InstantEvent) MarshalJSON() ([]byte, error) {
+	v := instantEventJSON{plainEvent: plainEvent(e.Event)}
+	if start, end, err := e.Instants(); err == nil {
+		v.Start = &start
+		v.End = &end
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON reads the event where the "start" and "end" instants are only used
+// when the matching day fields are missing. The instants are converted into the
+// event's zone, or into UTC if the event doesn't have a zone.
+func (e *InstantEvent) UnmarshalJSON(b []byte) error {
+	var v instantEventJSON
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	e.Event = Event(v.plainEvent)
+
+	loc := time.UTC
+	if e.Zone != "" {
+		l, err := time.LoadLocation(e.Zone)
+		if err != nil {
+			return ErrorInvalidZone
+		}
+		loc = l
+	} else if v.Start != nil || v.End != nil {
+		e.Zone = "UTC"
+	}
+
+	if e.StartDay == "" && v.Start != nil {
+		start := v.Start.In(loc)
+		e.StartDay = start.Format(time.DateOnly)
+		if !e.IsAllDay {
+			e.StartTime = formatTime(start)
+		}
+	}
+	if e.EndDay == "" && v.End != nil {
+		end := v.End.In(loc)
+		if e.IsAllDay {
+			// the end instant of an all day event is the midnight after the last day
+			e.EndDay = end.Add(-time.Nanosecond).Format(time.DateOnly)
+		} else {
+			e.EndDay = end.Format(time.DateOnly)
+			e.EndTime = formatTime(end)
+		}
+	}
+	return nil
+}
+
+// InstantEvents wraps each event so they are all converted to JSON with instants
+func InstantEvents(events []*Event) []InstantEvent {
+	result := make([]InstantEvent, 0, len(events))
+	for _, e := range events {
+		if e != nil {
+			result = append(result, InstantEvent{Event: *e})
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package cali
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstantEventMarshal(t *testing.T) {
+	b, err := json.Marshal(InstantEvent{Event{Id: 1, Title: "a", StartDay: "2008-01-02", StartTime: "09:00", EndDay: "2008-01-02", EndTime: "10:30", Zone: den}})
+	require.NoError(t, err)
+	var out map[string]interface{}
+	require.NoError(t, json.Unmarshal(b, &out))
+	assert.Equal(t, "2008-01-02T09:00:00-07:00", out["start"])
+	assert.Equal(t, "2008-01-02T10:30:00-07:00", out["end"])
+	assert.Equal(t, "2008-01-02", out["startDay"])
+	assert.Equal(t, "a", out["title"])
+
+	b, err = json.Marshal(InstantEvent{Event{StartDay: "2008-01-02", EndDay: "2008-01-03", IsAllDay: true, Zone: "UTC"}})
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &out))
+	assert.Equal(t, "2008-01-02T00:00:00Z", out["start"])
+	assert.Equal(t, "2008-01-04T00:00:00Z", out["end"])
+
+	// plain events don't get the instants
+	b, err = json.Marshal(Event{StartDay: "2008-01-02", EndDay: "2008-01-03", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), `"start"`)
+}
+
+func TestInstantEventUnmarshal(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		out  Event
+	}{
+		{
+			name: "instants in event zone",
+			in:   `{"title":"a","zone":"America/Denver","start":"2008-01-02T16:00:00Z","end":"2008-01-02T17:30:00Z"}`,
+			out:  Event{Title: "a", Zone: den, StartDay: "2008-01-02", StartTime: "09:00", EndDay: "2008-01-02", EndTime: "10:30"},
+		},
+		{
+			name: "instants without zone",
+			in:   `{"start":"2008-01-02T09:00:00-07:00","end":"2008-01-02T10:00:00-07:00"}`,
+			out:  Event{Zone: "UTC", StartDay: "2008-01-02", StartTime: "16:00", EndDay: "2008-01-02", EndTime: "17:00"},
+		},
+		{
+			name: "day fields win",
+			in:   `{"zone":"UTC","startDay":"2008-01-05","startTime":"01:00","endDay":"2008-01-05","endTime":"02:00","start":"2008-01-02T09:00:00Z","end":"2008-01-02T10:00:00Z"}`,
+			out:  Event{Zone: "UTC", StartDay: "2008-01-05", StartTime: "01:00", EndDay: "2008-01-05", EndTime: "02:00"},
+		},
+		{
+			name: "all day",
+			in:   `{"isAllDay":true,"zone":"UTC","start":"2008-01-02T00:00:00Z","end":"2008-01-04T00:00:00Z"}`,
+			out:  Event{Zone: "UTC", IsAllDay: true, StartDay: "2008-01-02", EndDay: "2008-01-03"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			var e InstantEvent
+			require.NoError(t, json.Unmarshal([]byte(tc.in), &e))
+			assert.Equal(t, tc.out, e.Event)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package cali
+
+import (
+	"net/url"
+
+	"github.com/Kenoshen/cali/ical"
+)
+
+// LinkType is what a link of an event is for
+type LinkType string
+
+const (
+	// LinkTypeOther is for a link that isn't one of the other types
+	LinkTypeOther LinkType = ""
+	// LinkTypeAgenda is for the agenda or notes of the meeting
+	LinkTypeAgenda LinkType = "agenda"
+	// LinkTypeDialIn is for joining the meeting, like a video call or a tel: number
+	LinkTypeDialIn LinkType = "dialIn"
+	// LinkTypeRecording is for the recording of the meeting
+	LinkTypeRecording LinkType = "recording"
+)
+
+// Link is one of the links of an event, like its agenda, dial-in, or recording
+type Link struct {
+	// Label is what the link is shown as, like "Agenda"
+	Label string `json:"label"`
+	// Url is where the link goes, which must be an absolute URL (like https: or tel:)
+	Url string `json:"url"`
+	// Type is what the link is for
+	Type LinkType `json:"type"`
+}
+
+// LinkStore is an optional interface for a data store that can save the links of events
+type LinkStore interface {
+	// SetLinks updates the links of the event, where nil removes them
+	SetLinks(eventId int64, links []Link) error
+}
+
+// ValidateLinks checks that the url of every link is an absolute URL
+func ValidateLinks(links []Link) error {
+	for _, link := range links {
+		u, err := url.Parse(link.Url)
+		if err != nil || u.Scheme == "" || (u.Host == "" && u.Opaque == "") {
+			return ErrorInvalidLink
+		}
+	}
+	return nil
+}
+
+// UpdateLinks sets the links of the event, which replace all of its links
+func (c *Calendar) UpdateLinks(eventId int64, links []Link, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[LinkStore](c.dataStore)
+		if !ok {
+			return ErrorLinksNotSupported
+		}
+		if err := ValidateLinks(links); err != nil {
+			return err
+		}
+		return c.editField(OverrideLinks, editType, eventId, func(eventId int64) error {
+			return store.SetLinks(eventId, links)
+		})
+	})
+}
+
+// iCalLinkLines gets the URL and LINK (RFC 9253) lines of the event, where the URL is the Url
+// of the event or else its first link, since a VEVENT can only have one URL
+func (e Event) iCalLinkLines() []string {
+	var lines []string
+	if e.Url != nil && len(*e.Url) > 0 {
+		lines = append(lines, ical.Property("URL", *e.Url))
+	} else if len(e.Links) > 0 {
+		lines = append(lines, ical.Property("URL", e.Links[0].Url))
+	}
+	for _, link := range e.Links {
+		params := []ical.Param{{Name: "VALUE", Value: "URI"}}
+		if link.Type != LinkTypeOther {
+			params = append(params, ical.Param{Name: "LINKREL", Value: string(link.Type)})
+		}
+		if link.Label != "" {
+			params = append(params, ical.Param{Name: "LABEL", Value: link.Label})
+		}
+		lines = append(lines, ical.Property("LINK", link.Url, params...))
+	}
+	return lines
+}
+
+// copyLinks copies the links so that the copy can be saved without sharing the slice
+func copyLinks(links []Link) []Link {
+	if links == nil {
+		return nil
+	}
+	return append([]Link(nil), links...)
+}
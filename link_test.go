@@ -0,0 +1,74 @@
+package cali
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kenoshen/cali/ical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLinks(t *testing.T) {
+	assert.NoError(t, ValidateLinks(nil))
+	assert.NoError(t, ValidateLinks([]Link{{Url: "https://example.com/agenda"}, {Url: "tel:+15555550100"}}))
+	for _, url := range []string{"", "agenda.pdf", "/agenda", "https://", "://example.com"} {
+		assert.Equal(t, ErrorInvalidLink, ValidateLinks([]Link{{Url: url}}), url)
+	}
+}
+
+func TestUpdateLinks(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store)
+			agenda := Link{Label: "Agenda", Url: "https://docs.example.com/agenda", Type: LinkTypeAgenda}
+			e, _, err := c.Create(Event{Title: "Planning", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Links: []Link{agenda}})
+			require.NoError(t, err)
+			_, _, err = c.Create(Event{Title: "Bad", Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Links: []Link{{Url: "agenda.pdf"}}})
+			assert.Equal(t, ErrorInvalidLink, err)
+
+			links := []Link{agenda, {Label: "Dial-in", Url: "https://meet.example.com/planning", Type: LinkTypeDialIn}, {Label: "Recording", Url: "https://video.example.com/1", Type: LinkTypeRecording}}
+			require.NoError(t, c.UpdateLinks(e.Id, links, RepeatEditTypeThis))
+			assert.Equal(t, ErrorInvalidLink, c.UpdateLinks(e.Id, []Link{{Url: "nope"}}, RepeatEditTypeThis))
+			got, err := c.Get(e.Id)
+			require.NoError(t, err)
+			assert.Equal(t, links, got.Links)
+
+			require.NoError(t, c.UpdateLinks(e.Id, nil, RepeatEditTypeThis))
+			got, err = c.Get(e.Id)
+			require.NoError(t, err)
+			assert.Empty(t, got.Links)
+		})
+	}
+	assert.Equal(t, ErrorLinksNotSupported, NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}).UpdateLinks(1, nil, RepeatEditTypeThis))
+}
+
+func TestLinksICal(t *testing.T) {
+	e := Event{Id: 1, Title: "Planning", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00",
+		Links: []Link{{Label: "Agenda; v2", Url: "https://docs.example.com/agenda", Type: LinkTypeAgenda}, {Url: "tel:+15555550100"}}}
+	ics := e.MarshallToICal()
+	assert.Contains(t, ics, "\r\nURL:https://docs.example.com/agenda\r\n", "the first link is the URL when the event has no Url")
+	assert.Contains(t, ical.Unfold(ics), "\r\nLINK;VALUE=URI;LINKREL=agenda;LABEL=\"Agenda; v2\":https://docs.example.com/agenda\r\n")
+	assert.Contains(t, ics, "\r\nLINK;VALUE=URI:tel:+15555550100\r\n")
+
+	url := "https://example.com/planning"
+	e.Url = &url
+	ics = e.MarshallToICal()
+	assert.Contains(t, ics, "\r\nURL:https://example.com/planning\r\n")
+	assert.Equal(t, 1, strings.Count(ics, "URL:"))
+}
@@ -0,0 +1,115 @@
+package cali
+
+import (
+	"time"
+)
+
+// EventLock is an advisory lock that a user holds on an event while they edit it, so that
+// collaborative applications can show who is editing the event
+type EventLock struct {
+	// EventId is the event that is locked
+	EventId int64 `json:"eventId"`
+	// UserId is the user that holds the lock
+	UserId int64 `json:"userId"`
+	// Acquired is when the user locked the event, refreshing the lock doesn't change it
+	Acquired time.Time `json:"acquired"`
+	// Expires is when the lock is released if the user doesn't refresh it
+	Expires time.Time `json:"expires"`
+}
+
+// Held returns true if the lock hasn't expired at the time
+func (l EventLock) Held(now time.Time) bool {
+	return now.Before(l.Expires)
+}
+
+// LockStore is an optional interface for a data store that can save the advisory locks of events
+type LockStore interface {
+	// LockEvent saves the lock of the event. If another user holds a lock on the event that
+	// hasn't expired at now, then it returns ErrorEventLocked and the lock isn't changed. If
+	// the user already holds the lock, then only the Expires field is changed.
+	LockEvent(lock EventLock, now time.Time) error
+	// GetEventLock retrieves the lock of the event, even if it has expired, or nil if there isn't one
+	GetEventLock(eventId int64) (*EventLock, error)
+	// UnlockEvent removes the lock of the event if the user holds it
+	UnlockEvent(eventId int64, userId int64) error
+}
+
+// LockEvent locks the event for the user for the ttl, or refreshes the user's lock. If
+// another user is editing the event, then it returns their lock with ErrorEventLocked
+// (for example, to show "Alice is editing this event").
+//
+// Locks are advisory for reads, but edits through a calendar scoped to a user (see AsUser)
+// return ErrorEventLocked when another user holds the lock of any of the edited events,
+// so two users can't edit the same series at the same time.
+func (c *Calendar) LockEvent(eventId int64, userId int64, ttl time.Duration) (*EventLock, error) {
+	store, ok := capability[LockStore](c.dataStore)
+	if !ok {
+		return nil, ErrorLocksNotSupported
+	}
+	if ttl <= 0 {
+		return nil, ErrorInvalidLockDuration
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	now := time.Now()
+	err = store.LockEvent(EventLock{EventId: eventId, UserId: userId, Acquired: now, Expires: now.Add(ttl)}, now)
+	if err == ErrorEventLocked {
+		held, getErr := c.GetEventLock(eventId)
+		if getErr != nil {
+			return nil, getErr
+		}
+		return held, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.GetEventLock(eventId)
+}
+
+// UnlockEvent releases the user's lock on the event, it does nothing if the user doesn't hold it
+func (c *Calendar) UnlockEvent(eventId int64, userId int64) error {
+	store, ok := capability[LockStore](c.dataStore)
+	if !ok {
+		return ErrorLocksNotSupported
+	}
+	return store.UnlockEvent(eventId, userId)
+}
+
+// GetEventLock gets the lock of the event, or nil if nobody is editing it
+func (c *Calendar) GetEventLock(eventId int64) (*EventLock, error) {
+	store, ok := capability[LockStore](c.dataStore)
+	if !ok {
+		return nil, ErrorLocksNotSupported
+	}
+	lock, err := store.GetEventLock(eventId)
+	if err != nil || lock == nil || !lock.Held(time.Now()) {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// checkLocks returns ErrorEventLocked if the calendar is scoped to a user and another user
+// holds the lock of one of the events of the edit. Calendars without an actor and data
+// stores without locks aren't checked.
+func (c *Calendar) checkLocks(editType RepeatEditType, eventId int64) error {
+	store, ok := capability[LockStore](c.dataStore)
+	if !ok || c.actor == nil {
+		return nil
+	}
+	now := time.Now()
+	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
+		lock, err := store.GetEventLock(eventId)
+		if err != nil {
+			return err
+		}
+		if lock != nil && lock.UserId != c.actor.UserId && lock.Held(now) {
+			return ErrorEventLocked
+		}
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockEvent(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	_, count, err := c.Create(Event{OwnerId: 1, Title: "Standup", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), count)
+	events, err := c.Query(Query{})
+	require.NoError(t, err)
+	first, last := events[0].Id, events[2].Id
+
+	lock, err := c.GetEventLock(last)
+	require.NoError(t, err)
+	assert.Nil(t, lock)
+
+	lock, err = c.LockEvent(last, 1, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, last, lock.EventId)
+	assert.Equal(t, int64(1), lock.UserId)
+	acquired := lock.Acquired
+
+	// another user sees who holds the lock
+	lock, err = c.LockEvent(last, 2, time.Minute)
+	assert.Equal(t, ErrorEventLocked, err)
+	require.NotNil(t, lock)
+	assert.Equal(t, int64(1), lock.UserId)
+
+	// the same user refreshes the lock
+	lock, err = c.LockEvent(last, 1, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, acquired, lock.Acquired)
+	assert.True(t, lock.Expires.After(time.Now().Add(time.Minute)))
+
+	// edits of the locked event by other users fail, even as a part of a series edit
+	alice, bob := c.AsUser(1), c.AsUser(2)
+	assert.Equal(t, ErrorEventLocked, bob.UpdateTitle(first, "Retro", RepeatEditTypeAll))
+	assert.Equal(t, ErrorEventLocked, bob.Cancel(last, RepeatEditTypeThis))
+	require.NoError(t, bob.UpdateTitle(first, "Retro", RepeatEditTypeThis))
+	require.NoError(t, alice.UpdateTitle(first, "Planning", RepeatEditTypeAll))
+	require.NoError(t, c.UpdateTitle(first, "Planning", RepeatEditTypeAll), "calendars without an actor aren't checked")
+
+	require.NoError(t, c.UnlockEvent(last, 2), "only the holder can unlock")
+	lock, err = c.GetEventLock(last)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+	require.NoError(t, c.UnlockEvent(last, 1))
+	lock, err = c.GetEventLock(last)
+	require.NoError(t, err)
+	assert.Nil(t, lock)
+	require.NoError(t, bob.Cancel(last, RepeatEditTypeThis))
+
+	_, err = c.LockEvent(first, 1, 0)
+	assert.Equal(t, ErrorInvalidLockDuration, err)
+	_, err = c.LockEvent(100, 1, time.Minute)
+	assert.Equal(t, ErrorEventNotFound, err)
+}
+
+func TestEventLockExpires(t *testing.T) {
+	store := &InMemoryDataStore{}
+	c := NewCalendar(store)
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, store.LockEvent(EventLock{EventId: e.Id, UserId: 1, Acquired: past, Expires: past.Add(time.Minute)}, past))
+	lock, err := c.GetEventLock(e.Id)
+	require.NoError(t, err)
+	assert.Nil(t, lock, "expired locks aren't held")
+	require.NoError(t, c.AsUser(2).UpdateTitle(e.Id, "Mine now", RepeatEditTypeThis))
+	lock, err = c.LockEvent(e.Id, 2, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), lock.UserId)
+}
+
+func TestLocksNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	_, err := c.LockEvent(1, 1, time.Minute)
+	assert.Equal(t, ErrorLocksNotSupported, err)
+	_, err = c.GetEventLock(1)
+	assert.Equal(t, ErrorLocksNotSupported, err)
+	assert.Equal(t, ErrorLocksNotSupported, c.UnlockEvent(1, 1))
+}
@@ -5,6 +5,8 @@ import (
 	"sort"
 	"strings"
 	"time"
+
+	"github.com/Kenoshen/cali/ical"
 )
 
 // Event is a single record of an event and also contains links to other events through
@@ -17,6 +19,9 @@ type Event struct {
 	CalendarId int64 `json:"calendarId"`
 	// SourceId represents an id for an external source object that this event is directly tied to
 	SourceId *int64 `json:"sourceId"`
+	// ExternalKey is an optional key that another system uses for the event, which is unique
+	// in the data store if it isn't empty (see GetByExternalKey)
+	ExternalKey string `json:"externalKey"`
 	// ParentId is the id of another event that this event is related to via repeating events
 	// and can be used to update other related repeating events when this one changes
 	ParentId *int64 `json:"parentId"`
@@ -32,9 +37,29 @@ type Event struct {
 	Description *string `json:"description"`
 	// Url is a quick way to set the destination on an event that is clicked on in an interface
 	Url *string `json:"url"`
+	// Links are the other links of the event, like its agenda, dial-in, and recording
+	Links []Link `json:"links"`
+	// Location is a free-form description of where the event takes place
+	Location *string `json:"location"`
+	// Geo is the latitude and longitude of the location
+	Geo *GeoPoint `json:"geo"`
 	// Status represents the current status of the event, defaults to active, but events can also
 	// be canceled or removed
 	Status Status `json:"status"`
+	// CancelReason is why the event was canceled (see CancelWithReason)
+	CancelReason *string `json:"cancelReason"`
+	// Priority is the importance of the event from 1 (highest) to 9 (lowest) where 0 is undefined
+	Priority Priority `json:"priority"`
+	// Visibility is who can see the event, defaults to private which is only the invited users
+	Visibility Visibility `json:"visibility"`
+	// DisallowForwarding is true if invitees can't forward their invitation to other users
+	DisallowForwarding bool `json:"disallowForwarding"`
+
+	// AddConference is set when creating an event to have the calendar's ConferenceProvider
+	// create an online meeting for the event
+	AddConference bool `json:"addConference"`
+	// Conference is the online meeting for the event
+	Conference *Conference `json:"conference"`
 
 	// IsAllDay is true if the event is an all day event which will set the time values to 00:00
 	IsAllDay bool `json:"isAllDay"`
@@ -43,6 +68,9 @@ type Event struct {
 	IsRepeating bool `json:"isRepeating"`
 	// Repeat is the pattern to repeat the event
 	Repeat *Repeat `json:"repeat"`
+	// AnniversaryYears is the number of years since the first occurrence of an anniversary (see
+	// Repeat.Anniversary), like 30 for a 30th birthday, or nil for other events
+	AnniversaryYears *int64 `json:"anniversaryYears"`
 
 	// Zone must be a valid time.Location name like "UTC" or "America/New_York"
 	Zone string `json:"zone"`
@@ -56,14 +84,34 @@ type Event struct {
 	EndDay string `json:"endDay"`
 	// EndTime is the HH:MM value representing the end time of this event
 	EndTime string `json:"endTime"`
+	// DurationMinutes is an optional alternative to EndDay and EndTime. If the event is created
+	// with a duration and without an end, then the end is worked out from the start and the
+	// duration, and the data store keeps the duration up to date when the times are changed.
+	DurationMinutes *int64 `json:"durationMinutes"`
+
+	// Overrides are the fields (like OverrideTitle) that were edited on this event of a series on
+	// its own, which edits to the rest of the series don't change (see OverrideStore)
+	Overrides []string `json:"overrides"`
+	// RecurrenceId is the YYYY-MM-DD HH:MM start (or YYYY-MM-DD start of an all day event) that
+	// the event had in its series before its time was overridden, or "" if it wasn't
+	RecurrenceId string `json:"recurrenceId"`
 
 	// Created is a UTC timestamp for when the event was created
 	Created time.Time `json:"created"`
 	// Updated is a UTC timestamp for when the event was modified last
 	Updated time.Time `json:"updated"`
+	// Version is incremented by the data store every time the event is modified
+	Version int64 `json:"version"`
 
 	// UserData is a custom and optional blob of JSON saved to the event
 	UserData map[string]interface{} `json:"userData"`
+
+	// Display is set by the display rules of the calendar (see WithDisplayRules) when the
+	// event is read, and it is never saved to the data store
+	Display *Display `json:"display"`
+	// ReactionCounts are the number of reactions with each emoji, which are set when the event
+	// is read if the calendar has WithReactionCounts, and are never saved to the data store
+	ReactionCounts map[string]int64 `json:"reactionCounts"`
 }
 
 // Start gets the time.Time value using the StartDay and StartTime fields
@@ -76,27 +124,120 @@ func (e Event) End() (time.Time, error) {
 	return parseDayTime(e.EndDay, e.EndTime)
 }
 
-const iCalDateTimeFormat = "20060102T150400Z"
+// span gets the start and end time.Time values of the event where all day
+// events (or events without an end time) last until the end of their end day
+func (e Event) span() (time.Time, time.Time, error) {
+	start, err := e.Start()
+	if err != nil {
+		return start, start, err
+	}
+	end, err := e.End()
+	if err != nil {
+		return start, end, err
+	}
+	if e.IsAllDay || e.EndTime == "" {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// FloatingSpan gets the start and end of the event as "YYYY-MM-DD HH:MM:SS" strings that can
+// be compared as text (in code or in a database) with the FloatingBound of a query. All day
+// events and events without times start at "00:00:00" of the start day and end at "24:00:00"
+// of the end day. The zone of the event is not used, so the strings are the wall clock times.
+func (e Event) FloatingSpan() (string, string) {
+	startTime, endTime := "00:00", "24:00"
+	if !e.IsAllDay && e.StartTime != "" {
+		startTime = e.StartTime
+	}
+	if !e.IsAllDay && e.EndTime != "" {
+		endTime = e.EndTime
+	}
+	return e.StartDay + " " + spanTime(startTime), e.EndDay + " " + spanTime(endTime)
+}
+
+// spanTime adds the seconds to an HH:MM time, so that every time of a FloatingSpan has
+// the same length as a FloatingBound
+func spanTime(t string) string {
+	if len(t) == len(TimeFormat) {
+		return t + ":00"
+	}
+	return t
+}
+
+// FloatingBound formats the query time as the wall clock time in its own location, to the
+// second, so that it can be compared with the FloatingSpan of events
+func FloatingBound(t time.Time) string {
+	return t.Format(time.DateTime)
+}
+
+// InRange returns true if any part of the event is between the start and end (inclusive),
+// where a nil start or end is unbounded. It compares the FloatingSpan of the event with the
+// FloatingBound of the start and end, so an event that ends at 10:00 is in the range starting
+// at 10:00, and an all day event is in every range that includes any time on its days.
+func (e Event) InRange(start, end *time.Time) bool {
+	return e.InBounds(start, end, RangeBoundInclusive, RangeBoundInclusive)
+}
+
+// Overlaps returns true if the two events share any amount of time. Events
+// that touch end to start (10:00-11:00 and 11:00-12:00) do not overlap.
+func (e Event) Overlaps(other Event) bool {
+	aStart, aEnd, err := e.span()
+	if err != nil {
+		return false
+	}
+	bStart, bEnd, err := other.span()
+	if err != nil {
+		return false
+	}
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+const iCalDateTimeFormat = "20060102T150405Z"
 
 // MarshallToICal marshalls this event to an ical format
 func (e Event) MarshallToICal() string {
+	return ical.Join(e.iCalLines(fmt.Sprintf("%v", e.Id)))
+}
+
+// iCalLines gets the lines of a VEVENT of the event with the uid, where the extra lines
+// (like RECURRENCE-ID) go after the times of the event
+func (e Event) iCalLines(uid string, extra ...string) []string {
 	start, _ := e.Start()
-	end, _ := e.Start()
+	end, _ := e.End()
+	stamp := start
+	if zoned, _, err := e.zonedSpan(); err == nil {
+		stamp = zoned.UTC()
+	}
 	s := []string{
 		"BEGIN:VEVENT",
-		fmt.Sprintf("UID:%v", e.Id),
-		fmt.Sprintf("DTSTAMP:%v", start.Format(iCalDateTimeFormat)),
-		fmt.Sprintf("DTSTART:%v", start.Format(iCalDateTimeFormat)),
-		fmt.Sprintf("DTEND:%v", end.Format(iCalDateTimeFormat)),
-		fmt.Sprintf("SUMMARY:%v", strings.ReplaceAll(e.Title, "\n", " ")),
-		"CLASS:PRIVATE",
+		fmt.Sprintf("UID:%v", uid),
+		fmt.Sprintf("DTSTAMP:%v", stamp.Format(iCalDateTimeFormat)),
+		iCalDateTime("DTSTART", start, e.Zone),
+		iCalDateTime("DTEND", end, e.Zone),
+	}
+	s = append(s, extra...)
+	s = append(s, ical.Property("SUMMARY", ical.EscapeText(strings.ReplaceAll(e.Title, "\n", " "))))
+	if e.Visibility == VisibilityPublic {
+		s = append(s, "CLASS:PUBLIC")
+	} else {
+		s = append(s, "CLASS:PRIVATE")
 	}
 	if e.Description != nil && len(*e.Description) > 0 {
-		s = append(s, fmt.Sprintf("DESCRIPTION:%v", *e.Description))
+		s = append(s, ical.Property("DESCRIPTION", ical.EscapeText(*e.Description)))
+	}
+	if e.Priority != PriorityUndefined {
+		s = append(s, fmt.Sprintf("PRIORITY:%v", int64(e.Priority)))
+	}
+	s = append(s, e.iCalLinkLines()...)
+	if e.Status == StatusCanceled {
+		s = append(s, "STATUS:CANCELLED")
+		if e.CancelReason != nil && len(*e.CancelReason) > 0 {
+			s = append(s, ical.Property("COMMENT", ical.EscapeText(*e.CancelReason)))
+		}
 	}
 
-	s = append(s, "END:VEVENT")
-	return strings.Join(s, "\n")
+	return append(s, "END:VEVENT")
 }
 
 // parseDayTime takes a day of YYYY-MM-DD and an hourMin as HH-mm (or "")
@@ -108,16 +249,42 @@ func parseDayTime(day, hourMin string) (time.Time, error) {
 	if hourMin == "" {
 		return time.Parse(time.DateOnly, day)
 	}
+	if len(hourMin) == len(TimeSecondsFormat) {
+		return time.Parse(DayTimeSecondsFormat, fmt.Sprintf("%s %s", day, hourMin))
+	}
 
 	return time.Parse(DayTimeFormat, fmt.Sprintf("%s %s", day, hourMin))
 }
 
+// parseTime parses an HH:MM time, or an HH:MM:SS time for events that need second precision
+func parseTime(hourMin string) (time.Time, error) {
+	if len(hourMin) == len(TimeSecondsFormat) {
+		return time.Parse(TimeSecondsFormat, hourMin)
+	}
+	return time.Parse(TimeFormat, hourMin)
+}
+
+// formatTime formats the time as HH:MM, or as HH:MM:SS if it isn't on a whole minute
+func formatTime(t time.Time) string {
+	if t.Second() != 0 {
+		return t.Format(TimeSecondsFormat)
+	}
+	return t.Format(TimeFormat)
+}
+
 // DayTimeFormat is the time package format style for YYYY-MM-DD HH:mm
 const DayTimeFormat = time.DateOnly + " 15:04"
 
+// DayTimeSecondsFormat is the time package format style for YYYY-MM-DD HH:mm:ss
+const DayTimeSecondsFormat = time.DateOnly + " 15:04:05"
+
 // TimeFormat is the time package format style for HH:mm
 const TimeFormat = "15:04"
 
+// TimeSecondsFormat is the time package format style for HH:mm:ss, which the times of events
+// can use instead of TimeFormat when they need second precision (like broadcast schedules)
+const TimeSecondsFormat = "15:04:05"
+
 type Details struct {
 	// Id is the unique id for this event
 	Id int64
@@ -128,6 +295,8 @@ type Details struct {
 	Description *string
 	// Url is a quick way to set the destination on an event that is clicked on in an interface
 	Url *string
+	// Location is a free-form description of where the event takes place
+	Location *string
 	// Status represents the current status of the event, defaults to active, but events can also
 	// be canceled or removed
 	Status Status
@@ -162,6 +331,37 @@ const (
 	StatusAbandoned Status = -2
 	// StatusRemoved is when the event was deleted by the owner of the event and it disappears from the calendar
 	StatusRemoved Status = -1
+	// StatusPendingApproval is for new events on a calendar that moderates events (see WithApproval),
+	// and the event doesn't show up on the calendar until it is approved
+	StatusPendingApproval Status = 2
+)
+
+// Statuses are all of the statuses of events
+var Statuses = []Status{StatusActive, StatusCanceled, StatusAbandoned, StatusRemoved, StatusPendingApproval}
+
+// Priority is the importance of an event and matches the PRIORITY property of ICS
+// where 1 is the highest priority, 9 is the lowest, and 0 is undefined
+type Priority int64
+
+const (
+	// PriorityUndefined is the default for events and is treated the same as PriorityMedium
+	PriorityUndefined Priority = 0
+	// PriorityHigh is the highest priority
+	PriorityHigh Priority = 1
+	// PriorityMedium is the middle priority
+	PriorityMedium Priority = 5
+	// PriorityLow is the lowest priority
+	PriorityLow Priority = 9
+)
+
+// Visibility is who can see an event
+type Visibility int64
+
+const (
+	// VisibilityPrivate is the default for events and only the invited users can see the event
+	VisibilityPrivate Visibility = 0
+	// VisibilityPublic events can be seen by anyone through Calendar.PublicEvents
+	VisibilityPublic Visibility = 1
 )
 
 // EventType must be defined by the user of this library
@@ -178,6 +378,21 @@ type Invite struct {
 	Status InviteStatus
 	// Permission is a bitmask for the allowed permissions for this user on this event
 	Permission Permission
+	// PrivateNote is a note on the event that only the invited user can see
+	PrivateNote *string
+	// UserData is a custom and optional blob of JSON that only the invited user can see
+	UserData map[string]interface{}
+	// IsSeries is true if the invite is for every event in a repeating series, and then the
+	// EventId is the ParentId of the series. An invite to a single event of the series
+	// overrides the series invite for that event.
+	IsSeries bool
+	// Proposal is a new time for the event that the user proposed (see ProposeNewTime)
+	Proposal *TimeProposal
+	// ForwardedBy is the user that forwarded their invitation to this user, or nil if the
+	// user was invited directly
+	ForwardedBy *int64
+	// Muted is true if the user doesn't want any notifications for the event (see MuteNotifications)
+	Muted bool
 	// Created is a timestamp for when the invite invitation was created
 	Created time.Time
 	// Updated is a timestamp for when the invite invitation was modified last
@@ -199,6 +414,12 @@ const (
 	InviteStatusPending InviteStatus = 0
 	// InviteStatusConfirmed is an acknowledgment that the user is going to attend the event
 	InviteStatusConfirmed InviteStatus = 1
+	// InviteStatusTentative is when the user might attend the event, the event will remain on the user's
+	// calendar like a pending invite
+	InviteStatusTentative InviteStatus = 2
+	// InviteStatusWaitlisted is when the user wants to attend the event but there isn't room for them yet,
+	// the event will remain on the user's calendar like a pending invite
+	InviteStatusWaitlisted InviteStatus = 3
 	// InviteStatusDeclined is when the user decides tho not attend the event, if all users decline an event
 	// it becomes abandoned
 	InviteStatusDeclined InviteStatus = -1
@@ -232,6 +453,9 @@ const (
 	PermissionInvite
 	PermissionCancel
 	PermissionDelete
+	// PermissionApprove lets the user approve or reject an event that is pending approval,
+	// and it isn't a part of PermissionOwner so that owners can't approve their own events
+	PermissionApprove
 )
 
 const (
@@ -249,10 +473,16 @@ const MaxRepeatDuration = time.Duration(24*365*2) * time.Hour
 // over a period of time or for a number of occurrences
 type Repeat struct {
 	// RepeatType is a enumeration of the valid types of repeat events (daily,
-	// weekly, monthly, or yearly)
+	// weekly, monthly, yearly, or weekdays)
 	RepeatType RepeatType `json:"repeatType"`
 	// DayOfWeek is a bitmask of the days of the week (SMTWTFS)
 	DayOfWeek DayOfWeek `json:"dayOfWeek"`
+	// BusinessDaysOnly skips the occurrences of a daily repeat that land on a Saturday or
+	// Sunday, which makes the repeat the same as RepeatTypeWeekdays
+	BusinessDaysOnly bool `json:"businessDaysOnly"`
+	// Next is the strategy of a RepeatTypeCustom repeat. It isn't saved as JSON, so a data store
+	// that serializes events has to set it again when they are read.
+	Next NextOccurrence `json:"-"`
 	// RepeatOccurrences is a number of times the event should repeat.
 	// It should be 0 if RepeatStopDate is not nil.
 	// It can't be more than MaxRepeatOccurrence.
@@ -261,6 +491,16 @@ type Repeat struct {
 	// It should be nil if RepeatOccurrences > 1.
 	// It can't be more than MaxRepeatDuration.
 	RepeatStopDate *time.Time `json:"repeatStopDate"`
+	// CalendarSystem is the system that yearly repeats follow. The default is
+	// Gregorian, but a yearly repeat can follow another system so an event
+	// like Passover lands on the same Hebrew day each year.
+	CalendarSystem CalendarSystem `json:"calendarSystem"`
+	// Anniversary makes a yearly repeat of an all day event, like a birthday, count the years
+	// since its StartDay in the AnniversaryYears of each occurrence
+	Anniversary bool `json:"anniversary"`
+	// LeapDay is the day that a Gregorian yearly repeat starting on February 29 lands on in
+	// the years that aren't leap years
+	LeapDay LeapDayPolicy `json:"leapDay"`
 }
 
 type RepeatType int64
@@ -270,6 +510,11 @@ const (
 	RepeatTypeWeekly  RepeatType = 1
 	RepeatTypeMonthly RepeatType = 2
 	RepeatTypeYearly  RepeatType = 3
+	// RepeatTypeWeekdays repeats every Monday through Friday, like a weekly repeat
+	// with those days in DayOfWeek (the DayOfWeek of the repeat is ignored)
+	RepeatTypeWeekdays RepeatType = 4
+	// RepeatTypeCustom repeats with the NextOccurrence strategy in Next
+	RepeatTypeCustom RepeatType = 5
 )
 
 type DayOfWeek = Bitmask
@@ -284,6 +529,9 @@ const (
 	DayOfWeekSaturday
 )
 
+// DayOfWeekWeekdays are the business days of the week, Monday through Friday
+const DayOfWeekWeekdays DayOfWeek = DayOfWeekMonday | DayOfWeekTuesday | DayOfWeekWednesday | DayOfWeekThursday | DayOfWeekFriday
+
 func dayOfWeekFromWeekday(w time.Weekday) DayOfWeek {
 	switch w {
 	case time.Sunday:
@@ -310,18 +558,24 @@ func _t(t time.Time) *time.Time {
 
 // Query is the object that the data store uses to try and find the list of matching events
 type Query struct {
-	// Start is an inclusive timestamp and should be compared against the end timestamp of other events (overlap)
+	// Start is a timestamp that should be compared against the end timestamp of other events (overlap)
 	Start *time.Time
-	// End is an inclusive timestamp and should be compared against the start timestamp of other events (overlap)
+	// End is a timestamp that should be compared against the start timestamp of other events (overlap)
 	End *time.Time
+	// StartBound is whether the events that end at the Start are matched, which is inclusive
+	// unless the calendar has other bounds (see WithRangeBounds)
+	StartBound RangeBound
+	// EndBound is whether the events that start at the End are matched, which is inclusive
+	// unless the calendar has other bounds (see WithRangeBounds)
+	EndBound RangeBound
 	// EventIds is a list of specific events that you want to query
 	EventIds []int64
 	// CalendarIds is a list of specific calendars that you want to query
 	CalendarIds []int64
 	// ParentIds is a list of parent ids that should be searched for and will find all events that have a match to the parent id
 	ParentIds []int64
-	// UserIds is a check if the user has an invite record for the event that is not
-	// declined or revoked
+	// UserIds is a check if the user has an invite record for the event (or for the
+	// event's series) that is not declined or revoked
 	UserIds []int64
 	// EventTypes is a check if the event has a specific event type
 	EventTypes []EventType
@@ -329,36 +583,32 @@ type Query struct {
 	SourceIds []int64
 	// Statuses is an OR search for specific statuses
 	Statuses []Status
+	// Priorities is an OR search for specific priorities
+	Priorities []Priority
+	// Near is a search for events with a Geo point within a distance of a center point
+	Near *GeoRadius
+	// Visibilities is an OR search for specific visibilities
+	Visibilities []Visibility
 	// Text is an OR search for specific words
 	Text []string
+	// CustomFields is an AND search on the values of custom fields (see WithCustomFields). The
+	// calendar checks it on the results of the data store, so data stores can ignore it.
+	CustomFields []CustomFieldQuery
+	// IncludeRemoved keeps the removed events in the results of Calendar.Query when the query
+	// has no Statuses, which are left out by default (along with the abandoned events if the
+	// calendar has WithAbandonedHidden). Data stores don't use it.
+	IncludeRemoved bool
 }
 
-// Matches does a local check if the given event matches the query
+// Matches does a local check if the given event matches the query. The UserIds field
+// needs the invites of the event, so it is not checked here (see MatchesInvites).
 func (q Query) Matches(event *Event) bool {
 	if event == nil {
 		return false
 	}
 
-	if q.Start != nil {
-		startDay := q.Start.Format(time.DateOnly)
-		startTime := q.Start.Format(TimeFormat)
-		if startDay > event.EndDay {
-			return false
-		}
-		if event.EndTime != "" && startDay+startTime > event.EndDay+event.EndTime {
-			return false
-		}
-	}
-
-	if q.End != nil {
-		endDay := q.End.Format(time.DateOnly)
-		endTime := q.End.Format(TimeFormat)
-		if endDay < event.StartDay {
-			return false
-		}
-		if event.StartTime != "" && endDay+endTime < event.StartDay+event.StartTime {
-			return false
-		}
+	if !event.InBounds(q.Start, q.End, q.StartBound, q.EndBound) {
+		return false
 	}
 
 	found := false
@@ -439,6 +689,36 @@ func (q Query) Matches(event *Event) bool {
 		}
 	}
 
+	if len(q.Priorities) > 0 {
+		found = false
+		for _, priority := range q.Priorities {
+			if event.Priority == priority {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(q.Visibilities) > 0 {
+		found = false
+		for _, visibility := range q.Visibilities {
+			if event.Visibility == visibility {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if q.Near != nil && !q.Near.Contains(event.Geo) {
+		return false
+	}
+
 	if len(q.Text) > 0 {
 		found = false
 		for _, text := range q.Text {
@@ -456,9 +736,57 @@ func (q Query) Matches(event *Event) bool {
 		}
 	}
 
+	for _, field := range q.CustomFields {
+		if !field.Matches(event.UserData) {
+			return false
+		}
+	}
+
 	return true
 }
 
+// MatchesInvites does a local check if the given event matches the query, including
+// the UserIds field, where invites are the invites of the event and its series
+func (q Query) MatchesInvites(event *Event, invites []*Invite) bool {
+	return q.Matches(event) && q.Invited(event, invites)
+}
+
+// Invited checks if any of the UserIds has an invite to the event that is not declined or
+// revoked. An invite to the event itself is used instead of the user's series invite, and
+// invites to other events are ignored. If the query has no UserIds, then it is true.
+func (q Query) Invited(event *Event, invites []*Invite) bool {
+	if len(q.UserIds) == 0 {
+		return true
+	}
+	if event == nil {
+		return false
+	}
+	for _, userId := range q.UserIds {
+		if invite := userInvite(event, invites, userId); invite != nil && invite.Status >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// userInvite finds the invite of the user to the event, or the user's series invite if there
+// isn't one, or nil if the user has neither
+func userInvite(event *Event, invites []*Invite, userId int64) *Invite {
+	var invite *Invite
+	for _, i := range invites {
+		if i.UserId != userId {
+			continue
+		}
+		if !i.IsSeries && i.EventId == event.Id {
+			return i
+		}
+		if i.IsSeries && event.ParentId != nil && i.EventId == *event.ParentId && invite == nil {
+			invite = i
+		}
+	}
+	return invite
+}
+
 type RepeatEditType int64
 
 const (
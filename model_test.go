@@ -125,6 +125,76 @@ func TestQueryMatch(t *testing.T) {
 	}
 }
 
+func TestQueryInvited(t *testing.T) {
+	parentId := int64(1)
+	event := &Event{Id: 2, ParentId: &parentId}
+	invites := []*Invite{
+		{EventId: 2, UserId: 10, Status: InviteStatusConfirmed},
+		{EventId: 2, UserId: 11, Status: InviteStatusDeclined},
+		{EventId: 1, UserId: 11, Status: InviteStatusConfirmed, IsSeries: true},
+		{EventId: 1, UserId: 12, Status: InviteStatusPending, IsSeries: true},
+		{EventId: 1, UserId: 13, Status: InviteStatusRevoked, IsSeries: true},
+		{EventId: 3, UserId: 14, Status: InviteStatusConfirmed},
+	}
+	testCases := []struct {
+		name    string
+		userIds []int64
+		out     bool
+	}{
+		{"no users", nil, true},
+		{"confirmed", []int64{10}, true},
+		{"declined event invite overrides the series", []int64{11}, false},
+		{"pending series invite", []int64{12}, true},
+		{"revoked series invite", []int64{13}, false},
+		{"invite to another event", []int64{14}, false},
+		{"not invited", []int64{15}, false},
+		{"any user", []int64{13, 12}, true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			q := Query{UserIds: tc.userIds}
+			assert.Equal(t, tc.out, q.Invited(event, invites))
+			assert.Equal(t, tc.out, q.MatchesInvites(event, invites))
+		})
+	}
+}
+
+func TestEventInRange(t *testing.T) {
+	allDay := Event{IsAllDay: true, StartDay: "2008-01-01", EndDay: "2008-01-03", StartTime: "00:00", EndTime: "00:00"}
+	timed := Event{StartDay: "2008-01-01", StartTime: "22:00", EndDay: "2008-01-02", EndTime: "02:00"}
+	noTimes := Event{StartDay: "2008-01-01", EndDay: "2008-01-01"}
+	testCases := []struct {
+		name  string
+		event Event
+		start *time.Time
+		end   *time.Time
+		out   bool
+	}{
+		{"unbounded", timed, nil, nil, true},
+		{"all day middle of the last day", allDay, tt("2008-01-03 12:00"), tt("2008-01-03 13:00"), true},
+		{"all day middle of the middle day", allDay, tt("2008-01-02 12:00"), tt("2008-01-02 13:00"), true},
+		{"all day after", allDay, tt("2008-01-04 00:00"), nil, false},
+		{"all day before", allDay, nil, tt("2007-12-31 23:59"), false},
+		{"timed during the next day", timed, tt("2008-01-02 01:00"), tt("2008-01-02 01:30"), true},
+		{"timed ends at the start", timed, tt("2008-01-02 02:00"), nil, true},
+		{"timed after", timed, tt("2008-01-02 02:01"), nil, false},
+		{"timed starts at the end", timed, nil, tt("2008-01-01 22:00"), true},
+		{"timed before", timed, nil, tt("2008-01-01 21:59"), false},
+		{"no times late in the day", noTimes, tt("2008-01-01 23:00"), nil, true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			assert.Equal(t, tc.out, tc.event.InRange(tc.start, tc.end))
+		})
+	}
+}
+
 func TestParseDayTime(t *testing.T) {
 	testCases := []struct {
 		name    string
@@ -0,0 +1,44 @@
+package cali
+
+// MuteStore is an optional interface for a data store that can mute the notifications of invites
+type MuteStore interface {
+	// SetInviteMuted uses the EventId and UserId to set the Muted field of the invite and
+	// updates the Updated date too
+	SetInviteMuted(eventId, userId int64, muted bool) error
+}
+
+// MuteNotifications stops every notification (including reminders) to the user about the
+// event, or about the events of its series for the other edit types. The status of the
+// user's invitation isn't changed.
+func (c *Calendar) MuteNotifications(eventId int64, userId int64, editType RepeatEditType) error {
+	return c.setMuted(eventId, userId, true, editType)
+}
+
+// UnmuteNotifications sends the notifications about the event to the user again
+func (c *Calendar) UnmuteNotifications(eventId int64, userId int64, editType RepeatEditType) error {
+	return c.setMuted(eventId, userId, false, editType)
+}
+
+func (c *Calendar) setMuted(eventId int64, userId int64, muted bool, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[MuteStore](c.dataStore)
+		if !ok {
+			return ErrorMuteNotSupported
+		}
+		return c.editInviteOrSeries(OperationRespond, editType, eventId, userId, nil, func(eventId int64) error {
+			return store.SetInviteMuted(eventId, userId, muted)
+		})
+	})
+}
+
+// isMuted returns true if the user of the notification muted the event
+func (c *Calendar) isMuted(n Notification) (bool, error) {
+	if _, ok := capability[MuteStore](c.dataStore); !ok {
+		return false, nil
+	}
+	invite, err := c.GetInvitation(n.Event.Id, n.UserId)
+	if err != nil {
+		return false, err
+	}
+	return invite != nil && invite.Muted, nil
+}
@@ -0,0 +1,55 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMuteNotifications(t *testing.T) {
+	sender := &testSender{}
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender))
+	_, _, err := c.Create(Event{OwnerId: 1, Title: "Standup", Zone: "UTC", IsRepeating: true, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15", Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	events, err := c.Query(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	require.NoError(t, c.InviteUser(events[0].Id, 2, PermissionInvitee, RepeatEditTypeAll))
+	require.NoError(t, c.AcceptInvitation(events[0].Id, 2, RepeatEditTypeAll))
+
+	// muting the first event doesn't change the rsvp
+	require.NoError(t, c.MuteNotifications(events[0].Id, 2, RepeatEditTypeThis))
+	invite, err := c.GetInvitation(events[0].Id, 2)
+	require.NoError(t, err)
+	assert.True(t, invite.Muted)
+	assert.Equal(t, InviteStatusConfirmed, invite.Status)
+
+	sender.sent = nil
+	require.NoError(t, c.UpdateTime(events[0].Id, "10:00", "10:15", RepeatEditTypeAll))
+	require.Len(t, sender.sent, 2, "the muted event isn't notified")
+	for _, n := range sender.sent {
+		assert.NotEqual(t, events[0].Id, n.Event.Id)
+	}
+
+	// reminders are muted too
+	r, err := c.AddReminder(events[0].Id, 2, time.Minute)
+	require.NoError(t, err)
+	sender.sent = nil
+	_, err = c.SendDueReminders(r.RemindAt)
+	require.NoError(t, err)
+	assert.Empty(t, sender.sent)
+
+	// muting the whole series, and then unmuting it
+	require.NoError(t, c.MuteNotifications(events[1].Id, 2, RepeatEditTypeAll))
+	require.NoError(t, c.Cancel(events[2].Id, RepeatEditTypeThis))
+	assert.Empty(t, sender.sent)
+	require.NoError(t, c.UnmuteNotifications(events[1].Id, 2, RepeatEditTypeAll))
+	require.NoError(t, c.Cancel(events[1].Id, RepeatEditTypeThis))
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, NotificationTypeEventCanceled, sender.sent[0].Type)
+
+	c = NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	assert.Equal(t, ErrorMuteNotSupported, c.MuteNotifications(events[0].Id, 2, RepeatEditTypeThis))
+}
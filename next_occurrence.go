@@ -0,0 +1,57 @@
+package cali
+
+import (
+	"time"
+)
+
+// NextOccurrence is a strategy for a RepeatTypeCustom repeat that works out each occurrence from
+// the one before it, for recurrences that don't fit a daily, weekly, monthly, or yearly pattern
+// (like a follow up visit some days after the last one). RepeatOccurrences and RepeatStopDate
+// still limit the occurrences of the repeat. A strategy should always give the same occurrences
+// for the same first occurrence, since the occurrences are generated again when a series is extended.
+type NextOccurrence interface {
+	// Next gets the start day of the occurrence after the previous one (with the start and end day
+	// of the previous occurrence and count of occurrences so far), or false if there are no more.
+	// The next start day must be after the previous start day.
+	Next(previousStart, previousEnd time.Time, count int) (time.Time, bool)
+}
+
+// DaysAfter is a NextOccurrence strategy where each occurrence starts the number of days after the
+// end day of the previous occurrence
+type DaysAfter int
+
+func (d DaysAfter) Next(previousStart, previousEnd time.Time, count int) (time.Time, bool) {
+	return previousEnd.AddDate(0, 0, int(d)), d >= 0
+}
+
+// EveryNth is a NextOccurrence strategy that only keeps every Nth occurrence of another strategy,
+// like every 3rd occurrence of a weekly visit
+type EveryNth struct {
+	// N is how many occurrences of the strategy there are for each occurrence of the repeat
+	N int
+	// Of is the strategy that the occurrences are picked from
+	Of NextOccurrence
+}
+
+func (e EveryNth) Next(previousStart, previousEnd time.Time, count int) (time.Time, bool) {
+	if e.N < 1 || e.Of == nil {
+		return time.Time{}, false
+	}
+	start, end := previousStart, previousEnd
+	for i := 0; i < e.N; i++ {
+		next, ok := e.Of.Next(start, end, (count-1)*e.N+i+1)
+		if !ok {
+			return time.Time{}, false
+		}
+		start, end = next, next.Add(end.Sub(start))
+	}
+	return start, true
+}
+
+// EveryDays is a NextOccurrence strategy where each occurrence starts the number of days after the
+// start day of the previous occurrence, which with EveryNth can pick from a regular pattern
+type EveryDays int
+
+func (d EveryDays) Next(previousStart, previousEnd time.Time, count int) (time.Time, bool) {
+	return previousStart.AddDate(0, 0, int(d)), d > 0
+}
@@ -0,0 +1,74 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// untilCount is a strategy that has occurrences every day until the count
+type untilCount int
+
+func (u untilCount) Next(previousStart, previousEnd time.Time, count int) (time.Time, bool) {
+	return previousStart.AddDate(0, 0, 1), count < int(u)
+}
+
+// stuck is a strategy that never moves past the first occurrence
+type stuck struct{}
+
+func (stuck) Next(previousStart, previousEnd time.Time, count int) (time.Time, bool) {
+	return previousStart, true
+}
+
+func TestNextOccurrence(t *testing.T) {
+	testCases := []struct {
+		name   string
+		repeat Repeat
+		days   []string
+		err    error
+	}{
+		{name: "days after the previous end", repeat: Repeat{RepeatType: RepeatTypeCustom, Next: DaysAfter(10), RepeatOccurrences: 3}, days: []string{"2008-01-01/2008-01-02", "2008-01-12/2008-01-13", "2008-01-23/2008-01-24"}},
+		{name: "every 3rd week", repeat: Repeat{RepeatType: RepeatTypeCustom, Next: EveryNth{N: 3, Of: EveryDays(7)}, RepeatOccurrences: 3}, days: []string{"2008-01-01/2008-01-02", "2008-01-22/2008-01-23", "2008-02-12/2008-02-13"}},
+		{name: "stop date", repeat: Repeat{RepeatType: RepeatTypeCustom, Next: EveryDays(14), RepeatStopDate: _t(time.Date(2008, time.January, 20, 0, 0, 0, 0, time.UTC))}, days: []string{"2008-01-01/2008-01-02", "2008-01-15/2008-01-16", "2008-01-29/2008-01-30"}},
+		{name: "strategy stops", repeat: Repeat{RepeatType: RepeatTypeCustom, Next: untilCount(2), RepeatOccurrences: 5}, days: []string{"2008-01-01/2008-01-02", "2008-01-02/2008-01-03"}},
+		{name: "missing strategy", repeat: Repeat{RepeatType: RepeatTypeCustom, RepeatOccurrences: 3}, err: ErrorMissingNextOccurrence},
+		{name: "strategy goes backwards", repeat: Repeat{RepeatType: RepeatTypeCustom, Next: DaysAfter(-2), RepeatOccurrences: 3}, days: []string{"2008-01-01/2008-01-02"}},
+		{name: "strategy doesn't move", repeat: Repeat{RepeatType: RepeatTypeCustom, Next: stuck{}, RepeatOccurrences: 3}, err: ErrorInvalidNextOccurrence},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			repeat := tc.repeat
+			var days []string
+			err := RepeatOccurrences(Event{IsRepeating: true, IsAllDay: true, Zone: "UTC", StartDay: "2008-01-01", EndDay: "2008-01-02", Repeat: &repeat}, func(startDay, endDay time.Time) bool {
+				days = append(days, startDay.Format(time.DateOnly)+"/"+endDay.Format(time.DateOnly))
+				return true
+			})
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.days, days)
+		})
+	}
+}
+
+func TestNextOccurrenceSeries(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	_, count, err := c.Create(Event{OwnerId: 1, Zone: "UTC", IsRepeating: true, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Repeat: &Repeat{RepeatType: RepeatTypeCustom, Next: DaysAfter(30), RepeatOccurrences: 4}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+
+	events, err := c.Query(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	var days []string
+	for _, e := range events {
+		days = append(days, e.StartDay)
+	}
+	assert.Equal(t, []string{"2008-01-01", "2008-01-31", "2008-03-01", "2008-03-31"}, days)
+}
@@ -0,0 +1,263 @@
+package cali
+
+import (
+	"strconv"
+)
+
+// NotificationSender delivers notifications to users (by email, push notification, etc)
+type NotificationSender interface {
+	// Send delivers a single notification to the UserId of the notification
+	Send(n Notification) error
+}
+
+// NotificationType is the reason a notification is sent
+type NotificationType int64
+
+const (
+	// NotificationTypeEventChanged is sent to the invitees of an event when its time or location changes
+	NotificationTypeEventChanged NotificationType = 0
+	// NotificationTypeInvited is sent to a user when they are invited to an event or a series
+	NotificationTypeInvited NotificationType = 1
+	// NotificationTypeTimeProposed is sent to the owner of an event when an invitee proposes a new time
+	NotificationTypeTimeProposed NotificationType = 2
+	// NotificationTypeRespondReminder is sent to an invitee that hasn't responded to an invitation (see NudgePendingInvites)
+	NotificationTypeRespondReminder NotificationType = 3
+	// NotificationTypeEventReminder is sent to a user when one of their reminders is due (see SendDueReminders)
+	NotificationTypeEventReminder NotificationType = 4
+	// NotificationTypeEventCanceled is sent to the invitees of an event when it is canceled
+	NotificationTypeEventCanceled NotificationType = 5
+	// NotificationTypeEventRescheduled is sent to the invitees of an event when it is moved with Reschedule
+	NotificationTypeEventRescheduled NotificationType = 6
+)
+
+// Notification is the payload given to a NotificationSender for a single user
+type Notification struct {
+	// Type is the reason for the notification
+	Type NotificationType `json:"type"`
+	// UserId is the user that should receive the notification
+	UserId int64 `json:"userId"`
+	// Event is the event after the change
+	Event Event `json:"event"`
+	// Changes are the fields of the event that changed
+	Changes []FieldChange `json:"changes"`
+	// InviteStatus is the user's current response to the invitation
+	InviteStatus InviteStatus `json:"inviteStatus"`
+	// FromUserId is the user that caused the notification, like the invitee that proposed a new time
+	FromUserId *int64 `json:"fromUserId"`
+	// Proposal is the new time for NotificationTypeTimeProposed notifications
+	Proposal *TimeProposal `json:"proposal"`
+	// Reminder is the reminder for NotificationTypeEventReminder notifications, which has the
+	// id to snooze or dismiss it with
+	Reminder *Reminder `json:"reminder"`
+	// Previous is the event before it was moved for NotificationTypeEventRescheduled notifications
+	Previous *Event `json:"previous"`
+	// Follower is true if the user follows the event instead of being invited (see Follow)
+	Follower bool `json:"follower"`
+	// Content is the rendered subject and body of the notification, or nil if the calendar
+	// doesn't have a renderer for the notification (see WithNotificationRenderer)
+	Content *NotificationContent `json:"content"`
+}
+
+// FieldChange is the old and new value of a single changed event field, where
+// the field is named the same as the JSON of the event
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// WithNotificationSender sends a notification to every invitee (other than the owner) when the
+// time or location of an event changes and to users when they are invited. The data store must
+// implement InviteListStore. Use a NotificationQueue to limit how fast notifications are sent.
+func WithNotificationSender(sender NotificationSender) CalendarOption {
+	return func(c *Calendar) {
+		c.notificationSender = sender
+	}
+}
+
+// DiffEvents lists the fields that are different between the two versions of an event
+func DiffEvents(before, after Event) []FieldChange {
+	str := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	fields := []FieldChange{
+		{"title", before.Title, after.Title},
+		{"description", str(before.Description), str(after.Description)},
+		{"url", str(before.Url), str(after.Url)},
+		{"location", str(before.Location), str(after.Location)},
+		{"priority", strconv.FormatInt(int64(before.Priority), 10), strconv.FormatInt(int64(after.Priority), 10)},
+		{"isAllDay", strconv.FormatBool(before.IsAllDay), strconv.FormatBool(after.IsAllDay)},
+		{"zone", before.Zone, after.Zone},
+		{"startDay", before.StartDay, after.StartDay},
+		{"startTime", before.StartTime, after.StartTime},
+		{"endDay", before.EndDay, after.EndDay},
+		{"endTime", before.EndTime, after.EndTime},
+	}
+	var changes []FieldChange
+	for _, f := range fields {
+		if f.Old != f.New {
+			changes = append(changes, f)
+		}
+	}
+	return changes
+}
+
+// BuildChangeNotifications makes a NotificationTypeEventChanged notification for every
+// invited user (other than the owner and revoked users) if anything changed
+func BuildChangeNotifications(before, after Event, invites []*Invite) []Notification {
+	changes := DiffEvents(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	var result []Notification
+	for _, invite := range invites {
+		if invite.UserId == after.OwnerId || invite.Status == InviteStatusRevoked {
+			continue
+		}
+		result = append(result, Notification{
+			Type:         NotificationTypeEventChanged,
+			UserId:       invite.UserId,
+			Event:        after,
+			Changes:      changes,
+			InviteStatus: invite.Status,
+		})
+	}
+	return result
+}
+
+// notifyChanges applies the edit to the event, resets the responses if the RSVPResetPolicy
+// finds the edit significant, and then sends the change notifications to the invitees. The
+// edit is saved even if sending a notification fails.
+func (c *Calendar) notifyChanges(eventId int64, apply func() error) error {
+	if c.notificationSender == nil && c.rsvpResetPolicy == nil {
+		return apply()
+	}
+	before, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return ErrorEventNotFound
+	}
+	old := *before
+	if err := apply(); err != nil {
+		return err
+	}
+	after, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if after == nil {
+		return ErrorEventNotFound
+	}
+	if err := c.resetForPolicy(old, *after); err != nil {
+		return err
+	}
+	if c.notificationSender == nil {
+		return nil
+	}
+	invites, err := c.getInvites(eventId)
+	if err != nil {
+		return err
+	}
+	for _, n := range BuildChangeNotifications(old, *after, invites) {
+		if err := c.sendNotification(n); err != nil {
+			return err
+		}
+	}
+	if changes := DiffEvents(old, *after); len(changes) > 0 {
+		return c.notifyFollowers(Notification{Type: NotificationTypeEventChanged, Event: *after, Changes: changes}, invites)
+	}
+	return nil
+}
+
+// notifyInvited sends a NotificationTypeInvited notification to the invited user
+func (c *Calendar) notifyInvited(eventId int64, userId int64) error {
+	if c.notificationSender == nil {
+		return nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return ErrorEventNotFound
+	}
+	if e.OwnerId == userId {
+		return nil
+	}
+	invite, err := c.GetInvitation(eventId, userId)
+	if err != nil {
+		return err
+	}
+	if invite == nil {
+		return ErrorInviteNotFound
+	}
+	return c.sendNotification(Notification{
+		Type:         NotificationTypeInvited,
+		UserId:       userId,
+		Event:        *e,
+		InviteStatus: invite.Status,
+	})
+}
+
+// notifyCanceled sends a NotificationTypeEventCanceled notification to every invited user
+// (other than the owner and users that declined or were revoked)
+func (c *Calendar) notifyCanceled(eventId int64) error {
+	if c.notificationSender == nil {
+		return nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return ErrorEventNotFound
+	}
+	invites, err := c.getInvites(eventId)
+	if err != nil {
+		return err
+	}
+	for _, invite := range invites {
+		if invite.UserId == e.OwnerId || invite.Status == InviteStatusRevoked || invite.Status == InviteStatusDeclined {
+			continue
+		}
+		err := c.sendNotification(Notification{
+			Type:         NotificationTypeEventCanceled,
+			UserId:       invite.UserId,
+			Event:        *e,
+			InviteStatus: invite.Status,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return c.notifyFollowers(Notification{Type: NotificationTypeEventCanceled, Event: *e}, invites)
+}
+
+// sendNotification drops the notification if the user muted the event (see MuteNotifications),
+// renders it if the calendar has a renderer, and then writes it to the outbox if there is one,
+// otherwise it is sent right away with the notification sender
+func (c *Calendar) sendNotification(n Notification) error {
+	if muted, err := c.isMuted(n); err != nil || muted {
+		return err
+	}
+	if c.notificationRenderer != nil && n.Content == nil {
+		locale := DefaultTemplateLocale
+		if c.userLocale != nil {
+			locale = c.userLocale(n.UserId)
+		}
+		content, err := c.notificationRenderer.Render(n, locale)
+		if err != nil && err != ErrorTemplateNotFound {
+			return err
+		}
+		n.Content = content
+	}
+	if c.outbox {
+		return c.addToOutbox(OutboxKindNotification, n)
+	}
+	return c.notificationSender.Send(n)
+}
@@ -0,0 +1,115 @@
+package cali
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BatchNotificationSender is an optional interface for a NotificationSender that can
+// deliver many notifications with a single call (like a bulk email API)
+type BatchNotificationSender interface {
+	// SendBatch delivers all of the notifications
+	SendBatch(ns []Notification) error
+}
+
+// NotificationQueue is a NotificationSender that holds notifications and passes them on to
+// another sender at a limited rate, so that inviting hundreds of users at once doesn't get the
+// calendar throttled by an email provider. Send only adds to the queue, and Run (or SendNext)
+// does the sending.
+//
+//	queue := cali.NewNotificationQueue(emailSender, 50, time.Second)
+//	go queue.Run(ctx)
+//	c := cali.NewCalendar(store, cali.WithNotificationSender(queue))
+type NotificationQueue struct {
+	// Sender delivers the notifications
+	Sender NotificationSender
+	// Limit is the most notifications sent every Interval
+	Limit int
+	// Interval is how often notifications are sent
+	Interval time.Duration
+	// BatchSize is the most notifications given to SendBatch at a time when the Sender is a
+	// BatchNotificationSender, zero or one sends each notification on its own
+	BatchSize int
+	// OnError is called with the notifications that failed to send, and they are not retried
+	OnError func(ns []Notification, err error)
+
+	mu      sync.Mutex
+	pending []Notification
+}
+
+// NewNotificationQueue sends up to limit notifications with the sender every interval
+func NewNotificationQueue(sender NotificationSender, limit int, interval time.Duration) *NotificationQueue {
+	return &NotificationQueue{
+		Sender:   sender,
+		Limit:    limit,
+		Interval: interval,
+	}
+}
+
+// Send adds the notification to the end of the queue
+func (q *NotificationQueue) Send(n Notification) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = append(q.pending, n)
+	return nil
+}
+
+// Len is the number of notifications waiting to be sent
+func (q *NotificationQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// SendNext sends up to Limit notifications from the front of the queue and returns the
+// number that were sent without an error
+func (q *NotificationQueue) SendNext() int {
+	q.mu.Lock()
+	count := len(q.pending)
+	if q.Limit > 0 && count > q.Limit {
+		count = q.Limit
+	}
+	next := q.pending[:count:count]
+	q.pending = q.pending[count:]
+	q.mu.Unlock()
+
+	size := 1
+	batcher, ok := q.Sender.(BatchNotificationSender)
+	if ok && q.BatchSize > 1 {
+		size = q.BatchSize
+	}
+	sent := 0
+	for start := 0; start < len(next); start += size {
+		end := min(start+size, len(next))
+		batch := next[start:end]
+		var err error
+		if size > 1 {
+			err = batcher.SendBatch(batch)
+		} else {
+			err = q.Sender.Send(batch[0])
+		}
+		if err != nil {
+			if q.OnError != nil {
+				q.OnError(batch, err)
+			}
+			continue
+		}
+		sent += len(batch)
+	}
+	return sent
+}
+
+// Run sends the next notifications every Interval until the context is done
+func (q *NotificationQueue) Run(ctx context.Context) error {
+	ticker := time.NewTicker(q.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			q.SendNext()
+		}
+	}
+}
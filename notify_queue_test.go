@@ -0,0 +1,79 @@
+package cali
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testBatchSender struct {
+	testSender
+	batches [][]Notification
+	fail    bool
+}
+
+func (s *testBatchSender) SendBatch(ns []Notification) error {
+	if s.fail {
+		return errors.New("throttled")
+	}
+	s.batches = append(s.batches, ns)
+	return nil
+}
+
+func TestNotificationQueue(t *testing.T) {
+	sender := &testSender{}
+	queue := NewNotificationQueue(sender, 2, time.Hour)
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(queue))
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	for userId := int64(2); userId < 7; userId++ {
+		require.NoError(t, c.InviteUser(e.Id, userId, PermissionViewer, RepeatEditTypeThis))
+	}
+	assert.Empty(t, sender.sent)
+	assert.Equal(t, 5, queue.Len())
+
+	assert.Equal(t, 2, queue.SendNext())
+	assert.Equal(t, 2, queue.SendNext())
+	assert.Equal(t, 1, queue.SendNext())
+	assert.Equal(t, 0, queue.SendNext())
+	require.Len(t, sender.sent, 5)
+	for i, n := range sender.sent {
+		assert.Equal(t, int64(i+2), n.UserId, "notifications should be sent in order")
+	}
+}
+
+func TestNotificationQueueBatches(t *testing.T) {
+	sender := &testBatchSender{}
+	queue := NewNotificationQueue(sender, 5, time.Millisecond)
+	queue.BatchSize = 2
+	var failed []Notification
+	queue.OnError = func(ns []Notification, err error) {
+		failed = append(failed, ns...)
+	}
+	for userId := int64(1); userId <= 5; userId++ {
+		require.NoError(t, queue.Send(Notification{UserId: userId}))
+	}
+	assert.Equal(t, 5, queue.SendNext())
+	require.Len(t, sender.batches, 3)
+	assert.Len(t, sender.batches[0], 2)
+	assert.Len(t, sender.batches[2], 1)
+	assert.Empty(t, sender.sent, "batches should not use Send")
+
+	sender.fail = true
+	require.NoError(t, queue.Send(Notification{UserId: 6}))
+	assert.Equal(t, 0, queue.SendNext())
+	require.Len(t, failed, 1)
+	assert.Equal(t, int64(6), failed[0].UserId)
+
+	// Run keeps sending until the context is done
+	sender.fail = false
+	require.NoError(t, queue.Send(Notification{UserId: 7}))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, queue.Run(ctx), context.DeadlineExceeded)
+	assert.Equal(t, 0, queue.Len())
+}
@@ -0,0 +1,156 @@
+package cali
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// DefaultTemplateLocale is the locale that is used when there is no template for the locale of the user
+const DefaultTemplateLocale = "en"
+
+// NotificationContent is the rendered subject and body of a notification, like for an email
+type NotificationContent struct {
+	// Locale is the tag of the locale that the content was rendered in
+	Locale  string `json:"locale"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// NotificationRenderer renders the content of a notification in a locale
+type NotificationRenderer interface {
+	// Render renders the notification, or returns ErrorTemplateNotFound if there is nothing
+	// to render it with, in which case the notification is sent without content
+	Render(n Notification, locale string) (*NotificationContent, error)
+}
+
+// WithNotificationRenderer renders every notification before it is sent (see WithNotificationSender)
+// in the locale of its user, which is found with userLocale (or DefaultTemplateLocale if it is nil)
+func WithNotificationRenderer(renderer NotificationRenderer, userLocale func(userId int64) string) CalendarOption {
+	return func(c *Calendar) {
+		c.notificationRenderer = renderer
+		c.userLocale = userLocale
+	}
+}
+
+// TemplateData is what notification templates are executed with
+type TemplateData struct {
+	Notification
+	// Locale is the tag of the locale of the template
+	Locale string
+	// When is the time of the event rendered in the locale and the event's zone (see Formatter.Range)
+	When string
+	// PreviousWhen is When for the Previous event of the notification, or "" if it doesn't have one
+	PreviousWhen string
+}
+
+// notificationTemplate is the parsed subject and body of a template
+type notificationTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// templateKey is the notification type and lower case locale tag of a template
+type templateKey struct {
+	notificationType NotificationType
+	locale           string
+}
+
+// TemplateRegistry is a NotificationRenderer that renders notifications with text/template
+// templates for each notification type and locale. When there is no template for a locale
+// the language without the region is tried ("fr-CA" falls back to "fr") and then
+// DefaultTemplateLocale.
+type TemplateRegistry struct {
+	mu        sync.RWMutex
+	templates map[templateKey]notificationTemplate
+}
+
+// NewTemplateRegistry creates a registry with the default English templates for every notification type
+func NewTemplateRegistry() *TemplateRegistry {
+	r := &TemplateRegistry{templates: map[templateKey]notificationTemplate{}}
+	for notificationType, t := range defaultTemplates {
+		if err := r.Register(notificationType, DefaultTemplateLocale, t[0], t[1]); err != nil {
+			panic(err)
+		}
+	}
+	return r
+}
+
+// defaultTemplates are the English subject and body of each notification type
+var defaultTemplates = map[NotificationType][2]string{
+	NotificationTypeInvited:          {"Invitation: {{.Event.Title}}", "You have been invited to {{.Event.Title}} on {{.When}}."},
+	NotificationTypeEventChanged:     {"Updated: {{.Event.Title}}", "{{.Event.Title}} has been updated and is now on {{.When}}."},
+	NotificationTypeEventCanceled:    {"Canceled: {{.Event.Title}}", "{{.Event.Title}} on {{.When}} has been canceled."},
+	NotificationTypeEventRescheduled: {"Rescheduled: {{.Event.Title}}", "{{.Event.Title}} has moved from {{.PreviousWhen}} to {{.When}}."},
+	NotificationTypeEventReminder:    {"Reminder: {{.Event.Title}}", "{{.Event.Title}} is on {{.When}}."},
+	NotificationTypeRespondReminder:  {"Please respond: {{.Event.Title}}", "You haven't responded to the invitation to {{.Event.Title}} on {{.When}}."},
+	NotificationTypeTimeProposed:     {"New time proposed: {{.Event.Title}}", "A new time was proposed for {{.Event.Title}} on {{.When}}."},
+}
+
+// Register parses and adds (or replaces) the subject and body templates of the notification type for the locale
+func (r *TemplateRegistry) Register(notificationType NotificationType, locale string, subject string, body string) error {
+	s, err := template.New("subject").Parse(subject)
+	if err != nil {
+		return err
+	}
+	b, err := template.New("body").Parse(body)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.templates == nil {
+		r.templates = map[templateKey]notificationTemplate{}
+	}
+	r.templates[templateKey{notificationType, normalizeLocale(locale)}] = notificationTemplate{subject: s, body: b}
+	return nil
+}
+
+// Render renders the notification with the template of its type for the locale
+func (r *TemplateRegistry) Render(n Notification, locale string) (*NotificationContent, error) {
+	t, locale, ok := r.lookup(n.Type, locale)
+	if !ok {
+		return nil, ErrorTemplateNotFound
+	}
+	data := TemplateData{Notification: n, Locale: locale}
+	if l, ok := LookupLocale(locale); ok && n.Event.Zone != "" {
+		if f, err := NewFormatter(l.Tag, n.Event.Zone); err == nil {
+			data.When, _ = f.Range(n.Event)
+			if n.Previous != nil {
+				data.PreviousWhen, _ = f.Range(*n.Previous)
+			}
+		}
+	}
+	var subject, body bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	return &NotificationContent{Locale: locale, Subject: subject.String(), Body: body.String()}, nil
+}
+
+// lookup finds the template for the locale and returns the locale that it is for
+func (r *TemplateRegistry) lookup(notificationType NotificationType, locale string) (notificationTemplate, string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	locale = normalizeLocale(locale)
+	candidates := []string{locale}
+	if i := strings.Index(locale, "-"); i > 0 {
+		candidates = append(candidates, locale[:i])
+	}
+	candidates = append(candidates, DefaultTemplateLocale)
+	for _, candidate := range candidates {
+		if t, ok := r.templates[templateKey{notificationType, candidate}]; ok {
+			return t, candidate, true
+		}
+	}
+	return notificationTemplate{}, "", false
+}
+
+// normalizeLocale makes the locale tag lower case with dashes like LookupLocale
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
@@ -0,0 +1,80 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateRegistry(t *testing.T) {
+	r := NewTemplateRegistry()
+	require.NoError(t, r.Register(NotificationTypeInvited, "fr", "Invitation : {{.Event.Title}}", "Vous êtes invité à {{.Event.Title}} le {{.When}}."))
+	assert.Error(t, r.Register(NotificationTypeInvited, "de", "{{.Event.Title", ""))
+
+	e := Event{Title: "Lunch", Zone: "UTC", StartDay: "2008-03-03", StartTime: "12:00", EndDay: "2008-03-03", EndTime: "13:00"}
+	testCases := []struct {
+		name         string
+		notification NotificationType
+		locale       string
+		content      NotificationContent
+	}{
+		{
+			name:         "english",
+			notification: NotificationTypeInvited,
+			locale:       "en-US",
+			content:      NotificationContent{Locale: "en", Subject: "Invitation: Lunch", Body: "You have been invited to Lunch on Mar 3, 12–1 PM."},
+		},
+		{
+			name:         "region falls back to the language",
+			notification: NotificationTypeInvited,
+			locale:       "fr_CA",
+			content:      NotificationContent{Locale: "fr", Subject: "Invitation : Lunch", Body: "Vous êtes invité à Lunch le 3 mars, 12:00–13:00."},
+		},
+		{
+			name:         "missing locale falls back to english",
+			notification: NotificationTypeEventCanceled,
+			locale:       "fr",
+			content:      NotificationContent{Locale: "en", Subject: "Canceled: Lunch", Body: "Lunch on Mar 3, 12–1 PM has been canceled."},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			content, err := r.Render(Notification{Type: tc.notification, Event: e}, tc.locale)
+			require.NoError(t, err)
+			assert.Equal(t, tc.content, *content)
+		})
+	}
+
+	_, err := (&TemplateRegistry{}).Render(Notification{Type: NotificationTypeInvited, Event: e}, "en")
+	assert.Equal(t, ErrorTemplateNotFound, err)
+}
+
+func TestWithNotificationRenderer(t *testing.T) {
+	sender := &testSender{}
+	locales := map[int64]string{2: "en", 3: "fr"}
+	r := NewTemplateRegistry()
+	require.NoError(t, r.Register(NotificationTypeEventCanceled, "fr", "Annulé : {{.Event.Title}}", "{{.Event.Title}} a été annulé."))
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender), WithNotificationRenderer(r, func(userId int64) string {
+		return locales[userId]
+	}))
+	e, _, err := c.Create(Event{OwnerId: 1, Title: "Lunch", Zone: "UTC", StartDay: "2008-03-03", StartTime: "12:00", EndDay: "2008-03-03", EndTime: "13:00"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 4, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.DeclineInvitation(e.Id, 4, RepeatEditTypeThis))
+	sender.sent = nil
+
+	require.NoError(t, c.Cancel(e.Id, RepeatEditTypeThis))
+	require.Len(t, sender.sent, 2, "the owner and users that declined aren't told")
+	assert.Equal(t, NotificationTypeEventCanceled, sender.sent[0].Type)
+	require.NotNil(t, sender.sent[0].Content)
+	assert.Equal(t, "Canceled: Lunch", sender.sent[0].Content.Subject)
+	require.NotNil(t, sender.sent[1].Content)
+	assert.Equal(t, "Annulé : Lunch", sender.sent[1].Content.Subject)
+	assert.Equal(t, "Lunch a été annulé.", sender.sent[1].Content.Body)
+}
@@ -0,0 +1,83 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testSender struct {
+	sent []Notification
+}
+
+func (s *testSender) Send(n Notification) error {
+	s.sent = append(s.sent, n)
+	return nil
+}
+
+func TestDiffEvents(t *testing.T) {
+	location := "Room 1"
+	before := Event{Title: "a", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00"}
+	after := before
+	after.StartTime = "10:00"
+	after.EndTime = "11:00"
+	after.Location = &location
+
+	assert.Equal(t, []FieldChange{
+		{Field: "location", Old: "", New: "Room 1"},
+		{Field: "startTime", Old: "09:00", New: "10:00"},
+		{Field: "endTime", Old: "10:00", New: "11:00"},
+	}, DiffEvents(before, after))
+	assert.Empty(t, DiffEvents(before, before))
+}
+
+func TestChangeNotifications(t *testing.T) {
+	sender := &testSender{}
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender))
+	a, _, err := c.Create(Event{
+		OwnerId:   1,
+		Title:     "Standup",
+		StartDay:  "2008-01-01",
+		StartTime: "09:00",
+		EndDay:    "2008-01-01",
+		EndTime:   "09:15",
+		Zone:      "UTC",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(a.Id, 8, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(a.Id, 9, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.AcceptInvitation(a.Id, 7, RepeatEditTypeThis))
+	require.NoError(t, c.RevokeInvitation(a.Id, 9, RepeatEditTypeThis))
+	// every invited user is told about the invitation
+	require.Len(t, sender.sent, 3)
+	for _, n := range sender.sent {
+		assert.Equal(t, NotificationTypeInvited, n.Type)
+		assert.Equal(t, InviteStatusPending, n.InviteStatus)
+	}
+	sender.sent = nil
+
+	require.NoError(t, c.UpdateTime(a.Id, "09:30", "09:45", RepeatEditTypeThis))
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, int64(7), sender.sent[0].UserId)
+	assert.Equal(t, InviteStatusConfirmed, sender.sent[0].InviteStatus)
+	assert.Equal(t, int64(8), sender.sent[1].UserId)
+	assert.Equal(t, InviteStatusPending, sender.sent[1].InviteStatus)
+	assert.Equal(t, NotificationTypeEventChanged, sender.sent[0].Type)
+	assert.Equal(t, []FieldChange{
+		{Field: "startTime", Old: "09:00", New: "09:30"},
+		{Field: "endTime", Old: "09:15", New: "09:45"},
+	}, sender.sent[0].Changes)
+	assert.Equal(t, "09:30", sender.sent[0].Event.StartTime)
+
+	location := "Room 1"
+	require.NoError(t, c.UpdateLocation(a.Id, &location, RepeatEditTypeThis))
+	require.Len(t, sender.sent, 4)
+	assert.Equal(t, "location", sender.sent[3].Changes[0].Field)
+
+	// other changes and changes that don't change anything aren't sent
+	require.NoError(t, c.UpdateTitle(a.Id, "Sync", RepeatEditTypeThis))
+	require.NoError(t, c.UpdateLocation(a.Id, &location, RepeatEditTypeThis))
+	assert.Len(t, sender.sent, 4)
+}
@@ -0,0 +1,65 @@
+package cali
+
+import (
+	"sort"
+	"time"
+)
+
+// PendingInviteStore is an optional interface for a data store that can find the invitations
+// that haven't been responded to, so that the invitees can be reminded
+type PendingInviteStore interface {
+	// PendingInvitesBefore retrieves the pending invites and series invites of active events
+	// (other than the owner's) that were created before the time
+	PendingInvitesBefore(before time.Time) ([]*Invite, error)
+}
+
+// PendingInvitesOlderThan gets the invitations of active events that are still pending after
+// being created for at least d, sorted by when they were created
+func (c *Calendar) PendingInvitesOlderThan(d time.Duration) ([]*Invite, error) {
+	store, ok := capability[PendingInviteStore](c.dataStore)
+	if !ok {
+		return nil, ErrorPendingInvitesNotSupported
+	}
+	invites, err := store.PendingInvitesBefore(time.Now().Add(-d))
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(invites, func(i, j int) bool {
+		return invites[i].Created.Before(invites[j].Created)
+	})
+	return invites, nil
+}
+
+// NudgePendingInvites sends a NotificationTypeRespondReminder notification to every invitee
+// that is still pending after being invited for at least d and returns the number sent. Every
+// call sends the reminders again, so a worker should call it as often as invitees should be reminded.
+func (c *Calendar) NudgePendingInvites(d time.Duration) (int64, error) {
+	if c.notificationSender == nil {
+		return 0, ErrorNotificationsNotConfigured
+	}
+	invites, err := c.PendingInvitesOlderThan(d)
+	if err != nil {
+		return 0, err
+	}
+	var sent int64
+	for _, invite := range invites {
+		e, err := c.dataStore.Get(invite.EventId)
+		if err != nil {
+			return sent, err
+		}
+		if e == nil {
+			continue
+		}
+		err = c.sendNotification(Notification{
+			Type:         NotificationTypeRespondReminder,
+			UserId:       invite.UserId,
+			Event:        *e,
+			InviteStatus: invite.Status,
+		})
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
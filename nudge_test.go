@@ -0,0 +1,61 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNudgePendingInvites(t *testing.T) {
+	sender := &testSender{}
+	store := &InMemoryDataStore{}
+	c := NewCalendar(store, WithNotificationSender(sender))
+	create := func(e Event) *Event {
+		e.OwnerId, e.StartDay, e.StartTime, e.EndDay, e.EndTime, e.Zone = 1, "2008-01-01", "09:00", "2008-01-01", "10:00", "UTC"
+		created, _, err := c.Create(e)
+		require.NoError(t, err)
+		return created
+	}
+	a := create(Event{})
+	canceled := create(Event{})
+	series := create(Event{IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+	require.NoError(t, c.InviteUser(a.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(a.Id, 3, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(a.Id, 4, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(canceled.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(series.Id, 5, PermissionInvitee, RepeatEditTypeAll))
+	require.NoError(t, c.AcceptInvitation(a.Id, 3, RepeatEditTypeThis))
+	require.NoError(t, c.Cancel(canceled.Id, RepeatEditTypeThis))
+	sender.sent = nil
+
+	// everyone but user 4 was invited three days ago
+	threeDaysAgo := time.Now().Add(-72 * time.Hour)
+	for _, i := range append(store.invites, store.seriesInvites...) {
+		if i.UserId != 4 {
+			i.Created = threeDaysAgo
+		}
+	}
+
+	invites, err := c.PendingInvitesOlderThan(48 * time.Hour)
+	require.NoError(t, err)
+	require.Len(t, invites, 2)
+	assert.Equal(t, int64(2), invites[0].UserId)
+	assert.Equal(t, a.Id, invites[0].EventId)
+	assert.Equal(t, int64(5), invites[1].UserId)
+	assert.True(t, invites[1].IsSeries)
+
+	sent, err := c.NudgePendingInvites(48 * time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), sent)
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, NotificationTypeRespondReminder, sender.sent[0].Type)
+	assert.Equal(t, int64(2), sender.sent[0].UserId)
+	assert.Equal(t, a.Id, sender.sent[0].Event.Id)
+
+	_, err = NewCalendar(store).NudgePendingInvites(time.Hour)
+	assert.Equal(t, ErrorNotificationsNotConfigured, err)
+	_, err = NewCalendar(struct{ DataStore }{store}).PendingInvitesOlderThan(time.Hour)
+	assert.Equal(t, ErrorPendingInvitesNotSupported, err)
+}
@@ -0,0 +1,56 @@
+package cali
+
+import (
+	"sort"
+)
+
+// IsOrganizer returns true if the invite has every permission of PermissionOwner and isn't
+// revoked, so the user is a co-organizer of the event along with its owner
+func (i Invite) IsOrganizer() bool {
+	return i.Status != InviteStatusRevoked && i.Permission&PermissionOwner == PermissionOwner
+}
+
+// AddCoOrganizer gives the user the permissions of the owner on the event, inviting them
+// if they aren't invited yet. A co-organizer of every event of a series can edit the series
+// and manage its invites, so use RepeatEditTypeAll for that.
+func (c *Calendar) AddCoOrganizer(eventId int64, userId int64, editType RepeatEditType) error {
+	invite, err := c.GetInvitation(eventId, userId)
+	if err != nil {
+		return err
+	}
+	if invite == nil || invite.Status == InviteStatusRevoked {
+		return c.InviteUser(eventId, userId, PermissionOwner, editType)
+	}
+	return c.UpdateInvitationPermission(eventId, userId, invite.Permission|PermissionOwner, editType)
+}
+
+// Organizers gets the owner of the event followed by its co-organizers sorted by user id.
+// The data store must implement InviteListStore.
+func (c *Calendar) Organizers(eventId int64) ([]int64, error) {
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	invites, err := c.getInvites(eventId)
+	if err != nil {
+		return nil, err
+	}
+	var coOrganizers []int64
+	seen := map[int64]bool{e.OwnerId: true}
+	for _, invite := range invites {
+		if seen[invite.UserId] {
+			continue
+		}
+		seen[invite.UserId] = true
+		if invite.IsOrganizer() {
+			coOrganizers = append(coOrganizers, invite.UserId)
+		}
+	}
+	sort.Slice(coOrganizers, func(i, j int) bool {
+		return coOrganizers[i] < coOrganizers[j]
+	})
+	return append([]int64{e.OwnerId}, coOrganizers...), nil
+}
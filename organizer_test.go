@@ -0,0 +1,44 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoOrganizers(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 4, PermissionEditor, RepeatEditTypeThis))
+
+	require.NoError(t, c.AddCoOrganizer(e.Id, 3, RepeatEditTypeThis))
+	require.NoError(t, c.AddCoOrganizer(e.Id, 2, RepeatEditTypeAll))
+	organizers, err := c.Organizers(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, organizers)
+
+	// the series invite makes them a co-organizer of every event of the series
+	events, err := c.Query(Query{ParentIds: []int64{*e.ParentId}})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	organizers, err = c.Organizers(events[1].Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, organizers)
+
+	invite, err := c.GetInvitation(e.Id, 3)
+	require.NoError(t, err)
+	assert.True(t, invite.IsOrganizer())
+	assert.Equal(t, InviteStatusPending, invite.Status)
+	invite, err = c.GetInvitation(e.Id, 4)
+	require.NoError(t, err)
+	assert.False(t, invite.IsOrganizer())
+
+	require.NoError(t, c.RevokeInvitation(e.Id, 3, RepeatEditTypeThis))
+	organizers, err = c.Organizers(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, organizers)
+}
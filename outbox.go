@@ -0,0 +1,194 @@
+package cali
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// OutboxKind is the kind of payload in an outbox record
+type OutboxKind string
+
+const (
+	// OutboxKindChange records have the JSON of a Change as the payload
+	OutboxKindChange OutboxKind = "change"
+	// OutboxKindNotification records have the JSON of a Notification as the payload
+	OutboxKindNotification OutboxKind = "notification"
+)
+
+// OutboxRecord is a change or notification that is waiting to be delivered
+type OutboxRecord struct {
+	// Id is unique for every record and can be used by receivers to ignore repeated deliveries
+	Id int64 `json:"id"`
+	// Kind is the kind of payload
+	Kind OutboxKind `json:"kind"`
+	// Payload is the JSON of the change or notification
+	Payload json.RawMessage `json:"payload"`
+	// Attempts is the number of failed deliveries
+	Attempts int64 `json:"attempts"`
+	// LastError is the error of the last failed delivery
+	LastError string `json:"lastError"`
+	// Created is when the record was added to the outbox
+	Created time.Time `json:"created"`
+	// Delivered is when the record was delivered, or nil if it hasn't been delivered yet
+	Delivered *time.Time `json:"delivered"`
+}
+
+// Change decodes the payload of an OutboxKindChange record
+func (r OutboxRecord) Change() (Change, error) {
+	var change Change
+	err := json.Unmarshal(r.Payload, &change)
+	return change, err
+}
+
+// Notification decodes the payload of an OutboxKindNotification record
+func (r OutboxRecord) Notification() (Notification, error) {
+	var n Notification
+	err := json.Unmarshal(r.Payload, &n)
+	return n, err
+}
+
+// OutboxStore is an optional interface for a data store that keeps an outbox of changes and
+// notifications to deliver. If the data store also implements TxStore, the calendar adds the
+// outbox records in the transaction of the changes, so that a change is never saved without
+// its record.
+type OutboxStore interface {
+	// AddOutboxRecord saves a new record and handles setting the Id and Created fields
+	AddOutboxRecord(record OutboxRecord) (*OutboxRecord, error)
+	// PendingOutboxRecords gets up to limit records that haven't been delivered, oldest first
+	PendingOutboxRecords(limit int) ([]*OutboxRecord, error)
+	// MarkOutboxDelivered sets the Delivered field of the record
+	MarkOutboxDelivered(id int64) error
+	// MarkOutboxFailed increments the Attempts and sets the LastError of the record
+	MarkOutboxFailed(id int64, reason string) error
+}
+
+// WithOutbox writes every change (see Watch) and notification (see WithNotificationSender)
+// to the outbox of the data store instead of sending notifications right away. An
+// OutboxDrainer delivers the records at least once, even if the process stops between
+// saving a change and sending it. The data store must implement OutboxStore.
+func WithOutbox() CalendarOption {
+	return func(c *Calendar) {
+		c.outbox = true
+	}
+}
+
+// outboxTx calls f with a calendar whose data store makes the changes of f in one transaction
+// along with their outbox records, so that a change is never saved without its record. The
+// changes are sent to the watchers once the transaction is committed. Callers name the
+// calendar of f c, so that nothing in f can reach the data store outside of the transaction.
+// Without an outbox, or in an outbox transaction already, f is called with the calendar.
+func (c *Calendar) outboxTx(f func(c *Calendar) error) error {
+	if !c.outbox || c.outboxChanges != nil {
+		return f(c)
+	}
+	var changes []Change
+	scoped := *c
+	scoped.outboxChanges = &changes
+	err := scoped.inTx(f)
+	if _, ok := capability[TxStore](c.dataStore); ok && err != nil {
+		// the changes were rolled back
+		return err
+	}
+	for _, change := range changes {
+		c.sendChange(change)
+	}
+	return err
+}
+
+// addToOutbox saves the payload as a new outbox record
+func (c *Calendar) addToOutbox(kind OutboxKind, payload interface{}) error {
+	store, ok := capability[OutboxStore](c.dataStore)
+	if !ok {
+		return ErrorOutboxNotSupported
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = store.AddOutboxRecord(OutboxRecord{Kind: kind, Payload: b})
+	return err
+}
+
+// DefaultOutboxBatchSize is the number of records an OutboxDrainer delivers at a time
+const DefaultOutboxBatchSize = 100
+
+// OutboxDrainer delivers the pending records of an outbox. A record is only marked as
+// delivered after Deliver returns nil, so a record can be delivered more than once if the
+// process stops in between, and receivers should use the Id to ignore repeats.
+type OutboxDrainer struct {
+	// Store is the outbox to deliver
+	Store OutboxStore
+	// Deliver sends a single record (to a webhook, a NotificationSender, etc)
+	Deliver func(record OutboxRecord) error
+	// BatchSize is the most records read at a time, the default is DefaultOutboxBatchSize
+	BatchSize int
+}
+
+// DeliverNotifications makes a Deliver function for an OutboxDrainer that sends notification
+// records with the sender and passes change records to changes (which can be nil to skip them)
+func DeliverNotifications(sender NotificationSender, changes func(change Change) error) func(record OutboxRecord) error {
+	return func(record OutboxRecord) error {
+		switch record.Kind {
+		case OutboxKindNotification:
+			n, err := record.Notification()
+			if err != nil {
+				return err
+			}
+			return sender.Send(n)
+		case OutboxKindChange:
+			if changes == nil {
+				return nil
+			}
+			change, err := record.Change()
+			if err != nil {
+				return err
+			}
+			return changes(change)
+		}
+		return nil
+	}
+}
+
+// Drain delivers one batch of pending records and returns the number that were delivered.
+// Records that fail are marked as failed and are tried again by the next Drain.
+func (d *OutboxDrainer) Drain() (int, error) {
+	size := d.BatchSize
+	if size <= 0 {
+		size = DefaultOutboxBatchSize
+	}
+	records, err := d.Store.PendingOutboxRecords(size)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for _, record := range records {
+		if err := d.Deliver(*record); err != nil {
+			if err := d.Store.MarkOutboxFailed(record.Id, err.Error()); err != nil {
+				return delivered, err
+			}
+			continue
+		}
+		if err := d.Store.MarkOutboxDelivered(record.Id); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// Run drains the outbox every interval until the context is done
+func (d *OutboxDrainer) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Drain(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cali
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutbox(t *testing.T) {
+	d := &InMemoryDataStore{}
+	sender := &testSender{}
+	c := NewCalendar(d, WithNotificationSender(sender), WithOutbox())
+
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", StartTime: "10:00", EndTime: "11:00", Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionViewer, RepeatEditTypeThis))
+	require.NoError(t, c.UpdateTime(e.Id, "12:00", "13:00", RepeatEditTypeThis))
+	assert.Empty(t, sender.sent, "notifications should wait in the outbox")
+
+	var changes []Change
+	failing := true
+	drainer := &OutboxDrainer{
+		Store: d,
+		Deliver: DeliverNotifications(sender, func(change Change) error {
+			if failing {
+				return errors.New("webhook is down")
+			}
+			changes = append(changes, change)
+			return nil
+		}),
+	}
+	delivered, err := drainer.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 2, delivered, "only the notifications should be delivered")
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, NotificationTypeInvited, sender.sent[0].Type)
+	assert.Equal(t, NotificationTypeEventChanged, sender.sent[1].Type)
+	assert.Equal(t, int64(2), sender.sent[1].UserId)
+
+	pending, err := d.PendingOutboxRecords(10)
+	require.NoError(t, err)
+	require.Len(t, pending, 3)
+	assert.Equal(t, int64(1), pending[0].Attempts)
+	assert.Equal(t, "webhook is down", pending[0].LastError)
+
+	failing = false
+	delivered, err = drainer.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 3, delivered)
+	require.Len(t, changes, 3)
+	assert.Equal(t, ChangeTypeCreated, changes[0].Type)
+	assert.Equal(t, ChangeTypeInvite, changes[1].Type)
+	assert.Equal(t, ChangeTypeUpdated, changes[2].Type)
+	require.NotNil(t, changes[2].Event)
+	assert.Equal(t, "12:00", changes[2].Event.StartTime)
+
+	pending, err = d.PendingOutboxRecords(10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutboxNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}, WithOutbox())
+	_, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	assert.Equal(t, ErrorOutboxNotSupported, err)
+}
+
+func TestEncryptedOutbox(t *testing.T) {
+	d := &InMemoryDataStore{}
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	c := NewCalendar(d, WithEncryptor(encryptor), WithOutbox())
+	_, _, err = c.Create(Event{Title: "secret meeting", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+
+	require.Len(t, d.outbox, 1)
+	assert.NotContains(t, string(d.outbox[0].Payload), "secret meeting")
+
+	drainer := &OutboxDrainer{Store: c.dataStore.(OutboxStore), Deliver: func(record OutboxRecord) error {
+		change, err := record.Change()
+		require.NoError(t, err)
+		require.NotNil(t, change.Event)
+		assert.Equal(t, "secret meeting", change.Event.Title)
+		return nil
+	}}
+	delivered, err := drainer.Drain()
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+}
+
+// txOutboxStore is a data store with transactions that fails the outbox records that aren't
+// added in a transaction, or all of them when fail is set
+type txOutboxStore struct {
+	*InMemoryDataStore
+	inTx bool
+	fail bool
+}
+
+func (d *txOutboxStore) InTx(f func(tx DataStore) error) error {
+	d.inTx = true
+	defer func() { d.inTx = false }()
+	return f(d)
+}
+
+func (d *txOutboxStore) AddOutboxRecord(record OutboxRecord) (*OutboxRecord, error) {
+	if !d.inTx {
+		return nil, errors.New("outbox record outside of a transaction")
+	}
+	if d.fail {
+		return nil, errors.New("disk full")
+	}
+	return d.InMemoryDataStore.AddOutboxRecord(record)
+}
+
+func TestOutboxTx(t *testing.T) {
+	d := &txOutboxStore{InMemoryDataStore: &InMemoryDataStore{}}
+	c := NewCalendar(d, WithOutbox())
+	changes, stop := c.Watch(10)
+	defer stop()
+
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", StartTime: "10:00", EndTime: "11:00", Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionViewer, RepeatEditTypeThis))
+	require.NoError(t, c.UpdateTitle(e.Id, "Lunch", RepeatEditTypeThis))
+	_, err = c.CancelMany(Query{EventIds: []int64{e.Id}})
+	require.NoError(t, err)
+	pending, err := d.PendingOutboxRecords(10)
+	require.NoError(t, err)
+	assert.Len(t, pending, 4, "the records are added in the transactions of the changes")
+	assert.Len(t, changes, 4)
+
+	d.fail = true
+	assert.EqualError(t, c.UpdateTitle(e.Id, "Dinner", RepeatEditTypeThis), "disk full")
+	assert.Len(t, changes, 4, "the watchers only get the changes of committed transactions")
+}
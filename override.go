@@ -0,0 +1,119 @@
+package cali
+
+// The fields of an event in a series that can be overridden, which are the JSON names of the fields
+// (where OverrideTime is the start and end day and time)
+const (
+	OverrideTitle              = "title"
+	OverrideDescription        = "description"
+	OverrideUrl                = "url"
+	OverrideLinks              = "links"
+	OverrideLocation           = "location"
+	OverrideGeo                = "geo"
+	OverrideVisibility         = "visibility"
+	OverrideDisallowForwarding = "disallowForwarding"
+	OverridePriority           = "priority"
+	OverrideTime               = "time"
+)
+
+// OverrideStore is an optional interface for a data store that can keep track of the fields of
+// events in a series that were edited on their own (with RepeatEditTypeThis). Edits to the other
+// events of the series (with RepeatEditTypeAll or RepeatEditTypeThisAndAfter) skip the fields
+// that an event overrides instead of changing them back.
+type OverrideStore interface {
+	// SetOverrides updates the overridden fields of the event, where nil removes them
+	SetOverrides(eventId int64, overrides []string) error
+	// SetRecurrenceId updates the start that the event had in its series before its time was overridden
+	SetRecurrenceId(eventId int64, recurrenceId string) error
+}
+
+// HasOverride returns true if the field of the event was edited on its own and isn't
+// changed by edits to the rest of its series
+func (e Event) HasOverride(field string) bool {
+	for _, o := range e.Overrides {
+		if o == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ResetOverrides removes the overridden fields of the event so that edits to its series
+// change all of the fields of the event again
+func (c *Calendar) ResetOverrides(eventId int64) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[OverrideStore](c.dataStore)
+		if !ok {
+			return ErrorOverridesNotSupported
+		}
+		return c.editEvents(OperationUpdate, RepeatEditTypeThis, eventId, func(eventId int64) error {
+			return store.SetOverrides(eventId, nil)
+		})
+	})
+}
+
+// editField is editEvents for an edit of one of the override fields. An edit of a single event
+// in a series marks the field as overridden, and an edit of many events skips the events (other
+// than the event being edited) that override the field. If the data store doesn't implement
+// OverrideStore, then it is the same as editEvents.
+func (c *Calendar) editField(field string, editType RepeatEditType, eventId int64, f func(eventId int64) error) error {
+	store, ok := capability[OverrideStore](c.dataStore)
+	if !ok {
+		return c.editEvents(OperationUpdate, editType, eventId, f)
+	}
+	if err := c.authorize(OperationUpdate, eventId, nil); err != nil {
+		return err
+	}
+	if err := c.checkLocks(editType, eventId); err != nil {
+		return err
+	}
+	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(id int64) error {
+		e, err := c.dataStore.Get(id)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrorEventNotFound
+		}
+		if editType != RepeatEditTypeThis && id != eventId && e.HasOverride(field) {
+			return nil
+		}
+		event := *e
+		if err := f(id); err != nil {
+			return err
+		}
+		if editType == RepeatEditTypeThis {
+			if err := addOverride(store, event, field); err != nil {
+				return err
+			}
+		}
+		return c.publish(ChangeTypeUpdated, id, nil)
+	})
+}
+
+// addOverride marks the field of the event (from before the edit) as overridden if the event is in
+// a series, and keeps the start of the event as its RecurrenceId the first time that its time is overridden
+func addOverride(store OverrideStore, e Event, field string) error {
+	if e.ParentId == nil {
+		return nil
+	}
+	if field == OverrideTime && e.RecurrenceId == "" {
+		if err := store.SetRecurrenceId(e.Id, e.recurrenceStart()); err != nil {
+			return err
+		}
+	}
+	if e.HasOverride(field) {
+		return nil
+	}
+	return store.SetOverrides(e.Id, append(append([]string(nil), e.Overrides...), field))
+}
+
+// recurrenceStart is the RecurrenceId of the event, or its start if its time wasn't overridden
+func (e Event) recurrenceStart() string {
+	if e.RecurrenceId != "" {
+		return e.RecurrenceId
+	}
+	if e.IsAllDay || e.StartTime == "" {
+		return e.StartDay
+	}
+	return e.StartDay + " " + e.StartTime
+}
@@ -0,0 +1,69 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverrides(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	_, _, err := c.Create(Event{OwnerId: 1, Title: "Standup", Zone: "UTC", IsRepeating: true, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15", Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	events, err := c.Query(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	first, second, third := events[0].Id, events[1].Id, events[2].Id
+
+	// editing one event of the series overrides the field
+	require.NoError(t, c.UpdateTitle(second, "Planning", RepeatEditTypeThis))
+	require.NoError(t, c.UpdateTime(second, "10:00", "11:00", RepeatEditTypeThis))
+	e, err := c.Get(second)
+	require.NoError(t, err)
+	assert.Equal(t, []string{OverrideTitle, OverrideTime}, e.Overrides)
+
+	// edits to the series skip the overridden fields
+	require.NoError(t, c.UpdateTitle(first, "Daily standup", RepeatEditTypeAll))
+	require.NoError(t, c.UpdateTime(first, "09:30", "09:45", RepeatEditTypeAll))
+	notes := "notes"
+	require.NoError(t, c.UpdateDescription(first, &notes, RepeatEditTypeAll))
+	for _, id := range []int64{first, third} {
+		e, err := c.Get(id)
+		require.NoError(t, err)
+		assert.Equal(t, "Daily standup", e.Title)
+		assert.Equal(t, "09:30", e.StartTime)
+		assert.Empty(t, e.Overrides)
+	}
+	e, err = c.Get(second)
+	require.NoError(t, err)
+	assert.Equal(t, "Planning", e.Title)
+	assert.Equal(t, "10:00", e.StartTime)
+	assert.Equal(t, "notes", *e.Description)
+
+	// an edit to the series from the overridden event still changes that event
+	require.NoError(t, c.UpdateTitle(second, "Sync", RepeatEditTypeAll))
+	e, err = c.Get(second)
+	require.NoError(t, err)
+	assert.Equal(t, "Sync", e.Title)
+
+	// reset the overrides so that the series edits move the event again
+	require.NoError(t, c.ResetOverrides(second))
+	require.NoError(t, c.UpdateTime(first, "08:00", "08:15", RepeatEditTypeAll))
+	e, err = c.Get(second)
+	require.NoError(t, err)
+	assert.Empty(t, e.Overrides)
+	assert.Equal(t, "08:30", e.StartTime)
+
+	// events that aren't in a series don't have overrides
+	single, _, err := c.Create(Event{OwnerId: 1, Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15"})
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateTitle(single.Id, "Lunch", RepeatEditTypeThis))
+	e, err = c.Get(single.Id)
+	require.NoError(t, err)
+	assert.Empty(t, e.Overrides)
+
+	// data stores without overrides
+	c = NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	assert.Equal(t, ErrorOverridesNotSupported, c.ResetOverrides(first))
+}
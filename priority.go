@@ -0,0 +1,46 @@
+package cali
+
+import (
+	"sort"
+)
+
+// PriorityStore is an optional interface for a data store that can change the priority of
+// events (see UpdatePriority)
+type PriorityStore interface {
+	// SetPriority updates the event with the priority value
+	SetPriority(eventId int64, priority Priority) error
+}
+
+// rank orders priorities from 1 (highest) to 9 (lowest) where undefined is treated as medium
+func (p Priority) rank() Priority {
+	if p == PriorityUndefined {
+		return PriorityMedium
+	}
+	return p
+}
+
+// HigherThan returns true if the priority is more important than the other priority
+func (p Priority) HigherThan(other Priority) bool {
+	return p.rank() < other.rank()
+}
+
+// RescheduleCandidates finds the user's active events that overlap with the event and have a
+// lower priority than it, so they can be suggested for rescheduling to make room for the event.
+// The events with the lowest priority are first.
+func (c *Calendar) RescheduleCandidates(userId int64, e Event) ([]*Event, error) {
+	conflicts, err := c.conflictingEvents(userId, e)
+	if err != nil {
+		return nil, err
+	}
+	var result []*Event
+	for _, other := range conflicts {
+		if e.Priority.HigherThan(other.Priority) {
+			result = append(result, other)
+		}
+	}
+	Sort(result)
+	sort.SliceStable(result, func(a, b int) bool {
+		return result[b].Priority.HigherThan(result[a].Priority)
+	})
+	return result, nil
+}
@@ -0,0 +1,51 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriorityQuery(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	for _, p := range []Priority{PriorityUndefined, PriorityHigh, PriorityLow} {
+		_, _, err := c.Create(Event{Priority: p, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+	}
+	_, _, err := c.Create(Event{Priority: 10, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	assert.Equal(t, ErrorInvalidPriority, err)
+
+	events, err := c.Query(Query{Priorities: []Priority{PriorityHigh, PriorityLow}})
+	require.NoError(t, err)
+	assert.Len(t, events, 2)
+
+	require.NoError(t, c.UpdatePriority(events[0].Id, PriorityMedium, RepeatEditTypeThis))
+	assert.Equal(t, ErrorInvalidPriority, c.UpdatePriority(events[0].Id, -1, RepeatEditTypeThis))
+	events, err = c.Query(Query{Priorities: []Priority{PriorityMedium}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Contains(t, events[0].MarshallToICal(), "PRIORITY:5")
+}
+
+func TestRescheduleCandidates(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	create := func(title string, p Priority, startTime, endTime string) *Event {
+		e, _, err := c.Create(Event{OwnerId: 1, Title: title, Priority: p, StartDay: "2008-01-01", StartTime: startTime, EndDay: "2008-01-01", EndTime: endTime, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	create("important", PriorityHigh, "09:00", "10:00")
+	create("normal", PriorityUndefined, "09:30", "10:30")
+	create("optional", PriorityLow, "09:45", "11:00")
+	create("later", PriorityLow, "12:00", "13:00")
+
+	candidates, err := c.RescheduleCandidates(1, Event{Priority: 2, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "11:00", Zone: "UTC"})
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, "optional", candidates[0].Title)
+	assert.Equal(t, "normal", candidates[1].Title)
+
+	assert.True(t, PriorityHigh.HigherThan(PriorityUndefined))
+	assert.False(t, PriorityUndefined.HigherThan(PriorityMedium))
+}
@@ -0,0 +1,138 @@
+package cali
+
+import (
+	"time"
+)
+
+// TimeProposal is a new time for an event that an invitee asked the owner for
+type TimeProposal struct {
+	// StartDay is the YYYY-MM-DD value of the proposed start in the zone of the event
+	StartDay string `json:"startDay"`
+	// StartTime is the HH:MM value of the proposed start in the zone of the event
+	StartTime string `json:"startTime"`
+	// EndDay is the YYYY-MM-DD value of the proposed end in the zone of the event
+	EndDay string `json:"endDay"`
+	// EndTime is the HH:MM value of the proposed end in the zone of the event
+	EndTime string `json:"endTime"`
+	// Comment is an optional message to the owner of the event
+	Comment *string `json:"comment"`
+	// Created is a timestamp for when the time was proposed
+	Created time.Time `json:"created"`
+}
+
+// ProposalStore is an optional interface for a data store that can save the new times that invitees propose
+type ProposalStore interface {
+	// SetInviteProposal uses the EventId and UserId to update the proposal of the invite (nil removes it)
+	// and updates the Updated date too
+	SetInviteProposal(eventId, userId int64, proposal *TimeProposal) error
+}
+
+// ProposeNewTime declines the user's invitation to the event and records the start and end as a new
+// time on the invite. The owner of the event is sent a NotificationTypeTimeProposed notification, and
+// they can reschedule the event to the new time with AcceptProposal.
+func (c *Calendar) ProposeNewTime(eventId int64, userId int64, start, end time.Time, comment *string) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[ProposalStore](c.dataStore)
+		if !ok {
+			return ErrorProposalsNotSupported
+		}
+		e, err := c.dataStore.Get(eventId)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrorEventNotFound
+		}
+		loc, err := time.LoadLocation(e.Zone)
+		if err != nil {
+			return ErrorInvalidZone
+		}
+		if !start.Before(end) {
+			return ErrorInvalidProposal
+		}
+		start, end = start.In(loc), end.In(loc)
+		proposal := &TimeProposal{
+			StartDay:  start.Format(time.DateOnly),
+			StartTime: start.Format(TimeFormat),
+			EndDay:    end.Format(time.DateOnly),
+			EndTime:   end.Format(TimeFormat),
+			Comment:   comment,
+			Created:   time.Now(),
+		}
+
+		if err := c.setInviteStatus(eventId, userId, InviteStatusDeclined, RepeatEditTypeThis); err != nil {
+			return err
+		}
+		if err := store.SetInviteProposal(eventId, userId, proposal); err != nil {
+			return err
+		}
+		if err := c.publish(ChangeTypeInvite, eventId, &userId); err != nil {
+			return err
+		}
+		if c.notificationSender == nil || e.OwnerId == userId {
+			return nil
+		}
+		return c.sendNotification(Notification{
+			Type:         NotificationTypeTimeProposed,
+			UserId:       e.OwnerId,
+			Event:        *e,
+			InviteStatus: InviteStatusDeclined,
+			FromUserId:   &userId,
+			Proposal:     proposal,
+		})
+	})
+}
+
+// AcceptProposal moves the event to the new time that the user proposed, confirms the user's
+// invitation, and removes the proposal
+func (c *Calendar) AcceptProposal(eventId int64, userId int64) error {
+	store, proposal, err := c.getProposal(eventId, userId)
+	if err != nil {
+		return err
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return ErrorEventNotFound
+	}
+	err = c.UpdateDayTime(eventId, proposal.StartDay, proposal.StartTime, proposal.EndDay, proposal.EndTime, e.Zone, false)
+	if err != nil {
+		return err
+	}
+	if err := store.SetInviteProposal(eventId, userId, nil); err != nil {
+		return err
+	}
+	return c.AcceptInvitation(eventId, userId, RepeatEditTypeThis)
+}
+
+// DeclineProposal removes the new time that the user proposed without changing the event
+func (c *Calendar) DeclineProposal(eventId int64, userId int64) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, _, err := c.getProposal(eventId, userId)
+		if err != nil {
+			return err
+		}
+		if err := store.SetInviteProposal(eventId, userId, nil); err != nil {
+			return err
+		}
+		return c.publish(ChangeTypeInvite, eventId, &userId)
+	})
+}
+
+// getProposal gets the proposal on the user's invite or returns ErrorProposalNotFound
+func (c *Calendar) getProposal(eventId int64, userId int64) (ProposalStore, *TimeProposal, error) {
+	store, ok := capability[ProposalStore](c.dataStore)
+	if !ok {
+		return nil, nil, ErrorProposalsNotSupported
+	}
+	invite, err := c.dataStore.GetInvite(eventId, userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if invite == nil || invite.Proposal == nil {
+		return nil, nil, ErrorProposalNotFound
+	}
+	return store, invite.Proposal, nil
+}
@@ -0,0 +1,76 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProposeNewTime(t *testing.T) {
+	sender := &testSender{}
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender))
+	a, _, err := c.Create(Event{
+		OwnerId:   1,
+		Title:     "Review",
+		StartDay:  "2008-01-01",
+		StartTime: "09:00",
+		EndDay:    "2008-01-01",
+		EndTime:   "10:00",
+		Zone:      "America/New_York",
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeThis))
+	sender.sent = nil
+
+	start := time.Date(2008, 1, 2, 14, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+	assert.Equal(t, ErrorInvalidProposal, c.ProposeNewTime(a.Id, 7, end, start, nil))
+	assert.Equal(t, ErrorProposalNotFound, c.AcceptProposal(a.Id, 7))
+
+	comment := "I'm out in the morning"
+	require.NoError(t, c.ProposeNewTime(a.Id, 7, start, end, &comment))
+	invite, err := c.GetInvitation(a.Id, 7)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusDeclined, invite.Status)
+	require.NotNil(t, invite.Proposal)
+	// the proposal is in the zone of the event
+	assert.Equal(t, "2008-01-02", invite.Proposal.StartDay)
+	assert.Equal(t, "09:00", invite.Proposal.StartTime)
+	assert.Equal(t, "09:30", invite.Proposal.EndTime)
+	assert.Equal(t, &comment, invite.Proposal.Comment)
+
+	// the owner is told about the new time
+	require.Len(t, sender.sent, 1)
+	n := sender.sent[0]
+	assert.Equal(t, NotificationTypeTimeProposed, n.Type)
+	assert.Equal(t, int64(1), n.UserId)
+	assert.Equal(t, int64(7), *n.FromUserId)
+	assert.Equal(t, invite.Proposal, n.Proposal)
+
+	require.NoError(t, c.AcceptProposal(a.Id, 7))
+	e, err := c.Get(a.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "2008-01-02", e.StartDay)
+	assert.Equal(t, "09:00", e.StartTime)
+	assert.Equal(t, "09:30", e.EndTime)
+	invite, err = c.GetInvitation(a.Id, 7)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusConfirmed, invite.Status)
+	assert.Nil(t, invite.Proposal)
+
+	// a declined proposal doesn't change the event
+	require.NoError(t, c.ProposeNewTime(a.Id, 7, start.Add(time.Hour), end.Add(time.Hour), nil))
+	require.NoError(t, c.DeclineProposal(a.Id, 7))
+	e, err = c.Get(a.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "09:00", e.StartTime)
+	invite, err = c.GetInvitation(a.Id, 7)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusDeclined, invite.Status)
+	assert.Nil(t, invite.Proposal)
+
+	c = NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	assert.Equal(t, ErrorProposalsNotSupported, c.ProposeNewTime(a.Id, 7, start, end, nil))
+}
@@ -0,0 +1,316 @@
+package cali
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuickAdd is the result of parsing a free-form sentence like
+// "Lunch with Sam next Tuesday 12-1pm at Café Rio" into a candidate event
+type QuickAdd struct {
+	// Event is the candidate event that was parsed out of the text. It has
+	// not been validated or saved yet.
+	Event Event
+	// Confidence is how sure the parser is about each part of the event
+	Confidence QuickAddConfidence
+}
+
+// QuickAddConfidence is a set of scores between 0 and 1 where 1 means the value
+// was explicitly found in the text and 0 means nothing could be found
+type QuickAddConfidence struct {
+	Day      float64
+	Time     float64
+	Title    float64
+	Location float64
+}
+
+// Overall is the average of all of the individual confidence scores
+func (c QuickAddConfidence) Overall() float64 {
+	return (c.Day + c.Time + c.Title + c.Location) / 4
+}
+
+// QuickAddDuration is how long an event lasts when only a start time is found
+const QuickAddDuration = time.Hour
+
+var (
+	quickAddWeekdays = map[string]time.Weekday{
+		"sun": time.Sunday, "sunday": time.Sunday,
+		"mon": time.Monday, "monday": time.Monday,
+		"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+		"wed": time.Wednesday, "wednesday": time.Wednesday,
+		"thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+		"fri": time.Friday, "friday": time.Friday,
+		"sat": time.Saturday, "saturday": time.Saturday,
+	}
+	quickAddMonths = map[string]time.Month{
+		"jan": time.January, "january": time.January,
+		"feb": time.February, "february": time.February,
+		"mar": time.March, "march": time.March,
+		"apr": time.April, "april": time.April,
+		"may": time.May,
+		"jun": time.June, "june": time.June,
+		"jul": time.July, "july": time.July,
+		"aug": time.August, "august": time.August,
+		"sep": time.September, "sept": time.September, "september": time.September,
+		"oct": time.October, "october": time.October,
+		"nov": time.November, "november": time.November,
+		"dec": time.December, "december": time.December,
+	}
+
+	quickAddTimeRange  = regexp.MustCompile(`(?i)(?:\b(?:from|at)\s+)?\b(\d{1,2})(?::(\d{2}))?\s*(am|pm)?\s*(?:-|–|to)\s*(\d{1,2})(?::(\d{2}))?\s*(am|pm)?\b`)
+	quickAddTime       = regexp.MustCompile(`(?i)(?:\bat\s+)?\b(\d{1,2})(?::(\d{2}))?\s*(am|pm)\b|(?:\bat\s+)?\b(\d{1,2}):(\d{2})\b|(?:\bat\s+)?\b(noon|midnight)\b`)
+	quickAddISODay     = regexp.MustCompile(`(?i)(?:\bon\s+)?\b(\d{4})-(\d{2})-(\d{2})\b`)
+	quickAddSlashDay   = regexp.MustCompile(`(?i)(?:\bon\s+)?\b(\d{1,2})/(\d{1,2})(?:/(\d{4}))?\b`)
+	quickAddMonthDay   = regexp.MustCompile(`(?i)(?:\bon\s+)?\b(jan|january|feb|february|mar|march|apr|april|may|jun|june|jul|july|aug|august|sep|sept|september|oct|october|nov|november|dec|december)\.?\s+(\d{1,2})(?:st|nd|rd|th)?(?:,?\s+(\d{4}))?\b`)
+	quickAddRelative   = regexp.MustCompile(`(?i)\b(today|tonight|tomorrow)\b`)
+	quickAddWeekday    = regexp.MustCompile(`(?i)(?:\bon\s+)?\b(?:(next|this)\s+)?(sunday|sun|monday|mon|tuesday|tues|tue|wednesday|wed|thursday|thurs|thur|thu|friday|fri|saturday|sat)\b`)
+	quickAddLocation   = regexp.MustCompile(`(?i)\s+(?:at|@)\s+(.+)$`)
+	quickAddWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// ParseQuickAdd converts a sentence like "Lunch with Sam next Tuesday 12-1pm at Café Rio"
+// into a candidate event relative to the current local time
+func ParseQuickAdd(text string) (*QuickAdd, error) {
+	return ParseQuickAddAt(text, time.Now())
+}
+
+// ParseQuickAddAt converts a sentence into a candidate event where relative days like
+// "tomorrow" or "next Tuesday" are relative to now and the zone is now's location
+func ParseQuickAddAt(text string, now time.Time) (*QuickAdd, error) {
+	rest := strings.TrimSpace(text)
+	if rest == "" {
+		return nil, ErrorQuickAddEmpty
+	}
+
+	q := &QuickAdd{
+		Event: Event{
+			Status: StatusActive,
+			Zone:   now.Location().String(),
+		},
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	var startTime, endTime string
+	for _, m := range quickAddTimeRange.FindAllStringSubmatchIndex(rest, -1) {
+		g := submatches(rest, m)
+		start, end, ok := quickAddRange(g[1], g[2], g[3], g[4], g[5], g[6])
+		if ok {
+			startTime, endTime = start, end
+			q.Confidence.Time = 1
+			rest = cut(rest, m)
+			break
+		}
+	}
+	if startTime == "" {
+		if m := quickAddTime.FindStringSubmatchIndex(rest); m != nil {
+			g := submatches(rest, m)
+			var start string
+			var ok bool
+			switch {
+			case g[1] != "":
+				start, ok = quickAddClock(g[1], g[2], g[3])
+			case g[4] != "":
+				start, ok = quickAddClock(g[4], g[5], "")
+			case strings.EqualFold(g[6], "noon"):
+				start, ok = "12:00", true
+			default:
+				start, ok = "00:00", true
+			}
+			if ok {
+				startTime = start
+				q.Confidence.Time = 0.8
+				rest = cut(rest, m)
+			}
+		}
+	}
+
+	day, confidence, rest := quickAddDay(rest, today)
+	q.Confidence.Day = confidence
+
+	if m := quickAddLocation.FindStringSubmatchIndex(rest); m != nil {
+		location := strings.TrimSpace(rest[m[2]:m[3]])
+		if location != "" {
+			q.Event.Location = &location
+			q.Confidence.Location = 0.7
+			rest = rest[:m[0]]
+		}
+	}
+
+	title := strings.Trim(quickAddWhitespace.ReplaceAllString(rest, " "), " ,.-")
+	if title == "" {
+		return nil, ErrorQuickAddEmpty
+	}
+	q.Event.Title = title
+	q.Confidence.Title = 0.9
+
+	q.Event.StartDay = day.Format(time.DateOnly)
+	q.Event.EndDay = q.Event.StartDay
+	switch {
+	case startTime == "":
+		q.Event.IsAllDay = true
+		q.Confidence.Time = 0.5
+	case endTime == "":
+		start, _ := time.Parse(TimeFormat, startTime)
+		end := start.Add(QuickAddDuration)
+		q.Event.StartTime = startTime
+		q.Event.EndTime = end.Format(TimeFormat)
+		if end.Day() != start.Day() {
+			q.Event.EndDay = day.AddDate(0, 0, 1).Format(time.DateOnly)
+		}
+	default:
+		q.Event.StartTime = startTime
+		q.Event.EndTime = endTime
+		if endTime < startTime {
+			q.Event.EndDay = day.AddDate(0, 0, 1).Format(time.DateOnly)
+		}
+	}
+
+	return q, nil
+}
+
+// quickAddDay finds the first day expression in the text and returns the
+// day, the confidence of the day, and the text without the day expression.
+// If there is no day expression, then today is returned with low confidence.
+func quickAddDay(text string, today time.Time) (time.Time, float64, string) {
+	if m := quickAddISODay.FindStringSubmatchIndex(text); m != nil {
+		g := submatches(text, m)
+		if d, err := time.Parse(time.DateOnly, fmt.Sprintf("%s-%s-%s", g[1], g[2], g[3])); err == nil {
+			return d, 1, cut(text, m)
+		}
+	}
+	if m := quickAddMonthDay.FindStringSubmatchIndex(text); m != nil {
+		g := submatches(text, m)
+		month := quickAddMonths[strings.ToLower(g[1])]
+		dayOfMonth, _ := strconv.Atoi(g[2])
+		if d, ok := quickAddDate(today, g[3], month, dayOfMonth); ok {
+			return d, 1, cut(text, m)
+		}
+	}
+	if m := quickAddSlashDay.FindStringSubmatchIndex(text); m != nil {
+		g := submatches(text, m)
+		month, _ := strconv.Atoi(g[1])
+		dayOfMonth, _ := strconv.Atoi(g[2])
+		if d, ok := quickAddDate(today, g[3], time.Month(month), dayOfMonth); ok {
+			return d, 0.9, cut(text, m)
+		}
+	}
+	if m := quickAddRelative.FindStringSubmatchIndex(text); m != nil {
+		g := submatches(text, m)
+		d := today
+		if strings.EqualFold(g[1], "tomorrow") {
+			d = d.AddDate(0, 0, 1)
+		}
+		return d, 1, cut(text, m)
+	}
+	if m := quickAddWeekday.FindStringSubmatchIndex(text); m != nil {
+		g := submatches(text, m)
+		weekday := quickAddWeekdays[strings.ToLower(g[2])]
+		days := (int(weekday) - int(today.Weekday()) + 7) % 7
+		if days == 0 && strings.EqualFold(g[1], "next") {
+			days = 7
+		}
+		return today.AddDate(0, 0, days), 0.9, cut(text, m)
+	}
+	return today, 0.3, text
+}
+
+// quickAddDate builds a valid date from the parts, if the year is missing then
+// the next occurrence of that month and day is used
+func quickAddDate(today time.Time, year string, month time.Month, day int) (time.Time, bool) {
+	if month < time.January || month > time.December || day < 1 || day > 31 {
+		return today, false
+	}
+	y := today.Year()
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+	}
+	d := time.Date(y, month, day, 0, 0, 0, 0, time.UTC)
+	if d.Day() != day {
+		return today, false
+	}
+	if year == "" && d.Before(today) {
+		d = d.AddDate(1, 0, 0)
+	}
+	return d, true
+}
+
+// quickAddRange converts the parts of a time range into HH:mm values. If only the end
+// has a meridiem, then the start uses the same one unless that would put the start
+// after the end (ex: "11-1pm" is 11:00-13:00).
+func quickAddRange(startHour, startMin, startMeridiem, endHour, endMin, endMeridiem string) (string, string, bool) {
+	if startMeridiem == "" && endMeridiem == "" && startMin == "" && endMin == "" {
+		// a bare "3-4" is more likely to be something like a score or a count
+		return "", "", false
+	}
+	end, ok := quickAddClock(endHour, endMin, endMeridiem)
+	if !ok {
+		return "", "", false
+	}
+	if startMeridiem == "" && endMeridiem != "" {
+		start, ok := quickAddClock(startHour, startMin, endMeridiem)
+		if ok && start <= end {
+			return start, end, true
+		}
+		startMeridiem = "am"
+	}
+	start, ok := quickAddClock(startHour, startMin, startMeridiem)
+	if !ok {
+		return "", "", false
+	}
+	return start, end, true
+}
+
+// quickAddClock converts an hour, optional minute, and optional am/pm into an HH:mm value
+func quickAddClock(hour, minute, meridiem string) (string, bool) {
+	h, err := strconv.Atoi(hour)
+	if err != nil {
+		return "", false
+	}
+	m := 0
+	if minute != "" {
+		m, err = strconv.Atoi(minute)
+		if err != nil || m > 59 {
+			return "", false
+		}
+	}
+	switch strings.ToLower(meridiem) {
+	case "am":
+		if h < 1 || h > 12 {
+			return "", false
+		}
+		if h == 12 {
+			h = 0
+		}
+	case "pm":
+		if h < 1 || h > 12 {
+			return "", false
+		}
+		if h != 12 {
+			h += 12
+		}
+	default:
+		if h > 23 {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%02d:%02d", h, m), true
+}
+
+// submatches converts regexp submatch indexes into strings where missing groups are ""
+func submatches(s string, m []int) []string {
+	result := make([]string, len(m)/2)
+	for i := range result {
+		if m[i*2] >= 0 {
+			result[i] = s[m[i*2]:m[i*2+1]]
+		}
+	}
+	return result
+}
+
+// cut removes the whole match from the string
+func cut(s string, m []int) string {
+	return s[:m[0]] + " " + s[m[1]:]
+}
@@ -0,0 +1,94 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuickAdd(t *testing.T) {
+	// Wednesday, Jan 2nd 2008
+	now := time.Date(2008, time.January, 2, 15, 0, 0, 0, time.UTC)
+	s := func(s string) *string {
+		return &s
+	}
+	testCases := []struct {
+		name string
+		in   string
+		out  Event
+		err  error
+	}{
+		{
+			name: "empty",
+			in:   "   ",
+			err:  ErrorQuickAddEmpty,
+		},
+		{
+			name: "no title",
+			in:   "tomorrow at 3pm",
+			err:  ErrorQuickAddEmpty,
+		},
+		{
+			name: "full sentence",
+			in:   "Lunch with Sam next Tuesday 12-1pm at Café Rio",
+			out:  Event{Title: "Lunch with Sam", Location: s("Café Rio"), StartDay: "2008-01-08", StartTime: "12:00", EndDay: "2008-01-08", EndTime: "13:00"},
+		},
+		{
+			name: "morning range spanning noon",
+			in:   "Standup tomorrow 11-1pm",
+			out:  Event{Title: "Standup", StartDay: "2008-01-03", StartTime: "11:00", EndDay: "2008-01-03", EndTime: "13:00"},
+		},
+		{
+			name: "single time defaults to an hour",
+			in:   "Dentist on Mar 3rd at 9:30am",
+			out:  Event{Title: "Dentist", StartDay: "2008-03-03", StartTime: "09:30", EndDay: "2008-03-03", EndTime: "10:30"},
+		},
+		{
+			name: "24 hour range",
+			in:   "Review 2008-02-01 14:00-15:30",
+			out:  Event{Title: "Review", StartDay: "2008-02-01", StartTime: "14:00", EndDay: "2008-02-01", EndTime: "15:30"},
+		},
+		{
+			name: "all day",
+			in:   "Mom's birthday 1/1",
+			out:  Event{Title: "Mom's birthday", StartDay: "2009-01-01", EndDay: "2009-01-01", IsAllDay: true},
+		},
+		{
+			name: "today is the weekday",
+			in:   "Yoga wednesday at noon",
+			out:  Event{Title: "Yoga", StartDay: "2008-01-02", StartTime: "12:00", EndDay: "2008-01-02", EndTime: "13:00"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			result, err := ParseQuickAddAt(tc.in, now)
+			if tc.err != nil {
+				require.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			tc.out.Zone = "UTC"
+			assert.Equal(t, tc.out, result.Event)
+			assert.NoError(t, Validate(result.Event))
+		})
+	}
+}
+
+func TestQuickAddConfidence(t *testing.T) {
+	now := time.Date(2008, time.January, 2, 15, 0, 0, 0, time.UTC)
+
+	explicit, err := ParseQuickAddAt("Lunch next Tuesday 12-1pm at Café Rio", now)
+	require.NoError(t, err)
+	vague, err := ParseQuickAddAt("Lunch", now)
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(1), explicit.Confidence.Time)
+	assert.Equal(t, float64(0), vague.Confidence.Location)
+	assert.Greater(t, explicit.Confidence.Overall(), vague.Confidence.Overall())
+}
@@ -0,0 +1,62 @@
+package cali
+
+import (
+	"time"
+)
+
+// RangeBound is how the Start or End of a query compares with the events that touch it, like
+// an event that ends at 10:00 for a query that starts at 10:00
+type RangeBound int64
+
+const (
+	// RangeBoundDefault uses the bound of the calendar (see WithRangeBounds), which is
+	// RangeBoundInclusive unless it is set
+	RangeBoundDefault RangeBound = 0
+	// RangeBoundInclusive matches the events that touch the bound, so back to back events
+	// (10:00-11:00 and 11:00-12:00) are both in a query from 11:00 to 12:00
+	RangeBoundInclusive RangeBound = 1
+	// RangeBoundExclusive leaves out the events that only touch the bound, so a query from
+	// 11:00 to 12:00 only has the events that have some time between 11:00 and 12:00
+	RangeBoundExclusive RangeBound = 2
+)
+
+// WithRangeBounds sets the bounds of the queries that use RangeBoundDefault. Calendars that
+// show back to back events in views (like a day view of hour slots) should use half-open
+// ranges, where both bounds are RangeBoundExclusive, so an event isn't counted in two slots.
+func WithRangeBounds(start, end RangeBound) CalendarOption {
+	return func(c *Calendar) {
+		c.startBound, c.endBound = start, end
+	}
+}
+
+// withRangeBounds sets the default bounds of the query to the bounds of the calendar
+func (c *Calendar) withRangeBounds(q Query) Query {
+	if q.StartBound == RangeBoundDefault {
+		q.StartBound = c.startBound
+	}
+	if q.EndBound == RangeBoundDefault {
+		q.EndBound = c.endBound
+	}
+	return q
+}
+
+// InBounds is InRange with the bounds of the start and end, where RangeBoundExclusive leaves
+// out an event that ends at the start, or starts at the end, of the range. An event without
+// any length (like a deadline at 11:00) is still in a range that starts at its time, so it is
+// only in one of two back to back ranges.
+func (e Event) InBounds(start, end *time.Time, startBound, endBound RangeBound) bool {
+	eventStart, eventEnd := e.FloatingSpan()
+	if start != nil {
+		bound := FloatingBound(*start)
+		if eventEnd < bound || (startBound == RangeBoundExclusive && eventEnd == bound && eventStart != eventEnd) {
+			return false
+		}
+	}
+	if end != nil {
+		bound := FloatingBound(*end)
+		if eventStart > bound || (endBound == RangeBoundExclusive && eventStart == bound) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInBounds(t *testing.T) {
+	at := func(hour int) *time.Time {
+		t := time.Date(2008, 1, 1, hour, 0, 0, 0, time.UTC)
+		return &t
+	}
+	early := Event{Zone: "UTC", StartDay: "2008-01-01", StartTime: "10:00", EndDay: "2008-01-01", EndTime: "11:00"}
+	late := Event{Zone: "UTC", StartDay: "2008-01-01", StartTime: "11:00", EndDay: "2008-01-01", EndTime: "12:00"}
+	deadline := Event{Zone: "UTC", StartDay: "2008-01-01", StartTime: "11:00", EndDay: "2008-01-01", EndTime: "11:00"}
+
+	assert.True(t, early.InBounds(at(11), at(12), RangeBoundInclusive, RangeBoundInclusive))
+	assert.True(t, early.InBounds(at(11), at(12), RangeBoundDefault, RangeBoundDefault))
+	assert.False(t, early.InBounds(at(11), at(12), RangeBoundExclusive, RangeBoundInclusive))
+	assert.True(t, late.InBounds(at(10), at(11), RangeBoundExclusive, RangeBoundInclusive))
+	assert.False(t, late.InBounds(at(10), at(11), RangeBoundInclusive, RangeBoundExclusive))
+	assert.True(t, late.InBounds(at(11), at(12), RangeBoundExclusive, RangeBoundExclusive))
+
+	// an event without any length is only in the range that starts at its time
+	assert.False(t, deadline.InBounds(at(10), at(11), RangeBoundExclusive, RangeBoundExclusive))
+	assert.True(t, deadline.InBounds(at(11), at(12), RangeBoundExclusive, RangeBoundExclusive))
+
+	// the bounds keep their seconds, so a range that starts within the minute an event ends does
+	// not include it
+	second := func(hour, sec int) *time.Time {
+		t := time.Date(2008, 1, 1, hour, 0, sec, 0, time.UTC)
+		return &t
+	}
+	assert.False(t, early.InBounds(second(11, 30), at(12), RangeBoundInclusive, RangeBoundInclusive))
+	assert.True(t, late.InBounds(at(10), second(11, 30), RangeBoundInclusive, RangeBoundExclusive))
+}
+
+func TestRangeBounds(t *testing.T) {
+	store := &InMemoryDataStore{}
+	for _, e := range []Event{
+		{Title: "Early", Zone: "UTC", StartDay: "2008-01-01", StartTime: "10:00", EndDay: "2008-01-01", EndTime: "11:00"},
+		{Title: "Late", Zone: "UTC", StartDay: "2008-01-01", StartTime: "11:00", EndDay: "2008-01-01", EndTime: "12:00"},
+	} {
+		_, _, err := NewCalendar(store).Create(e)
+		require.NoError(t, err)
+	}
+	titles := func(c *Calendar, q Query) []string {
+		events, err := c.Query(q)
+		require.NoError(t, err)
+		var result []string
+		for _, e := range events {
+			result = append(result, e.Title)
+		}
+		return result
+	}
+	start := time.Date(2008, 1, 1, 11, 0, 0, 0, time.UTC)
+	end := time.Date(2008, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	closed := NewCalendar(store, WithQueryCache(10, time.Minute))
+	assert.Equal(t, []string{"Early", "Late"}, titles(closed, Query{Start: &start, End: &end}))
+	assert.Equal(t, []string{"Late"}, titles(closed, Query{Start: &start, End: &end, StartBound: RangeBoundExclusive}), "the bounds are a part of the cache key")
+
+	halfOpen := NewCalendar(store, WithRangeBounds(RangeBoundExclusive, RangeBoundExclusive))
+	assert.Equal(t, []string{"Late"}, titles(halfOpen, Query{Start: &start, End: &end}))
+	assert.Equal(t, []string{"Early", "Late"}, titles(halfOpen, Query{Start: &start, End: &end, StartBound: RangeBoundInclusive}))
+}
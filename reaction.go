@@ -0,0 +1,135 @@
+package cali
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxReactionLength is the most bytes that the emoji of a reaction can have, which is enough
+// for the longest emoji sequences (like family or flag sequences)
+const MaxReactionLength = 32
+
+// Reaction is a user's emoji reaction to an event, where a user can react to an event with
+// many different emoji but only once with each one
+type Reaction struct {
+	// EventId is the event that the user reacted to
+	EventId int64 `json:"eventId"`
+	// UserId is the user that reacted
+	UserId int64 `json:"userId"`
+	// Emoji is the reaction, like "🎉"
+	Emoji string `json:"emoji"`
+	// Created is a timestamp for when the user reacted
+	Created time.Time `json:"created"`
+}
+
+// ReactionStore is an optional interface for a data store that can save reactions to events
+type ReactionStore interface {
+	// AddReaction saves the reaction and handles setting the Created field. If the user already
+	// reacted to the event with the emoji it returns the existing reaction
+	AddReaction(r Reaction) (*Reaction, error)
+	// RemoveReaction deletes the reaction. If there is no reaction it returns nil
+	RemoveReaction(eventId, userId int64, emoji string) error
+	// GetReactions retrieves all of the reactions to the event
+	GetReactions(eventId int64) ([]*Reaction, error)
+	// CountReactions gets the number of reactions with each emoji for each of the events, where
+	// events without reactions can be left out
+	CountReactions(eventIds []int64) (map[int64]map[string]int64, error)
+}
+
+// WithReactionCounts sets the ReactionCounts of the events from Get, Query, and the other
+// methods that read events, which needs a data store that implements ReactionStore
+func WithReactionCounts() CalendarOption {
+	return func(c *Calendar) {
+		c.reactionCounts = true
+	}
+}
+
+// ValidateReaction checks that the emoji of a reaction is a single short token
+func ValidateReaction(emoji string) error {
+	if emoji == "" || len(emoji) > MaxReactionLength || !utf8.ValidString(emoji) || strings.ContainsAny(emoji, " \t\r\n") {
+		return ErrorInvalidReaction
+	}
+	return nil
+}
+
+// React adds the user's reaction to the event, which has to be readable by the calendar (see Authorizer)
+func (c *Calendar) React(eventId int64, userId int64, emoji string) (*Reaction, error) {
+	store, ok := capability[ReactionStore](c.dataStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	if err := ValidateReaction(emoji); err != nil {
+		return nil, err
+	}
+	e, err := c.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	return store.AddReaction(Reaction{EventId: eventId, UserId: userId, Emoji: emoji})
+}
+
+// Unreact removes the user's reaction to the event
+func (c *Calendar) Unreact(eventId int64, userId int64, emoji string) error {
+	store, ok := capability[ReactionStore](c.dataStore)
+	if !ok {
+		return ErrorReactionsNotSupported
+	}
+	return store.RemoveReaction(eventId, userId, emoji)
+}
+
+// GetReactions gets all of the reactions to the event
+func (c *Calendar) GetReactions(eventId int64) ([]*Reaction, error) {
+	store, ok := capability[ReactionStore](c.dataStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	return store.GetReactions(eventId)
+}
+
+// withReactions copies the events and sets their ReactionCounts if the calendar has WithReactionCounts
+func (c *Calendar) withReactions(events []*Event) ([]*Event, error) {
+	store, ok := capability[ReactionStore](c.dataStore)
+	if !c.reactionCounts || !ok {
+		return events, nil
+	}
+	var ids []int64
+	for _, e := range events {
+		if e != nil && e.Id > 0 {
+			ids = append(ids, e.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return events, nil
+	}
+	counts, err := store.CountReactions(ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Event, len(events))
+	for i, e := range events {
+		result[i] = e
+		if e == nil || len(counts[e.Id]) == 0 {
+			continue
+		}
+		copied := *e
+		copied.ReactionCounts = counts[e.Id]
+		result[i] = &copied
+	}
+	return result, nil
+}
+
+// countReactions counts the reactions of each event by emoji
+func countReactions(reactions []*Reaction) map[int64]map[string]int64 {
+	counts := map[int64]map[string]int64{}
+	for _, r := range reactions {
+		if counts[r.EventId] == nil {
+			counts[r.EventId] = map[string]int64{}
+		}
+		counts[r.EventId][r.Emoji]++
+	}
+	return counts
+}
@@ -0,0 +1,89 @@
+package cali
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateReaction(t *testing.T) {
+	for _, emoji := range []string{"🎉", "👍🏽", "👨‍👩‍👧‍👦", "+1"} {
+		assert.NoError(t, ValidateReaction(emoji), emoji)
+	}
+	for _, emoji := range []string{"", "🎉 🎉", strings.Repeat("🎉", 9), "\xff"} {
+		assert.Equal(t, ErrorInvalidReaction, ValidateReaction(emoji), emoji)
+	}
+}
+
+func TestReactions(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store, WithReactionCounts())
+			var events []*Event
+			for _, day := range []string{"2008-01-01", "2008-01-02"} {
+				e, _, err := c.Create(Event{OwnerId: 1, StartDay: day, EndDay: day, IsAllDay: true, Zone: "UTC"})
+				require.NoError(t, err)
+				events = append(events, e)
+			}
+			for _, userId := range []int64{1, 2, 3} {
+				_, err := c.React(events[0].Id, userId, "🎉")
+				require.NoError(t, err)
+			}
+			_, err := c.React(events[0].Id, 2, "🎉")
+			require.NoError(t, err, "reacting twice with the same emoji only counts once")
+			_, err = c.React(events[0].Id, 2, "👍")
+			require.NoError(t, err)
+			_, err = c.React(events[0].Id, 2, "")
+			assert.Equal(t, ErrorInvalidReaction, err)
+			_, err = c.React(999, 2, "👍")
+			assert.Equal(t, ErrorEventNotFound, err)
+
+			reactions, err := c.GetReactions(events[0].Id)
+			require.NoError(t, err)
+			assert.Len(t, reactions, 4)
+
+			got, err := c.Get(events[0].Id)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]int64{"🎉": 3, "👍": 1}, got.ReactionCounts)
+
+			require.NoError(t, c.Unreact(events[0].Id, 3, "🎉"))
+			found, err := c.Query(Query{})
+			require.NoError(t, err)
+			require.Len(t, found, 2)
+			assert.Equal(t, map[string]int64{"🎉": 2, "👍": 1}, found[0].ReactionCounts)
+			assert.Nil(t, found[1].ReactionCounts)
+		})
+	}
+}
+
+func TestReactionCountsOff(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	_, err = c.React(e.Id, 2, "🎉")
+	require.NoError(t, err)
+	got, err := c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Nil(t, got.ReactionCounts)
+
+	c = NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	_, err = c.React(e.Id, 2, "🎉")
+	assert.Equal(t, ErrorReactionsNotSupported, err)
+}
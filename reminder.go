@@ -0,0 +1,176 @@
+package cali
+
+import (
+	"sort"
+	"time"
+)
+
+// Reminder is a user's reminder for an event. The snooze and dismiss state is saved in the
+// data store, so it survives restarts and every device of the user sees the same state.
+type Reminder struct {
+	// Id is the unique id for this reminder
+	Id int64 `json:"id"`
+	// EventId is the event that the reminder is for
+	EventId int64 `json:"eventId"`
+	// UserId is the user that is reminded
+	UserId int64 `json:"userId"`
+	// RemindAt is when the reminder is first due
+	RemindAt time.Time `json:"remindAt"`
+	// SnoozedUntil is when a snoozed reminder is due again, or nil if it wasn't snoozed
+	SnoozedUntil *time.Time `json:"snoozedUntil"`
+	// Sent is when the reminder was last sent, or nil if it hasn't been sent since it was
+	// created or snoozed
+	Sent *time.Time `json:"sent"`
+	// Dismissed is when the user dismissed the reminder, or nil if it is still active
+	Dismissed *time.Time `json:"dismissed"`
+	// Created is a timestamp for when the reminder was created
+	Created time.Time `json:"created"`
+	// Updated is a timestamp for when the reminder was modified last
+	Updated time.Time `json:"updated"`
+}
+
+// DueAt gets when the reminder is due, which is when the snooze ends if it was snoozed
+func (r Reminder) DueAt() time.Time {
+	if r.SnoozedUntil != nil {
+		return *r.SnoozedUntil
+	}
+	return r.RemindAt
+}
+
+// IsDue returns true if the reminder hasn't been sent or dismissed and is due at or before now
+func (r Reminder) IsDue(now time.Time) bool {
+	return r.Dismissed == nil && r.Sent == nil && !r.DueAt().After(now)
+}
+
+// ReminderStore is an optional interface for a data store that keeps the reminders of users
+type ReminderStore interface {
+	// AddReminder saves a new reminder and handles setting the Id, Created, and Updated fields
+	AddReminder(r Reminder) (*Reminder, error)
+	// GetReminder retrieves the reminder, or nil if there isn't one with the id
+	GetReminder(reminderId int64) (*Reminder, error)
+	// UpdateReminder saves the SnoozedUntil, Sent, and Dismissed fields of the reminder and sets Updated
+	UpdateReminder(r Reminder) error
+	// DueReminders retrieves the reminders that are due at or before the time (see Reminder.IsDue)
+	DueReminders(before time.Time) ([]*Reminder, error)
+}
+
+// AddReminder reminds the user of the event the duration before the event starts
+func (c *Calendar) AddReminder(eventId int64, userId int64, before time.Duration) (*Reminder, error) {
+	store, ok := capability[ReminderStore](c.dataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	start, _, err := e.zonedSpan()
+	if err != nil {
+		return nil, err
+	}
+	return store.AddReminder(Reminder{EventId: eventId, UserId: userId, RemindAt: start.Add(-before)})
+}
+
+// GetReminder retrieves the reminder
+func (c *Calendar) GetReminder(reminderId int64) (*Reminder, error) {
+	store, ok := capability[ReminderStore](c.dataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return c.getReminder(store, reminderId)
+}
+
+func (c *Calendar) getReminder(store ReminderStore, reminderId int64) (*Reminder, error) {
+	r, err := store.GetReminder(reminderId)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrorReminderNotFound
+	}
+	return r, nil
+}
+
+// SnoozeReminder makes the reminder due again at the time, even if it was already sent
+func (c *Calendar) SnoozeReminder(reminderId int64, until time.Time) error {
+	store, ok := capability[ReminderStore](c.dataStore)
+	if !ok {
+		return ErrorRemindersNotSupported
+	}
+	r, err := c.getReminder(store, reminderId)
+	if err != nil {
+		return err
+	}
+	if r.Dismissed != nil {
+		return ErrorReminderDismissed
+	}
+	r.SnoozedUntil = &until
+	r.Sent = nil
+	return store.UpdateReminder(*r)
+}
+
+// DismissReminder stops the reminder of the user from being sent again. Dismissing a
+// reminder that was already dismissed does nothing, and ErrorReminderNotFound is returned
+// if the reminder isn't the user's (unless an admin is acting on behalf of the user who
+// owns it, see AsAdminOnBehalfOf).
+func (c *Calendar) DismissReminder(reminderId int64, userId int64) error {
+	store, ok := capability[ReminderStore](c.dataStore)
+	if !ok {
+		return ErrorRemindersNotSupported
+	}
+	r, err := c.getReminder(store, reminderId)
+	if err != nil {
+		return err
+	}
+	if r.UserId != userId && !c.isAdminFor(r.UserId) {
+		return ErrorReminderNotFound
+	}
+	if r.Dismissed != nil {
+		return nil
+	}
+	now := time.Now()
+	r.Dismissed = &now
+	return store.UpdateReminder(*r)
+}
+
+// SendDueReminders sends a NotificationTypeEventReminder notification for every reminder that
+// is due at or before now and returns the number sent. A reminder is only sent once unless it
+// is snoozed, and reminders of events that aren't active are skipped.
+func (c *Calendar) SendDueReminders(now time.Time) (int64, error) {
+	if c.notificationSender == nil {
+		return 0, ErrorNotificationsNotConfigured
+	}
+	store, ok := capability[ReminderStore](c.dataStore)
+	if !ok {
+		return 0, ErrorRemindersNotSupported
+	}
+	reminders, err := store.DueReminders(now)
+	if err != nil {
+		return 0, err
+	}
+	sort.SliceStable(reminders, func(i, j int) bool {
+		return reminders[i].DueAt().Before(reminders[j].DueAt())
+	})
+	var sent int64
+	for _, r := range reminders {
+		e, err := c.dataStore.Get(r.EventId)
+		if err != nil {
+			return sent, err
+		}
+		r.Sent = &now
+		if e != nil && e.Status == StatusActive {
+			reminder := *r
+			if err := c.sendNotification(Notification{Type: NotificationTypeEventReminder, UserId: r.UserId, Event: *e, Reminder: &reminder}); err != nil {
+				return sent, err
+			}
+			sent++
+		}
+		if err := store.UpdateReminder(*r); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
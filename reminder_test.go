@@ -0,0 +1,87 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReminders(t *testing.T) {
+	sender := &testSender{}
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender))
+	e, _, err := c.Create(Event{OwnerId: 1, Title: "Lunch", Zone: "UTC", StartDay: "2008-01-01", StartTime: "12:00", EndDay: "2008-01-01", EndTime: "13:00"})
+	require.NoError(t, err)
+
+	r, err := c.AddReminder(e.Id, 1, 15*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2008, 1, 1, 11, 45, 0, 0, time.UTC), r.RemindAt.UTC())
+	other, err := c.AddReminder(e.Id, 2, time.Hour)
+	require.NoError(t, err)
+
+	// nothing is due before the reminders
+	sent, err := c.SendDueReminders(time.Date(2008, 1, 1, 10, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sent)
+
+	// both are due, and they are only sent once
+	now := time.Date(2008, 1, 1, 11, 50, 0, 0, time.UTC)
+	sent, err = c.SendDueReminders(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), sent)
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, NotificationTypeEventReminder, sender.sent[0].Type)
+	assert.Equal(t, int64(2), sender.sent[0].UserId, "the earliest reminder is sent first")
+	assert.Equal(t, other.Id, sender.sent[0].Reminder.Id)
+	sent, err = c.SendDueReminders(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sent)
+
+	// a snoozed reminder is sent again when the snooze ends
+	require.NoError(t, c.SnoozeReminder(r.Id, now.Add(5*time.Minute)))
+	sent, err = c.SendDueReminders(now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sent)
+	sent, err = c.SendDueReminders(now.Add(5 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), sent)
+
+	// a dismissed reminder is never sent again and can't be snoozed
+	require.NoError(t, c.SnoozeReminder(r.Id, now.Add(10*time.Minute)))
+	assert.Equal(t, ErrorReminderNotFound, c.DismissReminder(r.Id, 2), "only the user can dismiss their reminder")
+	require.NoError(t, c.DismissReminder(r.Id, 1))
+	require.NoError(t, c.DismissReminder(r.Id, 1))
+	sent, err = c.SendDueReminders(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sent)
+	assert.Equal(t, ErrorReminderDismissed, c.SnoozeReminder(r.Id, now.Add(time.Hour)))
+	saved, err := c.GetReminder(r.Id)
+	require.NoError(t, err)
+	assert.NotNil(t, saved.Dismissed)
+
+	// reminders of canceled events are marked as sent without a notification
+	require.NoError(t, c.SnoozeReminder(other.Id, now.Add(time.Hour)))
+	require.NoError(t, c.Cancel(e.Id, RepeatEditTypeThis))
+	sent, err = c.SendDueReminders(now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), sent)
+	saved, err = c.GetReminder(other.Id)
+	require.NoError(t, err)
+	assert.NotNil(t, saved.Sent)
+
+	_, err = c.GetReminder(100)
+	assert.Equal(t, ErrorReminderNotFound, err)
+	_, err = c.AddReminder(100, 1, time.Minute)
+	assert.Equal(t, ErrorEventNotFound, err)
+}
+
+func TestRemindersNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}, WithNotificationSender(&testSender{}))
+	_, err := c.AddReminder(1, 1, time.Minute)
+	assert.Equal(t, ErrorRemindersNotSupported, err)
+	assert.Equal(t, ErrorRemindersNotSupported, c.SnoozeReminder(1, time.Now()))
+	assert.Equal(t, ErrorRemindersNotSupported, c.DismissReminder(1, 1))
+	_, err = c.SendDueReminders(time.Now())
+	assert.Equal(t, ErrorRemindersNotSupported, err)
+}
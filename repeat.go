@@ -4,74 +4,239 @@ import (
 	"time"
 )
 
+// RepeatExpansionStore is an optional interface for a data store that can expand the repeat
+// of an event itself (for example, with generate_series in Postgres), so that the occurrences
+// of a repeating event are saved in a single round trip instead of being generated in Go
+type RepeatExpansionStore interface {
+	// CreateRepeating saves an event for every occurrence of the repeat of the event (the
+	// same as GenerateRepeatEvents) the same way as Create, where the first event is the
+	// parent of the others, and returns them in order. Only the first event keeps the
+	// ExternalKey. A repeat without occurrences returns ErrorEmptyRepeatingEvents.
+	CreateRepeating(e Event) ([]*Event, error)
+}
+
+// createRepeating saves the events of the repeating event with CreateRepeating if the data
+// store supports it, otherwise the events are generated in Go (see GenerateRepeatEvents)
+func createRepeating(store DataStore, e Event) ([]*Event, error) {
+	if expansion, ok := capability[RepeatExpansionStore](store); ok {
+		return expansion.CreateRepeating(e)
+	}
+	return generateRepeating(store, e)
+}
+
+// generateRepeating generates the events of the repeating event and saves them
+func generateRepeating(store DataStore, e Event) ([]*Event, error) {
+	events, err := GenerateRepeatEvents(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, ErrorEmptyRepeatingEvents
+	}
+	return createSeriesEvents(store, events)
+}
+
+// createSeriesEvents saves the events of a series, where the first event is the parent of
+// the others. If the data store implements BatchCreateStore, then the other events are saved
+// with a single CreateBatch, otherwise each event is created on its own.
+func createSeriesEvents(store DataStore, events []*Event) ([]*Event, error) {
+	first, err := store.Create(*events[0])
+	if err != nil {
+		return nil, err
+	}
+	if first == nil {
+		return nil, ErrorEmptyRepeatingEvents
+	}
+	rest := make([]Event, 0, len(events)-1)
+	for _, event := range events[1:] {
+		event.ParentId = &first.Id
+		// the external key is unique, so only the first event of the series has it
+		event.ExternalKey = ""
+		rest = append(rest, *event)
+	}
+	results := []*Event{first}
+	if batch, ok := capability[BatchCreateStore](store); ok && len(rest) > 0 {
+		created, err := batch.CreateBatch(rest)
+		if err != nil {
+			return nil, err
+		}
+		return append(results, created...), nil
+	}
+	for _, event := range rest {
+		newEvent, err := store.Create(event)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, newEvent)
+	}
+	return results, nil
+}
+
+// GenerateRepeatEvents makes a copy of the event for every occurrence of its repeat, which
+// has the AnniversaryYears of the occurrence if the repeat is an anniversary. Use
+// RepeatOccurrences when only the days of the occurrences are needed.
 func GenerateRepeatEvents(e Event) ([]*Event, error) {
+	var events []*Event
+	err := RepeatOccurrences(e, func(startDay, endDay time.Time) bool {
+		nextEvent := e
+		nextEvent.StartDay = startDay.Format(time.DateOnly)
+		nextEvent.EndDay = endDay.Format(time.DateOnly)
+		nextEvent.AnniversaryYears = e.anniversaryYears(len(events))
+		events = append(events, &nextEvent)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// RepeatOccurrences calls f with the start and end day of every occurrence of the repeating
+// event in order without making a copy of the event for each one. If f returns false, then
+// no more occurrences are generated. It returns the same errors as GenerateRepeatEvents.
+func RepeatOccurrences(e Event, f func(startDay, endDay time.Time) bool) error {
+	return repeatOccurrences(e, int(MaxRepeatOccurrence), f)
+}
+
+// TruncateRepeat limits the repeat of the event to MaxRepeatOccurrence occurrences and returns
+// the event with the shorter repeat and the number of occurrences that were dropped. A repeat
+// with a RepeatStopDate that has too many occurrences is changed to use RepeatOccurrences.
+func TruncateRepeat(e Event) (Event, int64, error) {
+	if !e.IsRepeating || e.Repeat == nil {
+		return e, 0, nil
+	}
+	repeat := *e.Repeat
+	e.Repeat = &repeat
+	if repeat.RepeatOccurrences > MaxRepeatOccurrence {
+		dropped := repeat.RepeatOccurrences - MaxRepeatOccurrence
+		repeat.RepeatOccurrences = MaxRepeatOccurrence
+		return e, dropped, nil
+	}
+	if repeat.RepeatStopDate == nil {
+		return e, 0, nil
+	}
+	var total int64
+	err := repeatOccurrences(e, -1, func(startDay, endDay time.Time) bool {
+		total++
+		return true
+	})
+	if err != nil || total <= MaxRepeatOccurrence {
+		return e, 0, err
+	}
+	repeat.RepeatStopDate = nil
+	repeat.RepeatOccurrences = MaxRepeatOccurrence
+	return e, total - MaxRepeatOccurrence, nil
+}
+
+// repeatOccurrences is RepeatOccurrences where limit is the most occurrences allowed
+// for repeats with a RepeatStopDate, or -1 for no limit
+func repeatOccurrences(e Event, limit int, f func(startDay, endDay time.Time) bool) error {
 	if !e.IsRepeating {
-		return nil, ErrorNotRepeatingEvent
+		return ErrorNotRepeatingEvent
 	}
 
 	startDay, err := time.Parse(time.DateOnly, e.StartDay)
 	if err != nil {
-		return nil, ErrorInvalidStartDay
+		return ErrorInvalidStartDay
 	}
 	endDay, err := time.Parse(time.DateOnly, e.EndDay)
 	if err != nil {
-		return nil, ErrorInvalidEndDay
+		return ErrorInvalidEndDay
 	}
 	nextStart := startDay
 	nextEnd := endDay
-	year, month, day := 0, 0, 0
+	month, day := 0, 0
+	// yearly repeats always count from the first day so that a shortened month
+	// one year (like February 29 or a short Hebrew month) doesn't shift all later years
+	years := 0
+	// count is the number of occurrences so far and stopped is true once f returns false
+	// or a custom repeat has no more occurrences
+	count := 0
+	stopped := false
+	var incrementErr error
 	increment := func() {
-		nextStart = nextStart.AddDate(year, month, day)
-		nextEnd = nextEnd.AddDate(year, month, day)
+		if e.Repeat.RepeatType == RepeatTypeYearly {
+			years++
+			next := addYears(startDay, years, e.Repeat.LeapDay)
+			if e.Repeat.CalendarSystem != CalendarSystemGregorian {
+				var err error
+				if next, err = addAltYears(e.Repeat.CalendarSystem, startDay, years); err != nil {
+					incrementErr = err
+				}
+			}
+			nextStart = next
+			nextEnd = next.Add(endDay.Sub(startDay))
+			return
+		}
+		if e.Repeat.RepeatType == RepeatTypeCustom {
+			next, ok := e.Repeat.Next.Next(nextStart, nextEnd, count)
+			if !ok {
+				stopped = true
+				return
+			}
+			if !next.After(nextStart) {
+				incrementErr = ErrorInvalidNextOccurrence
+				return
+			}
+			nextStart = next
+			nextEnd = next.Add(endDay.Sub(startDay))
+			return
+		}
+		nextStart = nextStart.AddDate(0, month, day)
+		nextEnd = nextEnd.AddDate(0, month, day)
 	}
 
 	if err := Validate(e); err != nil {
-		return nil, err
+		return err
 	}
 	r := e.Repeat
 
-	var events []*Event
+	// weekday repeats and daily repeats of business days are weekly repeats of Monday through Friday
+	repeatType, days := r.RepeatType, r.DayOfWeek
+	if repeatType == RepeatTypeWeekdays || (repeatType == RepeatTypeDaily && r.BusinessDaysOnly) {
+		repeatType, days = RepeatTypeWeekly, DayOfWeekWeekdays
+	}
+
+	emit := func() {
+		if stopped {
+			return
+		}
+		count++
+		stopped = !f(nextStart, nextEnd)
+	}
 
-	switch e.Repeat.RepeatType {
-	case RepeatTypeDaily, RepeatTypeMonthly, RepeatTypeYearly:
-		events = append(events, &e)
-		// daily, monthly, and yearly repeats are all the same
+	switch repeatType {
+	case RepeatTypeDaily, RepeatTypeMonthly, RepeatTypeYearly, RepeatTypeCustom:
+		emit()
+		// daily, monthly, yearly, and custom repeats are all the same
 		// kind of repeating
-		switch e.Repeat.RepeatType {
+		switch repeatType {
 		case RepeatTypeDaily:
 			day++
 		case RepeatTypeMonthly:
 			month++
-		case RepeatTypeYearly:
-			year++
 		}
 		if r.RepeatOccurrences >= 2 {
 			// loop until there are a specific number of events
-			for len(events) < int(r.RepeatOccurrences) {
-				nextEvent := e
-
+			for !stopped && count < int(r.RepeatOccurrences) {
 				increment()
-
-				nextEvent.StartDay = nextStart.Format(time.DateOnly)
-				nextEvent.EndDay = nextEnd.Format(time.DateOnly)
-
-				events = append(events, &nextEvent)
+				if incrementErr != nil {
+					return incrementErr
+				}
+				emit()
 			}
 		} else if r.RepeatStopDate != nil {
 			// loop until the next start date is after the stop date
-			for !nextStart.After(*r.RepeatStopDate) {
+			for !stopped && !nextStart.After(*r.RepeatStopDate) {
 				// if there are more event repeats than allowed, throw error
-				if len(events) > int(MaxRepeatOccurrence) {
-					return nil, ErrorTooManyRepeatOccurrences
+				if limit >= 0 && count > limit {
+					return ErrorTooManyRepeatOccurrences
 				}
-				nextEvent := e
-
 				increment()
-
-				nextEvent.StartDay = nextStart.Format(time.DateOnly)
-				nextEvent.EndDay = nextEnd.Format(time.DateOnly)
-
-				events = append(events, &nextEvent)
+				if incrementErr != nil {
+					return incrementErr
+				}
+				emit()
 			}
 		}
 	case RepeatTypeWeekly:
@@ -83,17 +248,14 @@ func GenerateRepeatEvents(e Event) ([]*Event, error) {
 		// says Tuesday and Thursday.
 		if r.RepeatOccurrences >= 2 {
 			// loop until there are a specific number of events
-			for len(events) < int(r.RepeatOccurrences) {
+			for !stopped && count < int(r.RepeatOccurrences) {
 				day := dayOfWeekFromWeekday(nextStart.Weekday())
-				if !r.DayOfWeek.HasFlag(day) {
+				if !days.HasFlag(day) {
 					increment()
 					continue
 				}
 
-				nextEvent := e
-				nextEvent.StartDay = nextStart.Format(time.DateOnly)
-				nextEvent.EndDay = nextEnd.Format(time.DateOnly)
-				events = append(events, &nextEvent)
+				emit()
 
 				// go to the next day (do this at the end of the for loop
 				// since we need to check the original event)
@@ -101,22 +263,19 @@ func GenerateRepeatEvents(e Event) ([]*Event, error) {
 			}
 		} else if r.RepeatStopDate != nil {
 			// loop until the next start date is after the stop date
-			for !nextStart.After(*r.RepeatStopDate) {
+			for !stopped && !nextStart.After(*r.RepeatStopDate) {
 				// if there are more event repeats than allowed, throw error
-				if len(events) > int(MaxRepeatOccurrence) {
-					return nil, ErrorTooManyRepeatOccurrences
+				if limit >= 0 && count > limit {
+					return ErrorTooManyRepeatOccurrences
 				}
 
 				day := dayOfWeekFromWeekday(nextStart.Weekday())
-				if !r.DayOfWeek.HasFlag(day) {
+				if !days.HasFlag(day) {
 					increment()
 					continue
 				}
 
-				nextEvent := e
-				nextEvent.StartDay = nextStart.Format(time.DateOnly)
-				nextEvent.EndDay = nextEnd.Format(time.DateOnly)
-				events = append(events, &nextEvent)
+				emit()
 
 				// go to the next day (do this at the end of the for loop
 				// since we need to check the original event)
@@ -125,10 +284,13 @@ func GenerateRepeatEvents(e Event) ([]*Event, error) {
 		}
 	}
 
-	if events == nil || len(events) == 0 {
-		return nil, ErrorEmptyRepeatingEvents
+	if incrementErr != nil {
+		return incrementErr
 	}
 
-	return events, nil
+	if count == 0 {
+		return ErrorEmptyRepeatingEvents
+	}
 
+	return nil
 }
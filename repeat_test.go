@@ -1,6 +1,8 @@
 package cali
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -268,3 +270,145 @@ func TestGenerateRepeatEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestRepeatOccurrences(t *testing.T) {
+	e := Event{
+		IsRepeating: true,
+		IsAllDay:    true,
+		StartDay:    "2008-01-01", EndDay: "2008-01-02",
+		Repeat: &Repeat{RepeatType: RepeatTypeWeekly, DayOfWeek: DayOfWeekTuesday | DayOfWeekThursday, RepeatOccurrences: 5},
+	}
+	events, err := GenerateRepeatEvents(e)
+	require.NoError(t, err)
+
+	var days []string
+	err = RepeatOccurrences(e, func(startDay, endDay time.Time) bool {
+		days = append(days, startDay.Format(time.DateOnly)+"/"+endDay.Format(time.DateOnly))
+		return true
+	})
+	require.NoError(t, err)
+	require.Len(t, days, len(events))
+	for i, event := range events {
+		assert.Equal(t, event.StartDay+"/"+event.EndDay, days[i])
+	}
+
+	// stop after the first two occurrences
+	count := 0
+	err = RepeatOccurrences(e, func(startDay, endDay time.Time) bool {
+		count++
+		return count < 2
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	err = RepeatOccurrences(Event{StartDay: "2008-01-01", EndDay: "2008-01-01"}, func(startDay, endDay time.Time) bool {
+		return true
+	})
+	assert.Equal(t, ErrorNotRepeatingEvent, err)
+}
+
+func TestRepeatWeekdays(t *testing.T) {
+	testCases := []struct {
+		name   string
+		repeat Repeat
+		days   []string
+		err    error
+	}{
+		{name: "weekdays", repeat: Repeat{RepeatType: RepeatTypeWeekdays, RepeatOccurrences: 6}, days: []string{"2008-01-03", "2008-01-04", "2008-01-07", "2008-01-08", "2008-01-09", "2008-01-10"}},
+		{name: "weekdays ignores the day of week", repeat: Repeat{RepeatType: RepeatTypeWeekdays, DayOfWeek: DayOfWeekSaturday, RepeatOccurrences: 3}, days: []string{"2008-01-03", "2008-01-04", "2008-01-07"}},
+		{name: "daily business days", repeat: Repeat{RepeatType: RepeatTypeDaily, BusinessDaysOnly: true, RepeatStopDate: _t(time.Date(2008, time.January, 8, 0, 0, 0, 0, time.UTC))}, days: []string{"2008-01-03", "2008-01-04", "2008-01-07", "2008-01-08"}},
+		{name: "daily every day", repeat: Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}, days: []string{"2008-01-03", "2008-01-04", "2008-01-05"}},
+		{name: "weekly business days", repeat: Repeat{RepeatType: RepeatTypeWeekly, DayOfWeek: DayOfWeekMonday, BusinessDaysOnly: true, RepeatOccurrences: 3}, err: ErrorInvalidBusinessDaysOnly},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			repeat := tc.repeat
+			events, err := GenerateRepeatEvents(Event{IsRepeating: true, IsAllDay: true, Zone: "UTC", StartDay: "2008-01-03", EndDay: "2008-01-03", Repeat: &repeat})
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			var days []string
+			for _, e := range events {
+				days = append(days, e.StartDay)
+			}
+			assert.Equal(t, tc.days, days)
+		})
+	}
+}
+
+func TestTruncateRepeat(t *testing.T) {
+	daily := func(repeat Repeat) Event {
+		return Event{
+			IsRepeating: true,
+			IsAllDay:    true,
+			StartDay:    "2008-01-01", EndDay: "2008-01-01",
+			Repeat: &repeat,
+		}
+	}
+	testCases := []struct {
+		desc    string
+		in      Event
+		dropped int64
+		count   int
+	}{
+		{"not too many", daily(Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}), 0, 3},
+		{"too many occurrences", daily(Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 50}), 20, int(MaxRepeatOccurrence)},
+		{"stop date too far", daily(Repeat{RepeatType: RepeatTypeDaily, RepeatStopDate: _t(time.Date(2008, time.March, 1, 0, 0, 0, 0, time.UTC))}), 32, int(MaxRepeatOccurrence)},
+		{"stop date close", daily(Repeat{RepeatType: RepeatTypeDaily, RepeatStopDate: _t(time.Date(2008, time.January, 5, 0, 0, 0, 0, time.UTC))}), 0, 6},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.desc)
+			original := *tc.in.Repeat
+			out, dropped, err := TruncateRepeat(tc.in)
+			require.NoError(t, err)
+			assert.Equal(t, tc.dropped, dropped)
+			assert.Equal(t, original, *tc.in.Repeat, "the original repeat should not change")
+			events, err := GenerateRepeatEvents(out)
+			require.NoError(t, err)
+			assert.Len(t, events, tc.count)
+		})
+	}
+}
+
+// expansionStore records the events that the data store expands itself
+type expansionStore struct {
+	DataStore
+	expanded []Event
+}
+
+func (d *expansionStore) CreateRepeating(e Event) ([]*Event, error) {
+	d.expanded = append(d.expanded, e)
+	return d.DataStore.(RepeatExpansionStore).CreateRepeating(e)
+}
+
+func (d *expansionStore) Create(event Event) (*Event, error) {
+	return nil, errors.New("the calendar should not generate the repeat")
+}
+
+func TestRepeatExpansionStore(t *testing.T) {
+	store := &expansionStore{DataStore: &InMemoryDataStore{}}
+	c := NewCalendar(store)
+	e, count, err := c.Create(Event{ExternalKey: "standup", StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 4}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+	require.Len(t, store.expanded, 1)
+	assert.Equal(t, "standup", e.ExternalKey)
+
+	events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+	for i, event := range events[1:] {
+		assert.Equal(t, e.Id, *event.ParentId)
+		assert.Equal(t, "", event.ExternalKey)
+		assert.Equal(t, fmt.Sprintf("2008-01-0%d", i+2), event.StartDay)
+	}
+}
@@ -0,0 +1,826 @@
+package cali
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithReadReplica sends Get and Query (and reads of invites) to the replica while every write
+// goes to the primary data store, see NewReplicatedDataStore
+func WithReadReplica(replica DataStore, readYourWrites time.Duration) CalendarOption {
+	return func(c *Calendar) {
+		c.dataStore = NewReplicatedDataStore(c.dataStore, replica, readYourWrites)
+	}
+}
+
+// ReplicatedDataStore wraps a primary data store and a read replica of it (like a database
+// and its read replica). Get, Query, GetInvite, and GetInvites are read from the replica and
+// everything else (including sync, which can't miss changes) uses the primary.
+//
+// Since a replica can lag behind the primary, reads go to the primary for the ReadYourWrites
+// duration after any write so that a user sees their own changes.
+type ReplicatedDataStore struct {
+	DataStore
+	Replica DataStore
+	// ReadYourWrites is how long reads use the primary after a write (0 always reads the replica)
+	ReadYourWrites time.Duration
+
+	lastWrite atomic.Int64
+}
+
+// NewReplicatedDataStore reads from the replica and writes to the primary data store
+func NewReplicatedDataStore(primary, replica DataStore, readYourWrites time.Duration) *ReplicatedDataStore {
+	return &ReplicatedDataStore{
+		DataStore:      primary,
+		Replica:        replica,
+		ReadYourWrites: readYourWrites,
+	}
+}
+
+// Unwrap gets the primary and the replica (see WrapperStore)
+func (d *ReplicatedDataStore) Unwrap() []DataStore {
+	return []DataStore{d.DataStore, d.Replica}
+}
+
+// reader picks the primary if there was a write within the ReadYourWrites duration, otherwise the replica
+func (d *ReplicatedDataStore) reader() DataStore {
+	if d.ReadYourWrites > 0 && time.Since(time.Unix(0, d.lastWrite.Load())) < d.ReadYourWrites {
+		return d.DataStore
+	}
+	return d.Replica
+}
+
+// wrote marks the time of the latest write
+func (d *ReplicatedDataStore) wrote() {
+	d.lastWrite.Store(time.Now().UnixNano())
+}
+
+func (d *ReplicatedDataStore) Get(eventId int64) (*Event, error) {
+	return d.reader().Get(eventId)
+}
+
+func (d *ReplicatedDataStore) GetMany(eventIds []int64) ([]*Event, error) {
+	return getMany(d.reader(), eventIds)
+}
+
+func (d *ReplicatedDataStore) Query(q Query) ([]*Event, error) {
+	return d.reader().Query(q)
+}
+
+func (d *ReplicatedDataStore) GetInvite(eventId, userId int64) (*Invite, error) {
+	return d.reader().GetInvite(eventId, userId)
+}
+
+func (d *ReplicatedDataStore) GetInvites(eventId int64) ([]*Invite, error) {
+	store, ok := capability[InviteListStore](d.reader())
+	if !ok {
+		return nil, ErrorInviteListNotSupported
+	}
+	return store.GetInvites(eventId)
+}
+
+// HasGeoIndex is true if the replica (which handles queries) has a geo index
+func (d *ReplicatedDataStore) HasGeoIndex() bool {
+	store, ok := capability[GeoIndexStore](d.Replica)
+	return ok && store.HasGeoIndex()
+}
+
+func (d *ReplicatedDataStore) Create(event Event) (*Event, error) {
+	defer d.wrote()
+	return d.DataStore.Create(event)
+}
+
+func (d *ReplicatedDataStore) SetTime(eventId int64, startTime, endTime string) error {
+	defer d.wrote()
+	return d.DataStore.SetTime(eventId, startTime, endTime)
+}
+
+func (d *ReplicatedDataStore) SetDayTime(eventId int64, startDay, startTime, endDay, endTime, zone string, isAllDay bool) error {
+	defer d.wrote()
+	return d.DataStore.SetDayTime(eventId, startDay, startTime, endDay, endTime, zone, isAllDay)
+}
+
+func (d *ReplicatedDataStore) SetStatus(eventId int64, status Status) error {
+	defer d.wrote()
+	return d.DataStore.SetStatus(eventId, status)
+}
+
+func (d *ReplicatedDataStore) SetTitle(eventId int64, title string) error {
+	defer d.wrote()
+	return d.DataStore.SetTitle(eventId, title)
+}
+
+func (d *ReplicatedDataStore) SetDescription(eventId int64, description *string) error {
+	defer d.wrote()
+	return d.DataStore.SetDescription(eventId, description)
+}
+
+func (d *ReplicatedDataStore) SetUrl(eventId int64, url *string) error {
+	defer d.wrote()
+	return d.DataStore.SetUrl(eventId, url)
+}
+
+func (d *ReplicatedDataStore) SetLocation(eventId int64, location *string) error {
+	store, ok := capability[LocationStore](d.DataStore)
+	if !ok {
+		return ErrorLocationNotSupported
+	}
+	defer d.wrote()
+	return store.SetLocation(eventId, location)
+}
+
+func (d *ReplicatedDataStore) SetGeo(eventId int64, geo *GeoPoint) error {
+	store, ok := capability[GeoPointStore](d.DataStore)
+	if !ok {
+		return ErrorGeoNotSupported
+	}
+	defer d.wrote()
+	return store.SetGeo(eventId, geo)
+}
+
+func (d *ReplicatedDataStore) SetDisallowForwarding(eventId int64, disallow bool) error {
+	store, ok := capability[ForwardingStore](d.DataStore)
+	if !ok {
+		return ErrorForwardingNotSupported
+	}
+	defer d.wrote()
+	return store.SetDisallowForwarding(eventId, disallow)
+}
+
+func (d *ReplicatedDataStore) SetVisibility(eventId int64, visibility Visibility) error {
+	store, ok := capability[VisibilityStore](d.DataStore)
+	if !ok {
+		return ErrorVisibilityNotSupported
+	}
+	defer d.wrote()
+	return store.SetVisibility(eventId, visibility)
+}
+
+func (d *ReplicatedDataStore) SetPriority(eventId int64, priority Priority) error {
+	store, ok := capability[PriorityStore](d.DataStore)
+	if !ok {
+		return ErrorPriorityNotSupported
+	}
+	defer d.wrote()
+	return store.SetPriority(eventId, priority)
+}
+
+func (d *ReplicatedDataStore) SetConference(eventId int64, conference *Conference) error {
+	store, ok := capability[ConferenceStore](d.DataStore)
+	if !ok {
+		return ErrorConferenceNotSupported
+	}
+	defer d.wrote()
+	return store.SetConference(eventId, conference)
+}
+
+func (d *ReplicatedDataStore) SetUserData(eventId int64, userData map[string]interface{}) error {
+	defer d.wrote()
+	return d.DataStore.SetUserData(eventId, userData)
+}
+
+func (d *ReplicatedDataStore) AddInvite(invite Invite) (*Invite, error) {
+	defer d.wrote()
+	return d.DataStore.AddInvite(invite)
+}
+
+func (d *ReplicatedDataStore) SetInviteStatus(eventId, userId int64, status InviteStatus) error {
+	defer d.wrote()
+	return d.DataStore.SetInviteStatus(eventId, userId, status)
+}
+
+func (d *ReplicatedDataStore) SetInvitePermissions(eventId, userId int64, permissions Permission) error {
+	defer d.wrote()
+	return d.DataStore.SetInvitePermissions(eventId, userId, permissions)
+}
+
+func (d *ReplicatedDataStore) SetInvitePrivateNote(eventId, userId int64, note *string) error {
+	store, ok := capability[PrivateInviteStore](d.DataStore)
+	if !ok {
+		return ErrorPrivateInviteNotSupported
+	}
+	defer d.wrote()
+	return store.SetInvitePrivateNote(eventId, userId, note)
+}
+
+func (d *ReplicatedDataStore) SetInviteUserData(eventId, userId int64, userData map[string]interface{}) error {
+	store, ok := capability[PrivateInviteStore](d.DataStore)
+	if !ok {
+		return ErrorPrivateInviteNotSupported
+	}
+	defer d.wrote()
+	return store.SetInviteUserData(eventId, userId, userData)
+}
+
+func (d *ReplicatedDataStore) InTx(f func(tx DataStore) error) error {
+	defer d.wrote()
+	store, ok := capability[TxStore](d.DataStore)
+	if !ok {
+		return f(d)
+	}
+	return store.InTx(f)
+}
+
+func (d *ReplicatedDataStore) IfVersion(eventId int64, version int64, f func(store DataStore) error) error {
+	defer d.wrote()
+	store, ok := capability[VersionStore](d.DataStore)
+	if !ok {
+		return f(d)
+	}
+	return store.IfVersion(eventId, version, f)
+}
+
+func (d *ReplicatedDataStore) CreateBatch(events []Event) ([]*Event, error) {
+	defer d.wrote()
+	store, ok := capability[BatchCreateStore](d.DataStore)
+	if !ok {
+		var result []*Event
+		for _, event := range events {
+			e, err := d.DataStore.Create(event)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, e)
+		}
+		return result, nil
+	}
+	return store.CreateBatch(events)
+}
+
+func (d *ReplicatedDataStore) CreateRepeating(e Event) ([]*Event, error) {
+	defer d.wrote()
+	store, ok := capability[RepeatExpansionStore](d.DataStore)
+	if !ok {
+		return generateRepeating(d.DataStore, e)
+	}
+	return store.CreateRepeating(e)
+}
+
+func (d *ReplicatedDataStore) SetStatusWhere(q Query, status Status) ([]int64, error) {
+	defer d.wrote()
+	store, ok := capability[BulkStatusStore](d.DataStore)
+	if ok {
+		return store.SetStatusWhere(q, status)
+	}
+	events, err := d.DataStore.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	var result []int64
+	for _, e := range events {
+		if e.Status == status {
+			continue
+		}
+		if err := d.DataStore.SetStatus(e.Id, status); err != nil {
+			return nil, err
+		}
+		result = append(result, e.Id)
+	}
+	return result, nil
+}
+
+func (d *ReplicatedDataStore) SetStatusBatch(eventIds []int64, status Status) error {
+	defer d.wrote()
+	store, ok := capability[BatchStatusStore](d.DataStore)
+	if ok {
+		return store.SetStatusBatch(eventIds, status)
+	}
+	for _, id := range eventIds {
+		if err := d.DataStore.SetStatus(id, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *ReplicatedDataStore) SetAutoResponsePolicy(policy AutoResponsePolicy) error {
+	store, ok := capability[AutoResponseStore](d.DataStore)
+	if !ok {
+		return ErrorAutoResponseNotSupported
+	}
+	defer d.wrote()
+	return store.SetAutoResponsePolicy(policy)
+}
+
+func (d *ReplicatedDataStore) GetAutoResponsePolicy(userId int64) (*AutoResponsePolicy, error) {
+	store, ok := capability[AutoResponseStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetAutoResponsePolicy(userId)
+}
+
+func (d *ReplicatedDataStore) AddSeriesInvite(invite Invite) (*Invite, error) {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSeriesInviteNotSupported
+	}
+	defer d.wrote()
+	return store.AddSeriesInvite(invite)
+}
+
+func (d *ReplicatedDataStore) SetSeriesInviteStatus(parentId, userId int64, status InviteStatus) error {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return ErrorSeriesInviteNotSupported
+	}
+	defer d.wrote()
+	return store.SetSeriesInviteStatus(parentId, userId, status)
+}
+
+func (d *ReplicatedDataStore) SetSeriesInvitePermissions(parentId, userId int64, permissions Permission) error {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return ErrorSeriesInviteNotSupported
+	}
+	defer d.wrote()
+	return store.SetSeriesInvitePermissions(parentId, userId, permissions)
+}
+
+func (d *ReplicatedDataStore) GetSeriesInvite(parentId, userId int64) (*Invite, error) {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetSeriesInvite(parentId, userId)
+}
+
+func (d *ReplicatedDataStore) GetSeriesInvites(parentId int64) ([]*Invite, error) {
+	store, ok := capability[SeriesInviteStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetSeriesInvites(parentId)
+}
+
+func (d *ReplicatedDataStore) SetSubscription(s Subscription) (*Subscription, error) {
+	store, ok := capability[SubscriptionStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSubscriptionNotSupported
+	}
+	defer d.wrote()
+	return store.SetSubscription(s)
+}
+
+func (d *ReplicatedDataStore) RemoveSubscription(userId, calendarId int64) error {
+	store, ok := capability[SubscriptionStore](d.DataStore)
+	if !ok {
+		return ErrorSubscriptionNotSupported
+	}
+	defer d.wrote()
+	return store.RemoveSubscription(userId, calendarId)
+}
+
+func (d *ReplicatedDataStore) GetSubscriptions(userId int64) ([]*Subscription, error) {
+	store, ok := capability[SubscriptionStore](d.DataStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetSubscriptions(userId)
+}
+
+func (d *ReplicatedDataStore) ChangedEvents(since time.Time) ([]*Event, error) {
+	store, ok := capability[SyncStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSyncNotSupported
+	}
+	return store.ChangedEvents(since)
+}
+
+func (d *ReplicatedDataStore) ChangedInvites(since time.Time) ([]*Invite, error) {
+	store, ok := capability[SyncStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSyncNotSupported
+	}
+	return store.ChangedInvites(since)
+}
+
+func (d *ReplicatedDataStore) AddOutboxRecord(record OutboxRecord) (*OutboxRecord, error) {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return nil, ErrorOutboxNotSupported
+	}
+	defer d.wrote()
+	return store.AddOutboxRecord(record)
+}
+
+func (d *ReplicatedDataStore) PendingOutboxRecords(limit int) ([]*OutboxRecord, error) {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return nil, ErrorOutboxNotSupported
+	}
+	return store.PendingOutboxRecords(limit)
+}
+
+func (d *ReplicatedDataStore) MarkOutboxDelivered(id int64) error {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return ErrorOutboxNotSupported
+	}
+	defer d.wrote()
+	return store.MarkOutboxDelivered(id)
+}
+
+func (d *ReplicatedDataStore) MarkOutboxFailed(id int64, reason string) error {
+	store, ok := capability[OutboxStore](d.DataStore)
+	if !ok {
+		return ErrorOutboxNotSupported
+	}
+	defer d.wrote()
+	return store.MarkOutboxFailed(id, reason)
+}
+
+func (d *ReplicatedDataStore) AddAvailability(a Availability) (*Availability, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	defer d.wrote()
+	return store.AddAvailability(a)
+}
+
+func (d *ReplicatedDataStore) GetAvailability(id int64) (*Availability, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	return store.GetAvailability(id)
+}
+
+func (d *ReplicatedDataStore) BookSlot(b Booking, e Event) (*Booking, *Event, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, nil, ErrorSlotsNotSupported
+	}
+	defer d.wrote()
+	return store.BookSlot(b, e)
+}
+
+func (d *ReplicatedDataStore) GetBooking(id int64) (*Booking, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	return store.GetBooking(id)
+}
+
+func (d *ReplicatedDataStore) GetBookings(availabilityId int64, startDay, endDay string) ([]*Booking, error) {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	return store.GetBookings(availabilityId, startDay, endDay)
+}
+
+func (d *ReplicatedDataStore) SetBookingStatus(id int64, status BookingStatus) error {
+	store, ok := capability[SlotStore](d.DataStore)
+	if !ok {
+		return ErrorSlotsNotSupported
+	}
+	defer d.wrote()
+	return store.SetBookingStatus(id, status)
+}
+
+func (d *ReplicatedDataStore) Stats(userId int64, window TimeWindow) (*Stats, error) {
+	reader := d.reader()
+	if store, ok := capability[StatsStore](reader); ok {
+		return store.Stats(userId, window)
+	}
+	return computeStats(reader, userId, window)
+}
+
+func (d *ReplicatedDataStore) RSVPSummary(eventId int64) (*RSVPSummary, error) {
+	reader := d.reader()
+	if store, ok := capability[RSVPSummaryStore](reader); ok {
+		return store.RSVPSummary(eventId)
+	}
+	return computeRSVPSummary(reader, eventId)
+}
+
+func (d *ReplicatedDataStore) PendingInvitesBefore(before time.Time) ([]*Invite, error) {
+	store, ok := capability[PendingInviteStore](d.reader())
+	if !ok {
+		return nil, ErrorPendingInvitesNotSupported
+	}
+	return store.PendingInvitesBefore(before)
+}
+
+func (d *ReplicatedDataStore) CreateSeries(s Series) (*Series, error) {
+	store, ok := capability[SeriesStore](d.DataStore)
+	if !ok {
+		return nil, ErrorSeriesRecordsNotSupported
+	}
+	defer d.wrote()
+	return store.CreateSeries(s)
+}
+
+func (d *ReplicatedDataStore) SetSeries(s Series) error {
+	store, ok := capability[SeriesStore](d.DataStore)
+	if !ok {
+		return ErrorSeriesRecordsNotSupported
+	}
+	defer d.wrote()
+	return store.SetSeries(s)
+}
+
+func (d *ReplicatedDataStore) GetSeries(seriesIds []int64) ([]*Series, error) {
+	store, ok := capability[SeriesStore](d.reader())
+	if !ok {
+		return nil, ErrorSeriesRecordsNotSupported
+	}
+	return store.GetSeries(seriesIds)
+}
+
+func (d *ReplicatedDataStore) GetByExternalKey(key string) (*Event, error) {
+	store, ok := capability[ExternalKeyStore](d.reader())
+	if !ok {
+		return nil, ErrorExternalKeysNotSupported
+	}
+	return store.GetByExternalKey(key)
+}
+
+func (d *ReplicatedDataStore) SetExternalKey(eventId int64, key string) error {
+	store, ok := capability[ExternalKeyStore](d.DataStore)
+	if !ok {
+		return ErrorExternalKeysNotSupported
+	}
+	defer d.wrote()
+	return store.SetExternalKey(eventId, key)
+}
+
+func (d *ReplicatedDataStore) SetOverrides(eventId int64, overrides []string) error {
+	store, ok := capability[OverrideStore](d.DataStore)
+	if !ok {
+		return ErrorOverridesNotSupported
+	}
+	defer d.wrote()
+	return store.SetOverrides(eventId, overrides)
+}
+
+func (d *ReplicatedDataStore) SetCancelReason(eventId int64, reason *string) error {
+	store, ok := capability[CancelReasonStore](d.DataStore)
+	if !ok {
+		return ErrorCancelReasonNotSupported
+	}
+	defer d.wrote()
+	return store.SetCancelReason(eventId, reason)
+}
+
+func (d *ReplicatedDataStore) SetLinks(eventId int64, links []Link) error {
+	store, ok := capability[LinkStore](d.DataStore)
+	if !ok {
+		return ErrorLinksNotSupported
+	}
+	defer d.wrote()
+	return store.SetLinks(eventId, links)
+}
+
+func (d *ReplicatedDataStore) AddFollower(f Follower) (*Follower, error) {
+	store, ok := capability[FollowerStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	defer d.wrote()
+	return store.AddFollower(f)
+}
+
+func (d *ReplicatedDataStore) RemoveFollower(eventId, userId int64) error {
+	store, ok := capability[FollowerStore](d.DataStore)
+	if !ok {
+		return ErrorFollowersNotSupported
+	}
+	defer d.wrote()
+	return store.RemoveFollower(eventId, userId)
+}
+
+func (d *ReplicatedDataStore) GetFollowers(eventId int64) ([]*Follower, error) {
+	store, ok := capability[FollowerStore](d.reader())
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	return store.GetFollowers(eventId)
+}
+
+func (d *ReplicatedDataStore) GetFollowedEventIds(userId int64) ([]int64, error) {
+	store, ok := capability[FollowerStore](d.reader())
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	return store.GetFollowedEventIds(userId)
+}
+
+func (d *ReplicatedDataStore) AddReaction(r Reaction) (*Reaction, error) {
+	store, ok := capability[ReactionStore](d.DataStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	defer d.wrote()
+	return store.AddReaction(r)
+}
+
+func (d *ReplicatedDataStore) RemoveReaction(eventId, userId int64, emoji string) error {
+	store, ok := capability[ReactionStore](d.DataStore)
+	if !ok {
+		return ErrorReactionsNotSupported
+	}
+	defer d.wrote()
+	return store.RemoveReaction(eventId, userId, emoji)
+}
+
+func (d *ReplicatedDataStore) GetReactions(eventId int64) ([]*Reaction, error) {
+	store, ok := capability[ReactionStore](d.reader())
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	return store.GetReactions(eventId)
+}
+
+func (d *ReplicatedDataStore) CountReactions(eventIds []int64) (map[int64]map[string]int64, error) {
+	store, ok := capability[ReactionStore](d.reader())
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	return store.CountReactions(eventIds)
+}
+
+func (d *ReplicatedDataStore) AddAttachment(a Attachment) (*Attachment, error) {
+	store, ok := capability[AttachmentStore](d.DataStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	defer d.wrote()
+	return store.AddAttachment(a)
+}
+
+func (d *ReplicatedDataStore) GetAttachment(id int64) (*Attachment, error) {
+	store, ok := capability[AttachmentStore](d.reader())
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	return store.GetAttachment(id)
+}
+
+func (d *ReplicatedDataStore) GetAttachments(eventId int64) ([]*Attachment, error) {
+	store, ok := capability[AttachmentStore](d.reader())
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	return store.GetAttachments(eventId)
+}
+
+func (d *ReplicatedDataStore) SetAttachmentStatus(id int64, status AttachmentStatus, reason *string) error {
+	store, ok := capability[AttachmentStore](d.DataStore)
+	if !ok {
+		return ErrorAttachmentsNotSupported
+	}
+	defer d.wrote()
+	return store.SetAttachmentStatus(id, status, reason)
+}
+
+func (d *ReplicatedDataStore) AddFeedToken(t FeedToken) (*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	defer d.wrote()
+	return store.AddFeedToken(t)
+}
+
+func (d *ReplicatedDataStore) GetFeedToken(id int64) (*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.reader())
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.GetFeedToken(id)
+}
+
+// GetFeedTokenByHash always reads from the primary, so that a revoked token can't be used
+// while the replica catches up
+func (d *ReplicatedDataStore) GetFeedTokenByHash(hash string) (*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.GetFeedTokenByHash(hash)
+}
+
+func (d *ReplicatedDataStore) GetFeedTokens(userId int64) ([]*FeedToken, error) {
+	store, ok := capability[FeedTokenStore](d.reader())
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	return store.GetFeedTokens(userId)
+}
+
+func (d *ReplicatedDataStore) RevokeFeedToken(id int64) error {
+	store, ok := capability[FeedTokenStore](d.DataStore)
+	if !ok {
+		return ErrorFeedTokensNotSupported
+	}
+	defer d.wrote()
+	return store.RevokeFeedToken(id)
+}
+
+func (d *ReplicatedDataStore) SetRecurrenceId(eventId int64, recurrenceId string) error {
+	store, ok := capability[OverrideStore](d.DataStore)
+	if !ok {
+		return ErrorOverridesNotSupported
+	}
+	defer d.wrote()
+	return store.SetRecurrenceId(eventId, recurrenceId)
+}
+
+func (d *ReplicatedDataStore) SetInviteProposal(eventId, userId int64, proposal *TimeProposal) error {
+	store, ok := capability[ProposalStore](d.DataStore)
+	if !ok {
+		return ErrorProposalsNotSupported
+	}
+	defer d.wrote()
+	return store.SetInviteProposal(eventId, userId, proposal)
+}
+
+func (d *ReplicatedDataStore) SetInviteMuted(eventId, userId int64, muted bool) error {
+	store, ok := capability[MuteStore](d.DataStore)
+	if !ok {
+		return ErrorMuteNotSupported
+	}
+	defer d.wrote()
+	return store.SetInviteMuted(eventId, userId, muted)
+}
+
+func (d *ReplicatedDataStore) AddReminder(r Reminder) (*Reminder, error) {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	defer d.wrote()
+	return store.AddReminder(r)
+}
+
+func (d *ReplicatedDataStore) GetReminder(reminderId int64) (*Reminder, error) {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.GetReminder(reminderId)
+}
+
+func (d *ReplicatedDataStore) UpdateReminder(r Reminder) error {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return ErrorRemindersNotSupported
+	}
+	defer d.wrote()
+	return store.UpdateReminder(r)
+}
+
+func (d *ReplicatedDataStore) DueReminders(before time.Time) ([]*Reminder, error) {
+	store, ok := capability[ReminderStore](d.DataStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.DueReminders(before)
+}
+
+func (d *ReplicatedDataStore) AddAuditEntry(entry AuditEntry) (*AuditEntry, error) {
+	store, ok := capability[AuditStore](d.DataStore)
+	if !ok {
+		return nil, ErrorAuditLogNotSupported
+	}
+	defer d.wrote()
+	return store.AddAuditEntry(entry)
+}
+
+func (d *ReplicatedDataStore) GetAuditEntries(eventId int64) ([]*AuditEntry, error) {
+	store, ok := capability[AuditStore](d.reader())
+	if !ok {
+		return nil, ErrorAuditLogNotSupported
+	}
+	return store.GetAuditEntries(eventId)
+}
+
+func (d *ReplicatedDataStore) LockEvent(lock EventLock, now time.Time) error {
+	store, ok := capability[LockStore](d.DataStore)
+	if !ok {
+		return ErrorLocksNotSupported
+	}
+	defer d.wrote()
+	return store.LockEvent(lock, now)
+}
+
+func (d *ReplicatedDataStore) GetEventLock(eventId int64) (*EventLock, error) {
+	store, ok := capability[LockStore](d.DataStore)
+	if !ok {
+		return nil, ErrorLocksNotSupported
+	}
+	return store.GetEventLock(eventId)
+}
+
+func (d *ReplicatedDataStore) UnlockEvent(eventId int64, userId int64) error {
+	store, ok := capability[LockStore](d.DataStore)
+	if !ok {
+		return ErrorLocksNotSupported
+	}
+	defer d.wrote()
+	return store.UnlockEvent(eventId, userId)
+}
@@ -0,0 +1,54 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicatedDataStore(t *testing.T) {
+	primary := &InMemoryDataStore{}
+	// the replica is never caught up, so it shows which store was read
+	replica := &InMemoryDataStore{}
+	d := NewReplicatedDataStore(primary, replica, 0)
+
+	e, err := d.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	assert.Len(t, primary.events, 1)
+	assert.Empty(t, replica.events)
+
+	found, err := d.Get(e.Id)
+	require.NoError(t, err)
+	assert.Nil(t, found, "reads should use the replica")
+
+	d.ReadYourWrites = time.Minute
+	require.NoError(t, d.SetTitle(e.Id, "changed"))
+	found, err = d.Get(e.Id)
+	require.NoError(t, err)
+	require.NotNil(t, found, "reads after a write should use the primary")
+	assert.Equal(t, "changed", found.Title)
+
+	events, err := d.Query(Query{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestReplicatedPlainDataStore(t *testing.T) {
+	primary := &InMemoryDataStore{}
+	assertPlainWrapper(t, NewReplicatedDataStore(plainStore{primary}, plainStore{primary}, 0))
+}
+
+func TestWithReadReplica(t *testing.T) {
+	primary := &InMemoryDataStore{}
+	replica := &InMemoryDataStore{}
+	c := NewCalendar(primary, WithReadReplica(replica, time.Minute))
+	e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+
+	found, err := c.Get(e.Id)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Empty(t, replica.events)
+}
@@ -0,0 +1,265 @@
+package cali
+
+import (
+	"sort"
+	"time"
+)
+
+// Utilization is how much of the available hours of a resource (like a room) are booked
+type Utilization struct {
+	// UserId is the user id of the resource
+	UserId int64 `json:"userId"`
+	// BookedHours are the hours of the available hours that the resource has events in
+	BookedHours float64 `json:"bookedHours"`
+	// AvailableHours are the working hours of the resource in the window
+	AvailableHours float64 `json:"availableHours"`
+	// Percent is the percent (0 to 100) of the available hours that are booked
+	Percent float64 `json:"percent"`
+}
+
+// MeetingLoad is the total meeting hours of the members of a team
+type MeetingLoad struct {
+	// Team is the name of the team
+	Team string `json:"team"`
+	// Members is the number of users in the team
+	Members int64 `json:"members"`
+	// MeetingHours is the total of the meeting hours of each member (see Stats), so a
+	// meeting between two members counts for both of them
+	MeetingHours float64 `json:"meetingHours"`
+	// HoursPerMember is the average meeting hours of the members
+	HoursPerMember float64 `json:"hoursPerMember"`
+}
+
+// AfterHours is the number of a user's meetings that aren't within their working hours
+type AfterHours struct {
+	// UserId is the user
+	UserId int64 `json:"userId"`
+	// Meetings is the number of meetings of the user (see Stats)
+	Meetings int64 `json:"meetings"`
+	// AfterHours is the number of the meetings that aren't within the working hours of the user
+	AfterHours int64 `json:"afterHours"`
+}
+
+// ReportBackend totals up the reports over many users. The default backend reads the events of
+// each user through the calendar, so a backend that aggregates in the database (or a data
+// warehouse) can be used with WithReportBackend for large organizations.
+type ReportBackend interface {
+	// Utilization gets the utilization of each resource in the same order as the resource ids
+	Utilization(resourceIds []int64, hours WorkingHours, window TimeWindow) ([]*Utilization, error)
+	// MeetingLoad gets the meeting load of each team sorted by the team name
+	MeetingLoad(teams map[string][]int64, window TimeWindow) ([]*MeetingLoad, error)
+	// AfterHoursMeetings counts the meetings of each user in the same order as the user ids, where
+	// the working hours of a user's auto response policy are used instead of the hours if they have one
+	AfterHoursMeetings(userIds []int64, hours WorkingHours, window TimeWindow) ([]*AfterHours, error)
+}
+
+// WithReportBackend uses the backend for the organization reports instead of reading the events
+// of every user through the calendar
+func WithReportBackend(backend ReportBackend) CalendarOption {
+	return func(c *Calendar) {
+		c.reportBackend = backend
+	}
+}
+
+// reports gets the report backend of the calendar
+func (c *Calendar) reports() ReportBackend {
+	if c.reportBackend != nil {
+		return c.reportBackend
+	}
+	return calendarReports{c}
+}
+
+// Utilization gets the percent of the working hours that each resource (like a room) is booked in the window
+func (c *Calendar) Utilization(resourceIds []int64, hours WorkingHours, window TimeWindow) ([]*Utilization, error) {
+	if err := ValidateWorkingHours(hours); err != nil {
+		return nil, err
+	}
+	return c.reports().Utilization(resourceIds, hours, window)
+}
+
+// MeetingLoad gets the meeting hours of each team (by name to the user ids of its members) in the window
+func (c *Calendar) MeetingLoad(teams map[string][]int64, window TimeWindow) ([]*MeetingLoad, error) {
+	return c.reports().MeetingLoad(teams, window)
+}
+
+// AfterHoursMeetings counts the meetings of each user in the window that aren't within their
+// working hours, which are the hours unless the user has working hours in their auto response policy
+func (c *Calendar) AfterHoursMeetings(userIds []int64, hours WorkingHours, window TimeWindow) ([]*AfterHours, error) {
+	if err := ValidateWorkingHours(hours); err != nil {
+		return nil, err
+	}
+	return c.reports().AfterHoursMeetings(userIds, hours, window)
+}
+
+// calendarReports is the default ReportBackend that reads the events of each user through the calendar
+type calendarReports struct {
+	c *Calendar
+}
+
+// userEvents gets the active events of the user in the window
+func (r calendarReports) userEvents(userId int64, window TimeWindow) ([]*Event, error) {
+	return r.c.dataStore.Query(Query{
+		UserIds:  []int64{userId},
+		Start:    &window.Start,
+		End:      &window.End,
+		Statuses: []Status{StatusActive},
+	})
+}
+
+func (r calendarReports) Utilization(resourceIds []int64, hours WorkingHours, window TimeWindow) ([]*Utilization, error) {
+	available, err := hours.intervals(window)
+	if err != nil {
+		return nil, err
+	}
+	var availableHours float64
+	for _, interval := range available {
+		availableHours += interval[1].Sub(interval[0]).Hours()
+	}
+
+	result := make([]*Utilization, 0, len(resourceIds))
+	for _, resourceId := range resourceIds {
+		events, err := r.userEvents(resourceId, window)
+		if err != nil {
+			return nil, err
+		}
+		u := &Utilization{UserId: resourceId, AvailableHours: availableHours}
+		for _, interval := range available {
+			u.BookedHours += bookedHours(events, interval[0], interval[1])
+		}
+		if availableHours > 0 {
+			u.Percent = u.BookedHours / availableHours * 100
+		}
+		result = append(result, u)
+	}
+	return result, nil
+}
+
+func (r calendarReports) MeetingLoad(teams map[string][]int64, window TimeWindow) ([]*MeetingLoad, error) {
+	result := make([]*MeetingLoad, 0, len(teams))
+	for team, userIds := range teams {
+		load := &MeetingLoad{Team: team, Members: int64(len(userIds))}
+		for _, userId := range userIds {
+			s, err := r.c.Stats(userId, window)
+			if err != nil {
+				return nil, err
+			}
+			load.MeetingHours += s.MeetingHours
+		}
+		if load.Members > 0 {
+			load.HoursPerMember = load.MeetingHours / float64(load.Members)
+		}
+		result = append(result, load)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Team < result[j].Team
+	})
+	return result, nil
+}
+
+func (r calendarReports) AfterHoursMeetings(userIds []int64, hours WorkingHours, window TimeWindow) ([]*AfterHours, error) {
+	policies, hasPolicies := capability[AutoResponseStore](r.c.dataStore)
+	result := make([]*AfterHours, 0, len(userIds))
+	for _, userId := range userIds {
+		userHours := hours
+		if hasPolicies {
+			policy, err := policies.GetAutoResponsePolicy(userId)
+			if err != nil {
+				return nil, err
+			}
+			if policy != nil && policy.WorkingHours != nil {
+				userHours = *policy.WorkingHours
+			}
+		}
+		events, err := r.userEvents(userId, window)
+		if err != nil {
+			return nil, err
+		}
+		a := &AfterHours{UserId: userId}
+		for _, e := range events {
+			invites, err := r.c.getInvites(e.Id)
+			if err != nil && err != ErrorInviteListNotSupported {
+				return nil, err
+			}
+			if e.IsAllDay || !isMeeting(e, userId, invites) {
+				continue
+			}
+			a.Meetings++
+			if !userHours.Contains(*e) {
+				a.AfterHours++
+			}
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
+
+// intervals gets the start and end of the working hours on each working day in the window
+func (w WorkingHours) intervals(window TimeWindow) ([][2]time.Time, error) {
+	loc, err := time.LoadLocation(w.Zone)
+	if err != nil {
+		return nil, ErrorInvalidZone
+	}
+	open, err := time.Parse(TimeFormat, w.StartTime)
+	if err != nil {
+		return nil, ErrorInvalidStartTime
+	}
+	closing, err := time.Parse(TimeFormat, w.EndTime)
+	if err != nil {
+		return nil, ErrorInvalidEndTime
+	}
+	var result [][2]time.Time
+	start, end := window.Start.In(loc), window.End.In(loc)
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc); day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !w.DayOfWeek.HasFlag(dayOfWeekFromWeekday(day.Weekday())) {
+			continue
+		}
+		from := time.Date(day.Year(), day.Month(), day.Day(), open.Hour(), open.Minute(), 0, 0, loc)
+		to := time.Date(day.Year(), day.Month(), day.Day(), closing.Hour(), closing.Minute(), 0, 0, loc)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if from.Before(to) {
+			result = append(result, [2]time.Time{from, to})
+		}
+	}
+	return result, nil
+}
+
+// bookedHours gets the hours between the start and end that any of the events are in,
+// where the time that events overlap is only counted once
+func bookedHours(events []*Event, start, end time.Time) float64 {
+	var spans [][2]time.Time
+	for _, e := range events {
+		from, to, err := e.zonedSpan()
+		if err != nil {
+			continue
+		}
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if from.Before(to) {
+			spans = append(spans, [2]time.Time{from, to})
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i][0].Before(spans[j][0])
+	})
+	var total time.Duration
+	var covered time.Time
+	for _, span := range spans {
+		if span[0].Before(covered) {
+			span[0] = covered
+		}
+		if span[0].Before(span[1]) {
+			total += span[1].Sub(span[0])
+			covered = span[1]
+		}
+	}
+	return total.Hours()
+}
@@ -0,0 +1,75 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReports(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	create := func(ownerId int64, startDay, startTime, endTime string, invitees ...int64) {
+		e, _, err := c.Create(Event{OwnerId: ownerId, StartDay: startDay, StartTime: startTime, EndDay: startDay, EndTime: endTime, Zone: "UTC"})
+		require.NoError(t, err)
+		for _, userId := range invitees {
+			require.NoError(t, c.InviteUser(e.Id, userId, PermissionInvitee, RepeatEditTypeThis))
+		}
+	}
+	const room = 10
+	create(1, "2008-01-07", "09:00", "10:00", 2, room)
+	create(3, "2008-01-07", "10:00", "12:00", room)
+	create(1, "2008-01-08", "16:00", "18:00", room)
+	create(3, "2008-01-12", "10:00", "12:00", room)
+	create(1, "2008-01-08", "19:00", "20:00", 2)
+	create(3, "2008-01-09", "09:00", "11:00")
+	require.NoError(t, c.SetAutoResponsePolicy(AutoResponsePolicy{UserId: 2, WorkingHours: &WorkingHours{Zone: "UTC", DayOfWeek: DayOfWeekMonday | DayOfWeekTuesday, StartTime: "19:00", EndTime: "23:00"}}))
+
+	hours := WorkingHours{Zone: "UTC", DayOfWeek: DayOfWeekMonday | DayOfWeekTuesday | DayOfWeekWednesday | DayOfWeekThursday | DayOfWeekFriday, StartTime: "09:00", EndTime: "17:00"}
+	window := TimeWindow{Start: *tt("2008-01-07 00:00"), End: *tt("2008-01-14 00:00")}
+
+	utilization, err := c.Utilization([]int64{room, 11}, hours, window)
+	require.NoError(t, err)
+	assert.Equal(t, []*Utilization{
+		{UserId: room, BookedHours: 4, AvailableHours: 40, Percent: 10},
+		{UserId: 11, AvailableHours: 40},
+	}, utilization)
+
+	load, err := c.MeetingLoad(map[string][]int64{"b": {1, 2}, "a": {3}}, window)
+	require.NoError(t, err)
+	assert.Equal(t, []*MeetingLoad{
+		{Team: "a", Members: 1, MeetingHours: 4, HoursPerMember: 4},
+		{Team: "b", Members: 2, MeetingHours: 6, HoursPerMember: 3},
+	}, load)
+
+	afterHours, err := c.AfterHoursMeetings([]int64{1, 2, 3}, hours, window)
+	require.NoError(t, err)
+	assert.Equal(t, []*AfterHours{
+		{UserId: 1, Meetings: 3, AfterHours: 2},
+		{UserId: 2, Meetings: 2, AfterHours: 1},
+		{UserId: 3, Meetings: 2, AfterHours: 1},
+	}, afterHours)
+
+	_, err = c.Utilization([]int64{room}, WorkingHours{Zone: "UTC"}, window)
+	assert.Equal(t, ErrorInvalidWorkingHours, err)
+}
+
+type testReportBackend struct {
+	calendarReports
+	calls int
+}
+
+func (b *testReportBackend) Utilization(resourceIds []int64, hours WorkingHours, window TimeWindow) ([]*Utilization, error) {
+	b.calls++
+	return []*Utilization{{UserId: resourceIds[0], Percent: 50}}, nil
+}
+
+func TestReportBackend(t *testing.T) {
+	backend := &testReportBackend{}
+	c := NewCalendar(&InMemoryDataStore{}, WithReportBackend(backend))
+	hours := WorkingHours{Zone: "UTC", DayOfWeek: DayOfWeekMonday, StartTime: "09:00", EndTime: "17:00"}
+	utilization, err := c.Utilization([]int64{10}, hours, TimeWindow{Start: *tt("2008-01-07 00:00"), End: *tt("2008-01-14 00:00")})
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.calls)
+	assert.Equal(t, []*Utilization{{UserId: 10, Percent: 50}}, utilization)
+}
@@ -0,0 +1,188 @@
+package cali
+
+import (
+	"time"
+)
+
+// RescheduleOptions changes what Reschedule does besides moving the events
+type RescheduleOptions struct {
+	// ResetResponses sets the confirmed, tentative, and declined invitations of the moved events back to
+	// InviteStatusPending, since the invitees may not be able to make the new time. Otherwise
+	// the RSVPResetPolicy of the calendar decides (see WithRSVPResetPolicy).
+	ResetResponses bool
+}
+
+// rescheduled is an event that was moved by Reschedule and what it was before the move
+type rescheduled struct {
+	before Event
+	reset  []int64
+}
+
+// Reschedule moves the event to the start and end, which are read as wall clock times in the
+// zone of the event. For a series edit the other events move by the same amount and get the
+// same length, like ShiftTime, and all day events only move by whole days. The events are
+// changed in one transaction if the data store implements TxStore, and then the changes are
+// published and the invitees get a NotificationTypeEventRescheduled notification with the
+// event from before the move.
+func (c *Calendar) Reschedule(eventId int64, start, end time.Time, editType RepeatEditType, opts RescheduleOptions) error {
+	return c.outboxTx(func(c *Calendar) error {
+		if !end.After(start) {
+			return ErrorInvalidEndTime
+		}
+		if err := c.authorize(OperationUpdate, eventId, nil); err != nil {
+			return err
+		}
+		if err := c.checkLocks(editType, eventId); err != nil {
+			return err
+		}
+		e, err := c.dataStore.Get(eventId)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrorEventNotFound
+		}
+		oldStart, err := e.Start()
+		if err != nil {
+			return ErrorInvalidStartDay
+		}
+		wallStart := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, time.UTC)
+		delta, duration := wallStart.Sub(oldStart), end.Sub(start)
+
+		var moved []rescheduled
+		err = c.inTx(func(tx *Calendar) error {
+			moved = nil
+			return tx.applyEditBasedOnRepeatEditType(editType, eventId, func(id int64) error {
+				m, ok, err := tx.rescheduleEvent(id, id == eventId, editType, delta, duration, opts)
+				if ok {
+					moved = append(moved, m)
+				}
+				return err
+			})
+		})
+		if err != nil {
+			return err
+		}
+		for _, m := range moved {
+			if err := c.publish(ChangeTypeUpdated, m.before.Id, nil); err != nil {
+				return err
+			}
+			for _, userId := range m.reset {
+				userId := userId
+				if err := c.publish(ChangeTypeInvite, m.before.Id, &userId); err != nil {
+					return err
+				}
+			}
+			if err := c.notifyRescheduled(m.before); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// rescheduleEvent moves a single event of the edit and resets its responses, where other
+// events of a series edit that have their own time are left alone
+func (c *Calendar) rescheduleEvent(eventId int64, edited bool, editType RepeatEditType, delta, duration time.Duration, opts RescheduleOptions) (rescheduled, bool, error) {
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return rescheduled{}, false, err
+	}
+	if e == nil {
+		return rescheduled{}, false, ErrorEventNotFound
+	}
+	if !edited && editType != RepeatEditTypeThis && e.HasOverride(OverrideTime) {
+		return rescheduled{}, false, nil
+	}
+	m := rescheduled{before: *e}
+	shifted, err := ShiftEvent(m.before, delta, duration)
+	if err != nil {
+		return rescheduled{}, false, err
+	}
+	if err := ValidateDayTimeValues(shifted.StartDay, shifted.StartTime, shifted.EndDay, shifted.EndTime, shifted.Zone, shifted.IsAllDay); err != nil {
+		return rescheduled{}, false, err
+	}
+	if err := c.dataStore.SetDayTime(eventId, shifted.StartDay, shifted.StartTime, shifted.EndDay, shifted.EndTime, shifted.Zone, shifted.IsAllDay); err != nil {
+		return rescheduled{}, false, err
+	}
+	if store, ok := capability[OverrideStore](c.dataStore); ok && editType == RepeatEditTypeThis {
+		if err := addOverride(store, m.before, OverrideTime); err != nil {
+			return rescheduled{}, false, err
+		}
+	}
+	var statuses []InviteStatus
+	if opts.ResetResponses {
+		statuses = []InviteStatus{InviteStatusConfirmed, InviteStatusTentative, InviteStatusDeclined}
+	} else if c.rsvpResetPolicy != nil && c.rsvpResetPolicy.Significant(m.before, shifted) {
+		statuses = []InviteStatus{InviteStatusConfirmed}
+	}
+	if len(statuses) > 0 {
+		if m.reset, err = c.resetResponses(m.before, statuses...); err != nil {
+			return rescheduled{}, false, err
+		}
+	}
+	return m, true, nil
+}
+
+// containsInviteStatus reports if the status is one of the statuses
+func containsInviteStatus(statuses []InviteStatus, status InviteStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// resetResponses sets the invitations of the event (other than the owner's) that have one of
+// the statuses back to InviteStatusPending and returns the users that were reset
+func (c *Calendar) resetResponses(e Event, statuses ...InviteStatus) ([]int64, error) {
+	invites, err := c.getInvites(e.Id)
+	if err != nil {
+		return nil, err
+	}
+	var reset []int64
+	for _, invite := range invites {
+		if invite.UserId == e.OwnerId || !containsInviteStatus(statuses, invite.Status) {
+			continue
+		}
+		if err := c.overrideSeriesInvite(e.Id, invite.UserId); err != nil {
+			return nil, err
+		}
+		if err := c.dataStore.SetInviteStatus(e.Id, invite.UserId, InviteStatusPending); err != nil {
+			return nil, err
+		}
+		reset = append(reset, invite.UserId)
+	}
+	return reset, nil
+}
+
+// notifyRescheduled sends a NotificationTypeEventRescheduled notification to the invitees of
+// the event with the event from before it was moved
+func (c *Calendar) notifyRescheduled(before Event) error {
+	if c.notificationSender == nil {
+		return nil
+	}
+	after, err := c.dataStore.Get(before.Id)
+	if err != nil {
+		return err
+	}
+	if after == nil {
+		return ErrorEventNotFound
+	}
+	invites, err := c.getInvites(before.Id)
+	if err != nil {
+		return err
+	}
+	for _, n := range BuildChangeNotifications(before, *after, invites) {
+		n.Type = NotificationTypeEventRescheduled
+		n.Previous = &before
+		if err := c.sendNotification(n); err != nil {
+			return err
+		}
+	}
+	if changes := DiffEvents(before, *after); len(changes) > 0 {
+		return c.notifyFollowers(Notification{Type: NotificationTypeEventRescheduled, Event: *after, Changes: changes, Previous: &before}, invites)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReschedule(t *testing.T) {
+	sender := &testSender{}
+	c := NewCalendar(&InMemoryDataStore{}, WithNotificationSender(sender))
+	e, _, err := c.Create(Event{OwnerId: 1, Title: "Review", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "America/New_York"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.AcceptInvitation(e.Id, 2, RepeatEditTypeThis))
+	changes, stop := c.Watch(10)
+	defer stop()
+	sender.sent = nil
+
+	start := time.Date(2008, 1, 2, 14, 0, 0, 0, time.UTC)
+	assert.Equal(t, ErrorInvalidEndTime, c.Reschedule(e.Id, start, start, RepeatEditTypeThis, RescheduleOptions{}))
+	require.NoError(t, c.Reschedule(e.Id, start, start.Add(30*time.Minute), RepeatEditTypeThis, RescheduleOptions{ResetResponses: true}))
+
+	got, err := c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2008-01-02", "14:00", "2008-01-02", "14:30"}, []string{got.StartDay, got.StartTime, got.EndDay, got.EndTime})
+	invite, err := c.GetInvitation(e.Id, 2)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusPending, invite.Status)
+
+	assert.Equal(t, ChangeTypeUpdated, (<-changes).Type)
+	change := <-changes
+	assert.Equal(t, ChangeTypeInvite, change.Type)
+	assert.Equal(t, int64(2), *change.UserId)
+
+	require.Len(t, sender.sent, 2)
+	for _, n := range sender.sent {
+		assert.Equal(t, NotificationTypeEventRescheduled, n.Type)
+		assert.Equal(t, InviteStatusPending, n.InviteStatus)
+		require.NotNil(t, n.Previous)
+		assert.Equal(t, "09:00", n.Previous.StartTime)
+		assert.Equal(t, "14:00", n.Event.StartTime)
+	}
+
+	content, err := NewTemplateRegistry().Render(sender.sent[0], "en")
+	require.NoError(t, err)
+	assert.Equal(t, "Rescheduled: Review", content.Subject)
+	assert.Contains(t, content.Body, "has moved from")
+}
+
+func TestRescheduleSeries(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	// the last event has its own time, which the series edit doesn't change
+	require.NoError(t, c.UpdateDayTime(events[2].Id, "2008-01-03", "16:00", "2008-01-03", "17:00", "UTC", false))
+
+	start := time.Date(2008, 1, 1, 11, 0, 0, 0, time.UTC)
+	require.NoError(t, c.Reschedule(events[0].Id, start, start.Add(2*time.Hour), RepeatEditTypeAll, RescheduleOptions{}))
+	events, err = c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	var times []string
+	for _, e := range events {
+		times = append(times, e.StartDay+" "+e.StartTime+" - "+e.EndTime)
+	}
+	assert.Equal(t, []string{"2008-01-01 11:00 - 13:00", "2008-01-02 11:00 - 13:00", "2008-01-03 16:00 - 17:00"}, times)
+}
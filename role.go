@@ -0,0 +1,77 @@
+package cali
+
+import (
+	"strings"
+)
+
+// Role is a named set of permissions so that applications don't have to build the
+// permission bitmasks themselves
+type Role string
+
+const (
+	// RoleOwner can do everything to the event
+	RoleOwner Role = "owner"
+	// RoleEditor can read, invite, and modify the event, but can't cancel or delete it
+	RoleEditor Role = "editor"
+	// RoleInviter can read the event and invite other users
+	RoleInviter Role = "inviter"
+	// RoleViewer can only read the event
+	RoleViewer Role = "viewer"
+)
+
+const (
+	PermissionEditor  = PermissionModify | PermissionInvite | PermissionRead
+	PermissionInviter = PermissionInvite | PermissionRead
+	PermissionViewer  = PermissionRead
+)
+
+// Roles are all of the roles from the most permissions to the least
+var Roles = []Role{RoleOwner, RoleEditor, RoleInviter, RoleViewer}
+
+var rolePermissions = map[Role]Permission{
+	RoleOwner:   PermissionOwner,
+	RoleEditor:  PermissionEditor,
+	RoleInviter: PermissionInviter,
+	RoleViewer:  PermissionViewer,
+}
+
+// Permission gets the permission bitmask of the role, or 0 if it isn't a valid role
+func (r Role) Permission() Permission {
+	return rolePermissions[r]
+}
+
+// ValidRole returns true if the role is one of the pre-defined roles from this library
+func ValidRole(r Role) bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// ParseRole finds the role by name ignoring case
+func ParseRole(s string) (Role, error) {
+	r := Role(strings.ToLower(strings.TrimSpace(s)))
+	if !ValidRole(r) {
+		return "", ErrorInvalidRole
+	}
+	return r, nil
+}
+
+// RoleFromPermission finds the role with the most permissions that the permission
+// bitmask has all of the flags for. It returns true if the bitmask is exactly the
+// role, and false if it has extra flags (or is not valid and has no role at all).
+func RoleFromPermission(p Permission) (Role, bool) {
+	for _, r := range Roles {
+		rp := r.Permission()
+		if p&rp == rp {
+			return r, p == rp
+		}
+	}
+	return "", false
+}
+
+// InviteUserWithRole creates a pending invitation for a user on an event with the permissions of the role
+func (c *Calendar) InviteUserWithRole(eventId int64, userId int64, role Role, editType RepeatEditType) error {
+	if !ValidRole(role) {
+		return ErrorInvalidRole
+	}
+	return c.InviteUser(eventId, userId, role.Permission(), editType)
+}
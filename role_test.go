@@ -0,0 +1,45 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoles(t *testing.T) {
+	for _, r := range Roles {
+		assert.NoError(t, ValidatePermission(r.Permission()), r)
+		role, exact := RoleFromPermission(r.Permission())
+		assert.Equal(t, r, role)
+		assert.True(t, exact)
+	}
+
+	role, exact := RoleFromPermission(PermissionRead | PermissionInvite | PermissionModify | PermissionCancel)
+	assert.Equal(t, RoleEditor, role)
+	assert.False(t, exact)
+
+	role, exact = RoleFromPermission(PermissionModify)
+	assert.Equal(t, Role(""), role)
+	assert.False(t, exact)
+
+	r, err := ParseRole(" Editor")
+	require.NoError(t, err)
+	assert.Equal(t, RoleEditor, r)
+	_, err = ParseRole("admin")
+	assert.Equal(t, ErrorInvalidRole, err)
+	assert.Equal(t, Permission(0), Role("admin").Permission())
+}
+
+func TestInviteUserWithRole(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	a, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+
+	require.NoError(t, c.InviteUserWithRole(a.Id, 7, RoleEditor, RepeatEditTypeThis))
+	invite, err := c.GetInvitation(a.Id, 7)
+	require.NoError(t, err)
+	assert.Equal(t, Permission(PermissionEditor), invite.Permission)
+
+	assert.Equal(t, ErrorInvalidRole, c.InviteUserWithRole(a.Id, 8, "admin", RepeatEditTypeThis))
+}
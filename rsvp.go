@@ -0,0 +1,99 @@
+package cali
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultRSVPTokenTTL is how long an RSVP token is good for if no ttl is given to WithRSVPTokens
+const DefaultRSVPTokenTTL = 14 * 24 * time.Hour
+
+// WithRSVPTokens allows RSVP tokens to be made with RSVPToken. The tokens are signed with the
+// key (which should be random and at least 32 bytes) and expire after the ttl.
+func WithRSVPTokens(key []byte, ttl time.Duration) CalendarOption {
+	return func(c *Calendar) {
+		if ttl <= 0 {
+			ttl = DefaultRSVPTokenTTL
+		}
+		c.rsvpKey = key
+		c.rsvpTTL = ttl
+	}
+}
+
+// RSVPToken makes a signed token for the user's invitation to the event that can be put in a
+// link in an email, so that the user can accept or decline without logging in. The token can
+// only be redeemed once, and any change to the invitation makes the token invalid.
+func (c *Calendar) RSVPToken(eventId int64, userId int64) (string, error) {
+	if len(c.rsvpKey) == 0 {
+		return "", ErrorRSVPTokensNotConfigured
+	}
+	invite, err := c.GetInvitation(eventId, userId)
+	if err != nil {
+		return "", err
+	}
+	if invite == nil {
+		return "", ErrorInviteNotFound
+	}
+	expires := time.Now().Add(c.rsvpTTL).Unix()
+	payload := fmt.Sprintf("%d.%d.%d.%d", eventId, userId, expires, invite.Updated.UnixNano())
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + c.signRSVP(payload), nil
+}
+
+// RedeemRSVPToken sets the status of the invitation in the token to InviteStatusConfirmed
+// or InviteStatusDeclined and returns the updated invitation
+func (c *Calendar) RedeemRSVPToken(token string, status InviteStatus) (*Invite, error) {
+	if len(c.rsvpKey) == 0 {
+		return nil, ErrorRSVPTokensNotConfigured
+	}
+	if status != InviteStatusConfirmed && status != InviteStatusDeclined {
+		return nil, ErrorInvalidInviteStatus
+	}
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrorInvalidRSVPToken
+	}
+	b, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrorInvalidRSVPToken
+	}
+	payload := string(b)
+	if !hmac.Equal([]byte(signature), []byte(c.signRSVP(payload))) {
+		return nil, ErrorInvalidRSVPToken
+	}
+	var eventId, userId, expires, updated int64
+	if _, err := fmt.Sscanf(payload, "%d.%d.%d.%d", &eventId, &userId, &expires, &updated); err != nil {
+		return nil, ErrorInvalidRSVPToken
+	}
+	if time.Now().Unix() > expires {
+		return nil, ErrorRSVPTokenExpired
+	}
+
+	invite, err := c.GetInvitation(eventId, userId)
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil {
+		return nil, ErrorInviteNotFound
+	}
+	if invite.Updated.UnixNano() != updated {
+		return nil, ErrorRSVPTokenUsed
+	}
+	if invite.Status == InviteStatusRevoked {
+		return nil, ErrorInvalidInviteStatus
+	}
+	if err := c.setInviteStatus(eventId, userId, status, RepeatEditTypeThis); err != nil {
+		return nil, err
+	}
+	return c.GetInvitation(eventId, userId)
+}
+
+// signRSVP makes the base64 HMAC signature of the payload
+func (c *Calendar) signRSVP(payload string) string {
+	mac := hmac.New(sha256.New, c.rsvpKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,79 @@
+package cali
+
+import (
+	"time"
+)
+
+// RSVPResetPolicy decides which edits of an event are big enough that the invitees who
+// confirmed may not be able to make it anymore, so their invitations go back to
+// InviteStatusPending (see WithRSVPResetPolicy)
+type RSVPResetPolicy struct {
+	// MinTimeChange resets the responses when the start or the end of the event moves by more
+	// than the duration, where zero doesn't reset for time changes
+	MinTimeChange time.Duration
+	// Location resets the responses when the location changes
+	Location bool
+}
+
+// WithRSVPResetPolicy sets the confirmed invitations of an event back to pending when it is
+// edited in a way that the policy finds significant. The policy is checked for every edited
+// event, so series edits reset the responses across the series.
+func WithRSVPResetPolicy(policy RSVPResetPolicy) CalendarOption {
+	return func(c *Calendar) {
+		c.rsvpResetPolicy = &policy
+	}
+}
+
+// Significant reports if the change of the event should reset the responses to it
+func (p RSVPResetPolicy) Significant(before, after Event) bool {
+	if p.Location && !equalOptional(before.Location, after.Location) {
+		return true
+	}
+	if p.MinTimeChange <= 0 {
+		return false
+	}
+	beforeStart, beforeEnd, err := before.zonedSpan()
+	if err != nil {
+		return false
+	}
+	afterStart, afterEnd, err := after.zonedSpan()
+	if err != nil {
+		return false
+	}
+	return absDuration(afterStart.Sub(beforeStart)) > p.MinTimeChange || absDuration(afterEnd.Sub(beforeEnd)) > p.MinTimeChange
+}
+
+// resetForPolicy resets the confirmed invitations of the event if the calendar's policy finds
+// the change significant, and publishes a ChangeTypeInvite change for each of them
+func (c *Calendar) resetForPolicy(before, after Event) error {
+	if c.rsvpResetPolicy == nil || !c.rsvpResetPolicy.Significant(before, after) {
+		return nil
+	}
+	reset, err := c.resetResponses(after, InviteStatusConfirmed)
+	if err != nil {
+		return err
+	}
+	for _, userId := range reset {
+		userId := userId
+		if err := c.publish(ChangeTypeInvite, after.Id, &userId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// equalOptional reports if the optional strings are both nil or have the same value
+func equalOptional(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// absDuration gets the length of the duration without its sign
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,82 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRSVPResetPolicySignificant(t *testing.T) {
+	office, cafe := "Office", "Cafe"
+	base := Event{StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC", Location: &office}
+	moved := func(startTime, endTime string) Event {
+		e := base
+		e.StartTime, e.EndTime = startTime, endTime
+		return e
+	}
+	relocated := base
+	relocated.Location = &cafe
+	rezoned := base
+	rezoned.Zone = "America/New_York"
+	policy := RSVPResetPolicy{MinTimeChange: 30 * time.Minute, Location: true}
+
+	tests := []struct {
+		name     string
+		policy   RSVPResetPolicy
+		after    Event
+		expected bool
+	}{
+		{name: "unchanged", policy: policy, after: base, expected: false},
+		{name: "small move", policy: policy, after: moved("09:15", "10:15"), expected: false},
+		{name: "exactly the minimum", policy: policy, after: moved("09:30", "10:30"), expected: false},
+		{name: "big move", policy: policy, after: moved("10:00", "11:00"), expected: true},
+		{name: "longer", policy: policy, after: moved("09:00", "11:00"), expected: true},
+		{name: "other zone", policy: policy, after: rezoned, expected: true},
+		{name: "location", policy: policy, after: relocated, expected: true},
+		{name: "location ignored", policy: RSVPResetPolicy{MinTimeChange: time.Hour}, after: relocated, expected: false},
+		{name: "time ignored", policy: RSVPResetPolicy{Location: true}, after: moved("15:00", "16:00"), expected: false},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			assert.Equal(t, tc.expected, tc.policy.Significant(base, tc.after))
+		})
+	}
+}
+
+func TestRSVPResetPolicyAcrossSeries(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithRSVPResetPolicy(RSVPResetPolicy{MinTimeChange: 30 * time.Minute}))
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeAll))
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionInvitee, RepeatEditTypeAll))
+	require.NoError(t, c.AcceptInvitation(e.Id, 2, RepeatEditTypeAll))
+	require.NoError(t, c.DeclineInvitation(e.Id, 3, RepeatEditTypeAll))
+	events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	statuses := func(userId int64) []InviteStatus {
+		var result []InviteStatus
+		for _, e := range events {
+			invite, err := c.GetInvitation(e.Id, userId)
+			require.NoError(t, err)
+			result = append(result, invite.Status)
+		}
+		return result
+	}
+
+	// a small change keeps the responses
+	require.NoError(t, c.UpdateTime(e.Id, "09:15", "10:15", RepeatEditTypeAll))
+	assert.Equal(t, []InviteStatus{InviteStatusConfirmed, InviteStatusConfirmed, InviteStatusConfirmed}, statuses(2))
+
+	// a big change resets the confirmed responses and leaves the declined ones
+	require.NoError(t, c.UpdateTime(e.Id, "13:00", "14:00", RepeatEditTypeAll))
+	assert.Equal(t, []InviteStatus{InviteStatusPending, InviteStatusPending, InviteStatusPending}, statuses(2))
+	assert.Equal(t, []InviteStatus{InviteStatusDeclined, InviteStatusDeclined, InviteStatusDeclined}, statuses(3))
+}
@@ -0,0 +1,110 @@
+package cali
+
+import (
+	"sort"
+)
+
+// RSVPSummary is the number of users and the users with each response to the invitations of an
+// event, like for the attendee list that the organizer sees. The owner of the event and revoked
+// invitations aren't counted.
+type RSVPSummary struct {
+	// EventId is the event that the summary is for
+	EventId int64 `json:"eventId"`
+	// Confirmed is the number of users that accepted
+	Confirmed int64 `json:"confirmed"`
+	// Pending is the number of users that haven't responded
+	Pending int64 `json:"pending"`
+	// Tentative is the number of users that might attend
+	Tentative int64 `json:"tentative"`
+	// Waitlisted is the number of users that are waiting for room
+	Waitlisted int64 `json:"waitlisted"`
+	// Declined is the number of users that declined
+	Declined int64 `json:"declined"`
+	// ConfirmedUserIds are the users that accepted, sorted by user id
+	ConfirmedUserIds []int64 `json:"confirmedUserIds"`
+	// PendingUserIds are the users that haven't responded, sorted by user id
+	PendingUserIds []int64 `json:"pendingUserIds"`
+	// TentativeUserIds are the users that might attend, sorted by user id
+	TentativeUserIds []int64 `json:"tentativeUserIds"`
+	// WaitlistedUserIds are the users that are waiting for room, sorted by user id
+	WaitlistedUserIds []int64 `json:"waitlistedUserIds"`
+	// DeclinedUserIds are the users that declined, sorted by user id
+	DeclinedUserIds []int64 `json:"declinedUserIds"`
+}
+
+// RSVPSummaryStore is an optional interface for a data store that can total up the responses to
+// the invitations of an event in one round trip, instead of the calendar reading the event, its
+// invites, and the series invites of its series one at a time
+type RSVPSummaryStore interface {
+	// RSVPSummary totals up the responses of the invite of every user on the event, where users
+	// without an invite to the event itself are counted by their series invite
+	RSVPSummary(eventId int64) (*RSVPSummary, error)
+}
+
+// RSVPSummary totals up the responses to the invitations of the event, using the data store if it
+// implements RSVPSummaryStore. Otherwise the data store must implement InviteListStore.
+func (c *Calendar) RSVPSummary(eventId int64) (*RSVPSummary, error) {
+	if store, ok := capability[RSVPSummaryStore](c.dataStore); ok {
+		return store.RSVPSummary(eventId)
+	}
+	return computeRSVPSummary(c.dataStore, eventId)
+}
+
+// computeRSVPSummary totals up the responses by reading the invites of the event
+func computeRSVPSummary(store DataStore, eventId int64) (*RSVPSummary, error) {
+	e, err := store.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	invites, err := storeInvites(store, eventId)
+	if err != nil {
+		return nil, err
+	}
+	return newRSVPSummary(*e, invites), nil
+}
+
+// newRSVPSummary totals up the invites of the event other than the owner's, where only the
+// first invite of each user is counted
+func newRSVPSummary(e Event, invites []*Invite) *RSVPSummary {
+	s := &RSVPSummary{
+		EventId:           e.Id,
+		ConfirmedUserIds:  []int64{},
+		PendingUserIds:    []int64{},
+		TentativeUserIds:  []int64{},
+		WaitlistedUserIds: []int64{},
+		DeclinedUserIds:   []int64{},
+	}
+	seen := map[int64]bool{}
+	for _, invite := range invites {
+		if invite.UserId == e.OwnerId || seen[invite.UserId] {
+			continue
+		}
+		seen[invite.UserId] = true
+		switch invite.Status {
+		case InviteStatusConfirmed:
+			s.Confirmed++
+			s.ConfirmedUserIds = append(s.ConfirmedUserIds, invite.UserId)
+		case InviteStatusPending:
+			s.Pending++
+			s.PendingUserIds = append(s.PendingUserIds, invite.UserId)
+		case InviteStatusTentative:
+			s.Tentative++
+			s.TentativeUserIds = append(s.TentativeUserIds, invite.UserId)
+		case InviteStatusWaitlisted:
+			s.Waitlisted++
+			s.WaitlistedUserIds = append(s.WaitlistedUserIds, invite.UserId)
+		case InviteStatusDeclined:
+			s.Declined++
+			s.DeclinedUserIds = append(s.DeclinedUserIds, invite.UserId)
+		}
+	}
+	for _, userIds := range [][]int64{s.ConfirmedUserIds, s.PendingUserIds, s.TentativeUserIds, s.WaitlistedUserIds, s.DeclinedUserIds} {
+		sort.Slice(userIds, func(i, j int) bool {
+			return userIds[i] < userIds[j]
+		})
+	}
+	return s
+}
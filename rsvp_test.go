@@ -0,0 +1,114 @@
+package cali
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRSVPToken(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	c := NewCalendar(&InMemoryDataStore{}, WithRSVPTokens(key, time.Hour))
+	a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeThis))
+
+	_, err = c.RSVPToken(a.Id, 8)
+	assert.Equal(t, ErrorInviteNotFound, err)
+
+	token, err := c.RSVPToken(a.Id, 7)
+	require.NoError(t, err)
+
+	_, err = c.RedeemRSVPToken(token, InviteStatusRevoked)
+	assert.Equal(t, ErrorInvalidInviteStatus, err)
+	_, err = c.RedeemRSVPToken(strings.Replace(token, ".", "x.", 1), InviteStatusConfirmed)
+	assert.Equal(t, ErrorInvalidRSVPToken, err)
+	_, err = NewCalendar(&InMemoryDataStore{}, WithRSVPTokens([]byte("other"), 0)).RedeemRSVPToken(token, InviteStatusConfirmed)
+	assert.Equal(t, ErrorInvalidRSVPToken, err)
+
+	invite, err := c.RedeemRSVPToken(token, InviteStatusDeclined)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusDeclined, invite.Status)
+
+	// tokens can only be used once
+	_, err = c.RedeemRSVPToken(token, InviteStatusConfirmed)
+	assert.Equal(t, ErrorRSVPTokenUsed, err)
+
+	// a new token can change the response
+	token, err = c.RSVPToken(a.Id, 7)
+	require.NoError(t, err)
+	invite, err = c.RedeemRSVPToken(token, InviteStatusConfirmed)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusConfirmed, invite.Status)
+}
+
+func TestRSVPTokenExpired(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithRSVPTokens([]byte("key"), -time.Hour))
+	a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeThis))
+
+	c.rsvpTTL = -time.Hour
+	token, err := c.RSVPToken(a.Id, 7)
+	require.NoError(t, err)
+	_, err = c.RedeemRSVPToken(token, InviteStatusConfirmed)
+	assert.Equal(t, ErrorRSVPTokenExpired, err)
+
+	_, err = NewCalendar(&InMemoryDataStore{}).RSVPToken(a.Id, 7)
+	assert.Equal(t, ErrorRSVPTokensNotConfigured, err)
+}
+
+func TestRSVPSummary(t *testing.T) {
+	stores := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+	}
+	for _, tc := range stores {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store)
+			e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+				IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+			require.NoError(t, err)
+			require.NoError(t, c.InviteUser(e.Id, 4, PermissionInvitee, RepeatEditTypeAll))
+			for _, userId := range []int64{2, 3, 5, 6, 7, 8, 9} {
+				require.NoError(t, c.InviteUser(e.Id, userId, PermissionInvitee, RepeatEditTypeThis))
+			}
+			require.NoError(t, c.AcceptInvitation(e.Id, 3, RepeatEditTypeThis))
+			require.NoError(t, c.AcceptInvitation(e.Id, 2, RepeatEditTypeThis))
+			require.NoError(t, c.DeclineInvitation(e.Id, 5, RepeatEditTypeThis))
+			require.NoError(t, c.RevokeInvitation(e.Id, 6, RepeatEditTypeThis))
+			require.NoError(t, c.TentativelyAcceptInvitation(e.Id, 9, RepeatEditTypeThis))
+			require.NoError(t, c.TentativelyAcceptInvitation(e.Id, 7, RepeatEditTypeThis))
+			require.NoError(t, c.WaitlistInvitation(e.Id, 8, RepeatEditTypeThis))
+			// the invite to this event overrides the series invite
+			require.NoError(t, c.DeclineInvitation(e.Id, 4, RepeatEditTypeThis))
+
+			s, err := c.RSVPSummary(e.Id)
+			require.NoError(t, err)
+			assert.Equal(t, &RSVPSummary{
+				EventId:           e.Id,
+				Confirmed:         2,
+				Tentative:         2,
+				Waitlisted:        1,
+				Declined:          2,
+				ConfirmedUserIds:  []int64{2, 3},
+				PendingUserIds:    []int64{},
+				TentativeUserIds:  []int64{7, 9},
+				WaitlistedUserIds: []int64{8},
+				DeclinedUserIds:   []int64{4, 5},
+			}, s)
+
+			_, err = c.RSVPSummary(e.Id + 1000)
+			assert.Equal(t, ErrorEventNotFound, err)
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package cali
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDraft is the JSON Schema version of the generated schemas
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document (or sub-schema) generated from the model
+// so that client SDKs in other languages can be generated against it
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+}
+
+// schemaEnums are the known values for the enumeration types of the model
+var schemaEnums = map[reflect.Type][]interface{}{
+	reflect.TypeOf(Status(0)):         {StatusActive, StatusCanceled, StatusAbandoned, StatusRemoved, StatusPendingApproval},
+	reflect.TypeOf(InviteStatus(0)):   {InviteStatusPending, InviteStatusConfirmed, InviteStatusTentative, InviteStatusWaitlisted, InviteStatusDeclined, InviteStatusRevoked},
+	reflect.TypeOf(RepeatType(0)):     {RepeatTypeDaily, RepeatTypeWeekly, RepeatTypeMonthly, RepeatTypeYearly, RepeatTypeWeekdays, RepeatTypeCustom},
+	reflect.TypeOf(RepeatEditType(0)): {RepeatEditTypeThis, RepeatEditTypeAll, RepeatEditTypeThisAndAfter},
+	reflect.TypeOf(CalendarSystem(0)): {CalendarSystemGregorian, CalendarSystemHebrew, CalendarSystemIslamic, CalendarSystemChinese},
+	reflect.TypeOf(LeapDayPolicy(0)):  {LeapDayMarch1, LeapDayFebruary28},
+	reflect.TypeOf(Visibility(0)):     {VisibilityPrivate, VisibilityPublic},
+}
+
+// schemaFormats are the string formats of the day and time fields since they
+// can't be found through reflection
+var schemaFormats = map[string]string{
+	"startDay":  "date",
+	"endDay":    "date",
+	"StartDay":  "date",
+	"EndDay":    "date",
+	"startTime": "HH:mm",
+	"endTime":   "HH:mm",
+	"StartTime": "HH:mm",
+	"EndTime":   "HH:mm",
+}
+
+// JSONSchemas are the schemas of the main model objects keyed by name
+func JSONSchemas() map[string]*Schema {
+	return map[string]*Schema{
+		"Event":  GenerateJSONSchema(Event{}),
+		"Invite": GenerateJSONSchema(Invite{}),
+		"Repeat": GenerateJSONSchema(Repeat{}),
+		"Query":  GenerateJSONSchema(Query{}),
+	}
+}
+
+// OpenAPIComponents is an OpenAPI 3.1 document that only has the model schemas
+// under components, which an HTTP layer can merge into its own document
+func OpenAPIComponents(title, version string) map[string]interface{} {
+	schemas := map[string]*Schema{}
+	for name, s := range JSONSchemas() {
+		s.Schema = ""
+		schemas[name] = s
+	}
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":   title,
+			"version": version,
+		},
+		"paths": map[string]interface{}{},
+		"components": map[string]interface{}{
+			"schemas": schemas,
+		},
+	}
+}
+
+// GenerateJSONSchema uses reflection and the json struct tags of the value's type to build a schema
+func GenerateJSONSchema(v interface{}) *Schema {
+	t := reflect.TypeOf(v)
+	s := schemaForType(t, "")
+	s.Schema = JSONSchemaDraft
+	s.Title = t.Name()
+	return s
+}
+
+func schemaForType(t reflect.Type, field string) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	s := &Schema{}
+	if enum, ok := schemaEnums[t]; ok {
+		s.Enum = enum
+	}
+
+	var typ string
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		typ = "string"
+		s.Format = "date-time"
+	case t.Kind() == reflect.Struct:
+		typ = "object"
+		s.Properties = map[string]*Schema{}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, omitEmpty, skip := jsonFieldName(f)
+			if skip {
+				continue
+			}
+			s.Properties[name] = schemaForType(f.Type, name)
+			if f.Type.Kind() != reflect.Pointer && f.Type.Kind() != reflect.Map && f.Type.Kind() != reflect.Slice && !omitEmpty {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.AdditionalProperties = false
+	case t.Kind() == reflect.Map:
+		typ = "object"
+		if t.Elem().Kind() == reflect.Interface {
+			s.AdditionalProperties = true
+		} else {
+			s.AdditionalProperties = schemaForType(t.Elem(), "")
+		}
+		nullable = true
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		typ = "array"
+		s.Items = schemaForType(t.Elem(), "")
+		nullable = t.Kind() == reflect.Slice
+	case t.Kind() == reflect.String:
+		typ = "string"
+		s.Format = schemaFormats[field]
+	case t.Kind() == reflect.Bool:
+		typ = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		typ = "integer"
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		typ = "integer"
+		zero := float64(0)
+		s.Minimum = &zero
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		typ = "number"
+	}
+
+	if typ != "" && nullable {
+		s.Type = []string{typ, "null"}
+	} else if typ != "" {
+		s.Type = typ
+	}
+	return s
+}
+
+// jsonFieldName follows the same rules as encoding/json to find the name of the field
+func jsonFieldName(f reflect.StructField) (string, bool, bool) {
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = f.Name
+	}
+	omitEmpty := false
+	for _, p := range parts[1:] {
+		if p == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
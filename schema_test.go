@@ -0,0 +1,51 @@
+package cali
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	s := GenerateJSONSchema(Event{})
+	assert.Equal(t, JSONSchemaDraft, s.Schema)
+	assert.Equal(t, "Event", s.Title)
+	assert.Equal(t, "object", s.Type)
+
+	require.Contains(t, s.Properties, "startDay")
+	assert.Equal(t, "string", s.Properties["startDay"].Type)
+	assert.Equal(t, "date", s.Properties["startDay"].Format)
+
+	require.Contains(t, s.Properties, "description")
+	assert.Equal(t, []string{"string", "null"}, s.Properties["description"].Type)
+	assert.NotContains(t, s.Required, "description")
+	assert.Contains(t, s.Required, "title")
+
+	require.Contains(t, s.Properties, "status")
+	assert.Equal(t, []interface{}{StatusActive, StatusCanceled, StatusAbandoned, StatusRemoved, StatusPendingApproval}, s.Properties["status"].Enum)
+
+	require.Contains(t, s.Properties, "repeat")
+	repeat := s.Properties["repeat"]
+	assert.Equal(t, []string{"object", "null"}, repeat.Type)
+	require.Contains(t, repeat.Properties, "repeatStopDate")
+	assert.Equal(t, "date-time", repeat.Properties["repeatStopDate"].Format)
+
+	require.Contains(t, s.Properties, "userData")
+	assert.Equal(t, true, s.Properties["userData"].AdditionalProperties)
+}
+
+func TestJSONSchemas(t *testing.T) {
+	schemas := JSONSchemas()
+	assert.Len(t, schemas, 4)
+	// fields without json tags use the go field name
+	assert.Contains(t, schemas["Invite"].Properties, "EventId")
+	assert.Equal(t, []string{"array", "null"}, schemas["Query"].Properties["UserIds"].Type)
+
+	doc := OpenAPIComponents("cali", "1.0.0")
+	b, err := json.Marshal(doc)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), `"openapi":"3.1.0"`)
+	assert.NotContains(t, string(b), "$schema")
+}
@@ -0,0 +1,183 @@
+package cali
+
+import (
+	"time"
+)
+
+// InviteListStore is an optional interface for a data store that can list every invite of an event
+type InviteListStore interface {
+	// GetInvites retrieves all of the invites for the event (but not the series invites)
+	GetInvites(eventId int64) ([]*Invite, error)
+}
+
+// InviteCarryOver decides how an invite on the last event of a series is copied onto the
+// new events when the series is extended. It returns the invite to add and false if the
+// invite should not be copied at all.
+type InviteCarryOver func(invite Invite) (Invite, bool)
+
+// WithInviteCarryOver sets how invites are copied onto new events when a series is extended.
+// By default, every invite is copied with the same status and permission.
+func WithInviteCarryOver(carryOver InviteCarryOver) CalendarOption {
+	return func(c *Calendar) {
+		c.inviteCarryOver = carryOver
+	}
+}
+
+// ResetDeclinedInvites is an InviteCarryOver that asks users who declined to respond again
+// by setting their invites back to pending, and doesn't copy revoked invites.
+func ResetDeclinedInvites(invite Invite) (Invite, bool) {
+	switch invite.Status {
+	case InviteStatusRevoked:
+		return invite, false
+	case InviteStatusDeclined:
+		invite.Status = InviteStatusPending
+	}
+	return invite, true
+}
+
+// ExtendSeries creates the events of the repeating series that the event is a part of using the
+// new repeat (which usually has more occurrences or a later stop date). Only the events that
+// start after the last event of the series are created, and they are copies of the last event.
+// The invites of the last event are copied onto each new event (see WithInviteCarryOver), and
+// series invites already cover the new events.
+func (c *Calendar) ExtendSeries(eventId int64, repeat Repeat) ([]*Event, error) {
+	var created []*Event
+	err := c.outboxTx(func(c *Calendar) error {
+		var err error
+		created, err = c.extendSeries(eventId, repeat)
+		return err
+	})
+	return created, err
+}
+
+// extendSeries is ExtendSeries in the outbox transaction (see outboxTx)
+func (c *Calendar) extendSeries(eventId int64, repeat Repeat) ([]*Event, error) {
+	lister, ok := capability[InviteListStore](c.dataStore)
+	if !ok {
+		return nil, ErrorInviteListNotSupported
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	if !e.IsRepeating || e.ParentId == nil {
+		return nil, ErrorNotRepeatingEvent
+	}
+
+	existing, err := c.getAllRepeatingEvents(*e)
+	if err != nil {
+		return nil, err
+	}
+	var first, last *Event
+	for _, event := range existing {
+		if first == nil || event.StartDay < first.StartDay {
+			first = event
+		}
+		if last == nil || event.StartDay > last.StartDay {
+			last = event
+		}
+	}
+	if first == nil {
+		return nil, ErrorEventNotFound
+	}
+
+	template := *first
+	template.Repeat = &repeat
+	generated, err := GenerateRepeatEvents(template)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := lister.GetInvites(last.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*Event
+	for _, g := range generated {
+		if g.StartDay <= last.StartDay {
+			continue
+		}
+		next := *last
+		next.Id = 0
+		next.ExternalKey = ""
+		next.Overrides = nil
+		next.RecurrenceId = ""
+		next.Repeat = &repeat
+		next.StartDay = g.StartDay
+		next.EndDay = g.EndDay
+		next.AnniversaryYears = g.AnniversaryYears
+		next.Status = StatusActive
+		if err := Validate(next); err != nil {
+			return nil, err
+		}
+		newEvent, err := c.dataStore.Create(next)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.carryOverInvites(newEvent.Id, invites); err != nil {
+			return nil, err
+		}
+		if err := c.publish(ChangeTypeCreated, newEvent.Id, nil); err != nil {
+			return nil, err
+		}
+		results = append(results, newEvent)
+	}
+	if store, ok := capability[SeriesStore](c.dataStore); ok && c.seriesRecords {
+		series, err := store.GetSeries([]int64{*e.ParentId})
+		if err != nil {
+			return nil, err
+		}
+		if len(series) > 0 {
+			series[0].Repeat = repeat
+			if err := store.SetSeries(*series[0]); err != nil {
+				return nil, err
+			}
+		}
+		return c.withSeries(results)
+	}
+	return results, nil
+}
+
+// carryOverInvites copies the invites onto the new event
+func (c *Calendar) carryOverInvites(eventId int64, invites []*Invite) error {
+	now := time.Now()
+	for _, invite := range invites {
+		i := *invite
+		keep := true
+		if c.inviteCarryOver != nil {
+			i, keep = c.inviteCarryOver(i)
+		}
+		if !keep {
+			continue
+		}
+		current, err := c.dataStore.GetInvite(eventId, i.UserId)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			// the owner invite is already added when the event is created
+			continue
+		}
+		i.EventId = eventId
+		i.IsSeries = false
+		i.Created = now
+		i.Updated = now
+		// the status is set afterwards since a new invite can't be revoked
+		status := i.Status
+		if status == InviteStatusRevoked {
+			i.Status = InviteStatusPending
+		}
+		if _, err := c.dataStore.AddInvite(i); err != nil {
+			return err
+		}
+		if status != i.Status {
+			if err := c.dataStore.SetInviteStatus(eventId, i.UserId, status); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package cali
+
+import (
+	"time"
+)
+
+// InviteUserToSeries creates a single pending invitation for a user on every event of the
+// repeating series that the event is a part of, including events that are added to the
+// series later. Edits with RepeatEditTypeAll change the series invite, while edits to
+// single events create an invite for just that event which overrides the series invite.
+func (c *Calendar) InviteUserToSeries(eventId int64, userId int64, permission Permission) error {
+	return c.outboxTx(func(c *Calendar) error {
+		store, ok := capability[SeriesInviteStore](c.dataStore)
+		if !ok {
+			return ErrorSeriesInviteNotSupported
+		}
+		if err := c.authorize(OperationInvite, eventId, &userId); err != nil {
+			return err
+		}
+		if err := c.checkIfMatch(eventId); err != nil {
+			return err
+		}
+		e, err := c.dataStore.Get(eventId)
+		if err != nil {
+			return err
+		}
+		if e == nil {
+			return ErrorEventNotFound
+		}
+		if !e.IsRepeating || e.ParentId == nil {
+			return ErrorNotRepeatingEvent
+		}
+
+		i := Invite{
+			EventId:    *e.ParentId,
+			UserId:     userId,
+			Status:     InviteStatusPending,
+			Permission: permission,
+			IsSeries:   true,
+			Created:    time.Now(),
+		}
+		i.Updated = i.Created
+		if err := ValidateInvite(i); err != nil {
+			return err
+		}
+		if err := c.checkInvitePolicies(eventId, i); err != nil {
+			return err
+		}
+		if _, err := store.AddSeriesInvite(i); err != nil {
+			return err
+		}
+
+		events, err := c.getAllRepeatingEvents(*e)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := c.applyAutoResponse(event.Id, userId); err != nil {
+				return err
+			}
+			if err := c.publish(ChangeTypeInvite, event.Id, &userId); err != nil {
+				return err
+			}
+		}
+		return c.notifyInvited(eventId, userId)
+	})
+}
+
+// getSeriesInvite finds the series invite for the user on the series of the event, or nil
+// if the data store doesn't support series invites or there is no series invite
+func (c *Calendar) getSeriesInvite(eventId int64, userId int64) (*Invite, error) {
+	store, ok := capability[SeriesInviteStore](c.dataStore)
+	if !ok {
+		return nil, nil
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil || e == nil || e.ParentId == nil {
+		return nil, err
+	}
+	return store.GetSeriesInvite(*e.ParentId, userId)
+}
+
+// overrideSeriesInvite copies the series invite of the user onto the single event
+// so that it can be changed without changing the rest of the series
+func (c *Calendar) overrideSeriesInvite(eventId int64, userId int64) error {
+	invite, err := c.dataStore.GetInvite(eventId, userId)
+	if err != nil || invite != nil {
+		return err
+	}
+	series, err := c.getSeriesInvite(eventId, userId)
+	if err != nil || series == nil {
+		return err
+	}
+	override := *series
+	override.EventId = eventId
+	override.IsSeries = false
+	_, err = c.dataStore.AddInvite(override)
+	return err
+}
+
+// editInviteOrSeries applies an invite edit for the user. When the user has a series invite
+// and the edit type is RepeatEditTypeAll, then setSeries changes the series invite and f is
+// only applied to the events that override it. Otherwise, f is applied to each event after
+// the series invite is copied onto the event. A nil setSeries always applies f to each event.
+func (c *Calendar) editInviteOrSeries(op Operation, editType RepeatEditType, eventId int64, userId int64, setSeries func(store SeriesInviteStore, parentId int64) error, f func(eventId int64) error) error {
+	if err := c.authorize(op, eventId, &userId); err != nil {
+		return err
+	}
+	series, err := c.getSeriesInvite(eventId, userId)
+	if err != nil {
+		return err
+	}
+	if series != nil && setSeries != nil && editType == RepeatEditTypeAll {
+		if err := c.checkIfMatch(eventId); err != nil {
+			return err
+		}
+		if err := setSeries(c.dataStore.(SeriesInviteStore), series.EventId); err != nil {
+			return err
+		}
+		return c.applyInviteEdit(editType, eventId, userId, func(eventId int64) error {
+			invite, err := c.dataStore.GetInvite(eventId, userId)
+			if err != nil || invite == nil {
+				return err
+			}
+			return f(eventId)
+		})
+	}
+	return c.applyInviteEdit(editType, eventId, userId, func(eventId int64) error {
+		if err := c.overrideSeriesInvite(eventId, userId); err != nil {
+			return err
+		}
+		return f(eventId)
+	})
+}
+
+// setInviteStatus changes the status of the user's invitation to the event or its series
+func (c *Calendar) setInviteStatus(eventId int64, userId int64, status InviteStatus, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		return c.editInviteOrSeries(OperationRespond, editType, eventId, userId, func(store SeriesInviteStore, parentId int64) error {
+			if err := store.SetSeriesInviteStatus(parentId, userId, status); err != nil {
+				return err
+			}
+			return c.updateAbandonedSeries(parentId)
+		}, func(eventId int64) error {
+			if err := c.dataStore.SetInviteStatus(eventId, userId, status); err != nil {
+				return err
+			}
+			return c.updateAbandoned(eventId)
+		})
+	})
+}
+
+// getInvites gets the invite of every user on the event, where users without an
+// invite to the event itself get their series invite
+func (c *Calendar) getInvites(eventId int64) ([]*Invite, error) {
+	return storeInvites(c.dataStore, eventId)
+}
+
+// storeInvites gets the invite of every user on the event from the data store, where users
+// without an invite to the event itself get their series invite
+func storeInvites(d DataStore, eventId int64) ([]*Invite, error) {
+	lister, ok := capability[InviteListStore](d)
+	if !ok {
+		return nil, ErrorInviteListNotSupported
+	}
+	invites, err := lister.GetInvites(eventId)
+	if err != nil {
+		return nil, err
+	}
+	store, ok := capability[SeriesInviteStore](d)
+	if !ok {
+		return invites, nil
+	}
+	e, err := d.Get(eventId)
+	if err != nil || e == nil || e.ParentId == nil {
+		return invites, err
+	}
+	series, err := store.GetSeriesInvites(*e.ParentId)
+	if err != nil {
+		return nil, err
+	}
+	users := map[int64]bool{}
+	for _, invite := range invites {
+		users[invite.UserId] = true
+	}
+	for _, invite := range series {
+		if !users[invite.UserId] {
+			invites = append(invites, invite)
+		}
+	}
+	return invites, nil
+}
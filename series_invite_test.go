@@ -0,0 +1,84 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesInvite(t *testing.T) {
+	d := &InMemoryDataStore{}
+	c := NewCalendar(d)
+
+	a, count, err := c.Create(Event{
+		OwnerId:     1,
+		StartDay:    "2008-01-01",
+		EndDay:      "2008-01-01",
+		IsAllDay:    true,
+		Zone:        "UTC",
+		IsRepeating: true,
+		Repeat:      &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 4},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), count)
+	events, err := c.Query(Query{ParentIds: []int64{a.Id}})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	// inviting to all of the events makes a single series invite
+	require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeAll))
+	assert.Len(t, d.invites, 4)
+	require.Len(t, d.seriesInvites, 1)
+	for _, e := range events {
+		invite, err := c.GetInvitation(e.Id, 7)
+		require.NoError(t, err)
+		require.NotNil(t, invite)
+		assert.True(t, invite.IsSeries)
+		assert.Equal(t, a.Id, invite.EventId)
+	}
+	mine, err := c.Query(Query{UserIds: []int64{7}})
+	require.NoError(t, err)
+	assert.Len(t, mine, 4)
+
+	// declining a single event overrides the series invite
+	require.NoError(t, c.DeclineInvitation(events[1].Id, 7, RepeatEditTypeThis))
+	invite, err := c.GetInvitation(events[1].Id, 7)
+	require.NoError(t, err)
+	assert.False(t, invite.IsSeries)
+	assert.Equal(t, InviteStatusDeclined, invite.Status)
+	mine, err = c.Query(Query{UserIds: []int64{7}})
+	require.NoError(t, err)
+	assert.Len(t, mine, 3)
+
+	// accepting all updates the series and the override without making more invites
+	require.NoError(t, c.AcceptInvitation(events[2].Id, 7, RepeatEditTypeAll))
+	assert.Len(t, d.invites, 5)
+	assert.Equal(t, InviteStatusConfirmed, d.seriesInvites[0].Status)
+	invite, err = c.GetInvitation(events[1].Id, 7)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusConfirmed, invite.Status)
+
+	require.NoError(t, c.UpdateInvitationPermission(a.Id, 7, PermissionEditor, RepeatEditTypeAll))
+	assert.Equal(t, Permission(PermissionEditor), d.seriesInvites[0].Permission)
+	assert.Equal(t, Permission(PermissionEditor), invite.Permission)
+
+	// a private note is always for single events
+	note := "remember"
+	require.NoError(t, c.UpdatePrivateNote(events[3].Id, 7, &note, RepeatEditTypeThis))
+	assert.Len(t, d.invites, 6)
+	assert.Nil(t, d.seriesInvites[0].PrivateNote)
+}
+
+func TestSeriesInviteNotRepeating(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	a, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	assert.Equal(t, ErrorNotRepeatingEvent, c.InviteUserToSeries(a.Id, 7, PermissionInvitee))
+
+	// a single event is still invited normally with RepeatEditTypeAll
+	require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeAll))
+	invite, err := c.GetInvitation(a.Id, 7)
+	require.NoError(t, err)
+	assert.False(t, invite.IsSeries)
+}
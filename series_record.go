@@ -0,0 +1,187 @@
+package cali
+
+import (
+	"time"
+)
+
+// Series is the shared metadata of a repeating series that its events reference by their
+// ParentId (see WithSeriesRecords). The events of the series leave the shared fields nil and
+// get them from the series when they are read, so a value on an event is an explicit override
+// of the series for just that event.
+type Series struct {
+	// Id is the ParentId of the events of the series
+	Id int64 `json:"id"`
+	// OwnerId is the user that owns the series
+	OwnerId int64 `json:"ownerId"`
+	// Repeat is how the series repeats, which is given to every event of the series when it is read
+	Repeat Repeat `json:"repeat"`
+	// Description is the description of the events that don't have their own
+	Description *string `json:"description"`
+	// Url is the url of the events that don't have their own
+	Url *string `json:"url"`
+	// Location is the location of the events that don't have their own
+	Location *string `json:"location"`
+	// Created is a timestamp for when the series was created
+	Created time.Time `json:"created"`
+	// Updated is a timestamp for when the series was modified last
+	Updated time.Time `json:"updated"`
+}
+
+// SeriesStore is an optional interface for a data store that can save the series records of
+// repeating series
+type SeriesStore interface {
+	// CreateSeries saves a new series with the Id of the series and handles setting the Created and Updated fields
+	CreateSeries(series Series) (*Series, error)
+	// SetSeries replaces the series with the same Id and updates the Updated field
+	SetSeries(series Series) error
+	// GetSeries retrieves the series with the ids, skipping ids that aren't found
+	GetSeries(seriesIds []int64) ([]*Series, error)
+}
+
+// WithSeriesRecords saves the Repeat, Description, Url, and Location of new repeating events
+// once in a Series instead of on every event of the series, so that UpdateSeriesDescription
+// (and the other UpdateSeries methods) only change a single record. The data store must
+// implement SeriesStore. Query.Text is matched by the data store, so it only matches the
+// fields that are saved on the events themselves.
+func WithSeriesRecords() CalendarOption {
+	return func(c *Calendar) {
+		c.seriesRecords = true
+	}
+}
+
+// GetSeries gets the series record of the repeating series that the event is a part of, or nil
+// if the series doesn't have one (like a series created without WithSeriesRecords)
+func (c *Calendar) GetSeries(eventId int64) (*Series, error) {
+	store, ok := capability[SeriesStore](c.dataStore)
+	if !ok {
+		return nil, ErrorSeriesRecordsNotSupported
+	}
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrorEventNotFound
+	}
+	if !e.IsRepeating || e.ParentId == nil {
+		return nil, ErrorNotRepeatingEvent
+	}
+	series, err := store.GetSeries([]int64{*e.ParentId})
+	if err != nil || len(series) == 0 {
+		return nil, err
+	}
+	return series[0], nil
+}
+
+// UpdateSeriesDescription sets the description of every event of the series that doesn't override it
+func (c *Calendar) UpdateSeriesDescription(eventId int64, description *string) error {
+	return c.editSeries(eventId, func(s *Series) {
+		s.Description = description
+	})
+}
+
+// UpdateSeriesUrl sets the url of every event of the series that doesn't override it
+func (c *Calendar) UpdateSeriesUrl(eventId int64, url *string) error {
+	return c.editSeries(eventId, func(s *Series) {
+		s.Url = url
+	})
+}
+
+// UpdateSeriesLocation sets the location of every event of the series that doesn't override it
+func (c *Calendar) UpdateSeriesLocation(eventId int64, location *string) error {
+	return c.editSeries(eventId, func(s *Series) {
+		s.Location = location
+	})
+}
+
+// editSeries applies the edit to the series record of the event and publishes a
+// ChangeTypeUpdated change for every event of the series
+func (c *Calendar) editSeries(eventId int64, f func(s *Series)) error {
+	return c.outboxTx(func(c *Calendar) error {
+		series, err := c.GetSeries(eventId)
+		if err != nil {
+			return err
+		}
+		if series == nil {
+			return ErrorSeriesNotFound
+		}
+		f(series)
+		if err := c.dataStore.(SeriesStore).SetSeries(*series); err != nil {
+			return err
+		}
+		events, err := c.dataStore.Query(Query{ParentIds: []int64{series.Id}})
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := c.publish(ChangeTypeUpdated, e.Id, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// newSeries makes the series record for the repeating event and removes the shared fields
+// from the event so that it gets them from the series
+func newSeries(e *Event) Series {
+	s := Series{
+		OwnerId:     e.OwnerId,
+		Repeat:      *e.Repeat,
+		Description: e.Description,
+		Url:         e.Url,
+		Location:    e.Location,
+	}
+	e.Description, e.Url, e.Location = nil, nil, nil
+	return s
+}
+
+// withSeries copies the events and fills in the fields that the events of a series get from
+// their series record
+func (c *Calendar) withSeries(events []*Event) ([]*Event, error) {
+	store, ok := capability[SeriesStore](c.dataStore)
+	if !c.seriesRecords || !ok {
+		return events, nil
+	}
+	var ids []int64
+	seen := map[int64]bool{}
+	for _, e := range events {
+		if e.IsRepeating && e.ParentId != nil && !seen[*e.ParentId] {
+			seen[*e.ParentId] = true
+			ids = append(ids, *e.ParentId)
+		}
+	}
+	if len(ids) == 0 {
+		return events, nil
+	}
+	found, err := store.GetSeries(ids)
+	if err != nil {
+		return nil, err
+	}
+	series := map[int64]*Series{}
+	for _, s := range found {
+		series[s.Id] = s
+	}
+	result := make([]*Event, len(events))
+	for i, e := range events {
+		result[i] = e
+		if e.ParentId == nil || series[*e.ParentId] == nil {
+			continue
+		}
+		s := series[*e.ParentId]
+		filled := *e
+		repeat := s.Repeat
+		filled.Repeat = &repeat
+		if filled.Description == nil {
+			filled.Description = s.Description
+		}
+		if filled.Url == nil {
+			filled.Url = s.Url
+		}
+		if filled.Location == nil {
+			filled.Location = s.Location
+		}
+		result[i] = &filled
+	}
+	return result, nil
+}
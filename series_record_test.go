@@ -0,0 +1,97 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesRecords(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	stores := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+	}
+	for _, tc := range stores {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store, WithSeriesRecords())
+			description := "Weekly sync"
+			location := "Room 1"
+			a, count, err := c.Create(Event{OwnerId: 1, Title: "Sync", Description: &description, Location: &location,
+				StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+				IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+			require.NoError(t, err)
+			require.Equal(t, int64(3), count)
+			assert.Equal(t, &description, a.Description)
+
+			// the events themselves don't have the shared fields
+			stored, err := tc.store.Get(a.Id)
+			require.NoError(t, err)
+			assert.Nil(t, stored.Description)
+			assert.Nil(t, stored.Location)
+
+			series, err := c.GetSeries(a.Id)
+			require.NoError(t, err)
+			require.NotNil(t, series)
+			assert.Equal(t, *a.ParentId, series.Id)
+			assert.Equal(t, &description, series.Description)
+			assert.Equal(t, int64(3), series.Repeat.RepeatOccurrences)
+
+			events, err := c.Query(Query{ParentIds: []int64{*a.ParentId}})
+			require.NoError(t, err)
+			require.Len(t, events, 3)
+			// an event can override the series
+			other := "Room 2"
+			require.NoError(t, c.UpdateLocation(events[1].Id, &other, RepeatEditTypeThis))
+
+			updated := "Weekly sync with notes"
+			require.NoError(t, c.UpdateSeriesDescription(a.Id, &updated))
+			require.NoError(t, c.UpdateSeriesLocation(a.Id, nil))
+			events, err = c.Query(Query{ParentIds: []int64{*a.ParentId}})
+			require.NoError(t, err)
+			require.Len(t, events, 3)
+			for _, e := range events {
+				assert.Equal(t, &updated, e.Description)
+			}
+			assert.Nil(t, events[0].Location)
+			assert.Equal(t, &other, events[1].Location)
+			assert.Nil(t, events[2].Location)
+
+			_, err = c.ExtendSeries(a.Id, Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 5})
+			require.NoError(t, err)
+			events, err = c.Query(Query{ParentIds: []int64{*a.ParentId}})
+			require.NoError(t, err)
+			require.Len(t, events, 5)
+			for _, e := range events {
+				assert.Equal(t, int64(5), e.Repeat.RepeatOccurrences)
+				assert.Equal(t, &updated, e.Description)
+			}
+		})
+	}
+}
+
+func TestSeriesRecordsNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}, WithSeriesRecords())
+	_, _, err := c.Create(Event{OwnerId: 1, Title: "Sync", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	assert.Equal(t, ErrorSeriesRecordsNotSupported, err)
+
+	// a series created without series records doesn't have one
+	c = NewCalendar(&InMemoryDataStore{})
+	a, _, err := c.Create(Event{OwnerId: 1, Title: "Sync", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "10:00", Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	series, err := c.GetSeries(a.Id)
+	require.NoError(t, err)
+	assert.Nil(t, series)
+	assert.Equal(t, ErrorSeriesNotFound, c.UpdateSeriesDescription(a.Id, nil))
+}
@@ -0,0 +1,98 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtendSeries(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []CalendarOption
+		statuses  map[int64]InviteStatus
+		missingId int64
+	}{
+		{
+			name:     "copy statuses",
+			statuses: map[int64]InviteStatus{1: InviteStatusConfirmed, 7: InviteStatusDeclined, 8: InviteStatusRevoked},
+		},
+		{
+			name:      "reset declined",
+			opts:      []CalendarOption{WithInviteCarryOver(ResetDeclinedInvites)},
+			statuses:  map[int64]InviteStatus{1: InviteStatusConfirmed, 7: InviteStatusPending},
+			missingId: 8,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			d := &InMemoryDataStore{}
+			c := NewCalendar(d, tc.opts...)
+			a, _, err := c.Create(Event{
+				OwnerId:     1,
+				StartDay:    "2008-01-01",
+				EndDay:      "2008-01-01",
+				IsAllDay:    true,
+				Zone:        "UTC",
+				IsRepeating: true,
+				Repeat:      &Repeat{RepeatType: RepeatTypeWeekly, DayOfWeek: DayOfWeekTuesday, RepeatOccurrences: 2},
+			})
+			require.NoError(t, err)
+			require.NoError(t, c.InviteUser(a.Id, 7, PermissionInvitee, RepeatEditTypeThis))
+			require.NoError(t, c.InviteUser(a.Id, 8, PermissionInvitee, RepeatEditTypeThis))
+			require.NoError(t, c.InviteUser(a.Id, 9, PermissionInvitee, RepeatEditTypeAll))
+			events, err := c.Query(Query{ParentIds: []int64{a.Id}})
+			require.NoError(t, err)
+			require.Len(t, events, 2)
+			// only the last event's invites are copied
+			require.NoError(t, c.InviteUser(events[1].Id, 7, PermissionInvitee, RepeatEditTypeThis))
+			require.NoError(t, c.InviteUser(events[1].Id, 8, PermissionInvitee, RepeatEditTypeThis))
+			require.NoError(t, c.DeclineInvitation(events[1].Id, 7, RepeatEditTypeThis))
+			require.NoError(t, c.RevokeInvitation(events[1].Id, 8, RepeatEditTypeThis))
+			require.NoError(t, c.UpdateTitle(events[1].Id, "latest", RepeatEditTypeThis))
+
+			added, err := c.ExtendSeries(a.Id, Repeat{RepeatType: RepeatTypeWeekly, DayOfWeek: DayOfWeekTuesday, RepeatOccurrences: 4})
+			require.NoError(t, err)
+			require.Len(t, added, 2)
+			assert.Equal(t, "2008-01-15", added[0].StartDay)
+			assert.Equal(t, "2008-01-22", added[1].StartDay)
+			for _, e := range added {
+				assert.Equal(t, "latest", e.Title)
+				assert.Equal(t, a.Id, *e.ParentId)
+				for userId, status := range tc.statuses {
+					invite, err := c.GetInvitation(e.Id, userId)
+					require.NoError(t, err)
+					require.NotNil(t, invite, userId)
+					assert.Equal(t, status, invite.Status, userId)
+				}
+				if tc.missingId != 0 {
+					invite, err := c.GetInvitation(e.Id, tc.missingId)
+					require.NoError(t, err)
+					assert.Nil(t, invite)
+				}
+				// the series invite covers the new events too
+				invite, err := c.GetInvitation(e.Id, 9)
+				require.NoError(t, err)
+				require.NotNil(t, invite)
+				assert.True(t, invite.IsSeries)
+			}
+
+			events, err = c.Query(Query{ParentIds: []int64{a.Id}})
+			require.NoError(t, err)
+			assert.Len(t, events, 4)
+		})
+	}
+}
+
+func TestExtendSeriesNotRepeating(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	a, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	_, err = c.ExtendSeries(a.Id, Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3})
+	assert.Equal(t, ErrorNotRepeatingEvent, err)
+}
@@ -0,0 +1,1362 @@
+package cali
+
+import (
+	"sync"
+	"time"
+)
+
+// ShardKey picks the value that an event is sharded by
+type ShardKey func(e Event) int64
+
+// ShardByOwner keeps all of the events of an owner on the same shard
+func ShardByOwner(e Event) int64 {
+	return e.OwnerId
+}
+
+// ShardByCalendar keeps all of the events of a calendar on the same shard
+func ShardByCalendar(e Event) int64 {
+	return e.CalendarId
+}
+
+// ShardedDataStore splits the events over a number of data stores (shards) using the
+// ShardKey of each new event, and the events of a repeating series are always kept on
+// the shard of the first event. Query is sent to every shard at the same time and the
+// results are merged and sorted.
+//
+// Since each shard makes its own ids, the ids of events (and the EventId of invites)
+// outside of the sharded data store are the id from the shard times the number of shards
+// plus the index of the shard. This means the shards can never be added to or reordered.
+// Auto response policies and subscriptions are kept on the shard of the user id, and the
+// outbox is kept on the first shard. Availabilities (and their bookings) are kept on the shard
+// that their booked events go to, so that booking a slot only needs one shard. Followers and
+// reactions are kept on the shard of their event, and so are attachments (with ids like the
+// ids of events). Feed tokens are kept on the shard of their user with ids like the ids of
+// events, and finding a token by its hash asks every shard.
+type ShardedDataStore struct {
+	Shards []DataStore
+	Key    ShardKey
+}
+
+// NewShardedDataStore splits the events over the shards by the key
+func NewShardedDataStore(key ShardKey, shards ...DataStore) (*ShardedDataStore, error) {
+	if len(shards) == 0 {
+		return nil, ErrorNoShards
+	}
+	return &ShardedDataStore{Shards: shards, Key: key}, nil
+}
+
+// Unwrap gets the shards (see WrapperStore)
+func (d *ShardedDataStore) Unwrap() []DataStore {
+	return d.Shards
+}
+
+// split gets the shard index and the id in the shard from the outside id
+func (d *ShardedDataStore) split(id int64) (int, int64) {
+	n := int64(len(d.Shards))
+	shard := id % n
+	if shard < 0 {
+		shard = -shard
+	}
+	return int(shard), id / n
+}
+
+// join makes the outside id from the shard index and the id in the shard
+func (d *ShardedDataStore) join(shard int, id int64) int64 {
+	return id*int64(len(d.Shards)) + int64(shard)
+}
+
+// shard gets the data store and the id in the data store for the outside event id
+func (d *ShardedDataStore) shard(eventId int64) (DataStore, int64) {
+	shard, local := d.split(eventId)
+	return d.Shards[shard], local
+}
+
+// userShard gets the data store that has the data of the user
+func (d *ShardedDataStore) userShard(userId int64) DataStore {
+	shard, _ := d.split(userId)
+	return d.Shards[shard]
+}
+
+// outEvent copies the event from the shard with the outside ids
+func (d *ShardedDataStore) outEvent(shard int, e *Event) *Event {
+	if e == nil {
+		return nil
+	}
+	out := *e
+	out.Id = d.join(shard, e.Id)
+	if e.ParentId != nil {
+		parentId := d.join(shard, *e.ParentId)
+		out.ParentId = &parentId
+	}
+	return &out
+}
+
+// outEvents copies the events from the shard with the outside ids
+func (d *ShardedDataStore) outEvents(shard int, events []*Event) []*Event {
+	result := make([]*Event, 0, len(events))
+	for _, e := range events {
+		result = append(result, d.outEvent(shard, e))
+	}
+	return result
+}
+
+// outInvite copies the invite from the shard with the outside event id
+func (d *ShardedDataStore) outInvite(shard int, i *Invite) *Invite {
+	if i == nil {
+		return nil
+	}
+	out := *i
+	out.EventId = d.join(shard, i.EventId)
+	return &out
+}
+
+// outInvites copies the invites from the shard with the outside event ids
+func (d *ShardedDataStore) outInvites(shard int, invites []*Invite) []*Invite {
+	result := make([]*Invite, 0, len(invites))
+	for _, i := range invites {
+		result = append(result, d.outInvite(shard, i))
+	}
+	return result
+}
+
+// shardQuery changes the EventIds and ParentIds of the query to the ids in the shard, and
+// returns false if the query can't match anything in the shard
+func (d *ShardedDataStore) shardQuery(q Query, shard int) (Query, bool) {
+	local := func(ids []int64) ([]int64, bool) {
+		if len(ids) == 0 {
+			return nil, true
+		}
+		var result []int64
+		for _, id := range ids {
+			if s, l := d.split(id); s == shard {
+				result = append(result, l)
+			}
+		}
+		return result, len(result) > 0
+	}
+	var ok bool
+	if q.EventIds, ok = local(q.EventIds); !ok {
+		return q, false
+	}
+	if q.ParentIds, ok = local(q.ParentIds); !ok {
+		return q, false
+	}
+	return q, true
+}
+
+// fanOut calls f for every shard at the same time and returns the first error
+func (d *ShardedDataStore) fanOut(f func(shard int, store DataStore) error) error {
+	errs := make([]error, len(d.Shards))
+	var wg sync.WaitGroup
+	for i, store := range d.Shards {
+		wg.Add(1)
+		go func(i int, store DataStore) {
+			defer wg.Done()
+			errs[i] = f(i, store)
+		}(i, store)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IfVersion calls f with a copy of the data store where the shard of the event is in IfVersion
+// (see VersionStore), or with the data store itself if the shard doesn't implement VersionStore
+func (d *ShardedDataStore) IfVersion(eventId int64, version int64, f func(store DataStore) error) error {
+	shard, local := d.split(eventId)
+	store, ok := capability[VersionStore](d.Shards[shard])
+	if !ok {
+		return f(d)
+	}
+	return store.IfVersion(local, version, func(scoped DataStore) error {
+		shards := append([]DataStore(nil), d.Shards...)
+		shards[shard] = scoped
+		return f(&ShardedDataStore{Shards: shards, Key: d.Key})
+	})
+}
+
+func (d *ShardedDataStore) Create(event Event) (*Event, error) {
+	key := int64(0)
+	if d.Key != nil {
+		key = d.Key(event)
+	}
+	shard, _ := d.split(key)
+	if event.ParentId != nil {
+		var parentId int64
+		shard, parentId = d.split(*event.ParentId)
+		event.ParentId = &parentId
+	}
+	if event.ExternalKey != "" {
+		if err := d.checkExternalKey(event.ExternalKey); err != nil {
+			return nil, err
+		}
+	}
+	e, err := d.Shards[shard].Create(event)
+	if err != nil {
+		return nil, err
+	}
+	return d.outEvent(shard, e), nil
+}
+
+func (d *ShardedDataStore) CreateRepeating(e Event) ([]*Event, error) {
+	key := int64(0)
+	if d.Key != nil {
+		key = d.Key(e)
+	}
+	shard, _ := d.split(key)
+	store, ok := d.Shards[shard].(RepeatExpansionStore)
+	if !ok {
+		return generateRepeating(d, e)
+	}
+	if e.ExternalKey != "" {
+		if err := d.checkExternalKey(e.ExternalKey); err != nil {
+			return nil, err
+		}
+	}
+	created, err := store.CreateRepeating(e)
+	if err != nil {
+		return nil, err
+	}
+	return d.outEvents(shard, created), nil
+}
+
+func (d *ShardedDataStore) SetStatusBatch(eventIds []int64, status Status) error {
+	locals := make([][]int64, len(d.Shards))
+	for _, id := range eventIds {
+		shard, local := d.split(id)
+		locals[shard] = append(locals[shard], local)
+	}
+	return d.fanOut(func(shard int, store DataStore) error {
+		if len(locals[shard]) == 0 {
+			return nil
+		}
+		if batch, ok := capability[BatchStatusStore](store); ok {
+			return batch.SetStatusBatch(locals[shard], status)
+		}
+		for _, id := range locals[shard] {
+			if err := store.SetStatus(id, status); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (d *ShardedDataStore) SetTime(eventId int64, startTime, endTime string) error {
+	store, local := d.shard(eventId)
+	return store.SetTime(local, startTime, endTime)
+}
+
+func (d *ShardedDataStore) SetDayTime(eventId int64, startDay, startTime, endDay, endTime, zone string, isAllDay bool) error {
+	store, local := d.shard(eventId)
+	return store.SetDayTime(local, startDay, startTime, endDay, endTime, zone, isAllDay)
+}
+
+func (d *ShardedDataStore) SetStatus(eventId int64, status Status) error {
+	store, local := d.shard(eventId)
+	return store.SetStatus(local, status)
+}
+
+func (d *ShardedDataStore) SetTitle(eventId int64, title string) error {
+	store, local := d.shard(eventId)
+	return store.SetTitle(local, title)
+}
+
+func (d *ShardedDataStore) SetDescription(eventId int64, description *string) error {
+	store, local := d.shard(eventId)
+	return store.SetDescription(local, description)
+}
+
+func (d *ShardedDataStore) SetUrl(eventId int64, url *string) error {
+	store, local := d.shard(eventId)
+	return store.SetUrl(local, url)
+}
+
+func (d *ShardedDataStore) SetLocation(eventId int64, location *string) error {
+	store, local := d.shard(eventId)
+	locations, ok := capability[LocationStore](store)
+	if !ok {
+		return ErrorLocationNotSupported
+	}
+	return locations.SetLocation(local, location)
+}
+
+func (d *ShardedDataStore) SetGeo(eventId int64, geo *GeoPoint) error {
+	store, local := d.shard(eventId)
+	points, ok := capability[GeoPointStore](store)
+	if !ok {
+		return ErrorGeoNotSupported
+	}
+	return points.SetGeo(local, geo)
+}
+
+func (d *ShardedDataStore) SetDisallowForwarding(eventId int64, disallow bool) error {
+	store, local := d.shard(eventId)
+	forwarding, ok := capability[ForwardingStore](store)
+	if !ok {
+		return ErrorForwardingNotSupported
+	}
+	return forwarding.SetDisallowForwarding(local, disallow)
+}
+
+func (d *ShardedDataStore) SetVisibility(eventId int64, visibility Visibility) error {
+	store, local := d.shard(eventId)
+	visibilities, ok := capability[VisibilityStore](store)
+	if !ok {
+		return ErrorVisibilityNotSupported
+	}
+	return visibilities.SetVisibility(local, visibility)
+}
+
+func (d *ShardedDataStore) SetPriority(eventId int64, priority Priority) error {
+	store, local := d.shard(eventId)
+	priorities, ok := capability[PriorityStore](store)
+	if !ok {
+		return ErrorPriorityNotSupported
+	}
+	return priorities.SetPriority(local, priority)
+}
+
+func (d *ShardedDataStore) SetConference(eventId int64, conference *Conference) error {
+	store, local := d.shard(eventId)
+	conferences, ok := capability[ConferenceStore](store)
+	if !ok {
+		return ErrorConferenceNotSupported
+	}
+	return conferences.SetConference(local, conference)
+}
+
+func (d *ShardedDataStore) SetUserData(eventId int64, userData map[string]interface{}) error {
+	store, local := d.shard(eventId)
+	return store.SetUserData(local, userData)
+}
+
+func (d *ShardedDataStore) SetInviteStatus(eventId, userId int64, status InviteStatus) error {
+	store, local := d.shard(eventId)
+	return store.SetInviteStatus(local, userId, status)
+}
+
+func (d *ShardedDataStore) SetInvitePermissions(eventId, userId int64, permissions Permission) error {
+	store, local := d.shard(eventId)
+	return store.SetInvitePermissions(local, userId, permissions)
+}
+
+func (d *ShardedDataStore) SetInvitePrivateNote(eventId, userId int64, note *string) error {
+	store, local := d.shard(eventId)
+	private, ok := capability[PrivateInviteStore](store)
+	if !ok {
+		return ErrorPrivateInviteNotSupported
+	}
+	return private.SetInvitePrivateNote(local, userId, note)
+}
+
+func (d *ShardedDataStore) SetInviteUserData(eventId, userId int64, userData map[string]interface{}) error {
+	store, local := d.shard(eventId)
+	private, ok := capability[PrivateInviteStore](store)
+	if !ok {
+		return ErrorPrivateInviteNotSupported
+	}
+	return private.SetInviteUserData(local, userId, userData)
+}
+
+func (d *ShardedDataStore) SetInviteProposal(eventId, userId int64, proposal *TimeProposal) error {
+	store, local := d.shard(eventId)
+	proposals, ok := capability[ProposalStore](store)
+	if !ok {
+		return ErrorProposalsNotSupported
+	}
+	return proposals.SetInviteProposal(local, userId, proposal)
+}
+
+func (d *ShardedDataStore) SetInviteMuted(eventId, userId int64, muted bool) error {
+	store, local := d.shard(eventId)
+	mutes, ok := capability[MuteStore](store)
+	if !ok {
+		return ErrorMuteNotSupported
+	}
+	return mutes.SetInviteMuted(local, userId, muted)
+}
+
+func (d *ShardedDataStore) Get(eventId int64) (*Event, error) {
+	shard, local := d.split(eventId)
+	e, err := d.Shards[shard].Get(local)
+	if err != nil {
+		return nil, err
+	}
+	return d.outEvent(shard, e), nil
+}
+
+func (d *ShardedDataStore) GetMany(eventIds []int64) ([]*Event, error) {
+	locals := make([][]int64, len(d.Shards))
+	indexes := make([][]int, len(d.Shards))
+	for i, id := range eventIds {
+		shard, local := d.split(id)
+		locals[shard] = append(locals[shard], local)
+		indexes[shard] = append(indexes[shard], i)
+	}
+	result := make([]*Event, len(eventIds))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		if len(locals[shard]) == 0 {
+			return nil
+		}
+		events, err := getMany(store, locals[shard])
+		if err != nil {
+			return err
+		}
+		for j, e := range events {
+			result[indexes[shard][j]] = d.outEvent(shard, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) Query(q Query) ([]*Event, error) {
+	results := make([][]*Event, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		sq, ok := d.shardQuery(q, shard)
+		if !ok {
+			return nil
+		}
+		events, err := store.Query(sq)
+		if err != nil {
+			return err
+		}
+		results[shard] = d.outEvents(shard, events)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var merged []*Event
+	for _, events := range results {
+		merged = append(merged, events...)
+	}
+	return Sort(merged), nil
+}
+
+func (d *ShardedDataStore) AddInvite(invite Invite) (*Invite, error) {
+	shard, local := d.split(invite.EventId)
+	invite.EventId = local
+	i, err := d.Shards[shard].AddInvite(invite)
+	if err != nil {
+		return nil, err
+	}
+	return d.outInvite(shard, i), nil
+}
+
+func (d *ShardedDataStore) GetInvite(eventId, userId int64) (*Invite, error) {
+	shard, local := d.split(eventId)
+	i, err := d.Shards[shard].GetInvite(local, userId)
+	if err != nil {
+		return nil, err
+	}
+	return d.outInvite(shard, i), nil
+}
+
+func (d *ShardedDataStore) GetInvites(eventId int64) ([]*Invite, error) {
+	shard, local := d.split(eventId)
+	store, ok := d.Shards[shard].(InviteListStore)
+	if !ok {
+		return nil, ErrorInviteListNotSupported
+	}
+	invites, err := store.GetInvites(local)
+	if err != nil {
+		return nil, err
+	}
+	return d.outInvites(shard, invites), nil
+}
+
+func (d *ShardedDataStore) AddSeriesInvite(invite Invite) (*Invite, error) {
+	shard, local := d.split(invite.EventId)
+	store, ok := d.Shards[shard].(SeriesInviteStore)
+	if !ok {
+		return nil, ErrorSeriesInviteNotSupported
+	}
+	invite.EventId = local
+	i, err := store.AddSeriesInvite(invite)
+	if err != nil {
+		return nil, err
+	}
+	return d.outInvite(shard, i), nil
+}
+
+func (d *ShardedDataStore) SetSeriesInviteStatus(parentId, userId int64, status InviteStatus) error {
+	shard, local := d.split(parentId)
+	store, ok := d.Shards[shard].(SeriesInviteStore)
+	if !ok {
+		return ErrorSeriesInviteNotSupported
+	}
+	return store.SetSeriesInviteStatus(local, userId, status)
+}
+
+func (d *ShardedDataStore) SetSeriesInvitePermissions(parentId, userId int64, permissions Permission) error {
+	shard, local := d.split(parentId)
+	store, ok := d.Shards[shard].(SeriesInviteStore)
+	if !ok {
+		return ErrorSeriesInviteNotSupported
+	}
+	return store.SetSeriesInvitePermissions(local, userId, permissions)
+}
+
+func (d *ShardedDataStore) GetSeriesInvite(parentId, userId int64) (*Invite, error) {
+	shard, local := d.split(parentId)
+	store, ok := d.Shards[shard].(SeriesInviteStore)
+	if !ok {
+		return nil, nil
+	}
+	i, err := store.GetSeriesInvite(local, userId)
+	if err != nil {
+		return nil, err
+	}
+	return d.outInvite(shard, i), nil
+}
+
+func (d *ShardedDataStore) GetSeriesInvites(parentId int64) ([]*Invite, error) {
+	shard, local := d.split(parentId)
+	store, ok := d.Shards[shard].(SeriesInviteStore)
+	if !ok {
+		return nil, nil
+	}
+	invites, err := store.GetSeriesInvites(local)
+	if err != nil {
+		return nil, err
+	}
+	return d.outInvites(shard, invites), nil
+}
+
+func (d *ShardedDataStore) SetAutoResponsePolicy(policy AutoResponsePolicy) error {
+	store, ok := d.userShard(policy.UserId).(AutoResponseStore)
+	if !ok {
+		return ErrorAutoResponseNotSupported
+	}
+	return store.SetAutoResponsePolicy(policy)
+}
+
+func (d *ShardedDataStore) GetAutoResponsePolicy(userId int64) (*AutoResponsePolicy, error) {
+	store, ok := d.userShard(userId).(AutoResponseStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetAutoResponsePolicy(userId)
+}
+
+func (d *ShardedDataStore) SetSubscription(s Subscription) (*Subscription, error) {
+	store, ok := d.userShard(s.UserId).(SubscriptionStore)
+	if !ok {
+		return nil, ErrorSubscriptionNotSupported
+	}
+	return store.SetSubscription(s)
+}
+
+func (d *ShardedDataStore) RemoveSubscription(userId, calendarId int64) error {
+	store, ok := d.userShard(userId).(SubscriptionStore)
+	if !ok {
+		return ErrorSubscriptionNotSupported
+	}
+	return store.RemoveSubscription(userId, calendarId)
+}
+
+func (d *ShardedDataStore) GetSubscriptions(userId int64) ([]*Subscription, error) {
+	store, ok := d.userShard(userId).(SubscriptionStore)
+	if !ok {
+		return nil, nil
+	}
+	return store.GetSubscriptions(userId)
+}
+
+func (d *ShardedDataStore) ChangedEvents(since time.Time) ([]*Event, error) {
+	results := make([][]*Event, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		syncStore, ok := capability[SyncStore](store)
+		if !ok {
+			return ErrorSyncNotSupported
+		}
+		events, err := syncStore.ChangedEvents(since)
+		if err != nil {
+			return err
+		}
+		results[shard] = d.outEvents(shard, events)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var merged []*Event
+	for _, events := range results {
+		merged = append(merged, events...)
+	}
+	return merged, nil
+}
+
+func (d *ShardedDataStore) ChangedInvites(since time.Time) ([]*Invite, error) {
+	results := make([][]*Invite, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		syncStore, ok := capability[SyncStore](store)
+		if !ok {
+			return ErrorSyncNotSupported
+		}
+		invites, err := syncStore.ChangedInvites(since)
+		if err != nil {
+			return err
+		}
+		results[shard] = d.outInvites(shard, invites)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var merged []*Invite
+	for _, invites := range results {
+		merged = append(merged, invites...)
+	}
+	return merged, nil
+}
+
+// HasGeoIndex is true if every shard has a geo index
+func (d *ShardedDataStore) HasGeoIndex() bool {
+	for _, shard := range d.Shards {
+		store, ok := capability[GeoIndexStore](shard)
+		if !ok || !store.HasGeoIndex() {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *ShardedDataStore) AddOutboxRecord(record OutboxRecord) (*OutboxRecord, error) {
+	store, ok := d.Shards[0].(OutboxStore)
+	if !ok {
+		return nil, ErrorOutboxNotSupported
+	}
+	return store.AddOutboxRecord(record)
+}
+
+func (d *ShardedDataStore) PendingOutboxRecords(limit int) ([]*OutboxRecord, error) {
+	store, ok := d.Shards[0].(OutboxStore)
+	if !ok {
+		return nil, ErrorOutboxNotSupported
+	}
+	return store.PendingOutboxRecords(limit)
+}
+
+func (d *ShardedDataStore) MarkOutboxDelivered(id int64) error {
+	store, ok := d.Shards[0].(OutboxStore)
+	if !ok {
+		return ErrorOutboxNotSupported
+	}
+	return store.MarkOutboxDelivered(id)
+}
+
+func (d *ShardedDataStore) MarkOutboxFailed(id int64, reason string) error {
+	store, ok := d.Shards[0].(OutboxStore)
+	if !ok {
+		return ErrorOutboxNotSupported
+	}
+	return store.MarkOutboxFailed(id, reason)
+}
+
+func (d *ShardedDataStore) Stats(userId int64, window TimeWindow) (*Stats, error) {
+	results := make([]*Stats, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		var err error
+		if statsStore, ok := capability[StatsStore](store); ok {
+			results[shard], err = statsStore.Stats(userId, window)
+		} else {
+			results[shard], err = computeStats(store, userId, window)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := newStats(userId, window)
+	for _, result := range results {
+		s.merge(result)
+	}
+	s.finish()
+	return s, nil
+}
+
+func (d *ShardedDataStore) RSVPSummary(eventId int64) (*RSVPSummary, error) {
+	store, local := d.shard(eventId)
+	var s *RSVPSummary
+	var err error
+	if summaryStore, ok := capability[RSVPSummaryStore](store); ok {
+		s, err = summaryStore.RSVPSummary(local)
+	} else {
+		s, err = computeRSVPSummary(store, local)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.EventId = eventId
+	return s, nil
+}
+
+func (d *ShardedDataStore) PendingInvitesBefore(before time.Time) ([]*Invite, error) {
+	results := make([][]*Invite, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		pending, ok := capability[PendingInviteStore](store)
+		if !ok {
+			return ErrorPendingInvitesNotSupported
+		}
+		invites, err := pending.PendingInvitesBefore(before)
+		results[shard] = d.outInvites(shard, invites)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []*Invite
+	for _, invites := range results {
+		result = append(result, invites...)
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) CreateSeries(s Series) (*Series, error) {
+	shard, local := d.split(s.Id)
+	store, ok := d.Shards[shard].(SeriesStore)
+	if !ok {
+		return nil, ErrorSeriesRecordsNotSupported
+	}
+	s.Id = local
+	stored, err := store.CreateSeries(s)
+	if err != nil {
+		return nil, err
+	}
+	return d.outSeries(shard, stored), nil
+}
+
+func (d *ShardedDataStore) SetSeries(s Series) error {
+	shard, local := d.split(s.Id)
+	store, ok := d.Shards[shard].(SeriesStore)
+	if !ok {
+		return ErrorSeriesRecordsNotSupported
+	}
+	s.Id = local
+	return store.SetSeries(s)
+}
+
+func (d *ShardedDataStore) GetSeries(seriesIds []int64) ([]*Series, error) {
+	locals := make([][]int64, len(d.Shards))
+	for _, id := range seriesIds {
+		shard, local := d.split(id)
+		locals[shard] = append(locals[shard], local)
+	}
+	results := make([][]*Series, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		if len(locals[shard]) == 0 {
+			return nil
+		}
+		seriesStore, ok := capability[SeriesStore](store)
+		if !ok {
+			return ErrorSeriesRecordsNotSupported
+		}
+		series, err := seriesStore.GetSeries(locals[shard])
+		for _, s := range series {
+			results[shard] = append(results[shard], d.outSeries(shard, s))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []*Series
+	for _, series := range results {
+		result = append(result, series...)
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) GetByExternalKey(key string) (*Event, error) {
+	results := make([]*Event, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		keyStore, ok := capability[ExternalKeyStore](store)
+		if !ok {
+			return ErrorExternalKeysNotSupported
+		}
+		e, err := keyStore.GetByExternalKey(key)
+		results[shard] = d.outEvent(shard, e)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range results {
+		if e != nil {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *ShardedDataStore) SetExternalKey(eventId int64, key string) error {
+	store, local := d.shard(eventId)
+	keyStore, ok := capability[ExternalKeyStore](store)
+	if !ok {
+		return ErrorExternalKeysNotSupported
+	}
+	if key != "" {
+		e, err := d.GetByExternalKey(key)
+		if err != nil {
+			return err
+		}
+		if e != nil && e.Id != eventId {
+			return ErrorDuplicateExternalKey
+		}
+	}
+	return keyStore.SetExternalKey(local, key)
+}
+
+func (d *ShardedDataStore) SetOverrides(eventId int64, overrides []string) error {
+	store, local := d.shard(eventId)
+	overrideStore, ok := capability[OverrideStore](store)
+	if !ok {
+		return ErrorOverridesNotSupported
+	}
+	return overrideStore.SetOverrides(local, overrides)
+}
+
+func (d *ShardedDataStore) SetCancelReason(eventId int64, reason *string) error {
+	store, local := d.shard(eventId)
+	reasonStore, ok := capability[CancelReasonStore](store)
+	if !ok {
+		return ErrorCancelReasonNotSupported
+	}
+	return reasonStore.SetCancelReason(local, reason)
+}
+
+func (d *ShardedDataStore) SetLinks(eventId int64, links []Link) error {
+	store, local := d.shard(eventId)
+	linkStore, ok := capability[LinkStore](store)
+	if !ok {
+		return ErrorLinksNotSupported
+	}
+	return linkStore.SetLinks(local, links)
+}
+
+func (d *ShardedDataStore) AddFollower(f Follower) (*Follower, error) {
+	shard, local := d.split(f.EventId)
+	store, ok := d.Shards[shard].(FollowerStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	f.EventId = local
+	follower, err := store.AddFollower(f)
+	if err != nil {
+		return nil, err
+	}
+	return d.outFollower(shard, follower), nil
+}
+
+func (d *ShardedDataStore) RemoveFollower(eventId, userId int64) error {
+	store, local := d.shard(eventId)
+	followers, ok := capability[FollowerStore](store)
+	if !ok {
+		return ErrorFollowersNotSupported
+	}
+	return followers.RemoveFollower(local, userId)
+}
+
+func (d *ShardedDataStore) GetFollowers(eventId int64) ([]*Follower, error) {
+	shard, local := d.split(eventId)
+	store, ok := d.Shards[shard].(FollowerStore)
+	if !ok {
+		return nil, ErrorFollowersNotSupported
+	}
+	followers, err := store.GetFollowers(local)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Follower, 0, len(followers))
+	for _, f := range followers {
+		result = append(result, d.outFollower(shard, f))
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) GetFollowedEventIds(userId int64) ([]int64, error) {
+	results := make([][]int64, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		followers, ok := capability[FollowerStore](store)
+		if !ok {
+			return ErrorFollowersNotSupported
+		}
+		ids, err := followers.GetFollowedEventIds(userId)
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			results[shard] = append(results[shard], d.join(shard, id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, shardIds := range results {
+		ids = append(ids, shardIds...)
+	}
+	return ids, nil
+}
+
+// outFollower copies the follower from the shard with the outside event id
+func (d *ShardedDataStore) outFollower(shard int, f *Follower) *Follower {
+	out := *f
+	out.EventId = d.join(shard, f.EventId)
+	return &out
+}
+
+func (d *ShardedDataStore) AddReaction(r Reaction) (*Reaction, error) {
+	shard, local := d.split(r.EventId)
+	store, ok := d.Shards[shard].(ReactionStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	r.EventId = local
+	reaction, err := store.AddReaction(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.outReaction(shard, reaction), nil
+}
+
+func (d *ShardedDataStore) RemoveReaction(eventId, userId int64, emoji string) error {
+	store, local := d.shard(eventId)
+	reactions, ok := capability[ReactionStore](store)
+	if !ok {
+		return ErrorReactionsNotSupported
+	}
+	return reactions.RemoveReaction(local, userId, emoji)
+}
+
+func (d *ShardedDataStore) GetReactions(eventId int64) ([]*Reaction, error) {
+	shard, local := d.split(eventId)
+	store, ok := d.Shards[shard].(ReactionStore)
+	if !ok {
+		return nil, ErrorReactionsNotSupported
+	}
+	reactions, err := store.GetReactions(local)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Reaction, 0, len(reactions))
+	for _, r := range reactions {
+		result = append(result, d.outReaction(shard, r))
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) CountReactions(eventIds []int64) (map[int64]map[string]int64, error) {
+	locals := make([][]int64, len(d.Shards))
+	for _, id := range eventIds {
+		shard, local := d.split(id)
+		locals[shard] = append(locals[shard], local)
+	}
+	counts := map[int64]map[string]int64{}
+	for shard, ids := range locals {
+		if len(ids) == 0 {
+			continue
+		}
+		store, ok := d.Shards[shard].(ReactionStore)
+		if !ok {
+			return nil, ErrorReactionsNotSupported
+		}
+		shardCounts, err := store.CountReactions(ids)
+		if err != nil {
+			return nil, err
+		}
+		for local, emoji := range shardCounts {
+			counts[d.join(shard, local)] = emoji
+		}
+	}
+	return counts, nil
+}
+
+// outReaction copies the reaction from the shard with the outside event id
+func (d *ShardedDataStore) outReaction(shard int, r *Reaction) *Reaction {
+	out := *r
+	out.EventId = d.join(shard, r.EventId)
+	return &out
+}
+
+func (d *ShardedDataStore) AddAttachment(a Attachment) (*Attachment, error) {
+	shard, local := d.split(a.EventId)
+	store, ok := d.Shards[shard].(AttachmentStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	a.EventId = local
+	added, err := store.AddAttachment(a)
+	if err != nil {
+		return nil, err
+	}
+	return d.outAttachment(shard, added), nil
+}
+
+func (d *ShardedDataStore) GetAttachment(id int64) (*Attachment, error) {
+	shard, local := d.split(id)
+	store, ok := d.Shards[shard].(AttachmentStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	a, err := store.GetAttachment(local)
+	if err != nil || a == nil {
+		return nil, err
+	}
+	return d.outAttachment(shard, a), nil
+}
+
+func (d *ShardedDataStore) GetAttachments(eventId int64) ([]*Attachment, error) {
+	shard, local := d.split(eventId)
+	store, ok := d.Shards[shard].(AttachmentStore)
+	if !ok {
+		return nil, ErrorAttachmentsNotSupported
+	}
+	attachments, err := store.GetAttachments(local)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Attachment, 0, len(attachments))
+	for _, a := range attachments {
+		result = append(result, d.outAttachment(shard, a))
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) SetAttachmentStatus(id int64, status AttachmentStatus, reason *string) error {
+	shard, local := d.split(id)
+	store, ok := d.Shards[shard].(AttachmentStore)
+	if !ok {
+		return ErrorAttachmentsNotSupported
+	}
+	return store.SetAttachmentStatus(local, status, reason)
+}
+
+// outAttachment copies the attachment from the shard with the outside ids
+func (d *ShardedDataStore) outAttachment(shard int, a *Attachment) *Attachment {
+	out := *a
+	out.Id = d.join(shard, a.Id)
+	out.EventId = d.join(shard, a.EventId)
+	return &out
+}
+
+func (d *ShardedDataStore) AddFeedToken(t FeedToken) (*FeedToken, error) {
+	shard, _ := d.split(t.UserId)
+	store, ok := d.Shards[shard].(FeedTokenStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	added, err := store.AddFeedToken(t)
+	if err != nil {
+		return nil, err
+	}
+	return d.outFeedToken(shard, added), nil
+}
+
+func (d *ShardedDataStore) GetFeedToken(id int64) (*FeedToken, error) {
+	shard, local := d.split(id)
+	store, ok := d.Shards[shard].(FeedTokenStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	t, err := store.GetFeedToken(local)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	return d.outFeedToken(shard, t), nil
+}
+
+func (d *ShardedDataStore) GetFeedTokenByHash(hash string) (*FeedToken, error) {
+	results := make([]*FeedToken, len(d.Shards))
+	err := d.fanOut(func(shard int, store DataStore) error {
+		tokenStore, ok := capability[FeedTokenStore](store)
+		if !ok {
+			return ErrorFeedTokensNotSupported
+		}
+		t, err := tokenStore.GetFeedTokenByHash(hash)
+		if err != nil || t == nil {
+			return err
+		}
+		results[shard] = d.outFeedToken(shard, t)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range results {
+		if t != nil {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *ShardedDataStore) GetFeedTokens(userId int64) ([]*FeedToken, error) {
+	shard, _ := d.split(userId)
+	store, ok := d.Shards[shard].(FeedTokenStore)
+	if !ok {
+		return nil, ErrorFeedTokensNotSupported
+	}
+	tokens, err := store.GetFeedTokens(userId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*FeedToken, 0, len(tokens))
+	for _, t := range tokens {
+		result = append(result, d.outFeedToken(shard, t))
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) RevokeFeedToken(id int64) error {
+	shard, local := d.split(id)
+	store, ok := d.Shards[shard].(FeedTokenStore)
+	if !ok {
+		return ErrorFeedTokensNotSupported
+	}
+	return store.RevokeFeedToken(local)
+}
+
+// outFeedToken copies the feed token from the shard with the outside id
+func (d *ShardedDataStore) outFeedToken(shard int, t *FeedToken) *FeedToken {
+	out := *t
+	out.Id = d.join(shard, t.Id)
+	return &out
+}
+
+func (d *ShardedDataStore) SetRecurrenceId(eventId int64, recurrenceId string) error {
+	store, local := d.shard(eventId)
+	overrideStore, ok := capability[OverrideStore](store)
+	if !ok {
+		return ErrorOverridesNotSupported
+	}
+	return overrideStore.SetRecurrenceId(local, recurrenceId)
+}
+
+// checkExternalKey returns ErrorDuplicateExternalKey if an event in any shard has the key. The
+// shards are checked one after another, so two events created with the same key at the same
+// time in different shards can both be saved.
+func (d *ShardedDataStore) checkExternalKey(key string) error {
+	e, err := d.GetByExternalKey(key)
+	if err == ErrorExternalKeysNotSupported {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if e != nil {
+		return ErrorDuplicateExternalKey
+	}
+	return nil
+}
+
+// outSeries copies the series from the shard with the outside id
+func (d *ShardedDataStore) outSeries(shard int, s *Series) *Series {
+	if s == nil {
+		return nil
+	}
+	out := *s
+	out.Id = d.join(shard, s.Id)
+	return &out
+}
+
+// outAvailability copies the availability from the shard with the outside id
+func (d *ShardedDataStore) outAvailability(shard int, a *Availability) *Availability {
+	if a == nil {
+		return nil
+	}
+	out := *a
+	out.Id = d.join(shard, a.Id)
+	return &out
+}
+
+// outBooking copies the booking from the shard with the outside ids
+func (d *ShardedDataStore) outBooking(shard int, b *Booking) *Booking {
+	if b == nil {
+		return nil
+	}
+	out := *b
+	out.Id = d.join(shard, b.Id)
+	out.AvailabilityId = d.join(shard, b.AvailabilityId)
+	out.EventId = d.join(shard, b.EventId)
+	return &out
+}
+
+// slotShard gets the slot store of the shard and the id in the shard for the outside id
+func (d *ShardedDataStore) slotShard(id int64) (SlotStore, int, int64, error) {
+	shard, local := d.split(id)
+	store, ok := d.Shards[shard].(SlotStore)
+	if !ok {
+		return nil, 0, 0, ErrorSlotsNotSupported
+	}
+	return store, shard, local, nil
+}
+
+func (d *ShardedDataStore) AddAvailability(a Availability) (*Availability, error) {
+	key := int64(0)
+	if d.Key != nil {
+		key = d.Key(Event{OwnerId: a.OwnerId, CalendarId: a.CalendarId, EventType: a.EventType})
+	}
+	store, shard, _, err := d.slotShard(key)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := store.AddAvailability(a)
+	if err != nil {
+		return nil, err
+	}
+	return d.outAvailability(shard, stored), nil
+}
+
+func (d *ShardedDataStore) GetAvailability(id int64) (*Availability, error) {
+	store, shard, local, err := d.slotShard(id)
+	if err != nil {
+		return nil, err
+	}
+	a, err := store.GetAvailability(local)
+	if err != nil {
+		return nil, err
+	}
+	return d.outAvailability(shard, a), nil
+}
+
+func (d *ShardedDataStore) BookSlot(b Booking, e Event) (*Booking, *Event, error) {
+	store, shard, local, err := d.slotShard(b.AvailabilityId)
+	if err != nil {
+		return nil, nil, err
+	}
+	b.AvailabilityId = local
+	booking, event, err := store.BookSlot(b, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.outBooking(shard, booking), d.outEvent(shard, event), nil
+}
+
+func (d *ShardedDataStore) GetBooking(id int64) (*Booking, error) {
+	store, shard, local, err := d.slotShard(id)
+	if err != nil {
+		return nil, err
+	}
+	b, err := store.GetBooking(local)
+	if err != nil {
+		return nil, err
+	}
+	return d.outBooking(shard, b), nil
+}
+
+func (d *ShardedDataStore) GetBookings(availabilityId int64, startDay, endDay string) ([]*Booking, error) {
+	store, shard, local, err := d.slotShard(availabilityId)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := store.GetBookings(local, startDay, endDay)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Booking, 0, len(bookings))
+	for _, b := range bookings {
+		result = append(result, d.outBooking(shard, b))
+	}
+	return result, nil
+}
+
+func (d *ShardedDataStore) SetBookingStatus(id int64, status BookingStatus) error {
+	store, _, local, err := d.slotShard(id)
+	if err != nil {
+		return err
+	}
+	return store.SetBookingStatus(local, status)
+}
+
+func (d *ShardedDataStore) AddReminder(r Reminder) (*Reminder, error) {
+	store, ok := d.Shards[0].(ReminderStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.AddReminder(r)
+}
+
+func (d *ShardedDataStore) GetReminder(reminderId int64) (*Reminder, error) {
+	store, ok := d.Shards[0].(ReminderStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.GetReminder(reminderId)
+}
+
+func (d *ShardedDataStore) UpdateReminder(r Reminder) error {
+	store, ok := d.Shards[0].(ReminderStore)
+	if !ok {
+		return ErrorRemindersNotSupported
+	}
+	return store.UpdateReminder(r)
+}
+
+func (d *ShardedDataStore) DueReminders(before time.Time) ([]*Reminder, error) {
+	store, ok := d.Shards[0].(ReminderStore)
+	if !ok {
+		return nil, ErrorRemindersNotSupported
+	}
+	return store.DueReminders(before)
+}
+
+func (d *ShardedDataStore) AddAuditEntry(entry AuditEntry) (*AuditEntry, error) {
+	store, ok := d.Shards[0].(AuditStore)
+	if !ok {
+		return nil, ErrorAuditLogNotSupported
+	}
+	return store.AddAuditEntry(entry)
+}
+
+func (d *ShardedDataStore) GetAuditEntries(eventId int64) ([]*AuditEntry, error) {
+	store, ok := d.Shards[0].(AuditStore)
+	if !ok {
+		return nil, ErrorAuditLogNotSupported
+	}
+	return store.GetAuditEntries(eventId)
+}
+
+func (d *ShardedDataStore) LockEvent(lock EventLock, now time.Time) error {
+	store, local := d.shard(lock.EventId)
+	locks, ok := capability[LockStore](store)
+	if !ok {
+		return ErrorLocksNotSupported
+	}
+	lock.EventId = local
+	return locks.LockEvent(lock, now)
+}
+
+func (d *ShardedDataStore) GetEventLock(eventId int64) (*EventLock, error) {
+	store, local := d.shard(eventId)
+	locks, ok := capability[LockStore](store)
+	if !ok {
+		return nil, ErrorLocksNotSupported
+	}
+	lock, err := locks.GetEventLock(local)
+	if err != nil || lock == nil {
+		return nil, err
+	}
+	lock.EventId = eventId
+	return lock, nil
+}
+
+func (d *ShardedDataStore) UnlockEvent(eventId int64, userId int64) error {
+	store, local := d.shard(eventId)
+	locks, ok := capability[LockStore](store)
+	if !ok {
+		return ErrorLocksNotSupported
+	}
+	return locks.UnlockEvent(local, userId)
+}
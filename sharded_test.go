@@ -0,0 +1,93 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardedDataStore(t *testing.T) {
+	_, err := NewShardedDataStore(ShardByOwner)
+	require.Equal(t, ErrorNoShards, err)
+
+	shards := []*InMemoryDataStore{{}, {}, {}}
+	d, err := NewShardedDataStore(ShardByOwner, shards[0], shards[1], shards[2])
+	require.NoError(t, err)
+	c := NewCalendar(d)
+
+	var ids []int64
+	for owner := int64(0); owner < 3; owner++ {
+		day := "2008-01-0" + string(rune('3'-owner))
+		e, _, err := c.Create(Event{OwnerId: owner, StartDay: day, EndDay: day, IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		ids = append(ids, e.Id)
+		assert.Len(t, shards[owner].events, 1, "the event should be on the shard of the owner")
+	}
+	assert.Len(t, map[int64]bool{ids[0]: true, ids[1]: true, ids[2]: true}, 3, "ids should be unique across shards")
+
+	// the results of every shard are merged and sorted
+	events, err := c.Query(Query{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, []int64{ids[2], ids[1], ids[0]}, []int64{events[0].Id, events[1].Id, events[2].Id})
+
+	events, err = c.Query(Query{EventIds: []int64{ids[1]}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, ids[1], events[0].Id)
+
+	require.NoError(t, c.UpdateTitle(ids[1], "changed", RepeatEditTypeThis))
+	e, err := c.Get(ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, "changed", e.Title)
+
+	require.NoError(t, c.InviteUser(ids[2], 9, PermissionViewer, RepeatEditTypeThis))
+	invite, err := c.GetInvitation(ids[2], 9)
+	require.NoError(t, err)
+	require.NotNil(t, invite)
+	assert.Equal(t, ids[2], invite.EventId)
+	events, err = c.Query(Query{UserIds: []int64{9}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, ids[2], events[0].Id)
+}
+
+func TestShardedPlainDataStore(t *testing.T) {
+	store, err := NewShardedDataStore(nil, plainStore{&InMemoryDataStore{}}, plainStore{&InMemoryDataStore{}})
+	require.NoError(t, err)
+	assertPlainWrapper(t, store)
+}
+
+func TestShardedDataStoreRepeatingEvents(t *testing.T) {
+	shards := []*InMemoryDataStore{{}, {}}
+	d, err := NewShardedDataStore(ShardByCalendar, shards[0], shards[1])
+	require.NoError(t, err)
+	c := NewCalendar(d)
+
+	e, count, err := c.Create(Event{
+		CalendarId:  1,
+		StartDay:    "2008-01-01",
+		EndDay:      "2008-01-01",
+		Zone:        "UTC",
+		IsAllDay:    true,
+		IsRepeating: true,
+		Repeat:      &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+	assert.Len(t, shards[1].events, 3, "the series should stay on one shard")
+	require.NotNil(t, e.ParentId)
+	assert.Equal(t, e.Id, *e.ParentId)
+
+	events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	require.NoError(t, c.UpdateTitle(e.Id, "all", RepeatEditTypeAll))
+	events, err = c.Query(Query{ParentIds: []int64{e.Id}})
+	require.NoError(t, err)
+	for _, event := range events {
+		assert.Equal(t, "all", event.Title)
+	}
+}
@@ -0,0 +1,96 @@
+package cali
+
+import (
+	"time"
+)
+
+// ShiftDayTime moves the YYYY-MM-DD day and HH:MM time by the delta as a wall clock time,
+// so the day rolls over when the time crosses midnight
+func ShiftDayTime(day, hourMin string, delta time.Duration) (string, string, error) {
+	t, err := parseDayTime(day, hourMin)
+	if err != nil {
+		return "", "", err
+	}
+	t = t.Add(delta)
+	return t.Format(time.DateOnly), formatTime(t), nil
+}
+
+// ShiftEvent moves the start of the event by the delta and sets its end to the duration after
+// the start, rolling the StartDay and EndDay over when the times cross midnight. All day events
+// are only moved by the whole days of the delta and keep their length.
+func ShiftEvent(e Event, delta, duration time.Duration) (Event, error) {
+	start, err := e.Start()
+	if err != nil {
+		return e, ErrorInvalidStartDay
+	}
+	if e.IsAllDay {
+		end, err := e.End()
+		if err != nil {
+			return e, ErrorInvalidEndDay
+		}
+		days := int(delta / (24 * time.Hour))
+		e.StartDay = start.AddDate(0, 0, days).Format(time.DateOnly)
+		e.EndDay = end.AddDate(0, 0, days).Format(time.DateOnly)
+		return e, nil
+	}
+	start = start.Add(delta)
+	end := start.Add(duration)
+	e.StartDay, e.StartTime = start.Format(time.DateOnly), formatTime(start)
+	e.EndDay, e.EndTime = end.Format(time.DateOnly), formatTime(end)
+	return e, nil
+}
+
+// ShiftTime moves the events forward (or backward for a negative delta) by the delta and keeps
+// the length of each event, like moving a 23:30 event forward an hour to 00:30 on the next day
+func (c *Calendar) ShiftTime(eventId int64, delta time.Duration, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		return c.editField(OverrideTime, editType, eventId, func(eventId int64) error {
+			return c.shiftEvent(eventId, func(e Event) (Event, error) {
+				start, end, err := e.span()
+				if err != nil {
+					return e, err
+				}
+				return ShiftEvent(e, delta, end.Sub(start))
+			})
+		})
+	})
+}
+
+// timeChange gets how far the start of the event moves and how long the event is when its times
+// change to the start and end time on the same days, where an end before the start is on the next day
+func timeChange(e Event, startTime, endTime string) (time.Duration, time.Duration, error) {
+	oldStart, err := e.Start()
+	if err != nil {
+		return 0, 0, ErrorInvalidStartDay
+	}
+	start, err := parseDayTime(e.StartDay, startTime)
+	if err != nil {
+		return 0, 0, ErrorInvalidStartTime
+	}
+	end, err := parseDayTime(e.EndDay, endTime)
+	if err != nil {
+		return 0, 0, ErrorInvalidEndTime
+	}
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start.Sub(oldStart), end.Sub(start), nil
+}
+
+// shiftEvent saves the day and time of the event after the shift
+func (c *Calendar) shiftEvent(eventId int64, shift func(e Event) (Event, error)) error {
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return ErrorEventNotFound
+	}
+	shifted, err := shift(*e)
+	if err != nil {
+		return err
+	}
+	return c.notifyChanges(eventId, func() error {
+		return c.dataStore.SetDayTime(eventId, shifted.StartDay, shifted.StartTime, shifted.EndDay, shifted.EndTime, shifted.Zone, shifted.IsAllDay)
+	})
+}
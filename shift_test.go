@@ -0,0 +1,132 @@
+package cali
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShiftDayTime(t *testing.T) {
+	day, hourMin, err := ShiftDayTime("2008-12-31", "23:30", time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, "2009-01-01", day)
+	assert.Equal(t, "00:30", hourMin)
+
+	day, hourMin, err = ShiftDayTime("2008-03-01", "00:15", -30*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "2008-02-29", day)
+	assert.Equal(t, "23:45", hourMin)
+
+	_, _, err = ShiftDayTime("", "00:15", time.Hour)
+	assert.Error(t, err)
+}
+
+func TestShiftEventAllDay(t *testing.T) {
+	e := Event{IsAllDay: true, StartDay: "2008-01-01", EndDay: "2008-01-02"}
+	shifted, err := ShiftEvent(e, 49*time.Hour, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "2008-01-03", shifted.StartDay)
+	assert.Equal(t, "2008-01-04", shifted.EndDay)
+}
+
+func TestUpdateTimeAcrossMidnight(t *testing.T) {
+	testCases := []struct {
+		name      string
+		startTime string
+		endTime   string
+		editType  RepeatEditType
+		shift     time.Duration
+		times     []string
+	}{
+		{
+			name:      "end crosses midnight",
+			startTime: "23:30",
+			endTime:   "00:30",
+			editType:  RepeatEditTypeAll,
+			times: []string{
+				"2008-01-01 23:30 - 2008-01-02 00:30",
+				"2008-01-02 23:30 - 2008-01-03 00:30",
+				"2008-01-03 23:30 - 2008-01-04 00:30",
+			},
+		},
+		{
+			name:      "end crosses midnight for this and after",
+			startTime: "23:00",
+			endTime:   "01:00",
+			editType:  RepeatEditTypeThisAndAfter,
+			times: []string{
+				"2008-01-01 22:30 - 2008-01-01 23:30",
+				"2008-01-02 23:00 - 2008-01-03 01:00",
+				"2008-01-03 23:00 - 2008-01-04 01:00",
+			},
+		},
+		{
+			name:     "shift forward an hour",
+			shift:    time.Hour,
+			editType: RepeatEditTypeAll,
+			times: []string{
+				"2008-01-01 23:30 - 2008-01-02 00:30",
+				"2008-01-02 23:30 - 2008-01-03 00:30",
+				"2008-01-03 23:30 - 2008-01-04 00:30",
+			},
+		},
+		{
+			name:     "shift across midnight",
+			shift:    90 * time.Minute,
+			editType: RepeatEditTypeAll,
+			times: []string{
+				"2008-01-02 00:00 - 2008-01-02 01:00",
+				"2008-01-03 00:00 - 2008-01-03 01:00",
+				"2008-01-04 00:00 - 2008-01-04 01:00",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(&InMemoryDataStore{})
+			a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "22:30", EndDay: "2008-01-01", EndTime: "23:30", Zone: den,
+				IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+			require.NoError(t, err)
+			events, err := c.Query(Query{ParentIds: []int64{*a.ParentId}})
+			require.NoError(t, err)
+			require.Len(t, events, 3)
+
+			if tc.shift != 0 {
+				require.NoError(t, c.ShiftTime(events[1].Id, tc.shift, tc.editType))
+			} else {
+				require.NoError(t, c.UpdateTimeOvernight(events[1].Id, tc.startTime, tc.endTime, tc.editType))
+			}
+			events, err = c.Query(Query{ParentIds: []int64{*a.ParentId}})
+			require.NoError(t, err)
+			var times []string
+			for _, e := range events {
+				times = append(times, fmt.Sprintf("%s %s - %s %s", e.StartDay, e.StartTime, e.EndDay, e.EndTime))
+			}
+			assert.Equal(t, tc.times, times)
+		})
+	}
+}
+
+func TestUpdateTimeEndBeforeStart(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", StartTime: "22:30", EndDay: "2008-01-01", EndTime: "23:30", Zone: den,
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+	require.NoError(t, err)
+
+	for _, editType := range []RepeatEditType{RepeatEditTypeThis, RepeatEditTypeAll} {
+		assert.Equal(t, ErrorStartTimeIsAfterEndTime, c.UpdateTime(a.Id, "23:30", "00:30", editType))
+	}
+	events, err := c.Query(Query{ParentIds: []int64{*a.ParentId}})
+	require.NoError(t, err)
+	for _, e := range events {
+		assert.Equal(t, "22:30", e.StartTime, "the events aren't changed")
+		assert.Equal(t, "23:30", e.EndTime)
+		assert.Equal(t, e.StartDay, e.EndDay)
+	}
+}
@@ -0,0 +1,471 @@
+package cali
+
+import (
+	"time"
+)
+
+// Availability is a recurring window of time that other users can book slots in,
+// like office hours on Tuesdays and Thursdays from 13:00 to 16:00 in 30 minute slots
+type Availability struct {
+	// Id is the unique id for this availability
+	Id int64 `json:"id"`
+	// OwnerId is the user that the booked events belong to
+	OwnerId int64 `json:"ownerId"`
+	// CalendarId is the calendar that the booked events are a part of
+	CalendarId int64 `json:"calendarId"`
+	// EventType is the type of the booked events
+	EventType EventType `json:"eventType"`
+	// Title is the title of the booked events
+	Title string `json:"title"`
+	// Hours are the days of the week and the times of the day that can be booked
+	Hours WorkingHours `json:"hours"`
+	// SlotMinutes is the length of each slot, the hours are split into as many slots as fit
+	SlotMinutes int64 `json:"slotMinutes"`
+	// StartDay is the YYYY-MM-DD value of the first day that can be booked
+	StartDay string `json:"startDay"`
+	// EndDay is the YYYY-MM-DD value of the last day that can be booked, or nil if there isn't one
+	EndDay *string `json:"endDay"`
+	// ConfirmMinutes is how long a new booking has to be confirmed (see Calendar.ConfirmBooking)
+	// before it expires and the slot can be booked again, or 0 if bookings don't need to be confirmed.
+	// The deadline is never after the start of the slot.
+	ConfirmMinutes int64 `json:"confirmMinutes"`
+	// CancelNoticeMinutes is how long before the start of the slot a booking can no longer be
+	// canceled, like 1440 for bookings that can't be canceled within 24 hours, or 0 for any time
+	CancelNoticeMinutes int64 `json:"cancelNoticeMinutes"`
+	// Created is a timestamp for when the availability was created
+	Created time.Time `json:"created"`
+	// Updated is a timestamp for when the availability was modified last
+	Updated time.Time `json:"updated"`
+}
+
+// Slot is a single piece of an availability that can be booked
+type Slot struct {
+	// AvailabilityId is the availability that the slot is from
+	AvailabilityId int64 `json:"availabilityId"`
+	// Day is the YYYY-MM-DD value of the slot in the zone of the availability
+	Day string `json:"day"`
+	// StartTime is the HH:MM value of when the slot starts
+	StartTime string `json:"startTime"`
+	// EndTime is the HH:MM value of when the slot ends
+	EndTime string `json:"endTime"`
+	// BookingId is the booking of the slot, or nil if the slot is open
+	BookingId *int64 `json:"bookingId"`
+}
+
+// BookingStatus is the state of a booked slot
+type BookingStatus int64
+
+const (
+	// BookingStatusBooked is for a slot that is taken
+	BookingStatusBooked BookingStatus = 0
+	// BookingStatusCanceled is for a booking that was canceled, and the slot can be booked again
+	BookingStatusCanceled BookingStatus = 1
+	// BookingStatusPending is for a booking that has to be confirmed before its ConfirmBy deadline,
+	// and the slot is held until then
+	BookingStatusPending BookingStatus = 2
+)
+
+// Booking is a slot of an availability that a user booked
+type Booking struct {
+	// Id is the unique id for this booking
+	Id int64 `json:"id"`
+	// AvailabilityId is the availability that the slot is from
+	AvailabilityId int64 `json:"availabilityId"`
+	// UserId is the user that booked the slot
+	UserId int64 `json:"userId"`
+	// EventId is the event that was created for the booking
+	EventId int64 `json:"eventId"`
+	// Day is the YYYY-MM-DD value of the slot
+	Day string `json:"day"`
+	// StartTime is the HH:MM value of when the slot starts
+	StartTime string `json:"startTime"`
+	// Status is whether the slot is still booked
+	Status BookingStatus `json:"status"`
+	// ConfirmBy is the deadline to confirm a booking with BookingStatusPending
+	ConfirmBy *time.Time `json:"confirmBy"`
+	// Created is a timestamp for when the slot was booked
+	Created time.Time `json:"created"`
+	// Updated is a timestamp for when the booking was modified last
+	Updated time.Time `json:"updated"`
+}
+
+// Holds returns true if the booking keeps other users from booking its slot at the time,
+// which is until it is canceled or until the deadline to confirm it has passed
+func (b Booking) Holds(now time.Time) bool {
+	switch b.Status {
+	case BookingStatusBooked:
+		return true
+	case BookingStatusPending:
+		return b.ConfirmBy == nil || now.Before(*b.ConfirmBy)
+	default:
+		return false
+	}
+}
+
+// expired returns true if the booking wasn't confirmed before its deadline
+func (b Booking) expired(now time.Time) bool {
+	return b.Status == BookingStatusPending && !b.Holds(now)
+}
+
+// SlotStore is an optional interface for a data store that can save availabilities and bookings
+type SlotStore interface {
+	// AddAvailability creates the availability and handles setting the Id, Created, and Updated fields
+	AddAvailability(a Availability) (*Availability, error)
+	// GetAvailability retrieves the availability or nil if there isn't one
+	GetAvailability(id int64) (*Availability, error)
+	// BookSlot creates the event and the booking (with its EventId) at the same time. If the
+	// slot already has a booking that holds it (see Booking.Holds), then it returns
+	// ErrorSlotAlreadyBooked and neither the event nor the booking is created.
+	BookSlot(b Booking, e Event) (*Booking, *Event, error)
+	// GetBooking retrieves the booking or nil if there isn't one
+	GetBooking(id int64) (*Booking, error)
+	// GetBookings retrieves the bookings (of any status) of the availability between the
+	// YYYY-MM-DD start and end days (inclusive)
+	GetBookings(availabilityId int64, startDay, endDay string) ([]*Booking, error)
+	// SetBookingStatus changes the status of the booking
+	SetBookingStatus(id int64, status BookingStatus) error
+}
+
+// ValidateAvailability makes sure the availability has valid hours and days, and
+// that at least one slot fits in the hours
+func ValidateAvailability(a Availability) error {
+	if err := ValidateWorkingHours(a.Hours); err != nil {
+		return err
+	}
+	if a.SlotMinutes <= 0 || a.ConfirmMinutes < 0 || a.CancelNoticeMinutes < 0 {
+		return ErrorInvalidAvailability
+	}
+	start, _ := time.Parse(TimeFormat, a.Hours.StartTime)
+	end, _ := time.Parse(TimeFormat, a.Hours.EndTime)
+	if start.Add(time.Duration(a.SlotMinutes) * time.Minute).After(end) {
+		return ErrorInvalidAvailability
+	}
+	endDay := a.StartDay
+	if a.EndDay != nil {
+		endDay = *a.EndDay
+	}
+	return ValidateDayValues(a.StartDay, endDay)
+}
+
+// Slots gets all of the slots of the availability that start at or after the start and before the end
+func (a Availability) Slots(start, end time.Time) ([]Slot, error) {
+	loc, err := time.LoadLocation(a.Hours.Zone)
+	if err != nil {
+		return nil, ErrorInvalidZone
+	}
+	open, err := time.Parse(TimeFormat, a.Hours.StartTime)
+	if err != nil {
+		return nil, ErrorInvalidStartTime
+	}
+	closing, err := time.Parse(TimeFormat, a.Hours.EndTime)
+	if err != nil {
+		return nil, ErrorInvalidEndTime
+	}
+	length := time.Duration(a.SlotMinutes) * time.Minute
+	if length <= 0 {
+		return nil, ErrorInvalidAvailability
+	}
+
+	var result []Slot
+	start, end = start.In(loc), end.In(loc)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		d := day.Format(time.DateOnly)
+		if d < a.StartDay || (a.EndDay != nil && d > *a.EndDay) || !a.Hours.DayOfWeek.HasFlag(dayOfWeekFromWeekday(day.Weekday())) {
+			continue
+		}
+		for t := open; !t.Add(length).After(closing); t = t.Add(length) {
+			slotStart := time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+			if slotStart.Before(start) || !slotStart.Before(end) {
+				continue
+			}
+			result = append(result, Slot{
+				AvailabilityId: a.Id,
+				Day:            d,
+				StartTime:      t.Format(TimeFormat),
+				EndTime:        t.Add(length).Format(TimeFormat),
+			})
+		}
+	}
+	return result, nil
+}
+
+// start gets the time that the slot starts in the zone of the availability
+func (a Availability) start(day, startTime string) (time.Time, error) {
+	loc, err := time.LoadLocation(a.Hours.Zone)
+	if err != nil {
+		return time.Time{}, ErrorInvalidZone
+	}
+	start, err := time.ParseInLocation(DayTimeFormat, day+" "+startTime, loc)
+	if err != nil {
+		return time.Time{}, ErrorInvalidSlot
+	}
+	return start, nil
+}
+
+// slot finds the slot of the availability on the day at the start time
+func (a Availability) slot(day, startTime string) (*Slot, error) {
+	start, err := a.start(day, startTime)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := a.Slots(start, start.Add(time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	if len(slots) == 0 {
+		return nil, ErrorInvalidSlot
+	}
+	return &slots[0], nil
+}
+
+// CreateAvailability validates and saves the availability so that its slots can be booked
+func (c *Calendar) CreateAvailability(a Availability) (*Availability, error) {
+	store, ok := capability[SlotStore](c.dataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	if err := ValidateAvailability(a); err != nil {
+		return nil, err
+	}
+	return store.AddAvailability(a)
+}
+
+// getAvailability gets the availability or returns ErrorAvailabilityNotFound
+func (c *Calendar) getAvailability(store SlotStore, availabilityId int64) (*Availability, error) {
+	a, err := store.GetAvailability(availabilityId)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrorAvailabilityNotFound
+	}
+	return a, nil
+}
+
+// Slots gets the slots of the availability that start at or after the start and before the
+// end, where the slots that are booked have the BookingId of their booking
+func (c *Calendar) Slots(availabilityId int64, start, end time.Time) ([]Slot, error) {
+	store, ok := capability[SlotStore](c.dataStore)
+	if !ok {
+		return nil, ErrorSlotsNotSupported
+	}
+	a, err := c.getAvailability(store, availabilityId)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := a.Slots(start, end)
+	if err != nil || len(slots) == 0 {
+		return slots, err
+	}
+	bookings, err := store.GetBookings(availabilityId, slots[0].Day, slots[len(slots)-1].Day)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	booked := map[string]int64{}
+	for _, b := range bookings {
+		if b.Holds(now) {
+			booked[b.Day+" "+b.StartTime] = b.Id
+		}
+	}
+	for i := range slots {
+		if id, ok := booked[slots[i].Day+" "+slots[i].StartTime]; ok {
+			slots[i].BookingId = &id
+		}
+	}
+	return slots, nil
+}
+
+// BookSlot books the slot of the availability on the day at the start time for the user. The
+// event for the slot is created at the same time as the booking so that a slot can't be booked
+// twice (ErrorSlotAlreadyBooked), and the user is invited to the event. If the availability has
+// ConfirmMinutes, then the booking (and the user's invitation) is pending until it is confirmed.
+func (c *Calendar) BookSlot(availabilityId int64, userId int64, day, startTime string) (*Booking, *Event, error) {
+	var booking *Booking
+	var event *Event
+	err := c.outboxTx(func(c *Calendar) error {
+		var err error
+		booking, event, err = c.bookSlot(availabilityId, userId, day, startTime)
+		return err
+	})
+	return booking, event, err
+}
+
+// bookSlot is BookSlot in the outbox transaction (see outboxTx)
+func (c *Calendar) bookSlot(availabilityId int64, userId int64, day, startTime string) (*Booking, *Event, error) {
+	store, ok := capability[SlotStore](c.dataStore)
+	if !ok {
+		return nil, nil, ErrorSlotsNotSupported
+	}
+	a, err := c.getAvailability(store, availabilityId)
+	if err != nil {
+		return nil, nil, err
+	}
+	slot, err := a.slot(day, startTime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	e := c.pendingApproval(Event{
+		OwnerId:    a.OwnerId,
+		CalendarId: a.CalendarId,
+		EventType:  a.EventType,
+		Title:      a.Title,
+		Zone:       a.Hours.Zone,
+		StartDay:   slot.Day,
+		StartTime:  slot.StartTime,
+		EndDay:     slot.Day,
+		EndTime:    slot.EndTime,
+	})
+	if err := Validate(e); err != nil {
+		return nil, nil, err
+	}
+	if err := c.expireBookings(store, availabilityId, slot.Day); err != nil {
+		return nil, nil, err
+	}
+	b := Booking{
+		AvailabilityId: availabilityId,
+		UserId:         userId,
+		Day:            slot.Day,
+		StartTime:      slot.StartTime,
+		Status:         BookingStatusBooked,
+	}
+	inviteStatus := InviteStatusConfirmed
+	if a.ConfirmMinutes > 0 {
+		start, err := a.start(slot.Day, slot.StartTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		confirmBy := time.Now().Add(time.Duration(a.ConfirmMinutes) * time.Minute)
+		if confirmBy.After(start) {
+			confirmBy = start
+		}
+		b.Status = BookingStatusPending
+		b.ConfirmBy = &confirmBy
+		inviteStatus = InviteStatusPending
+	}
+	booking, event, err := store.BookSlot(b, e)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := c.publish(ChangeTypeCreated, event.Id, nil); err != nil {
+		return booking, event, err
+	}
+	if userId != a.OwnerId {
+		_, err := c.dataStore.AddInvite(Invite{
+			EventId:    event.Id,
+			UserId:     userId,
+			Status:     inviteStatus,
+			Permission: PermissionInvitee,
+		})
+		if err != nil {
+			return booking, event, err
+		}
+		if err := c.publish(ChangeTypeInvite, event.Id, &userId); err != nil {
+			return booking, event, err
+		}
+	}
+	return booking, event, nil
+}
+
+// ConfirmBooking confirms a pending booking (and the user's invitation) before its deadline,
+// otherwise the booking is canceled and it returns ErrorBookingConfirmationExpired
+func (c *Calendar) ConfirmBooking(bookingId int64) error {
+	store, ok := capability[SlotStore](c.dataStore)
+	if !ok {
+		return ErrorSlotsNotSupported
+	}
+	b, err := c.getBooking(store, bookingId)
+	if err != nil {
+		return err
+	}
+	switch {
+	case b.Status == BookingStatusBooked:
+		return nil
+	case b.Status == BookingStatusCanceled:
+		return ErrorBookingCanceled
+	case b.expired(time.Now()):
+		if err := c.cancelBooking(store, b); err != nil {
+			return err
+		}
+		return ErrorBookingConfirmationExpired
+	}
+	if err := store.SetBookingStatus(bookingId, BookingStatusBooked); err != nil {
+		return err
+	}
+	invite, err := c.dataStore.GetInvite(b.EventId, b.UserId)
+	if err != nil || invite == nil || invite.Status != InviteStatusPending {
+		return err
+	}
+	return c.AcceptInvitation(b.EventId, b.UserId, RepeatEditTypeThis)
+}
+
+// CancelBooking cancels the booking and its event so that the slot can be booked again. If the
+// availability has CancelNoticeMinutes, then a booking can't be canceled that close to the start
+// of the slot (ErrorCancellationWindowClosed).
+func (c *Calendar) CancelBooking(bookingId int64) error {
+	store, ok := capability[SlotStore](c.dataStore)
+	if !ok {
+		return ErrorSlotsNotSupported
+	}
+	b, err := c.getBooking(store, bookingId)
+	if err != nil {
+		return err
+	}
+	if b.Status == BookingStatusCanceled {
+		return nil
+	}
+	a, err := c.getAvailability(store, b.AvailabilityId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if a.CancelNoticeMinutes > 0 && !b.expired(now) {
+		start, err := a.start(b.Day, b.StartTime)
+		if err != nil {
+			return err
+		}
+		if now.After(start.Add(-time.Duration(a.CancelNoticeMinutes) * time.Minute)) {
+			return ErrorCancellationWindowClosed
+		}
+	}
+	return c.cancelBooking(store, b)
+}
+
+// getBooking gets the booking or returns ErrorBookingNotFound
+func (c *Calendar) getBooking(store SlotStore, bookingId int64) (*Booking, error) {
+	b, err := store.GetBooking(bookingId)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, ErrorBookingNotFound
+	}
+	return b, nil
+}
+
+// cancelBooking sets the status of the booking to BookingStatusCanceled and cancels its event
+func (c *Calendar) cancelBooking(store SlotStore, b *Booking) error {
+	if err := store.SetBookingStatus(b.Id, BookingStatusCanceled); err != nil {
+		return err
+	}
+	return c.Cancel(b.EventId, RepeatEditTypeThis)
+}
+
+// expireBookings cancels the bookings of the availability on the day that weren't confirmed in time
+func (c *Calendar) expireBookings(store SlotStore, availabilityId int64, day string) error {
+	bookings, err := store.GetBookings(availabilityId, day, day)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, b := range bookings {
+		if b.expired(now) {
+			if err := c.cancelBooking(store, b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func officeHours() Availability {
+	endDay := "2008-01-31"
+	return Availability{
+		OwnerId:     1,
+		CalendarId:  2,
+		Title:       "Office hours",
+		Hours:       WorkingHours{Zone: "America/New_York", DayOfWeek: DayOfWeekTuesday | DayOfWeekThursday, StartTime: "13:00", EndTime: "16:00"},
+		SlotMinutes: 30,
+		StartDay:    "2008-01-01",
+		EndDay:      &endDay,
+	}
+}
+
+func TestValidateAvailability(t *testing.T) {
+	testCases := []struct {
+		name string
+		edit func(a *Availability)
+		err  error
+	}{
+		{name: "valid", edit: func(a *Availability) {}},
+		{name: "no end day", edit: func(a *Availability) { a.EndDay = nil }},
+		{name: "no days of the week", edit: func(a *Availability) { a.Hours.DayOfWeek = 0 }, err: ErrorInvalidWorkingHours},
+		{name: "no slot length", edit: func(a *Availability) { a.SlotMinutes = 0 }, err: ErrorInvalidAvailability},
+		{name: "slot longer than the hours", edit: func(a *Availability) { a.SlotMinutes = 181 }, err: ErrorInvalidAvailability},
+		{name: "end day before start day", edit: func(a *Availability) { a.StartDay = "2008-02-01" }, err: ErrorStartDayIsAfterEndDay},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			a := officeHours()
+			tc.edit(&a)
+			assert.Equal(t, tc.err, ValidateAvailability(a))
+		})
+	}
+}
+
+func TestAvailabilitySlots(t *testing.T) {
+	a := officeHours()
+	loc, err := time.LoadLocation(a.Hours.Zone)
+	require.NoError(t, err)
+
+	slots, err := a.Slots(time.Date(2008, 1, 1, 0, 0, 0, 0, loc), time.Date(2008, 1, 8, 0, 0, 0, 0, loc))
+	require.NoError(t, err)
+	require.Len(t, slots, 12)
+	assert.Equal(t, Slot{Day: "2008-01-01", StartTime: "13:00", EndTime: "13:30"}, slots[0])
+	assert.Equal(t, Slot{Day: "2008-01-01", StartTime: "15:30", EndTime: "16:00"}, slots[5])
+	assert.Equal(t, Slot{Day: "2008-01-03", StartTime: "13:00", EndTime: "13:30"}, slots[6])
+
+	// the start and end are in UTC, so this is 14:00 to 15:00 in New York
+	slots, err = a.Slots(time.Date(2008, 1, 1, 19, 0, 0, 0, time.UTC), time.Date(2008, 1, 1, 20, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, slots, 2)
+	assert.Equal(t, "14:00", slots[0].StartTime)
+	assert.Equal(t, "14:30", slots[1].StartTime)
+
+	slots, err = a.Slots(time.Date(2008, 1, 31, 0, 0, 0, 0, loc), time.Date(2008, 2, 29, 0, 0, 0, 0, loc))
+	require.NoError(t, err)
+	assert.Len(t, slots, 6, "only the last day of the availability")
+}
+
+func TestBookSlot(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	sharded, err := NewShardedDataStore(ShardByCalendar, &InMemoryDataStore{}, &InMemoryDataStore{}, &InMemoryDataStore{})
+	require.NoError(t, err)
+	testCases := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: sharded},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store)
+			a, err := c.CreateAvailability(officeHours())
+			require.NoError(t, err)
+
+			booking, e, err := c.BookSlot(a.Id, 5, "2008-01-03", "14:30")
+			require.NoError(t, err)
+			assert.Equal(t, e.Id, booking.EventId)
+			assert.Equal(t, int64(1), e.OwnerId)
+			assert.Equal(t, int64(2), e.CalendarId)
+			assert.Equal(t, "Office hours", e.Title)
+			assert.Equal(t, "2008-01-03", e.StartDay)
+			assert.Equal(t, "14:30", e.StartTime)
+			assert.Equal(t, "15:00", e.EndTime)
+			invite, err := c.GetInvitation(e.Id, 5)
+			require.NoError(t, err)
+			require.NotNil(t, invite)
+			assert.Equal(t, InviteStatusConfirmed, invite.Status)
+
+			_, _, err = c.BookSlot(a.Id, 6, "2008-01-03", "14:30")
+			assert.Equal(t, ErrorSlotAlreadyBooked, err)
+			_, _, err = c.BookSlot(a.Id, 6, "2008-01-03", "14:15")
+			assert.Equal(t, ErrorInvalidSlot, err)
+			_, _, err = c.BookSlot(a.Id, 6, "2008-01-02", "14:30")
+			assert.Equal(t, ErrorInvalidSlot, err, "not a day of the availability")
+
+			loc, err := time.LoadLocation("America/New_York")
+			require.NoError(t, err)
+			slots, err := c.Slots(a.Id, time.Date(2008, 1, 3, 0, 0, 0, 0, loc), time.Date(2008, 1, 4, 0, 0, 0, 0, loc))
+			require.NoError(t, err)
+			require.Len(t, slots, 6)
+			require.NotNil(t, slots[3].BookingId)
+			assert.Equal(t, booking.Id, *slots[3].BookingId)
+			assert.Nil(t, slots[2].BookingId)
+
+			require.NoError(t, c.CancelBooking(booking.Id))
+			e, err = c.Get(e.Id)
+			require.NoError(t, err)
+			assert.Equal(t, StatusCanceled, e.Status)
+			_, _, err = c.BookSlot(a.Id, 6, "2008-01-03", "14:30")
+			assert.NoError(t, err, "a canceled slot can be booked again")
+		})
+	}
+}
+
+func TestSlotsNotSupported(t *testing.T) {
+	c := NewCalendar(struct{ DataStore }{&InMemoryDataStore{}})
+	_, err := c.CreateAvailability(officeHours())
+	assert.Equal(t, ErrorSlotsNotSupported, err)
+}
+
+func dailyHours(confirmMinutes, cancelNoticeMinutes int64) Availability {
+	return Availability{
+		OwnerId:             1,
+		Title:               "Appointment",
+		Hours:               WorkingHours{Zone: "UTC", DayOfWeek: DayOfWeekSunday | DayOfWeekMonday | DayOfWeekTuesday | DayOfWeekWednesday | DayOfWeekThursday | DayOfWeekFriday | DayOfWeekSaturday, StartTime: "09:00", EndTime: "10:00"},
+		SlotMinutes:         60,
+		StartDay:            time.Now().UTC().Format(time.DateOnly),
+		ConfirmMinutes:      confirmMinutes,
+		CancelNoticeMinutes: cancelNoticeMinutes,
+	}
+}
+
+func TestConfirmBooking(t *testing.T) {
+	store := &InMemoryDataStore{}
+	c := NewCalendar(store)
+	a, err := c.CreateAvailability(dailyHours(60, 0))
+	require.NoError(t, err)
+	day := func(days int) string {
+		return time.Now().UTC().AddDate(0, 0, days).Format(time.DateOnly)
+	}
+
+	booking, e, err := c.BookSlot(a.Id, 5, day(3), "09:00")
+	require.NoError(t, err)
+	assert.Equal(t, BookingStatusPending, booking.Status)
+	require.NotNil(t, booking.ConfirmBy)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *booking.ConfirmBy, time.Minute)
+	invite, err := c.GetInvitation(e.Id, 5)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusPending, invite.Status)
+	_, _, err = c.BookSlot(a.Id, 6, day(3), "09:00")
+	assert.Equal(t, ErrorSlotAlreadyBooked, err, "a pending booking holds the slot")
+
+	require.NoError(t, c.ConfirmBooking(booking.Id))
+	booking, err = store.GetBooking(booking.Id)
+	require.NoError(t, err)
+	assert.Equal(t, BookingStatusBooked, booking.Status)
+	invite, err = c.GetInvitation(e.Id, 5)
+	require.NoError(t, err)
+	assert.Equal(t, InviteStatusConfirmed, invite.Status)
+
+	// the deadline to confirm is never after the start of the slot
+	booking, _, err = c.BookSlot(a.Id, 5, day(0), "09:00")
+	require.NoError(t, err)
+	assert.False(t, booking.ConfirmBy.After(time.Now().Add(time.Hour)))
+
+	past := time.Now().Add(-time.Minute)
+	expired, e, err := c.BookSlot(a.Id, 5, day(4), "09:00")
+	require.NoError(t, err)
+	expired.ConfirmBy = &past
+	assert.Equal(t, ErrorBookingConfirmationExpired, c.ConfirmBooking(expired.Id))
+	assert.Equal(t, ErrorBookingCanceled, c.ConfirmBooking(expired.Id))
+	e, err = c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, e.Status)
+
+	expired, e, err = c.BookSlot(a.Id, 5, day(5), "09:00")
+	require.NoError(t, err)
+	expired.ConfirmBy = &past
+	_, _, err = c.BookSlot(a.Id, 6, day(5), "09:00")
+	require.NoError(t, err, "a booking that wasn't confirmed in time doesn't hold the slot")
+	assert.Equal(t, BookingStatusCanceled, expired.Status)
+	e, err = c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, e.Status)
+}
+
+func TestCancelBookingNotice(t *testing.T) {
+	day := time.Now().UTC().AddDate(0, 0, 3).Format(time.DateOnly)
+	testCases := []struct {
+		name   string
+		notice int64
+		err    error
+	}{
+		{name: "no notice", notice: 0},
+		{name: "outside of the notice", notice: 24 * 60},
+		{name: "inside of the notice", notice: 10 * 24 * 60, err: ErrorCancellationWindowClosed},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(&InMemoryDataStore{})
+			a, err := c.CreateAvailability(dailyHours(0, tc.notice))
+			require.NoError(t, err)
+			booking, e, err := c.BookSlot(a.Id, 5, day, "09:00")
+			require.NoError(t, err)
+			assert.Equal(t, BookingStatusBooked, booking.Status)
+			assert.Nil(t, booking.ConfirmBy)
+
+			assert.Equal(t, tc.err, c.CancelBooking(booking.Id))
+			e, err = c.Get(e.Id)
+			require.NoError(t, err)
+			if tc.err == nil {
+				assert.Equal(t, StatusCanceled, e.Status)
+			} else {
+				assert.Equal(t, StatusActive, e.Status)
+			}
+		})
+	}
+}
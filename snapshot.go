@@ -0,0 +1,207 @@
+package cali
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// SnapshotFormat is the format version of the snapshots written by Snapshot
+const SnapshotFormat = 1
+
+// snapshot is what Snapshot writes: every event of the data store with its audit log, which
+// has a version of the event for every change (see AuditEntry)
+type snapshot struct {
+	Format int           `json:"format"`
+	Taken  time.Time     `json:"taken"`
+	Events []*Event      `json:"events"`
+	Audit  []*AuditEntry `json:"audit"`
+}
+
+// Snapshot writes every event of the calendar (in every status) and its audit log to w as
+// JSON. The audit log only has versions of the events for the changes made with
+// WithAuditLog, so the calendar should have it for as long as it might need to be rolled
+// back (see RestoreSnapshot).
+func (c *Calendar) Snapshot(w io.Writer) error {
+	store, ok := capability[AuditStore](c.dataStore)
+	if !ok {
+		return ErrorAuditLogNotSupported
+	}
+	events, err := c.dataStore.Query(Query{Statuses: Statuses})
+	if err != nil {
+		return err
+	}
+	s := snapshot{Format: SnapshotFormat, Taken: time.Now().UTC(), Events: events}
+	for _, e := range events {
+		entries, err := store.GetAuditEntries(e.Id)
+		if err != nil {
+			return err
+		}
+		s.Audit = append(s.Audit, entries...)
+	}
+	return json.NewEncoder(w).Encode(s)
+}
+
+// RestoreSnapshot rolls the events of the snapshot back to how they were at the time, like
+// after a bad bulk import. Each event gets the version from its last audit entry at or
+// before the time, and events that were created after the time are removed (see
+// StatusRemoved). Every event that is changed publishes a ChangeTypeUpdated change, so the
+// restore is in the audit log too.
+//
+// An event is left as it is if it was changed after the time but has no version from
+// before it (like when the audit log was turned on later), or if it isn't in the data store
+// anymore. Events that aren't in the snapshot, and the invites of all events, are never
+// changed.
+func (c *Calendar) RestoreSnapshot(r io.Reader, at time.Time) error {
+	return c.outboxTx(func(c *Calendar) error {
+		var s snapshot
+		if err := json.NewDecoder(r).Decode(&s); err != nil {
+			return err
+		}
+		if s.Format != SnapshotFormat {
+			return ErrorInvalidSnapshot
+		}
+		versions := map[int64][]*AuditEntry{}
+		for _, entry := range s.Audit {
+			if entry.Event != nil {
+				versions[entry.EventId] = append(versions[entry.EventId], entry)
+			}
+		}
+		var restored []int64
+		err := c.inTx(func(tx *Calendar) error {
+			for _, e := range s.Events {
+				target, ok := versionAt(*e, versions[e.Id], at)
+				if !ok {
+					continue
+				}
+				changed, err := tx.restoreEvent(e.Id, target)
+				if err != nil {
+					return err
+				}
+				if changed {
+					restored = append(restored, e.Id)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range restored {
+			if err := c.publish(ChangeTypeUpdated, id, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// versionAt finds the version of the event at the time from its audit entries, where a nil
+// version means that the event didn't exist yet. It returns false if the version isn't known.
+func versionAt(e Event, entries []*AuditEntry, at time.Time) (*Event, bool) {
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	var version *Event
+	for _, entry := range entries {
+		if entry.Time.After(at) {
+			break
+		}
+		version = entry.Event
+	}
+	switch {
+	case version != nil:
+		return version, true
+	case e.Created.After(at):
+		return nil, true
+	case len(entries) == 0:
+		return &e, true
+	default:
+		return nil, false
+	}
+}
+
+// restoreEvent sets the fields of the event in the data store that differ from the version,
+// or removes the event if the version is nil, and returns true if anything was changed
+func (c *Calendar) restoreEvent(eventId int64, version *Event) (bool, error) {
+	current, err := c.dataStore.Get(eventId)
+	if err != nil || current == nil {
+		return false, err
+	}
+	e := *current
+	if version == nil {
+		if e.Status == StatusRemoved {
+			return false, nil
+		}
+		if err := c.authorize(OperationRemove, eventId, nil); err != nil {
+			return false, err
+		}
+		return true, c.dataStore.SetStatus(eventId, StatusRemoved)
+	}
+	if err := c.authorize(OperationUpdate, eventId, nil); err != nil {
+		return false, err
+	}
+	v := *version
+	var edits []func() error
+	if e.StartDay != v.StartDay || e.StartTime != v.StartTime || e.EndDay != v.EndDay || e.EndTime != v.EndTime || e.Zone != v.Zone || e.IsAllDay != v.IsAllDay {
+		edits = append(edits, func() error {
+			return c.dataStore.SetDayTime(eventId, v.StartDay, v.StartTime, v.EndDay, v.EndTime, v.Zone, v.IsAllDay)
+		})
+	}
+	if e.Status != v.Status {
+		edits = append(edits, func() error { return c.dataStore.SetStatus(eventId, v.Status) })
+	}
+	if store, ok := capability[CancelReasonStore](c.dataStore); ok && !equalOptional(e.CancelReason, v.CancelReason) {
+		edits = append(edits, func() error { return store.SetCancelReason(eventId, v.CancelReason) })
+	}
+	if e.Title != v.Title {
+		edits = append(edits, func() error { return c.dataStore.SetTitle(eventId, v.Title) })
+	}
+	if !equalOptional(e.Description, v.Description) {
+		edits = append(edits, func() error { return c.dataStore.SetDescription(eventId, v.Description) })
+	}
+	if !equalOptional(e.Url, v.Url) {
+		edits = append(edits, func() error { return c.dataStore.SetUrl(eventId, v.Url) })
+	}
+	if store, ok := capability[LinkStore](c.dataStore); ok && !reflect.DeepEqual(e.Links, v.Links) {
+		edits = append(edits, func() error { return store.SetLinks(eventId, v.Links) })
+	}
+	if store, ok := capability[LocationStore](c.dataStore); ok && !equalOptional(e.Location, v.Location) {
+		edits = append(edits, func() error { return store.SetLocation(eventId, v.Location) })
+	}
+	if store, ok := capability[GeoPointStore](c.dataStore); ok && !reflect.DeepEqual(e.Geo, v.Geo) {
+		edits = append(edits, func() error { return store.SetGeo(eventId, v.Geo) })
+	}
+	if store, ok := capability[VisibilityStore](c.dataStore); ok && e.Visibility != v.Visibility {
+		edits = append(edits, func() error { return store.SetVisibility(eventId, v.Visibility) })
+	}
+	if store, ok := capability[ForwardingStore](c.dataStore); ok && e.DisallowForwarding != v.DisallowForwarding {
+		edits = append(edits, func() error { return store.SetDisallowForwarding(eventId, v.DisallowForwarding) })
+	}
+	if store, ok := capability[PriorityStore](c.dataStore); ok && e.Priority != v.Priority {
+		edits = append(edits, func() error { return store.SetPriority(eventId, v.Priority) })
+	}
+	if store, ok := capability[ConferenceStore](c.dataStore); ok && !reflect.DeepEqual(e.Conference, v.Conference) {
+		edits = append(edits, func() error { return store.SetConference(eventId, v.Conference) })
+	}
+	if !equalUserData(e.UserData, v.UserData) {
+		edits = append(edits, func() error { return c.dataStore.SetUserData(eventId, v.UserData) })
+	}
+	for _, edit := range edits {
+		if err := edit(); err != nil {
+			return false, err
+		}
+	}
+	return len(edits) > 0, nil
+}
+
+// equalUserData compares user data by its JSON, since numbers read from a snapshot are
+// always float64
+func equalUserData(a, b map[string]interface{}) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && string(x) == string(y)
+}
@@ -0,0 +1,73 @@
+package cali
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	encryptor, err := NewAESEncryptor([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	tests := []struct {
+		name  string
+		store DataStore
+	}{
+		{name: "in memory", store: &InMemoryDataStore{}},
+		{name: "encrypted", store: NewEncryptedDataStore(&InMemoryDataStore{}, encryptor)},
+		{name: "sharded", store: &ShardedDataStore{Shards: []DataStore{&InMemoryDataStore{}, &InMemoryDataStore{}}}},
+		{name: "replicated", store: NewReplicatedDataStore(&InMemoryDataStore{}, &InMemoryDataStore{}, time.Hour)},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store, WithAuditLog())
+			standup, _, err := c.Create(Event{OwnerId: 1, Title: "Standup", Zone: "UTC", StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15"})
+			require.NoError(t, err)
+			lunch, _, err := c.Create(Event{OwnerId: 1, Title: "Lunch", Zone: "UTC", StartDay: "2008-01-01", StartTime: "12:00", EndDay: "2008-01-01", EndTime: "13:00"})
+			require.NoError(t, err)
+			require.NoError(t, c.UpdateTitle(standup.Id, "Daily standup", RepeatEditTypeThis))
+			good := time.Now()
+
+			// a bad import
+			require.NoError(t, c.UpdateTitle(standup.Id, "IMPORTED", RepeatEditTypeThis))
+			require.NoError(t, c.UpdateDayTime(standup.Id, "2008-01-02", "10:00", "2008-01-02", "10:15", "UTC", false))
+			require.NoError(t, c.Cancel(lunch.Id, RepeatEditTypeThis))
+			imported, _, err := c.Create(Event{OwnerId: 1, Title: "IMPORTED", Zone: "UTC", StartDay: "2008-01-03", EndDay: "2008-01-03", IsAllDay: true})
+			require.NoError(t, err)
+
+			var b bytes.Buffer
+			require.NoError(t, c.Snapshot(&b))
+			require.NoError(t, c.RestoreSnapshot(&b, good))
+
+			got, err := c.Get(standup.Id)
+			require.NoError(t, err)
+			assert.Equal(t, "Daily standup", got.Title)
+			assert.Equal(t, "2008-01-01", got.StartDay)
+			assert.Equal(t, "09:00", got.StartTime)
+			got, err = c.Get(lunch.Id)
+			require.NoError(t, err)
+			assert.Equal(t, StatusActive, got.Status)
+			got, err = c.dataStore.Get(imported.Id)
+			require.NoError(t, err)
+			assert.Equal(t, StatusRemoved, got.Status)
+
+			log, err := c.AuditLog(standup.Id)
+			require.NoError(t, err)
+			assert.Equal(t, "Daily standup", log[len(log)-1].Event.Title, "the restore is audited too")
+		})
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithAuditLog())
+	assert.Equal(t, ErrorInvalidSnapshot, c.RestoreSnapshot(strings.NewReader(`{"format":99}`), time.Now()))
+	assert.Error(t, c.RestoreSnapshot(strings.NewReader(`not json`), time.Now()))
+	assert.Equal(t, ErrorAuditLogNotSupported, NewCalendar(struct{ DataStore }{&InMemoryDataStore{}}).Snapshot(&bytes.Buffer{}))
+}
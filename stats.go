@@ -0,0 +1,209 @@
+package cali
+
+import (
+	"time"
+)
+
+// TimeWindow is the time between Start (inclusive) and End (exclusive)
+type TimeWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Stats are the totals of a user's active events in a time window, like for a "your week
+// in review" summary. Hours only count the part of each event that is in the window, and
+// all day events are counted as events but not as hours. Like queries, the times of events
+// are compared as wall clock times (see FloatingSpan).
+type Stats struct {
+	// UserId is the user that the stats are for
+	UserId int64 `json:"userId"`
+	// Window is the time that the stats are for
+	Window TimeWindow `json:"window"`
+	// Events is the number of events that the user owns or has an invite to that isn't declined
+	Events int64 `json:"events"`
+	// EventHours is the total hours of the events
+	EventHours float64 `json:"eventHours"`
+	// MeetingHours is the total hours of the events with another user, which are events
+	// that the user doesn't own or that another user has an invite to that isn't declined
+	MeetingHours float64 `json:"meetingHours"`
+	// DayHours are the event hours of each YYYY-MM-DD day that has any
+	DayHours map[string]float64 `json:"dayHours"`
+	// BusiestDay is the YYYY-MM-DD day with the most event hours (the earliest if there is a
+	// tie), or "" if there aren't any event hours
+	BusiestDay string `json:"busiestDay"`
+	// EventTypes is the number of events of each event type
+	EventTypes map[EventType]int64 `json:"eventTypes"`
+	// Invites is the number of events that the user was invited to by other users, including declined invites
+	Invites int64 `json:"invites"`
+	// DeclinedInvites is the number of the invites that the user declined
+	DeclinedInvites int64 `json:"declinedInvites"`
+	// DeclinedPercent is the percent (0 to 100) of the invites that the user declined
+	DeclinedPercent float64 `json:"declinedPercent"`
+}
+
+// StatsStore is an optional interface for a data store that can total up the stats of a user
+// itself, like with an aggregate query, instead of the calendar reading every event in the window
+type StatsStore interface {
+	// Stats totals up the stats of the user's active events in the window
+	Stats(userId int64, window TimeWindow) (*Stats, error)
+}
+
+// Stats totals up the hours, event types, and declined invites of the user's active events
+// in the window, using the data store if it implements StatsStore
+func (c *Calendar) Stats(userId int64, window TimeWindow) (*Stats, error) {
+	if store, ok := capability[StatsStore](c.dataStore); ok {
+		return store.Stats(userId, window)
+	}
+	return computeStats(c.dataStore, userId, window)
+}
+
+// computeStats totals up the stats by reading every active event in the window and the invites of the user
+func computeStats(store DataStore, userId int64, window TimeWindow) (*Stats, error) {
+	events, err := store.Query(Query{Start: &window.Start, End: &window.End, Statuses: []Status{StatusActive}})
+	if err != nil {
+		return nil, err
+	}
+	lister, hasInviteList := capability[InviteListStore](store)
+	seriesStore, hasSeries := capability[SeriesInviteStore](store)
+	s := newStats(userId, window)
+	for _, e := range events {
+		var invites []*Invite
+		if hasInviteList {
+			if invites, err = lister.GetInvites(e.Id); err != nil {
+				return nil, err
+			}
+		} else {
+			invite, err := store.GetInvite(e.Id, userId)
+			if err != nil {
+				return nil, err
+			}
+			if invite != nil {
+				invites = append(invites, invite)
+			}
+		}
+		if hasSeries && e.ParentId != nil {
+			series, err := seriesStore.GetSeriesInvite(*e.ParentId, userId)
+			if err != nil {
+				return nil, err
+			}
+			if series != nil {
+				invites = append(invites, series)
+			}
+		}
+		s.add(e, invites)
+	}
+	s.finish()
+	return s, nil
+}
+
+// newStats makes empty stats for the user and window
+func newStats(userId int64, window TimeWindow) *Stats {
+	return &Stats{
+		UserId:     userId,
+		Window:     window,
+		DayHours:   map[string]float64{},
+		EventTypes: map[EventType]int64{},
+	}
+}
+
+// add counts the event if the user owns it or is invited to it, where invites has the user's
+// invite and any other invites of the event that are known
+func (s *Stats) add(e *Event, invites []*Invite) {
+	invite := userInvite(e, invites, s.UserId)
+	owner := e.OwnerId == s.UserId
+	if !owner && (invite == nil || invite.Status == InviteStatusRevoked) {
+		return
+	}
+
+	// the part of the event in the window, where an event that only touches the window isn't in it
+	start, end, err := e.span()
+	if err != nil {
+		return
+	}
+	if windowStart := floatingTime(s.Window.Start); start.Before(windowStart) {
+		start = windowStart
+	}
+	if windowEnd := floatingTime(s.Window.End); end.After(windowEnd) {
+		end = windowEnd
+	}
+	if !start.Before(end) {
+		return
+	}
+
+	if !owner {
+		s.Invites++
+		if invite.Status == InviteStatusDeclined {
+			s.DeclinedInvites++
+			return
+		}
+	}
+	s.Events++
+	s.EventTypes[e.EventType]++
+	if e.IsAllDay {
+		return
+	}
+
+	meeting := isMeeting(e, s.UserId, invites)
+	for start.Before(end) {
+		dayEnd := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, time.UTC)
+		if dayEnd.After(end) {
+			dayEnd = end
+		}
+		hours := dayEnd.Sub(start).Hours()
+		s.DayHours[start.Format(time.DateOnly)] += hours
+		s.EventHours += hours
+		if meeting {
+			s.MeetingHours += hours
+		}
+		start = dayEnd
+	}
+}
+
+// isMeeting returns true if the event is with another user, which is when the user doesn't own
+// the event or another user has an invite to it that isn't declined
+func isMeeting(e *Event, userId int64, invites []*Invite) bool {
+	if e.OwnerId != userId {
+		return true
+	}
+	for _, i := range invites {
+		if i.UserId != userId && i.Status >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// merge adds the totals of the other stats, like from another shard
+func (s *Stats) merge(other *Stats) {
+	s.Events += other.Events
+	s.EventHours += other.EventHours
+	s.MeetingHours += other.MeetingHours
+	for day, hours := range other.DayHours {
+		s.DayHours[day] += hours
+	}
+	for eventType, count := range other.EventTypes {
+		s.EventTypes[eventType] += count
+	}
+	s.Invites += other.Invites
+	s.DeclinedInvites += other.DeclinedInvites
+}
+
+// finish works out the busiest day and the declined percent from the totals
+func (s *Stats) finish() {
+	s.BusiestDay = ""
+	for day, hours := range s.DayHours {
+		busiest := s.DayHours[s.BusiestDay]
+		if s.BusiestDay == "" || hours > busiest || (hours == busiest && day < s.BusiestDay) {
+			s.BusiestDay = day
+		}
+	}
+	s.DeclinedPercent = 0
+	if s.Invites > 0 {
+		s.DeclinedPercent = float64(s.DeclinedInvites) / float64(s.Invites) * 100
+	}
+}
+
+// floatingTime is the wall clock time of t in UTC so that it can be compared with the span of events
+func floatingTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,82 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	sharded, err := NewShardedDataStore(ShardByOwner, &InMemoryDataStore{}, &InMemoryDataStore{})
+	require.NoError(t, err)
+	computed := &InMemoryDataStore{}
+	testCases := []struct {
+		name  string
+		store DataStore
+		stats func(c *Calendar, userId int64, window TimeWindow) (*Stats, error)
+	}{
+		{name: "store", store: &InMemoryDataStore{}},
+		{name: "computed", store: computed, stats: func(c *Calendar, userId int64, window TimeWindow) (*Stats, error) {
+			return computeStats(computed, userId, window)
+		}},
+		{name: "sharded", store: sharded},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			c := NewCalendar(tc.store)
+			create := func(ownerId int64, eventType EventType, startDay, startTime, endDay, endTime string) int64 {
+				e, _, err := c.Create(Event{OwnerId: ownerId, EventType: eventType, StartDay: startDay, StartTime: startTime, EndDay: endDay, EndTime: endTime, IsAllDay: startTime == "", Zone: "UTC"})
+				require.NoError(t, err)
+				return e.Id
+			}
+			create(1, 1, "2008-01-07", "09:00", "2008-01-07", "10:00")
+			meeting := create(1, 2, "2008-01-08", "10:00", "2008-01-08", "12:00")
+			require.NoError(t, c.InviteUser(meeting, 2, PermissionInvitee, RepeatEditTypeThis))
+			invited := create(2, 2, "2008-01-08", "13:00", "2008-01-08", "14:30")
+			require.NoError(t, c.InviteUser(invited, 1, PermissionInvitee, RepeatEditTypeThis))
+			declined := create(3, 1, "2008-01-09", "09:00", "2008-01-09", "10:00")
+			require.NoError(t, c.InviteUser(declined, 1, PermissionInvitee, RepeatEditTypeThis))
+			require.NoError(t, c.DeclineInvitation(declined, 1, RepeatEditTypeThis))
+			create(1, 1, "2008-01-10", "", "2008-01-10", "")
+			create(1, 0, "2008-01-13", "23:00", "2008-01-14", "01:00")
+			canceled := create(1, 1, "2008-01-09", "11:00", "2008-01-09", "12:00")
+			require.NoError(t, c.Cancel(canceled, RepeatEditTypeThis))
+			create(1, 1, "2008-01-14", "00:00", "2008-01-14", "01:00")
+			create(1, 1, "2008-01-20", "09:00", "2008-01-20", "10:00")
+			create(4, 1, "2008-01-08", "09:00", "2008-01-08", "10:00")
+
+			window := TimeWindow{Start: *tt("2008-01-07 00:00"), End: *tt("2008-01-14 00:00")}
+			stats := c.Stats
+			if tc.stats != nil {
+				stats = func(userId int64, window TimeWindow) (*Stats, error) {
+					return tc.stats(c, userId, window)
+				}
+			}
+			s, err := stats(1, window)
+			require.NoError(t, err)
+			assert.Equal(t, &Stats{
+				UserId:          1,
+				Window:          window,
+				Events:          5,
+				EventHours:      5.5,
+				MeetingHours:    3.5,
+				DayHours:        map[string]float64{"2008-01-07": 1, "2008-01-08": 3.5, "2008-01-13": 1},
+				BusiestDay:      "2008-01-08",
+				EventTypes:      map[EventType]int64{0: 1, 1: 2, 2: 2},
+				Invites:         2,
+				DeclinedInvites: 1,
+				DeclinedPercent: 50,
+			}, s)
+
+			s, err = stats(5, window)
+			require.NoError(t, err)
+			assert.Equal(t, int64(0), s.Events)
+			assert.Equal(t, "", s.BusiestDay)
+			assert.Equal(t, float64(0), s.DeclinedPercent)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package cali
+
+// WithAbandonedHidden leaves the abandoned events out of the results of Query (unless the
+// query has Statuses or IncludeRemoved) the same way as the removed events
+func WithAbandonedHidden() CalendarOption {
+	return func(c *Calendar) {
+		c.hideAbandoned = true
+	}
+}
+
+// withStatusDefaults sets the Statuses of a query that doesn't have any to every status
+// except for the hidden ones, so that removed events don't show up by accident
+func (c *Calendar) withStatusDefaults(q Query) Query {
+	if len(q.Statuses) > 0 || q.IncludeRemoved {
+		return q
+	}
+	for _, status := range Statuses {
+		if status == StatusRemoved || (c.hideAbandoned && status == StatusAbandoned) {
+			continue
+		}
+		q.Statuses = append(q.Statuses, status)
+	}
+	return q
+}
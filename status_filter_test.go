@@ -0,0 +1,45 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLeavesOutRemovedEvents(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []CalendarOption
+		query    Query
+		expected []string
+	}{
+		{name: "default", query: Query{}, expected: []string{"active", "canceled", "abandoned"}},
+		{name: "include removed", query: Query{IncludeRemoved: true}, expected: []string{"active", "canceled", "abandoned", "removed"}},
+		{name: "statuses", query: Query{Statuses: []Status{StatusRemoved}}, expected: []string{"removed"}},
+		{name: "abandoned hidden", opts: []CalendarOption{WithAbandonedHidden()}, query: Query{}, expected: []string{"active", "canceled"}},
+		{name: "abandoned hidden and include removed", opts: []CalendarOption{WithAbandonedHidden()}, query: Query{IncludeRemoved: true},
+			expected: []string{"active", "canceled", "abandoned", "removed"}},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			store := &InMemoryDataStore{}
+			c := NewCalendar(store, tc.opts...)
+			for _, status := range []Status{StatusActive, StatusCanceled, StatusAbandoned, StatusRemoved} {
+				e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+				require.NoError(t, err)
+				require.NoError(t, store.SetStatus(e.Id, status))
+			}
+			events, err := c.Query(tc.query)
+			require.NoError(t, err)
+			var statuses []string
+			for _, e := range events {
+				statuses = append(statuses, map[Status]string{StatusActive: "active", StatusCanceled: "canceled", StatusAbandoned: "abandoned", StatusRemoved: "removed"}[e.Status])
+			}
+			assert.ElementsMatch(t, tc.expected, statuses)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package cali
+
+import (
+	"fmt"
+)
+
+// StatusTransition is a change of the status of an event by a calendar operation
+type StatusTransition struct {
+	// From is the current status of the event
+	From Status
+	// To is the status the event is changed to
+	To Status
+	// Operation is what changes the status, like OperationCancel for Cancel
+	Operation Operation
+}
+
+// StatusTransitionPolicy decides if the status of an event can change (see WithStatusTransitions)
+type StatusTransitionPolicy func(t StatusTransition) bool
+
+// StatusTransitionError is returned when the status of an event can't change, and it
+// matches ErrorInvalidStatusTransition with errors.Is
+type StatusTransitionError struct {
+	EventId int64
+	StatusTransition
+}
+
+func (e *StatusTransitionError) Error() string {
+	return fmt.Sprintf("event %v can not go from status %v to %v with %v", e.EventId, e.From, e.To, e.Operation)
+}
+
+func (e *StatusTransitionError) Is(target error) bool {
+	return target == ErrorInvalidStatusTransition
+}
+
+// DefaultStatusTransitions is the policy of calendars without WithStatusTransitions:
+//
+//   - an event can always be removed, and stays the same if it already has the status
+//   - removed and canceled events only become active again with Restore
+//   - pending events only become active when they are approved
+//   - active events only become abandoned when every invitation (including the owner's) is
+//     declined, and abandoned events become active again when an invitee responds with
+//     anything but a decline, or the event is restored
+func DefaultStatusTransitions(t StatusTransition) bool {
+	if t.From == t.To || t.To == StatusRemoved {
+		return true
+	}
+	switch t.To {
+	case StatusActive:
+		switch t.From {
+		case StatusCanceled, StatusRemoved:
+			return t.Operation == OperationRestore
+		case StatusPendingApproval:
+			return t.Operation == OperationApprove
+		case StatusAbandoned:
+			return t.Operation == OperationRestore || t.Operation == OperationRespond
+		}
+	case StatusCanceled:
+		return t.From == StatusActive || t.From == StatusPendingApproval
+	case StatusAbandoned:
+		return t.From == StatusActive && t.Operation == OperationRespond
+	}
+	return false
+}
+
+// WithStatusTransitions replaces the rules for which status changes are allowed, the
+// default is DefaultStatusTransitions. A policy can call DefaultStatusTransitions for the
+// transitions it doesn't change.
+func WithStatusTransitions(policy StatusTransitionPolicy) CalendarOption {
+	return func(c *Calendar) {
+		c.statusTransitions = policy
+	}
+}
+
+// Restore sets the status of a removed, canceled, or abandoned event back to StatusActive
+// and clears its CancelReason. The other events of a series edit that can't be restored are
+// left alone.
+func (c *Calendar) Restore(eventId int64, editType RepeatEditType) error {
+	return c.outboxTx(func(c *Calendar) error {
+		return c.setStatus(OperationRestore, editType, eventId, StatusActive, c.clearCancelReason)
+	})
+}
+
+// updateAbandoned is called after a user responds to an invitation to the event. It abandons
+// an active event when every invitation that isn't revoked (including the owner's) is
+// declined, and makes an abandoned event active again when one of them isn't. The status is
+// left alone if the data store can't list the invitations or the policy doesn't allow it.
+func (c *Calendar) updateAbandoned(eventId int64) error {
+	e, err := c.dataStore.Get(eventId)
+	if err != nil || e == nil || (e.Status != StatusActive && e.Status != StatusAbandoned) {
+		return err
+	}
+	invites, err := c.getInvites(eventId)
+	if err == ErrorInviteListNotSupported {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	declined, open := 0, 0
+	for _, invite := range invites {
+		switch invite.Status {
+		case InviteStatusDeclined:
+			declined++
+		case InviteStatusRevoked:
+		default:
+			open++
+		}
+	}
+	status := StatusActive
+	if declined > 0 && open == 0 {
+		status = StatusAbandoned
+	}
+	if status == e.Status || c.checkTransition(OperationRespond, e, status) != nil {
+		return nil
+	}
+	if err := c.dataStore.SetStatus(eventId, status); err != nil {
+		return err
+	}
+	return c.publish(ChangeTypeUpdated, eventId, nil)
+}
+
+// updateAbandonedSeries is updateAbandoned for every event of the series
+func (c *Calendar) updateAbandonedSeries(parentId int64) error {
+	events, err := c.dataStore.Query(Query{ParentIds: []int64{parentId}})
+	if err != nil {
+		return err
+	}
+	for _, e := range events {
+		if err := c.updateAbandoned(e.Id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTransition returns a StatusTransitionError if the event can't change to the status
+func (c *Calendar) checkTransition(op Operation, e *Event, status Status) error {
+	policy := c.statusTransitions
+	if policy == nil {
+		policy = DefaultStatusTransitions
+	}
+	t := StatusTransition{From: e.Status, To: status, Operation: op}
+	if !policy(t) {
+		return &StatusTransitionError{EventId: e.Id, StatusTransition: t}
+	}
+	return nil
+}
+
+// skipTransition reports if an event of an edit keeps its status because it can't change to
+// the status, where the event that was edited returns the error instead
+func (c *Calendar) skipTransition(op Operation, editedId int64, e *Event, status Status) (bool, error) {
+	if err := c.checkTransition(op, e, status); err != nil {
+		if e.Id == editedId {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// skipStatus is skipTransition for the event with the id, where missing events are skipped
+func (c *Calendar) skipStatus(op Operation, editedId int64, eventId int64, status Status) (bool, error) {
+	e, err := c.dataStore.Get(eventId)
+	if err != nil {
+		return false, err
+	}
+	if e == nil {
+		return true, nil
+	}
+	return c.skipTransition(op, editedId, e, status)
+}
+
+// transitionableStatuses gets the statuses that can change to the status with the operation
+func (c *Calendar) transitionableStatuses(op Operation, statuses []Status, status Status) []Status {
+	var allowed []Status
+	for _, from := range statuses {
+		if c.checkTransition(op, &Event{Status: from}, status) == nil {
+			allowed = append(allowed, from)
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,165 @@
+package cali
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name     string
+		t        StatusTransition
+		expected bool
+	}{
+		{name: "cancel", t: StatusTransition{From: StatusActive, To: StatusCanceled, Operation: OperationCancel}, expected: true},
+		{name: "remove canceled", t: StatusTransition{From: StatusCanceled, To: StatusRemoved, Operation: OperationRemove}, expected: true},
+		{name: "cancel removed", t: StatusTransition{From: StatusRemoved, To: StatusCanceled, Operation: OperationCancel}, expected: false},
+		{name: "restore removed", t: StatusTransition{From: StatusRemoved, To: StatusActive, Operation: OperationRestore}, expected: true},
+		{name: "approve removed", t: StatusTransition{From: StatusRemoved, To: StatusActive, Operation: OperationApprove}, expected: false},
+		{name: "approve pending", t: StatusTransition{From: StatusPendingApproval, To: StatusActive, Operation: OperationApprove}, expected: true},
+		{name: "abandon by declines", t: StatusTransition{From: StatusActive, To: StatusAbandoned, Operation: OperationRespond}, expected: true},
+		{name: "abandon by update", t: StatusTransition{From: StatusActive, To: StatusAbandoned, Operation: OperationUpdate}, expected: false},
+		{name: "abandon canceled", t: StatusTransition{From: StatusCanceled, To: StatusAbandoned, Operation: OperationRespond}, expected: false},
+		{name: "same status", t: StatusTransition{From: StatusCanceled, To: StatusCanceled, Operation: OperationCancel}, expected: true},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			assert.Equal(t, tc.expected, DefaultStatusTransitions(tc.t))
+		})
+	}
+}
+
+func TestStatusTransitions(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.Remove(e.Id, RepeatEditTypeThis))
+
+	err = c.Cancel(e.Id, RepeatEditTypeThis)
+	assert.ErrorIs(t, err, ErrorInvalidStatusTransition)
+	var transitionErr *StatusTransitionError
+	require.True(t, errors.As(err, &transitionErr))
+	assert.Equal(t, StatusTransitionError{EventId: e.Id, StatusTransition: StatusTransition{From: StatusRemoved, To: StatusCanceled, Operation: OperationCancel}}, *transitionErr)
+
+	require.NoError(t, c.Restore(e.Id, RepeatEditTypeThis))
+	require.NoError(t, c.Cancel(e.Id, RepeatEditTypeThis))
+	got, err := c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCanceled, got.Status)
+}
+
+func TestStatusTransitionsOfSeries(t *testing.T) {
+	for _, store := range []DataStore{&InMemoryDataStore{}, struct{ DataStore }{&InMemoryDataStore{}}} {
+		c := NewCalendar(store)
+		e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+			IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+		require.NoError(t, err)
+		events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+		require.NoError(t, err)
+		require.Len(t, events, 3)
+		require.NoError(t, c.Remove(events[2].Id, RepeatEditTypeThis))
+
+		// the removed event of the series isn't canceled
+		require.NoError(t, c.Cancel(e.Id, RepeatEditTypeAll))
+		events, err = c.Query(Query{ParentIds: []int64{e.Id}, IncludeRemoved: true})
+		require.NoError(t, err)
+		statuses := map[int64]Status{}
+		for _, e := range events {
+			statuses[e.Id] = e.Status
+		}
+		assert.Equal(t, map[int64]Status{events[0].Id: StatusCanceled, events[1].Id: StatusCanceled, events[2].Id: StatusRemoved}, statuses)
+
+		count, err := c.CancelMany(Query{ParentIds: []int64{e.Id}, Statuses: []Status{StatusRemoved}})
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), count)
+	}
+}
+
+func TestWithStatusTransitions(t *testing.T) {
+	// removed events can't be restored
+	c := NewCalendar(&InMemoryDataStore{}, WithStatusTransitions(func(t StatusTransition) bool {
+		return t.From != StatusRemoved && DefaultStatusTransitions(t)
+	}))
+	e, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.Cancel(e.Id, RepeatEditTypeThis))
+	require.NoError(t, c.Restore(e.Id, RepeatEditTypeThis))
+	require.NoError(t, c.Remove(e.Id, RepeatEditTypeThis))
+	assert.ErrorIs(t, c.Restore(e.Id, RepeatEditTypeThis), ErrorInvalidStatusTransition)
+}
+
+func TestAbandoned(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(e.Id, 3, PermissionInvitee, RepeatEditTypeThis))
+	status := func() Status {
+		got, err := c.Get(e.Id)
+		require.NoError(t, err)
+		return got.Status
+	}
+
+	require.NoError(t, c.DeclineInvitation(e.Id, 2, RepeatEditTypeThis))
+	require.NoError(t, c.DeclineInvitation(e.Id, 1, RepeatEditTypeThis))
+	assert.Equal(t, StatusActive, status(), "user 3 hasn't declined")
+	require.NoError(t, c.RevokeInvitation(e.Id, 3, RepeatEditTypeThis))
+	assert.Equal(t, StatusAbandoned, status(), "every invitation that isn't revoked is declined")
+
+	require.NoError(t, c.AcceptInvitation(e.Id, 2, RepeatEditTypeThis))
+	assert.Equal(t, StatusActive, status())
+	require.NoError(t, c.DeclineInvitation(e.Id, 2, RepeatEditTypeThis))
+	assert.Equal(t, StatusAbandoned, status())
+	require.NoError(t, c.Restore(e.Id, RepeatEditTypeThis))
+	assert.Equal(t, StatusActive, status())
+
+	// canceled events aren't abandoned
+	require.NoError(t, c.Cancel(e.Id, RepeatEditTypeThis))
+	require.NoError(t, c.AcceptInvitation(e.Id, 2, RepeatEditTypeThis))
+	require.NoError(t, c.DeclineInvitation(e.Id, 2, RepeatEditTypeThis))
+	assert.Equal(t, StatusCanceled, status())
+}
+
+func TestAbandonedSeries(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3}})
+	require.NoError(t, err)
+	require.NoError(t, c.InviteUser(e.Id, 2, PermissionInvitee, RepeatEditTypeAll))
+	require.NoError(t, c.DeclineInvitation(e.Id, 1, RepeatEditTypeAll))
+	statuses := func() []Status {
+		events, err := c.Query(Query{ParentIds: []int64{e.Id}})
+		require.NoError(t, err)
+		var result []Status
+		for _, e := range events {
+			result = append(result, e.Status)
+		}
+		return result
+	}
+	assert.Equal(t, []Status{StatusActive, StatusActive, StatusActive}, statuses())
+
+	// the series invite of user 2 is declined for every event
+	require.NoError(t, c.DeclineInvitation(e.Id, 2, RepeatEditTypeAll))
+	assert.Equal(t, []Status{StatusAbandoned, StatusAbandoned, StatusAbandoned}, statuses())
+	require.NoError(t, c.AcceptInvitation(e.Id, 2, RepeatEditTypeAll))
+	assert.Equal(t, []Status{StatusActive, StatusActive, StatusActive}, statuses())
+}
+
+func TestAbandonedPolicy(t *testing.T) {
+	// events are never abandoned
+	c := NewCalendar(&InMemoryDataStore{}, WithStatusTransitions(func(t StatusTransition) bool {
+		return t.To != StatusAbandoned && DefaultStatusTransitions(t)
+	}))
+	e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.DeclineInvitation(e.Id, 1, RepeatEditTypeThis))
+	got, err := c.Get(e.Id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusActive, got.Status)
+}
@@ -0,0 +1,115 @@
+package cali
+
+import (
+	"time"
+)
+
+// Subscription is a user's view of another calendar (like a team calendar or a
+// holiday calendar) that is merged into the user's own events when querying by user
+type Subscription struct {
+	// UserId is the user that subscribed
+	UserId int64 `json:"userId"`
+	// CalendarId is the calendar that the user subscribed to
+	CalendarId int64 `json:"calendarId"`
+	// Color is how the user wants the calendar's events to be displayed, like "#3366ff"
+	Color string `json:"color"`
+	// Hidden keeps the subscription, but leaves the calendar's events out of queries
+	Hidden bool `json:"hidden"`
+	// Created is a timestamp for when the user subscribed
+	Created time.Time `json:"created"`
+	// Updated is a timestamp for when the subscription was modified last
+	Updated time.Time `json:"updated"`
+}
+
+// SubscriptionStore is an optional interface for a data store that can save calendar subscriptions
+type SubscriptionStore interface {
+	// SetSubscription creates or replaces the subscription for the UserId and CalendarId
+	// and handles setting the Created and Updated fields
+	SetSubscription(s Subscription) (*Subscription, error)
+	// RemoveSubscription deletes the subscription. If there is no subscription it returns nil
+	RemoveSubscription(userId, calendarId int64) error
+	// GetSubscriptions retrieves all of the subscriptions of the user
+	GetSubscriptions(userId int64) ([]*Subscription, error)
+}
+
+// Subscribe creates or replaces a user's subscription to a calendar. Once subscribed, the
+// results of Query with the user in UserIds include the events of the calendar.
+func (c *Calendar) Subscribe(s Subscription) (*Subscription, error) {
+	store, ok := capability[SubscriptionStore](c.dataStore)
+	if !ok {
+		return nil, ErrorSubscriptionNotSupported
+	}
+	subscription, err := store.SetSubscription(s)
+	// the subscriptions change the events of queries by user, so none of the cached queries can be used
+	c.InvalidateQueryCache()
+	return subscription, err
+}
+
+// Unsubscribe removes the user's subscription to the calendar
+func (c *Calendar) Unsubscribe(userId int64, calendarId int64) error {
+	store, ok := capability[SubscriptionStore](c.dataStore)
+	if !ok {
+		return ErrorSubscriptionNotSupported
+	}
+	err := store.RemoveSubscription(userId, calendarId)
+	c.InvalidateQueryCache()
+	return err
+}
+
+// GetSubscriptions gets all of the calendars that the user subscribed to
+func (c *Calendar) GetSubscriptions(userId int64) ([]*Subscription, error) {
+	store, ok := capability[SubscriptionStore](c.dataStore)
+	if !ok {
+		return nil, ErrorSubscriptionNotSupported
+	}
+	return store.GetSubscriptions(userId)
+}
+
+// subscribedEvents gets the events of the calendars that the users of the query subscribed
+// to (and didn't hide) that match the rest of the query
+func (c *Calendar) subscribedEvents(q Query) ([]*Event, error) {
+	store, ok := capability[SubscriptionStore](c.dataStore)
+	if !ok || len(q.UserIds) == 0 {
+		return nil, nil
+	}
+	allowed := map[int64]bool{}
+	for _, id := range q.CalendarIds {
+		allowed[id] = true
+	}
+	seen := map[int64]bool{}
+	var calendarIds []int64
+	for _, userId := range q.UserIds {
+		subscriptions, err := store.GetSubscriptions(userId)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range subscriptions {
+			if s.Hidden || seen[s.CalendarId] || (len(allowed) > 0 && !allowed[s.CalendarId]) {
+				continue
+			}
+			seen[s.CalendarId] = true
+			calendarIds = append(calendarIds, s.CalendarId)
+		}
+	}
+	if len(calendarIds) == 0 {
+		return nil, nil
+	}
+	q.UserIds = nil
+	q.CalendarIds = calendarIds
+	return c.queryNear(q)
+}
+
+// mergeEvents adds the other events to the events that aren't already in the list
+func mergeEvents(events []*Event, others []*Event) []*Event {
+	ids := map[int64]bool{}
+	for _, e := range events {
+		ids[e.Id] = true
+	}
+	for _, e := range others {
+		if !ids[e.Id] {
+			ids[e.Id] = true
+			events = append(events, e)
+		}
+	}
+	return events
+}
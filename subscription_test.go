@@ -0,0 +1,54 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscriptions(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	create := func(title string, calendarId int64, ownerId int64, day string) *Event {
+		e, _, err := c.Create(Event{Title: title, CalendarId: calendarId, OwnerId: ownerId, StartDay: day, EndDay: day, IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	create("mine", 1, 7, "2008-01-01")
+	create("team", 2, 8, "2008-01-02")
+	create("other team", 3, 8, "2008-01-03")
+
+	titles := func(q Query) []string {
+		events, err := c.Query(q)
+		require.NoError(t, err)
+		var result []string
+		for _, e := range events {
+			result = append(result, e.Title)
+		}
+		return result
+	}
+	assert.Equal(t, []string{"mine"}, titles(Query{UserIds: []int64{7}}))
+
+	s, err := c.Subscribe(Subscription{UserId: 7, CalendarId: 2, Color: "#3366ff"})
+	require.NoError(t, err)
+	assert.False(t, s.Created.IsZero())
+	assert.Equal(t, []string{"mine", "team"}, titles(Query{UserIds: []int64{7}}))
+	assert.Equal(t, []string{"team"}, titles(Query{UserIds: []int64{7}, CalendarIds: []int64{2, 3}}))
+	// other users don't see the subscription
+	assert.Equal(t, []string{"team", "other team"}, titles(Query{UserIds: []int64{8}}))
+
+	// hiding the subscription keeps its settings
+	_, err = c.Subscribe(Subscription{UserId: 7, CalendarId: 2, Color: "#3366ff", Hidden: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mine"}, titles(Query{UserIds: []int64{7}}))
+	subscriptions, err := c.GetSubscriptions(7)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 1)
+	assert.Equal(t, "#3366ff", subscriptions[0].Color)
+	assert.Equal(t, s.Created, subscriptions[0].Created)
+
+	require.NoError(t, c.Unsubscribe(7, 2))
+	subscriptions, err = c.GetSubscriptions(7)
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions)
+}
@@ -0,0 +1,128 @@
+package cali
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncStore is an optional interface for a data store that can find everything
+// that changed after a point in time so that offline clients can catch up. Removed
+// events are never deleted from the store (they only get StatusRemoved), so they
+// are returned as tombstones for at least as long as the sync retention of the calendar.
+type SyncStore interface {
+	// ChangedEvents gets every event (including removed events) with an Updated field at or after the time
+	ChangedEvents(since time.Time) ([]*Event, error)
+	// ChangedInvites gets every invite with an Updated field at or after the time
+	ChangedInvites(since time.Time) ([]*Invite, error)
+}
+
+// Tombstone is the record of an event that was removed (or abandoned) so that an
+// offline client knows to delete its local copy
+type Tombstone struct {
+	// EventId is the id of the removed event
+	EventId int64 `json:"eventId"`
+	// CalendarId is the calendar the removed event was a part of
+	CalendarId int64 `json:"calendarId"`
+	// Status is either StatusRemoved or StatusAbandoned
+	Status Status `json:"status"`
+	// Deleted is when the event was removed
+	Deleted time.Time `json:"deleted"`
+}
+
+// SyncResult is everything that changed since the token that was given to Sync
+type SyncResult struct {
+	// Events are the new and modified events that are not removed
+	Events []*Event `json:"events"`
+	// Tombstones are the events that were removed, these are empty on a full sync
+	Tombstones []Tombstone `json:"tombstones"`
+	// Invites are the new and modified invites
+	Invites []*Invite `json:"invites"`
+	// Token should be saved by the client and given to the next call to Sync
+	Token string `json:"token"`
+}
+
+// WithSyncRetention sets how long a sync token is good for. Older tokens return
+// ErrorSyncTokenExpired and the client has to do a full sync (with an empty token),
+// which means a store may clean up tombstones that are older than the retention.
+func WithSyncRetention(retention time.Duration) CalendarOption {
+	return func(c *Calendar) {
+		c.syncRetention = retention
+	}
+}
+
+// Sync gets everything that changed since the token. An empty token does a full
+// sync of every event that isn't removed. The Token on the result should be
+// given to the next call. Changes made while Sync runs may be sent twice, so
+// clients should apply the results by id.
+func (c *Calendar) Sync(token string) (*SyncResult, error) {
+	store, ok := capability[SyncStore](c.dataStore)
+	if !ok {
+		return nil, ErrorSyncNotSupported
+	}
+	since, err := parseSyncToken(token)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token != "" && c.syncRetention > 0 && now.Sub(since) > c.syncRetention {
+		return nil, ErrorSyncTokenExpired
+	}
+
+	events, err := store.ChangedEvents(since)
+	if err != nil {
+		return nil, err
+	}
+	invites, err := store.ChangedInvites(since)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SyncResult{
+		Token: syncToken(now),
+	}
+	for _, e := range events {
+		if e.Status == StatusRemoved || e.Status == StatusAbandoned {
+			if token != "" {
+				result.Tombstones = append(result.Tombstones, Tombstone{
+					EventId:    e.Id,
+					CalendarId: e.CalendarId,
+					Status:     e.Status,
+					Deleted:    e.Updated,
+				})
+			}
+			continue
+		}
+		result.Events = append(result.Events, e)
+	}
+	result.Invites = invites
+	return result, nil
+}
+
+const syncTokenPrefix = "1:"
+
+// syncToken encodes the time into an opaque token
+func syncToken(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(t.UnixNano(), 10)))
+}
+
+// parseSyncToken decodes the time from a token where an empty token is the zero time
+func parseSyncToken(token string) (time.Time, error) {
+	if token == "" {
+		return time.Time{}, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, ErrorInvalidSyncToken
+	}
+	s, ok := strings.CutPrefix(string(b), syncTokenPrefix)
+	if !ok {
+		return time.Time{}, ErrorInvalidSyncToken
+	}
+	nanos, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrorInvalidSyncToken
+	}
+	return time.Unix(0, nanos), nil
+}
@@ -0,0 +1,67 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	newEvent := func() *Event {
+		e, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+		require.NoError(t, err)
+		return e
+	}
+	a := newEvent()
+	b := newEvent()
+	require.NoError(t, c.Remove(b.Id, RepeatEditTypeThis))
+
+	full, err := c.Sync("")
+	require.NoError(t, err)
+	require.Len(t, full.Events, 1)
+	assert.Equal(t, a.Id, full.Events[0].Id)
+	assert.Empty(t, full.Tombstones)
+	assert.Len(t, full.Invites, 2)
+	assert.NotEmpty(t, full.Token)
+
+	empty, err := c.Sync(full.Token)
+	require.NoError(t, err)
+	assert.Empty(t, empty.Events)
+	assert.Empty(t, empty.Tombstones)
+	assert.Empty(t, empty.Invites)
+
+	d := newEvent()
+	require.NoError(t, c.UpdateTitle(a.Id, "changed", RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(a.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+	require.NoError(t, c.Remove(d.Id, RepeatEditTypeThis))
+
+	delta, err := c.Sync(empty.Token)
+	require.NoError(t, err)
+	require.Len(t, delta.Events, 1)
+	assert.Equal(t, "changed", delta.Events[0].Title)
+	require.Len(t, delta.Tombstones, 1)
+	assert.Equal(t, d.Id, delta.Tombstones[0].EventId)
+	assert.Equal(t, StatusRemoved, delta.Tombstones[0].Status)
+	// the owner invite of the new event and the invite for user 2
+	assert.Len(t, delta.Invites, 2)
+}
+
+func TestSyncToken(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithSyncRetention(time.Hour))
+	_, err := c.Sync("not a token")
+	assert.Equal(t, ErrorInvalidSyncToken, err)
+
+	_, err = c.Sync(syncToken(time.Now().Add(-2 * time.Hour)))
+	assert.Equal(t, ErrorSyncTokenExpired, err)
+
+	_, err = c.Sync(syncToken(time.Now().Add(-time.Minute)))
+	assert.NoError(t, err)
+
+	since := time.Unix(0, 1234567890)
+	parsed, err := parseSyncToken(syncToken(since))
+	require.NoError(t, err)
+	assert.True(t, since.Equal(parsed))
+}
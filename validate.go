@@ -34,6 +34,104 @@ var (
 	ErrorInviteNotFound               = errors.New("invitation not found")
 	ErrorInvalidRepeatEditType        = errors.New("invalid repeat edit type")
 	ErrorAllDayCantHaveTimes          = errors.New("all day events cant have times")
+	ErrorAutoResponseNotSupported     = errors.New("data store does not support auto response policies")
+	ErrorInvalidWorkingHours          = errors.New("invalid working hours")
+	ErrorQuickAddEmpty                = errors.New("quick add text is missing a title")
+	ErrorUnknownLocale                = errors.New("unknown locale")
+	ErrorInvalidCalendarSystem        = errors.New("invalid calendar system")
+	ErrorInvalidAltDate               = errors.New("invalid date for calendar system")
+	ErrorMissingCSVColumn             = errors.New("missing csv column")
+	ErrorPreconditionFailed           = errors.New("event has been modified since it was read")
+	ErrorSyncNotSupported             = errors.New("data store does not support sync")
+	ErrorInvalidSyncToken             = errors.New("invalid sync token")
+	ErrorSyncTokenExpired             = errors.New("sync token has expired, a full sync is required")
+	ErrorDecryptionFailed             = errors.New("could not decrypt value")
+	ErrorInvalidRole                  = errors.New("invalid role")
+	ErrorSeriesInviteNotSupported     = errors.New("data store does not support series invites")
+	ErrorInviteListNotSupported       = errors.New("data store does not support listing invites")
+	ErrorRSVPTokensNotConfigured      = errors.New("rsvp tokens are not configured")
+	ErrorInvalidRSVPToken             = errors.New("invalid rsvp token")
+	ErrorRSVPTokenExpired             = errors.New("rsvp token has expired")
+	ErrorRSVPTokenUsed                = errors.New("rsvp token has already been used")
+	ErrorInvalidPriority              = errors.New("invalid priority")
+	ErrorConferenceNotConfigured      = errors.New("calendar does not have a conference provider")
+	ErrorInvalidGeo                   = errors.New("invalid latitude or longitude")
+	ErrorInvalidVisibility            = errors.New("invalid visibility")
+	ErrorSubscriptionNotSupported     = errors.New("data store does not support subscriptions")
+	ErrorNoShards                     = errors.New("sharded data store needs at least one shard")
+	ErrorOutboxNotSupported           = errors.New("data store does not support an outbox")
+	ErrorOutboxRecordNotFound         = errors.New("outbox record not found")
+	ErrorTooManyInvitees              = errors.New("too many users are invited to the event")
+	ErrorInviteNotAllowed             = errors.New("user is not allowed to be invited")
+	ErrorApprovalNotAllowed           = errors.New("user is not allowed to approve the event")
+	ErrorNotPendingApproval           = errors.New("event is not pending approval")
+	ErrorSlotsNotSupported            = errors.New("data store does not support bookable slots")
+	ErrorInvalidAvailability          = errors.New("invalid availability")
+	ErrorAvailabilityNotFound         = errors.New("there is no availability with that id")
+	ErrorInvalidSlot                  = errors.New("not a slot of the availability")
+	ErrorSlotAlreadyBooked            = errors.New("slot is already booked")
+	ErrorBookingNotFound              = errors.New("there is no booking with that id")
+	ErrorBookingCanceled              = errors.New("booking has been canceled")
+	ErrorBookingConfirmationExpired   = errors.New("booking was not confirmed in time")
+	ErrorCancellationWindowClosed     = errors.New("booking can no longer be canceled")
+	ErrorProposalsNotSupported        = errors.New("data store does not support proposing new times")
+	ErrorInvalidProposal              = errors.New("proposed start must be before the proposed end")
+	ErrorProposalNotFound             = errors.New("there is no proposed time on the invitation")
+	ErrorForwardingNotAllowed         = errors.New("invitation can not be forwarded")
+	ErrorAlreadyInvited               = errors.New("user is already invited")
+	ErrorPendingInvitesNotSupported   = errors.New("data store does not support finding pending invites")
+	ErrorNotificationsNotConfigured   = errors.New("calendar does not have a notification sender")
+	ErrorSeriesRecordsNotSupported    = errors.New("data store does not support series records")
+	ErrorSeriesNotFound               = errors.New("there is no series record for the event")
+	ErrorExternalKeysNotSupported     = errors.New("data store does not support external keys")
+	ErrorDuplicateExternalKey         = errors.New("another event already has the external key")
+	ErrorInvalidGranularity           = errors.New("time granularity must be minutes that divide an hour evenly")
+	ErrorInvalidDuration              = errors.New("duration must be zero or more minutes of an event with a start time")
+	ErrorDurationMismatch             = errors.New("duration does not match the end day and end time")
+	ErrorInvalidBusinessDaysOnly      = errors.New("only daily repeats can be limited to business days")
+	ErrorMissingNextOccurrence        = errors.New("custom repeat is missing the next occurrence strategy")
+	ErrorInvalidNextOccurrence        = errors.New("next occurrence must start after the previous occurrence")
+	ErrorOverridesNotSupported        = errors.New("data store does not support overrides")
+	ErrorInvalidICalProperty          = errors.New("ical property names must start with X- and be unique")
+	ErrorInvalidICal                  = errors.New("invalid ical data")
+	ErrorInvalidBinary                = errors.New("invalid binary data")
+	ErrorInvalidHeatmap               = errors.New("heatmap needs a positive slot size and a window that ends after it starts")
+	ErrorInvalidSuggestion            = errors.New("suggestions need a positive duration and step and a window that ends after it starts")
+	ErrorRemindersNotSupported        = errors.New("data store does not support reminders")
+	ErrorReminderNotFound             = errors.New("there is no reminder with that id")
+	ErrorReminderDismissed            = errors.New("reminder has been dismissed")
+	ErrorTemplateNotFound             = errors.New("there is no template for the notification")
+	ErrorMuteNotSupported             = errors.New("data store does not support muting notifications")
+	ErrorAuditLogNotSupported         = errors.New("data store does not support an audit log")
+	ErrorNotAuthorized                = errors.New("the operation is not authorized")
+	ErrorLocksNotSupported            = errors.New("data store does not support event locks")
+	ErrorEventLocked                  = errors.New("event is locked by another user")
+	ErrorInvalidLockDuration          = errors.New("lock duration must be greater than zero")
+	ErrorInvalidStatusTransition      = errors.New("event can not change to the status")
+	ErrorCancelReasonNotSupported     = errors.New("data store does not support cancel reasons")
+	ErrorFollowersNotSupported        = errors.New("data store does not support followers")
+	ErrorReactionsNotSupported        = errors.New("data store does not support reactions")
+	ErrorInvalidReaction              = errors.New("reaction must be a single emoji")
+	ErrorAttachmentsNotSupported      = errors.New("data store does not support attachments")
+	ErrorAttachmentNotFound           = errors.New("attachment not found")
+	ErrorAttachmentTooLarge           = errors.New("attachment is too large")
+	ErrorAttachmentTypeNotAllowed     = errors.New("attachment type is not allowed")
+	ErrorAttachmentNotQuarantined     = errors.New("attachment is not waiting for a scan")
+	ErrorFeedTokensNotSupported       = errors.New("data store does not support feed tokens")
+	ErrorInvalidFeedToken             = errors.New("feed token is invalid or revoked")
+	ErrorInvalidSnapshot              = errors.New("snapshot has an unknown format")
+	ErrorLinksNotSupported            = errors.New("data store does not support links")
+	ErrorInvalidLink                  = errors.New("link url must be an absolute url")
+	ErrorInvalidCustomField           = errors.New("user data does not fit the custom fields of the event type")
+	ErrorInvalidAnniversary           = errors.New("only yearly repeats of all day events can be anniversaries")
+	ErrorInvalidLeapDayPolicy         = errors.New("invalid leap day policy")
+	ErrorForwardingNotSupported       = errors.New("data store does not support disallowing forwarding")
+	ErrorVisibilityNotSupported       = errors.New("data store does not support visibility")
+	ErrorGeoNotSupported              = errors.New("data store does not support geo points")
+	ErrorConferenceNotSupported       = errors.New("data store does not support conferences")
+	ErrorPriorityNotSupported         = errors.New("data store does not support priorities")
+	ErrorPrivateInviteNotSupported    = errors.New("data store does not support private notes and user data on invites")
+	ErrorLocationNotSupported         = errors.New("data store does not support locations")
 )
 
 // VAlidate makes sure the event object doesn't have conflicting values
@@ -50,34 +148,52 @@ func Validate(e Event) error {
 		return ErrorInvalidStatus
 	}
 
+	if !ValidPriority(e.Priority) {
+		return ErrorInvalidPriority
+	}
+
+	if e.Geo != nil && !e.Geo.Valid() {
+		return ErrorInvalidGeo
+	}
+
+	if !ValidVisibility(e.Visibility) {
+		return ErrorInvalidVisibility
+	}
+
 	return nil
 }
 
 // ValidateInvite makes sure the invite object doesn't have conflicting values
 func ValidateInvite(a Invite) error {
 	switch a.Status {
-	case InviteStatusPending, InviteStatusConfirmed, InviteStatusDeclined:
+	case InviteStatusPending, InviteStatusConfirmed, InviteStatusTentative, InviteStatusWaitlisted, InviteStatusDeclined:
 	default:
 		return ErrorInvalidInviteStatus
 	}
 
-	if a.Permission <= 0 {
+	return ValidatePermission(a.Permission)
+}
+
+// ValidatePermission makes sure the permission bitmask doesn't have flags without the
+// flags they depend on (like PermissionModify without PermissionInvite)
+func ValidatePermission(p Permission) error {
+	if p <= 0 {
 		return ErrorMissingInvitePermission
 	}
 
-	if !a.Permission.HasFlag(PermissionRead) && (a.Permission.HasFlag(PermissionDelete) || a.Permission.HasFlag(PermissionCancel) || a.Permission.HasFlag(PermissionInvite) || a.Permission.HasFlag(PermissionModify)) {
+	if !p.HasFlag(PermissionRead) && (p.HasFlag(PermissionDelete) || p.HasFlag(PermissionCancel) || p.HasFlag(PermissionInvite) || p.HasFlag(PermissionModify) || p.HasFlag(PermissionApprove)) {
 		return ErrorIncompatibleInvitePermission
 	}
 
-	if !a.Permission.HasFlag(PermissionInvite) && a.Permission.HasFlag(PermissionModify) {
+	if !p.HasFlag(PermissionInvite) && p.HasFlag(PermissionModify) {
 		return ErrorIncompatibleInvitePermission
 	}
 
-	if !a.Permission.HasFlag(PermissionModify) && (a.Permission.HasFlag(PermissionDelete) || a.Permission.HasFlag(PermissionCancel)) {
+	if !p.HasFlag(PermissionModify) && (p.HasFlag(PermissionDelete) || p.HasFlag(PermissionCancel)) {
 		return ErrorIncompatibleInvitePermission
 	}
 
-	if !a.Permission.HasFlag(PermissionCancel) && a.Permission.HasFlag(PermissionDelete) {
+	if !p.HasFlag(PermissionCancel) && p.HasFlag(PermissionDelete) {
 		return ErrorIncompatibleInvitePermission
 	}
 
@@ -87,13 +203,23 @@ func ValidateInvite(a Invite) error {
 // ValidStatus returns true if the status is one of the pre-defined statuses from this library
 func ValidStatus(s Status) bool {
 	switch s {
-	case StatusActive, StatusCanceled, StatusAbandoned, StatusRemoved:
+	case StatusActive, StatusCanceled, StatusAbandoned, StatusRemoved, StatusPendingApproval:
 		return true
 	default:
 		return false
 	}
 }
 
+// ValidVisibility returns true if the visibility is one of the pre-defined visibilities from this library
+func ValidVisibility(v Visibility) bool {
+	return v == VisibilityPrivate || v == VisibilityPublic
+}
+
+// ValidPriority returns true if the priority is between 0 and 9
+func ValidPriority(p Priority) bool {
+	return p >= PriorityUndefined && p <= PriorityLow
+}
+
 // ValidRepeat checks the event.Repeat if event.IsRepeating is true to see if there are invalid values within the repeat
 func ValidRepeat(e Event) error {
 	if e.IsRepeating {
@@ -124,6 +250,13 @@ func ValidRepeat(e Event) error {
 			}
 		}
 
+		if !ValidCalendarSystem(e.Repeat.CalendarSystem) {
+			return ErrorInvalidCalendarSystem
+		}
+		if e.Repeat.CalendarSystem != CalendarSystemGregorian && e.Repeat.RepeatType != RepeatTypeYearly {
+			return ErrorInvalidCalendarSystem
+		}
+
 		switch e.Repeat.RepeatType {
 		case RepeatTypeDaily:
 		case RepeatTypeWeekly:
@@ -132,9 +265,23 @@ func ValidRepeat(e Event) error {
 			}
 		case RepeatTypeMonthly:
 		case RepeatTypeYearly:
+		case RepeatTypeWeekdays:
+		case RepeatTypeCustom:
+			if e.Repeat.Next == nil {
+				return ErrorMissingNextOccurrence
+			}
 		default:
 			return ErrorInvalidRepeatType
 		}
+		if e.Repeat.BusinessDaysOnly && e.Repeat.RepeatType != RepeatTypeDaily && e.Repeat.RepeatType != RepeatTypeWeekdays {
+			return ErrorInvalidBusinessDaysOnly
+		}
+		if e.Repeat.Anniversary && (e.Repeat.RepeatType != RepeatTypeYearly || !e.IsAllDay) {
+			return ErrorInvalidAnniversary
+		}
+		if !ValidLeapDayPolicy(e.Repeat.LeapDay) {
+			return ErrorInvalidLeapDayPolicy
+		}
 	}
 	return nil
 }
@@ -142,11 +289,11 @@ func ValidRepeat(e Event) error {
 // ValidateTimeValues compares two HH:mm values to make sure they are
 // correctly formatted and start time is equal or before the end time
 func ValidateTimeValues(startTime, endTime string) error {
-	_, err := time.Parse(TimeFormat, startTime)
+	_, err := parseTime(startTime)
 	if err != nil {
 		return ErrorInvalidStartTime
 	}
-	_, err = time.Parse(TimeFormat, endTime)
+	_, err = parseTime(endTime)
 	if err != nil {
 		return ErrorInvalidEndTime
 	}
@@ -187,11 +334,11 @@ func ValidateDayTimeValues(startDay, startTime, endDay, endTime string, zone str
 		return ErrorAllDayCantHaveTimes
 	}
 	if !isAllDay {
-		_, err = time.Parse(TimeFormat, startTime)
+		_, err = parseTime(startTime)
 		if err != nil {
 			return ErrorInvalidStartTime
 		}
-		_, err = time.Parse(TimeFormat, endTime)
+		_, err = parseTime(endTime)
 		if err != nil {
 			return ErrorInvalidEndTime
 		}
@@ -212,3 +359,33 @@ func ValidateDayTimeValues(startDay, startTime, endDay, endTime string, zone str
 
 	return nil
 }
+
+// ValidateAutoResponsePolicy makes sure the auto response policy has the values
+// it needs for the responses that are turned on
+func ValidateAutoResponsePolicy(p AutoResponsePolicy) error {
+	if p.DeclineOutsideWorkingHours && p.WorkingHours == nil {
+		return ErrorInvalidWorkingHours
+	}
+	if p.WorkingHours != nil {
+		return ValidateWorkingHours(*p.WorkingHours)
+	}
+	return nil
+}
+
+// ValidateWorkingHours makes sure the working hours have at least one day of
+// the week, a valid zone, and a start time before the end time
+func ValidateWorkingHours(w WorkingHours) error {
+	if w.DayOfWeek <= 0 {
+		return ErrorInvalidWorkingHours
+	}
+	if err := ValidateTimeValues(w.StartTime, w.EndTime); err != nil {
+		return err
+	}
+	if w.StartTime == w.EndTime {
+		return ErrorInvalidWorkingHours
+	}
+	if _, err := time.LoadLocation(w.Zone); err != nil {
+		return ErrorInvalidZone
+	}
+	return nil
+}
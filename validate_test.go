@@ -166,6 +166,18 @@ func TestValidate(t *testing.T) {
 				Repeat:      &Repeat{RepeatType: -1, DayOfWeek: 0, RepeatStopDate: _t(time.Date(2008, time.January, 20, 0, 0, 0, 0, time.UTC))},
 			},
 			err: ErrorInvalidRepeatType,
+		}, {
+			desc: "calendar system on non-yearly repeat",
+			in: Event{
+				StartDay:    "2008-01-01",
+				EndDay:      "2008-01-01",
+				StartTime:   "13:00",
+				EndTime:     "14:00",
+				Zone:        "America/Denver",
+				IsRepeating: true,
+				Repeat:      &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2, CalendarSystem: CalendarSystemHebrew},
+			},
+			err: ErrorInvalidCalendarSystem,
 		}, {
 			desc: "success",
 			in: Event{
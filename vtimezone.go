@@ -0,0 +1,170 @@
+package cali
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Kenoshen/cali/ical"
+)
+
+// iCalLocalFormat is the ical format of a local date-time, which is in the zone of its TZID
+const iCalLocalFormat = "20060102T150405"
+
+// iCalDateTime gets the ical property of the wall clock time in the zone, which is a UTC time for
+// UTC (or an unknown zone) and a local time with the TZID of the zone otherwise
+func iCalDateTime(name string, t time.Time, zone string) string {
+	if zone == "" || zone == "UTC" {
+		return fmt.Sprintf("%s:%s", name, t.Format(iCalDateTimeFormat))
+	}
+	return fmt.Sprintf("%s;TZID=%s:%s", name, zone, t.Format(iCalLocalFormat))
+}
+
+// tzTransition is a change of the UTC offset of a zone
+type tzTransition struct {
+	at       time.Time
+	from, to int
+	name     string
+	dst      bool
+}
+
+// tzRule is a STANDARD or DAYLIGHT component of a VTIMEZONE, which is the transitions that are
+// on the same weekday of the month at the same local time from the first year to the last year
+type tzRule struct {
+	first     tzTransition
+	last      tzTransition
+	key       string
+	firstYear int
+	lastYear  int
+}
+
+// VTimezone gets the VTIMEZONE component of the zone with the daylight saving time rules of the
+// zone from the first year to the last year. A rule that is still used in the last year doesn't
+// have an end, and a zone without any transitions in the years has a single STANDARD component.
+func VTimezone(zone string, firstYear, lastYear int) (string, error) {
+	lines, err := vTimezoneLines(zone, firstYear, lastYear)
+	if err != nil {
+		return "", err
+	}
+	return ical.Join(lines), nil
+}
+
+// vTimezoneLines gets the content lines of VTimezone
+func vTimezoneLines(zone string, firstYear, lastYear int) ([]string, error) {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, ErrorInvalidZone
+	}
+	lines := []string{"BEGIN:VTIMEZONE", ical.Property("TZID", zone)}
+
+	var rules []*tzRule
+	for year := firstYear; year <= lastYear; year++ {
+		for _, t := range yearTransitions(loc, year) {
+			key := t.ruleKey()
+			var rule *tzRule
+			for _, r := range rules {
+				if r.key == key && r.lastYear == year-1 {
+					rule = r
+				}
+			}
+			if rule == nil {
+				rule = &tzRule{first: t, key: key, firstYear: year}
+				rules = append(rules, rule)
+			}
+			rule.last, rule.lastYear = t, year
+		}
+	}
+	if len(rules) == 0 {
+		name, offset := time.Date(firstYear, time.January, 1, 0, 0, 0, 0, loc).Zone()
+		lines = append(lines,
+			"BEGIN:STANDARD",
+			"DTSTART:19700101T000000",
+			"TZOFFSETFROM:"+iCalOffset(offset),
+			"TZOFFSETTO:"+iCalOffset(offset),
+			"TZNAME:"+name,
+			"END:STANDARD",
+		)
+	}
+	for _, r := range rules {
+		component := "STANDARD"
+		if r.first.dst {
+			component = "DAYLIGHT"
+		}
+		lines = append(lines,
+			"BEGIN:"+component,
+			"DTSTART:"+r.first.local().Format(iCalLocalFormat),
+			"TZOFFSETFROM:"+iCalOffset(r.first.from),
+			"TZOFFSETTO:"+iCalOffset(r.first.to),
+			"TZNAME:"+r.first.name,
+		)
+		// a rule that stopped before the last year ends with its last transition
+		rrule := "RRULE:FREQ=YEARLY;BYMONTH=" + fmt.Sprint(int(r.first.local().Month())) + ";BYDAY=" + r.first.byDay()
+		if r.lastYear == lastYear {
+			lines = append(lines, rrule)
+		} else if r.lastYear > r.firstYear {
+			lines = append(lines, rrule+";UNTIL="+r.last.at.UTC().Format(iCalDateTimeFormat))
+		}
+		lines = append(lines, "END:"+component)
+	}
+	return append(lines, "END:VTIMEZONE"), nil
+}
+
+// yearTransitions finds the changes of the UTC offset of the zone in the year
+func yearTransitions(loc *time.Location, year int) []tzTransition {
+	var result []tzTransition
+	t := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := t.AddDate(1, 0, 0)
+	_, offset := t.In(loc).Zone()
+	for t.Before(end) {
+		next := t.Add(24 * time.Hour)
+		if _, nextOffset := next.In(loc).Zone(); nextOffset != offset {
+			// the transition is the first second with the new offset
+			low, high := t, next
+			for high.Sub(low) > time.Second {
+				mid := low.Add(high.Sub(low) / 2)
+				if _, o := mid.In(loc).Zone(); o == offset {
+					low = mid
+				} else {
+					high = mid
+				}
+			}
+			name, to := high.In(loc).Zone()
+			result = append(result, tzTransition{at: high, from: offset, to: to, name: name, dst: high.In(loc).IsDST()})
+			offset = to
+		}
+		t = next
+	}
+	return result
+}
+
+// local is the wall clock time of the transition before the offset changes
+func (t tzTransition) local() time.Time {
+	return t.at.Add(time.Duration(t.from) * time.Second)
+}
+
+// byDay is the BYDAY of the transition, like 2SU for the second Sunday of the month or -1SU for the last
+func (t tzTransition) byDay() string {
+	local := t.local()
+	days := []string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+	daysInMonth := time.Date(local.Year(), local.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if local.Day()+7 > daysInMonth {
+		return "-1" + days[local.Weekday()]
+	}
+	return fmt.Sprint((local.Day()-1)/7+1) + days[local.Weekday()]
+}
+
+// ruleKey is the same for transitions of the same rule in different years
+func (t tzTransition) ruleKey() string {
+	return fmt.Sprintf("%d %s %s %d %d %s %v", t.local().Month(), t.byDay(), t.local().Format("150405"), t.from, t.to, t.name, t.dst)
+}
+
+// iCalOffset formats the UTC offset in seconds like -0700
+func iCalOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	if seconds := offset % 60; seconds != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, offset/3600, offset%3600/60, seconds)
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+}
@@ -0,0 +1,126 @@
+package cali
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVTimezone(t *testing.T) {
+	testCases := []struct {
+		name      string
+		zone      string
+		firstYear int
+		lastYear  int
+		out       []string
+		err       error
+	}{
+		{
+			name: "rules that changed", zone: den, firstYear: 2005, lastYear: 2009,
+			out: []string{
+				"BEGIN:VTIMEZONE",
+				"TZID:America/Denver",
+				"BEGIN:DAYLIGHT",
+				"DTSTART:20050403T020000",
+				"TZOFFSETFROM:-0700",
+				"TZOFFSETTO:-0600",
+				"TZNAME:MDT",
+				"RRULE:FREQ=YEARLY;BYMONTH=4;BYDAY=1SU;UNTIL=20060402T090000Z",
+				"END:DAYLIGHT",
+				"BEGIN:STANDARD",
+				"DTSTART:20051030T020000",
+				"TZOFFSETFROM:-0600",
+				"TZOFFSETTO:-0700",
+				"TZNAME:MST",
+				"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU;UNTIL=20061029T080000Z",
+				"END:STANDARD",
+				"BEGIN:DAYLIGHT",
+				"DTSTART:20070311T020000",
+				"TZOFFSETFROM:-0700",
+				"TZOFFSETTO:-0600",
+				"TZNAME:MDT",
+				"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=2SU",
+				"END:DAYLIGHT",
+				"BEGIN:STANDARD",
+				"DTSTART:20071104T020000",
+				"TZOFFSETFROM:-0600",
+				"TZOFFSETTO:-0700",
+				"TZNAME:MST",
+				"RRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=1SU",
+				"END:STANDARD",
+				"END:VTIMEZONE",
+			},
+		},
+		{
+			name: "last sunday of the month", zone: "Europe/London", firstYear: 2008, lastYear: 2008,
+			out: []string{
+				"BEGIN:VTIMEZONE",
+				"TZID:Europe/London",
+				"BEGIN:DAYLIGHT",
+				"DTSTART:20080330T010000",
+				"TZOFFSETFROM:+0000",
+				"TZOFFSETTO:+0100",
+				"TZNAME:BST",
+				"RRULE:FREQ=YEARLY;BYMONTH=3;BYDAY=-1SU",
+				"END:DAYLIGHT",
+				"BEGIN:STANDARD",
+				"DTSTART:20081026T020000",
+				"TZOFFSETFROM:+0100",
+				"TZOFFSETTO:+0000",
+				"TZNAME:GMT",
+				"RRULE:FREQ=YEARLY;BYMONTH=10;BYDAY=-1SU",
+				"END:STANDARD",
+				"END:VTIMEZONE",
+			},
+		},
+		{
+			name: "no daylight saving time", zone: "Asia/Tokyo", firstYear: 2008, lastYear: 2010,
+			out: []string{
+				"BEGIN:VTIMEZONE",
+				"TZID:Asia/Tokyo",
+				"BEGIN:STANDARD",
+				"DTSTART:19700101T000000",
+				"TZOFFSETFROM:+0900",
+				"TZOFFSETTO:+0900",
+				"TZNAME:JST",
+				"END:STANDARD",
+				"END:VTIMEZONE",
+			},
+		},
+		{name: "invalid zone", zone: "Nowhere/Else", firstYear: 2008, lastYear: 2008, err: ErrorInvalidZone},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			t.Log(tc.name)
+			out, err := VTimezone(tc.zone, tc.firstYear, tc.lastYear)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, strings.Join(tc.out, "\r\n"), out)
+		})
+	}
+}
+
+func TestMarshallCalendarToICal(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	_, _, err := c.Create(Event{OwnerId: 1, Title: "Lunch", Zone: den, StartDay: "2008-07-01", StartTime: "12:00", EndDay: "2008-07-01", EndTime: "13:00"})
+	require.NoError(t, err)
+	_, _, err = c.Create(Event{OwnerId: 1, Title: "Standup", Zone: "UTC", IsRepeating: true, StartDay: "2008-01-01", StartTime: "09:00", EndDay: "2008-01-01", EndTime: "09:15", Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 2}})
+	require.NoError(t, err)
+
+	ical, err := c.ExportICal(Query{UserIds: []int64{1}})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ical, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ical, "END:VEVENT\r\nEND:VCALENDAR"))
+	assert.Equal(t, 1, strings.Count(ical, "BEGIN:VTIMEZONE"))
+	assert.Contains(t, ical, "TZID:America/Denver\r\n")
+	assert.Contains(t, ical, "DTSTAMP:20080701T180000Z\r\nDTSTART;TZID=America/Denver:20080701T120000\r\nDTEND;TZID=America/Denver:20080701T130000\r\n")
+	assert.Contains(t, ical, "DTSTART:20080101T090000Z\r\nDTEND:20080101T091500Z\r\nRDATE:20080102T090000Z\r\n")
+	assert.Equal(t, 2, strings.Count(ical, "BEGIN:VEVENT"))
+}
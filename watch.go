@@ -0,0 +1,170 @@
+package cali
+
+import (
+	"sync"
+	"time"
+)
+
+// ChangeType is the kind of modification that a Change describes
+type ChangeType int64
+
+const (
+	// ChangeTypeCreated is for a new event
+	ChangeTypeCreated ChangeType = 0
+	// ChangeTypeUpdated is for any change to the fields of an event, including its status
+	ChangeTypeUpdated ChangeType = 1
+	// ChangeTypeInvite is for a new invitation or a change to the status or permission of an invitation
+	ChangeTypeInvite ChangeType = 2
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeTypeCreated:
+		return "created"
+	case ChangeTypeUpdated:
+		return "updated"
+	case ChangeTypeInvite:
+		return "invite"
+	}
+	return "unknown"
+}
+
+// Change is a single modification made through the calendar that is sent to every watcher
+type Change struct {
+	// Type is the kind of modification
+	Type ChangeType `json:"type"`
+	// EventId is the event that was modified
+	EventId int64 `json:"eventId"`
+	// UserId is the invited user for ChangeTypeInvite changes
+	UserId *int64 `json:"userId"`
+	// Event is a copy of the event after the change was made
+	Event *Event `json:"event"`
+	// Actor is who made the change, or nil if the calendar wasn't scoped to an actor (see AsAdminOnBehalfOf)
+	Actor *Actor `json:"actor"`
+	// Time is when the change was made
+	Time time.Time `json:"time"`
+}
+
+// changeFeed fans changes out to all of the current watchers
+type changeFeed struct {
+	mu       sync.Mutex
+	watchers map[int]chan Change
+	nextId   int
+}
+
+// Watch subscribes to every change made through this calendar. Changes are dropped
+// for a watcher whose channel is full, so the buffer should be big enough for the
+// watcher to keep up. The returned function stops the watch and closes the channel.
+func (c *Calendar) Watch(buffer int) (<-chan Change, func()) {
+	ch := make(chan Change, buffer)
+	c.feed.mu.Lock()
+	defer c.feed.mu.Unlock()
+	if c.feed.watchers == nil {
+		c.feed.watchers = map[int]chan Change{}
+	}
+	id := c.feed.nextId
+	c.feed.nextId++
+	c.feed.watchers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			c.feed.mu.Lock()
+			defer c.feed.mu.Unlock()
+			delete(c.feed.watchers, id)
+			close(ch)
+		})
+	}
+}
+
+// publish sends the change for the event to all of the watchers, removes the cached queries
+// that it changes (see WithQueryCache), and writes it to the audit log (see WithAuditLog) and
+// the outbox (see WithOutbox). An error is only returned if the audit entry or the outbox
+// record couldn't be written. In an outbox transaction (see outboxTx), the change is only sent
+// once the transaction is committed.
+func (c *Calendar) publish(changeType ChangeType, eventId int64, userId *int64) error {
+	c.feed.mu.Lock()
+	watched := len(c.feed.watchers) > 0
+	c.feed.mu.Unlock()
+	if !watched && !c.outbox && !c.auditLog && c.queryCache == nil {
+		return nil
+	}
+	change := Change{
+		Type:    changeType,
+		EventId: eventId,
+		UserId:  userId,
+		Actor:   c.actor,
+		Time:    time.Now(),
+	}
+	if e, err := c.dataStore.Get(eventId); err == nil && e != nil {
+		copied := *e
+		change.Event = &copied
+	}
+	if c.auditLog {
+		if err := c.addAuditEntry(change); err != nil {
+			return err
+		}
+	}
+	if c.outbox {
+		if err := c.addToOutbox(OutboxKindChange, change); err != nil {
+			return err
+		}
+	}
+	if c.outboxChanges != nil {
+		*c.outboxChanges = append(*c.outboxChanges, change)
+		return nil
+	}
+	c.sendChange(change)
+	return nil
+}
+
+// sendChange removes the cached queries that the change changes and sends it to the watchers
+func (c *Calendar) sendChange(change Change) {
+	if c.queryCache != nil {
+		c.queryCache.invalidate(change)
+	}
+	c.feed.mu.Lock()
+	defer c.feed.mu.Unlock()
+	for _, ch := range c.feed.watchers {
+		select {
+		case ch <- change:
+		default:
+		}
+	}
+}
+
+// editEvents authorizes the operation, checks the locks, applies the edit, and publishes a ChangeTypeUpdated
+// change for every edited event
+func (c *Calendar) editEvents(op Operation, editType RepeatEditType, eventId int64, f func(eventId int64) error) error {
+	if err := c.authorize(op, eventId, nil); err != nil {
+		return err
+	}
+	if err := c.checkLocks(editType, eventId); err != nil {
+		return err
+	}
+	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
+		if err := f(eventId); err != nil {
+			return err
+		}
+		return c.publish(ChangeTypeUpdated, eventId, nil)
+	})
+}
+
+// editInvites authorizes the operation, applies the edit, and publishes a ChangeTypeInvite
+// change for the user on every edited event
+func (c *Calendar) editInvites(op Operation, editType RepeatEditType, eventId int64, userId int64, f func(eventId int64) error) error {
+	if err := c.authorize(op, eventId, &userId); err != nil {
+		return err
+	}
+	return c.applyInviteEdit(editType, eventId, userId, f)
+}
+
+// applyInviteEdit is editInvites without the authorization
+func (c *Calendar) applyInviteEdit(editType RepeatEditType, eventId int64, userId int64, f func(eventId int64) error) error {
+	return c.applyEditBasedOnRepeatEditType(editType, eventId, func(eventId int64) error {
+		if err := f(eventId); err != nil {
+			return err
+		}
+		return c.publish(ChangeTypeInvite, eventId, &userId)
+	})
+}
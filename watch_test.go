@@ -0,0 +1,68 @@
+package cali
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	changes, stop := c.Watch(10)
+
+	a, _, err := c.Create(Event{OwnerId: 1, StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateTitle(a.Id, "title", RepeatEditTypeThis))
+	require.NoError(t, c.InviteUser(a.Id, 2, PermissionInvitee, RepeatEditTypeThis))
+
+	change := <-changes
+	assert.Equal(t, ChangeTypeCreated, change.Type)
+	assert.Equal(t, a.Id, change.EventId)
+
+	change = <-changes
+	assert.Equal(t, ChangeTypeUpdated, change.Type)
+	assert.Equal(t, "title", change.Event.Title)
+
+	change = <-changes
+	assert.Equal(t, ChangeTypeInvite, change.Type)
+	require.NotNil(t, change.UserId)
+	assert.Equal(t, int64(2), *change.UserId)
+
+	// the published event is a copy
+	require.NoError(t, c.UpdateTitle(a.Id, "other", RepeatEditTypeThis))
+	assert.Equal(t, "title", change.Event.Title)
+	<-changes
+
+	stop()
+	stop()
+	_, ok := <-changes
+	assert.False(t, ok)
+	require.NoError(t, c.UpdateTitle(a.Id, "after", RepeatEditTypeThis))
+}
+
+func TestWatchRepeating(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	changes, stop := c.Watch(10)
+	defer stop()
+
+	a, count, err := c.Create(Event{
+		StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC",
+		IsRepeating: true, Repeat: &Repeat{RepeatType: RepeatTypeDaily, RepeatOccurrences: 3},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Cancel(a.Id, RepeatEditTypeAll))
+	assert.Len(t, changes, int(count)*2)
+}
+
+func TestWatchFullBuffer(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{})
+	changes, stop := c.Watch(1)
+	defer stop()
+
+	a, _, err := c.Create(Event{StartDay: "2008-01-01", EndDay: "2008-01-01", IsAllDay: true, Zone: "UTC"})
+	require.NoError(t, err)
+	require.NoError(t, c.UpdateTitle(a.Id, "title", RepeatEditTypeThis))
+	assert.Len(t, changes, 1)
+	assert.Equal(t, ChangeTypeCreated, (<-changes).Type)
+}
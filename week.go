@@ -0,0 +1,99 @@
+package cali
+
+import (
+	"strings"
+	"time"
+)
+
+// WithWeekStart sets the first day of the week of the calendar, which is Sunday unless it is
+// set. It is used by the week views (see Week and ThisWeek) and by the formatters of the
+// calendar (see NewFormatter) to list the days of weekly repeats. Days that aren't a
+// time.Weekday are ignored.
+func WithWeekStart(day time.Weekday) CalendarOption {
+	return func(c *Calendar) {
+		if day >= time.Sunday && day <= time.Saturday {
+			c.weekStart = day
+		}
+	}
+}
+
+// WeekStart gets the first day of the week of the calendar
+func (c *Calendar) WeekStart() time.Weekday {
+	return c.weekStart
+}
+
+// Weekdays gets the days of the week in the order of the calendar, like for the columns of
+// a week view
+func (c *Calendar) Weekdays() [7]time.Weekday {
+	return weekdays(c.weekStart)
+}
+
+// Week gets the week that t is in, from midnight of the first day of the week in the
+// location of t until midnight of the first day of the next week
+func (c *Calendar) Week(t time.Time) TimeWindow {
+	start := StartOfWeek(t, c.weekStart)
+	return TimeWindow{Start: start, End: start.AddDate(0, 0, 7)}
+}
+
+// QueryWeek queries the events of the week that t is in (see Week). The Start and End of the
+// query are replaced, and it has an exclusive EndBound unless it has a bound already, so an
+// event that starts at midnight of the next week isn't in it.
+func (c *Calendar) QueryWeek(t time.Time, q Query) ([]*Event, error) {
+	week := c.Week(t)
+	q.Start, q.End = &week.Start, &week.End
+	if q.EndBound == RangeBoundDefault {
+		q.EndBound = RangeBoundExclusive
+	}
+	return c.Query(q)
+}
+
+// ThisWeek queries the events of the current week in the location (see QueryWeek)
+func (c *Calendar) ThisWeek(loc *time.Location, q Query) ([]*Event, error) {
+	return c.QueryWeek(time.Now().In(loc), q)
+}
+
+// NewFormatter creates a formatter (see NewFormatter) that uses the week start of the calendar
+func (c *Calendar) NewFormatter(localeTag string, zone string) (*Formatter, error) {
+	f, err := NewFormatter(localeTag, zone)
+	if err != nil {
+		return nil, err
+	}
+	f.WeekStart = c.weekStart
+	return f, nil
+}
+
+// StartOfWeek gets midnight of the first day of the week that t is in, in the location of t
+func StartOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	back := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-back, 0, 0, 0, 0, t.Location())
+}
+
+// RepeatDays lists the abbreviated days that a weekly repeat lands on in the order of the
+// WeekStart of the formatter ("Mon, Wed, Sun" for a Monday start), or "" if the repeat isn't
+// weekly. A RepeatTypeWeekdays repeat, or a daily repeat of business days, lists Monday
+// through Friday.
+func (f Formatter) RepeatDays(r Repeat) string {
+	days := r.DayOfWeek
+	switch {
+	case r.RepeatType == RepeatTypeWeekdays || (r.RepeatType == RepeatTypeDaily && r.BusinessDaysOnly):
+		days = DayOfWeekWeekdays
+	case r.RepeatType != RepeatTypeWeekly:
+		return ""
+	}
+	var names []string
+	for _, day := range weekdays(f.WeekStart) {
+		if days.HasFlag(dayOfWeekFromWeekday(day)) {
+			names = append(names, f.Locale.Weekdays[day])
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// weekdays gets the days of the week starting with the week start
+func weekdays(weekStart time.Weekday) [7]time.Weekday {
+	var days [7]time.Weekday
+	for i := range days {
+		days[i] = (weekStart + time.Weekday(i)) % 7
+	}
+	return days
+}
@@ -0,0 +1,71 @@
+package cali
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartOfWeek(t *testing.T) {
+	// Wednesday, January 2 2008
+	wednesday := time.Date(2008, 1, 2, 15, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2007, 12, 30, 0, 0, 0, 0, time.UTC), StartOfWeek(wednesday, time.Sunday))
+	assert.Equal(t, time.Date(2007, 12, 31, 0, 0, 0, 0, time.UTC), StartOfWeek(wednesday, time.Monday))
+	assert.Equal(t, time.Date(2008, 1, 2, 0, 0, 0, 0, time.UTC), StartOfWeek(wednesday, time.Wednesday))
+
+	// a Sunday is the end of a Monday week
+	sunday := time.Date(2008, 1, 6, 9, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2008, 1, 6, 0, 0, 0, 0, time.UTC), StartOfWeek(sunday, time.Sunday))
+	assert.Equal(t, time.Date(2007, 12, 31, 0, 0, 0, 0, time.UTC), StartOfWeek(sunday, time.Monday))
+}
+
+func TestWeekStart(t *testing.T) {
+	store := &InMemoryDataStore{}
+	for _, e := range []Event{
+		{Title: "Sunday", Zone: "UTC", StartDay: "2008-01-06", StartTime: "10:00", EndDay: "2008-01-06", EndTime: "11:00"},
+		{Title: "Monday", Zone: "UTC", StartDay: "2008-01-07", StartTime: "00:00", EndDay: "2008-01-07", EndTime: "01:00"},
+		{Title: "Tuesday", Zone: "UTC", StartDay: "2008-01-08", StartTime: "10:00", EndDay: "2008-01-08", EndTime: "11:00"},
+	} {
+		_, _, err := NewCalendar(store).Create(e)
+		require.NoError(t, err)
+	}
+	titles := func(events []*Event, err error) []string {
+		require.NoError(t, err)
+		var result []string
+		for _, e := range events {
+			result = append(result, e.Title)
+		}
+		return result
+	}
+	// Sunday, January 6 2008
+	sunday := time.Date(2008, 1, 6, 12, 0, 0, 0, time.UTC)
+
+	sundayStart := NewCalendar(store)
+	assert.Equal(t, time.Sunday, sundayStart.WeekStart())
+	assert.Equal(t, TimeWindow{Start: time.Date(2008, 1, 6, 0, 0, 0, 0, time.UTC), End: time.Date(2008, 1, 13, 0, 0, 0, 0, time.UTC)}, sundayStart.Week(sunday))
+	assert.Equal(t, []string{"Sunday", "Monday", "Tuesday"}, titles(sundayStart.QueryWeek(sunday, Query{})))
+
+	mondayStart := NewCalendar(store, WithWeekStart(time.Monday))
+	assert.Equal(t, time.Monday, mondayStart.WeekStart())
+	assert.Equal(t, [7]time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}, mondayStart.Weekdays())
+	assert.Equal(t, TimeWindow{Start: time.Date(2007, 12, 31, 0, 0, 0, 0, time.UTC), End: time.Date(2008, 1, 7, 0, 0, 0, 0, time.UTC)}, mondayStart.Week(sunday))
+	assert.Equal(t, []string{"Sunday"}, titles(mondayStart.QueryWeek(sunday, Query{})), "the event at midnight of the next week isn't in the week")
+
+	assert.Equal(t, time.Sunday, NewCalendar(store, WithWeekStart(time.Weekday(9))).WeekStart())
+}
+
+func TestRepeatDays(t *testing.T) {
+	c := NewCalendar(&InMemoryDataStore{}, WithWeekStart(time.Monday))
+	f, err := c.NewFormatter("en-GB", "UTC")
+	require.NoError(t, err)
+	weekly := Repeat{RepeatType: RepeatTypeWeekly, DayOfWeek: DayOfWeekSunday | DayOfWeekMonday | DayOfWeekWednesday}
+	assert.Equal(t, "Mon, Wed, Sun", f.RepeatDays(weekly))
+	assert.Equal(t, "Mon, Tue, Wed, Thu, Fri", f.RepeatDays(Repeat{RepeatType: RepeatTypeWeekdays}))
+	assert.Equal(t, "", f.RepeatDays(Repeat{RepeatType: RepeatTypeMonthly}))
+
+	us, err := NewFormatter("en-US", "UTC")
+	require.NoError(t, err)
+	assert.Equal(t, "Sun, Mon, Wed", us.RepeatDays(weekly))
+}